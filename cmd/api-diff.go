@@ -0,0 +1,237 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/OpenCHAMI/ochami/internal/color"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/bss"
+	"github.com/OpenCHAMI/ochami/pkg/client/ci"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/OpenCHAMI/ochami/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+// openAPIClient is implemented by the clients of services whose OpenAPI spec
+// can be fetched.
+type openAPIClient interface {
+	GetOpenAPI() (client.HTTPEnvelope, error)
+	GetData(endpoint, query string, headers *client.HTTPHeaders) (client.HTTPEnvelope, error)
+}
+
+// apiServices are the services that 'api diff' supports.
+var apiServices = []string{"bss", "cloud-init", "smd"}
+
+// apiDiffCmd represents the api-diff command
+var apiDiffCmd = &cobra.Command{
+	Use:       "diff [--spec-path <path> | --file <file>] [--exit-code] (bss|cloud-init|smd)",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: apiServices,
+	Short:     "Compare a service's live OpenAPI spec against what ochami knows",
+	Long: `Compare the OpenAPI (or Swagger 2.0) spec served by a service against the
+endpoints and schemas that this version of ochami was built to use. This is
+useful for spotting drift after a service is upgraded. The following are
+listed:
+
+  - endpoints in the spec that ochami does not use
+  - endpoints ochami uses that are missing from the spec
+  - fields of schemas in the spec that ochami does not know about
+  - schemas ochami knows about that are missing from the spec
+
+By default, the spec is fetched from openapi.json under the service's base
+path (/hsm/v2 for SMD, /boot/v1 for BSS, and /cloud-init for cloud-init).
+Services do not agree on where, or whether, they serve their spec, so pass
+--spec-path to fetch it from a different path relative to the base path, or
+--file to read it from a file instead (- for standard input).
+
+The differences are printed as text unless --output-format is passed, in
+which case they are printed in that format. If --exit-code is passed, the
+exit status is 2 if there are differences.`,
+	Example: `  ochami api diff smd
+  ochami api diff bss --spec-path /openapi.yaml
+  ochami api diff cloud-init --file cloud-init-openapi.json
+  ochami api diff smd -F yaml --exit-code`,
+	Run: func(cmd *cobra.Command, args []string) {
+		service := args[0]
+		var known openapi.Known
+		switch service {
+		case "bss":
+			known = bss.KnownAPI
+		case "cloud-init":
+			known = ci.KnownAPI
+		case "smd":
+			known = smd.KnownAPI
+		default:
+			log.Logger.Error().Msgf("unknown service %q, must be one of %v", service, apiServices)
+			os.Exit(1)
+		}
+
+		var specBytes []byte
+		if cmd.Flag("file").Changed {
+			var err error
+			specFile := cmd.Flag("file").Value.String()
+			if specFile == "-" {
+				specBytes, err = io.ReadAll(os.Stdin)
+			} else {
+				specBytes, err = os.ReadFile(specFile)
+			}
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to read spec file")
+				os.Exit(1)
+			}
+		} else {
+			specBytes = fetchOpenAPI(cmd, service)
+		}
+
+		report, err := openapi.Diff(specBytes, known)
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to compare %s spec", service)
+			os.Exit(1)
+		}
+
+		// Print output
+		if cmd.Flag("output-format").Changed {
+			outFmt, err := cmd.Flags().GetString("output-format")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+				os.Exit(1)
+			}
+			reportBytes, err := json.Marshal(report)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to marshal API diff")
+				os.Exit(1)
+			}
+			if outBytes, err := client.FormatBody(reportBytes, outFmt); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to format output")
+				os.Exit(1)
+			} else {
+				fmt.Print(string(outBytes))
+			}
+		} else {
+			writeAPIDiff(os.Stdout, report)
+		}
+
+		if cmd.Flag("exit-code").Changed && !report.Empty() {
+			os.Exit(2)
+		}
+	},
+}
+
+// fetchOpenAPI fetches the OpenAPI spec of service from the path passed with
+// --spec-path, or from the default path of service if it was not passed, and
+// returns it. If fetching fails, the error is logged and the program exits.
+func fetchOpenAPI(cmd *cobra.Command, service string) []byte {
+	// Without a base URI, we cannot do anything
+	baseURI, err := getBaseURI(cmd)
+	if err != nil {
+		log.Logger.Error().Err(err).Msgf("failed to get base URI for %s", service)
+		os.Exit(1)
+	}
+
+	var c openAPIClient
+	switch service {
+	case "bss":
+		bssClient, err := bss.NewClient(baseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new BSS client")
+			os.Exit(1)
+		}
+		configureClient(bssClient.OchamiClient)
+		c = bssClient
+	case "cloud-init":
+		ciClient, err := ci.NewClient(baseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new cloud-init client")
+			os.Exit(1)
+		}
+		configureClient(ciClient.OchamiClient)
+		c = ciClient
+	case "smd":
+		smdClient, err := smd.NewClient(baseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+		configureClient(smdClient.OchamiClient)
+		c = smdClient
+	}
+
+	var henv client.HTTPEnvelope
+	if cmd.Flag("spec-path").Changed {
+		henv, err = c.GetData(cmd.Flag("spec-path").Value.String(), "", nil)
+	} else {
+		henv, err = c.GetOpenAPI()
+	}
+	if err != nil {
+		if errors.Is(err, client.UnsuccessfulHTTPError) {
+			log.Logger.Error().Err(err).Msgf("%s OpenAPI spec request yielded unsuccessful HTTP response (try --spec-path or --file)", service)
+		} else {
+			log.Logger.Error().Err(err).Msgf("failed to get %s OpenAPI spec", service)
+		}
+		os.Exit(1)
+	}
+
+	return henv.Body
+}
+
+// writeAPIDiff writes report to w as text, one section per kind of difference,
+// colored if color.Stdout is enabled. Sections without differences are
+// omitted.
+func writeAPIDiff(w io.Writer, report openapi.Report) {
+	if report.Empty() {
+		fmt.Fprintln(w, "No differences found.")
+		return
+	}
+	c := color.Stdout
+	if len(report.UnknownEndpoints) > 0 {
+		fmt.Fprintln(w, c.Bold("Endpoints not known to ochami:"))
+		for _, e := range report.UnknownEndpoints {
+			fmt.Fprintln(w, c.Green(fmt.Sprintf("  + %s", e)))
+		}
+	}
+	if len(report.MissingEndpoints) > 0 {
+		fmt.Fprintln(w, c.Bold("Endpoints used by ochami but missing from spec:"))
+		for _, e := range report.MissingEndpoints {
+			fmt.Fprintln(w, c.Red(fmt.Sprintf("  - %s", e)))
+		}
+	}
+	if len(report.UnknownFields) > 0 {
+		fmt.Fprintln(w, c.Bold("Fields not known to ochami:"))
+		var names []string
+		for name := range report.UnknownFields {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			for _, f := range report.UnknownFields[name] {
+				fmt.Fprintln(w, c.Green("  + "+name+"."+f))
+			}
+		}
+	}
+	if len(report.MissingSchemas) > 0 {
+		fmt.Fprintln(w, c.Bold("Schemas known to ochami but missing from spec:"))
+		for _, s := range report.MissingSchemas {
+			fmt.Fprintln(w, c.Red("  - "+s))
+		}
+	}
+}
+
+func init() {
+	apiDiffCmd.Flags().String("spec-path", "", "path relative to the service's base path to fetch the spec from")
+	apiDiffCmd.Flags().String("file", "", "read spec from file instead of fetching it (- for standard input)")
+	apiDiffCmd.Flags().Bool("exit-code", false, "exit with status 2 if there are differences")
+	apiDiffCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	apiDiffCmd.MarkFlagsMutuallyExclusive("spec-path", "file")
+
+	setCommandServices(apiDiffCmd, "bss", "cloud-init", "smd")
+
+	apiCmd.AddCommand(apiDiffCmd)
+}
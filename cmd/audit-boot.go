@@ -0,0 +1,204 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/OpenCHAMI/bss/pkg/bssTypes"
+	"github.com/OpenCHAMI/cloud-init/pkg/citypes"
+	"github.com/OpenCHAMI/ochami/internal/color"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/audit"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/bss"
+	"github.com/OpenCHAMI/ochami/pkg/client/ci"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// auditBootCmd represents the audit-boot command
+var auditBootCmd = &cobra.Command{
+	Use:   "boot [--cloud-init | --no-cloud-init] [--exit-code]",
+	Args:  cobra.NoArgs,
+	Short: "Check that every node in SMD can boot from BSS",
+	Long: `Check that every node in SMD can boot from BSS. Components, ethernet
+interfaces, and boot parameters are fetched from SMD and BSS and the
+following are reported:
+
+  - enabled Node components without boot parameters of their own, by xname,
+    MAC address, or NID (an error, or a warning if BSS would fall back to
+    the boot parameters of the node's role or the Default boot parameters)
+  - MAC addresses in boot parameters that are not ethernet interfaces in SMD
+    (a warning)
+  - nodes whose kernel parameters use the cloud-init nocloud datasource
+    (ds=nocloud...) but that have no cloud-init config named after their
+    xname or one of their MAC addresses (an error)
+
+Cloud-init configs are only fetched if any boot parameters use cloud-init.
+Pass --cloud-init to expect every node to have a cloud-init config, or
+--no-cloud-init to skip checking cloud-init altogether. Nothing is modified.
+
+The findings are printed as a table unless --output-format is passed, in
+which case they are printed in that format. If --exit-code is passed, the
+exit status is 2 if there are findings.`,
+	Example: `  ochami audit boot
+  ochami audit boot --cloud-init --exit-code
+  ochami audit boot -F yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		baseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI")
+			os.Exit(1)
+		}
+
+		// These endpoints require authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		ctx := context.Background()
+		if runner != nil {
+			ctx = runner.Context()
+		}
+
+		// Get components and ethernet interfaces from SMD
+		smdClient, err := smd.NewClient(baseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+		configureClient(smdClient.OchamiClient)
+		var data audit.BootData
+		if data.Components, err = smdClient.ComponentPager("", token).All(ctx); err != nil {
+			logAuditError(err, "failed to get components from SMD")
+			os.Exit(1)
+		}
+		if data.EthernetInterfaces, err = smdClient.EthernetInterfacePager("", token).All(ctx); err != nil {
+			logAuditError(err, "failed to get ethernet interfaces from SMD")
+			os.Exit(1)
+		}
+
+		// Get boot parameters from BSS
+		bssClient, err := bss.NewClient(baseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new BSS client")
+			os.Exit(1)
+		}
+		configureClient(bssClient.OchamiClient)
+		henv, err := bssClient.GetBootParams("", token)
+		if err != nil {
+			logAuditError(err, "failed to get boot parameters from BSS")
+			os.Exit(1)
+		}
+		if err := json.Unmarshal(henv.Body, &data.BootParams); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to unmarshal boot parameters")
+			os.Exit(1)
+		}
+
+		// Get configs from cloud-init if they will be checked
+		requireCloudInit := cmd.Flag("cloud-init").Changed
+		if !cmd.Flag("no-cloud-init").Changed && (requireCloudInit || bootParamsUseCloudInit(data.BootParams)) {
+			ciClient, err := ci.NewClient(baseURI, insecure)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("error creating new cloud-init client")
+				os.Exit(1)
+			}
+			configureClient(ciClient.OchamiClient)
+			henv, err := ciClient.GetConfigs("")
+			if err != nil {
+				logAuditError(err, "failed to get configs from cloud-init (pass --no-cloud-init to skip checking cloud-init)")
+				os.Exit(1)
+			}
+			data.CloudInitConfigs = make(map[string]citypes.CI)
+			if err := json.Unmarshal(henv.Body, &data.CloudInitConfigs); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to unmarshal cloud-init configs")
+				os.Exit(1)
+			}
+		}
+
+		report := audit.Boot(data, requireCloudInit)
+
+		// Print output
+		if cmd.Flag("output-format").Changed {
+			outFmt, err := cmd.Flags().GetString("output-format")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+				os.Exit(1)
+			}
+			reportBytes, err := json.Marshal(report)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to marshal boot audit")
+				os.Exit(1)
+			}
+			if outBytes, err := client.FormatBody(reportBytes, outFmt); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to format output")
+				os.Exit(1)
+			} else {
+				fmt.Print(string(outBytes))
+			}
+		} else {
+			writeAuditReport(os.Stdout, report)
+		}
+
+		if cmd.Flag("exit-code").Changed && !report.Empty() {
+			os.Exit(2)
+		}
+	},
+}
+
+// bootParamsUseCloudInit reports whether any of bps configure nodes to use
+// cloud-init.
+func bootParamsUseCloudInit(bps []bssTypes.BootParams) bool {
+	for _, bp := range bps {
+		if audit.UsesCloudInit(bp.Params) {
+			return true
+		}
+	}
+	return false
+}
+
+// logAuditError logs err, which occurred while fetching data to audit, with
+// msg.
+func logAuditError(err error, msg string) {
+	if errors.Is(err, client.UnsuccessfulHTTPError) {
+		log.Logger.Error().Err(err).Msg(msg + ": unsuccessful HTTP response")
+	} else {
+		log.Logger.Error().Err(err).Msg(msg)
+	}
+}
+
+// writeAuditReport writes report to w as a table of findings, most severe
+// first, followed by a summary line.
+func writeAuditReport(w io.Writer, report audit.Report) {
+	if report.Empty() {
+		fmt.Fprintf(w, "No problems found (%d nodes checked).\n", report.Nodes)
+		return
+	}
+	tw := color.NewTable(w)
+	fmt.Fprintln(tw, "SEVERITY\tCHECK\tID\tMESSAGE")
+	for _, f := range report.Findings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", strings.ToUpper(f.Severity), f.Check, f.ID, f.Message)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\n%d nodes checked: %d errors, %d warnings\n", report.Nodes,
+		report.Counts[audit.SeverityError], report.Counts[audit.SeverityWarning])
+}
+
+func init() {
+	auditBootCmd.Flags().Bool("cloud-init", false, "expect every node to have a cloud-init config")
+	auditBootCmd.Flags().Bool("no-cloud-init", false, "do not check cloud-init")
+	auditBootCmd.Flags().Bool("exit-code", false, "exit with status 2 if there are findings")
+	auditBootCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	auditBootCmd.MarkFlagsMutuallyExclusive("cloud-init", "no-cloud-init")
+
+	setCommandServices(auditBootCmd, "bss", "cloud-init", "smd")
+
+	auditCmd.AddCommand(auditBootCmd)
+}
@@ -0,0 +1,33 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/spf13/cobra"
+)
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Args:  cobra.NoArgs,
+	Short: "Check the consistency of data across OpenCHAMI services",
+	Long: `Check the consistency of data across OpenCHAMI services. This is a
+metacommand. Commands under this one only read data from services.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+}
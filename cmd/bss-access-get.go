@@ -0,0 +1,159 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/OpenCHAMI/bss/pkg/bssTypes"
+	"github.com/OpenCHAMI/ochami/internal/color"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/bss"
+	"github.com/spf13/cobra"
+)
+
+// bssAccessGetCmd represents the bss-access-get command
+var bssAccessGetCmd = &cobra.Command{
+	Use:   "get [--xname <xname>,...] [--endpoint bootscript|user-data]",
+	Args:  cobra.NoArgs,
+	Short: "Show when components last fetched their boot script or other BSS endpoints",
+	Long: `Show when components last fetched their boot script or other BSS endpoints.
+BSS records the last time each component accessed each of its endpoints
+(currently bootscript and user-data). This is useful for debugging boot loops:
+a node stuck in a loop keeps refreshing its last bootscript access.
+
+By default, a table of the accesses is printed, most recent first, with the
+time of each access and how long ago it was. If --output-format is passed, the
+accesses are printed as returned by BSS instead, with times as UNIX timestamps.
+Components that have never accessed an endpoint are shown as "never".
+
+If --xname is not passed, the accesses of all components are shown.`,
+	Example: `  ochami bss access get
+  ochami bss access get --xname x1000c1s7b0n0
+  ochami bss access get --xname x1000c1s7b0n0,x1000c1s7b1n0 --endpoint bootscript
+  ochami bss access get -F yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		bssBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for BSS")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to BSS
+		bssClient, err := bss.NewClient(bssBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new BSS client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(bssClient.OchamiClient)
+
+		endpoint, err := cmd.Flags().GetString("endpoint")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("unable to fetch endpoint")
+			os.Exit(1)
+		}
+		xnames, err := cmd.Flags().GetStringSlice("xname")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("unable to fetch xname list")
+			os.Exit(1)
+		}
+
+		// BSS filters by a single name, so query each xname separately.
+		// An empty name gets the accesses of all components.
+		if len(xnames) == 0 {
+			xnames = []string{""}
+		}
+		accesses := []bssTypes.EndpointAccess{}
+		for _, x := range xnames {
+			values := url.Values{}
+			if x != "" {
+				values.Add("name", x)
+			}
+			if endpoint != "" {
+				values.Add("endpoint", endpoint)
+			}
+			a, err := bssClient.GetEndpointAccessValues(values, token)
+			if err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msg("BSS endpoint access request yielded unsuccessful HTTP response")
+				} else {
+					log.Logger.Error().Err(err).Msg("failed to request endpoint accesses from BSS")
+				}
+				os.Exit(1)
+			}
+			accesses = append(accesses, a...)
+		}
+
+		// Print raw data if an output format was requested
+		if cmd.Flag("output-format").Changed {
+			outFmt, err := cmd.Flags().GetString("output-format")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+				os.Exit(1)
+			}
+			aBytes, err := json.Marshal(accesses)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to marshal endpoint accesses")
+				os.Exit(1)
+			}
+			outBytes, err := client.FormatBody(aBytes, outFmt)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to format output")
+				os.Exit(1)
+			}
+			fmt.Printf("%s\n", string(outBytes))
+			return
+		}
+
+		if err := printEndpointAccesses(accesses, time.Now()); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to print endpoint accesses")
+			os.Exit(1)
+		}
+	},
+}
+
+// printEndpointAccesses prints accesses as a table to standard output, most
+// recent first, with the age of each access relative to now.
+func printEndpointAccesses(accesses []bssTypes.EndpointAccess, now time.Time) error {
+	sort.SliceStable(accesses, func(i, j int) bool {
+		if accesses[i].LastEpoch != accesses[j].LastEpoch {
+			return accesses[i].LastEpoch > accesses[j].LastEpoch
+		}
+		return accesses[i].Name < accesses[j].Name
+	})
+
+	tw := color.NewTable(os.Stdout)
+	fmt.Fprintln(tw, "NAME\tENDPOINT\tLAST ACCESS\tAGE")
+	for _, a := range accesses {
+		last, age := "never", "-"
+		if a.LastEpoch > 0 {
+			t := time.Unix(a.LastEpoch, 0)
+			last = t.Format(time.RFC3339)
+			age = now.Sub(t).Round(time.Second).String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", a.Name, a.Endpoint, last, age)
+	}
+	return tw.Flush()
+}
+
+func init() {
+	bssAccessGetCmd.Flags().StringSlice("xname", []string{}, "one or more xnames whose accesses to show")
+	bssAccessGetCmd.Flags().String("endpoint", "", "only show accesses of this endpoint (bootscript,user-data)")
+	bssAccessGetCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+
+	bssAccessCmd.AddCommand(bssAccessGetCmd)
+}
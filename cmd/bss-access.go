@@ -0,0 +1,33 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/spf13/cobra"
+)
+
+// bssAccessCmd represents the bss-access command
+var bssAccessCmd = &cobra.Command{
+	Use:   "access",
+	Args:  cobra.NoArgs,
+	Short: "View when components last accessed BSS endpoints",
+	Long: `View when components last accessed BSS endpoints, e.g. when a node last
+fetched its boot script. This is a metacommand.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+	},
+}
+
+func init() {
+	bssCmd.AddCommand(bssAccessCmd)
+}
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"os"
 
-	"github.com/OpenCHAMI/bss/pkg/bssTypes"
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
 	"github.com/OpenCHAMI/ochami/pkg/client/bss"
@@ -19,11 +18,17 @@ var bootParamsAddCmd = &cobra.Command{
 	Args:  cobra.NoArgs,
 	Short: "Add new boot parameters for one or more components",
 	Long: `Add new boot parameters for one or more components. At least one of --kernel,
---initrd, or --params must be specified as well as at least one of --xname,
+--initrd, or --params must be specified as well as exactly one of --xname,
 --mac, or --nid. Alternatively, pass -f to pass a file (optionally specifying
 --payload-format, JSON by default), but the rules above still apply for the
 payload. If the specified file path is -, the data is read from standard input.
 
+Any field of the boot parameters can also be set with --set <field>=<value>,
+using the field names of the payload (e.g. hosts or cloud-init.meta-data).
+Fields passed with --set override those in the payload file, and flags
+override both. Values are parsed as YAML, so lists can be passed in flow
+style (e.g. [a, b]).
+
 This command sends a POST to BSS. An access token is required.`,
 	Example: `  ochami bss boot params add \
     --mac 00:de:ad:be:ef:00 \
@@ -32,6 +37,7 @@ This command sends a POST to BSS. An access token is required.`,
     --params 'quiet nosplash'
   ochami bss boot params add --mac 00:de:ad:be:ef:00,00:c0:ff:ee:00:00 --params 'quiet nosplash'
   ochami bss boot params add --mac 00:de:ad:be:ef:00 --mac 00:c0:ff:ee:00:00 --kernel https://example.com/kernel
+  ochami bss boot params add --set "hosts=[x3000c1s7b56n0]" --set kernel=https://example.com/kernel
   ochami bss boot params add -f payload.json
   ochami bss boot params add -f payload.yaml --payload-format yaml
   echo '<json_data>' | ochami bss boot params add -f -
@@ -40,7 +46,8 @@ This command sends a POST to BSS. An access token is required.`,
 		// cmd.LocalFlags().NFlag() doesn't seem to work, so we check every flag
 		if len(args) == 0 &&
 			!cmd.Flag("xname").Changed && !cmd.Flag("nid").Changed && !cmd.Flag("mac").Changed &&
-			!cmd.Flag("kernel").Changed && !cmd.Flag("initrd").Changed && !cmd.Flag("payload").Changed {
+			!cmd.Flag("kernel").Changed && !cmd.Flag("initrd").Changed && !cmd.Flag("payload").Changed &&
+			!cmd.Flag("set").Changed {
 			err := cmd.Usage()
 			if err != nil {
 				log.Logger.Error().Err(err).Msg("failed to print usage")
@@ -67,64 +74,12 @@ This command sends a POST to BSS. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(bssClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(bssClient.OchamiClient)
 
 		// The BSS BootParams struct we will send
-		bp := bssTypes.BootParams{}
-
-		// Read payload from file first, allowing overwrites from flags
-		handlePayload(cmd, &bp)
-
-		// Set the hosts the boot parameters are for
-		if cmd.Flag("xname").Changed {
-			bp.Hosts, err = cmd.Flags().GetStringSlice("xname")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch xname list")
-				os.Exit(1)
-			}
-		}
-		if cmd.Flag("mac").Changed {
-			bp.Macs, err = cmd.Flags().GetStringSlice("mac")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch mac list")
-				os.Exit(1)
-			}
-			if err = bp.CheckMacs(); err != nil {
-				log.Logger.Error().Err(err).Msg("invalid mac(s)")
-				os.Exit(1)
-			}
-		}
-		if cmd.Flag("nid").Changed {
-			bp.Nids, err = cmd.Flags().GetInt32Slice("nid")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch nid list")
-				os.Exit(1)
-			}
-		}
-
-		// Set the boot parameters
-		if cmd.Flag("kernel").Changed {
-			bp.Kernel, err = cmd.Flags().GetString("kernel")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch kernel uri")
-				os.Exit(1)
-			}
-		}
-		if cmd.Flag("initrd").Changed {
-			bp.Initrd, err = cmd.Flags().GetString("initrd")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch initrd uri")
-				os.Exit(1)
-			}
-		}
-		if cmd.Flag("params").Changed {
-			bp.Params, err = cmd.Flags().GetString("params")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch params")
-				os.Exit(1)
-			}
-		}
+		bp := bootParamsFromCmd(cmd, false)
+		verifyBootParamsHosts(cmd, bssBaseURI, bp)
 
 		// Send 'em off
 		_, err = bssClient.PostBootParams(bp, token)
@@ -146,11 +101,17 @@ func init() {
 	bootParamsAddCmd.Flags().StringSliceP("xname", "x", []string{}, "one or more xnames whose boot parameters to add")
 	bootParamsAddCmd.Flags().StringSliceP("mac", "m", []string{}, "one or more MAC addresses whose boot parameters to add")
 	bootParamsAddCmd.Flags().Int32SliceP("nid", "n", []int32{}, "one or more node IDs whose boot parameters to add")
-	bootParamsAddCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	bootParamsAddCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	addSetFlag(bootParamsAddCmd, "the boot parameters")
+	bootParamsAddCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	bootParamsAddCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	bootParamsAddCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
+	addVerifyHostsFlags(bootParamsAddCmd)
+
+	bootParamsAddCmd.MarkFlagsOneRequired("xname", "mac", "nid", "set", "payload")
+	bootParamsAddCmd.MarkFlagsOneRequired("kernel", "initrd", "params", "set", "payload")
+	bootParamsAddCmd.MarkFlagsMutuallyExclusive("xname", "mac", "nid")
 
-	bootParamsAddCmd.MarkFlagsOneRequired("xname", "mac", "nid", "payload")
-	bootParamsAddCmd.MarkFlagsOneRequired("kernel", "initrd", "params", "payload")
+	setCommandServices(bootParamsAddCmd, "bss", "smd")
 
 	bootParamsCmd.AddCommand(bootParamsAddCmd)
 }
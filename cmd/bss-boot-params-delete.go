@@ -6,7 +6,6 @@ import (
 	"errors"
 	"os"
 
-	"github.com/OpenCHAMI/bss/pkg/bssTypes"
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
 	"github.com/OpenCHAMI/ochami/pkg/client/bss"
@@ -66,64 +65,11 @@ This command sends a DELETE to BSS. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(bssClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(bssClient.OchamiClient)
 
 		// The BSS BootParams struct we will send
-		bp := bssTypes.BootParams{}
-
-		// Read payload from file first, allowing overwrites from flags
-		handlePayload(cmd, &bp)
-
-		// Set the hosts the boot parameters are for
-		if cmd.Flag("xname").Changed {
-			bp.Hosts, err = cmd.Flags().GetStringSlice("xname")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch xname list")
-				os.Exit(1)
-			}
-		}
-		if cmd.Flag("mac").Changed {
-			bp.Macs, err = cmd.Flags().GetStringSlice("mac")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch mac list")
-				os.Exit(1)
-			}
-			if err = bp.CheckMacs(); err != nil {
-				log.Logger.Error().Err(err).Msg("invalid mac(s)")
-				os.Exit(1)
-			}
-		}
-		if cmd.Flag("nid").Changed {
-			bp.Nids, err = cmd.Flags().GetInt32Slice("nid")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch nid list")
-				os.Exit(1)
-			}
-		}
-
-		// Set the boot parameters
-		if cmd.Flag("kernel").Changed {
-			bp.Kernel, err = cmd.Flags().GetString("kernel")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch kernel uri")
-				os.Exit(1)
-			}
-		}
-		if cmd.Flag("initrd").Changed {
-			bp.Initrd, err = cmd.Flags().GetString("initrd")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch initrd uri")
-				os.Exit(1)
-			}
-		}
-		if cmd.Flag("params").Changed {
-			bp.Params, err = cmd.Flags().GetString("params")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch params")
-				os.Exit(1)
-			}
-		}
+		bp := bootParamsFromCmd(cmd, true)
 
 		// Ask before attempting deletion unless --force was passed
 		if !cmd.Flag("force").Changed {
@@ -157,12 +103,14 @@ func init() {
 	bootParamsDeleteCmd.Flags().StringSliceP("xname", "x", []string{}, "one or more xnames whose boot parameters to delete")
 	bootParamsDeleteCmd.Flags().StringSliceP("mac", "m", []string{}, "one or more MAC addresses whose boot parameters to delete")
 	bootParamsDeleteCmd.Flags().Int32SliceP("nid", "n", []int32{}, "one or more node IDs whose boot parameters to delete")
-	bootParamsDeleteCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	bootParamsDeleteCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	bootParamsDeleteCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	bootParamsDeleteCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	bootParamsDeleteCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
 	bootParamsDeleteCmd.Flags().Bool("force", false, "do not ask before attempting deletion")
 
 	// We can delete either by component or by boot parameters
 	bootParamsDeleteCmd.MarkFlagsOneRequired("xname", "mac", "nid", "kernel", "initrd", "params", "payload")
+	bootParamsDeleteCmd.MarkFlagsMutuallyExclusive("xname", "mac", "nid")
 
 	bootParamsCmd.AddCommand(bootParamsDeleteCmd)
 }
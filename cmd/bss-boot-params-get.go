@@ -11,6 +11,7 @@ import (
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
 	"github.com/OpenCHAMI/ochami/pkg/client/bss"
+	"github.com/OpenCHAMI/ochami/pkg/format"
 	"github.com/spf13/cobra"
 )
 
@@ -47,15 +48,14 @@ This command sends a GET to BSS. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(bssClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(bssClient.OchamiClient)
 
 		// If no ID flags are specified, get all boot parameters
-		qstr := ""
+		values := url.Values{}
 		if cmd.Flag("xname").Changed ||
 			cmd.Flag("mac").Changed ||
 			cmd.Flag("nid").Changed {
-			values := url.Values{}
 			if cmd.Flag("xname").Changed {
 				s, err := cmd.Flags().GetStringSlice("xname")
 				if err != nil {
@@ -86,29 +86,18 @@ This command sends a GET to BSS. An access token is required.`,
 					values.Add("nid", fmt.Sprintf("%d", n))
 				}
 			}
-			qstr = values.Encode()
 		}
-		httpEnv, err := bssClient.GetBootParams(qstr, token)
+		httpEnv, err := bssClient.GetBootParamsValues(values, token)
 		if err != nil {
 			if errors.Is(err, client.UnsuccessfulHTTPError) {
-				log.Logger.Error().Err(err).Msg("BSS boot parameter request yielded unsuccessful HTTP response")
+				logHTTPError(err, httpEnv, "BSS boot parameter request yielded unsuccessful HTTP response")
 			} else {
 				log.Logger.Error().Err(err).Msg("failed to request boot parameters from BSS")
 			}
 			os.Exit(1)
 		}
 
-		outFmt, err := cmd.Flags().GetString("output-format")
-		if err != nil {
-			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
-			os.Exit(1)
-		}
-		if outBytes, err := client.FormatBody(httpEnv.Body, outFmt); err != nil {
-			log.Logger.Error().Err(err).Msg("failed to format output")
-			os.Exit(1)
-		} else {
-			fmt.Printf(string(outBytes))
-		}
+		printEnvelope(cmd, httpEnv)
 	},
 }
 
@@ -117,5 +106,7 @@ func init() {
 	bootParamsGetCmd.Flags().StringSliceP("mac", "m", []string{}, "one or more MAC addresses whose boot parameters to get")
 	bootParamsGetCmd.Flags().Int32SliceP("nid", "n", []int32{}, "one or more node IDs whose boot parameters to get")
 	bootParamsGetCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	addTableFormat(bootParamsGetCmd, format.KindBootParams)
+	addListFlags(bootParamsGetCmd)
 	bootParamsCmd.AddCommand(bootParamsGetCmd)
 }
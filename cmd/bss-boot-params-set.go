@@ -3,8 +3,10 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"os"
+	"strings"
 
 	"github.com/OpenCHAMI/bss/pkg/bssTypes"
 	"github.com/OpenCHAMI/ochami/internal/log"
@@ -20,18 +22,35 @@ var bootParamsSetCmd = &cobra.Command{
 	Short: "Set boot parameters for one or more components, overwriting any previous",
 	Long: `Set boot parameters for one or mote components, overwriting any previously-set
 parameters. At least one of --kernel, --initrd, or --params is
-required to tell ochami which boot data to set. Also, at least
-one of --xname, --mac, or --nid is required to tell ochami which
-components need modification. Alternatively, pass -f to pass a
+required to tell ochami which boot data to set. Also, exactly
+one of --xname, --mac, --nid, or --group is required to tell ochami
+which components need modification. Alternatively, pass -f to pass a
 file (optionally specifying --payload-format, JSON by default),
 but the rules above still apply for the payload. If the specified
 file path is -, the data is read from standard input.
 
-This command sends a PUT to BSS. An access token is required.`,
+Any field of the boot parameters can also be set with --set
+<field>=<value>, using the field names of the payload (e.g. hosts
+or cloud-init.meta-data). Fields passed with --set override those
+in the payload file, and flags override both. Values are parsed as
+YAML, so lists can be passed in flow style (e.g. [a, b]).
+
+With --group, the boot parameters are set for the members of the SMD
+groups passed, by xname or, with --by-mac, by the MAC addresses of
+their ethernet interfaces. Pass --reconcile to also delete the boot
+parameters of hosts that have the same kernel, initrd, and kernel
+parameters but are no longer members, so that running the same command
+after the membership of a group changed keeps BSS in sync with it.
+
+This command sends a PUT to BSS. With --group, it sends GETs to SMD
+first, and with --reconcile, a GET and a DELETE to BSS after. An access token is required.`,
 	Example: `  ochami bss boot params set --xname x1000c1s7b0 --kernel https://example.com/kernel
   ochami bss boot params set --xname x1000c1s7b0,x1000c1s7b1 --kernel https://example.com/kernel
   ochami bss boot params set --xname x1000c1s7b0 --xname x1000c1s7b1 --kernel https://example.com/kernel
-  ochami bss boot params set --xname x1000c1s7b0 --nid 1 --mac 00:c0:ff:ee:00:00 --params 'quiet nosplash'
+  ochami bss boot params set --mac 00:de:ad:be:ef:00,00:c0:ff:ee:00:00 --params 'quiet nosplash'
+  ochami bss boot params set --group compute --kernel https://example.com/kernel --initrd https://example.com/initrd
+  ochami bss boot params set --group compute --by-mac --reconcile --kernel https://example.com/kernel
+  ochami bss boot params set --set "hosts=[x1000c1s7b0]" --set kernel=https://example.com/kernel
   ochami bss boot params set -f payload.json
   ochami bss boot params set -f payload.yaml --payload-format yaml
   echo <json_data> | ochami bss boot params set -f -
@@ -39,8 +58,9 @@ This command sends a PUT to BSS. An access token is required.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// cmd.LocalFlags().NFlag() doesn't seem to work, so we check every flag
 		if len(args) == 0 &&
-			!cmd.Flag("xname").Changed && !cmd.Flag("nid").Changed && !cmd.Flag("mac").Changed &&
-			!cmd.Flag("kernel").Changed && !cmd.Flag("initrd").Changed && !cmd.Flag("payload").Changed {
+			!cmd.Flag("xname").Changed && !cmd.Flag("nid").Changed && !cmd.Flag("mac").Changed && !cmd.Flag("group").Changed &&
+			!cmd.Flag("kernel").Changed && !cmd.Flag("initrd").Changed && !cmd.Flag("payload").Changed &&
+			!cmd.Flag("set").Changed {
 			err := cmd.Usage()
 			if err != nil {
 				log.Logger.Error().Err(err).Msg("failed to print usage")
@@ -49,6 +69,15 @@ This command sends a PUT to BSS. An access token is required.`,
 			os.Exit(0)
 		}
 
+		if !cmd.Flag("group").Changed {
+			for _, f := range []string{"by-mac", "reconcile"} {
+				if cmd.Flag(f).Changed {
+					log.Logger.Error().Msgf("--%s can only be used with --group", f)
+					os.Exit(1)
+				}
+			}
+		}
+
 		// Without a base URI, we cannot do anything
 		bssBaseURI, err := getBaseURI(cmd)
 		if err != nil {
@@ -67,64 +96,12 @@ This command sends a PUT to BSS. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(bssClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(bssClient.OchamiClient)
 
 		// The BSS BootParams struct we will send
-		bp := bssTypes.BootParams{}
-
-		// Read payload from file first, allowing overwrites from flags
-		handlePayload(cmd, &bp)
-
-		// Set the hosts the boot parameters are for
-		if cmd.Flag("xname").Changed {
-			bp.Hosts, err = cmd.Flags().GetStringSlice("xname")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch xname list")
-				os.Exit(1)
-			}
-		}
-		if cmd.Flag("mac").Changed {
-			bp.Macs, err = cmd.Flags().GetStringSlice("mac")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch mac list")
-				os.Exit(1)
-			}
-			if err = bp.CheckMacs(); err != nil {
-				log.Logger.Error().Err(err).Msg("invalid mac(s)")
-				os.Exit(1)
-			}
-		}
-		if cmd.Flag("nid").Changed {
-			bp.Nids, err = cmd.Flags().GetInt32Slice("nid")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch nid list")
-				os.Exit(1)
-			}
-		}
-
-		// Set the boot parameters
-		if cmd.Flag("kernel").Changed {
-			bp.Kernel, err = cmd.Flags().GetString("kernel")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch kernel uri")
-				os.Exit(1)
-			}
-		}
-		if cmd.Flag("initrd").Changed {
-			bp.Initrd, err = cmd.Flags().GetString("initrd")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch initrd uri")
-				os.Exit(1)
-			}
-		}
-		if cmd.Flag("params").Changed {
-			bp.Params, err = cmd.Flags().GetString("params")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch params")
-				os.Exit(1)
-			}
-		}
+		bp := bootParamsFromCmd(cmd, false)
+		verifyBootParamsHosts(cmd, bssBaseURI, bp)
 
 		// Send 'em off
 		_, err = bssClient.PutBootParams(bp, token)
@@ -136,9 +113,55 @@ This command sends a PUT to BSS. An access token is required.`,
 			}
 			os.Exit(1)
 		}
+
+		if cmd.Flag("reconcile").Changed {
+			reconcileGroupBootParams(cmd, bssClient, bp)
+		}
 	},
 }
 
+// reconcileGroupBootParams deletes the boot parameters in BSS that are the same
+// as bp, which were set for the members of groups, but are for hosts (or, if
+// --by-mac was passed to cmd, MAC addresses) that bp is not for, i.e. that are
+// no longer members. If an error occurs, a log is printed and the program
+// exits.
+func reconcileGroupBootParams(cmd *cobra.Command, bssClient *bss.BSSClient, bp bssTypes.BootParams) {
+	henv, err := bssClient.GetBootParams("", token)
+	if err != nil {
+		if errors.Is(err, client.UnsuccessfulHTTPError) {
+			log.Logger.Error().Err(err).Msg("BSS boot parameter request yielded unsuccessful HTTP response")
+		} else {
+			log.Logger.Error().Err(err).Msg("failed to get boot parameters from BSS")
+		}
+		os.Exit(1)
+	}
+	var existing []bssTypes.BootParams
+	if err := json.Unmarshal(henv.Body, &existing); err != nil {
+		log.Logger.Error().Err(err).Msg("failed to unmarshal boot parameters")
+		os.Exit(1)
+	}
+
+	var stale bssTypes.BootParams
+	if cmd.Flag("by-mac").Changed {
+		stale.Macs = bss.StaleMACs(existing, bp)
+	} else {
+		stale.Hosts = bss.StaleHosts(existing, bp)
+	}
+	if len(stale.Hosts) == 0 && len(stale.Macs) == 0 {
+		log.Logger.Info().Msg("no boot parameters of former group members to delete")
+		return
+	}
+	log.Logger.Info().Msgf("deleting boot parameters of former group members: %s", strings.Join(append(stale.Hosts, stale.Macs...), ","))
+	if _, err := bssClient.DeleteBootParams(stale, token); err != nil {
+		if errors.Is(err, client.UnsuccessfulHTTPError) {
+			log.Logger.Error().Err(err).Msg("BSS boot parameter request yielded unsuccessful HTTP response")
+		} else {
+			log.Logger.Error().Err(err).Msg("failed to delete boot parameters of former group members from BSS")
+		}
+		os.Exit(1)
+	}
+}
+
 func init() {
 	bootParamsSetCmd.Flags().String("kernel", "", "URI of kernel")
 	bootParamsSetCmd.Flags().String("initrd", "", "URI of initrd/initramfs")
@@ -146,11 +169,20 @@ func init() {
 	bootParamsSetCmd.Flags().StringSliceP("xname", "x", []string{}, "one or more xnames whose boot parameters to set")
 	bootParamsSetCmd.Flags().StringSliceP("mac", "m", []string{}, "one or more MAC addresses whose boot parameters to set")
 	bootParamsSetCmd.Flags().Int32SliceP("nid", "n", []int32{}, "one or more node IDs whose boot parameters to set")
-	bootParamsSetCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	bootParamsSetCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
-
-	bootParamsSetCmd.MarkFlagsOneRequired("xname", "mac", "nid", "payload")
-	bootParamsSetCmd.MarkFlagsOneRequired("kernel", "initrd", "params", "payload")
+	bootParamsSetCmd.Flags().StringSlice("group", []string{}, "one or more SMD groups whose members' boot parameters to set")
+	bootParamsSetCmd.Flags().Bool("by-mac", false, "with --group, set boot parameters for the MAC addresses of members' ethernet interfaces instead of their xnames")
+	bootParamsSetCmd.Flags().Bool("reconcile", false, "with --group, delete the same boot parameters of hosts that are no longer members")
+	addSetFlag(bootParamsSetCmd, "the boot parameters")
+	bootParamsSetCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	bootParamsSetCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	bootParamsSetCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
+	addVerifyHostsFlags(bootParamsSetCmd)
+
+	bootParamsSetCmd.MarkFlagsOneRequired("xname", "mac", "nid", "group", "set", "payload")
+	bootParamsSetCmd.MarkFlagsOneRequired("kernel", "initrd", "params", "set", "payload")
+	bootParamsSetCmd.MarkFlagsMutuallyExclusive("xname", "mac", "nid", "group")
+
+	setCommandServices(bootParamsSetCmd, "bss", "smd")
 
 	bootParamsCmd.AddCommand(bootParamsSetCmd)
 }
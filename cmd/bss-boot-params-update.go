@@ -3,13 +3,20 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/OpenCHAMI/bss/pkg/bssTypes"
+	"github.com/OpenCHAMI/ochami/internal/cli"
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
 	"github.com/OpenCHAMI/ochami/pkg/client/bss"
+	"github.com/OpenCHAMI/ochami/pkg/kparams"
 	"github.com/spf13/cobra"
 )
 
@@ -19,17 +26,35 @@ var bootParamsUpdateCmd = &cobra.Command{
 	Args:  cobra.NoArgs,
 	Short: "Update some or all boot parameters for one or more components",
 	Long: `Update some or all boot parameters for one or more components. At least one of
---kernel, initrd, or --params must be specified as well as at least
+--kernel, initrd, or --params must be specified as well as exactly
 one of --xname, --mac, or --nid. Alternatively, pass -f to pass a
 file (optionally specifying --payload-format, JSON by default), but
 the rules above still apply for the payload. If the specified file
 path is -, the data is read from standard input.
 
+Instead of replacing the whole kernel command line with --params, it can
+be edited with --params-add, --params-set, and --params-remove, each of
+which can be passed more than once:
+
+  --params-add     appends parameters that are not present yet
+  --params-set     sets key=value, replacing every parameter with that key
+  --params-remove  removes parameters by key, or by key=value if a value is
+                   given
+
+Editing requires the current boot parameters, so they are fetched first.
+The entries to edit are selected with --xname, --mac, or --nid or with
+--select, which takes a query string using BSS's /bootparameters query
+parameters (name, mac, and nid), e.g. 'mac=aa:bb:cc:dd:ee:ff'. --select can
+also be used with --kernel or --initrd. Each matching entry is PATCHed
+separately and entries that would not change are skipped.
+
 This command sends a PATCH to BSS. An access token is required.`,
 	Example: `  ochami bss boot params update --xname x1000c1s7b0 --kernel https://example.com/kernel
   ochami bss boot params update --xname x1000c1s7b0,x1000c1s7b1 --kernel https://example.com/kernel
   ochami bss boot params update --xname x1000c1s7b0 --xname x1000c1s7b1 --kernel https://example.com/kernel
-  ochami bss boot params update --xname x1000c1s7b0 --nid 1 --mac 00:c0:ff:ee:00:00 --params 'quiet nosplash'
+  ochami bss boot params update --nid 1,2 --params 'quiet nosplash'
+  ochami bss boot params update --select 'mac=aa:bb:cc:dd:ee:ff' --params-add console=ttyS0 --params-remove quiet
+  ochami bss boot params update --xname x1000c1s7b0 --params-set root=live:https://example.com/image
   ochami bss boot params update -f payload.json
   ochami bss boot params update -f payload.yaml --payload-format yaml
   echo '<json_data>' | ochami bss boot params update -f -
@@ -38,7 +63,7 @@ This command sends a PATCH to BSS. An access token is required.`,
 		// cmd.LocalFlags().NFlag() doesn't seem to work, so we check every flag
 		if len(args) == 0 &&
 			!cmd.Flag("xname").Changed && !cmd.Flag("nid").Changed && !cmd.Flag("mac").Changed &&
-			!cmd.Flag("kernel").Changed && !cmd.Flag("initrd").Changed && !cmd.Flag("payload").Changed {
+			!cmd.Flag("select").Changed && !cmd.Flag("kernel").Changed && !cmd.Flag("initrd").Changed && !cmd.Flag("payload").Changed {
 			err := cmd.Usage()
 			if err != nil {
 				log.Logger.Error().Err(err).Msg("failed to print usage")
@@ -65,90 +90,242 @@ This command sends a PATCH to BSS. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(bssClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(bssClient.OchamiClient)
+		useIfMatch(cmd, bssClient.OchamiClient)
 
-		// The BSS BootParams struct we will send
-		bp := bssTypes.BootParams{}
+		// Editing the kernel parameters or selecting by query requires
+		// the current boot parameters
+		if cmd.Flag("select").Changed || cmd.Flag("params-add").Changed ||
+			cmd.Flag("params-set").Changed || cmd.Flag("params-remove").Changed {
+			editBootParams(cmd, bssClient)
+			return
+		}
 
-		// Read payload from file first, allowing overwrites from flags
-		handlePayload(cmd, &bp)
+		// The BSS BootParams struct we will send
+		bp := bootParamsFromCmd(cmd, false)
+		verifyBootParamsHosts(cmd, bssBaseURI, bp)
 
-		// Set the hosts the boot parameters are for
-		if cmd.Flag("xname").Changed {
-			bp.Hosts, err = cmd.Flags().GetStringSlice("xname")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch xname list")
-				os.Exit(1)
+		// Send 'em off
+		_, err = bssClient.PatchBootParams(bp, token)
+		if err != nil {
+			if errors.Is(err, client.PreconditionFailedError) {
+				log.Logger.Error().Err(err).Msg("boot parameters were modified by someone else since they were read; rerun to update the current version")
+			} else if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msg("BSS boot parameter request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to set boot parameters in BSS")
 			}
+			os.Exit(1)
 		}
-		if cmd.Flag("mac").Changed {
-			bp.Macs, err = cmd.Flags().GetStringSlice("mac")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch mac list")
-				os.Exit(1)
-			}
-			if err = bp.CheckMacs(); err != nil {
-				log.Logger.Error().Err(err).Msg("invalid mac(s)")
+	},
+}
+
+// editBootParams fetches the boot parameters selected by --select or by
+// --xname, --mac, or --nid, applies the kernel parameter edits (or --params)
+// and the --kernel and --initrd values passed to cmd to each, and PATCHes those that
+// changed. If an error occurs, it is logged and the program exits.
+func editBootParams(cmd *cobra.Command, bssClient *bss.BSSClient) {
+	query := cmd.Flag("select").Value.String()
+	if cmd.Flag("select").Changed {
+		if vals, err := url.ParseQuery(query); err != nil || len(vals) == 0 {
+			log.Logger.Error().Msgf("invalid --select %q: must be a non-empty query string, e.g. 'mac=aa:bb:cc:dd:ee:ff'", query)
+			os.Exit(1)
+		}
+	} else {
+		sel := bootParamsFromCmd(cmd, true)
+		verifyBootParamsHosts(cmd, bssClient.BaseURI.String(), sel)
+		vals := url.Values{}
+		for _, h := range sel.Hosts {
+			vals.Add("name", h)
+		}
+		for _, m := range sel.Macs {
+			vals.Add("mac", m)
+		}
+		for _, n := range sel.Nids {
+			vals.Add("nid", strconv.Itoa(int(n)))
+		}
+		query = vals.Encode()
+	}
+
+	var adds, sets, removes []kparams.Param
+	for _, f := range []struct {
+		name string
+		dst  *[]kparams.Param
+	}{{"params-add", &adds}, {"params-set", &sets}, {"params-remove", &removes}} {
+		vals, err := cmd.Flags().GetStringArray(f.name)
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to get value for --%s", f.name)
+			os.Exit(1)
+		}
+		for _, v := range vals {
+			// kparams.Parse alone accepts parameters without a key,
+			// e.g. "=foo"
+			if err := bss.CheckParams(v); err != nil {
+				log.Logger.Error().Err(err).Msgf("invalid --%s", f.name)
 				os.Exit(1)
 			}
-		}
-		if cmd.Flag("nid").Changed {
-			bp.Nids, err = cmd.Flags().GetInt32Slice("nid")
+			c, err := kparams.Parse(v)
 			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch nid list")
+				log.Logger.Error().Err(err).Msgf("invalid --%s", f.name)
 				os.Exit(1)
 			}
+			*f.dst = append(*f.dst, c.Params()...)
+		}
+	}
+	for _, s := range sets {
+		if !s.HasValue {
+			log.Logger.Error().Msgf("invalid --params-set %q: must be key=value", s)
+			os.Exit(1)
 		}
+	}
+	if cmd.Flag("params").Changed {
+		if err := bss.CheckParams(cmd.Flag("params").Value.String()); err != nil {
+			log.Logger.Error().Err(err).Msg("invalid --params")
+			os.Exit(1)
+		}
+	}
+
+	henv, err := bssClient.GetBootParams(query, token)
+	if err != nil {
+		if errors.Is(err, client.UnsuccessfulHTTPError) {
+			log.Logger.Error().Err(err).Msg("BSS boot parameter request yielded unsuccessful HTTP response")
+		} else {
+			log.Logger.Error().Err(err).Msg("failed to get boot parameters from BSS")
+		}
+		os.Exit(1)
+	}
+	var bps []bssTypes.BootParams
+	if err := json.Unmarshal(henv.Body, &bps); err != nil {
+		log.Logger.Error().Err(err).Msg("failed to unmarshal boot parameters")
+		os.Exit(1)
+	}
+	if len(bps) == 0 {
+		log.Logger.Error().Msgf("no boot parameters match %q", query)
+		os.Exit(1)
+	}
 
-		// Set the boot parameters
+	// Use the ETag of the boot parameters the edit is based on so that
+	// changes made after they were read are not overwritten. A single ETag
+	// cannot stand for several entries, and fetching each one's right
+	// before writing it would not catch such changes.
+	if bssClient.IfMatch == client.IfMatchAuto {
+		if len(bps) > 1 {
+			log.Logger.Error().Msgf("--if-match auto cannot be used when %d boot parameter entries match %q; select a single entry or pass an ETag", len(bps), query)
+			os.Exit(1)
+		}
+		if henv.ETag != "" {
+			bssClient.IfMatch = henv.ETag
+		}
+	}
+
+	failed := 0
+	for _, bp := range bps {
+		id := bootParamsID(bp)
+		updated := bp
+		if cmd.Flag("params").Changed {
+			updated.Params = cmd.Flag("params").Value.String()
+		} else if updated.Params, err = editParams(bp.Params, adds, sets, removes); err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to parse kernel parameters of %s", id)
+			failed++
+			continue
+		}
 		if cmd.Flag("kernel").Changed {
-			bp.Kernel, err = cmd.Flags().GetString("kernel")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch kernel uri")
-				os.Exit(1)
-			}
+			updated.Kernel = cmd.Flag("kernel").Value.String()
 		}
 		if cmd.Flag("initrd").Changed {
-			bp.Initrd, err = cmd.Flags().GetString("initrd")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch initrd uri")
-				os.Exit(1)
-			}
+			updated.Initrd = cmd.Flag("initrd").Value.String()
 		}
-		if cmd.Flag("params").Changed {
-			bp.Params, err = cmd.Flags().GetString("params")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("unable to fetch params")
-				os.Exit(1)
-			}
+		if updated.Params == bp.Params && updated.Kernel == bp.Kernel && updated.Initrd == bp.Initrd {
+			log.Logger.Info().Msgf("boot parameters of %s are unchanged, skipping", id)
+			continue
 		}
-
-		// Send 'em off
-		_, err = bssClient.PatchBootParams(bp, token)
-		if err != nil {
-			if errors.Is(err, client.UnsuccessfulHTTPError) {
-				log.Logger.Error().Err(err).Msg("BSS boot parameter request yielded unsuccessful HTTP response")
+		if _, err := bssClient.PatchBootParams(updated, token); err != nil {
+			if errors.Is(err, client.PreconditionFailedError) {
+				log.Logger.Error().Err(err).Msgf("boot parameters of %s were modified by someone else since they were read; rerun to update the current version", id)
+			} else if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msgf("BSS boot parameter request yielded unsuccessful HTTP response for %s", id)
 			} else {
-				log.Logger.Error().Err(err).Msg("failed to set boot parameters in BSS")
+				log.Logger.Error().Err(err).Msgf("failed to update boot parameters of %s", id)
 			}
-			os.Exit(1)
+			failed++
+			continue
 		}
-	},
+		log.Logger.Info().Msgf("updated boot parameters of %s: %s", id, updated.Params)
+	}
+	exitIfItemsFailed("BSS boot parameter update", cli.BulkSummary{Total: len(bps), Succeeded: len(bps) - failed, Failed: failed})
+}
+
+// bootParamsID returns a description of the hosts, MAC addresses, or NIDs
+// that bp is for, for use in messages.
+func bootParamsID(bp bssTypes.BootParams) string {
+	switch {
+	case len(bp.Hosts) > 0:
+		return strings.Join(bp.Hosts, ",")
+	case len(bp.Macs) > 0:
+		return strings.Join(bp.Macs, ",")
+	case len(bp.Nids) > 0:
+		return fmt.Sprint(bp.Nids)
+	}
+	return "(unknown)"
+}
+
+// editParams removes, sets, and adds kernel parameters in params, in that
+// order, keeping the order of the remaining parameters. A parameter in
+// removes without a value removes every parameter with that key, and one with
+// a value only removes the equal parameter. A parameter in sets replaces the
+// first parameter with its key and removes the others, or is appended if there
+// is none. A parameter in adds is appended unless an equal parameter is
+// already present.
+func editParams(params string, adds, sets, removes []kparams.Param) (string, error) {
+	c, err := kparams.Parse(params)
+	if err != nil {
+		return "", err
+	}
+	for _, r := range removes {
+		if r.HasValue {
+			c.RemoveParam(r)
+		} else {
+			c.Remove(r.Key)
+		}
+	}
+	for _, s := range sets {
+		c.Set(s)
+	}
+	for _, a := range adds {
+		c.Add(a)
+	}
+	return c.String(), nil
 }
 
 func init() {
 	bootParamsUpdateCmd.Flags().String("kernel", "", "URI of kernel")
 	bootParamsUpdateCmd.Flags().String("initrd", "", "URI of initrd/initramfs")
 	bootParamsUpdateCmd.Flags().String("params", "", "kernel parameters")
+	bootParamsUpdateCmd.Flags().StringArray("params-add", []string{}, "kernel parameters to append if not present")
+	bootParamsUpdateCmd.Flags().StringArray("params-set", []string{}, "key=value kernel parameters to set, replacing those with the same key")
+	bootParamsUpdateCmd.Flags().StringArray("params-remove", []string{}, "kernel parameters to remove by key or key=value")
+	bootParamsUpdateCmd.Flags().String("select", "", "query string selecting the boot parameters to update (e.g. 'mac=aa:bb:cc:dd:ee:ff')")
 	bootParamsUpdateCmd.Flags().StringSliceP("xname", "x", []string{}, "one or more xnames whose boot parameters to update")
 	bootParamsUpdateCmd.Flags().StringSliceP("mac", "m", []string{}, "one or more MAC addresses whose boot parameters to update")
 	bootParamsUpdateCmd.Flags().Int32SliceP("nid", "n", []int32{}, "one or more node IDs whose boot parameters to update")
-	bootParamsUpdateCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	bootParamsUpdateCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	bootParamsUpdateCmd.Flags().String("if-match", "", "only write if the resource is unmodified; pass 'auto' to use the ETag from a preceding GET, or an ETag")
+	bootParamsUpdateCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	bootParamsUpdateCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	bootParamsUpdateCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
+	addVerifyHostsFlags(bootParamsUpdateCmd)
+
+	bootParamsUpdateCmd.MarkFlagsOneRequired("xname", "mac", "nid", "select", "payload")
+	bootParamsUpdateCmd.MarkFlagsOneRequired("kernel", "initrd", "params", "params-add", "params-set", "params-remove", "payload")
+	bootParamsUpdateCmd.MarkFlagsMutuallyExclusive("xname", "mac", "nid", "select")
+	bootParamsUpdateCmd.MarkFlagsMutuallyExclusive("select", "payload")
+	bootParamsUpdateCmd.MarkFlagsMutuallyExclusive("select", "verify-hosts")
+	for _, f := range []string{"params-add", "params-set", "params-remove"} {
+		bootParamsUpdateCmd.MarkFlagsMutuallyExclusive(f, "params")
+		bootParamsUpdateCmd.MarkFlagsMutuallyExclusive(f, "payload")
+	}
 
-	bootParamsUpdateCmd.MarkFlagsOneRequired("xname", "mac", "nid", "payload")
-	bootParamsUpdateCmd.MarkFlagsOneRequired("kernel", "initrd", "params", "payload")
+	setCommandServices(bootParamsUpdateCmd, "bss", "smd")
 
 	bootParamsCmd.AddCommand(bootParamsUpdateCmd)
 }
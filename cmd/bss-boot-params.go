@@ -3,9 +3,15 @@
 package cmd
 
 import (
+	"errors"
 	"os"
+	"strings"
 
+	"github.com/OpenCHAMI/bss/pkg/bssTypes"
 	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/bss"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
 	"github.com/spf13/cobra"
 )
 
@@ -31,3 +37,176 @@ and kernel command line arguments. This is a metacommand.`,
 func init() {
 	bootCmd.AddCommand(bootParamsCmd)
 }
+
+// bootParamsFromCmd reads the payload file for cmd, if passed, overrides its
+// fields with those passed with --set, if cmd has it, and then with those
+// passed via flags, and validates the result with a
+// bss.BootParamsBuilder. If selectorOnly is true, the boot parameters are only
+// validated for use as a selector (e.g. for deletion). If an error occurs, it
+// is logged and the program exits.
+func bootParamsFromCmd(cmd *cobra.Command, selectorOnly bool) bssTypes.BootParams {
+	// Read payload from file first, allowing overwrites from flags
+	var payload bssTypes.BootParams
+	handlePayload(cmd, &payload)
+	handleSet(cmd, &payload)
+	b := bss.FromBootParams(payload)
+
+	// Set the hosts the boot parameters are for
+	if cmd.Flag("xname").Changed {
+		xnames, err := cmd.Flags().GetStringSlice("xname")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("unable to fetch xname list")
+			os.Exit(1)
+		}
+		b.WithHosts(xnames...)
+	}
+	if cmd.Flag("mac").Changed {
+		macs, err := cmd.Flags().GetStringSlice("mac")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("unable to fetch mac list")
+			os.Exit(1)
+		}
+		b.WithMacs(macs...)
+	}
+	if cmd.Flag("nid").Changed {
+		nids, err := cmd.Flags().GetInt32Slice("nid")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("unable to fetch nid list")
+			os.Exit(1)
+		}
+		b.WithNids(nids...)
+	}
+	if f := cmd.Flags().Lookup("group"); f != nil && f.Changed {
+		if cmd.Flag("by-mac").Changed {
+			b.WithMacs(groupBootParamsHosts(cmd)...)
+		} else {
+			b.WithHosts(groupBootParamsHosts(cmd)...)
+		}
+	}
+
+	// Set the boot parameters
+	if cmd.Flag("kernel").Changed {
+		b.WithKernel(cmd.Flag("kernel").Value.String())
+	}
+	if cmd.Flag("initrd").Changed {
+		b.WithInitrd(cmd.Flag("initrd").Value.String())
+	}
+	if cmd.Flag("params").Changed {
+		b.WithParams(cmd.Flag("params").Value.String())
+	}
+
+	var (
+		bp  bssTypes.BootParams
+		err error
+	)
+	if selectorOnly {
+		bp, err = b.BuildSelector()
+	} else {
+		bp, err = b.Build()
+	}
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("invalid boot parameters")
+		os.Exit(1)
+	}
+
+	return bp
+}
+
+// groupBootParamsHosts returns the xnames of the members of the SMD groups
+// passed to cmd with --group or, if --by-mac was passed, the MAC addresses of
+// their ethernet interfaces, so that boot parameters can be set for them. If
+// an error occurs or there are no such hosts, a log is printed and the program
+// exits.
+func groupBootParamsHosts(cmd *cobra.Command) []string {
+	groups, err := cmd.Flags().GetStringSlice("group")
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("unable to fetch group list")
+		os.Exit(1)
+	}
+	baseURI, err := getBaseURI(cmd)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+		os.Exit(1)
+	}
+	smdClient, err := smd.NewClient(baseURI, insecure)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("error creating new SMD client")
+		os.Exit(1)
+	}
+	configureClient(smdClient.OchamiClient)
+
+	hosts, err := smdClient.GroupMembers(groups, token)
+	if err != nil {
+		if errors.Is(err, client.UnsuccessfulHTTPError) {
+			log.Logger.Error().Err(err).Msg("SMD group request yielded unsuccessful HTTP response")
+		} else {
+			log.Logger.Error().Err(err).Msg("failed to get members of groups")
+		}
+		os.Exit(1)
+	}
+	log.Logger.Debug().Msgf("groups %v have %d member(s)", groups, len(hosts))
+	if cmd.Flag("by-mac").Changed {
+		macs, without, err := smdClient.InterfaceMACs(hosts)
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msg("SMD ethernet interface request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to get ethernet interfaces of group members")
+			}
+			os.Exit(1)
+		}
+		if len(without) > 0 {
+			log.Logger.Warn().Msgf("group members without ethernet interfaces get no boot parameters: %s", strings.Join(without, ","))
+		}
+		hosts = macs
+	}
+	if len(hosts) == 0 {
+		log.Logger.Error().Msgf("groups %v have no members to set boot parameters for", groups)
+		os.Exit(1)
+	}
+
+	return hosts
+}
+
+// addVerifyHostsFlags adds the flags used by verifyBootParamsHosts to cmd.
+func addVerifyHostsFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("verify-hosts", false, "check that the xnames, MAC addresses, and NIDs exist in SMD before sending")
+	cmd.Flags().Bool("allow-unknown-hosts", false, "with --verify-hosts, only warn about hosts that do not exist in SMD")
+}
+
+// verifyBootParamsHosts checks, if --verify-hosts was passed to cmd, that the
+// xnames, MAC addresses, and NIDs that bp is for exist in SMD, so that a typo
+// does not leave a node silently without boot parameters. If any do not, an
+// error is logged and the program exits, unless --allow-unknown-hosts was
+// passed, in which case only a warning is logged.
+func verifyBootParamsHosts(cmd *cobra.Command, baseURI string, bp bssTypes.BootParams) {
+	if !cmd.Flag("verify-hosts").Changed {
+		return
+	}
+	smdClient, err := smd.NewClient(baseURI, insecure)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("error creating new SMD client")
+		os.Exit(1)
+	}
+	configureClient(smdClient.OchamiClient)
+
+	unknown, err := smdClient.VerifyHosts(bp.Hosts, bp.Macs, bp.Nids, token)
+	if err != nil {
+		if errors.Is(err, client.UnsuccessfulHTTPError) {
+			log.Logger.Error().Err(err).Msg("SMD request to verify hosts yielded unsuccessful HTTP response")
+		} else {
+			log.Logger.Error().Err(err).Msg("failed to verify hosts in SMD")
+		}
+		os.Exit(1)
+	}
+	if unknown.Empty() {
+		log.Logger.Debug().Msg("all hosts of boot parameters exist in SMD")
+		return
+	}
+	if cmd.Flag("allow-unknown-hosts").Changed {
+		log.Logger.Warn().Msgf("hosts do not exist in SMD: %s", unknown)
+		return
+	}
+	log.Logger.Error().Msgf("hosts do not exist in SMD: %s (pass --allow-unknown-hosts to send the boot parameters anyway)", unknown)
+	os.Exit(1)
+}
@@ -39,8 +39,8 @@ This command sends a GET to BSS. An access token is not required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(bssClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(bssClient.OchamiClient)
 
 		// Structure representing the boot script query string
 		values := url.Values{}
@@ -102,12 +102,10 @@ This command sends a GET to BSS. An access token is not required.`,
 			}
 			values.Add("timestamp", fmt.Sprintf("%d", s))
 		}
-		qstr := values.Encode()
-
-		httpEnv, err := bssClient.GetBootScript(qstr)
+		httpEnv, err := bssClient.GetBootScriptValues(values)
 		if err != nil {
 			if errors.Is(err, client.UnsuccessfulHTTPError) {
-				log.Logger.Error().Err(err).Msg("BSS boot script request yielded unsuccessful HTTP response")
+				logHTTPError(err, httpEnv, "BSS boot script request yielded unsuccessful HTTP response")
 			} else {
 				log.Logger.Error().Err(err).Msg("failed to request boot script from BSS")
 			}
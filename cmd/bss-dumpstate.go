@@ -4,7 +4,6 @@ package cmd
 
 import (
 	"errors"
-	"fmt"
 	"os"
 
 	"github.com/OpenCHAMI/ochami/internal/log"
@@ -33,14 +32,14 @@ var bssDumpStateCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(bssClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(bssClient.OchamiClient)
 
 		// Send request
 		httpEnv, err := bssClient.GetDumpState()
 		if err != nil {
 			if errors.Is(err, client.UnsuccessfulHTTPError) {
-				log.Logger.Error().Err(err).Msg("BSS dump state request yielded unsuccessful HTTP response")
+				logHTTPError(err, httpEnv, "BSS dump state request yielded unsuccessful HTTP response")
 			} else {
 				log.Logger.Error().Err(err).Msg("failed to request dump state from BSS")
 			}
@@ -48,18 +47,7 @@ var bssDumpStateCmd = &cobra.Command{
 		}
 
 		// Print output
-		fmt.Println(string(httpEnv.Body))
-		outFmt, err := cmd.Flags().GetString("output-format")
-		if err != nil {
-			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
-			os.Exit(1)
-		}
-		if outBytes, err := client.FormatBody(httpEnv.Body, outFmt); err != nil {
-			log.Logger.Error().Err(err).Msg("failed to format output")
-			os.Exit(1)
-		} else {
-			fmt.Printf(string(outBytes))
-		}
+		printEnvelope(cmd, httpEnv)
 	},
 }
 
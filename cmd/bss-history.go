@@ -3,11 +3,13 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
-	"fmt"
 	"net/url"
 	"os"
+	"time"
 
+	"github.com/OpenCHAMI/bss/pkg/bssTypes"
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
 	"github.com/OpenCHAMI/ochami/pkg/client/bss"
@@ -19,6 +21,15 @@ var bssHistoryCmd = &cobra.Command{
 	Use:   "history",
 	Args:  cobra.NoArgs,
 	Short: "Fetch the endpoint history of BSS",
+	Long: `Fetch the endpoint history of BSS, i.e. the last time each component
+fetched each BSS endpoint (e.g. its boot script).
+
+Pass --since and/or --until to only show accesses within a time window.
+BSS cannot filter by time, so this is done after fetching the history.`,
+	Example: `  ochami bss history
+  ochami bss history --xname x3000c1s7b56n0
+  ochami bss history --endpoint bootscript --since -24h
+  ochami bss history --since yesterday --until today`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Without a base URI, we cannot do anything
 		bssBaseURI, err := getBaseURI(cmd)
@@ -34,13 +45,12 @@ var bssHistoryCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(bssClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(bssClient.OchamiClient)
 
 		// If no ID flags are specified, get all boot parameters
-		qstr := ""
+		values := url.Values{}
 		if cmd.Flag("xname").Changed || cmd.Flag("endpoint").Changed {
-			values := url.Values{}
 			if cmd.Flag("xname").Changed {
 				x, err := cmd.Flags().GetString("xname")
 				if err != nil {
@@ -57,32 +67,40 @@ var bssHistoryCmd = &cobra.Command{
 				}
 				values.Add("endpoint", e)
 			}
-			qstr = values.Encode()
 		}
 
 		// Send request
-		httpEnv, err := bssClient.GetEndpointHistory(qstr)
+		httpEnv, err := bssClient.GetEndpointHistoryValues(values)
 		if err != nil {
 			if errors.Is(err, client.UnsuccessfulHTTPError) {
-				log.Logger.Error().Err(err).Msg("BSS endpoint history request yielded unsuccessful HTTP response")
+				logHTTPError(err, httpEnv, "BSS endpoint history request yielded unsuccessful HTTP response")
 			} else {
 				log.Logger.Error().Err(err).Msg("failed to request endpoint history from BSS")
 			}
 			os.Exit(1)
 		}
 
-		// Print output
-		outFmt, err := cmd.Flags().GetString("output-format")
-		if err != nil {
-			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
-			os.Exit(1)
-		}
-		if outBytes, err := client.FormatBody(httpEnv.Body, outFmt); err != nil {
-			log.Logger.Error().Err(err).Msg("failed to format output")
-			os.Exit(1)
-		} else {
-			fmt.Printf(string(outBytes))
+		// BSS has no time parameters, so filter accesses here
+		if w := timeWindow(cmd); !w.IsZero() && httpEnv.StatusCode < 300 {
+			var accesses []bssTypes.EndpointAccess
+			if err := json.Unmarshal(httpEnv.Body, &accesses); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to unmarshal endpoint history")
+				os.Exit(1)
+			}
+			inWindow := []bssTypes.EndpointAccess{}
+			for _, a := range accesses {
+				if w.Contains(time.Unix(a.LastEpoch, 0)) {
+					inWindow = append(inWindow, a)
+				}
+			}
+			if httpEnv.Body, err = json.Marshal(inWindow); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to marshal endpoint history")
+				os.Exit(1)
+			}
 		}
+
+		// Print output
+		printEnvelope(cmd, httpEnv)
 	},
 }
 
@@ -90,5 +108,7 @@ func init() {
 	bssHistoryCmd.Flags().String("xname", "", "filter by xname")
 	bssHistoryCmd.Flags().String("endpoint", "", "filter by endpoint")
 	bssHistoryCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	addListFlags(bssHistoryCmd)
+	addTimeWindowFlags(bssHistoryCmd)
 	bssCmd.AddCommand(bssHistoryCmd)
 }
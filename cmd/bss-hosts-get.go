@@ -34,15 +34,14 @@ var bssHostsGetCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(bssClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(bssClient.OchamiClient)
 
 		// If no ID flags are specified, get all boot parameters
-		qstr := ""
+		values := url.Values{}
 		if cmd.Flag("xname").Changed ||
 			cmd.Flag("mac").Changed ||
 			cmd.Flag("nid").Changed {
-			values := url.Values{}
 			if cmd.Flag("xname").Changed {
 				x, err := cmd.Flags().GetString("xname")
 				if err != nil {
@@ -67,12 +66,11 @@ var bssHostsGetCmd = &cobra.Command{
 				}
 				values.Add("nid", fmt.Sprintf("%d", n))
 			}
-			qstr = values.Encode()
 		}
-		httpEnv, err := bssClient.GetHosts(qstr)
+		httpEnv, err := bssClient.GetHostsValues(values)
 		if err != nil {
 			if errors.Is(err, client.UnsuccessfulHTTPError) {
-				log.Logger.Error().Err(err).Msg("BSS hosts request yielded unsuccessful HTTP response")
+				logHTTPError(err, httpEnv, "BSS hosts request yielded unsuccessful HTTP response")
 			} else {
 				log.Logger.Error().Err(err).Msg("failed to request hosts from BSS")
 			}
@@ -80,17 +78,7 @@ var bssHostsGetCmd = &cobra.Command{
 		}
 
 		// Print output
-		outFmt, err := cmd.Flags().GetString("output-format")
-		if err != nil {
-			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
-			os.Exit(1)
-		}
-		if outBytes, err := client.FormatBody(httpEnv.Body, outFmt); err != nil {
-			log.Logger.Error().Err(err).Msg("failed to format output")
-			os.Exit(1)
-		} else {
-			fmt.Printf(string(outBytes))
-		}
+		printEnvelope(cmd, httpEnv)
 	},
 }
 
@@ -99,5 +87,6 @@ func init() {
 	bssHostsGetCmd.Flags().StringP("mac", "m", "", "MAC address whose boot parameters to get")
 	bssHostsGetCmd.Flags().Int32P("nid", "n", 0, "node ID whose host information to get")
 	bssHostsGetCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	addListFlags(bssHostsGetCmd)
 	bssHostsCmd.AddCommand(bssHostsGetCmd)
 }
@@ -3,10 +3,15 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/OpenCHAMI/ochami/internal/color"
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
 	"github.com/OpenCHAMI/ochami/pkg/client/bss"
@@ -18,6 +23,20 @@ var bssStatusCmd = &cobra.Command{
 	Use:   "status",
 	Args:  cobra.NoArgs,
 	Short: "Get status of BSS service",
+	Long: `Get status of BSS service. By default, whether BSS is running is printed.
+Pass --all, --storage, --smd, or --version to print the raw status data of
+the corresponding BSS endpoint instead.
+
+Pass --detail to print a summary of whether BSS is running, its version, and
+whether it can reach SMD and its storage backend (e.g. postgres or etcd), as
+reported by BSS itself. This helps to tell which layer is down when, for
+example, boot scripts cannot be fetched. If --output-format is also passed,
+the summary is printed in that format instead. The exit status is 1 if BSS
+is not running or reports an error reaching SMD or its storage backend.`,
+	Example: `  ochami bss status
+  ochami bss status --smd
+  ochami bss status --detail
+  ochami bss status --detail -F yaml`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Without a base URI, we cannot do anything
 		bssBaseURI, err := getBaseURI(cmd)
@@ -33,8 +52,21 @@ var bssStatusCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(bssClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(bssClient.OchamiClient)
+
+		if cmd.Flag("detail").Changed {
+			detail, err := bssClient.GetStatusDetail()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get BSS status")
+				os.Exit(1)
+			}
+			printBSSStatusDetail(cmd, detail)
+			if !detail.Healthy() {
+				os.Exit(1)
+			}
+			return
+		}
 
 		// Determine which component to get status for and send request
 		var httpEnv client.HTTPEnvelope
@@ -51,7 +83,7 @@ var bssStatusCmd = &cobra.Command{
 		}
 		if err != nil {
 			if errors.Is(err, client.UnsuccessfulHTTPError) {
-				log.Logger.Error().Err(err).Msg("BSS status request yielded unsuccessful HTTP response")
+				logHTTPError(err, httpEnv, "BSS status request yielded unsuccessful HTTP response")
 			} else {
 				log.Logger.Error().Err(err).Msg("failed to get BSS status")
 			}
@@ -59,28 +91,98 @@ var bssStatusCmd = &cobra.Command{
 		}
 
 		// Print output
+		printEnvelope(cmd, httpEnv)
+	},
+}
+
+func init() {
+	bssStatusCmd.Flags().Bool("all", false, "print all status data from BSS")
+	bssStatusCmd.Flags().Bool("storage", false, "print status of storage backend from BSS")
+	bssStatusCmd.Flags().Bool("smd", false, "print status of BSS connection to SMD")
+	bssStatusCmd.Flags().Bool("version", false, "print version of BSS")
+	bssStatusCmd.Flags().Bool("detail", false, "print summary of BSS status and its connectivity to SMD and storage as a table")
+
+	bssStatusCmd.MarkFlagsMutuallyExclusive("all", "storage", "smd", "version", "detail")
+
+	bssStatusCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	bssCmd.AddCommand(bssStatusCmd)
+}
+
+// printBSSStatusDetail prints detail as a table unless --output-format was
+// passed, in which case detail is printed in that format.
+func printBSSStatusDetail(cmd *cobra.Command, detail bss.StatusDetail) {
+	if cmd.Flag("output-format").Changed {
 		outFmt, err := cmd.Flags().GetString("output-format")
 		if err != nil {
 			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
 			os.Exit(1)
 		}
-		if outBytes, err := client.FormatBody(httpEnv.Body, outFmt); err != nil {
+		detailBytes, err := json.Marshal(detail)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to marshal BSS status")
+			os.Exit(1)
+		}
+		if outBytes, err := client.FormatBody(detailBytes, outFmt); err != nil {
 			log.Logger.Error().Err(err).Msg("failed to format output")
 			os.Exit(1)
 		} else {
-			fmt.Printf(string(outBytes))
+			fmt.Print(string(outBytes))
 		}
-	},
+		return
+	}
+
+	if err := writeBSSStatusTable(os.Stdout, detail); err != nil {
+		log.Logger.Error().Err(err).Msg("failed to print BSS status")
+		os.Exit(1)
+	}
 }
 
-func init() {
-	bssStatusCmd.Flags().Bool("all", false, "print all status data from BSS")
-	bssStatusCmd.Flags().Bool("storage", false, "print status of storage backend from BSS")
-	bssStatusCmd.Flags().Bool("smd", false, "print status of BSS connection to SMD")
-	bssStatusCmd.Flags().Bool("version", false, "print version of BSS")
+// writeBSSStatusTable writes detail to w as a two-column table of fields and
+// their values.
+func writeBSSStatusTable(w io.Writer, detail bss.StatusDetail) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
 
-	bssStatusCmd.MarkFlagsMutuallyExclusive("all", "storage", "smd", "version")
+	running := "no"
+	if detail.Running {
+		running = "yes"
+	}
+	if detail.Message != "" {
+		running += " (" + detail.Message + ")"
+	}
+	version := detail.Version
+	if version == "" {
+		version = "unknown"
+	}
+	fmt.Fprintf(tw, "RUNNING\t%s\n", running)
+	fmt.Fprintf(tw, "VERSION\t%s\n", version)
+	fmt.Fprintf(tw, "SMD\t%s\n", dependencyString(detail.SMD))
+	fmt.Fprintf(tw, "STORAGE\t%s\n", dependencyString(detail.Storage))
 
-	bssStatusCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
-	bssCmd.AddCommand(bssStatusCmd)
+	return tw.Flush()
+}
+
+// dependencyString returns ds as e.g. "connected (postgres)" or
+// "error (500 Internal Server Error)", colored by status if color.Stdout is
+// enabled.
+func dependencyString(ds bss.DependencyStatus) string {
+	var s string
+	switch ds.Status {
+	case bss.DependencyConnected:
+		s = color.Stdout.Green(ds.Status)
+	case bss.DependencyError:
+		s = color.Stdout.Red(ds.Status)
+	default:
+		s = color.Stdout.Yellow(ds.Status)
+	}
+	var notes []string
+	if ds.Name != "" {
+		notes = append(notes, ds.Name)
+	}
+	if ds.Message != "" {
+		notes = append(notes, ds.Message)
+	}
+	if len(notes) > 0 {
+		s += " (" + strings.Join(notes, ": ") + ")"
+	}
+	return s
 }
@@ -16,7 +16,7 @@ import (
 
 // cloudInitConfigAddCmd represents the cloud-init-config-add command
 var cloudInitConfigAddCmd = &cobra.Command{
-	Use:   "add (-f <payload_file> | -d <json_data>)",
+	Use:   "add (-f <payload_file> | -d <json_data> | --set <field>=<value>...)",
 	Args:  cobra.NoArgs,
 	Short: "Add one or more new cloud-init configs",
 	Long: `Add one or more new cloud-init configs. Either a payload file
@@ -26,6 +26,10 @@ even if only one is being passed. An alternative to using
 -d would be to use -f and passing -, which will cause ochami
 to read the data from standard input.
 
+A single config can also be built with --set <field>=<value>, using the field
+names of the payload (e.g. name or cloud-init.metadata.instance-id). Values
+are parsed as YAML.
+
 This command sends a POST to cloud-init.`,
 	Example: `  ochami cloud-init config add -d \
     '[ \
@@ -46,6 +50,7 @@ This command sends a POST to cloud-init.`,
          } \
        } \
      ]'
+  ochami cloud-init config add --set name=compute --set cloud-init.metadata.instance-id=compute
   ochami cloud-init config add -f payload.json
   ochami cloud-init config add -f payload.yaml --payload-format yaml
   echo '<json_data>' | ochami cloud-init config add -f -
@@ -69,8 +74,8 @@ This command sends a POST to cloud-init.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(cloudInitClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(cloudInitClient.OchamiClient)
 
 		var ciData []citypes.CI
 		if cmd.Flag("payload").Changed {
@@ -88,6 +93,15 @@ This command sends a POST to cloud-init.`,
 				log.Logger.Error().Err(err).Msg("failed to marshal json data")
 				os.Exit(1)
 			}
+		} else {
+			// ...otherwise build a config from --set
+			var c citypes.CI
+			handleSet(cmd, &c)
+			if c.Name == "" {
+				log.Logger.Error().Msg("no config name passed (pass it with --set name=<name>)")
+				os.Exit(1)
+			}
+			ciData = append(ciData, c)
 		}
 
 		// Send off request
@@ -101,9 +115,10 @@ This command sends a POST to cloud-init.`,
 			log.Logger.Error().Err(err).Msg("failed to add cloud-init configs")
 			os.Exit(1)
 		}
+		exitIfInterrupted(errs)
+
 		// Since cloudInitClient.Post* functions do the addition iteratively, we need to deal with
 		// each error that might have occurred.
-		var errorsOccurred = false
 		for _, e := range errs {
 			if e != nil {
 				if errors.Is(err, client.UnsuccessfulHTTPError) {
@@ -111,25 +126,24 @@ This command sends a POST to cloud-init.`,
 				} else {
 					log.Logger.Error().Err(e).Msg("failed to add config(s) to cloud-init")
 				}
-				errorsOccurred = true
 			}
 		}
 		// Warn the user if any errors occurred during addition iterations
-		if errorsOccurred {
-			log.Logger.Warn().Msg("cloud-init config addition completed with errors")
-			os.Exit(1)
-		}
+		exitIfBulkFailed(errs, "cloud-init config addition")
 	},
 }
 
 func init() {
 	cloudInitConfigAddCmd.Flags().StringP("data", "d", "", "raw JSON data to use as payload")
-	cloudInitConfigAddCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	cloudInitConfigAddCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	addSetFlag(cloudInitConfigAddCmd, "the config")
+	cloudInitConfigAddCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	cloudInitConfigAddCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	cloudInitConfigAddCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
 
 	cloudInitConfigAddCmd.MarkFlagsMutuallyExclusive("data", "payload")
 	cloudInitConfigAddCmd.MarkFlagsMutuallyExclusive("data", "payload-format")
-	cloudInitConfigAddCmd.MarkFlagsOneRequired("data", "payload")
+	cloudInitConfigAddCmd.MarkFlagsMutuallyExclusive("set", "data", "payload")
+	cloudInitConfigAddCmd.MarkFlagsOneRequired("data", "payload", "set")
 
 	cloudInitConfigCmd.AddCommand(cloudInitConfigAddCmd)
 }
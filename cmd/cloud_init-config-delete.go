@@ -37,8 +37,8 @@ var cloudInitConfigDeleteCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(cloudInitClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(cloudInitClient.OchamiClient)
 
 		// Ask before attempting deletion unless --force was passed
 		if !cmd.Flag("force").Changed {
@@ -63,9 +63,10 @@ var cloudInitConfigDeleteCmd = &cobra.Command{
 			log.Logger.Error().Err(err).Msg("failed to delete cloud-init configs")
 			os.Exit(1)
 		}
+		exitIfInterrupted(errs)
+
 		// Since cloudInitClient.Delete* functions do the deletion iteratively, we need to deal with
 		// each error that might have occurred.
-		var errorsOccurred = false
 		for _, e := range errs {
 			if e != nil {
 				if errors.Is(e, client.UnsuccessfulHTTPError) {
@@ -73,14 +74,10 @@ var cloudInitConfigDeleteCmd = &cobra.Command{
 				} else {
 					log.Logger.Error().Err(e).Msg("failed to delete config(s) to cloud-init")
 				}
-				errorsOccurred = true
 			}
 		}
 		// Warn the user if any errors occurred during deletion iterations
-		if errorsOccurred {
-			log.Logger.Warn().Msg("cloud-init config deletion completed with errors")
-			os.Exit(1)
-		}
+		exitIfBulkFailed(errs, "cloud-init config deletion")
 	},
 }
 
@@ -0,0 +1,112 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+
+	"github.com/OpenCHAMI/cloud-init/pkg/citypes"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/ci"
+	"github.com/spf13/cobra"
+)
+
+// cloudInitConfigExportCmd represents the cloud-init-config-export command
+var cloudInitConfigExportCmd = &cobra.Command{
+	Use:   "export --dir <dir> [id]...",
+	Short: "Export cloud-init configs to files in a directory",
+	Long: `Export cloud-init configs to files in a directory. If one or more ids are
+passed, only the configs for those ids are exported. Otherwise, all
+configs are exported.
+
+Each config is written to its own subdirectory of the directory passed
+to --dir, named after the config id. Within it, the user-data,
+meta-data, and vendor-data are each written as an editable YAML file
+(user-data.yaml, meta-data.yaml, and vendor-data.yaml). Types of data
+that are empty are not written. The directory can then be edited, kept
+in version control, and uploaded again with 'ochami cloud-init config
+import'.`,
+	Example: `  ochami cloud-init config export --dir ci/
+  ochami cloud-init config export --dir ci/ compute
+  ochami cloud-init config export --secure --dir ci/ compute`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		cloudInitBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for cloud-init")
+			os.Exit(1)
+		}
+
+		// Create client to make request to cloud-init
+		cloudInitClient, err := ci.NewClient(cloudInitBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new cloud-init client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(cloudInitClient.OchamiClient)
+
+		// Fetch the full list of configs instead of each one by id,
+		// since fetching by id merges in data from the groups a node
+		// is a member of and we want the stored config as-is.
+		var henv client.HTTPEnvelope
+		if cloudInitCmd.Flag("secure").Changed {
+			// This endpoint requires authentication, so a token is needed
+			setTokenFromEnvVar(cmd)
+			checkToken(cmd)
+
+			henv, err = cloudInitClient.GetConfigsSecure("", token)
+		} else {
+			henv, err = cloudInitClient.GetConfigs("")
+		}
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msg("cloud-init config request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to request configs from cloud-init")
+			}
+			os.Exit(1)
+		}
+		var ciMap map[string]citypes.CI
+		if err := json.Unmarshal(henv.Body, &ciMap); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to unmarshal cloud-init configs")
+			os.Exit(1)
+		}
+
+		// Select configs to export
+		var ciData []citypes.CI
+		if len(args) > 0 {
+			for _, id := range args {
+				c, ok := ciMap[id]
+				if !ok {
+					log.Logger.Error().Msgf("cloud-init config %s not found", id)
+					os.Exit(1)
+				}
+				ciData = append(ciData, c)
+			}
+		} else {
+			for _, c := range ciMap {
+				ciData = append(ciData, c)
+			}
+			sort.Slice(ciData, func(i, j int) bool { return ciData[i].Name < ciData[j].Name })
+		}
+
+		dir := cmd.Flag("dir").Value.String()
+		if err := ci.WriteDir(dir, ciData); err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to export cloud-init configs to %s", dir)
+			os.Exit(1)
+		}
+		log.Logger.Info().Msgf("exported %d cloud-init config(s) to %s", len(ciData), dir)
+	},
+}
+
+func init() {
+	cloudInitConfigExportCmd.Flags().StringP("dir", "d", "", "directory to export configs to")
+	cloudInitConfigExportCmd.MarkFlagRequired("dir")
+	cloudInitConfigCmd.AddCommand(cloudInitConfigExportCmd)
+}
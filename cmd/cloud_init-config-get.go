@@ -4,7 +4,6 @@ package cmd
 
 import (
 	"errors"
-	"fmt"
 	"os"
 
 	"github.com/OpenCHAMI/ochami/internal/log"
@@ -36,8 +35,8 @@ var cloudInitConfigGetCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(cloudInitClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(cloudInitClient.OchamiClient)
 
 		// Make requests
 		var httpEnv client.HTTPEnvelope
@@ -56,7 +55,7 @@ var cloudInitConfigGetCmd = &cobra.Command{
 		}
 		if err != nil {
 			if errors.Is(err, client.UnsuccessfulHTTPError) {
-				log.Logger.Error().Err(err).Msg("cloud-init config request yielded unsuccessful HTTP response")
+				logHTTPError(err, httpEnv, "cloud-init config request yielded unsuccessful HTTP response")
 			} else {
 				log.Logger.Error().Err(err).Msg("failed to request configs from cloud-init")
 			}
@@ -64,17 +63,7 @@ var cloudInitConfigGetCmd = &cobra.Command{
 		}
 
 		// Format output
-		outFmt, err := cmd.Flags().GetString("output-format")
-		if err != nil {
-			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
-			os.Exit(1)
-		}
-		if outBytes, err := client.FormatBody(httpEnv.Body, outFmt); err != nil {
-			log.Logger.Error().Err(err).Msg("failed to format output")
-			os.Exit(1)
-		} else {
-			fmt.Printf(string(outBytes))
-		}
+		printEnvelope(cmd, httpEnv)
 	},
 }
 
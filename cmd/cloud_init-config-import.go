@@ -0,0 +1,150 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/OpenCHAMI/cloud-init/pkg/citypes"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/ci"
+	"github.com/spf13/cobra"
+)
+
+// cloudInitConfigImportCmd represents the cloud-init-config-import command
+var cloudInitConfigImportCmd = &cobra.Command{
+	Use:   "import --dir <dir> [id]...",
+	Short: "Import cloud-init configs from files in a directory",
+	Long: `Import cloud-init configs from files in a directory. This is the inverse of
+'ochami cloud-init config export'. Each subdirectory of the directory
+passed to --dir is read as a config whose id is the name of the
+subdirectory, and whose user-data, meta-data, and vendor-data are read
+from user-data.yaml, meta-data.yaml, and vendor-data.yaml within it.
+Missing files are treated as empty data. If one or more ids are passed,
+only the subdirectories for those ids are read.
+
+Configs that already exist in cloud-init are replaced and configs that
+do not are created.
+
+This command sends a PUT or POST to cloud-init for each config.`,
+	Example: `  ochami cloud-init config import --dir ci/
+  ochami cloud-init config import --dir ci/ compute
+  ochami cloud-init config import --secure --dir ci/ compute`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := cmd.Flag("dir").Value.String()
+		ciData, err := ci.ReadDir(dir, args...)
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to read cloud-init configs from %s", dir)
+			os.Exit(1)
+		}
+		if len(ciData) == 0 {
+			log.Logger.Warn().Msgf("no cloud-init configs found in %s", dir)
+			os.Exit(0)
+		}
+
+		// Without a base URI, we cannot do anything
+		cloudInitBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for cloud-init")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to cloud-init
+		cloudInitClient, err := ci.NewClient(cloudInitBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new cloud-init client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(cloudInitClient.OchamiClient)
+
+		// Determine which configs already exist so we know whether to
+		// PUT or POST each one
+		secure := cloudInitCmd.Flag("secure").Changed
+		var henv client.HTTPEnvelope
+		if secure {
+			henv, err = cloudInitClient.GetConfigsSecure("", token)
+		} else {
+			henv, err = cloudInitClient.GetConfigs("")
+		}
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msg("cloud-init config request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to request configs from cloud-init")
+			}
+			os.Exit(1)
+		}
+		var ciMap map[string]citypes.CI
+		if err := json.Unmarshal(henv.Body, &ciMap); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to unmarshal cloud-init configs")
+			os.Exit(1)
+		}
+		var toPut, toPost []citypes.CI
+		for _, c := range ciData {
+			if _, ok := ciMap[c.Name]; ok {
+				toPut = append(toPut, c)
+			} else {
+				toPost = append(toPost, c)
+			}
+		}
+
+		// Send off requests
+		var errs []error
+		if len(toPut) > 0 {
+			var putErrs []error
+			if secure {
+				_, putErrs, err = cloudInitClient.PutConfigsSecure(toPut, token)
+			} else {
+				_, putErrs, err = cloudInitClient.PutConfigs(toPut, token)
+			}
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to set cloud-init configs")
+				os.Exit(1)
+			}
+			errs = append(errs, putErrs...)
+		}
+		if len(toPost) > 0 {
+			var postErrs []error
+			if secure {
+				_, postErrs, err = cloudInitClient.PostConfigsSecure(toPost, token)
+			} else {
+				_, postErrs, err = cloudInitClient.PostConfigs(toPost, token)
+			}
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to add cloud-init configs")
+				os.Exit(1)
+			}
+			errs = append(errs, postErrs...)
+		}
+		exitIfInterrupted(errs)
+
+		// Since cloudInitClient.Put* and cloudInitClient.Post* functions
+		// do the requests iteratively, we need to deal with each error
+		// that might have occurred.
+		for _, e := range errs {
+			if e != nil {
+				if errors.Is(e, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(e).Msg("cloud-init config request yielded unsuccessful HTTP response")
+				} else {
+					log.Logger.Error().Err(e).Msg("failed to import config(s) into cloud-init")
+				}
+			}
+		}
+		exitIfBulkFailed(errs, "cloud-init config import")
+	},
+}
+
+func init() {
+	cloudInitConfigImportCmd.Flags().StringP("dir", "d", "", "directory to import configs from")
+	cloudInitConfigImportCmd.MarkFlagRequired("dir")
+	cloudInitConfigCmd.AddCommand(cloudInitConfigImportCmd)
+}
@@ -16,7 +16,7 @@ import (
 
 // cloudInitConfigUpdateCmd represents the cloud-init-config-update command
 var cloudInitConfigUpdateCmd = &cobra.Command{
-	Use:   "update (-f <payload_file> | -d <payload_data>)",
+	Use:   "update (-f <payload_file> | -d <payload_data> | --set <field>=<value>...)",
 	Args:  cobra.NoArgs,
 	Short: "Update cloud-init config for one or more ids, overwriting any previous",
 	Long: `Update cloud-init config for one or more ids. Either a payload file
@@ -26,6 +26,10 @@ one is being passed. An alternative to using -d would be to use -f
 and passing -, which will cause ochami to read the data from
 standard input.
 
+A single config can also be built with --set <field>=<value>, using the field
+names of the payload (e.g. name or cloud-init.metadata.instance-id). Values
+are parsed as YAML.
+
 This command sends a PUT to cloud-init.`,
 	Example: `  ochami cloud-init config update -d \
     '[ \
@@ -43,6 +47,7 @@ This command sends a PUT to cloud-init.`,
          } \
        } \
      ]'
+  ochami cloud-init config update --set name=compute --set cloud-init.metadata.instance-id=compute
   ochami cloud-init config update -f payload.json
   ochami cloud-init config update -f payload.yaml --payload-format yaml
   echo '<json_data>' | ochami cloud-init config update -f -
@@ -66,8 +71,9 @@ This command sends a PUT to cloud-init.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(cloudInitClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(cloudInitClient.OchamiClient)
+		useIfMatch(cmd, cloudInitClient.OchamiClient)
 
 		var ciData []citypes.CI
 		if cmd.Flag("payload").Changed {
@@ -85,6 +91,15 @@ This command sends a PUT to cloud-init.`,
 				log.Logger.Error().Err(err).Msg("failed to marshal json data")
 				os.Exit(1)
 			}
+		} else {
+			// ...otherwise build a config from --set
+			var c citypes.CI
+			handleSet(cmd, &c)
+			if c.Name == "" {
+				log.Logger.Error().Msg("no config name passed (pass it with --set name=<name>)")
+				os.Exit(1)
+			}
+			ciData = append(ciData, c)
 		}
 
 		// Send off request
@@ -98,35 +113,38 @@ This command sends a PUT to cloud-init.`,
 			log.Logger.Error().Err(err).Msg("failed to set cloud-init configs")
 			os.Exit(1)
 		}
+		exitIfInterrupted(errs)
+
 		// Since cloudInitClient.Put* functions do the setting iteratively, we need to deal with
 		// each error that might have occurred.
-		var errorsOccurred = false
 		for _, e := range errs {
 			if e != nil {
-				if errors.Is(e, client.UnsuccessfulHTTPError) {
+				if errors.Is(e, client.PreconditionFailedError) {
+					log.Logger.Error().Err(e).Msg("cloud-init config was modified by someone else since it was read; rerun to update the current version")
+				} else if errors.Is(e, client.UnsuccessfulHTTPError) {
 					log.Logger.Error().Err(e).Msg("cloud-init config request yielded unsuccessful HTTP response")
 				} else {
 					log.Logger.Error().Err(e).Msg("failed to set config(s) in cloud-init")
 				}
-				errorsOccurred = true
 			}
 		}
 		// Warn the user if any errors occurred during editing iterations
-		if errorsOccurred {
-			log.Logger.Warn().Msgf("cloud-init config setting completed with errors")
-			os.Exit(1)
-		}
+		exitIfBulkFailed(errs, "cloud-init config setting")
 	},
 }
 
 func init() {
 	cloudInitConfigUpdateCmd.Flags().StringP("data", "d", "", "raw JSON data to use as payload")
-	cloudInitConfigUpdateCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	cloudInitConfigUpdateCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	cloudInitConfigUpdateCmd.Flags().String("if-match", "", "only write if the resource is unmodified; pass 'auto' to use the ETag from a preceding GET, or an ETag")
+	addSetFlag(cloudInitConfigUpdateCmd, "the config")
+	cloudInitConfigUpdateCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	cloudInitConfigUpdateCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	cloudInitConfigUpdateCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
 
 	cloudInitConfigUpdateCmd.MarkFlagsMutuallyExclusive("data", "payload")
 	cloudInitConfigUpdateCmd.MarkFlagsMutuallyExclusive("data", "payload-format")
-	cloudInitConfigUpdateCmd.MarkFlagsOneRequired("data", "payload")
+	cloudInitConfigUpdateCmd.MarkFlagsMutuallyExclusive("set", "data", "payload")
+	cloudInitConfigUpdateCmd.MarkFlagsOneRequired("data", "payload", "set")
 
 	cloudInitConfigCmd.AddCommand(cloudInitConfigUpdateCmd)
 }
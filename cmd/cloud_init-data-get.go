@@ -50,8 +50,8 @@ or vendor-data, respectively.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(cloudInitClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(cloudInitClient.OchamiClient)
 
 		var (
 			henvs  []client.HTTPEnvelope
@@ -81,35 +81,37 @@ or vendor-data, respectively.`,
 			log.Logger.Error().Err(err).Msgf("failed to get %s from cloud-init", ciType)
 			os.Exit(1)
 		}
-		// Since the cloud-init data get functions do the deletion
+		exitIfInterrupted(errs)
+
+		// Since the cloud-init data get functions do the GETs
 		// iteratively, we need to deal with each error that might have
 		// occurred.
-		var errorsOccurred = false
 		for _, e := range errs {
-			if err != nil {
+			if e != nil {
 				if errors.Is(e, client.UnsuccessfulHTTPError) {
 					log.Logger.Error().Err(e).Msgf("cloud-init %s get yielded unsuccessful HTTP response", ciType)
-				} else if e != nil {
+				} else {
 					log.Logger.Error().Err(e).Msgf("failed to get %s", ciType)
 				}
-				errorsOccurred = true
 			}
 		}
-		// Warn the user if any errors occurred during deletion iterations
-		if errorsOccurred {
-			log.Logger.Warn().Msgf("cloud-init %s get completed with errors", ciType)
-			os.Exit(1)
-		}
 
 		// Print output
 		for hidx, henv := range henvs {
+			if hidx < len(errs) && errs[hidx] != nil {
+				continue
+			}
 			if hidx >= len(args) {
 				log.Logger.Warn().Msgf("unknown cloud-init %s data found", ciType)
 			} else {
 				log.Logger.Info().Msgf("printing cloud-init %s for %s", ciType, args[hidx])
 			}
-			fmt.Printf(string(henv.Body))
+			fmt.Print(string(henv.Body))
 		}
+
+		// Warn the user if any errors occurred during the GETs, after
+		// printing the data that was found
+		exitIfBulkFailed(errs, fmt.Sprintf("cloud-init %s get", ciType))
 	},
 }
 
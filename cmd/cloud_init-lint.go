@@ -0,0 +1,131 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/cloudconfig"
+	"github.com/spf13/cobra"
+)
+
+// lintResult is the result of linting one piece of cloud-config data, as
+// printed with --output-format.
+type lintResult struct {
+	Source   string                `json:"source"`
+	Problems []cloudconfig.Problem `json:"problems"`
+}
+
+// cloudInitLintCmd represents the cloud-init-lint command
+var cloudInitLintCmd = &cobra.Command{
+	Use:   "lint -d (<data> | @<path> | @-)...",
+	Args:  cobra.NoArgs,
+	Short: "Check cloud-config data locally before uploading it",
+	Long: `Check cloud-config data locally before uploading it, catching errors that
+would otherwise only appear on the first boot of a node. The data is passed
+with -d, either as is, as @<path> to read it from a file, or as @- to read it
+from standard input. -d can be passed several times.
+
+The following is checked:
+
+  - the first line is #cloud-config (or the second line, after
+    "## template: jinja")
+  - the data is valid YAML, e.g. without tabs in indentation or duplicate
+    keys
+  - the top level is a mapping whose keys are known to cloud-init
+  - list-valued keys (e.g. runcmd or write_files) are lists, and
+    write_files entries have a path
+
+Unknown keys are warnings, since cloud-init ignores them; everything else is
+an error. Scripts (starting with #!) are not checked. The exit status is 2 if
+errors are found, or if warnings are found and --strict is passed.
+
+No requests are sent.`,
+	Example: `  ochami cloud-init lint -d @user-data.yaml
+  ochami cloud-init lint -d @compute.yaml -d @io.yaml --strict
+  ochami cloud-init lint -d @user-data.yaml -F json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := cmd.Flags().GetStringArray("data")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --data")
+			os.Exit(1)
+		}
+
+		var results []lintResult
+		for i, d := range data {
+			source := fmt.Sprintf("data %d", i+1)
+			text := []byte(d)
+			if path, ok := strings.CutPrefix(d, "@"); ok {
+				source = path
+				if path == "-" {
+					source = "<stdin>"
+					text, err = io.ReadAll(os.Stdin)
+				} else {
+					text, err = os.ReadFile(path)
+				}
+				if err != nil {
+					log.Logger.Error().Err(err).Msgf("failed to read %s", source)
+					os.Exit(1)
+				}
+			}
+			results = append(results, lintResult{Source: source, Problems: cloudconfig.Lint(text)})
+		}
+
+		// Print output
+		errCount, warnCount := 0, 0
+		for _, r := range results {
+			for _, p := range r.Problems {
+				if p.Severity == cloudconfig.SeverityError {
+					errCount++
+				} else {
+					warnCount++
+				}
+			}
+		}
+		if cmd.Flag("output-format").Changed {
+			outFmt, err := cmd.Flags().GetString("output-format")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+				os.Exit(1)
+			}
+			resultBytes, err := json.Marshal(results)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to marshal lint results")
+				os.Exit(1)
+			}
+			if outBytes, err := client.FormatBody(resultBytes, outFmt); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to format output")
+				os.Exit(1)
+			} else {
+				fmt.Print(string(outBytes))
+			}
+		} else {
+			for _, r := range results {
+				for _, p := range r.Problems {
+					fmt.Printf("%s:%s\n", r.Source, p)
+				}
+			}
+			fmt.Printf("%d errors, %d warnings\n", errCount, warnCount)
+		}
+
+		if errCount > 0 || (warnCount > 0 && cmd.Flag("strict").Changed) {
+			os.Exit(2)
+		}
+	},
+}
+
+func init() {
+	cloudInitLintCmd.Flags().StringArrayP("data", "d", []string{}, "cloud-config data to check, or @<path> to read it from a file (@- for standard input)")
+	cloudInitLintCmd.Flags().Bool("strict", false, "exit with status 2 on warnings as well")
+	cloudInitLintCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+
+	_ = cloudInitLintCmd.MarkFlagRequired("data")
+
+	cloudInitCmd.AddCommand(cloudInitLintCmd)
+}
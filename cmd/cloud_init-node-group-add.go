@@ -0,0 +1,101 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/ci"
+	"github.com/spf13/cobra"
+)
+
+// cloudInitNodeGroupAddCmd represents the cloud-init-node-group-add command
+var cloudInitNodeGroupAddCmd = &cobra.Command{
+	Use:   "add <id> <group>...",
+	Args:  cobra.MinimumNArgs(2),
+	Short: "Attach a node to one or more cloud-init groups",
+	Long: `Attach a node to one or more cloud-init groups by adding it to the SMD groups
+of the same name. The groups must exist in SMD. A warning is printed for each
+group that has no cloud-init config, since attaching the node to it does not
+change the node's cloud-init data until one is added.`,
+	Example: `  ochami cloud-init node group add x3000c1s7b56n0 compute
+  ochami cloud-init node group add x3000c1s7b56n0 compute gpu`,
+	Run: func(cmd *cobra.Command, args []string) {
+		node, groups := args[0], args[1:]
+		smdClient := nodeGroupSMDClient(cmd)
+
+		// SMD rejects adding a component to a group if it is already in
+		// another group with the same exclusive group, so catch this
+		// beforehand and point to 'smd group member move'
+		conflicting := false
+		for _, group := range groups {
+			conflicts, err := smdClient.FindExclusiveConflicts(group, []string{node}, token)
+			if err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msgf("SMD group request for group %s yielded unsuccessful HTTP response", group)
+				} else {
+					log.Logger.Error().Err(err).Msgf("failed to check exclusive group membership for group %s", group)
+				}
+				os.Exit(1)
+			}
+			if peer, ok := conflicts[node]; ok {
+				log.Logger.Error().Msgf("%s is already a member of group %s, which shares an exclusive group with %s; use 'ochami smd group member move %s %s %s' instead", node, peer, group, peer, group, node)
+				conflicting = true
+			}
+		}
+		if conflicting {
+			os.Exit(1)
+		}
+
+		warnGroupsWithoutConfig(cmd, groups)
+
+		// Send off request
+		_, errs, err := smdClient.AddToGroups(token, node, groups...)
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to add %s to groups in SMD", node)
+			os.Exit(1)
+		}
+		exitIfInterrupted(errs)
+		logNodeGroupErrors("failed to add "+node+" to", groups, errs)
+		exitIfBulkFailed(errs, "cloud-init node group addition")
+	},
+}
+
+// warnGroupsWithoutConfig warns about each of groups that has no cloud-init
+// config. Errors other than the config not being found are only logged at
+// debug level, since the check is merely advisory.
+func warnGroupsWithoutConfig(cmd *cobra.Command, groups []string) {
+	baseURI, err := getBaseURI(cmd)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to get base URI for cloud-init")
+		os.Exit(1)
+	}
+	cloudInitClient, err := ci.NewClient(baseURI, insecure)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("error creating new cloud-init client")
+		os.Exit(1)
+	}
+	configureClient(cloudInitClient.OchamiClient)
+
+	for _, group := range groups {
+		var henv client.HTTPEnvelope
+		if cloudInitCmd.Flag("secure").Changed {
+			henv, err = cloudInitClient.GetConfigsSecure(group, token)
+		} else {
+			henv, err = cloudInitClient.GetConfigs(group)
+		}
+		if errors.Is(err, client.UnsuccessfulHTTPError) && henv.StatusCode == http.StatusNotFound {
+			log.Logger.Warn().Msgf("cloud-init has no config for group %s; add one with 'ochami cloud-init config add' for it to take effect", group)
+		} else if err != nil {
+			log.Logger.Debug().Err(err).Msgf("failed to check cloud-init config for group %s", group)
+		}
+	}
+}
+
+func init() {
+	cloudInitNodeGroupCmd.AddCommand(cloudInitNodeGroupAddCmd)
+}
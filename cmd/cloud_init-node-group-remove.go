@@ -0,0 +1,39 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/spf13/cobra"
+)
+
+// cloudInitNodeGroupRemoveCmd represents the cloud-init-node-group-remove command
+var cloudInitNodeGroupRemoveCmd = &cobra.Command{
+	Use:   "remove <id> <group>...",
+	Args:  cobra.MinimumNArgs(2),
+	Short: "Detach a node from one or more cloud-init groups",
+	Long: `Detach a node from one or more cloud-init groups by removing it from the SMD
+groups of the same name. The cloud-init configs of the groups are left as they
+are.`,
+	Example: `  ochami cloud-init node group remove x3000c1s7b56n0 gpu`,
+	Run: func(cmd *cobra.Command, args []string) {
+		node, groups := args[0], args[1:]
+		smdClient := nodeGroupSMDClient(cmd)
+
+		// Send off request
+		_, errs, err := smdClient.RemoveFromGroups(token, node, groups...)
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to remove %s from groups in SMD", node)
+			os.Exit(1)
+		}
+		exitIfInterrupted(errs)
+		logNodeGroupErrors("failed to remove "+node+" from", groups, errs)
+		exitIfBulkFailed(errs, "cloud-init node group removal")
+	},
+}
+
+func init() {
+	cloudInitNodeGroupCmd.AddCommand(cloudInitNodeGroupRemoveCmd)
+}
@@ -0,0 +1,86 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// cloudInitNodeGroupCmd represents the cloud-init-node-group command
+var cloudInitNodeGroupCmd = &cobra.Command{
+	Use:   "group",
+	Args:  cobra.NoArgs,
+	Short: "Attach nodes to and detach them from cloud-init groups",
+	Long: `Attach nodes to and detach them from cloud-init groups. This is a
+metacommand.
+
+The cloud-init service does not keep group membership itself. Instead, it
+looks up the SMD groups a node is a member of and merges the cloud-init
+configs named after them, in order, before the node's own config. Commands
+under this one therefore manage the membership of the node in those SMD
+groups, so that attaching a node to a cloud-init group does not require
+knowing how cloud-init resolves it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+	},
+}
+
+// nodeGroupSMDClient returns an SMD client for managing the cloud-init group
+// membership of nodes, which requires a token.
+func nodeGroupSMDClient(cmd *cobra.Command) *smd.SMDClient {
+	// Without a base URI, we cannot do anything
+	smdBaseURI, err := getBaseURI(cmd)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+		os.Exit(1)
+	}
+
+	// This endpoint requires authentication, so a token is needed
+	setTokenFromEnvVar(cmd)
+	checkToken(cmd)
+
+	// Create client to make request to SMD
+	smdClient, err := smd.NewClient(smdBaseURI, insecure)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("error creating new SMD client")
+		os.Exit(1)
+	}
+
+	// Configure client for the cluster (CA certificates, tenant, etc.)
+	configureClient(smdClient.OchamiClient)
+
+	return smdClient
+}
+
+// logNodeGroupErrors logs the per-group errors of adding a node to or
+// removing it from groups. failed describes the failure, e.g. "failed to add
+// x3000c1s7b56n0 to", and is followed by the group.
+func logNodeGroupErrors(failed string, groups []string, errs []error) {
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, client.UnsuccessfulHTTPError) {
+			log.Logger.Error().Err(err).Msgf("SMD group member request for group %s yielded unsuccessful HTTP response", groups[i])
+		} else {
+			log.Logger.Error().Err(err).Msgf("%s group %s", failed, groups[i])
+		}
+	}
+}
+
+func init() {
+	cloudInitNodeCmd.AddCommand(cloudInitNodeGroupCmd)
+}
@@ -0,0 +1,33 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/spf13/cobra"
+)
+
+// cloudInitNodeCmd represents the cloud-init-node command
+var cloudInitNodeCmd = &cobra.Command{
+	Use:   "node",
+	Args:  cobra.NoArgs,
+	Short: "Manage how nodes get their cloud-init data",
+	Long: `Manage how nodes get their cloud-init data. This is a metacommand. Commands
+under this one deal with the nodes that cloud-init serves data to.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+	},
+}
+
+func init() {
+	cloudInitCmd.AddCommand(cloudInitNodeCmd)
+}
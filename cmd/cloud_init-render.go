@@ -0,0 +1,308 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/ci"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// defaultRenderWidth is the width of side-by-side output if neither --width
+// nor the COLUMNS environment variable is set.
+const defaultRenderWidth = 160
+
+// cloudInitRenderCmd represents the cloud-init-render command
+var cloudInitRenderCmd = &cobra.Command{
+	Use:   "render [--user | --meta | --vendor] [--group <label> [--sample <n>]] [<id>...]",
+	Short: "Render cloud-init data of several nodes side by side",
+	Long: `Render cloud-init data of several nodes side by side to spot unintended
+per-node differences. By default, user-data is rendered. --meta or --vendor
+can be passed to render meta-data or vendor-data instead.
+
+The nodes are passed as arguments, taken from the members of the SMD group
+passed with --group, or both. With --group, --sample picks that many
+members, spread evenly over the group's members sorted by xname, so that
+repeated runs compare the same nodes. The data of all nodes is fetched
+concurrently.
+
+The data is printed in columns, one per node, with lines compared by
+position. Lines that are not the same for all nodes are marked with !.
+Pass --diff-only to only print those lines. If --output-format is passed,
+the data of each node is printed in that format instead, keyed by node.
+If --exit-code is passed, the exit status is 2 if the data differs.`,
+	Example: `  ochami cloud-init render --group compute --sample 3
+  ochami cloud-init render --meta x3000c1s7b56n0 x3000c1s7b57n0
+  ochami cloud-init render --group compute --sample 0 --diff-only --exit-code
+  ochami cloud-init render --group compute -F yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 && !cmd.Flag("group").Changed {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+
+		// Without a base URI, we cannot do anything
+		baseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI")
+			os.Exit(1)
+		}
+
+		ids := slices.Clone(args)
+		if cmd.Flag("group").Changed {
+			// This endpoint requires authentication, so a token is needed
+			setTokenFromEnvVar(cmd)
+			checkToken(cmd)
+
+			smdClient, err := smd.NewClient(baseURI, insecure)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("error creating new SMD client")
+				os.Exit(1)
+			}
+			configureClient(smdClient.OchamiClient)
+
+			label := cmd.Flag("group").Value.String()
+			group, err := smdClient.GetGroup(label, token)
+			if err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msg("SMD group request yielded unsuccessful HTTP response")
+				} else {
+					log.Logger.Error().Err(err).Msgf("failed to get group %s", label)
+				}
+				os.Exit(1)
+			}
+			sample, err := cmd.Flags().GetInt("sample")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get value for --sample")
+				os.Exit(1)
+			}
+			if sample < 0 {
+				log.Logger.Error().Msgf("invalid --sample %d: must not be negative", sample)
+				os.Exit(1)
+			}
+			members := sampleMembers(group.Members.IDs, sample)
+			if len(members) == 0 {
+				log.Logger.Error().Msgf("group %s has no members", label)
+				os.Exit(1)
+			}
+			log.Logger.Info().Msgf("rendering %d of %d members of group %s", len(members), len(group.Members.IDs), label)
+			for _, m := range members {
+				if !slices.Contains(ids, m) {
+					ids = append(ids, m)
+				}
+			}
+		}
+
+		// Create client to make request to cloud-init
+		cloudInitClient, err := ci.NewClient(baseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new cloud-init client")
+			os.Exit(1)
+		}
+		configureClient(cloudInitClient.OchamiClient)
+
+		ciType := ci.CloudInitUserData
+		if cmd.Flag("meta").Changed {
+			ciType = ci.CloudInitMetaData
+		} else if cmd.Flag("vendor").Changed {
+			ciType = ci.CloudInitVendorData
+		}
+
+		var (
+			henvs []client.HTTPEnvelope
+			errs  []error
+		)
+		if cloudInitCmd.Flag("secure").Changed {
+			// This endpoint requires authentication, so a token is needed
+			setTokenFromEnvVar(cmd)
+			checkToken(cmd)
+
+			henvs, errs, err = cloudInitClient.GetCloudInitDataSecure(ciType, ids, token)
+		} else {
+			henvs, errs, err = cloudInitClient.GetCloudInitData(ciType, ids)
+		}
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to get %s from cloud-init", ciType)
+			os.Exit(1)
+		}
+		exitIfInterrupted(errs)
+
+		var errorsOccurred = false
+		for i, e := range errs {
+			if e == nil {
+				continue
+			}
+			if errors.Is(e, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(e).Msgf("cloud-init %s get yielded unsuccessful HTTP response for %s", ciType, ids[i])
+			} else {
+				log.Logger.Error().Err(e).Msgf("failed to get %s for %s", ciType, ids[i])
+			}
+			errorsOccurred = true
+		}
+		if errorsOccurred {
+			log.Logger.Warn().Msgf("cloud-init %s render completed with errors", ciType)
+			os.Exit(1)
+		}
+
+		data := make([]string, len(ids))
+		for i := range ids {
+			data[i] = string(henvs[i].Body)
+		}
+		differs := slices.ContainsFunc(data, func(d string) bool { return d != data[0] })
+
+		// Print output
+		if cmd.Flag("output-format").Changed {
+			outFmt, err := cmd.Flags().GetString("output-format")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+				os.Exit(1)
+			}
+			byID := make(map[string]string, len(ids))
+			for i, id := range ids {
+				byID[id] = data[i]
+			}
+			dataBytes, err := json.Marshal(byID)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to marshal cloud-init data")
+				os.Exit(1)
+			}
+			if outBytes, err := client.FormatBody(dataBytes, outFmt); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to format output")
+				os.Exit(1)
+			} else {
+				fmt.Print(string(outBytes))
+			}
+		} else {
+			width, err := cmd.Flags().GetInt("width")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get value for --width")
+				os.Exit(1)
+			}
+			if width <= 0 {
+				width = defaultRenderWidth
+				if c, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && c > 0 {
+					width = c
+				}
+			}
+			writeSideBySide(os.Stdout, ids, data, width, cmd.Flag("diff-only").Changed)
+		}
+
+		if cmd.Flag("exit-code").Changed && differs {
+			os.Exit(2)
+		}
+	},
+}
+
+// sampleMembers returns n of members, sorted, spread evenly over the sorted
+// members. If n is 0 or at least the number of members, all are returned.
+func sampleMembers(members []string, n int) []string {
+	sorted := slices.Clone(members)
+	slices.Sort(sorted)
+	if n == 0 || n >= len(sorted) {
+		return sorted
+	}
+	sample := make([]string, n)
+	for i := range sample {
+		sample[i] = sorted[i*len(sorted)/n]
+	}
+	return sample
+}
+
+// writeSideBySide writes data, the text of each of ids, to w in columns that
+// fit in width, comparing lines by position. Lines that differ between
+// columns are marked with "!". If diffOnly is true, only those are written.
+func writeSideBySide(w io.Writer, ids, data []string, width int, diffOnly bool) {
+	const (
+		marker = 2 // width of the "! " marker
+		sep    = " | "
+	)
+	colWidth := (width - marker - len(sep)*(len(ids)-1)) / len(ids)
+	if colWidth < 10 {
+		colWidth = 10
+	}
+
+	lines := make([][]string, len(data))
+	rows := 0
+	for i, d := range data {
+		lines[i] = strings.Split(strings.TrimRight(d, "\n"), "\n")
+		rows = max(rows, len(lines[i]))
+	}
+
+	writeRow := func(mark string, cells []string) {
+		for i := range cells {
+			cells[i] = fitColumn(cells[i], colWidth)
+		}
+		fmt.Fprintln(w, strings.TrimRight(mark+strings.Join(cells, sep), " "))
+	}
+	writeRow("  ", slices.Clone(ids))
+	dashes := make([]string, len(ids))
+	for i := range dashes {
+		dashes[i] = strings.Repeat("-", colWidth)
+	}
+	writeRow("  ", dashes)
+
+	differing := 0
+	for r := 0; r < rows; r++ {
+		cells := make([]string, len(lines))
+		for i, l := range lines {
+			if r < len(l) {
+				cells[i] = l[r]
+			}
+		}
+		same := !slices.ContainsFunc(cells, func(c string) bool { return c != cells[0] })
+		if !same {
+			differing++
+		}
+		switch {
+		case !same:
+			writeRow("! ", cells)
+		case !diffOnly:
+			writeRow("  ", cells)
+		}
+	}
+	fmt.Fprintf(w, "\n%d of %d lines differ\n", differing, rows)
+}
+
+// fitColumn pads or truncates s to width runes, expanding tabs and marking
+// truncation with "~".
+func fitColumn(s string, width int) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	if n := utf8.RuneCountInString(s); n <= width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return string([]rune(s)[:width-1]) + "~"
+}
+
+func init() {
+	cloudInitRenderCmd.Flags().Bool("user", false, "render user-data")
+	cloudInitRenderCmd.Flags().Bool("meta", false, "render meta-data")
+	cloudInitRenderCmd.Flags().Bool("vendor", false, "render vendor-data")
+	cloudInitRenderCmd.Flags().String("group", "", "render members of this SMD group")
+	cloudInitRenderCmd.Flags().Int("sample", 3, "number of group members to render (0 for all)")
+	cloudInitRenderCmd.Flags().Bool("diff-only", false, "only print lines that differ between nodes")
+	cloudInitRenderCmd.Flags().Int("width", 0, "width of side-by-side output (default $COLUMNS, or 160 if unset)")
+	cloudInitRenderCmd.Flags().Bool("exit-code", false, "exit with status 2 if the data differs between nodes")
+	cloudInitRenderCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+
+	cloudInitRenderCmd.MarkFlagsMutuallyExclusive("user", "meta", "vendor")
+
+	setCommandServices(cloudInitRenderCmd, "cloud-init", "smd")
+
+	cloudInitCmd.AddCommand(cloudInitRenderCmd)
+}
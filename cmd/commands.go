@@ -0,0 +1,290 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/internal/color"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/internal/version"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Annotations holding the structured metadata of commands that is included in
+// the command catalog (see commandsCmd).
+const (
+	// servicesAnnotation holds the comma-separated names of the services a
+	// command sends requests to. If unset, it is the service whose command
+	// the command is under, if any and if the command has no subcommands (see
+	// commandServices).
+	servicesAnnotation = "ochami_services"
+
+	// mutatingAnnotation is "true" if a command sends requests that change
+	// data in services. If unset, it is derived from the command's name (see
+	// commandMutating).
+	mutatingAnnotation = "ochami_mutating"
+)
+
+// Annotations cobra sets on flags in groups (see
+// cobra.Command.MarkFlagsMutuallyExclusive and friends), which it does not
+// export.
+const (
+	cobraMutuallyExclusiveAnnotation = "cobra_annotation_mutually_exclusive"
+	cobraRequiredTogetherAnnotation  = "cobra_annotation_required_if_others_set"
+	cobraOneRequiredAnnotation       = "cobra_annotation_one_required"
+)
+
+// serviceCommands maps the names of the top-level commands of services to the
+// names of the services.
+var serviceCommands = map[string]string{
+	"bss":        "bss",
+	"cloud-init": "cloud-init",
+	"pcs":        "pcs",
+	"smd":        "smd",
+}
+
+// mutatingVerbs are the names of commands that change data in the services
+// they send requests to, unless annotated otherwise.
+var mutatingVerbs = []string{"add", "delete", "import", "move", "reconcile", "remove", "rename", "set", "set-state", "update"}
+
+// setCommandServices records that cmd sends requests to services, overriding
+// the services derived from where cmd is in the command tree.
+func setCommandServices(cmd *cobra.Command, services ...string) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[servicesAnnotation] = strings.Join(services, ",")
+}
+
+// setCommandMutating records whether cmd changes data in services, overriding
+// what is derived from its name.
+func setCommandMutating(cmd *cobra.Command, mutating bool) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[mutatingAnnotation] = fmt.Sprint(mutating)
+}
+
+// commandServices returns the names of the services cmd sends requests to.
+func commandServices(cmd *cobra.Command) []string {
+	if s, ok := cmd.Annotations[servicesAnnotation]; ok {
+		if s == "" {
+			return []string{}
+		}
+		return strings.Split(s, ",")
+	}
+	if svc, ok := serviceCommands[serviceOfCommand(cmd)]; ok && !cmd.HasAvailableSubCommands() {
+		return []string{svc}
+	}
+	return []string{}
+}
+
+// commandMutating reports whether cmd changes data in services.
+func commandMutating(cmd *cobra.Command) bool {
+	if m, ok := cmd.Annotations[mutatingAnnotation]; ok {
+		return m == "true"
+	}
+	return len(commandServices(cmd)) > 0 && slices.Contains(mutatingVerbs, cmd.Name())
+}
+
+// catalogFlag describes a flag in the command catalog.
+type catalogFlag struct {
+	Name         string       `json:"name"`
+	Shorthand    string       `json:"shorthand,omitempty"`
+	Type         string       `json:"type"`
+	Default      string       `json:"default"`
+	Usage        string       `json:"usage"`
+	Required     bool         `json:"required"`
+	Persistent   bool         `json:"persistent"`
+	Deprecated   *deprecation `json:"deprecated,omitempty"`
+	Experimental string       `json:"experimental,omitempty"`
+}
+
+// catalogFlagGroups describes the groups of flags of a command in the command
+// catalog.
+type catalogFlagGroups struct {
+	MutuallyExclusive [][]string `json:"mutuallyExclusive,omitempty"`
+	RequiredTogether  [][]string `json:"requiredTogether,omitempty"`
+	OneRequired       [][]string `json:"oneRequired,omitempty"`
+}
+
+// catalogCommand describes a command in the command catalog.
+type catalogCommand struct {
+	Path         string            `json:"path"`
+	Name         string            `json:"name"`
+	Aliases      []string          `json:"aliases,omitempty"`
+	Use          string            `json:"use"`
+	Short        string            `json:"short"`
+	Long         string            `json:"long,omitempty"`
+	Example      string            `json:"example,omitempty"`
+	Runnable     bool              `json:"runnable"`
+	Services     []string          `json:"services"`
+	Mutating     bool              `json:"mutating"`
+	Deprecated   *deprecation      `json:"deprecated,omitempty"`
+	Experimental string            `json:"experimental,omitempty"`
+	Flags        []catalogFlag     `json:"flags"`
+	FlagGroups   catalogFlagGroups `json:"flagGroups"`
+	Subcommands  []catalogCommand  `json:"subcommands,omitempty"`
+}
+
+// catalog is the command catalog printed by commandsCmd with --json.
+type catalog struct {
+	Program     string           `json:"program"`
+	Version     string           `json:"version"`
+	GlobalFlags []catalogFlag    `json:"globalFlags"`
+	Commands    []catalogCommand `json:"commands"`
+}
+
+// catalogFlags returns the flags of fs, except cobra's help flag and hidden
+// flags that are not deprecated, sorted by name. They are marked persistent if
+// persistent is true.
+func catalogFlags(fs *pflag.FlagSet, persistent bool) []catalogFlag {
+	flags := []catalogFlag{}
+	fs.VisitAll(func(f *pflag.Flag) {
+		d, deprecated := flagDeprecation(f)
+		if (f.Hidden && !deprecated) || f.Name == "help" {
+			return
+		}
+		req := f.Annotations[cobra.BashCompOneRequiredFlag]
+		flags = append(flags, catalogFlag{
+			Name:         f.Name,
+			Shorthand:    f.Shorthand,
+			Type:         f.Value.Type(),
+			Default:      f.DefValue,
+			Usage:        f.Usage,
+			Required:     len(req) > 0 && req[0] == "true",
+			Persistent:   persistent,
+			Experimental: flagFeature(f),
+		})
+		if deprecated {
+			flags[len(flags)-1].Deprecated = &d
+		}
+	})
+	return flags
+}
+
+// catalogFlagGroupsOf returns the groups of flags of cmd.
+func catalogFlagGroupsOf(cmd *cobra.Command) catalogFlagGroups {
+	var groups catalogFlagGroups
+	seen := map[string]bool{}
+	cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		for annotation, dst := range map[string]*[][]string{
+			cobraMutuallyExclusiveAnnotation: &groups.MutuallyExclusive,
+			cobraRequiredTogetherAnnotation:  &groups.RequiredTogether,
+			cobraOneRequiredAnnotation:       &groups.OneRequired,
+		} {
+			for _, g := range f.Annotations[annotation] {
+				if key := annotation + g; !seen[key] {
+					seen[key] = true
+					*dst = append(*dst, strings.Fields(g))
+				}
+			}
+		}
+	})
+	for _, g := range [][][]string{groups.MutuallyExclusive, groups.RequiredTogether, groups.OneRequired} {
+		sort.Slice(g, func(i, j int) bool { return strings.Join(g[i], " ") < strings.Join(g[j], " ") })
+	}
+	return groups
+}
+
+// catalogCommandOf returns the description of cmd and its available
+// subcommands for the command catalog.
+func catalogCommandOf(cmd *cobra.Command) catalogCommand {
+	cc := catalogCommand{
+		Path:         cmd.CommandPath(),
+		Name:         cmd.Name(),
+		Aliases:      cmd.Aliases,
+		Use:          cmd.Use,
+		Short:        cmd.Short,
+		Long:         cmd.Long,
+		Example:      cmd.Example,
+		Runnable:     cmd.Runnable(),
+		Services:     commandServices(cmd),
+		Mutating:     commandMutating(cmd),
+		Flags:        append(catalogFlags(cmd.LocalNonPersistentFlags(), false), catalogFlags(cmd.PersistentFlags(), true)...),
+		FlagGroups:   catalogFlagGroupsOf(cmd),
+		Experimental: cmd.Annotations[experimentalAnnotation],
+	}
+	if d, ok := commandDeprecation(cmd); ok {
+		cc.Deprecated = &d
+	}
+	for _, c := range cmd.Commands() {
+		if c.IsAvailableCommand() {
+			cc.Subcommands = append(cc.Subcommands, catalogCommandOf(c))
+		}
+	}
+	return cc
+}
+
+// commandsCmd represents the commands command
+var commandsCmd = &cobra.Command{
+	Use:   "commands [--json]",
+	Args:  cobra.NoArgs,
+	Short: "List all commands",
+	Long: `List all commands with a short description of each. Hidden commands are
+omitted. Deprecated and experimental commands are marked as such.
+
+With --json, the full command catalog is printed as JSON instead, e.g. to
+build wrappers or documentation. For each command, it holds its path, name,
+aliases, usage, descriptions, and examples, the services it sends requests to,
+whether it changes data in them, whether it is deprecated or experimental,
+its flags (with their types, defaults, and whether they are required), the
+groups of flags that are mutually exclusive, required together, or of which one
+is required, and its subcommands. The global flags are listed once at the top.`,
+	Example: `  ochami commands
+  ochami commands --json | jq '.. | objects | select(.mutating? == true) | .path'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		root := cmd.Root()
+		var top []catalogCommand
+		for _, c := range root.Commands() {
+			if c.IsAvailableCommand() {
+				top = append(top, catalogCommandOf(c))
+			}
+		}
+
+		if !cmd.Flag("json").Changed {
+			tw := color.NewTable(os.Stdout)
+			var walk func(ccs []catalogCommand)
+			walk = func(ccs []catalogCommand) {
+				for _, cc := range ccs {
+					if cc.Runnable {
+						fmt.Fprintf(tw, "%s\t%s\n", cc.Path, cc.Short)
+					}
+					walk(cc.Subcommands)
+				}
+			}
+			walk(top)
+			if err := tw.Flush(); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print commands")
+				os.Exit(1)
+			}
+			return
+		}
+
+		cat := catalog{
+			Program:     root.Name(),
+			Version:     version.Version,
+			GlobalFlags: catalogFlags(root.PersistentFlags(), true),
+			Commands:    top,
+		}
+		catBytes, err := json.MarshalIndent(cat, "", "  ")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to marshal command catalog")
+			os.Exit(1)
+		}
+		fmt.Println(string(catBytes))
+	},
+}
+
+func init() {
+	commandsCmd.Flags().Bool("json", false, "print the full command catalog as JSON")
+
+	rootCmd.AddCommand(commandsCmd)
+}
@@ -0,0 +1,176 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/snapshot"
+	"github.com/spf13/cobra"
+)
+
+// compareResources maps the resources that can be compared between clusters,
+// as passed to --resources, to the kinds of snapshotted resources they are
+// fetched as.
+var compareResources = []struct {
+	name string
+	kind string
+}{
+	{"smd.components", snapshot.KindComponents},
+	{"smd.groups", snapshot.KindGroups},
+	{"smd.ifaces", snapshot.KindEthernetInterfaces},
+	{"smd.rfes", snapshot.KindRedfishEndpoints},
+	{"bss.bootparams", snapshot.KindBootParams},
+}
+
+// compareResourceNames returns the names of the resources in compareResources.
+func compareResourceNames() []string {
+	var names []string
+	for _, r := range compareResources {
+		names = append(names, r.name)
+	}
+	return names
+}
+
+// compareClustersCmd represents the compare-clusters command
+var compareClustersCmd = &cobra.Command{
+	Use:   "clusters [--resources <resource>,...] [--exit-code] <cluster_a> <cluster_b>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Compare SMD and BSS data of two clusters",
+	Long: `Compare SMD and BSS data of two clusters, e.g. to check that a staging
+cluster matches production before a maintenance window. Both clusters must
+be configured in the config file. The following resources can be compared,
+all of them by default:
+
+  - smd.components
+  - smd.groups
+  - smd.ifaces
+  - smd.rfes
+  - bss.bootparams
+
+Pass --resources to only compare some of them. The resources that only
+exist in the first cluster are listed as removed, those that only exist in
+the second as added, and those that differ as modified, along with the
+fields that differ.
+
+The access token of each cluster is read from its <CLUSTER>_ACCESS_TOKEN
+environment variable or its token-source unless --token is passed, in
+which case it is used for both clusters.
+
+The differences are printed as text unless --output-format is passed, in
+which case they are printed in that format. If --exit-code is passed, the
+exit status is 2 if there are differences.`,
+	Example: `  ochami compare clusters staging prod
+  ochami compare clusters staging prod --resources smd.groups,bss.bootparams
+  ochami compare clusters staging prod --exit-code -F json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		names, err := cmd.Flags().GetStringSlice("resources")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --resources")
+			os.Exit(1)
+		}
+		var kinds []string
+		for _, name := range names {
+			found := false
+			for _, r := range compareResources {
+				if r.name == name {
+					kinds = append(kinds, r.kind)
+					found = true
+					break
+				}
+			}
+			if !found {
+				log.Logger.Error().Msgf("unknown resource %q, must be one of %v", name, compareResourceNames())
+				os.Exit(1)
+			}
+		}
+
+		from := fetchClusterSnapshot(cmd, args[0], kinds)
+		to := fetchClusterSnapshot(cmd, args[1], kinds)
+		report := snapshot.Diff(from, to)
+
+		// Print output
+		if cmd.Flag("output-format").Changed {
+			outFmt, err := cmd.Flags().GetString("output-format")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+				os.Exit(1)
+			}
+			reportBytes, err := json.Marshal(report)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to marshal cluster comparison")
+				os.Exit(1)
+			}
+			if outBytes, err := client.FormatBody(reportBytes, outFmt); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to format output")
+				os.Exit(1)
+			} else {
+				fmt.Print(string(outBytes))
+			}
+		} else {
+			writeSnapshotDiff(os.Stdout, report)
+		}
+
+		if cmd.Flag("exit-code").Changed && !report.Empty() {
+			os.Exit(2)
+		}
+	},
+}
+
+// fetchClusterSnapshot fetches the resources of kinds from the configured
+// cluster named name and returns them as a snapshot named after the cluster.
+// The access token is the value of --token, if passed, or else that of the
+// cluster (see clusterToken). If an error occurs, a log is
+// printed and the program exits.
+func fetchClusterSnapshot(cmd *cobra.Command, name string, kinds []string) *snapshot.Snapshot {
+	cluster := lookupCluster(name)
+	if cluster == nil {
+		log.Logger.Error().Msgf("cluster %s not found", name)
+		os.Exit(1)
+	}
+	uri, err := clusterBaseURI(cluster)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to get base URI")
+		os.Exit(1)
+	}
+
+	tok := token
+	if !cmd.Flag("token").Changed {
+		t, source, err := clusterToken(name)
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to get token for cluster %q", name)
+			os.Exit(1)
+		}
+		log.Logger.Debug().Msgf("Token found from %s: %s", source, client.Redact(t))
+		tok = t
+	}
+	if _, err := validateToken(tok); err != nil {
+		log.Logger.Error().Err(err).Msgf("token for cluster %s is invalid", name)
+		os.Exit(1)
+	}
+
+	snap := snapshot.New(name, uri)
+	snap.Name = name
+	for _, kind := range kinds {
+		log.Logger.Debug().Msgf("fetching %s from cluster %s", kind, name)
+		if err := snap.AddResponse(kind, fetchClusterResources(cluster, uri, tok, kind)); err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to read %s of cluster %s", kind, name)
+			os.Exit(1)
+		}
+	}
+	return snap
+}
+
+func init() {
+	compareClustersCmd.Flags().Bool("exit-code", false, "exit with status 2 if there are differences")
+	compareClustersCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	compareClustersCmd.Flags().StringSlice("resources", compareResourceNames(), "resources to compare")
+
+	setCommandServices(compareClustersCmd, "bss", "smd")
+
+	compareCmd.AddCommand(compareClustersCmd)
+}
@@ -0,0 +1,236 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/internal/config"
+	oio "github.com/OpenCHAMI/ochami/internal/io"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Lines delimiting the block added to shell rc files by 'completion
+	// install' so that it is only ever added once.
+	completionRCBegin = "# >>> " + config.ProgName + " completion >>>"
+	completionRCEnd   = "# <<< " + config.ProgName + " completion <<<"
+)
+
+// completionInstallCmd represents the completion-install command
+var completionInstallCmd = &cobra.Command{
+	Use:   "install [--shell <shell>] [--dir <dir>] [--no-rc] [--force]",
+	Args:  cobra.NoArgs,
+	Short: "Install the autocompletion script for the current shell",
+	Long: `Install the autocompletion script for the current shell. The shell is detected
+from the SHELL environment variable unless --shell is passed. Supported
+shells are bash, fish, and zsh.
+
+The script is written to the location the shell loads completions from
+for the current user:
+
+  bash  $XDG_DATA_HOME/bash-completion/completions/ochami
+        (requires the bash-completion package)
+  fish  $XDG_CONFIG_HOME/fish/completions/ochami.fish
+  zsh   $ZDOTDIR/.zsh/completions/_ochami
+
+For zsh, the completions directory must also be in fpath, so a block
+adding it is appended to $ZDOTDIR/.zshrc unless it is already there or
+--no-rc is passed. bash and fish load completions from the above
+directories by themselves, so their rc files are not changed.
+
+Unless --force is passed, the user is asked to confirm before any file is
+written.`,
+	Example: `  ochami completion install
+  ochami completion install --shell zsh --no-rc
+  ochami completion install --shell fish --force`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shell := cmd.Flag("shell").Value.String()
+		if shell == "" {
+			shell = filepath.Base(os.Getenv("SHELL"))
+			if shell == "." || shell == "/" {
+				log.Logger.Error().Msg("unable to detect shell from SHELL environment variable, pass --shell")
+				os.Exit(1)
+			}
+			log.Logger.Debug().Msgf("detected shell: %s", shell)
+		}
+
+		// Generate the completion script for the shell
+		var script bytes.Buffer
+		var err error
+		switch shell {
+		case "bash":
+			err = rootCmd.GenBashCompletionV2(&script, true)
+		case "fish":
+			err = rootCmd.GenFishCompletion(&script, true)
+		case "zsh":
+			err = rootCmd.GenZshCompletion(&script)
+		default:
+			log.Logger.Error().Msgf("unsupported shell %q, must be one of bash, fish, or zsh", shell)
+			os.Exit(1)
+		}
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to generate %s completion script", shell)
+			os.Exit(1)
+		}
+
+		// Determine where the script and, if needed, the rc file go
+		home, err := os.UserHomeDir()
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to determine home directory")
+			os.Exit(1)
+		}
+		scriptDir, scriptName, rcFile := completionPaths(shell, home)
+		if cmd.Flag("dir").Changed {
+			scriptDir = cmd.Flag("dir").Value.String()
+		}
+		scriptPath := filepath.Join(scriptDir, scriptName)
+		var rcBlock string
+		if rcFile != "" && !cmd.Flag("no-rc").Changed {
+			rcBlock = completionRCBlock(shell, scriptDir)
+			if hasRCBlock(rcFile) {
+				log.Logger.Info().Msgf("%s already sets up %s completion, leaving it alone", rcFile, config.ProgName)
+				rcBlock = ""
+			}
+		}
+
+		// Ask before writing anything unless --force was passed
+		if !cmd.Flag("force").Changed {
+			fmt.Fprintf(os.Stderr, "The %s completion script will be written to:\n  %s\n", shell, scriptPath)
+			if rcBlock != "" {
+				fmt.Fprintf(os.Stderr, "The following will be appended to %s:\n%s", rcFile, rcBlock)
+			}
+			if !loopYesNo("Proceed?") {
+				log.Logger.Info().Msg("User aborted completion installation")
+				os.Exit(0)
+			}
+		}
+
+		if err := os.MkdirAll(scriptDir, 0755); err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to create directory %s", scriptDir)
+			os.Exit(1)
+		}
+		if err := oio.WriteFileAtomic(scriptPath, script.Bytes(), false, 0644); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to write completion script")
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", scriptPath)
+
+		if rcBlock != "" {
+			if err := oio.WriteFileAtomic(rcFile, []byte(rcBlock), true, 0644); err != nil {
+				log.Logger.Error().Err(err).Msgf("failed to update %s", rcFile)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Updated %s\n", rcFile)
+		}
+		fmt.Fprintf(os.Stderr, "Start a new %s session for completions to take effect.\n", shell)
+	},
+}
+
+// completionPaths returns the directory and file name that shell loads the
+// completion script from for the user whose home directory is home, as well
+// as the rc file that has to be changed for shell to find the directory. If no
+// rc file needs changing, rcFile is empty.
+func completionPaths(shell, home string) (dir, name, rcFile string) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	zdotdir := os.Getenv("ZDOTDIR")
+	if zdotdir == "" {
+		zdotdir = home
+	}
+
+	switch shell {
+	case "bash":
+		return filepath.Join(dataHome, "bash-completion", "completions"), config.ProgName, ""
+	case "fish":
+		return filepath.Join(configHome, "fish", "completions"), config.ProgName + ".fish", ""
+	case "zsh":
+		return filepath.Join(zdotdir, ".zsh", "completions"), "_" + config.ProgName, filepath.Join(zdotdir, ".zshrc")
+	}
+	return "", "", ""
+}
+
+// completionRCBlock returns the block to append to the rc file of shell so that
+// completions in dir are loaded.
+func completionRCBlock(shell, dir string) string {
+	var b strings.Builder
+	b.WriteString("\n" + completionRCBegin + "\n")
+	switch shell {
+	case "zsh":
+		fmt.Fprintf(&b, "fpath=(%q $fpath)\n", dir)
+		b.WriteString("autoload -Uz compinit && compinit\n")
+	}
+	b.WriteString(completionRCEnd + "\n")
+	return b.String()
+}
+
+// hasRCBlock reports whether rcFile already contains the block added by
+// completionRCBlock. A missing or unreadable file is treated as not containing
+// it.
+func hasRCBlock(rcFile string) bool {
+	data, err := os.ReadFile(rcFile)
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(data, []byte(completionRCBegin))
+}
+
+// initCompletionCmd adds cobra's default 'completion' command to the root
+// command, which would otherwise only be added when the root command is
+// executed, adds the install subcommand to it, and registers the completion
+// of the format flags of all commands.
+func initCompletionCmd() {
+	registerFormatCompletions(rootCmd)
+	rootCmd.InitDefaultCompletionCmd()
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == "completion" {
+			c.AddCommand(completionInstallCmd)
+			return
+		}
+	}
+}
+
+// formatFlags are the flags completed with formats by
+// registerFormatCompletions, and the formats they are completed with.
+var formatFlags = map[string][]string{
+	"output-format":  outputFormats,
+	"payload-format": dataFormats,
+	"format-output":  outputFormats,
+	"format-input":   dataFormats,
+}
+
+// registerFormatCompletions registers the completion of the format flags of
+// cmd and its subcommands with the supported data formats, unless a command
+// registered its own (e.g. because it supports fewer formats).
+func registerFormatCompletions(cmd *cobra.Command) {
+	for name, formats := range formatFlags {
+		if cmd.Flags().Lookup(name) == nil {
+			continue
+		}
+		if _, ok := cmd.GetFlagCompletionFunc(name); ok {
+			continue
+		}
+		_ = cmd.RegisterFlagCompletionFunc(name, cobra.FixedCompletions(formats, cobra.ShellCompDirectiveNoFileComp))
+	}
+	for _, c := range cmd.Commands() {
+		registerFormatCompletions(c)
+	}
+}
+
+func init() {
+	completionInstallCmd.Flags().String("shell", "", "shell to install completion for (bash,fish,zsh); detected from SHELL if unset")
+	completionInstallCmd.Flags().String("dir", "", "directory to write completion script to instead of the shell's default")
+	completionInstallCmd.Flags().Bool("no-rc", false, "do not modify shell rc files")
+	completionInstallCmd.Flags().Bool("force", false, "do not ask before writing files")
+}
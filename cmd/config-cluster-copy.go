@@ -0,0 +1,122 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/internal/config"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/spf13/cobra"
+)
+
+// configClusterCopyCmd represents the config-cluster-copy command
+var configClusterCopyCmd = &cobra.Command{
+	Use:   "copy [--user | --system] [--set <key>=<value>]... [--default] <src_cluster> <dst_cluster>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Create a cluster from a copy of an existing one",
+	Long: `Create a cluster from a copy of an existing one. The configuration of
+src_cluster is copied to a new cluster named dst_cluster, with the keys passed
+with --set changed. For example:
+
+	ochami config cluster copy prod staging --set cluster.base-uri=https://staging.example.com
+
+Keys are relative to the cluster's entry in the 'clusters' list, e.g.
+cluster.base-uri or cluster.defaults.format-output. Values are parsed as YAML,
+so lists can be passed in flow style, e.g. --set 'cluster.pin-sha256=[a, b]'.
+
+dst_cluster must not exist already. Use 'ochami config cluster set' to modify
+an existing cluster.`,
+	Example: `  ochami config cluster copy prod staging --set cluster.base-uri=https://staging.example.com
+  ochami config cluster copy --default prod staging --set cluster.base-uri=https://staging.example.com`,
+	Run: func(cmd *cobra.Command, args []string) {
+		srcName, dstName := args[0], args[1]
+
+		overrides, err := clusterOverrides(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("invalid --set")
+			os.Exit(1)
+		}
+
+		// We must have a config file in order to write cluster info
+		var fileToModify string
+		if rootCmd.PersistentFlags().Lookup("config").Changed {
+			if fileToModify, err = rootCmd.PersistentFlags().GetString("config"); err != nil {
+				log.Logger.Error().Err(err).Msgf("unable to get value from --config flag")
+				os.Exit(1)
+			}
+		} else if configCmd.PersistentFlags().Lookup("system").Changed {
+			fileToModify = config.SystemConfigFile
+		} else {
+			fileToModify = config.UserConfigFile
+		}
+
+		// Read in config from file
+		cfg, err := config.ReadConfig(fileToModify)
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to read config from %s", fileToModify)
+			os.Exit(1)
+		}
+
+		src, _ := config.GetCluster(cfg.Clusters, srcName)
+		if src == nil {
+			log.Logger.Error().Msgf("cluster %s not found in config file %s", srcName, fileToModify)
+			os.Exit(1)
+		}
+		if dst, _ := config.GetCluster(cfg.Clusters, dstName); dst != nil {
+			log.Logger.Error().Msgf("cluster %s already exists in config file %s", dstName, fileToModify)
+			os.Exit(1)
+		}
+
+		newCluster, err := config.CopyCluster(*src, dstName, overrides)
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to copy cluster %s", srcName)
+			os.Exit(1)
+		}
+		cfg.Clusters = append(cfg.Clusters, newCluster)
+		log.Logger.Info().Msgf("copied cluster %s to new cluster %s", srcName, dstName)
+
+		// If --default was passed, make this cluster the default one
+		if cmd.Flag("default").Changed {
+			cfg.DefaultCluster = dstName
+			log.Logger.Info().Msgf("cluster %s set as default-cluster since --default passed", dstName)
+		}
+
+		// Write out modified config to the config file
+		// WARNING: This will rewrite the whole config file so modifications like
+		// comments will get erased.
+		if err := config.WriteConfig(fileToModify, cfg); err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to write modified config to %s", fileToModify)
+			os.Exit(1)
+		}
+	},
+}
+
+// clusterOverrides returns the key=value pairs passed to --set of cmd as a map
+// of keys to values.
+func clusterOverrides(cmd *cobra.Command) (map[string]string, error) {
+	sets, err := cmd.Flags().GetStringArray("set")
+	if err != nil {
+		return nil, err
+	}
+	overrides := make(map[string]string, len(sets))
+	for _, s := range sets {
+		key, value, found := strings.Cut(s, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("expected <key>=<value>, got %q", s)
+		}
+		if _, dup := overrides[key]; dup {
+			return nil, fmt.Errorf("key %s passed more than once", key)
+		}
+		overrides[key] = value
+	}
+	return overrides, nil
+}
+
+func init() {
+	configClusterCopyCmd.Flags().StringArray("set", []string{}, "set key (e.g. cluster.base-uri) of the new cluster to value (<key>=<value>)")
+	configClusterCopyCmd.Flags().BoolP("default", "d", false, "set the new cluster as the default")
+	configClusterCmd.AddCommand(configClusterCopyCmd)
+}
@@ -0,0 +1,135 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/internal/config"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+// configClusterPinCmd represents the config-cluster-pin command
+var configClusterPinCmd = &cobra.Command{
+	Use:   "pin [--user | --system] [--add] [--force] <cluster_name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Record the TLS certificate fingerprint of a cluster",
+	Long: `Record the TLS certificate fingerprint of a cluster. The certificate
+presented by the host in the cluster's base URI is fetched, its public
+key fingerprint is printed along with some details of the certificate,
+and, after confirmation, the fingerprint is stored in the cluster's
+pin-sha256 list. For example:
+
+	- name: foobar
+	  cluster:
+	    base-uri: https://foobar.openchami.cluster
+	    pin-sha256:
+	      - 47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=
+
+Once a cluster has pins, connections to it are only accepted if the
+server certificate's public key matches one of them. Unless --cacert is
+also passed, the pin is checked instead of verifying the certificate
+against a certificate authority, so self-signed certificates can be
+used. By default, the new fingerprint replaces any existing pins. Pass
+--add to keep them, e.g. while a certificate is being rotated.
+
+Since the certificate is fetched without being verified, make sure the
+fingerprint is the expected one before confirming.`,
+	Example: `  ochami config cluster pin foobar
+  ochami config cluster pin --add foobar
+  ochami config cluster pin --force foobar`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// We must have a config file in order to write cluster info
+		var fileToModify string
+		if rootCmd.PersistentFlags().Lookup("config").Changed {
+			var err error
+			if fileToModify, err = rootCmd.PersistentFlags().GetString("config"); err != nil {
+				log.Logger.Error().Err(err).Msgf("unable to get value from --config flag")
+				os.Exit(1)
+			}
+		} else if configCmd.PersistentFlags().Lookup("system").Changed {
+			fileToModify = config.SystemConfigFile
+		} else {
+			fileToModify = config.UserConfigFile
+		}
+
+		// Read in config from file
+		cfg, err := config.ReadConfig(fileToModify)
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to read config from %s", fileToModify)
+			os.Exit(1)
+		}
+
+		// Find cluster to pin
+		clusterName := args[0]
+		clusterIdx := -1
+		for idx, cluster := range cfg.Clusters {
+			if cluster.Name == clusterName {
+				clusterIdx = idx
+				break
+			}
+		}
+		if clusterIdx == -1 {
+			log.Logger.Error().Msgf("cluster %s not found in config file %s", clusterName, fileToModify)
+			os.Exit(1)
+		}
+		clusterURI := cfg.Clusters[clusterIdx].Cluster.BaseURI
+		if clusterURI == "" {
+			log.Logger.Error().Msgf("base-uri not set for cluster %s", clusterName)
+			os.Exit(1)
+		}
+
+		// Fetch and show certificate
+		cert, err := client.FetchServerCert(clusterURI)
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to fetch certificate for cluster %s", clusterName)
+			os.Exit(1)
+		}
+		pin := client.SPKIFingerprint(cert)
+		fmt.Fprintf(os.Stderr, "Certificate presented by %s:\n", clusterURI)
+		fmt.Fprintf(os.Stderr, "  Subject:     %s\n", cert.Subject)
+		fmt.Fprintf(os.Stderr, "  Issuer:      %s\n", cert.Issuer)
+		fmt.Fprintf(os.Stderr, "  Not after:   %s\n", cert.NotAfter.Local().Format(time.RFC1123))
+		fmt.Fprintf(os.Stderr, "  Fingerprint: sha256/%s\n", pin)
+
+		if !cmd.Flag("force").Changed {
+			if !loopYesNo(fmt.Sprintf("Pin this certificate for cluster %s?", clusterName)) {
+				log.Logger.Info().Msg("User aborted pinning certificate")
+				os.Exit(0)
+			}
+		}
+
+		// Record pin
+		pins := cfg.Clusters[clusterIdx].Cluster.PinSHA256
+		if cmd.Flag("add").Changed {
+			if slices.Contains(pins, pin) {
+				log.Logger.Info().Msgf("fingerprint already pinned for cluster %s", clusterName)
+				os.Exit(0)
+			}
+			pins = append(pins, pin)
+		} else {
+			pins = []string{pin}
+		}
+		cfg.Clusters[clusterIdx].Cluster.PinSHA256 = pins
+
+		// Write out modified config to the config file
+		// WARNING: This will rewrite the whole config file so modifications like
+		// comments will get erased.
+		if err := config.WriteConfig(fileToModify, cfg); err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to write modified config to %s", fileToModify)
+			os.Exit(1)
+		}
+		log.Logger.Info().Msgf("pinned certificate fingerprint %s for cluster %s", pin, clusterName)
+	},
+}
+
+func init() {
+	configClusterPinCmd.Flags().Bool("add", false, "add fingerprint to existing pins instead of replacing them")
+	configClusterPinCmd.Flags().Bool("force", false, "do not ask before recording fingerprint")
+	configClusterCmd.AddCommand(configClusterPinCmd)
+}
@@ -13,7 +13,7 @@ import (
 
 // configClusterSetCmd represents the config-cluster-set command
 var configClusterSetCmd = &cobra.Command{
-	Use:   "set [--user | --system] <cluster_name>",
+	Use:   "set [--user | --system] [--from-template <template>] [--set <key>=<value>]... <cluster_name>",
 	Short: "Add or set parameters for a cluster",
 	Long: `Add cluster with its configuration or set the configuration for
 an existing cluster. For example:
@@ -33,8 +33,19 @@ If this is the first cluster created, the following is also set:
 default-cluster is used to determine which cluster in the list should be used for subcommands.
 
 This same command can be use to modify existing cluster information. Running the same command above
-with a different base URL will change the base URL for the 'foobar' cluster.`,
-	Example: `  ochami config cluster set foobar.openchami.cluster --base-uri https://foobar.openchami.cluster`,
+with a different base URL will change the base URL for the 'foobar' cluster.
+
+--format-output and --format-input set the formats that commands use for the
+cluster when --output-format or --payload-format are not passed, under the
+cluster's 'defaults' key. Any other key of the cluster's entry can be set with
+--set <key>=<value>, e.g. --set cluster.defaults.log.level=debug.
+
+A new cluster can be created from a template with --from-template <name>.
+Templates are defined like clusters, under the 'cluster-templates' key, and
+are never used directly. The other flags are applied on top of the template.`,
+	Example: `  ochami config cluster set foobar.openchami.cluster --base-uri https://foobar.openchami.cluster
+  ochami config cluster set foobar.openchami.cluster --format-output yaml
+  ochami config cluster set --from-template site staging --base-uri https://staging.openchami.cluster`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Check that cluster name is only arg
 		if len(args) == 0 {
@@ -66,10 +77,10 @@ with a different base URL will change the base URL for the 'foobar' cluster.`,
 		// Ask user to create file if it does not exist
 		if err := AskToCreate(fileToModify); err != nil {
 			if errors.Is(err, UserDeclinedError) {
-				log.Logger.Info().Msgf("user declined creating config file %s, exiting")
+				log.Logger.Info().Msgf("user declined creating config file %s, exiting", fileToModify)
 				os.Exit(0)
 			} else {
-				log.Logger.Error().Err(err).Msgf("failed to create %s")
+				log.Logger.Error().Err(err).Msgf("failed to create %s", fileToModify)
 				os.Exit(1)
 			}
 		}
@@ -98,6 +109,19 @@ with a different base URL will change the base URL for the 'foobar' cluster.`,
 			newCluster := config.ConfigCluster{
 				Name: clusterName,
 			}
+			if cmd.Flag("from-template").Changed {
+				tmplName := cmd.Flag("from-template").Value.String()
+				tmpl, _ := config.GetCluster(cfg.ClusterTemplates, tmplName)
+				if tmpl == nil {
+					log.Logger.Error().Msgf("cluster template %s not found in config file %s", tmplName, fileToModify)
+					os.Exit(1)
+				}
+				if newCluster, err = config.CopyCluster(*tmpl, clusterName, nil); err != nil {
+					log.Logger.Error().Err(err).Msgf("failed to create cluster from template %s", tmplName)
+					os.Exit(1)
+				}
+				log.Logger.Debug().Msgf("using cluster template %s", tmplName)
+			}
 			if clusterUrl != "" {
 				newCluster.Cluster.BaseURI = clusterUrl
 				log.Logger.Debug().Msgf("using base-uri %s", clusterUrl)
@@ -111,9 +135,14 @@ with a different base URL will change the base URL for the 'foobar' cluster.`,
 
 			// Add new cluster to list
 			cfg.Clusters = append(cfg.Clusters, newCluster)
+			clusterIdx = len(cfg.Clusters) - 1
 			log.Logger.Info().Msgf("added new cluster: %s", clusterName)
 		} else {
 			// Cluster exists, modify it
+			if cmd.Flag("from-template").Changed {
+				log.Logger.Error().Msgf("cluster %s already exists, --from-template can only be used to create a cluster", clusterName)
+				os.Exit(1)
+			}
 			if clusterUrl != "" {
 				cfg.Clusters[clusterIdx].Cluster.BaseURI = clusterUrl
 				log.Logger.Debug().Msgf("updating base-uri for cluster %s: %s", clusterName, clusterUrl)
@@ -121,6 +150,29 @@ with a different base URL will change the base URL for the 'foobar' cluster.`,
 			log.Logger.Info().Msgf("modified config for existing cluster: %s", clusterName)
 		}
 
+		// Set the cluster's default formats, if passed
+		if cmd.Flag("format-output").Changed {
+			cfg.Clusters[clusterIdx].Cluster.Defaults.FormatOutput = cmd.Flag("format-output").Value.String()
+			log.Logger.Debug().Msgf("setting default output format for cluster %s: %s", clusterName, cfg.Clusters[clusterIdx].Cluster.Defaults.FormatOutput)
+		}
+		if cmd.Flag("format-input").Changed {
+			cfg.Clusters[clusterIdx].Cluster.Defaults.FormatInput = cmd.Flag("format-input").Value.String()
+			log.Logger.Debug().Msgf("setting default input format for cluster %s: %s", clusterName, cfg.Clusters[clusterIdx].Cluster.Defaults.FormatInput)
+		}
+
+		// Set any other keys passed with --set
+		overrides, err := clusterOverrides(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("invalid --set")
+			os.Exit(1)
+		}
+		if len(overrides) > 0 {
+			if cfg.Clusters[clusterIdx], err = config.CopyCluster(cfg.Clusters[clusterIdx], clusterName, overrides); err != nil {
+				log.Logger.Error().Err(err).Msgf("failed to set keys for cluster %s", clusterName)
+				os.Exit(1)
+			}
+		}
+
 		// If --default was passed, make this cluster the default one
 		if cmd.Flag("default").Changed {
 			cfg.DefaultCluster = clusterName
@@ -140,5 +192,9 @@ with a different base URL will change the base URL for the 'foobar' cluster.`,
 func init() {
 	configClusterSetCmd.Flags().StringP("base-uri", "u", "", "base URL of cluster")
 	configClusterSetCmd.Flags().BoolP("default", "d", false, "set cluster as the default")
+	configClusterSetCmd.Flags().String("format-output", "", "default output format for the cluster (json,json-pretty,yaml,toml)")
+	configClusterSetCmd.Flags().String("format-input", "", "default payload format for the cluster (json,yaml,toml)")
+	configClusterSetCmd.Flags().String("from-template", "", "create the cluster from the cluster template with this name")
+	configClusterSetCmd.Flags().StringArray("set", []string{}, "set key (e.g. cluster.base-uri) of the cluster to value (<key>=<value>)")
 	configClusterCmd.AddCommand(configClusterSetCmd)
 }
@@ -0,0 +1,83 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/config"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/spf13/cobra"
+)
+
+// configMigrateCmd represents the config-migrate command
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate [--user | --system | --config <path>] [--dry-run]",
+	Args:  cobra.NoArgs,
+	Short: "Migrate ochami CLI configuration to the latest layout",
+	Long: `Migrate ochami CLI configuration to the latest layout. By default, this
+command migrates the user config file, which also occurs if --user is passed.
+If --system is passed, this command migrates the system configuration file. If
+--config is passed instead, this command migrates the file at the path
+specified.
+
+Config files with an older layout are migrated automatically when loaded, but
+this command can be used to migrate files that ochami cannot write to (e.g.
+the system config file when not running as root) or, with --dry-run, to preview
+the changes that would be made without making them. The original file is saved
+next to it with a .bak extension before it is replaced.
+
+See ochami-config(5) for details on config file versions.`,
+	Example: `  ochami config migrate --dry-run
+  ochami config migrate
+  sudo ochami config migrate --system
+  ochami --config ./test.yaml config migrate`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var fileToMigrate string
+		if rootCmd.PersistentFlags().Lookup("config").Changed {
+			var err error
+			if fileToMigrate, err = rootCmd.PersistentFlags().GetString("config"); err != nil {
+				log.Logger.Error().Err(err).Msgf("unable to get value from --config flag")
+				os.Exit(1)
+			}
+		} else if configCmd.PersistentFlags().Lookup("system").Changed {
+			fileToMigrate = config.SystemConfigFile
+		} else {
+			fileToMigrate = config.UserConfigFile
+		}
+		if config.IsRemote(fileToMigrate) {
+			log.Logger.Error().Msgf("cannot migrate remote config file %s, it must be migrated where it is served from", fileToMigrate)
+			os.Exit(1)
+		}
+
+		dryRun := cmd.Flag("dry-run").Changed
+		changes, backup, err := config.MigrateFile(fileToMigrate, dryRun)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to migrate config file")
+			os.Exit(1)
+		}
+		if len(changes) == 0 {
+			fmt.Printf("%s is up to date (config-version %d)\n", fileToMigrate, config.ConfigVersion)
+			return
+		}
+
+		if dryRun {
+			fmt.Printf("Would migrate %s to config-version %d:\n", fileToMigrate, config.ConfigVersion)
+		} else {
+			fmt.Printf("Migrated %s to config-version %d:\n", fileToMigrate, config.ConfigVersion)
+		}
+		for _, c := range changes {
+			fmt.Printf("  - %s\n", c)
+		}
+		if backup != "" {
+			fmt.Printf("Original saved to %s\n", backup)
+		}
+	},
+}
+
+func init() {
+	configMigrateCmd.Flags().Bool("dry-run", false, "show changes that would be made without making them")
+
+	configCmd.AddCommand(configMigrateCmd)
+}
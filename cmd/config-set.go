@@ -37,7 +37,7 @@ This command does not handle cluster configs. For that, use the
 			}
 			os.Exit(0)
 		} else if len(args) != 2 {
-			log.Logger.Error().Msgf("expected 2 arguments (key, value) but got %s: %v", len(args), args)
+			log.Logger.Error().Msgf("expected 2 arguments (key, value) but got %d: %v", len(args), args)
 			os.Exit(1)
 		}
 
@@ -58,10 +58,10 @@ This command does not handle cluster configs. For that, use the
 		// Ask user to create file if it does not exist
 		if err := AskToCreate(fileToModify); err != nil {
 			if errors.Is(err, UserDeclinedError) {
-				log.Logger.Info().Msgf("user declined creating config file %s, exiting")
+				log.Logger.Info().Msgf("user declined creating config file %s, exiting", fileToModify)
 				os.Exit(0)
 			} else {
-				log.Logger.Error().Err(err).Msgf("failed to create %s")
+				log.Logger.Error().Err(err).Msgf("failed to create %s", fileToModify)
 				os.Exit(1)
 			}
 		}
@@ -36,7 +36,7 @@ This command does not handle cluster configs. For that, use the
 			}
 			os.Exit(0)
 		} else if len(args) != 1 {
-			log.Logger.Error().Msgf("expected 1 argument (key) but got %s: %v", len(args), args)
+			log.Logger.Error().Msgf("expected 1 argument (key) but got %d: %v", len(args), args)
 			os.Exit(1)
 		}
 
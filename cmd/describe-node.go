@@ -0,0 +1,268 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/OpenCHAMI/ochami/internal/color"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/bss"
+	"github.com/OpenCHAMI/ochami/pkg/client/ci"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/OpenCHAMI/ochami/pkg/describe"
+	"github.com/spf13/cobra"
+)
+
+// describeNodeCmd represents the describe-node command
+var describeNodeCmd = &cobra.Command{
+	Use:   "node <xname|mac|nid>...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Show everything OpenCHAMI services know about one or more nodes",
+	Long: `Show everything OpenCHAMI services know about one or more nodes:
+
+  - its component, ethernet interfaces, component endpoint, group
+    memberships, and the redfish endpoint of its BMC (SMD)
+  - its boot parameters (BSS)
+  - its cloud-init config (cloud-init)
+
+Nodes can be identified by their xnames, the MAC addresses of their ethernet
+interfaces, or their NIDs, which can be mixed. Each identifier is classified
+automatically and resolved to the xname of its component using SMD. It is an
+error for an identifier to be unknown or to refer to more than one component,
+e.g. a MAC address claimed by interfaces of different components.
+
+The records are fetched concurrently. By default, a summary of each node is
+printed as a table. If --output-format is passed, all records are printed in
+that format instead, as one document (a list of nodes if more than one node is
+described). Records that a service does not have are left out. If a service
+cannot be reached, the rest of the node is still described, and the exit
+status is 1.
+
+An access token is required.`,
+	Example: `  ochami describe node x1000c1s7b0n0
+  ochami describe node 12 x1000c1s7b0n0 de:ad:be:ef:00:01
+  ochami describe node x1000c1s7b0n0 -F yaml
+  ochami --cluster foobar describe node x1000c1s7b0n0 --no-cloud-init`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		baseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI")
+			os.Exit(1)
+		}
+
+		// These endpoints require authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		d := describe.Describer{Token: token}
+		smdClient, err := smd.NewClient(baseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+		configureClient(smdClient.OchamiClient)
+		d.SMD = smdClient
+		if !cmd.Flag("no-bss").Changed {
+			bssClient, err := bss.NewClient(baseURI, insecure)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("error creating new BSS client")
+				os.Exit(1)
+			}
+			configureClient(bssClient.OchamiClient)
+			d.BSS = bssClient
+		}
+		if !cmd.Flag("no-cloud-init").Changed {
+			cloudInitClient, err := ci.NewClient(baseURI, insecure)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("error creating new cloud-init client")
+				os.Exit(1)
+			}
+			configureClient(cloudInitClient.OchamiClient)
+			d.CloudInit = cloudInitClient
+			d.CloudInitSecure = cmd.Flag("cloud-init-secure").Changed
+		}
+
+		var (
+			nodes  []describe.Node
+			failed bool
+		)
+		for _, x := range uniqueStrings(xnamesFromIdentifiers(smdClient, args)) {
+			node, err := d.Node(x)
+			if err != nil {
+				log.Logger.Error().Err(err).Msgf("failed to describe node %s", x)
+				os.Exit(1)
+			}
+			for _, section := range sortedKeys(node.Errors) {
+				log.Logger.Error().Msgf("failed to get %s of %s: %s", strings.ReplaceAll(section, "_", " "), x, node.Errors[section])
+			}
+			failed = failed || len(node.Errors) > 0
+			nodes = append(nodes, node)
+		}
+
+		if cmd.Flag("output-format").Changed {
+			outFmt, err := cmd.Flags().GetString("output-format")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+				os.Exit(1)
+			}
+			var out any = nodes
+			if len(nodes) == 1 {
+				out = nodes[0]
+			}
+			nodeBytes, err := json.Marshal(out)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to marshal nodes")
+				os.Exit(1)
+			}
+			if outBytes, err := client.FormatBody(nodeBytes, outFmt); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to format output")
+				os.Exit(1)
+			} else {
+				fmt.Print(string(outBytes))
+			}
+		} else {
+			for i, node := range nodes {
+				if i > 0 {
+					fmt.Println()
+				}
+				if err := writeNodeTable(os.Stdout, node); err != nil {
+					log.Logger.Error().Err(err).Msg("failed to print node")
+					os.Exit(1)
+				}
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// writeNodeTable writes a summary of node to w as a two-column table of fields
+// and their values. Fields that have no record are shown as "-".
+func writeNodeTable(w io.Writer, node describe.Node) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	row := func(field string, value string) {
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", field, value)
+	}
+
+	row("XNAME", node.Xname)
+	if node.Component == nil {
+		row("COMPONENT", color.Stdout.Yellow("not in SMD"))
+	} else {
+		for _, f := range []string{"Type", "State", "Flag", "Enabled", "Role", "SubRole", "NID", "Arch"} {
+			row(strings.ToUpper(f), fieldString(node.Component, f))
+		}
+	}
+	row("GROUPS", strings.Join(node.Groups, ","))
+	row("PARTITION", node.Partition)
+	if len(node.EthernetInterfaces) == 0 {
+		row("INTERFACE", "")
+	}
+	for _, ei := range node.EthernetInterfaces {
+		var ips []string
+		if list, ok := ei["IPAddresses"].([]any); ok {
+			for _, v := range list {
+				if ip, ok := v.(map[string]any); ok {
+					ips = append(ips, fieldString(ip, "IPAddress"))
+				}
+			}
+		}
+		iface := fieldString(ei, "MACAddress")
+		if len(ips) > 0 {
+			iface += " (" + strings.Join(ips, ",") + ")"
+		}
+		row("INTERFACE", iface)
+	}
+	row("REDFISH URL", fieldString(node.ComponentEndpoint, "RedfishURL"))
+	if node.RedfishEndpoint != nil {
+		bmc := fieldString(node.RedfishEndpoint, "ID")
+		if addr := firstField(node.RedfishEndpoint, "FQDN", "IPAddress", "Hostname"); addr != "" {
+			bmc += " (" + addr + ")"
+		}
+		row("BMC", bmc)
+	} else {
+		row("BMC", "")
+	}
+	if len(node.BootParams) == 0 {
+		row("KERNEL", "")
+	}
+	for _, bp := range node.BootParams {
+		row("KERNEL", fieldString(bp, "kernel"))
+		row("INITRD", fieldString(bp, "initrd"))
+		row("PARAMS", fieldString(bp, "params"))
+	}
+	if node.CloudInit != nil {
+		var kinds []string
+		if data, ok := node.CloudInit["cloud-init"].(map[string]any); ok {
+			for _, k := range sortedKeys(data) {
+				if v, ok := data[k].(map[string]any); ok && len(v) > 0 {
+					kinds = append(kinds, k)
+				}
+			}
+		}
+		row("CLOUD-INIT", "config ("+strings.Join(kinds, ",")+")")
+	} else {
+		row("CLOUD-INIT", "")
+	}
+
+	return tw.Flush()
+}
+
+// fieldString returns the value of field in m formatted for a table, or an
+// empty string if m does not have it.
+func fieldString(m map[string]any, field string) string {
+	v, ok := m[field]
+	if !ok || v == nil {
+		return ""
+	}
+	if f, ok := v.(float64); ok && f == float64(int64(f)) {
+		return fmt.Sprint(int64(f))
+	}
+	return fmt.Sprint(v)
+}
+
+// firstField returns the first of fields in m that is not empty.
+func firstField(m map[string]any, fields ...string) string {
+	for _, f := range fields {
+		if s := fieldString(m, f); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	describeNodeCmd.Flags().Bool("no-bss", false, "do not get boot parameters from BSS")
+	describeNodeCmd.Flags().Bool("no-cloud-init", false, "do not get cloud-init config")
+	describeNodeCmd.Flags().Bool("cloud-init-secure", false, "use secure cloud-init endpoint")
+	describeNodeCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+
+	describeNodeCmd.MarkFlagsMutuallyExclusive("no-cloud-init", "cloud-init-secure")
+
+	setCommandServices(describeNodeCmd, "bss", "cloud-init", "smd")
+
+	describeCmd.AddCommand(describeNodeCmd)
+}
@@ -3,12 +3,19 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/OpenCHAMI/cloud-init/pkg/citypes"
+	"github.com/OpenCHAMI/ochami/internal/color"
+	"github.com/OpenCHAMI/ochami/internal/config"
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/bss"
+	"github.com/OpenCHAMI/ochami/pkg/client/ci"
 	"github.com/OpenCHAMI/ochami/pkg/client/smd"
 	"github.com/OpenCHAMI/ochami/pkg/discover"
 	"github.com/spf13/cobra"
@@ -16,11 +23,12 @@ import (
 
 // discoverCmd represents the discover command
 var discoverCmd = &cobra.Command{
-	Use:   "discover -f <payload_file> [--payload-format <format>] [--overwrite]",
+	Use:   "discover -f <payload_file> [--payload-format <format>] [--overwrite | --reconcile [--prune]]",
 	Args:  cobra.NoArgs,
-	Short: "Populate SMD with data",
-	Long: `Populate SMD with data. Currently, this command performs "fake" discovery,
-whereby data from a payload file is used to create the SMD structures.
+	Short: "Populate SMD, BSS, and cloud-init with data",
+	Long: `Populate SMD, BSS, and cloud-init with data. Currently, this command
+performs "fake" discovery, whereby data from a payload file is used to
+create the SMD structures.
 In this way, the command does not perform dynamic discovery like Magellan,
 but statically populates SMD using a file. If - is used as the argument to
 -f, the payload data is read from standard input.
@@ -38,17 +46,60 @@ nodes:
   interfaces:
   - mac_addr: de:ad:be:ee:ee:f1
     ip_addrs:
-    - name: internal
+    - network: internal
       ip_addr: 172.16.0.1
   - mac_addr: de:ad:be:ee:ee:f2
     ip_addrs:
-    - name: external
+    - network: external
       ip_addr: 10.15.3.100
   - mac_addr: 02:00:00:91:31:b3
     ip_addrs:
-    - name: HSN
+    - network: HSN
       ip_addr: 192.168.0.1
+  boot:
+    kernel: http://172.16.0.254/boot/vmlinuz
+    initrd: http://172.16.0.254/boot/initramfs.img
+    params: console=ttyS0,115200 root=live:http://172.16.0.254/image.squashfs
+  cloud_init:
+    meta_data:
+      hostname: node01
+groups:
+- name: compute
+  description: Compute nodes
+  cloud_init:
+    user_data:
+      write_files:
+      - path: /etc/motd
+        content: Welcome to a compute node
 
+The boot and cloud_init sections of nodes and the groups section are
+optional. If a node has a boot section, its boot parameters are sent to
+BSS. Nodes with identical boot sections share the same boot parameters.
+If a node or group has a cloud_init section, a cloud-init config named
+after the node's xname or the group's name is sent to cloud-init. Since
+cloud-init merges the configs of the groups a node is in into the node's
+config, this allows common data to be set for a whole group.
+
+A node is added to the SMD group named by its group key and to each of those
+listed under its groups key, e.g. "groups: [slurm, rack1]". The groups are
+created if they do not exist.
+
+Nodes without a group can be assigned one with --group-rule, whose pattern
+is matched against the node's xname. The BMC credentials and the scheme
+and port of the BMC redfish service roots are set in the redfish endpoints
+sent to SMD if passed. If these flags are not passed, they are taken from
+the discover section of the config of the cluster being used, if any (see
+ochami-config(5)).
+
+With --reconcile, discover can be re-run safely after the payload changes.
+The components, redfish endpoints, ethernet interfaces, and groups in SMD
+are compared with the discovered ones, only those that are new or differ are
+created or updated, and a summary of what was created, updated, unchanged,
+and deleted is printed. Ethernet interfaces are matched by MAC address, and
+group members are only added, never removed. With --prune, ethernet
+interfaces in SMD that belong to a discovered component but are no longer in
+the payload are deleted. Boot parameters and cloud-init configs are replaced
+as with --overwrite.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Check that all required args are passed
@@ -79,9 +130,13 @@ nodes:
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
+		if cmd.Flag("prune").Changed && !cmd.Flag("reconcile").Changed {
+			log.Logger.Error().Msg("--prune can only be used with --reconcile")
+			os.Exit(1)
+		}
 		if cmd.Flag("overwrite").Changed {
 			log.Logger.Warn().Msg("--overwrite passed; overwriting any existing data")
 		}
@@ -92,369 +147,792 @@ nodes:
 		log.Logger.Debug().Msgf("read %d nodes", len(nodes.Nodes))
 		log.Logger.Debug().Msgf("nodes: %s", nodes)
 
+		// Apply discovery settings from flags and cluster config
+		bmcOpts, groupRules := discoverSettings(cmd)
+		if n, err := nodes.AssignGroups(groupRules); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to assign groups to nodes")
+			os.Exit(1)
+		} else if n > 0 {
+			log.Logger.Info().Msgf("assigned groups to %d node(s) using group rules", n)
+		}
+
 		// Put together payload for different endpoints
 		log.Logger.Debug().Msg("generating redfish structures to send to SMD")
-		comps, rfes, ifaces, err := discover.DiscoveryInfoV2(smdBaseURI, nodes)
+		comps, rfes, ifaces, bootParams, ciConfigs, err := discover.DiscoveryInfoV4(smdBaseURI, nodes, bmcOpts)
 		if err != nil {
 			log.Logger.Error().Err(err).Msg("failed to construct structures to send to SMD")
 			os.Exit(1)
 		}
-		log.Logger.Debug().Msgf("generated redfish structures: %v", rfes.RedfishEndpoints)
+		if rfesJSON, err := json.Marshal(rfes.RedfishEndpoints); err == nil {
+			log.Logger.Debug().Msgf("generated redfish structures: %s", client.RedactBody(rfesJSON))
+		}
+		log.Logger.Debug().Msgf("generated %d boot parameter(s) and %d cloud-init config(s)", len(bootParams), len(ciConfigs))
 
-		// Send Component requests
-		// NOTE: These are sent *before* the RedfishEndpoints so the
-		// user-specified NIDs get used instead of the SMD-generated
-		// ones. The NIDs generated by SMD assume starting at 1 and
-		// increment up in the order added.
-		compErrorsOccurred := false
-		if cmd.Flag("overwrite").Changed {
-			// Send a PUT if --overwrite specified to overwrite any existing components
-			_, errs, err := smdClient.PutComponents(comps, token)
+		// Put together list of groups to add and which components to add to those groups
+		groupList, err := discover.DiscoveryGroups(nodes)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to construct groups to send to SMD")
+			os.Exit(1)
+		}
+
+		// Send the SMD structures, either all of them or, with --reconcile,
+		// only those that differ from what is in SMD
+		var failed discoveryFailures
+		if cmd.Flag("reconcile").Changed {
+			failed = reconcileDiscoveryInfo(cmd, smdClient, comps, rfes, ifaces, groupList)
+		} else {
+			failed = sendDiscoveryInfo(cmd, smdClient, comps, rfes, ifaces, groupList)
+		}
+
+		// Send boot parameters to BSS. BSS's PUT creates or replaces
+		// boot parameters, so it is used for --overwrite and --reconcile.
+		overwrite := cmd.Flag("overwrite").Changed || cmd.Flag("reconcile").Changed
+		bssErrorsOccurred := false
+		if len(bootParams) > 0 {
+			bssClient, err := bss.NewClient(smdBaseURI, insecure)
 			if err != nil {
-				log.Logger.Error().Err(err).Msg("failed to add/overwrite components in SMD")
-				compErrorsOccurred = true
+				log.Logger.Error().Err(err).Msg("error creating new BSS client")
+				os.Exit(1)
 			}
-			for _, err := range errs {
+			configureClient(bssClient.OchamiClient)
+			for _, bp := range bootParams {
+				if overwrite {
+					_, err = bssClient.PutBootParams(bp, token)
+				} else {
+					_, err = bssClient.PostBootParams(bp, token)
+				}
 				if err != nil {
-					var errMsg string
 					if errors.Is(err, client.UnsuccessfulHTTPError) {
-						errMsg = "SMD component request yielded unsuccessful HTTP response"
+						log.Logger.Error().Err(err).Msgf("BSS boot parameter request for %v yielded unsuccessful HTTP response", bp.Hosts)
 					} else {
-						errMsg = "failed to add/overwrite component in SMD"
+						log.Logger.Error().Err(err).Msgf("failed to add boot parameters for %v to BSS", bp.Hosts)
 					}
-					log.Logger.Error().Err(err).Msg(errMsg)
-					compErrorsOccurred = true
+					bssErrorsOccurred = true
 				}
 			}
+		}
 
-			// The SMD Components API does not modify the NID for
-			// PUTs. Thus, we explicitly do it with a PATCH to a
-			// specific endpoint that does it.
-			if _, err := smdClient.PatchComponentsNID(comps, token); err != nil {
-				log.Logger.Error().Err(err).Msg("failed to update NIDs for components in SMD")
-				compErrorsOccurred = true
+		// Send configs to cloud-init. With --overwrite or --reconcile,
+		// configs that already exist are PUT and the rest are POSTed.
+		ciErrorsOccurred := false
+		if len(ciConfigs) > 0 {
+			ciClient, err := ci.NewClient(smdBaseURI, insecure)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("error creating new cloud-init client")
+				os.Exit(1)
 			}
-		} else {
-			// Otherwise send a normal POST
-			_, err = smdClient.PostComponents(comps, token)
+			configureClient(ciClient.OchamiClient)
+			var toPost, toPut []citypes.CI
+			toPost = ciConfigs
+			if overwrite {
+				henv, err := ciClient.GetConfigs("")
+				if err != nil {
+					log.Logger.Error().Err(err).Msg("failed to get existing cloud-init configs")
+					os.Exit(1)
+				}
+				existing := make(map[string]citypes.CI)
+				if err := json.Unmarshal(henv.Body, &existing); err != nil {
+					log.Logger.Error().Err(err).Msg("failed to unmarshal existing cloud-init configs")
+					os.Exit(1)
+				}
+				toPost = nil
+				for _, c := range ciConfigs {
+					if _, ok := existing[c.Name]; ok {
+						toPut = append(toPut, c)
+					} else {
+						toPost = append(toPost, c)
+					}
+				}
+			}
+			var allErrs []error
+			if len(toPost) > 0 {
+				_, errs, err := ciClient.PostConfigs(toPost, token)
+				if err != nil {
+					log.Logger.Error().Err(err).Msg("failed to add cloud-init configs")
+					ciErrorsOccurred = true
+				}
+				allErrs = append(allErrs, errs...)
+			}
+			if len(toPut) > 0 {
+				_, errs, err := ciClient.PutConfigs(toPut, token)
+				if err != nil {
+					log.Logger.Error().Err(err).Msg("failed to overwrite cloud-init configs")
+					ciErrorsOccurred = true
+				}
+				allErrs = append(allErrs, errs...)
+			}
+			for _, err := range allErrs {
+				if err != nil {
+					if errors.Is(err, client.UnsuccessfulHTTPError) {
+						log.Logger.Error().Err(err).Msg("cloud-init config request yielded unsuccessful HTTP response")
+					} else {
+						log.Logger.Error().Err(err).Msg("failed to add cloud-init config")
+					}
+					ciErrorsOccurred = true
+				}
+			}
+		}
+
+		// Notify user if any request errors occurred
+		exitStatus := 0
+		if failed.comps {
+			log.Logger.Warn().Msg("component requests completed with errors")
+			exitStatus = 1
+		}
+		if failed.rfes {
+			log.Logger.Warn().Msg("redfish endpoint requests completed with errors")
+			exitStatus = 1
+		}
+		if failed.ifaces {
+			log.Logger.Warn().Msg("ethernet interface requests completed with errors")
+			exitStatus = 1
+		}
+		if failed.groups {
+			log.Logger.Warn().Msg("group requests completed with errors")
+			exitStatus = 1
+		}
+		if bssErrorsOccurred {
+			log.Logger.Warn().Msg("boot parameter requests completed with errors")
+			exitStatus = 1
+		}
+		if ciErrorsOccurred {
+			log.Logger.Warn().Msg("cloud-init config requests completed with errors")
+			exitStatus = 1
+		}
+		os.Exit(exitStatus)
+	},
+}
+
+// discoveryFailures records which kinds of SMD requests sent by discover
+// failed.
+type discoveryFailures struct {
+	comps, rfes, ifaces, groups bool
+}
+
+// sendDiscoveryInfo sends the components, redfish endpoints, ethernet
+// interfaces, and groups generated by discover to SMD. They are POSTed and,
+// with --overwrite, those that already exist are replaced or updated instead.
+func sendDiscoveryInfo(cmd *cobra.Command, smdClient *smd.SMDClient, comps smd.ComponentSlice, rfes smd.RedfishEndpointSliceV2, ifaces []smd.EthernetInterface, groupList []smd.Group) discoveryFailures {
+	for i := range groupList {
+		if groupList[i].Description == "" {
+			groupList[i].Description = defaultGroupDescription(groupList[i].Label)
+		}
+	}
+
+	// Send Component requests
+	// NOTE: These are sent *before* the RedfishEndpoints so the
+	// user-specified NIDs get used instead of the SMD-generated
+	// ones. The NIDs generated by SMD assume starting at 1 and
+	// increment up in the order added.
+	compErrorsOccurred := false
+	if cmd.Flag("overwrite").Changed {
+		// Send a PUT if --overwrite specified to overwrite any existing components
+		_, errs, err := smdClient.PutComponents(comps, token)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to add/overwrite components in SMD")
+			compErrorsOccurred = true
+		}
+		exitIfInterrupted(errs)
+		for _, err := range errs {
 			if err != nil {
 				var errMsg string
 				if errors.Is(err, client.UnsuccessfulHTTPError) {
 					errMsg = "SMD component request yielded unsuccessful HTTP response"
 				} else {
-					errMsg = "failed to add components to SMD"
+					errMsg = "failed to add/overwrite component in SMD"
 				}
 				log.Logger.Error().Err(err).Msg(errMsg)
 				compErrorsOccurred = true
 			}
 		}
 
-		// Send RedfishEndpoint requests
-		var (
-			rfeErrorsOccurred bool = false
-			rfeHenvs          []client.HTTPEnvelope
-			rfeErrs           []error
-			rfeErr            error
-		)
-		if cmd.Flag("overwrite").Changed {
-			// SMD's RedfishEndpoint API for PUT behaves more like
-			// PATCH. In other words, the RedfishEndpoint must exist
-			// _first_ before PUTting. This means that, to get
-			// normal PUT behavior, we have to first try to POST,
-			// then, if 409 is returned, try to PUT.
-			for _, rfe := range rfes.RedfishEndpoints {
-				// Attempt to POST the redfish endpoint
-				rfeListWrapper := smd.RedfishEndpointSliceV2{
-					RedfishEndpoints: []smd.RedfishEndpointV2{rfe},
-				}
-				rfeHenvs, rfeErrs, rfeErr = smdClient.PostRedfishEndpointsV2(rfeListWrapper, token)
+		// The SMD Components API does not modify the NID for
+		// PUTs. Thus, we explicitly do it with a PATCH to a
+		// specific endpoint that does it.
+		if _, err := smdClient.PatchComponentsNID(comps, token); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to update NIDs for components in SMD")
+			compErrorsOccurred = true
+		}
+	} else {
+		// Otherwise send a normal POST
+		_, err := smdClient.PostComponents(comps, token)
+		if err != nil {
+			var errMsg string
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				errMsg = "SMD component request yielded unsuccessful HTTP response"
+			} else {
+				errMsg = "failed to add components to SMD"
+			}
+			log.Logger.Error().Err(err).Msg(errMsg)
+			compErrorsOccurred = true
+		}
+	}
 
-				if rfeErr != nil {
-					// An error in the function occurred,
-					// err for this redfish endpoint and
-					// move on.
-					log.Logger.Error().Err(rfeErr).Msg("failed to add redfish endpoint to SMD")
-					rfeErrorsOccurred = true
-					continue
-				}
+	// Send RedfishEndpoint requests
+	var (
+		rfeErrorsOccurred bool = false
+		rfeHenvs          []client.HTTPEnvelope
+		rfeErrs           []error
+		rfeErr            error
+	)
+	if cmd.Flag("overwrite").Changed {
+		// SMD's RedfishEndpoint API for PUT behaves more like
+		// PATCH. In other words, the RedfishEndpoint must exist
+		// _first_ before PUTting. This means that, to get
+		// normal PUT behavior, we have to first try to POST,
+		// then, if 409 is returned, try to PUT.
+		for _, rfe := range rfes.RedfishEndpoints {
+			// Attempt to POST the redfish endpoint
+			rfeListWrapper := smd.RedfishEndpointSliceV2{
+				RedfishEndpoints: []smd.RedfishEndpointV2{rfe},
+			}
+			rfeHenvs, rfeErrs, rfeErr = smdClient.PostRedfishEndpointsV2(rfeListWrapper, token)
 
-				if rfeErrs[0] != nil {
-					// An HTTP error occurred
-					if errors.Is(rfeErrs[0], client.UnsuccessfulHTTPError) {
-						if rfeHenvs[0].StatusCode == 409 {
-							// RFE exists, PUT it
-							log.Logger.Info().Msgf("redfish endpoint %s exists, attempting to update it", rfe.ID)
-							_, putErrs, putErr := smdClient.PutRedfishEndpointsV2(rfeListWrapper, token)
-							if putErr != nil {
-								log.Logger.Error().Err(putErr).Msg("failed to update existing redfish endpoint in SMD")
-								rfeErrorsOccurred = true
-								continue
-							}
-							if putErrs[0] != nil {
-								var errMsg string
-								if errors.Is(putErrs[0], client.UnsuccessfulHTTPError) {
-									errMsg = "SMD redfish endpoint PUT yielded unsuccessful HTTP response"
-								} else {
-									errMsg = "failed to update existing redfish endpoint in SMD"
-								}
-								log.Logger.Error().Err(putErrs[0]).Msg(errMsg)
-								rfeErrorsOccurred = true
-								continue
+			if rfeErr != nil {
+				// An error in the function occurred,
+				// err for this redfish endpoint and
+				// move on.
+				log.Logger.Error().Err(rfeErr).Msg("failed to add redfish endpoint to SMD")
+				rfeErrorsOccurred = true
+				continue
+			}
+
+			if rfeErrs[0] != nil {
+				// An HTTP error occurred
+				if errors.Is(rfeErrs[0], client.UnsuccessfulHTTPError) {
+					if rfeHenvs[0].StatusCode == 409 {
+						// RFE exists, PUT it
+						log.Logger.Info().Msgf("redfish endpoint %s exists, attempting to update it", rfe.ID)
+						_, putErrs, putErr := smdClient.PutRedfishEndpointsV2(rfeListWrapper, token)
+						if putErr != nil {
+							log.Logger.Error().Err(putErr).Msg("failed to update existing redfish endpoint in SMD")
+							rfeErrorsOccurred = true
+							continue
+						}
+						if putErrs[0] != nil {
+							var errMsg string
+							if errors.Is(putErrs[0], client.UnsuccessfulHTTPError) {
+								errMsg = "SMD redfish endpoint PUT yielded unsuccessful HTTP response"
+							} else {
+								errMsg = "failed to update existing redfish endpoint in SMD"
 							}
-						} else {
-							// Some other HTTP error occurred, err
-							log.Logger.Error().Err(rfeErrs[0]).Msg("SMD redfish endpoint POST yielded non-409 (duplicate) failure")
+							log.Logger.Error().Err(putErrs[0]).Msg(errMsg)
 							rfeErrorsOccurred = true
 							continue
 						}
 					} else {
-						log.Logger.Error().Err(rfeErrs[0]).Msg("failed to add redfish endpoint to SMD")
+						// Some other HTTP error occurred, err
+						log.Logger.Error().Err(rfeErrs[0]).Msg("SMD redfish endpoint POST yielded non-409 (duplicate) failure")
 						rfeErrorsOccurred = true
 						continue
 					}
+				} else {
+					log.Logger.Error().Err(rfeErrs[0]).Msg("failed to add redfish endpoint to SMD")
+					rfeErrorsOccurred = true
+					continue
 				}
 			}
-		} else {
-			// --overwrite was not passed, perform regular POST.
-			_, rfeErrs, rfeErr = smdClient.PostRedfishEndpointsV2(rfes, token)
-			if rfeErr != nil {
-				log.Logger.Error().Err(rfeErr).Msg("failed to add redfish endpoints to SMD")
-				rfeErrorsOccurred = true
-			}
-			for _, err := range rfeErrs {
-				if err != nil {
-					var errMsg string
-					if errors.Is(err, client.UnsuccessfulHTTPError) {
-						errMsg = "SMD redfish endpoint request yielded unsuccessful HTTP response"
+		}
+	} else {
+		// --overwrite was not passed, perform regular POST.
+		_, rfeErrs, rfeErr = smdClient.PostRedfishEndpointsV2(rfes, token)
+		if rfeErr != nil {
+			log.Logger.Error().Err(rfeErr).Msg("failed to add redfish endpoints to SMD")
+			rfeErrorsOccurred = true
+		}
+		for _, err := range rfeErrs {
+			if err != nil {
+				var errMsg string
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					errMsg = "SMD redfish endpoint request yielded unsuccessful HTTP response"
+				} else {
+					if cmd.Flag("overwrite").Changed {
+						errMsg = "failed to add/overwrite redfish endpoint in SMD"
 					} else {
-						if cmd.Flag("overwrite").Changed {
-							errMsg = "failed to add/overwrite redfish endpoint in SMD"
-						} else {
-							errMsg = "failed to add redfish endpoint to SMD"
-						}
+						errMsg = "failed to add redfish endpoint to SMD"
 					}
-					log.Logger.Error().Err(err).Msg(errMsg)
-					rfeErrorsOccurred = true
 				}
+				log.Logger.Error().Err(err).Msg(errMsg)
+				rfeErrorsOccurred = true
 			}
 		}
+	}
 
-		// Send EthernetInterface requests
-		var (
-			ifaceErrorsOccurred bool = false
-			ifaceHenvs          []client.HTTPEnvelope
-			ifaceErrs           []error
-			ifaceErr            error
-		)
-		if cmd.Flag("overwrite").Changed {
-			// SMD's EthernetInterface API does not allow the PUT
-			// method. Instead, we loop over each ethernet interface
-			// to add and attempt a POST. Iff a 409 is returned for
-			// that interface, a PATCH is attempted. Otherwise, an
-			// error has occurred.
-			for _, iface := range ifaces {
-				// Attempt to POST the ethernet interface
-				ifaceListWrapper := []smd.EthernetInterface{iface}
-				ifaceHenvs, ifaceErrs, ifaceErr = smdClient.PostEthernetInterfaces(ifaceListWrapper, token)
-
-				if ifaceErr != nil {
-					// An error in the function occurred, err for
-					// this interface and move on.
-					log.Logger.Error().Err(ifaceErr).Msg("failed to add ethernet interface to SMD")
-					ifaceErrorsOccurred = true
-					continue
-				}
+	// Send EthernetInterface requests
+	var (
+		ifaceErrorsOccurred bool = false
+		ifaceHenvs          []client.HTTPEnvelope
+		ifaceErrs           []error
+		ifaceErr            error
+	)
+	if cmd.Flag("overwrite").Changed {
+		// SMD's EthernetInterface API does not allow the PUT
+		// method. Instead, we loop over each ethernet interface
+		// to add and attempt a POST. Iff a 409 is returned for
+		// that interface, a PATCH is attempted. Otherwise, an
+		// error has occurred.
+		for _, iface := range ifaces {
+			// Attempt to POST the ethernet interface
+			ifaceListWrapper := []smd.EthernetInterface{iface}
+			ifaceHenvs, ifaceErrs, ifaceErr = smdClient.PostEthernetInterfaces(ifaceListWrapper, token)
 
-				if ifaceErrs[0] != nil {
-					// An HTTP error occurred
-					if errors.Is(ifaceErrs[0], client.UnsuccessfulHTTPError) {
-						if ifaceHenvs[0].StatusCode == 409 {
-							// Ethernet interface exists, patch it
-							log.Logger.Info().Msgf("ethernet interface with MAC address %s exists, attempting to update it", iface.MACAddress)
-							_, patchErrs, patchErr := smdClient.PatchEthernetInterfaces(ifaceListWrapper, token)
-							if patchErr != nil {
-								log.Logger.Error().Err(patchErr).Msg("failed to update existing ethernet interface in SMD")
-								ifaceErrorsOccurred = true
-								continue
-							}
-							if patchErrs[0] != nil {
-								var errMsg string
-								if errors.Is(patchErrs[0], client.UnsuccessfulHTTPError) {
-									errMsg = "SMD ethernet interface PATCH yielded unsuccessful HTTP response"
-								} else {
-									errMsg = "failed to update existing ethernet interface in SMD"
-								}
-								log.Logger.Error().Err(patchErrs[0]).Msg(errMsg)
-								ifaceErrorsOccurred = true
-								continue
+			if ifaceErr != nil {
+				// An error in the function occurred, err for
+				// this interface and move on.
+				log.Logger.Error().Err(ifaceErr).Msg("failed to add ethernet interface to SMD")
+				ifaceErrorsOccurred = true
+				continue
+			}
+
+			if ifaceErrs[0] != nil {
+				// An HTTP error occurred
+				if errors.Is(ifaceErrs[0], client.UnsuccessfulHTTPError) {
+					if ifaceHenvs[0].StatusCode == 409 {
+						// Ethernet interface exists, patch it
+						log.Logger.Info().Msgf("ethernet interface with MAC address %s exists, attempting to update it", iface.MACAddress)
+						_, patchErrs, patchErr := smdClient.PatchEthernetInterfaces(ifaceListWrapper, token)
+						if patchErr != nil {
+							log.Logger.Error().Err(patchErr).Msg("failed to update existing ethernet interface in SMD")
+							ifaceErrorsOccurred = true
+							continue
+						}
+						if patchErrs[0] != nil {
+							var errMsg string
+							if errors.Is(patchErrs[0], client.UnsuccessfulHTTPError) {
+								errMsg = "SMD ethernet interface PATCH yielded unsuccessful HTTP response"
+							} else {
+								errMsg = "failed to update existing ethernet interface in SMD"
 							}
-						} else {
-							// Some other HTTP error occurred, err
-							log.Logger.Error().Err(ifaceErrs[0]).Msg("SMD ethernet interface POST yield non-409 (duplicate) failure")
+							log.Logger.Error().Err(patchErrs[0]).Msg(errMsg)
 							ifaceErrorsOccurred = true
 							continue
 						}
 					} else {
-						log.Logger.Error().Err(ifaceErrs[0]).Msg("failed to add ethernet interface to SMD")
+						// Some other HTTP error occurred, err
+						log.Logger.Error().Err(ifaceErrs[0]).Msg("SMD ethernet interface POST yield non-409 (duplicate) failure")
 						ifaceErrorsOccurred = true
 						continue
 					}
-				}
-			}
-		} else {
-			// --overwrite was not passed, perform regular POST.
-			_, ifaceErrs, ifaceErr = smdClient.PostEthernetInterfaces(ifaces, token)
-			if ifaceErr != nil {
-				log.Logger.Error().Err(ifaceErr).Msg("failed to add ethernet interfaces to SMD")
-				ifaceErrorsOccurred = true
-			}
-			for _, err := range ifaceErrs {
-				if err != nil {
-					var errMsg string
-					if errors.Is(err, client.UnsuccessfulHTTPError) {
-						errMsg = "SMD ethernet interface request yielded unsuccessful HTTP response"
-					} else {
-						errMsg = "failed to add ethernet interface to SMD"
-					}
-					log.Logger.Error().Err(err).Msg(errMsg)
+				} else {
+					log.Logger.Error().Err(ifaceErrs[0]).Msg("failed to add ethernet interface to SMD")
 					ifaceErrorsOccurred = true
+					continue
 				}
 			}
 		}
-
-		// Put together list of groups to add and which components to add to those groups
-		groupsToAdd := make(map[string]smd.Group)
-		for _, node := range nodes.Nodes {
-			if node.Group != "" {
-				if g, ok := groupsToAdd[node.Group]; !ok {
-					newGroup := smd.Group{
-						Label:       node.Group,
-						Description: fmt.Sprintf("The %s group", node.Group),
-					}
-					newGroup.Members.IDs = []string{node.Xname}
-					groupsToAdd[node.Group] = newGroup
+	} else {
+		// --overwrite was not passed, perform regular POST.
+		_, ifaceErrs, ifaceErr = smdClient.PostEthernetInterfaces(ifaces, token)
+		if ifaceErr != nil {
+			log.Logger.Error().Err(ifaceErr).Msg("failed to add ethernet interfaces to SMD")
+			ifaceErrorsOccurred = true
+		}
+		for _, err := range ifaceErrs {
+			if err != nil {
+				var errMsg string
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					errMsg = "SMD ethernet interface request yielded unsuccessful HTTP response"
 				} else {
-					g.Members.IDs = append(g.Members.IDs, node.Xname)
-					groupsToAdd[node.Group] = g
+					errMsg = "failed to add ethernet interface to SMD"
 				}
+				log.Logger.Error().Err(err).Msg(errMsg)
+				ifaceErrorsOccurred = true
 			}
 		}
-		groupList := make([]smd.Group, len(groupsToAdd))
-		var idx = 0
-		for _, g := range groupsToAdd {
-			groupList[idx] = g
-			idx++
-		}
+	}
 
-		// Add groups and components to those groups
-		var (
-			groupErrorsOccurred bool = false
-			groupHenvs          []client.HTTPEnvelope
-			groupErrs           []error
-			groupErr            error
-		)
-		if cmd.Flag("overwrite").Changed {
-			// SMD's groups API does not allow the PUT method.
-			// Instead, we loop over each group to add and attempt a
-			// POST. Iff a 409 is returned for that interface, a
-			// PATCH is attempted. Otherwise, an error has occurred.
-			for _, group := range groupList {
-				// Attempt to POST the group
-				groupListWrapper := []smd.Group{group}
-				groupHenvs, groupErrs, groupErr = smdClient.PostGroups(groupListWrapper, token)
-
-				if groupErr != nil {
-					// An error in the function occurred,
-					// err for this group and move on.
-					log.Logger.Error().Err(groupErr).Msg("failed to add group to SMD")
-					groupErrorsOccurred = true
-					continue
-				}
+	// Add groups and components to those groups
+	var (
+		groupErrorsOccurred bool = false
+		groupHenvs          []client.HTTPEnvelope
+		groupErrs           []error
+		groupErr            error
+	)
+	if cmd.Flag("overwrite").Changed {
+		// SMD's groups API does not allow the PUT method.
+		// Instead, we loop over each group to add and attempt a
+		// POST. Iff a 409 is returned for that interface, a
+		// PATCH is attempted. Otherwise, an error has occurred.
+		for _, group := range groupList {
+			// Attempt to POST the group
+			groupListWrapper := []smd.Group{group}
+			groupHenvs, groupErrs, groupErr = smdClient.PostGroups(groupListWrapper, token)
 
-				if groupErrs[0] != nil {
-					// An HTTP error occurred
-					if errors.Is(groupErrs[0], client.UnsuccessfulHTTPError) {
-						if groupHenvs[0].StatusCode == 409 {
-							// Group exists, patch it
-							log.Logger.Info().Msgf("group %s exists, attempting to update it", group.Label)
-							_, patchErrs, patchErr := smdClient.PatchGroups(groupListWrapper, token)
-							if patchErr != nil {
-								log.Logger.Error().Err(patchErr).Msg("failed to update existing group in SMD")
-								groupErrorsOccurred = true
-								continue
-							}
-							if patchErrs[0] != nil {
-								var errMsg string
-								if errors.Is(patchErrs[0], client.UnsuccessfulHTTPError) {
-									errMsg = "SMD group PATCH yielded unsuccessful HTTP response"
-								} else {
-									errMsg = "failed to update existing group in SMD"
-								}
-								log.Logger.Error().Err(patchErrs[0]).Msg(errMsg)
-								groupErrorsOccurred = true
-								continue
+			if groupErr != nil {
+				// An error in the function occurred,
+				// err for this group and move on.
+				log.Logger.Error().Err(groupErr).Msg("failed to add group to SMD")
+				groupErrorsOccurred = true
+				continue
+			}
+
+			if groupErrs[0] != nil {
+				// An HTTP error occurred
+				if errors.Is(groupErrs[0], client.UnsuccessfulHTTPError) {
+					if groupHenvs[0].StatusCode == 409 {
+						// Group exists, patch it
+						log.Logger.Info().Msgf("group %s exists, attempting to update it", group.Label)
+						_, patchErrs, patchErr := smdClient.PatchGroups(groupListWrapper, token)
+						if patchErr != nil {
+							log.Logger.Error().Err(patchErr).Msg("failed to update existing group in SMD")
+							groupErrorsOccurred = true
+							continue
+						}
+						if patchErrs[0] != nil {
+							var errMsg string
+							if errors.Is(patchErrs[0], client.UnsuccessfulHTTPError) {
+								errMsg = "SMD group PATCH yielded unsuccessful HTTP response"
+							} else {
+								errMsg = "failed to update existing group in SMD"
 							}
-						} else {
-							// Some other HTTP error occurred, err
-							log.Logger.Error().Err(groupErrs[0]).Msg("SMD group POST yielded non-409 (duplicate) failure")
+							log.Logger.Error().Err(patchErrs[0]).Msg(errMsg)
 							groupErrorsOccurred = true
 							continue
 						}
 					} else {
-						log.Logger.Error().Err(groupErrs[0]).Msg("failed to add group to SMD")
+						// Some other HTTP error occurred, err
+						log.Logger.Error().Err(groupErrs[0]).Msg("SMD group POST yielded non-409 (duplicate) failure")
 						groupErrorsOccurred = true
 						continue
 					}
+				} else {
+					log.Logger.Error().Err(groupErrs[0]).Msg("failed to add group to SMD")
+					groupErrorsOccurred = true
+					continue
 				}
 			}
-		} else {
-			_, groupErrs, groupErr = smdClient.PostGroups(groupList, token)
-			if groupErr != nil {
-				log.Logger.Error().Err(groupErr).Msg("failed to add groups to SMD")
+		}
+	} else {
+		_, groupErrs, groupErr = smdClient.PostGroups(groupList, token)
+		if groupErr != nil {
+			log.Logger.Error().Err(groupErr).Msg("failed to add groups to SMD")
+			groupErrorsOccurred = true
+		}
+		for _, err := range groupErrs {
+			if err != nil {
+				var errMsg string
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					errMsg = "SMD groups request yielded unsuccessful HTTP response"
+				} else {
+					errMsg = "failed to add groups to SMD"
+				}
+				log.Logger.Error().Err(err).Msg(errMsg)
 				groupErrorsOccurred = true
 			}
-			for _, err := range groupErrs {
-				if err != nil {
-					var errMsg string
-					if errors.Is(err, client.UnsuccessfulHTTPError) {
-						errMsg = "SMD groups request yielded unsuccessful HTTP response"
-					} else {
-						errMsg = "failed to add groups to SMD"
-					}
-					log.Logger.Error().Err(err).Msg(errMsg)
-					groupErrorsOccurred = true
-				}
+		}
+	}
+
+	return discoveryFailures{
+		comps:  compErrorsOccurred,
+		rfes:   rfeErrorsOccurred,
+		ifaces: ifaceErrorsOccurred,
+		groups: groupErrorsOccurred,
+	}
+}
+
+// reconcileDiscoveryInfo is like sendDiscoveryInfo except that, for
+// --reconcile, the components, redfish endpoints, ethernet interfaces, and
+// groups generated by discover are first compared with those in SMD so that
+// only the ones that are new or differ are sent. With --prune, the ethernet
+// interfaces of discovered components that were not discovered themselves are
+// deleted. A summary of the changes is printed.
+func reconcileDiscoveryInfo(cmd *cobra.Command, smdClient *smd.SMDClient, comps smd.ComponentSlice, rfes smd.RedfishEndpointSliceV2, ifaces []smd.EthernetInterface, groupList []smd.Group) discoveryFailures {
+	existing, err := getExistingDiscoveryInfo(smdClient)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to get existing data from SMD to reconcile against")
+		os.Exit(1)
+	}
+	plan, err := discover.PlanReconcile(comps.Components, rfes.RedfishEndpoints, ifaces, groupList, existing, cmd.Flag("prune").Changed)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to compare discovered data with SMD")
+		os.Exit(1)
+	}
+	for _, c := range plan.Changes {
+		if c.Action == discover.ActionUnchanged {
+			log.Logger.Debug().Msgf("%s %s: unchanged", c.Kind, c.ID)
+			continue
+		}
+		if len(c.Fields) > 0 {
+			log.Logger.Info().Msgf("%s %s: %s (%s)", c.Kind, c.ID, c.Action, strings.Join(c.Fields, ","))
+		} else {
+			log.Logger.Info().Msgf("%s %s: %s", c.Kind, c.ID, c.Action)
+		}
+	}
+
+	var failed discoveryFailures
+
+	// Components are sent first so that the discovered NIDs are used
+	// (see sendDiscoveryInfo).
+	if len(plan.CreateComponents) > 0 {
+		henv, err := smdClient.PostComponents(smd.ComponentSlice{Components: plan.CreateComponents}, token)
+		if err != nil {
+			logHTTPError(err, henv, "failed to add components to SMD")
+			failed.comps = true
+		}
+	}
+	if len(plan.UpdateComponents) > 0 {
+		update := smd.ComponentSlice{Components: plan.UpdateComponents}
+		henvs, errs, err := smdClient.PutComponents(update, token)
+		failed.comps = logDiscoveryErrors(henvs, errs, err, "failed to update component in SMD") || failed.comps
+		if henv, err := smdClient.PatchComponentsNID(update, token); err != nil {
+			logHTTPError(err, henv, "failed to update NIDs for components in SMD")
+			failed.comps = true
+		}
+	}
+
+	if len(plan.CreateRedfishEndpoints) > 0 {
+		henvs, errs, err := smdClient.PostRedfishEndpointsV2(smd.RedfishEndpointSliceV2{RedfishEndpoints: plan.CreateRedfishEndpoints}, token)
+		failed.rfes = logDiscoveryErrors(henvs, errs, err, "failed to add redfish endpoint to SMD")
+	}
+	if len(plan.UpdateRedfishEndpoints) > 0 {
+		henvs, errs, err := smdClient.PutRedfishEndpointsV2(smd.RedfishEndpointSliceV2{RedfishEndpoints: plan.UpdateRedfishEndpoints}, token)
+		failed.rfes = logDiscoveryErrors(henvs, errs, err, "failed to update redfish endpoint in SMD") || failed.rfes
+	}
+
+	if len(plan.DeleteEthernetInterfaces) > 0 {
+		henvs, errs, err := smdClient.DeleteEthernetInterfaces(token, plan.DeleteEthernetInterfaces...)
+		failed.ifaces = logDiscoveryErrors(henvs, errs, err, "failed to delete ethernet interface from SMD")
+	}
+	if len(plan.CreateEthernetInterfaces) > 0 {
+		henvs, errs, err := smdClient.PostEthernetInterfaces(plan.CreateEthernetInterfaces, token)
+		failed.ifaces = logDiscoveryErrors(henvs, errs, err, "failed to add ethernet interface to SMD") || failed.ifaces
+	}
+	if len(plan.UpdateEthernetInterfaces) > 0 {
+		henvs, errs, err := smdClient.PatchEthernetInterfaces(plan.UpdateEthernetInterfaces, token)
+		failed.ifaces = logDiscoveryErrors(henvs, errs, err, "failed to update ethernet interface in SMD") || failed.ifaces
+	}
+
+	if len(plan.CreateGroups) > 0 {
+		for i := range plan.CreateGroups {
+			if plan.CreateGroups[i].Description == "" {
+				plan.CreateGroups[i].Description = defaultGroupDescription(plan.CreateGroups[i].Label)
 			}
 		}
+		henvs, errs, err := smdClient.PostGroups(plan.CreateGroups, token)
+		failed.groups = logDiscoveryErrors(henvs, errs, err, "failed to add group to SMD")
+	}
+	for _, gu := range plan.UpdateGroups {
+		if len(gu.AddMembers) > 0 {
+			henvs, errs, err := smdClient.PostGroupMembers(token, gu.Label, gu.AddMembers...)
+			failed.groups = logDiscoveryErrors(henvs, errs, err, fmt.Sprintf("failed to add members to group %s in SMD", gu.Label)) || failed.groups
+		}
+		if gu.Description != "" {
+			henvs, errs, err := smdClient.PatchGroups([]smd.Group{{Label: gu.Label, Description: gu.Description}}, token)
+			failed.groups = logDiscoveryErrors(henvs, errs, err, fmt.Sprintf("failed to update description of group %s in SMD", gu.Label)) || failed.groups
+		}
+	}
 
-		// Notify user if any request errors occurred
-		exitStatus := 0
-		if compErrorsOccurred {
-			log.Logger.Warn().Msg("component requests completed with errors")
-			exitStatus = 1
+	tw := color.NewTable(os.Stdout)
+	fmt.Fprintln(tw, "KIND\tCREATED\tUPDATED\tUNCHANGED\tDELETED")
+	for _, kind := range discover.ReconcileKinds {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", kind,
+			plan.Count(kind, discover.ActionCreate),
+			plan.Count(kind, discover.ActionUpdate),
+			plan.Count(kind, discover.ActionUnchanged),
+			plan.Count(kind, discover.ActionDelete))
+	}
+	if err := tw.Flush(); err != nil {
+		log.Logger.Error().Err(err).Msg("failed to print reconcile summary")
+	}
+
+	return failed
+}
+
+// getExistingDiscoveryInfo gets the components, redfish endpoints, ethernet
+// interfaces, and groups in SMD for reconcileDiscoveryInfo.
+func getExistingDiscoveryInfo(smdClient *smd.SMDClient) (discover.Existing, error) {
+	var existing discover.Existing
+
+	henv, err := smdClient.GetComponents("", token)
+	if err != nil {
+		return existing, err
+	}
+	var compList struct {
+		Components []map[string]any `json:"Components"`
+	}
+	if err := json.Unmarshal(henv.Body, &compList); err != nil {
+		return existing, fmt.Errorf("failed to unmarshal components: %w", err)
+	}
+	existing.Components = compList.Components
+
+	henv, err = smdClient.GetRedfishEndpoints("", token)
+	if err != nil {
+		return existing, err
+	}
+	var rfeList struct {
+		RedfishEndpoints []map[string]any `json:"RedfishEndpoints"`
+	}
+	if err := json.Unmarshal(henv.Body, &rfeList); err != nil {
+		return existing, fmt.Errorf("failed to unmarshal redfish endpoints: %w", err)
+	}
+	existing.RedfishEndpoints = rfeList.RedfishEndpoints
+
+	henv, err = smdClient.GetEthernetInterfaces(smd.EthernetInterfaceFilter{})
+	if err != nil {
+		return existing, err
+	}
+	if err := json.Unmarshal(henv.Body, &existing.EthernetInterfaces); err != nil {
+		return existing, fmt.Errorf("failed to unmarshal ethernet interfaces: %w", err)
+	}
+
+	henv, err = smdClient.GetGroups("", token)
+	if err != nil {
+		return existing, err
+	}
+	if err := json.Unmarshal(henv.Body, &existing.Groups); err != nil {
+		return existing, fmt.Errorf("failed to unmarshal groups: %w", err)
+	}
+
+	return existing, nil
+}
+
+// logDiscoveryErrors logs err and each of errs, the errors returned by an SMD
+// request sent by discover along with henvs, with msg. It reports whether any
+// error occurred.
+func logDiscoveryErrors(henvs []client.HTTPEnvelope, errs []error, err error, msg string) bool {
+	exitIfInterrupted(errs)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg(msg)
+		return true
+	}
+	failed := false
+	for i, e := range errs {
+		if e == nil {
+			continue
 		}
-		if rfeErrorsOccurred {
-			log.Logger.Warn().Msg("redfish endpoint requests completed with errors")
-			exitStatus = 1
+		var henv client.HTTPEnvelope
+		if i < len(henvs) {
+			henv = henvs[i]
 		}
-		if ifaceErrorsOccurred {
-			log.Logger.Warn().Msg("ethernet interface requests completed with errors")
-			exitStatus = 1
+		logHTTPError(e, henv, msg)
+		failed = true
+	}
+	return failed
+}
+
+// defaultGroupDescription returns the description given to a group created by
+// discover whose description is not set in the payload.
+func defaultGroupDescription(label string) string {
+	return fmt.Sprintf("The %s group", label)
+}
+
+// discoverSettings returns the BMC options and group rules to use for
+// discovery. Each setting is taken from its flag if passed, or else from the
+// discover section of the config of the cluster being used, if any. Group
+// rules passed with --group-rule are tried before those in the config. If a
+// secret cannot be resolved, a log is printed and the program exits.
+func discoverSettings(cmd *cobra.Command) (discover.BMCOptions, []discover.GroupRule) {
+	var (
+		rules []discover.GroupRule
+		dc    config.ConfigDiscover
+	)
+	// An error here is reported when the base URI is determined
+	if cluster, err := getCluster(cmd); err == nil && cluster != nil {
+		dc = cluster.Cluster.Discover
+	}
+	bmcOpts := bmcSettings(cmd)
+
+	ruleFlags, err := cmd.Flags().GetStringArray("group-rule")
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to get value for --group-rule")
+		os.Exit(1)
+	}
+	for _, rf := range ruleFlags {
+		pattern, group, found := strings.Cut(rf, "=")
+		if !found {
+			log.Logger.Error().Msgf("invalid --group-rule %q: expected <pattern>=<group>", rf)
+			os.Exit(1)
 		}
-		if groupErrorsOccurred {
-			log.Logger.Warn().Msg("group requests completed with errors")
-			exitStatus = 1
+		rules = append(rules, discover.GroupRule{Pattern: pattern, Group: group})
+	}
+	for _, r := range dc.Groups {
+		rules = append(rules, discover.GroupRule{Pattern: r.Pattern, Group: r.Group})
+	}
+
+	return bmcOpts, rules
+}
+
+// bmcSettings returns the settings for connecting to BMCs over Redfish. Each
+// setting is taken from its flag (--bmc-username, --bmc-password-file,
+// --redfish-scheme, or --redfish-port) if cmd has it and it was passed, or else
+// from the discover section of the config of the cluster being used, if any.
+// If a secret cannot be resolved, a log is printed and the program exits.
+func bmcSettings(cmd *cobra.Command) discover.BMCOptions {
+	var (
+		bmcOpts discover.BMCOptions
+		dc      config.ConfigDiscover
+	)
+	// An error here is reported when the base URI is determined
+	if cluster, err := getCluster(cmd); err == nil && cluster != nil {
+		dc = cluster.Cluster.Discover
+	}
+	changed := func(name string) bool {
+		f := cmd.Flag(name)
+		return f != nil && f.Changed
+	}
+
+	if changed("bmc-username") {
+		bmcOpts.Username = cmd.Flag("bmc-username").Value.String()
+	} else if dc.BMCUsername.IsSet() {
+		u, err := dc.BMCUsername.Resolve()
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get BMC username from discover.bmc-username in config")
+			os.Exit(1)
 		}
-		os.Exit(exitStatus)
-	},
+		bmcOpts.Username = u
+	}
+	if changed("bmc-password-file") {
+		ref := config.ConfigSecretRef{File: cmd.Flag("bmc-password-file").Value.String()}
+		p, err := ref.Resolve()
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get BMC password from --bmc-password-file")
+			os.Exit(1)
+		}
+		bmcOpts.Password = p
+	} else if dc.BMCPassword.IsSet() {
+		p, err := dc.BMCPassword.Resolve()
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get BMC password from discover.bmc-password in config")
+			os.Exit(1)
+		}
+		bmcOpts.Password = p
+	}
+
+	bmcOpts.Scheme = dc.RedfishScheme
+	if changed("redfish-scheme") {
+		bmcOpts.Scheme = cmd.Flag("redfish-scheme").Value.String()
+	}
+	bmcOpts.Port = dc.RedfishPort
+	if changed("redfish-port") {
+		port, err := cmd.Flags().GetInt("redfish-port")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --redfish-port")
+			os.Exit(1)
+		}
+		bmcOpts.Port = port
+	}
+
+	return bmcOpts
 }
 
 func init() {
-	discoverCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	discoverCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	discoverCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	discoverCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	discoverCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
 	discoverCmd.Flags().Bool("overwrite", false, "overwrite any existing information instead of failing")
+	discoverCmd.Flags().Bool("reconcile", false, "only create or update data that differs from what is in SMD and print a summary")
+	discoverCmd.Flags().Bool("prune", false, "with --reconcile, delete ethernet interfaces of discovered nodes that are not in the payload")
+	discoverCmd.Flags().String("bmc-username", "", "username to set in redfish endpoints for BMCs")
+	discoverCmd.Flags().String("bmc-password-file", "", "file containing password to set in redfish endpoints for BMCs")
+	discoverCmd.Flags().String("redfish-scheme", "", "scheme (http,https) of BMC redfish service root URIs")
+	discoverCmd.Flags().Int("redfish-port", 0, "port of BMC redfish service root URIs")
+	discoverCmd.Flags().StringArray("group-rule", []string{}, "assign nodes without a group whose xname matches a glob to a group (<pattern>=<group>)")
 
 	discoverCmd.MarkFlagRequired("payload")
+	discoverCmd.MarkFlagsMutuallyExclusive("overwrite", "reconcile")
+
+	setCommandServices(discoverCmd, "bss", "cloud-init", "smd")
+	setCommandMutating(discoverCmd, true)
 
 	rootCmd.AddCommand(discoverCmd)
 }
@@ -0,0 +1,439 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/internal/color"
+	"github.com/OpenCHAMI/ochami/internal/config"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/spf13/cobra"
+)
+
+// configLoadErr is the error that loading the config failed with when running
+// doctor, which reports it instead of exiting (see InitConfig).
+var configLoadErr error
+
+// doctorTimeout is how long each network check of doctor may take.
+const doctorTimeout = 5 * time.Second
+
+// maxClockSkew is how far in the future a token's issued at or not before time
+// may be before doctor reports clock skew.
+const maxClockSkew = time.Minute
+
+// checkStatus is the outcome of a doctor check.
+type checkStatus string
+
+const (
+	checkOK   checkStatus = "OK"
+	checkWarn checkStatus = "WARN"
+	checkFail checkStatus = "FAIL"
+	checkSkip checkStatus = "SKIP"
+)
+
+// styled returns s padded to the width of the longest status and colored
+// according to its severity if color.Stdout is enabled.
+func (s checkStatus) styled() string {
+	padded := fmt.Sprintf("%-4s", s)
+	switch s {
+	case checkOK:
+		return color.Stdout.Green(padded)
+	case checkWarn:
+		return color.Stdout.Yellow(padded)
+	case checkFail:
+		return color.Stdout.Red(padded)
+	}
+	return color.Stdout.DarkGray(padded)
+}
+
+// checkResult is the result of a single doctor check. Hint is a suggestion on
+// how to fix a warning or failure.
+type checkResult struct {
+	Check  string
+	Status checkStatus
+	Detail string
+	Hint   string
+}
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Args:  cobra.NoArgs,
+	Short: "Diagnose problems with the local environment",
+	Long: `Diagnose problems with the local environment that keep commands from
+working. The following are checked:
+
+  - The syntax of the config files in use and their permissions.
+  - That a cluster is selected and has a base URI.
+  - That a token is available for the cluster, whether it has expired or
+    is about to, and whether its issued at and not before times are in the
+    future, which indicates that the local clock is behind.
+  - That the CA certificates in the cluster's ca-certs and passed with
+    --cacert, if any, can be read and contain valid certificates.
+  - That the hosts of the cluster's base URI and failover URIs resolve and
+    accept TCP connections.
+
+A line is printed for each check, followed by a hint on how to fix it if it
+did not pass. Unlike other commands, doctor does not exit if the config
+cannot be loaded, but reports it. The exit status is 1 if any check failed
+and 0 otherwise, including if there were only warnings.
+
+No requests are sent to the cluster's services.`,
+	Example: `  ochami doctor
+  ochami --cluster foobar doctor
+  ochami --cacert ca.pem doctor`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var results []checkResult
+		results = append(results, checkConfigFiles()...)
+		uris, res := checkCluster(cmd)
+		results = append(results, res)
+		results = append(results, checkTokenAvailable(cmd)...)
+		results = append(results, checkCACerts(cmd)...)
+		for _, u := range uris {
+			results = append(results, checkHost(u)...)
+		}
+
+		failed := false
+		for _, r := range results {
+			fmt.Printf("[%s] %s: %s\n", r.Status.styled(), r.Check, r.Detail)
+			if r.Hint != "" && (r.Status == checkWarn || r.Status == checkFail) {
+				fmt.Printf("       hint: %s\n", r.Hint)
+			}
+			if r.Status == checkFail {
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// checkConfigFiles checks that the config could be loaded and that the config
+// files in use exist, parse, and are not writable by other users.
+func checkConfigFiles() []checkResult {
+	if rootCmd.Flag("ignore-config").Changed {
+		return []checkResult{{Check: "config", Status: checkSkip, Detail: "--ignore-config passed"}}
+	}
+
+	var results []checkResult
+	files := []string{configFile}
+	if configFile == "" {
+		files = []string{config.SystemConfigFile, config.UserConfigFile}
+	}
+	for _, f := range files {
+		check := "config file " + f
+		if config.IsRemote(f) {
+			if configLoadErr == nil {
+				results = append(results, checkResult{Check: check, Status: checkOK, Detail: "remote config loaded"})
+			}
+			continue
+		}
+		fi, err := os.Stat(f)
+		if errors.Is(err, os.ErrNotExist) {
+			results = append(results, checkResult{Check: check, Status: checkSkip, Detail: "does not exist"})
+			continue
+		} else if err != nil {
+			results = append(results, checkResult{
+				Check:  check,
+				Status: checkFail,
+				Detail: err.Error(),
+				Hint:   "make sure the file is readable by the current user",
+			})
+			continue
+		}
+		if _, err := config.ReadConfig(f); err != nil {
+			results = append(results, checkResult{
+				Check:  check,
+				Status: checkFail,
+				Detail: err.Error(),
+				Hint:   "fix the syntax or unknown keys reported; see ochami-config(5) for the format",
+			})
+			continue
+		}
+		if perm := fi.Mode().Perm(); perm&0o022 != 0 {
+			results = append(results, checkResult{
+				Check:  check,
+				Status: checkWarn,
+				Detail: fmt.Sprintf("valid, but writable by other users (mode %04o)", perm),
+				Hint:   fmt.Sprintf("run 'chmod go-w %s' so that others cannot change which cluster commands talk to", f),
+			})
+			continue
+		}
+		results = append(results, checkResult{Check: check, Status: checkOK, Detail: "valid"})
+	}
+
+	// Report why the config could not be loaded unless a file check
+	// already did
+	if configLoadErr != nil {
+		for _, r := range results {
+			if r.Status == checkFail {
+				return results
+			}
+		}
+		results = append(results, checkResult{
+			Check:  "config",
+			Status: checkFail,
+			Detail: configLoadErr.Error(),
+			Hint:   "fix the reported problem; see ochami-config(5) for the format",
+		})
+	}
+
+	return results
+}
+
+// checkCluster checks that a cluster or base URI is selected and returns the
+// base URIs to check the hosts of.
+func checkCluster(cmd *cobra.Command) ([]string, checkResult) {
+	res := checkResult{Check: "cluster"}
+	cluster, err := getCluster(cmd)
+	if err != nil {
+		res.Status = checkFail
+		res.Detail = err.Error()
+		res.Hint = "pass an existing cluster with --cluster or check default-cluster with 'ochami config show'"
+		return nil, res
+	}
+	if cluster == nil {
+		if cmd.Flag("base-uri").Changed {
+			res.Status = checkOK
+			res.Detail = "using --base-uri " + baseURI
+			return []string{baseURI}, res
+		}
+		res.Status = checkFail
+		res.Detail = "no cluster selected and no --base-uri passed"
+		res.Hint = "add a cluster with 'ochami config cluster set <name> --base-uri <uri>', or pass --cluster or --base-uri"
+		return nil, res
+	}
+	if cluster.Cluster.BaseURI == "" {
+		res.Status = checkFail
+		res.Detail = fmt.Sprintf("cluster %s has no base-uri", cluster.Name)
+		res.Hint = fmt.Sprintf("run 'ochami config cluster set %s --base-uri <uri>'", cluster.Name)
+		return nil, res
+	}
+	res.Status = checkOK
+	res.Detail = fmt.Sprintf("using cluster %s (%s)", cluster.Name, cluster.Cluster.BaseURI)
+	return append([]string{cluster.Cluster.BaseURI}, cluster.Cluster.FailoverURIs...), res
+}
+
+// checkTokenAvailable checks that a token is available, as it would be
+// determined by setTokenFromEnvVar, and that it is valid and the local clock
+// agrees with its times.
+func checkTokenAvailable(cmd *cobra.Command) []checkResult {
+	res := checkResult{Check: "token"}
+	var tok, source string
+	if cmd.Flag("token").Changed {
+		tok, source = token, "--token"
+	} else {
+		var clusterName string
+		if cmd.Flag("cluster").Changed {
+			clusterName = cmd.Flag("cluster").Value.String()
+		} else if config.GlobalConfig.DefaultCluster != "" {
+			clusterName = config.GlobalConfig.DefaultCluster
+		} else {
+			res.Status = checkFail
+			res.Detail = "no cluster selected to read a token for and --token not passed"
+			res.Hint = "pass --token, or select a cluster with --cluster or default-cluster and set its token variable"
+			return []checkResult{res}
+		}
+		var err error
+		if tok, source, err = clusterToken(clusterName); err != nil {
+			envVar := tokenEnvVar(clusterName)
+			res.Status = checkFail
+			res.Detail = err.Error()
+			res.Hint = fmt.Sprintf("export %s=<token>, set token or token-source for cluster %s, or pass --token", envVar, clusterName)
+			return []checkResult{res}
+		}
+	}
+
+	t, err := validateToken(tok)
+	if t == nil {
+		res.Status = checkFail
+		res.Detail = fmt.Sprintf("token from %s cannot be parsed: %v", source, err)
+		res.Hint = "make sure the value is a JWT, without a 'Bearer ' prefix or surrounding quotes"
+		return []checkResult{res}
+	}
+
+	now := time.Now()
+	results := []checkResult{}
+	exp := t.Expiration()
+	switch {
+	case !exp.IsZero() && exp.Before(now):
+		res.Status = checkFail
+		res.Detail = fmt.Sprintf("token from %s expired %s ago", source, now.Sub(exp).Round(time.Second))
+		res.Hint = "get a new token"
+	case !exp.IsZero() && exp.Sub(now) <= 15*time.Minute:
+		res.Status = checkWarn
+		res.Detail = fmt.Sprintf("token from %s expires in %s", source, exp.Sub(now).Round(time.Second))
+		res.Hint = "get a new token before running long operations"
+	case exp.IsZero():
+		res.Status = checkOK
+		res.Detail = fmt.Sprintf("token from %s does not expire", source)
+	default:
+		res.Status = checkOK
+		res.Detail = fmt.Sprintf("token from %s expires in %s", source, exp.Sub(now).Round(time.Second))
+	}
+	results = append(results, res)
+
+	// A token issued in the future means that this host's clock is behind
+	// that of the token issuer (or the issuer's is ahead)
+	skew := checkResult{Check: "clock", Status: checkOK, Detail: "token times are consistent with the local clock"}
+	for _, c := range []struct {
+		name string
+		t    time.Time
+	}{{"issued at (iat)", t.IssuedAt()}, {"not before (nbf)", t.NotBefore()}} {
+		if !c.t.IsZero() && c.t.Sub(now) > maxClockSkew {
+			skew.Status = checkFail
+			skew.Detail = fmt.Sprintf("token %s time is %s in the future", c.name, c.t.Sub(now).Round(time.Second))
+			skew.Hint = "synchronize the local clock (e.g. with chrony or systemd-timesyncd); services will likely reject the token as well"
+			break
+		}
+	}
+	if iat := t.IssuedAt(); skew.Status == checkOK && !iat.IsZero() && !exp.IsZero() && exp.Sub(now) > exp.Sub(iat)+maxClockSkew {
+		skew.Status = checkWarn
+		skew.Detail = "token is valid for longer than its lifetime from now, so the local clock may be behind"
+		skew.Hint = "synchronize the local clock (e.g. with chrony or systemd-timesyncd)"
+	}
+	results = append(results, skew)
+
+	return results
+}
+
+// checkCACerts checks that the CA certificate bundles configured for the
+// cluster in use (ca-certs) and passed with --cacert, if any, can be read and
+// hold certificates that are currently valid.
+func checkCACerts(cmd *cobra.Command) []checkResult {
+	cluster, _ := getCluster(cmd)
+	paths, useSystemStore := caCerts(cluster)
+	if len(paths) == 0 {
+		res := checkResult{Check: "CA certificate"}
+		if !useSystemStore {
+			res.Status = checkFail
+			res.Detail = "use-system-store is false but no CA certificates are configured"
+			res.Hint = "add CA certificate bundles to the cluster's ca-certs or pass --cacert"
+			return []checkResult{res}
+		}
+		res.Status = checkSkip
+		res.Detail = "no CA certificates configured, using system CA certificates"
+		return []checkResult{res}
+	}
+	results := make([]checkResult, 0, len(paths))
+	for _, path := range paths {
+		results = append(results, checkCACert(path))
+	}
+	return results
+}
+
+// checkCACert checks that the CA certificate bundle at path can be read and
+// holds certificates that are currently valid.
+func checkCACert(path string) checkResult {
+	res := checkResult{Check: "CA certificate"}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		res.Status = checkFail
+		res.Detail = err.Error()
+		res.Hint = "check that the path in ca-certs or passed to --cacert exists and is readable by the current user"
+		return res
+	}
+
+	var (
+		certs   []*x509.Certificate
+		expired []string
+		now     = time.Now()
+	)
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			res.Status = checkFail
+			res.Detail = fmt.Sprintf("%s contains an invalid certificate: %v", path, err)
+			res.Hint = "replace the file with a valid PEM-encoded CA certificate bundle"
+			return res
+		}
+		certs = append(certs, cert)
+		if now.After(cert.NotAfter) || now.Before(cert.NotBefore) {
+			expired = append(expired, cert.Subject.String())
+		}
+	}
+	switch {
+	case len(certs) == 0:
+		res.Status = checkFail
+		res.Detail = fmt.Sprintf("%s contains no PEM-encoded certificates", path)
+		res.Hint = "pass a PEM-encoded CA certificate bundle (e.g. converted with 'openssl x509 -inform der')"
+	case len(expired) > 0:
+		res.Status = checkWarn
+		res.Detail = fmt.Sprintf("%s contains certificates that are expired or not yet valid: %s", path, strings.Join(expired, "; "))
+		res.Hint = "get a current CA certificate for the cluster, or check the local clock"
+	default:
+		res.Status = checkOK
+		res.Detail = fmt.Sprintf("%s contains %d certificate(s)", path, len(certs))
+	}
+	return res
+}
+
+// checkHost checks that the host of base URI uri resolves and accepts TCP
+// connections on the URI's port.
+func checkHost(uri string) []checkResult {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" {
+		return []checkResult{{
+			Check:  "base URI " + uri,
+			Status: checkFail,
+			Detail: "not a valid URI",
+			Hint:   "set the base URI to a URI like https://foobar.openchami.cluster",
+		}}
+	}
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+
+	dns := checkResult{Check: "DNS " + host}
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		dns.Status = checkFail
+		dns.Detail = err.Error()
+		dns.Hint = "check the host name in the base URI and the resolvers in /etc/resolv.conf, or add it to /etc/hosts"
+		return []checkResult{dns}
+	}
+	dns.Status = checkOK
+	dns.Detail = "resolves to " + strings.Join(addrs, ", ")
+
+	addr := net.JoinHostPort(host, port)
+	tcp := checkResult{Check: "TCP " + addr}
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", addr, doctorTimeout)
+	if err != nil {
+		tcp.Status = checkFail
+		tcp.Detail = err.Error()
+		tcp.Hint = "check that the services are running and that no firewall or proxy blocks the port; proxies from HTTPS_PROXY are not used by this check"
+		return []checkResult{dns, tcp}
+	}
+	conn.Close()
+	tcp.Status = checkOK
+	tcp.Detail = fmt.Sprintf("connected in %s", time.Since(start).Round(time.Millisecond))
+	log.Logger.Debug().Msgf("doctor: connected to %s", addr)
+
+	return []checkResult{dns, tcp}
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
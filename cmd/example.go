@@ -0,0 +1,73 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+// exampleCmd represents the example command
+var exampleCmd = &cobra.Command{
+	Use:       "example [--list] <resource>",
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: schema.Names(),
+	Short:     "Print an example payload file for a resource",
+	Long: `Print an example payload file for a resource, to be filled in and passed
+to commands with -f. The example is generated from the same data structures
+that ochami reads payloads into (see 'ochami schema'), so it contains every
+field that is accepted. Fields are set to example values or, where there is
+none, to empty values, and lists contain a single item.
+
+In YAML, the default, each field is preceded by a comment describing it,
+its type, and whether it is required. Pass '-F json' for a JSON payload,
+which has no comments. Pass --list to list the available resources.`,
+	Example: `  ochami example --list
+  ochami example component > components.yaml
+  ochami example discovery-items -F json > nodes.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cmd.Flag("list").Changed {
+			for _, name := range schema.Names() {
+				fmt.Printf("%-20s %s\n", name, schema.Resources[name].Description)
+			}
+			return
+		}
+		if len(args) == 0 {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+
+		outFmt, err := cmd.Flags().GetString("output-format")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+			os.Exit(1)
+		}
+		if _, ok := schema.Resources[args[0]]; !ok {
+			log.Logger.Error().Msgf("unknown resource %q (available resources: %s)", args[0], strings.Join(schema.Names(), ", "))
+			os.Exit(1)
+		}
+		out, err := schema.Example(args[0], outFmt)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to generate example")
+			os.Exit(1)
+		}
+		fmt.Print(string(out))
+	},
+}
+
+func init() {
+	exampleCmd.Flags().Bool("list", false, "list available resources and exit")
+	exampleCmd.Flags().StringP("output-format", "F", "yaml", "format of example payload (yaml,json)")
+	_ = exampleCmd.RegisterFlagCompletionFunc("output-format", cobra.FixedCompletions([]string{"yaml", "json"}, cobra.ShellCompDirectiveNoFileComp))
+
+	rootCmd.AddCommand(exampleCmd)
+}
@@ -0,0 +1,261 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/OpenCHAMI/ochami/internal/config"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Annotations holding the lifecycle of commands and flags (see
+// setCommandDeprecated and setCommandExperimental).
+const (
+	// deprecatedAnnotation holds the JSON-encoded deprecation of a command
+	// or flag.
+	deprecatedAnnotation = "ochami_deprecated"
+
+	// experimentalAnnotation holds the name of the experimental feature a
+	// command or flag belongs to.
+	experimentalAnnotation = "ochami_experimental"
+)
+
+// Environment variables controlling experimental features and deprecation
+// warnings, in addition to the features key of the config.
+const (
+	// experimentalEnvVar holds the comma-separated names of experimental
+	// features to enable, or "all".
+	experimentalEnvVar = "OCHAMI_EXPERIMENTAL"
+
+	// noDeprecationWarningsEnvVar suppresses deprecation warnings if not
+	// empty.
+	noDeprecationWarningsEnvVar = "OCHAMI_NO_DEPRECATION_WARNINGS"
+)
+
+// allFeatures enables all experimental features when listed in features.enable
+// or OCHAMI_EXPERIMENTAL.
+const allFeatures = "all"
+
+// deprecation describes a deprecated command or flag. All fields are
+// optional.
+type deprecation struct {
+	Since       string `json:"since,omitempty"`       // version of ochami that deprecated it
+	Removal     string `json:"removal,omitempty"`     // version of ochami it is planned to be removed in
+	Replacement string `json:"replacement,omitempty"` // what to use instead, e.g. "--network"
+	Reason      string `json:"reason,omitempty"`      // why it is deprecated
+}
+
+// String returns d as the end of a sentence saying that something is
+// deprecated.
+func (d deprecation) String() string {
+	var sb strings.Builder
+	if d.Since != "" {
+		fmt.Fprintf(&sb, " since %s", d.Since)
+	}
+	if d.Reason != "" {
+		fmt.Fprintf(&sb, " (%s)", d.Reason)
+	}
+	if d.Removal != "" {
+		fmt.Fprintf(&sb, " and will be removed in %s", d.Removal)
+	}
+	if d.Replacement != "" {
+		fmt.Fprintf(&sb, "; use %s instead", d.Replacement)
+	}
+	return sb.String()
+}
+
+// warnedDeprecations holds the subjects of the deprecation warnings logged so
+// far, so that each is logged once per invocation.
+var warnedDeprecations = struct {
+	sync.Mutex
+	m map[string]bool
+}{m: make(map[string]bool)}
+
+// setCommandDeprecated marks cmd as deprecated. Running it logs a deprecation
+// warning (see warnDeprecated), and its short description says that it is
+// deprecated. Unlike cobra's Deprecated, the command stays listed.
+func setCommandDeprecated(cmd *cobra.Command, d deprecation) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	b, _ := json.Marshal(d)
+	cmd.Annotations[deprecatedAnnotation] = string(b)
+}
+
+// setFlagDeprecated marks the flag name of cmd as deprecated. Passing it logs a
+// deprecation warning (see warnDeprecated) and it is hidden from the usage of
+// cmd. Unlike pflag's MarkDeprecated, the warning is structured and can be
+// suppressed.
+func setFlagDeprecated(cmd *cobra.Command, name string, d deprecation) {
+	b, _ := json.Marshal(d)
+	if err := cmd.Flags().SetAnnotation(name, deprecatedAnnotation, []string{string(b)}); err != nil {
+		panic(err)
+	}
+	cmd.Flags().Lookup(name).Hidden = true
+}
+
+// setCommandExperimental marks cmd as belonging to the experimental feature.
+// It refuses to run unless the feature is enabled (see featureEnabled), and
+// its short description says that it is experimental.
+func setCommandExperimental(cmd *cobra.Command, feature string) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[experimentalAnnotation] = feature
+}
+
+// setFlagExperimental marks the flag name of cmd as belonging to the
+// experimental feature. Passing it is an error unless the feature is enabled
+// (see featureEnabled), and its usage says that it is experimental.
+func setFlagExperimental(cmd *cobra.Command, name, feature string) {
+	if err := cmd.Flags().SetAnnotation(name, experimentalAnnotation, []string{feature}); err != nil {
+		panic(err)
+	}
+}
+
+// commandDeprecation returns the deprecation of cmd, if it is deprecated.
+func commandDeprecation(cmd *cobra.Command) (deprecation, bool) {
+	return parseDeprecation(cmd.Annotations[deprecatedAnnotation])
+}
+
+// flagDeprecation returns the deprecation of f, if it is deprecated.
+func flagDeprecation(f *pflag.Flag) (deprecation, bool) {
+	if a := f.Annotations[deprecatedAnnotation]; len(a) > 0 {
+		return parseDeprecation(a[0])
+	}
+	return deprecation{}, false
+}
+
+// parseDeprecation returns the deprecation encoded in s by
+// setCommandDeprecated or setFlagDeprecated, if s is not empty.
+func parseDeprecation(s string) (deprecation, bool) {
+	if s == "" {
+		return deprecation{}, false
+	}
+	var d deprecation
+	_ = json.Unmarshal([]byte(s), &d)
+	return d, true
+}
+
+// flagFeature returns the experimental feature f belongs to, if any.
+func flagFeature(f *pflag.Flag) string {
+	if a := f.Annotations[experimentalAnnotation]; len(a) > 0 {
+		return a[0]
+	}
+	return ""
+}
+
+// enabledFeatures returns the names of the experimental features enabled in
+// the config (features.enable) and in OCHAMI_EXPERIMENTAL.
+func enabledFeatures() []string {
+	features := slices.Clone(config.GlobalConfig.Features.Enable)
+	for _, f := range strings.Split(os.Getenv(experimentalEnvVar), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			features = append(features, f)
+		}
+	}
+	return features
+}
+
+// featureEnabled reports whether the experimental feature is enabled, i.e. it
+// or "all" is listed in features.enable of the config or in
+// OCHAMI_EXPERIMENTAL.
+func featureEnabled(feature string) bool {
+	enabled := enabledFeatures()
+	return slices.Contains(enabled, feature) || slices.Contains(enabled, allFeatures)
+}
+
+// deprecationWarningsEnabled reports whether deprecation warnings are logged,
+// which is the case unless features.deprecation-warnings is false in the
+// config or OCHAMI_NO_DEPRECATION_WARNINGS is set.
+func deprecationWarningsEnabled() bool {
+	if os.Getenv(noDeprecationWarningsEnvVar) != "" {
+		return false
+	}
+	dw := config.GlobalConfig.Features.DeprecationWarnings
+	return dw == nil || *dw
+}
+
+// warnDeprecated logs a warning that subject (e.g. `command "ochami foo"`) is
+// deprecated as described by d, with the fields of d as structured fields of
+// the message. Each subject is only warned about once per invocation, and not
+// at all if deprecation warnings are suppressed (see
+// deprecationWarningsEnabled).
+func warnDeprecated(subject string, d deprecation) {
+	if !deprecationWarningsEnabled() {
+		return
+	}
+	warnedDeprecations.Lock()
+	defer warnedDeprecations.Unlock()
+	if warnedDeprecations.m[subject] {
+		return
+	}
+	warnedDeprecations.m[subject] = true
+
+	ev := log.Logger.Warn().Str("deprecated", subject)
+	for _, field := range [][2]string{{"since", d.Since}, {"removal", d.Removal}, {"replacement", d.Replacement}} {
+		if field[1] != "" {
+			ev = ev.Str(field[0], field[1])
+		}
+	}
+	ev.Msgf("%s is deprecated%s", subject, d)
+}
+
+// applyCommandLifecycles marks the deprecated and experimental commands and
+// flags under cmd as such in their descriptions. It is called once all
+// commands are registered.
+func applyCommandLifecycles(cmd *cobra.Command) {
+	if _, ok := commandDeprecation(cmd); ok {
+		cmd.Short = "(deprecated) " + cmd.Short
+	} else if cmd.Annotations[experimentalAnnotation] != "" {
+		cmd.Short = "(experimental) " + cmd.Short
+	}
+	cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		if flagFeature(f) != "" && !strings.HasPrefix(f.Usage, "(experimental) ") {
+			f.Usage = "(experimental) " + f.Usage
+		}
+	})
+	for _, c := range cmd.Commands() {
+		applyCommandLifecycles(c)
+	}
+}
+
+// checkCommandLifecycle exits with an error if cmd, one of its parents, or one
+// of the flags passed to it belongs to an experimental feature that is not
+// enabled, and warns about the deprecated ones. It is called before any
+// command runs.
+func checkCommandLifecycle(cmd *cobra.Command) {
+	for c := cmd; c != nil; c = c.Parent() {
+		if feature := c.Annotations[experimentalAnnotation]; feature != "" && !featureEnabled(feature) {
+			log.Logger.Error().Msgf("%q is part of the experimental feature %q, which is not enabled; enable it with features.enable in the config or %s=%s",
+				c.CommandPath(), feature, experimentalEnvVar, feature)
+			os.Exit(1)
+		}
+	}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if feature := flagFeature(f); feature != "" && !featureEnabled(feature) {
+			log.Logger.Error().Msgf("--%s is part of the experimental feature %q, which is not enabled; enable it with features.enable in the config or %s=%s",
+				f.Name, feature, experimentalEnvVar, feature)
+			os.Exit(1)
+		}
+	})
+
+	for c := cmd; c != nil; c = c.Parent() {
+		if d, ok := commandDeprecation(c); ok {
+			warnDeprecated(fmt.Sprintf("command %q", c.CommandPath()), d)
+		}
+	}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if d, ok := flagDeprecation(f); ok {
+			warnDeprecated(fmt.Sprintf("flag --%s of %q", f.Name, cmd.CommandPath()), d)
+		}
+	})
+}
@@ -0,0 +1,217 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/OpenCHAMI/ochami/pkg/xname"
+	"github.com/openchami/schemas/schemas/csm"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Default port of the SSH server that serves the host console on BMCs
+	// that support it (e.g. OpenBMC).
+	defaultConsoleSSHPort = "2200"
+
+	// ipmitool reads the password from this environment variable when -E
+	// is passed, which keeps it off the command line.
+	ipmiPasswordEnvVar = "IPMI_PASSWORD"
+)
+
+// nodeConsoleCmd represents the node-console command
+var nodeConsoleCmd = &cobra.Command{
+	Use:   "console [--method ipmi|ssh] [--user <user>] [--port <port>] [--print] <xname|mac|nid>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Open a serial-over-LAN console to a node via its BMC",
+	Long: `Open a serial-over-LAN (SOL) console to a node via its BMC. The node can be
+identified by its xname, the MAC address of one of its ethernet interfaces, or
+its NID, which is resolved to its xname using SMD. The BMC's xname is derived
+from the node's xname and its address and username are looked up from the
+redfish endpoint stored for it in SMD. The address used is the endpoint's
+FQDN, hostname, or IP address, in that order of preference.
+
+With --method ipmi (the default), ipmitool is run with the lanplus interface.
+The password is passed to ipmitool via the IPMI_PASSWORD environment variable.
+If IPMI_PASSWORD is not already set, the password stored in SMD is used,
+if SMD returns one. With --method ssh, ssh is run against the console port
+that some BMCs (e.g. OpenBMC) serve the host console on, 2200 by default.
+
+Pass --print to print the command instead of running it. The password is
+never printed.`,
+	Example: `  ochami node console x1000c1s7b0n0
+  ochami node console --method ssh x1000c1s7b0n0
+  ochami node console 12
+  ochami node console --user admin --print x1000c1s7b0n0`,
+	Run: func(cmd *cobra.Command, args []string) {
+		method := cmd.Flag("method").Value.String()
+		if method != "ipmi" && method != "ssh" {
+			log.Logger.Error().Msgf("unknown console method %q, must be ipmi or ssh", method)
+			os.Exit(1)
+		}
+
+		// Without a base URI, we cannot do anything
+		smdBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to SMD
+		smdClient, err := smd.NewClient(smdBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		// Look up the node's BMC
+		nodeXname := xnamesFromIdentifiers(smdClient, args)[0]
+		bmcXname, err := xname.NodeXnameToBMCXname(nodeXname)
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to determine BMC of node %s", nodeXname)
+			os.Exit(1)
+		}
+		rfe, err := smdClient.GetRedfishEndpoint(bmcXname, token)
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msgf("SMD redfish endpoint request for BMC %s yielded unsuccessful HTTP response", bmcXname)
+			} else {
+				log.Logger.Error().Err(err).Msgf("failed to get redfish endpoint for BMC %s", bmcXname)
+			}
+			os.Exit(1)
+		}
+		addr := bmcAddress(rfe)
+		if addr == "" {
+			log.Logger.Error().Msgf("redfish endpoint %s has no FQDN, hostname, or IP address", bmcXname)
+			os.Exit(1)
+		}
+		user := rfe.User
+		if cmd.Flag("user").Changed {
+			user = cmd.Flag("user").Value.String()
+		}
+		if user == "" {
+			log.Logger.Error().Msgf("redfish endpoint %s has no username, pass --user", bmcXname)
+			os.Exit(1)
+		}
+		// The address and user come from SMD, so refuse values that the
+		// console command would take for options
+		if strings.HasPrefix(addr, "-") {
+			log.Logger.Error().Msgf("redfish endpoint %s has invalid address %q", bmcXname, addr)
+			os.Exit(1)
+		}
+		if strings.HasPrefix(user, "-") {
+			log.Logger.Error().Msgf("invalid username %q for redfish endpoint %s", user, bmcXname)
+			os.Exit(1)
+		}
+		log.Logger.Debug().Msgf("node %s has BMC %s at %s (user %s)", nodeXname, bmcXname, addr, user)
+
+		// Build the console command
+		var argv, env []string
+		switch method {
+		case "ipmi":
+			argv = []string{"ipmitool", "-I", "lanplus", "-H", addr}
+			if cmd.Flag("port").Changed {
+				argv = append(argv, "-p", cmd.Flag("port").Value.String())
+			}
+			argv = append(argv, "-U", user, "-E", "sol", "activate")
+			if _, set := os.LookupEnv(ipmiPasswordEnvVar); !set && rfe.Password != "" {
+				env = append(env, ipmiPasswordEnvVar+"="+rfe.Password)
+			}
+		case "ssh":
+			port := defaultConsoleSSHPort
+			if cmd.Flag("port").Changed {
+				port = cmd.Flag("port").Value.String()
+			}
+			argv = []string{"ssh", "-p", port, "--", user + "@" + addr}
+		}
+
+		if cmd.Flag("print").Changed {
+			if method == "ipmi" {
+				fmt.Printf("%s=<password> ", ipmiPasswordEnvVar)
+			}
+			fmt.Println(shellJoin(argv))
+			return
+		}
+
+		exe, err := exec.LookPath(argv[0])
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("%s is required for --method %s (pass --print to only print the command)", argv[0], method)
+			os.Exit(1)
+		}
+		if method == "ipmi" && len(env) == 0 {
+			if _, set := os.LookupEnv(ipmiPasswordEnvVar); !set {
+				log.Logger.Warn().Msgf("SMD did not return a password for %s and %s is not set", bmcXname, ipmiPasswordEnvVar)
+			}
+		}
+		c := exec.Command(exe, argv[1:]...)
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		c.Env = append(os.Environ(), env...)
+		if err := c.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+				os.Exit(exitErr.ExitCode())
+			}
+			log.Logger.Error().Err(err).Msgf("failed to run %s", argv[0])
+			os.Exit(1)
+		}
+	},
+}
+
+// bmcAddress returns the address to reach the BMC of rfe at: its FQDN if set,
+// its hostname (with its domain, if set) otherwise, and its IP address as a
+// last resort.
+func bmcAddress(rfe csm.RedfishEndpoint) string {
+	switch {
+	case rfe.FQDN != "":
+		return rfe.FQDN
+	case rfe.Hostname != "" && rfe.Domain != "":
+		return rfe.Hostname + "." + rfe.Domain
+	case rfe.Hostname != "":
+		return rfe.Hostname
+	}
+	return rfe.IPAddress
+}
+
+// shellJoin joins args into a command line that a POSIX shell would split back
+// into args, quoting any argument that needs it.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if a != "" && strings.IndexFunc(a, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@%+=:,./-_", r))
+		}) < 0 {
+			quoted[i] = a
+		} else {
+			quoted[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+func init() {
+	nodeConsoleCmd.Flags().String("method", "ipmi", "how to connect to the console (ipmi,ssh)")
+	nodeConsoleCmd.Flags().String("user", "", "BMC username to use instead of the one stored in SMD")
+	nodeConsoleCmd.Flags().String("port", "", "port to connect to on the BMC (default 623 for ipmi, "+defaultConsoleSSHPort+" for ssh)")
+	nodeConsoleCmd.Flags().Bool("print", false, "print the console command instead of running it")
+
+	setCommandServices(nodeConsoleCmd, "smd")
+
+	nodeCmd.AddCommand(nodeConsoleCmd)
+}
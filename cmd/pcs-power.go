@@ -0,0 +1,338 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/internal/cli"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/pcs"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// powerBatch is the outcome of the transition of a single batch of a power
+// rollout.
+type powerBatch struct {
+	Batch            int            `json:"batch"`
+	TransitionID     string         `json:"transitionID,omitempty"`
+	TransitionStatus string         `json:"transitionStatus,omitempty"`
+	Xnames           []string       `json:"xnames"`
+	TaskCounts       pcs.TaskCounts `json:"taskCounts"`
+	FailedTasks      []pcs.Task     `json:"failedTasks,omitempty"`
+}
+
+// powerRollout is the outcome of a power rollout, which is printed when it
+// ends, whether or not all batches were run.
+type powerRollout struct {
+	Operation  string       `json:"operation"`
+	Total      int          `json:"total"`
+	Succeeded  int          `json:"succeeded"`
+	Failed     int          `json:"failed"`
+	NotStarted int          `json:"notStarted"`
+	Stopped    string       `json:"stopped,omitempty"`
+	Batches    []powerBatch `json:"batches"`
+}
+
+// pcsPowerCmd represents the pcs-power command
+var pcsPowerCmd = &cobra.Command{
+	Use:   "power <operation> [--group <label>,...] [--batch-size <n>] [--stagger <duration>] [--max-failures <n>] [<xname|mac|nid>...]",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Perform a power operation on components, optionally in staggered batches",
+	Long: `Perform a power operation on components, optionally in staggered batches.
+operation is one of: ` + strings.Join(pcs.Operations, ", ") + `.
+
+The components are those passed as arguments, by xname, MAC address, or NID
+(resolved to xnames using SMD), and the members of the SMD groups passed with
+--group. By default, a single PCS transition is started for
+all of them. With --batch-size, the components are split into batches of that
+size and a transition is started for each batch in turn, waiting for the
+previous one to finish and then for --stagger, so that a whole group is not
+power cycled at once. Progress is reported on standard error.
+
+The rollout stops, without starting further batches, once more than
+--max-failures components have failed (0 by default, i.e. on the first
+failure; pass -1 to never stop), or if SIGINT or SIGTERM is received (the
+current batch is still waited for). A summary of the rollout is printed at the
+end.
+
+Unless --force is passed, the user is asked to confirm before the rollout
+starts.
+
+This command sends POSTs and GETs to PCS's /transitions endpoint. An access
+token is required.`,
+	Example: `  ochami pcs power soft-off x1000c1s7b0n0 x1000c1s7b1n0
+  ochami pcs power on 12 13 de:ad:be:ef:00:01
+  ochami pcs power soft-restart --group compute --batch-size 16 --stagger 10s
+  ochami pcs power on --group compute --batch-size 32 --max-failures 4 --force`,
+	Run: func(cmd *cobra.Command, args []string) {
+		operation := args[0]
+		if !slices.Contains(pcs.Operations, operation) {
+			log.Logger.Error().Msgf("unknown power operation %q, must be one of: %s", operation, strings.Join(pcs.Operations, ", "))
+			os.Exit(1)
+		}
+		batchSize, err := cmd.Flags().GetInt("batch-size")
+		if err != nil || batchSize < 0 {
+			log.Logger.Error().Err(err).Msg("invalid --batch-size")
+			os.Exit(1)
+		}
+		stagger, err := cmd.Flags().GetDuration("stagger")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("invalid --stagger")
+			os.Exit(1)
+		}
+		pollInterval, err := cmd.Flags().GetDuration("poll-interval")
+		if err != nil || pollInterval <= 0 {
+			log.Logger.Error().Err(err).Msg("invalid --poll-interval")
+			os.Exit(1)
+		}
+		maxFailures, err := cmd.Flags().GetInt("max-failures")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("invalid --max-failures")
+			os.Exit(1)
+		}
+		var deadline *int
+		if cmd.Flag("deadline").Changed {
+			d, err := cmd.Flags().GetInt("deadline")
+			if err != nil || d <= 0 {
+				log.Logger.Error().Err(err).Msg("invalid --deadline")
+				os.Exit(1)
+			}
+			deadline = &d
+		}
+
+		// Without a base URI, we cannot do anything
+		baseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for PCS")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to PCS
+		pcsClient, err := pcs.NewClient(baseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new PCS client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(pcsClient.OchamiClient)
+
+		// Collect the components to power, in the order passed
+		var xnames []string
+		groups, err := cmd.Flags().GetStringSlice("group")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("unable to fetch group list")
+			os.Exit(1)
+		}
+		if len(args) > 1 || len(groups) > 0 {
+			smdClient, err := smd.NewClient(baseURI, insecure)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("error creating new SMD client")
+				os.Exit(1)
+			}
+			configureClient(smdClient.OchamiClient)
+			if len(args) > 1 {
+				xnames = xnamesFromIdentifiers(smdClient, args[1:])
+			}
+			for _, label := range groups {
+				group, err := smdClient.GetGroup(label, token)
+				if err != nil {
+					if errors.Is(err, client.UnsuccessfulHTTPError) {
+						log.Logger.Error().Err(err).Msgf("SMD group request for %s yielded unsuccessful HTTP response", label)
+					} else {
+						log.Logger.Error().Err(err).Msgf("failed to get members of group %s", label)
+					}
+					os.Exit(1)
+				}
+				log.Logger.Debug().Msgf("group %s has %d members", label, len(group.Members.IDs))
+				xnames = append(xnames, group.Members.IDs...)
+			}
+		}
+		xnames = uniqueStrings(xnames)
+		if len(xnames) == 0 {
+			log.Logger.Error().Msg("no components to power, pass components or --group")
+			os.Exit(1)
+		}
+		batches := splitBatches(xnames, batchSize)
+
+		// Ask before powering unless --force was passed
+		if !cmd.Flag("force").Changed {
+			log.Logger.Debug().Msg("--force not passed, prompting user to confirm power operation")
+			if !loopYesNo(fmt.Sprintf("Really %s %d components in %d batch(es)?", operation, len(xnames), len(batches))) {
+				log.Logger.Info().Msg("User aborted power operation")
+				os.Exit(0)
+			} else {
+				log.Logger.Debug().Msg("User answered affirmatively to power components")
+			}
+		}
+
+		result := powerRollout{Operation: operation, Total: len(xnames), Batches: []powerBatch{}}
+		for i, batch := range batches {
+			if runner.Interrupted() {
+				result.Stopped = "interrupted"
+				break
+			}
+			if i > 0 && stagger > 0 {
+				fmt.Fprintf(progressWriter, "waiting %s before batch %d/%d\n", stagger, i+1, len(batches))
+				select {
+				case <-time.After(stagger):
+				case <-runner.Context().Done():
+				}
+				if runner.Interrupted() {
+					result.Stopped = "interrupted"
+					break
+				}
+			}
+
+			pb, err := runPowerBatch(pcsClient, operation, deadline, batch, pollInterval, fmt.Sprintf("batch %d/%d", i+1, len(batches)))
+			pb.Batch = i + 1
+			result.Batches = append(result.Batches, pb)
+			result.Succeeded += pb.TaskCounts.Succeeded
+			result.Failed += pb.TaskCounts.Failed
+			if err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msgf("PCS transition request for batch %d yielded unsuccessful HTTP response", i+1)
+				} else {
+					log.Logger.Error().Err(err).Msgf("failed to power batch %d", i+1)
+				}
+				result.Stopped = "error"
+				break
+			}
+			if maxFailures >= 0 && result.Failed > maxFailures {
+				log.Logger.Error().Msgf("%d components failed, more than --max-failures (%d), not starting further batches", result.Failed, maxFailures)
+				result.Stopped = "max-failures"
+				break
+			}
+		}
+		for _, pb := range result.Batches {
+			if pb.TransitionID == "" {
+				result.NotStarted += len(pb.Xnames)
+			}
+		}
+		for _, batch := range batches[len(result.Batches):] {
+			result.NotStarted += len(batch)
+		}
+
+		// Print summary
+		rBytes, err := json.Marshal(result)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to marshal power rollout summary")
+			os.Exit(1)
+		}
+		printEnvelope(cmd, client.HTTPEnvelope{Body: rBytes})
+
+		switch {
+		case result.Stopped == "interrupted":
+			log.Logger.Warn().Msgf("interrupted, %d of %d components not started", result.NotStarted, result.Total)
+			os.Exit(cli.ExitInterrupted)
+		case result.Stopped != "" || result.Failed > 0:
+			os.Exit(1)
+		}
+	},
+}
+
+// runPowerBatch starts a transition performing operation on xnames and waits
+// for it to finish, polling it every pollInterval and reporting progress on
+// standard error, prefixed with label. The outcome of the batch is returned
+// along with any error that prevented it from being started or followed.
+func runPowerBatch(pcsClient *pcs.PCSClient, operation string, deadline *int, xnames []string, pollInterval time.Duration, label string) (powerBatch, error) {
+	pb := powerBatch{Xnames: xnames}
+	params := pcs.TransitionParameters{
+		Operation:           operation,
+		TaskDeadlineMinutes: deadline,
+	}
+	for _, x := range xnames {
+		params.Location = append(params.Location, pcs.Location{Xname: x})
+	}
+	t, err := pcsClient.StartTransition(params, token)
+	if err != nil {
+		return pb, err
+	}
+	pb.TransitionID = t.TransitionID
+	fmt.Fprintf(progressWriter, "%s: started %s transition %s for %d components\n", label, operation, t.TransitionID, len(xnames))
+
+	// Keep following the transition even if interrupted, since PCS carries
+	// it out regardless
+	var last pcs.TaskCounts
+	for {
+		t, err = pcsClient.GetTransition(pb.TransitionID, token)
+		if err != nil {
+			return pb, err
+		}
+		pb.TransitionStatus = t.TransitionStatus
+		pb.TaskCounts = t.TaskCounts
+		if t.TaskCounts != last {
+			c := t.TaskCounts
+			fmt.Fprintf(progressWriter, "%s: %s, %d/%d succeeded, %d failed, %d in progress\n", label, t.TransitionStatus, c.Succeeded, c.Total, c.Failed, c.InProgress)
+			last = t.TaskCounts
+		}
+		if t.Finished() {
+			break
+		}
+		time.Sleep(pollInterval)
+	}
+	for _, task := range t.Tasks {
+		if task.TaskStatus == pcs.TaskStatusFailed {
+			pb.FailedTasks = append(pb.FailedTasks, task)
+		}
+	}
+
+	return pb, nil
+}
+
+// splitBatches splits items into consecutive batches of size items. If size is
+// less than 1, a single batch with all items is returned.
+func splitBatches[T any](items []T, size int) [][]T {
+	if size < 1 || size >= len(items) {
+		return [][]T{items}
+	}
+	var batches [][]T
+	for len(items) > size {
+		batches = append(batches, items[:size])
+		items = items[size:]
+	}
+	return append(batches, items)
+}
+
+// uniqueStrings returns s without duplicates, keeping the first occurrence of
+// each string.
+func uniqueStrings(s []string) []string {
+	seen := make(map[string]bool, len(s))
+	var u []string
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			u = append(u, v)
+		}
+	}
+	return u
+}
+
+func init() {
+	pcsPowerCmd.Flags().StringSlice("group", []string{}, "one or more SMD groups whose members to power")
+	pcsPowerCmd.Flags().Int("batch-size", 0, "number of components per transition (0 for all at once)")
+	addDurationFlag(pcsPowerCmd, "stagger", 0, "time to wait between batches (e.g. 10s)")
+	pcsPowerCmd.Flags().Int("max-failures", 0, "stop starting batches once more than this many components failed (-1 to never stop)")
+	pcsPowerCmd.Flags().Int("deadline", 0, "minutes PCS may take for each task of a transition (PCS default if not passed)")
+	addDurationFlag(pcsPowerCmd, "poll-interval", 5*time.Second, "how often to check the progress of a transition")
+	pcsPowerCmd.Flags().Bool("force", false, "do not ask before powering components")
+	pcsPowerCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+
+	setCommandServices(pcsPowerCmd, "pcs", "smd")
+	setCommandMutating(pcsPowerCmd, true)
+
+	pcsCmd.AddCommand(pcsPowerCmd)
+}
@@ -0,0 +1,32 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/spf13/cobra"
+)
+
+// pcsCmd represents the pcs command
+var pcsCmd = &cobra.Command{
+	Use:   "pcs",
+	Args:  cobra.NoArgs,
+	Short: "Communicate with the Power Control Service (PCS)",
+	Long:  `Communicate with the Power Control Service (PCS). This is a metacommand.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pcsCmd)
+}
@@ -0,0 +1,57 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/color"
+	"github.com/OpenCHAMI/ochami/internal/config"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/internal/plugin"
+	"github.com/spf13/cobra"
+)
+
+// pluginListCmd represents the plugin-list command
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Args:  cobra.NoArgs,
+	Short: "List plugins found on PATH",
+	Long: `List plugins found on PATH, i.e. executables named ochami-<name>, along with
+the path of the executable that is run for each. Plugins that cannot be run
+because they have the same name as a built-in command, and executables that
+are never run because one with the same name comes earlier in PATH, are
+reported as warnings.`,
+	Example: `  ochami plugin list`,
+	Run: func(cmd *cobra.Command, args []string) {
+		plugins := plugin.List(config.ProgName)
+		if len(plugins) == 0 {
+			log.Logger.Info().Msg("no plugins found in PATH")
+			return
+		}
+
+		tw := color.NewTable(os.Stdout)
+		fmt.Fprintln(tw, "NAME\tPATH")
+		for _, p := range plugins {
+			fmt.Fprintf(tw, "%s\t%s\n", p.Name, p.Path)
+		}
+		if err := tw.Flush(); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to print plugins")
+			os.Exit(1)
+		}
+
+		for _, p := range plugins {
+			if isBuiltinCommand(p.Name) {
+				log.Logger.Warn().Msgf("%s is never run because %s is a built-in command", p.Path, p.Name)
+			}
+			for _, s := range p.Shadowed {
+				log.Logger.Warn().Msgf("%s is never run because it is shadowed by %s", s, p.Path)
+			}
+		}
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginListCmd)
+}
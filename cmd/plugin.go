@@ -0,0 +1,227 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/OpenCHAMI/ochami/internal/config"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/internal/plugin"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// pluginEnvPrefix is prepended to the names of the environment variables that
+// are passed to plugins.
+var pluginEnvPrefix = strings.ToUpper(config.ProgName) + "_"
+
+// pluginCmd represents the plugin command
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Args:  cobra.NoArgs,
+	Short: "Manage external subcommands (plugins)",
+	Long: `Manage external subcommands (plugins). This is a metacommand.
+
+Any executable on PATH named ochami-<name> can be run as 'ochami <name>',
+unless <name> is the name of a built-in command. Global flags passed before
+<name> are processed by ochami, and the resolved configuration is passed to
+the plugin via environment variables. Arguments after <name> are passed to
+the plugin as is. See ochami-plugin(1) for the environment variables set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pluginCmd)
+}
+
+// isBuiltinCommand reports whether name is the name or alias of a subcommand of
+// the root command, or is reserved by cobra.
+func isBuiltinCommand(name string) bool {
+	if name == "help" || strings.HasPrefix(name, "__") {
+		return true
+	}
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginArgIndex returns the index of the first argument in args that is not a
+// global flag or the value of one, i.e. the name of the subcommand to run. If
+// there is none, -1 is returned.
+func pluginArgIndex(args []string) int {
+	flags := rootCmd.PersistentFlags()
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return -1
+		case strings.HasPrefix(arg, "--"):
+			name, _, hasValue := strings.Cut(arg[2:], "=")
+			if f := flags.Lookup(name); f != nil && f.NoOptDefVal == "" && !hasValue {
+				i++
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// Shorthand flags can be combined (e.g. -kv) and the last
+			// one can take a value, either attached or as the next
+			// argument.
+			for j := 1; j < len(arg); j++ {
+				f := flags.ShorthandLookup(arg[j : j+1])
+				if f == nil || f.NoOptDefVal != "" {
+					continue
+				}
+				if j == len(arg)-1 {
+					i++
+				}
+				break
+			}
+		default:
+			return i
+		}
+	}
+	return -1
+}
+
+// runPluginIfRequested checks whether args (the command line arguments without
+// the program name) invoke a plugin and, if so, runs it and exits with its exit
+// status. If args invoke a built-in command or no plugin matches, it returns
+// and the command line is handled as usual.
+func runPluginIfRequested(args []string) {
+	i := pluginArgIndex(args)
+	if i < 0 || isBuiltinCommand(args[i]) {
+		return
+	}
+	name := args[i]
+	path, err := plugin.Lookup(config.ProgName, name)
+	if err != nil {
+		return
+	}
+
+	// Process global flags as if a built-in command had been run
+	if err := rootCmd.PersistentFlags().Parse(args[:i]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", config.ProgName, err)
+		os.Exit(1)
+	}
+	InitConfig()
+	InitLogging()
+	InitOutput()
+	log.Logger.Debug().Msgf("running plugin %s: %s", name, path)
+
+	c := exec.Command(path, args[i+1:]...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(), pluginEnv()...)
+	if err := c.Start(); err != nil {
+		log.Logger.Error().Err(err).Msgf("failed to run plugin %s", name)
+		os.Exit(1)
+	}
+
+	// The plugin gets signals from the terminal too, so just make sure it
+	// gets the ones sent to ochami directly and let it decide when to exit
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for s := range sigs {
+			_ = c.Process.Signal(s)
+		}
+	}()
+
+	status := 0
+	if err := c.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			status = exitErr.ExitCode()
+			if status < 0 {
+				status = 1
+			}
+		} else {
+			log.Logger.Error().Err(err).Msgf("plugin %s failed", name)
+			status = 1
+		}
+	}
+	signal.Stop(sigs)
+
+	WriteOutputFile()
+	os.Exit(status)
+}
+
+// pluginEnv returns the environment variables, in key=value form, that pass the
+// global flags and resolved configuration to a plugin. Variables for settings
+// that are not set are omitted.
+func pluginEnv() []string {
+	var env []string
+	set := func(key, value string) {
+		if value != "" {
+			env = append(env, pluginEnvPrefix+key+"="+value)
+		}
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		set("BIN", exe)
+	}
+	set("CONFIG", configFile)
+	set("LOG_LEVEL", config.GlobalConfig.Log.Level)
+	set("LOG_FORMAT", config.GlobalConfig.Log.Format)
+	set("VERBOSITY", verbosity)
+	set("CACERT", cacertPath)
+	if insecure {
+		set("INSECURE", "true")
+	}
+	if client.LogSecrets {
+		set("LOG_SECRETS", "true")
+	}
+
+	cluster, err := getCluster(rootCmd)
+	if err != nil {
+		log.Logger.Warn().Err(err).Msg("not passing cluster configuration to plugin")
+	}
+	if cluster != nil {
+		set("CLUSTER", cluster.Name)
+		if cBytes, err := yaml.Marshal(cluster.Cluster); err != nil {
+			log.Logger.Warn().Err(err).Msg("failed to marshal cluster configuration for plugin")
+		} else {
+			set("CLUSTER_CONFIG", string(cBytes))
+		}
+	}
+	if uri, err := getBaseURI(rootCmd); err == nil {
+		set("BASE_URI", uri)
+	}
+	if t, h := clusterTenant(cluster); t != "" {
+		set("TENANT", t)
+		set("TENANT_HEADER", h)
+	}
+
+	// Unlike built-in commands, a missing token is not an error since not
+	// every plugin needs one
+	if rootCmd.Flag("token").Changed {
+		set("ACCESS_TOKEN", token)
+	} else if cluster != nil {
+		if t, _, err := clusterToken(cluster.Name); err == nil {
+			set("ACCESS_TOKEN", t)
+		} else {
+			log.Logger.Debug().Err(err).Msg("not passing access token to plugin")
+		}
+	}
+
+	return env
+}
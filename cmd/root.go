@@ -4,17 +4,29 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/OpenCHAMI/ochami/internal/cli"
+	"github.com/OpenCHAMI/ochami/internal/color"
 	"github.com/OpenCHAMI/ochami/internal/config"
+	oio "github.com/OpenCHAMI/ochami/internal/io"
 	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/internal/tokensource"
 	"github.com/OpenCHAMI/ochami/internal/version"
 	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/flagtypes"
+	"github.com/OpenCHAMI/ochami/pkg/format"
+	"github.com/OpenCHAMI/ochami/pkg/timeparse"
 	"github.com/lestrrat-go/jwx/jwt"
 	"github.com/spf13/cobra"
 )
@@ -24,6 +36,23 @@ const (
 	defaultOutputFormat  = "json"
 )
 
+// formatJSONPretty is the output format of JSON indented for reading.
+const formatJSONPretty = "json-pretty"
+
+// dataFormats are the formats that can be passed to --output-format and
+// --payload-format (and set in the config as format-output and format-input).
+var dataFormats = []string{"json", "yaml", "toml"}
+
+// outputFormats are the formats that can be passed to --output-format (and set
+// in the config as format-output), in addition to table for commands that print
+// tables.
+var outputFormats = []string{"json", formatJSONPretty, "yaml", "toml"}
+
+// humanOutputFormats are the output formats meant for people rather than
+// programs, which are not used by default when standard output is not a
+// terminal (see defaultOutputFormatFor).
+var humanOutputFormats = []string{formatJSONPretty, format.Table}
+
 var (
 	// Errors
 	UserDeclinedError = fmt.Errorf("user declined")
@@ -32,11 +61,61 @@ var (
 	logLevel   string
 	logFormat  string
 
+	// Preset passed to --verbosity (see verbosityPresets), or empty if it
+	// was not passed.
+	verbosity string
+
+	// Where long-running commands (e.g. 'pcs power') report their progress.
+	// Progress is discarded with --verbosity quiet.
+	progressWriter io.Writer = os.Stderr
+
 	// These are only used by 'bss' and 'smd' subcommands.
 	baseURI    string
 	cacertPath string
 	token      string
 	insecure   bool
+
+	// Values of --ip-version and --tenant. The IP version and tenant of
+	// the cluster being contacted are used instead if they were not
+	// passed (see clusterIPVersion and clusterTenant).
+	ipVersion string
+	tenant    string
+
+	// Socket of the connection daemon that clients send their requests
+	// through, set when --via-daemon is passed.
+	daemonSocket string
+
+	// Value of --compress. Request bodies are also compressed for clusters
+	// that ask for it if it was not passed (see clusterCompress).
+	compress bool
+
+	// Versions of services, by their names in lower case, that payloads
+	// are made compatible with, set from --compat.
+	compatVersions map[string]string
+
+	// Failover groups of the clusters contacted, by cluster name, so that
+	// the clients of a cluster share its group (see clusterFailover).
+	failoverGroups = make(map[string]*client.FailoverGroup)
+
+	// Plan that clients record mutating requests into or execute, set by
+	// initPlan.
+	activePlan *client.Plan
+
+	// Transforms that payload files are piped through, set by
+	// initTransforms.
+	payloadTransforms []client.Transform
+
+	// Stops iterative requests on SIGINT/SIGTERM. Set before any command
+	// runs.
+	runner *cli.Runner
+
+	// Set when --output-file is passed. It restores standard output and
+	// returns what was written to it.
+	finishOutput func() ([]byte, error)
+
+	// Set when --plan-only is passed. It restores standard output, which
+	// is discarded while planning so that only the plan is printed.
+	finishPlanOutput func() ([]byte, error)
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -46,6 +125,47 @@ var rootCmd = &cobra.Command{
 	Short:   "Command line interface for interacting with OpenCHAMI services",
 	Long:    "",
 	Version: version.Version,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		checkCommandLifecycle(cmd)
+		applyConfigFormats(cmd)
+
+		// Let iterative requests stop early if interrupted
+		runner = cli.NewRunner()
+		client.BulkContext = runner.Context()
+
+		// Summarize each request, e.g. to spot slow endpoints
+		if config.EarlyVerbose || verbosityPresets[verbosity].summaries {
+			client.SummaryWriter = os.Stderr
+		}
+
+		if err := client.ValidateIPVersion(ipVersion); err != nil {
+			log.Logger.Error().Err(err).Msg("invalid value for --ip-version")
+			os.Exit(1)
+		}
+		if err := client.ValidateTenant(tenant, client.DefaultTenantHeader); err != nil {
+			log.Logger.Error().Err(err).Msg("invalid value for --tenant")
+			os.Exit(1)
+		}
+
+		// Versions of services to make payloads compatible with
+		compat, err := cmd.Flags().GetStringArray("compat")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --compat")
+			os.Exit(1)
+		}
+		if compatVersions, err = client.ParseCompat(compat); err != nil {
+			log.Logger.Error().Err(err).Msg("invalid value for --compat")
+			os.Exit(1)
+		}
+
+		// Reuse the connections of the daemon, if it is running
+		if viaDaemon, _ := cmd.Flags().GetBool("via-daemon"); viaDaemon {
+			daemonSocket = client.DefaultDaemonSocket()
+		}
+
+		initPlan(cmd)
+		initTransforms(cmd)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
 			err := cmd.Usage()
@@ -61,36 +181,109 @@ var rootCmd = &cobra.Command{
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	initCompletionCmd()
+	runPluginIfRequested(os.Args[1:])
+	applyCommandLifecycles(rootCmd)
 	err := rootCmd.Execute()
 	if err != nil {
 		log.Logger.Error().Err(err).Msg("failed to execute root command")
 		os.Exit(1)
 	}
+	client.CloseSSHTunnels()
+	finishPlan()
+	WriteOutputFile()
 }
 
 func init() {
 	cobra.OnInitialize(
 		InitConfig,
 		InitLogging,
+		InitOutput,
 	)
-	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "path to configuration file to use")
-	rootCmd.PersistentFlags().StringP("log-format", "L", "", "log format (json,rfc3339,basic)")
-	rootCmd.PersistentFlags().StringP("log-level", "l", "", "set verbosity of logs (info,warning,debug)")
+	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "path or URL of configuration file to use")
+	rootCmd.PersistentFlags().StringP("log-format", "L", "", "log format (json,logfmt,rfc3339,basic)")
+	rootCmd.PersistentFlags().String("color", "auto", "when to color output (auto,always,never); auto colors terminals unless NO_COLOR is set")
+	rootCmd.PersistentFlags().StringArray("log-filter", []string{}, "only log info, debug, and trace messages of these components (component=cli,client,config,discover)")
+	rootCmd.PersistentFlags().StringP("log-level", "l", "", "set verbosity of logs (error,warning,info,debug,trace)")
+	rootCmd.PersistentFlags().String("verbosity", "", "set log level, request summaries, and progress output together ("+strings.Join(verbosityNames, ",")+"); --log-level overrides its log level")
 	rootCmd.PersistentFlags().StringP("cluster", "C", "", "name of cluster whose config to use for this command")
 	rootCmd.PersistentFlags().StringVarP(&baseURI, "base-uri", "u", "", "base URI for OpenCHAMI services")
-	rootCmd.PersistentFlags().StringVar(&cacertPath, "cacert", "", "path to root CA certificate in PEM format")
+	rootCmd.PersistentFlags().StringVar(&cacertPath, "cacert", "", "path to root CA certificate in PEM format to trust in addition to the system's")
 	rootCmd.PersistentFlags().StringVarP(&token, "token", "t", "", "access token to present for authentication")
 	rootCmd.PersistentFlags().BoolVarP(&insecure, "insecure", "k", false, "do not verify TLS certificates")
 	rootCmd.PersistentFlags().Bool("ignore-config", false, "do not use any config file")
-	rootCmd.PersistentFlags().BoolVarP(&config.EarlyVerbose, "verbose", "v", false, "be verbose before logging is initialized")
+	rootCmd.PersistentFlags().StringP("output-file", "o", "", "write output to file (atomically) instead of standard output")
+	rootCmd.PersistentFlags().Bool("append", false, "append to file passed to --output-file instead of replacing it")
+	rootCmd.PersistentFlags().Bool("no-headers", false, "do not print the row of column headers with --output-format table")
+	rootCmd.PersistentFlags().Bool("output-header", false, "wrap output in an object with the cluster, service, and request that produced it")
+	rootCmd.PersistentFlags().BoolVarP(&config.EarlyVerbose, "verbose", "v", false, "be verbose before logging is initialized and summarize each request")
+	rootCmd.PersistentFlags().BoolVar(&client.LogSecrets, "log-secrets", false, "do not redact tokens, passwords, etc. in debug and trace logs")
+	rootCmd.PersistentFlags().Bool("plan-only", false, "print plan of mutating requests instead of sending them")
+	rootCmd.PersistentFlags().String("plan", "", "plan file to write with --plan-only or to run with --execute")
+	rootCmd.PersistentFlags().Bool("execute", false, "only send mutating requests that are in the plan passed with --plan")
+	rootCmd.PersistentFlags().BoolVar(&compress, "compress", false, "gzip request bodies of 1 KiB or more (service must accept gzip-encoded requests)")
+	rootCmd.PersistentFlags().StringVar(&ipVersion, "ip-version", "auto", "only connect to services over IPv4 or IPv6 (4,6,auto)")
+	rootCmd.PersistentFlags().StringVar(&tenant, "tenant", "", "tenant to make requests on behalf of, sent in the tenant header of every request")
+	rootCmd.PersistentFlags().StringArray("transform", []string{}, "jq filter to apply to payload files before they are sent (e.g. 'del(.Components[].NID)')")
+	rootCmd.PersistentFlags().StringArray("transform-command", []string{}, "shell command to pipe payload files through (as JSON) before they are sent")
+	rootCmd.PersistentFlags().Bool("partial-ok", false, "exit successfully if some items of an iterative command fail but others succeed")
+	rootCmd.PersistentFlags().StringArray("compat", []string{}, "assume a service is of a version (<service>=<version>, e.g. smd=1.x) to downgrade payloads for, instead of asking it; \"latest\" disables downgrades")
+	rootCmd.PersistentFlags().Bool("via-daemon", false, "send requests through the connection daemon (see serve-proxy) if it is running")
 
 	// Either use cluster from config file or specify details on CLI
 	rootCmd.MarkFlagsMutuallyExclusive("cluster", "base-uri")
+	rootCmd.MarkFlagsMutuallyExclusive("plan-only", "execute")
+
+	_ = rootCmd.RegisterFlagCompletionFunc("verbosity", cobra.FixedCompletions(verbosityNames, cobra.ShellCompDirectiveNoFileComp))
+	_ = rootCmd.RegisterFlagCompletionFunc("ip-version", cobra.FixedCompletions([]string{"4", "6", "auto"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// verbosityPreset configures together how much ochami reports while it runs.
+type verbosityPreset struct {
+	logLevel  string // log level, as passed to --log-level
+	summaries bool   // whether to summarize each request, as with --verbose
+	progress  bool   // whether long-running commands report their progress
+}
+
+// verbosityNames are the names of the presets that can be passed to
+// --verbosity, from least to most verbose.
+var verbosityNames = []string{"quiet", "normal", "debug", "trace"}
+
+// verbosityPresets are the presets that can be passed to --verbosity. At the
+// trace log level, the headers and bodies of requests and responses are logged
+// as well (see client.OchamiClient.MakeRequestContext).
+var verbosityPresets = map[string]verbosityPreset{
+	"quiet":  {logLevel: "error"},
+	"normal": {logLevel: "warning", progress: true},
+	"debug":  {logLevel: "debug", summaries: true, progress: true},
+	"trace":  {logLevel: "trace", summaries: true, progress: true},
 }
 
-// Set log level verbosity based on config file (log.level) or --log-level.
-// The command line option overrides the config file option.
+// Set log level verbosity based on config file (log.level), --verbosity, or
+// --log-level, in increasing order of precedence. The config file option is
+// itself overridden by the log defaults of the cluster being used, if any.
 func InitLogging() {
+	// Color is decided first since it affects log output
+	colorMode := config.GlobalConfig.Color
+	if rootCmd.PersistentFlags().Lookup("color").Changed {
+		colorMode = rootCmd.PersistentFlags().Lookup("color").Value.String()
+	}
+	cm, err := color.ParseMode(colorMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", config.ProgName, err)
+		os.Exit(1)
+	}
+	color.Init(cm)
+
+	// An error here is reported when the base URI is determined
+	if cluster, err := getCluster(rootCmd); err == nil && cluster != nil {
+		if f := cluster.Cluster.Defaults.Log.Format; f != "" {
+			config.GlobalConfig.Log.Format = f
+		}
+		if l := cluster.Cluster.Defaults.Log.Level; l != "" {
+			config.GlobalConfig.Log.Level = l
+		}
+	}
 	if rootCmd.PersistentFlags().Lookup("log-format").Changed {
 		lf, err := rootCmd.PersistentFlags().GetString("log-format")
 		if err != nil {
@@ -99,6 +292,23 @@ func InitLogging() {
 		}
 		config.GlobalConfig.Log.Format = lf
 	}
+	if rootCmd.PersistentFlags().Lookup("verbosity").Changed {
+		v, err := rootCmd.PersistentFlags().GetString("verbosity")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: failed to fetch flag verbosity: %v\n", config.ProgName, err)
+			os.Exit(1)
+		}
+		preset, ok := verbosityPresets[v]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s: unknown verbosity %q, must be one of: %s\n", config.ProgName, v, strings.Join(verbosityNames, ", "))
+			os.Exit(1)
+		}
+		verbosity = v
+		config.GlobalConfig.Log.Level = preset.logLevel
+		if !preset.progress {
+			progressWriter = io.Discard
+		}
+	}
 	if rootCmd.PersistentFlags().Lookup("log-level").Changed {
 		ll, err := rootCmd.PersistentFlags().GetString("log-level")
 		if err != nil {
@@ -108,12 +318,226 @@ func InitLogging() {
 		config.GlobalConfig.Log.Level = ll
 	}
 
-	if err := log.Init(config.GlobalConfig.Log.Level, config.GlobalConfig.Log.Format); err != nil {
+	var components []string
+	filters, err := rootCmd.PersistentFlags().GetStringArray("log-filter")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: failed to fetch flag log-filter: %v\n", config.ProgName, err)
+		os.Exit(1)
+	}
+	for _, f := range filters {
+		comps, err := log.ParseFilter(f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", config.ProgName, err)
+			os.Exit(1)
+		}
+		components = append(components, comps...)
+	}
+
+	if err := log.Init(config.GlobalConfig.Log.Level, config.GlobalConfig.Log.Format, components...); err != nil {
 		fmt.Fprintf(os.Stderr, "%s: failed to initialize logger: %v\n", config.ProgName, err)
 		os.Exit(1)
 	}
 
 	log.Logger.Debug().Msg("logging has been initialized")
+	if client.LogSecrets {
+		log.Logger.Warn().Msg("--log-secrets passed, logs may contain tokens and passwords")
+	}
+}
+
+// applyConfigFormats sets the values of --output-format and --payload-format
+// for cmd, if it has them and they were not passed, to the defaults in the
+// config (see config.Config.Formats). The flags are not marked as changed so
+// that commands which only print structured output when -F is passed behave
+// the same.
+func applyConfigFormats(cmd *cobra.Command) {
+	cluster, _ := getCluster(cmd)
+	cmdPath := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	output, input := config.GlobalConfig.Formats(cluster, cmdPath)
+	for name, value := range map[string]string{"output-format": output, "payload-format": input} {
+		f := cmd.Flags().Lookup(name)
+		if f == nil || f.Changed {
+			continue
+		}
+		if name == "output-format" && f.DefValue == defaultOutputFormat {
+			value = defaultOutputFormatFor(value, stdoutIsTerminal())
+		}
+		if value == "" {
+			continue
+		}
+		if name == "output-format" && value == format.Table && tableKind(cmd) == "" {
+			log.Logger.Debug().Msgf("ignoring --%s %s from config, %s does not print tables", name, value, cmdPath)
+			continue
+		}
+		if err := f.Value.Set(value); err != nil {
+			log.Logger.Error().Err(err).Msgf("invalid default for --%s in config", name)
+			os.Exit(1)
+		}
+		log.Logger.Debug().Msgf("using --%s %s from config", name, value)
+	}
+}
+
+// stdoutIsTerminal reports whether standard output is a terminal. It is a
+// variable so that tests can replace it.
+var stdoutIsTerminal = func() bool {
+	return color.IsTerminal(os.Stdout)
+}
+
+// defaultOutputFormatFor returns the output format of commands whose
+// --output-format defaults to json when it is not passed, given configured, the
+// format configured for the command (see config.Config.Formats), if any, and
+// whether standard output is a terminal. If it is, the format is configured or
+// else json-pretty. Otherwise, e.g. when the output is piped into another
+// program, it is format-output-pipe from the config if set, configured unless
+// it is a format meant for people (see humanOutputFormats), or else compact
+// json, so that other programs get output that is easy to parse.
+func defaultOutputFormatFor(configured string, terminal bool) string {
+	if terminal {
+		if configured != "" {
+			return configured
+		}
+		return formatJSONPretty
+	}
+	if pipe := config.GlobalConfig.FormatOutputPipe; pipe != "" {
+		return pipe
+	}
+	if configured != "" && !slices.Contains(humanOutputFormats, configured) {
+		return configured
+	}
+	return defaultOutputFormat
+}
+
+// InitOutput starts capturing standard output if --output-file was passed so
+// that WriteOutputFile can write it to the file once the command has
+// completed.
+func InitOutput() {
+	if !rootCmd.PersistentFlags().Lookup("output-file").Changed {
+		if rootCmd.PersistentFlags().Lookup("append").Changed {
+			log.Logger.Warn().Msg("--append has no effect without --output-file")
+		}
+		return
+	}
+	if rootCmd.PersistentFlags().Lookup("output-file").Value.String() == "" {
+		log.Logger.Error().Msg("--output-file cannot be empty")
+		os.Exit(1)
+	}
+	var err error
+	finishOutput, err = oio.CaptureStdout()
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to redirect output")
+		os.Exit(1)
+	}
+	color.DisableStdout()
+}
+
+// initPlan sets activePlan according to --plan-only, --plan, and
+// --execute. With --plan-only, mutating requests are recorded into a new plan
+// and the output of the command is discarded so that finishPlan can print the
+// plan instead. With --execute, the plan in the file passed to --plan is read
+// and only the requests that are in it are sent.
+func initPlan(cmd *cobra.Command) {
+	planFile := cmd.Flag("plan").Value.String()
+	switch {
+	case cmd.Flag("execute").Changed:
+		if planFile == "" {
+			log.Logger.Error().Msg("--execute requires --plan")
+			os.Exit(1)
+		}
+		f, err := os.Open(planFile)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to open plan file")
+			os.Exit(1)
+		}
+		defer f.Close()
+		activePlan, err = client.ReadPlan(f)
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to read plan file %s", planFile)
+			os.Exit(1)
+		}
+		log.Logger.Debug().Msgf("executing plan of %d requests from %s", len(activePlan.Steps()), planFile)
+	case cmd.Flag("plan-only").Changed:
+		activePlan = client.NewPlan()
+		var err error
+		finishPlanOutput, err = oio.CaptureStdout()
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to redirect output")
+			os.Exit(1)
+		}
+	case cmd.Flag("plan").Changed:
+		log.Logger.Error().Msg("--plan requires --plan-only or --execute")
+		os.Exit(1)
+	}
+}
+
+// finishPlan completes the plan set up by initPlan once the command has
+// completed. With --plan-only, the recorded plan is written to the file passed
+// to --plan or, if none, to standard output. With --execute, the requests of
+// the plan that were not sent are logged and the program exits with an error.
+// If neither was passed, this is a no-op.
+func finishPlan() {
+	plan := activePlan
+	if plan == nil {
+		return
+	}
+	if finishPlanOutput == nil {
+		pending := plan.Pending()
+		for _, s := range pending {
+			log.Logger.Error().Msgf("planned request was not sent: %s %s", s.Method, s.URI)
+		}
+		if len(pending) > 0 {
+			log.Logger.Error().Msgf("%d of %d planned requests were not sent", len(pending), len(plan.Steps()))
+			os.Exit(1)
+		}
+		return
+	}
+
+	out, err := finishPlanOutput()
+	finishPlanOutput = nil
+	if err != nil {
+		log.Logger.Warn().Err(err).Msg("failed to collect output while planning")
+	}
+	log.Logger.Debug().Msgf("discarded %d bytes of output while planning", len(out))
+	var buf bytes.Buffer
+	if err := plan.Write(&buf); err != nil {
+		log.Logger.Error().Err(err).Msg("failed to write plan")
+		os.Exit(1)
+	}
+	planFile := rootCmd.PersistentFlags().Lookup("plan").Value.String()
+	if planFile == "" {
+		fmt.Print(buf.String())
+		return
+	}
+	if err := oio.WriteFileAtomic(planFile, buf.Bytes(), false, 0644); err != nil {
+		log.Logger.Error().Err(err).Msg("failed to write plan file")
+		os.Exit(1)
+	}
+	log.Logger.Info().Msgf("wrote plan of %d requests to %s", len(plan.Steps()), planFile)
+}
+
+// WriteOutputFile writes the output captured since InitOutput to the file
+// passed to --output-file. The file is replaced (or, with --append, extended)
+// atomically, so it is left untouched if the command exits early, e.g. due to
+// an error. If --output-file was not passed, this is a no-op.
+func WriteOutputFile() {
+	if finishOutput == nil {
+		return
+	}
+	out, err := finishOutput()
+	finishOutput = nil
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to collect output")
+		os.Exit(1)
+	}
+	outFile := rootCmd.PersistentFlags().Lookup("output-file").Value.String()
+	appendOut, err := rootCmd.PersistentFlags().GetBool("append")
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to fetch flag append")
+		os.Exit(1)
+	}
+	if err := oio.WriteFileAtomic(outFile, out, appendOut, 0644); err != nil {
+		log.Logger.Error().Err(err).Msg("failed to write output file")
+		os.Exit(1)
+	}
+	log.Logger.Debug().Msgf("wrote %d bytes of output to %s", len(out), outFile)
 }
 
 // AskToCreate prompts the user to, if path does not exist, to create a blank
@@ -124,6 +548,9 @@ func AskToCreate(path string) error {
 	if path == "" {
 		return fmt.Errorf("path cannot be empty")
 	}
+	if config.IsRemote(path) {
+		return fmt.Errorf("%s is a remote config file, which cannot be modified", path)
+	}
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		respConfigCreate := loopYesNo(fmt.Sprintf("%s does not exist. Create it?", path))
 		if respConfigCreate {
@@ -150,7 +577,14 @@ func InitConfig() {
 		return
 	}
 
-	if configFile != "" {
+	// Let doctor report problems with the config instead of exiting or
+	// prompting.
+	runningDoctor := false
+	if c, _, err := rootCmd.Find(os.Args[1:]); err == nil && c == doctorCmd {
+		runningDoctor = true
+	}
+
+	if configFile != "" && !config.IsRemote(configFile) && !runningDoctor {
 		// Try to create config file with default values if it doesn't exist
 		if err := AskToCreate(configFile); err != nil {
 			if errors.Is(err, UserDeclinedError) {
@@ -163,10 +597,18 @@ func InitConfig() {
 		}
 	}
 
+	// Let 'config migrate' migrate (or preview migrating) config files itself
+	// instead of them being migrated when loaded.
+	if c, _, err := rootCmd.Find(os.Args[1:]); err == nil && c == configMigrateCmd {
+		config.AutoMigrate = false
+	}
+
 	// Read configuration from file, if passed or merge config from system
 	// config file and user config file if not passed.
 	err := config.LoadConfig(configFile)
-	if err != nil {
+	if err != nil && runningDoctor {
+		configLoadErr = err
+	} else if err != nil {
 		fmt.Fprintf(os.Stderr, "%s: failed to load configuration: %v\n", config.ProgName, err)
 		os.Exit(1)
 	}
@@ -206,30 +648,72 @@ func loopYesNo(p string) bool {
 }
 
 // checkToken takes a pointer to a Cobra command and checks to see if --token
-// was set. If not, an error is printed and the program exits.
+// was set. If not, an error is printed and the program exits. Otherwise, the
+// token is parsed and validated with validateToken, exiting if it is invalid
+// and warning if it is close to expiring.
 func checkToken(cmd *cobra.Command) {
-	// TODO: Check token validity/expiration
 	if token == "" {
 		log.Logger.Error().Msg("no token set")
 		os.Exit(1)
 	}
 
-	// Try to parse token
-	t, err := jwt.ParseString(token, jwt.WithValidate(false))
+	t, err := validateToken(token)
 	if err != nil {
-		log.Logger.Error().Err(err).Msg("failed to parse token")
+		log.Logger.Error().Err(err).Msg("token is invalid")
 		os.Exit(1)
 	}
 
+	// Warn if expiration is near
+	if exp := t.Expiration(); !exp.IsZero() && time.Until(exp).Minutes() <= 15 {
+		log.Logger.Warn().Msgf("%s until token expires", time.Until(exp))
+	}
+
+	// Refuse to send a token for another tenant, if the token says which
+	// tenants it is for
+	cluster, _ := getCluster(cmd)
+	if tenant, _ := clusterTenant(cluster); tenant != "" {
+		if tenants := tokenTenants(t); len(tenants) == 0 {
+			log.Logger.Debug().Msgf("token has no tenant claims, cannot check that it is for tenant %s", tenant)
+		} else if !slices.Contains(tenants, tenant) {
+			log.Logger.Error().Msgf("token is not for tenant %s (it is for %s)", tenant, strings.Join(tenants, ","))
+			os.Exit(1)
+		}
+	}
+}
+
+// tenantClaims are the claims of tokens that may name the tenants a token is
+// for, as a string or a list of strings.
+var tenantClaims = []string{"tenant", "tenant_id", "tenants", "tenant_ids", "tid"}
+
+// tokenTenants returns the tenants that t is for according to its tenant
+// claims (see tenantClaims), or nothing if it has none.
+func tokenTenants(t jwt.Token) []string {
+	var tenants []string
+	for _, c := range tenantClaims {
+		if v, ok := t.Get(c); ok {
+			tenants = append(tenants, claimToStrings(v)...)
+		}
+	}
+	return tenants
+}
+
+// validateToken parses the JWT in tokenStr without verifying its signature and
+// checks its not before (nbf), issued at (iat), and expiration (exp) fields. The
+// parsed token is returned, even if validation fails, as long as parsing
+// succeeded so that callers can still inspect it.
+func validateToken(tokenStr string) (jwt.Token, error) {
+	// Try to parse token
+	t, err := jwt.ParseString(tokenStr, jwt.WithValidate(false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
 	// Check expiration
 	now := time.Now()
 	exp := t.Expiration()
-	if exp.Compare(now) < 0 {
-		log.Logger.Error().Msgf("token is expired (expired %s ago at %s)",
+	if !exp.IsZero() && exp.Compare(now) < 0 {
+		return t, fmt.Errorf("token is expired (expired %s ago at %s)",
 			now.Sub(exp), exp.Local().Format(time.RFC1123))
-		os.Exit(1)
-	} else if exp.Sub(now).Minutes() <= 15 {
-		log.Logger.Warn().Msgf("%s until token expires", exp.Sub(now))
 	}
 
 	// Validate not before (nbf), issued at (iat), and expiration (exp) fields
@@ -239,22 +723,138 @@ func checkToken(cmd *cobra.Command) {
 		jwt.WithValidator(jwt.IsExpirationValid()),
 	)
 	if err != nil {
-		log.Logger.Error().Err(err).Msg("token is invalid")
+		return t, err
+	}
+
+	return t, nil
+}
+
+// configureClient takes a pointer to a client.OchamiClient and configures it
+// for the cluster being contacted. It trusts the CA certificates in the bundles
+// in the ca-certs of the cluster and the one passed with --cacert, in addition
+// to the system's trust store unless the cluster sets use-system-store to
+// false (see caCerts). If the cluster has certificate pins configured
+// (pin-sha256), they are applied afterwards so that they are checked in
+// addition to the CA certificates, if any. Neither the cluster's CA
+// certificates nor its pins are applied if --insecure was passed. Its
+// connections use the IP version (see clusterIPVersion) and SSH tunnel of the
+// cluster, and the connection daemon if --via-daemon was passed. Its requests
+// are made on behalf of the tenant of the cluster (see clusterTenant) and
+// intercepted by the plan set up by initPlan, if any, and identical GETs are
+// coalesced. If an error occurs, a log is printed and the program exits.
+func configureClient(client *client.OchamiClient) {
+	cluster, _ := getCluster(rootCmd)
+	configureClusterClient(client, cluster)
+}
+
+// configureClusterClient is like configureClient, but configures the client
+// for cluster, which may be nil, instead of the cluster being contacted.
+func configureClusterClient(client *client.OchamiClient, cluster *config.ConfigCluster) {
+	if v := clusterIPVersion(cluster); v != "" {
+		if err := client.UseIPVersion(v); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to restrict IP version")
+			os.Exit(1)
+		}
+	}
+	if cluster != nil && cluster.Cluster.SSHTunnel != "" {
+		if err := client.UseSSHTunnel(cluster.Cluster.SSHTunnel); err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to tunnel connections to cluster %s", cluster.Name)
+			os.Exit(1)
+		}
+	}
+	if daemonSocket != "" {
+		if err := client.UseDaemon(daemonSocket); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to use connection daemon")
+			os.Exit(1)
+		}
+	}
+	client.Tenant, client.TenantHeader = clusterTenant(cluster)
+	client.Plan = activePlan
+	client.CoalesceGets = true
+	client.CompressRequests = clusterCompress(cluster)
+	client.CompatVersion = compatVersions[strings.ToLower(client.ServiceName)]
+	failover, err := clusterFailover(cluster)
+	if err != nil {
+		log.Logger.Error().Err(err).Msgf("invalid failover-uris for cluster %s", cluster.Name)
 		os.Exit(1)
 	}
+	client.Failover = failover
+
+	certCluster := cluster
+	if insecure {
+		certCluster = nil
+	}
+	paths, useSystemStore := caCerts(certCluster)
+	if len(paths) > 0 || !useSystemStore {
+		log.Logger.Debug().Msgf("Attempting to use CA certificates %v (system trust store: %t)", paths, useSystemStore)
+		if err := client.UseCACerts(paths, useSystemStore); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to load CA certificates")
+			os.Exit(1)
+		}
+	}
+	if certCluster == nil {
+		return
+	}
+	if len(certCluster.Cluster.PinSHA256) > 0 {
+		log.Logger.Debug().Msgf("using certificate pins for cluster %s: %v", certCluster.Name, certCluster.Cluster.PinSHA256)
+		if err := client.UsePinnedCerts(certCluster.Cluster.PinSHA256); err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to use certificate pins for cluster %s", certCluster.Name)
+			os.Exit(1)
+		}
+	}
 }
 
-// useCACert takes a pointer to a client.OchamiClient and, if a path to a CA
-// certificate has been set via --cacert, it configures it to use it. If an
-// error occurs, a log is printed and the program exits.
-func useCACert(client *client.OchamiClient) {
+// caCerts returns the paths of the CA certificate bundles trusted when
+// contacting cluster, which may be nil: those in its ca-certs followed by the
+// one passed with --cacert, if any. useSystemStore reports whether the system's
+// trust store is trusted as well.
+func caCerts(cluster *config.ConfigCluster) (paths []string, useSystemStore bool) {
+	useSystemStore = true
+	if cluster != nil {
+		paths = append(paths, cluster.Cluster.CACerts...)
+		useSystemStore = cluster.Cluster.SystemStore()
+	}
 	if cacertPath != "" {
-		log.Logger.Debug().Msgf("Attempting to use CA certificate at %s", cacertPath)
-		if err := client.UseCACert(cacertPath); err != nil {
-			log.Logger.Error().Err(err).Msgf("failed to load CA certificate %s", cacertPath)
-			os.Exit(1)
+		paths = append(paths, cacertPath)
+	}
+	return paths, useSystemStore
+}
+
+// getCluster returns the configuration of the cluster being contacted. If
+// --cluster was passed, the cluster with that name is used. Otherwise, if
+// --base-uri was not passed, the default cluster is used, if set. If no cluster
+// is being used, nil is returned. An error is returned if the selected cluster
+// does not exist in the config.
+func getCluster(cmd *cobra.Command) (*config.ConfigCluster, error) {
+	var clusterName string
+	if cmd.Flag("cluster").Changed {
+		clusterName = cmd.Flag("cluster").Value.String()
+	} else if cmd.Flag("base-uri").Changed {
+		return nil, nil
+	} else if config.GlobalConfig.DefaultCluster != "" {
+		clusterName = config.GlobalConfig.DefaultCluster
+	} else {
+		return nil, nil
+	}
+	if c := lookupCluster(clusterName); c != nil {
+		return c, nil
+	}
+	if cmd.Flag("cluster").Changed {
+		return nil, fmt.Errorf("cluster %s not found", clusterName)
+	}
+
+	return nil, fmt.Errorf("default cluster %s not found", clusterName)
+}
+
+// lookupCluster returns the configuration of the cluster named name, or nil if
+// there is no such cluster in the config.
+func lookupCluster(name string) *config.ConfigCluster {
+	for _, c := range config.GlobalConfig.Clusters {
+		if c.Name == name {
+			return &c
 		}
 	}
+	return nil
 }
 
 func getBaseURI(cmd *cobra.Command) (string, error) {
@@ -267,55 +867,135 @@ func getBaseURI(cmd *cobra.Command) (string, error) {
 	// 3. If "default-cluster" is set in config file (config file must be
 	//    specified), use cluster identified by that name as source of info.
 	// 4. Data sources exhausted, err.
-	var (
-		clusterList  []config.ConfigCluster
-		clusterToUse config.ConfigCluster
-		clusterName  string
-	)
-	if cmd.Flag("cluster").Changed {
-		clusterList = config.GlobalConfig.Clusters
-		clusterName = cmd.Flag("cluster").Value.String()
-		log.Logger.Debug().Msgf("using base URI from cluster %s passed from command line", clusterName)
-		for _, c := range clusterList {
-			if c.Name == clusterName {
-				clusterToUse = c
-				break
-			}
-		}
-		if clusterToUse == (config.ConfigCluster{}) {
-			return "", fmt.Errorf("cluster %s not found", clusterName)
-		}
-		if clusterToUse.Cluster.BaseURI == "" {
-			return "", fmt.Errorf("base-uri not set for cluster %s specified with --cluster", clusterName)
-		}
-
-		log.Logger.Debug().Msgf("base URI: %s", clusterToUse.Cluster.BaseURI)
-
-		return clusterToUse.Cluster.BaseURI, nil
+	cluster, err := getCluster(cmd)
+	if err != nil {
+		return "", err
+	}
+	if cluster != nil {
+		return clusterBaseURI(cluster)
 	} else if cmd.Flag("base-uri").Changed {
 		log.Logger.Debug().Msg("using base URI passed on command line")
 		log.Logger.Debug().Msgf("base URI: %s", baseURI)
 		return baseURI, nil
-	} else if config.GlobalConfig.DefaultCluster != "" {
-		clusterName = config.GlobalConfig.DefaultCluster
-		clusterList = config.GlobalConfig.Clusters
-		log.Logger.Debug().Msgf("using base URI from default cluster %s", clusterName)
-		for _, c := range clusterList {
-			if c.Name == clusterName {
-				clusterToUse = c
-				break
-			}
+	}
+
+	return "", fmt.Errorf("no base-uri set via --base-uri, --cluster, or config file")
+}
+
+// clusterBaseURI returns the base URI of cluster. An error is returned if the
+// failover URIs, IP version, tenant, or SSH tunnel configured for it, which
+// configureClient applies, are invalid.
+func clusterBaseURI(cluster *config.ConfigCluster) (string, error) {
+	log.Logger.Debug().Msgf("using base URI from cluster %s", cluster.Name)
+	if cluster.Cluster.BaseURI == "" {
+		return "", fmt.Errorf("base-uri not set for cluster %s", cluster.Name)
+	}
+	log.Logger.Debug().Msgf("base URI: %s", cluster.Cluster.BaseURI)
+
+	// Requests to the base URI fail over to the failover URIs, if any, when
+	// it cannot be reached
+	if len(cluster.Cluster.FailoverURIs) > 0 {
+		log.Logger.Debug().Msgf("failover URIs: %v", cluster.Cluster.FailoverURIs)
+		if _, err := clusterFailover(cluster); err != nil {
+			return "", fmt.Errorf("invalid failover-uris for cluster %s: %w", cluster.Name, err)
 		}
-		if clusterToUse == (config.ConfigCluster{}) {
-			return "", fmt.Errorf("default cluster %s not found", clusterName)
+	}
+
+	// Restrict connections to the IP version of the cluster if
+	// --ip-version was not passed
+	if v := cluster.Cluster.IPVersion; v != "" && !rootCmd.Flag("ip-version").Changed {
+		if err := client.ValidateIPVersion(v); err != nil {
+			return "", fmt.Errorf("invalid ip-version for cluster %s: %w", cluster.Name, err)
 		}
+		log.Logger.Debug().Msgf("using IP version %s for cluster %s", v, cluster.Name)
+	}
 
-		log.Logger.Debug().Msgf("base URI: %s", clusterToUse.Cluster.BaseURI)
+	// Make requests on behalf of the cluster's tenant if --tenant was not
+	// passed
+	t, h := clusterTenant(cluster)
+	if t != "" && !rootCmd.Flag("tenant").Changed {
+		log.Logger.Debug().Msgf("using tenant %s for cluster %s", t, cluster.Name)
+	}
+	if err := client.ValidateTenant(t, h); err != nil {
+		return "", fmt.Errorf("invalid tenant for cluster %s: %w", cluster.Name, err)
+	}
 
-		return clusterToUse.Cluster.BaseURI, nil
+	// Tunnel connections through the cluster's jump host, if any
+	if dest := cluster.Cluster.SSHTunnel; dest != "" {
+		if err := client.ValidateSSHTunnel(dest); err != nil {
+			return "", fmt.Errorf("invalid ssh-tunnel for cluster %s: %w", cluster.Name, err)
+		}
+		log.Logger.Debug().Msgf("tunneling connections to cluster %s through %s", cluster.Name, dest)
 	}
 
-	return "", fmt.Errorf("no base-uri set bia --base-uri, --cluster, or config file")
+	return cluster.Cluster.BaseURI, nil
+}
+
+// clusterIPVersion returns the IP version that connections to cluster, which
+// may be nil, are restricted to: the value of --ip-version if passed,
+// otherwise the ip-version of the cluster, if set.
+func clusterIPVersion(cluster *config.ConfigCluster) string {
+	if cluster != nil && cluster.Cluster.IPVersion != "" && !rootCmd.Flag("ip-version").Changed {
+		return cluster.Cluster.IPVersion
+	}
+	return ipVersion
+}
+
+// clusterCompress reports whether request bodies sent to cluster, which may be
+// nil, are compressed: the value of --compress if passed, otherwise the
+// compress setting of the cluster.
+func clusterCompress(cluster *config.ConfigCluster) bool {
+	if cluster != nil && !rootCmd.Flag("compress").Changed {
+		return cluster.Cluster.Compress
+	}
+	return compress
+}
+
+// clusterFailover returns the failover group of the base URI and failover URIs
+// of cluster, which may be nil, creating it the first time. nil is returned if
+// cluster has no failover URIs.
+func clusterFailover(cluster *config.ConfigCluster) (*client.FailoverGroup, error) {
+	if cluster == nil || len(cluster.Cluster.FailoverURIs) == 0 {
+		return nil, nil
+	}
+	if g, ok := failoverGroups[cluster.Name]; ok {
+		return g, nil
+	}
+	uris := append([]string{cluster.Cluster.BaseURI}, cluster.Cluster.FailoverURIs...)
+	g, err := client.NewFailoverGroup(uris...)
+	if err != nil {
+		return nil, err
+	}
+	failoverGroups[cluster.Name] = g
+	return g, nil
+}
+
+// clusterTenant returns the tenant that requests to cluster, which may be nil,
+// are made on behalf of and the header it is sent in: the value of --tenant if
+// passed, otherwise the tenant of the cluster, if set, and the tenant-header
+// of the cluster, if set, otherwise client.DefaultTenantHeader.
+func clusterTenant(cluster *config.ConfigCluster) (string, string) {
+	t, h := tenant, client.DefaultTenantHeader
+	if cluster == nil {
+		return t, h
+	}
+	if cluster.Cluster.Tenant != "" && !rootCmd.Flag("tenant").Changed {
+		t = cluster.Cluster.Tenant
+	}
+	if cluster.Cluster.TenantHeader != "" {
+		h = cluster.Cluster.TenantHeader
+	}
+	return t, h
+}
+
+// useIfMatch sets the If-Match mode of client to the value of --if-match, if
+// passed, so that its PUTs and PATCHes fail if the resource being written was
+// modified since it was read.
+func useIfMatch(cmd *cobra.Command, oc *client.OchamiClient) {
+	if cmd.Flag("if-match").Changed {
+		oc.IfMatch = cmd.Flag("if-match").Value.String()
+		log.Logger.Debug().Msgf("using If-Match mode: %s", oc.IfMatch)
+	}
 }
 
 // setTokenFromEnvVar sets the access token for a cobra command cmd. If --token
@@ -325,17 +1005,15 @@ func getBaseURI(cmd *cobra.Command) (string, error) {
 // The value of <CLUSTER> is determined by taking the cluster name, passed
 // either by --cluster or reading default-cluster from the config file (the
 // former preceding the latter), replacing spaces and dashes (-) with
-// underscores, and making the letters uppercase. If no config file is set or
-// the environment variable is not set, an error is logged and the program
-// exits.
+// underscores, and making the letters uppercase. If the environment variable
+// is not set, the token is read from the cluster's token or token-source (see
+// clusterToken). If no config file is set or no token is found, an error is
+// logged and the program exits.
 func setTokenFromEnvVar(cmd *cobra.Command) {
-	var (
-		clusterName string
-		varPrefix   string
-	)
+	var clusterName string
 	if cmd.Flag("token").Changed {
 		token = cmd.Flag("token").Value.String()
-		log.Logger.Debug().Msg("--token passed, setting token to its value: " + token)
+		log.Logger.Debug().Msg("--token passed, setting token to its value: " + client.Redact(token))
 		return
 	}
 
@@ -351,31 +1029,455 @@ func setTokenFromEnvVar(cmd *cobra.Command) {
 		os.Exit(1)
 	}
 
-	varPrefix = strings.ReplaceAll(clusterName, "-", "_")
+	t, source, err := clusterToken(clusterName)
+	if err != nil {
+		log.Logger.Error().Err(err).Msgf("failed to get token for cluster %q", clusterName)
+		os.Exit(1)
+	}
+	log.Logger.Debug().Msgf("Token found from %s: %s", source, client.Redact(t))
+	token = t
+}
+
+// clusterToken returns the access token of the cluster named clusterName,
+// along with a description of where it was read from. The token is read from
+// the environment variable of the cluster (see tokenEnvVar) if it is set, or
+// else from the token of the cluster in the config file, or else from its
+// token-source, if any. An error is returned if none is set or the token
+// cannot be read.
+func clusterToken(clusterName string) (string, string, error) {
+	envVar := tokenEnvVar(clusterName)
+	log.Logger.Debug().Msg("Reading token from environment variable: " + envVar)
+	if t, tokenSet := os.LookupEnv(envVar); tokenSet {
+		return t, "environment variable " + envVar, nil
+	}
+
+	cluster := lookupCluster(clusterName)
+	if cluster != nil && cluster.Cluster.Token.IsSet() {
+		log.Logger.Debug().Msg("Reading token from token in config")
+		t, err := cluster.Cluster.Token.Resolve()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get token from token in config: %w", err)
+		}
+		return t, "token in config", nil
+	}
+	if cluster == nil || cluster.Cluster.TokenSource == "" {
+		return "", "", fmt.Errorf("environment variable %s unset and no token or token-source configured", envVar)
+	}
+	att := cluster.Cluster.Attestation
+	if att.CACert == "" {
+		att.CACert = cacertPath
+	}
+	src, err := tokensource.Parse(cluster.Cluster.TokenSource, clusterName, tokensource.Options{
+		Attestation: tokensource.Attestation{
+			TokenURI:        att.TokenURI,
+			Document:        att.Document,
+			DocumentCommand: att.DocumentCommand,
+			CACert:          att.CACert,
+		},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	log.Logger.Debug().Msgf("Reading token from token source: %s", src)
+	t, err := src.Token()
+	if err != nil {
+		return "", "", err
+	}
+	return t, src.String(), nil
+}
+
+// tokenEnvVar returns the name of the environment variable that the access
+// token for the cluster named clusterName is read from.
+func tokenEnvVar(clusterName string) string {
+	varPrefix := strings.ReplaceAll(clusterName, "-", "_")
 	varPrefix = strings.ReplaceAll(varPrefix, " ", "_")
 
-	envVarToRead := strings.ToUpper(varPrefix) + "_ACCESS_TOKEN"
-	log.Logger.Debug().Msg("Reading token from environment variable: " + envVarToRead)
-	if t, tokenSet := os.LookupEnv(envVarToRead); tokenSet {
-		log.Logger.Debug().Msgf("Token found from environment variable: %s=%s", envVarToRead, t)
-		token = t
+	return strings.ToUpper(varPrefix) + "_ACCESS_TOKEN"
+}
+
+// clusterInUse returns the name of the cluster whose config is used, which
+// is empty if --base-uri was passed or no cluster is configured.
+func clusterInUse(cmd *cobra.Command) string {
+	if cmd.Flag("base-uri").Changed {
+		return ""
+	}
+	if cmd.Flag("cluster").Changed {
+		return cmd.Flag("cluster").Value.String()
+	}
+	return config.GlobalConfig.DefaultCluster
+}
+
+// serviceOfCommand returns the name of the top-level command that cmd is
+// under (e.g. smd for "ochami smd component get"), which names the service
+// that cmd contacts for service commands.
+func serviceOfCommand(cmd *cobra.Command) string {
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		if !c.Parent().HasParent() {
+			return c.Name()
+		}
+	}
+	return ""
+}
+
+// printEnvelope prints henv to standard output in the format passed to
+// --output-format, rendered by cli.RenderEnvelope. If an error occurs, it is
+// logged and the program exits.
+func printEnvelope(cmd *cobra.Command, henv client.HTTPEnvelope) {
+	outFmt, err := cmd.Flags().GetString("output-format")
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+		os.Exit(1)
+	}
+	if henv.StatusCode < 300 && len(henv.Body) > 0 {
+		if henv.Body, err = cli.ApplyListOptions(henv.Body, listOptions(cmd)); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to apply --limit/--sort")
+			os.Exit(1)
+		}
+	}
+	if outFmt == format.Table {
+		printTable(cmd, henv)
+		return
+	}
+	var outBytes []byte
+	if rootCmd.PersistentFlags().Lookup("output-header").Changed {
+		outBytes, err = cli.RenderEnvelopeWithHeader(henv, outFmt, cli.OutputHeader{
+			Cluster: clusterInUse(cmd),
+			Service: serviceOfCommand(cmd),
+		})
+	} else {
+		outBytes, err = cli.RenderEnvelope(henv, outFmt)
+	}
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to format output")
+		os.Exit(1)
+	}
+	fmt.Print(string(outBytes))
+}
+
+// tableKindAnnotation is the annotation of commands that can print their
+// output as a table, holding the kind of resource they print (see
+// format.Columns).
+const tableKindAnnotation = "ochami_table_kind"
+
+// addTableFormat lets cmd, a command that prints resources of kind with
+// printEnvelope, print them as a table with --output-format table.
+func addTableFormat(cmd *cobra.Command, kind string) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[tableKindAnnotation] = kind
+	_ = cmd.RegisterFlagCompletionFunc("output-format", cobra.FixedCompletions(append(slices.Clone(outputFormats), format.Table), cobra.ShellCompDirectiveNoFileComp))
+}
+
+// tableKind returns the kind of resource cmd prints as a table, or an empty
+// string if it cannot print tables.
+func tableKind(cmd *cobra.Command) string {
+	return cmd.Annotations[tableKindAnnotation]
+}
+
+// printTable prints the response data in henv as a table of the kind of
+// resource cmd prints. Responses that were not successful are printed as JSON,
+// since they are not lists of resources.
+func printTable(cmd *cobra.Command, henv client.HTTPEnvelope) {
+	kind := tableKind(cmd)
+	if kind == "" {
+		log.Logger.Error().Msgf("%s cannot print tables, use one of: %s", cmd.CommandPath(), strings.Join(outputFormats, ","))
+		os.Exit(1)
+	}
+	if rootCmd.PersistentFlags().Lookup("output-header").Changed {
+		log.Logger.Error().Msg("--output-header cannot be used with --output-format table")
+		os.Exit(1)
+	}
+	if henv.StatusCode >= 300 || len(henv.Body) == 0 {
+		outBytes, err := cli.RenderEnvelope(henv, "json")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to format output")
+			os.Exit(1)
+		}
+		fmt.Print(string(outBytes))
 		return
 	}
+	noHeaders, err := rootCmd.PersistentFlags().GetBool("no-headers")
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to get value for --no-headers")
+		os.Exit(1)
+	}
+	if err := format.RenderTable(os.Stdout, kind, henv.Body, noHeaders); err != nil {
+		log.Logger.Error().Err(err).Msg("failed to format output as table")
+		os.Exit(1)
+	}
+}
+
+// addListFlags adds --limit and --sort to cmd, a command that prints a list
+// with printEnvelope.
+func addListFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("limit", 0, "print at most this many items (0 for all)")
+	cmd.Flags().String("sort", "", "sort items by field, optionally followed by :asc or :desc (e.g. ID:desc)")
+}
+
+// listOptions returns the values of the flags added by addListFlags to cmd. If
+// cmd does not have them, the zero value, which leaves lists as is, is
+// returned.
+func listOptions(cmd *cobra.Command) cli.ListOptions {
+	var opts cli.ListOptions
+	if cmd.Flags().Lookup("limit") == nil {
+		return opts
+	}
+	var err error
+	if opts.Limit, err = cmd.Flags().GetInt("limit"); err != nil {
+		log.Logger.Error().Err(err).Msg("failed to get value for --limit")
+		os.Exit(1)
+	}
+	if s, _ := cmd.Flags().GetString("sort"); s != "" {
+		if err := opts.ParseSort(s); err != nil {
+			log.Logger.Error().Err(err).Msg("invalid value for --sort")
+			os.Exit(1)
+		}
+	}
+	return opts
+}
 
-	log.Logger.Error().Msgf("Environment variable %s unset for reading token for cluster %q", envVarToRead, clusterName)
-	os.Exit(1)
+// addTimeWindowFlags adds --since and --until to cmd, a command that fetches
+// history-style data that can be limited to a time window.
+func addTimeWindowFlags(cmd *cobra.Command) {
+	addTimeFlag(cmd, "since", "only show entries at or after this time (RFC 3339, date, -24h, today, yesterday, ...)")
+	addTimeFlag(cmd, "until", "only show entries at or before this time (RFC 3339, date, -24h, today, yesterday, ...)")
+}
+
+// addTimeFlag adds the flag name, which takes a point in time (see
+// flagtypes.Time), to cmd, with completion hints.
+func addTimeFlag(cmd *cobra.Command, name, usage string) {
+	cmd.Flags().Var(flagtypes.NewTime(), name, usage)
+	_ = cmd.RegisterFlagCompletionFunc(name, cobra.FixedCompletions(flagtypes.TimeHints, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// addDurationFlag adds the flag name, which takes a duration (see
+// flagtypes.Duration) and defaults to value, to cmd, with completion hints.
+// Its value is read with GetDuration.
+func addDurationFlag(cmd *cobra.Command, name string, value time.Duration, usage string) {
+	cmd.Flags().Var(flagtypes.NewDuration(value), name, usage)
+	_ = cmd.RegisterFlagCompletionFunc(name, cobra.FixedCompletions(flagtypes.DurationHints, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// timeWindow returns the time window passed to cmd with the flags added by
+// addTimeWindowFlags. If neither was passed, the zero window, which contains
+// all times, is returned.
+func timeWindow(cmd *cobra.Command) timeparse.Window {
+	since, _ := flagtypes.GetTime(cmd.Flags(), "since")
+	until, _ := flagtypes.GetTime(cmd.Flags(), "until")
+	w, err := timeparse.NewWindow(since, until)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("invalid value for --since/--until")
+		os.Exit(1)
+	}
+	return w
+}
+
+// exitIfInterrupted checks whether the program was interrupted while an
+// iterative operation, whose per-item errors are errs, was running. If so, the
+// errors of the requests that failed and a summary of the operation are logged
+// and the program exits with cli.ExitInterrupted. Otherwise, it returns so that
+// errs can be handled as usual.
+func exitIfInterrupted(errs []error) {
+	if runner == nil || !runner.Interrupted() {
+		return
+	}
+	for _, e := range errs {
+		if e != nil && !errors.Is(e, client.ErrNotSent) {
+			log.Logger.Error().Err(e).Msg("request failed")
+		}
+	}
+	log.Logger.Warn().Msgf("interrupted, stopped sending requests: %s", cli.SummarizeBulk(errs))
+	os.Exit(cli.ExitInterrupted)
+}
+
+// exitIfBulkFailed summarizes errs, the per-item errors of the iterative
+// operation described by what (e.g. "SMD group addition"), and exits if any
+// item failed (see exitIfItemsFailed). The errors themselves should already
+// have been logged.
+func exitIfBulkFailed(errs []error, what string) {
+	exitIfItemsFailed(what, cli.SummarizeBulk(errs))
+}
+
+// exitIfItemsFailed returns if none of the items of the iterative operation
+// described by what, whose outcomes are summarized by s, failed. If all of them
+// failed, the program exits with status 1. If only some of them failed, the
+// program exits with cli.ExitPartialFailure, unless --partial-ok was passed, in
+// which case a warning is logged and it returns.
+func exitIfItemsFailed(what string, s cli.BulkSummary) {
+	if s.Succeeded == s.Total {
+		return
+	}
+	if !s.Partial() {
+		log.Logger.Warn().Msgf("%s failed: %s", what, s)
+		os.Exit(1)
+	}
+	if partialOK, _ := rootCmd.PersistentFlags().GetBool("partial-ok"); partialOK {
+		log.Logger.Warn().Msgf("%s completed with errors, ignoring due to --partial-ok: %s", what, s)
+		return
+	}
+	log.Logger.Warn().Msgf("%s partially failed: %s", what, s)
+	os.Exit(cli.ExitPartialFailure)
+}
+
+// logHTTPError logs err, which was returned along with henv, with the message
+// msg. If err is due to an unsuccessful HTTP response, the problem details in
+// its body are logged as fields instead of the raw body.
+func logHTTPError(err error, henv client.HTTPEnvelope, msg string) {
+	if !errors.Is(err, client.UnsuccessfulHTTPError) || henv.StatusCode == 0 {
+		log.Logger.Error().Err(err).Msg(msg)
+		return
+	}
+	p, _ := cli.ProblemFromEnvelope(henv)
+	e := log.Logger.Error().Int("status", p.Status)
+	if p.Title != "" {
+		e = e.Str("title", p.Title)
+	}
+	if p.Detail != "" {
+		e = e.Str("detail", p.Detail)
+	}
+	if p.Instance != "" {
+		e = e.Str("instance", p.Instance)
+	}
+	e.Msg(msg)
 }
 
 // handlePayload unmarshals a payload file into data for command cmd if
-// --payload and, optionally, --payload-format, are passed.
+// --payload and, optionally, --payload-format and --payload-insecure, are
+// passed. The payload file can also be a URL.
 func handlePayload(cmd *cobra.Command, data any) {
 	if cmd.Flag("payload").Changed {
 		dFile := cmd.Flag("payload").Value.String()
 		dFormat := cmd.Flag("payload-format").Value.String()
-		err := client.ReadPayload(dFile, dFormat, data)
+		dInsecure := false
+		if f := cmd.Flag("payload-insecure"); f != nil {
+			dInsecure = f.Changed
+		}
+		err := client.ReadPayload(dFile, dFormat, dInsecure, data, payloadTransforms...)
 		if err != nil {
 			log.Logger.Error().Err(err).Msg("unable to read payload for request")
 			os.Exit(1)
 		}
 	}
 }
+
+// addTemplateFlags adds --template and --var-file to cmd, an add command, so
+// that many items can be added by rendering a template once per row of a CSV
+// file (see handleTemplate). The flags are mutually exclusive with --payload
+// and --set.
+func addTemplateFlags(cmd *cobra.Command) {
+	cmd.Flags().String("template", "", "template of one payload item, or @<path> to read it from a file; rendered once per row of --var-file in --payload-format")
+	cmd.Flags().String("var-file", "", "CSV file (- for standard input) whose header names the variables of --template and whose rows are rendered with it")
+	cmd.MarkFlagsRequiredTogether("template", "var-file")
+	cmd.MarkFlagsMutuallyExclusive("template", "payload")
+	cmd.MarkFlagsMutuallyExclusive("template", "set")
+}
+
+// handleTemplate renders the template passed to cmd with --template once per
+// row of the file passed with --var-file, unmarshalling the items into data,
+// which must be a pointer to a slice. If an error occurs, a log is printed and
+// the program exits.
+func handleTemplate(cmd *cobra.Command, data any) {
+	tmpl := cmd.Flag("template").Value.String()
+	if path, ok := strings.CutPrefix(tmpl, "@"); ok {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("unable to read template")
+			os.Exit(1)
+		}
+		tmpl = string(b)
+	}
+	varFile := cmd.Flag("var-file").Value.String()
+	dFormat := cmd.Flag("payload-format").Value.String()
+	if err := client.ReadTemplatePayload(tmpl, varFile, dFormat, data); err != nil {
+		log.Logger.Error().Err(err).Msg("unable to render template for request")
+		os.Exit(1)
+	}
+}
+
+// initTransforms sets payloadTransforms from --transform and
+// --transform-command. jq filters are applied before commands. If a jq filter
+// is passed but jq is not installed, a log is printed and the program exits.
+func initTransforms(cmd *cobra.Command) {
+	filters, err := cmd.Flags().GetStringArray("transform")
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to fetch flag transform")
+		os.Exit(1)
+	}
+	commands, err := cmd.Flags().GetStringArray("transform-command")
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to fetch flag transform-command")
+		os.Exit(1)
+	}
+	if len(filters) > 0 {
+		if _, err := exec.LookPath("jq"); err != nil {
+			log.Logger.Error().Err(err).Msg("--transform requires jq to be installed")
+			os.Exit(1)
+		}
+	}
+	for _, f := range filters {
+		payloadTransforms = append(payloadTransforms, client.JQTransform(f))
+	}
+	for _, c := range commands {
+		payloadTransforms = append(payloadTransforms, client.CommandTransform(c))
+	}
+}
+
+// addSetFlag adds --set to cmd, an add or update command, so that fields of
+// what (e.g. "the component") can be set on the command line (see handleSet).
+func addSetFlag(cmd *cobra.Command, what string) {
+	cmd.Flags().StringArray("set", []string{}, "set field of "+what+" to value, parsed as YAML (<field>=<value>); nested fields are separated by dots")
+}
+
+// handleSet sets the fields of data passed to cmd with --set, if any, using
+// client.SetFields. Fields that are not passed are left as they are in data. If
+// an error occurs, a log is printed and the program exits.
+func handleSet(cmd *cobra.Command, data any) {
+	if f := cmd.Flag("set"); f == nil || !f.Changed {
+		return
+	}
+	sets, err := cmd.Flags().GetStringArray("set")
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("unable to fetch fields passed with --set")
+		os.Exit(1)
+	}
+	if err := client.SetFields(data, sets); err != nil {
+		log.Logger.Error().Err(err).Msg("invalid --set")
+		os.Exit(1)
+	}
+}
+
+// addPatchFlags adds --patch-file and --patch-type to cmd, an update command
+// whose service accepts patch documents (see handlePatch).
+func addPatchFlags(cmd *cobra.Command) {
+	cmd.Flags().String("patch-file", "", "file or URL containing a patch document to send instead of an update; format set by --payload-format")
+	cmd.Flags().String("patch-type", string(client.PatchTypeMergePatch), "type of patch passed with --patch-file (json-patch,merge-patch)")
+}
+
+// handlePatch returns the type and contents of the patch document passed to
+// cmd with --patch-file, reading it like a payload file (see handlePayload).
+// The patch is checked with client.ValidatePatch. If an error occurs, a log is
+// printed and the program exits.
+func handlePatch(cmd *cobra.Command) (client.PatchType, client.HTTPBody) {
+	pt, err := client.ParsePatchType(cmd.Flag("patch-type").Value.String())
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("invalid value for --patch-type")
+		os.Exit(1)
+	}
+	pFile := cmd.Flag("patch-file").Value.String()
+	pFormat := cmd.Flag("payload-format").Value.String()
+	pInsecure := false
+	if f := cmd.Flag("payload-insecure"); f != nil {
+		pInsecure = f.Changed
+	}
+	var patch json.RawMessage
+	if err := client.ReadPayload(pFile, pFormat, pInsecure, &patch, payloadTransforms...); err != nil {
+		log.Logger.Error().Err(err).Msg("unable to read patch file")
+		os.Exit(1)
+	}
+	if err := client.ValidatePatch(pt, client.HTTPBody(patch)); err != nil {
+		log.Logger.Error().Err(err).Msgf("invalid %s in %s", pt, pFile)
+		os.Exit(1)
+	}
+	return pt, client.HTTPBody(patch)
+}
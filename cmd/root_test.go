@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/OpenCHAMI/ochami/internal/config"
+	"github.com/OpenCHAMI/ochami/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// withConfig sets the global config to c for the duration of t.
+func withConfig(t *testing.T, c config.Config) {
+	t.Helper()
+	saved := config.GlobalConfig
+	config.GlobalConfig = c
+	t.Cleanup(func() { config.GlobalConfig = saved })
+}
+
+// withTerminal makes stdoutIsTerminal report terminal for the duration of t.
+func withTerminal(t *testing.T, terminal bool) {
+	t.Helper()
+	saved := stdoutIsTerminal
+	stdoutIsTerminal = func() bool { return terminal }
+	t.Cleanup(func() { stdoutIsTerminal = saved })
+}
+
+func TestDefaultOutputFormatFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		terminal   bool
+		pipe       string
+		configured string
+		want       string
+	}{
+		{name: "terminal", terminal: true, want: formatJSONPretty},
+		{name: "terminal configured", terminal: true, configured: "yaml", want: "yaml"},
+		{name: "terminal configured table", terminal: true, configured: format.Table, want: format.Table},
+		{name: "terminal ignores format-output-pipe", terminal: true, pipe: "toml", want: formatJSONPretty},
+		{name: "pipe", want: defaultOutputFormat},
+		{name: "pipe configured", configured: "yaml", want: "yaml"},
+		{name: "pipe configured json-pretty", configured: formatJSONPretty, want: defaultOutputFormat},
+		{name: "pipe configured table", configured: format.Table, want: defaultOutputFormat},
+		{name: "pipe format-output-pipe", pipe: "toml", want: "toml"},
+		{name: "pipe format-output-pipe over configured", pipe: "toml", configured: "yaml", want: "toml"},
+		{name: "pipe format-output-pipe human", pipe: formatJSONPretty, configured: "yaml", want: formatJSONPretty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withConfig(t, config.Config{FormatOutputPipe: tt.pipe})
+			if got := defaultOutputFormatFor(tt.configured, tt.terminal); got != tt.want {
+				t.Errorf("defaultOutputFormatFor(%q, %v) = %q, want %q", tt.configured, tt.terminal, got, tt.want)
+			}
+		})
+	}
+}
+
+// newFormatTestCmd returns a command named get under a root command, with the
+// root flags applyConfigFormats looks at and an --output-format flag that
+// defaults to defValue.
+func newFormatTestCmd(defValue string) *cobra.Command {
+	root := &cobra.Command{Use: "ochami"}
+	root.PersistentFlags().String("cluster", "", "")
+	root.PersistentFlags().String("base-uri", "", "")
+	cmd := &cobra.Command{Use: "get", Run: func(*cobra.Command, []string) {}}
+	cmd.Flags().StringP("output-format", "F", defValue, "")
+	root.AddCommand(cmd)
+	return cmd
+}
+
+func TestApplyConfigFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		terminal bool
+		cfg      config.Config
+		flag     string // value of --output-format passed, if any
+		defValue string
+		want     string
+	}{
+		{name: "terminal", terminal: true, defValue: defaultOutputFormat, want: formatJSONPretty},
+		{name: "pipe", defValue: defaultOutputFormat, want: defaultOutputFormat},
+		{name: "pipe format-output-pipe", cfg: config.Config{FormatOutputPipe: "yaml"}, defValue: defaultOutputFormat, want: "yaml"},
+		{name: "terminal configured", terminal: true, cfg: config.Config{FormatOutput: "toml"}, defValue: defaultOutputFormat, want: "toml"},
+		{name: "pipe configured human", cfg: config.Config{FormatOutput: formatJSONPretty}, defValue: defaultOutputFormat, want: defaultOutputFormat},
+		{
+			name:     "pipe configured for command",
+			cfg:      config.Config{FormatOutput: "toml", Commands: map[string]config.ConfigFormat{"get": {FormatOutput: "yaml"}}},
+			defValue: defaultOutputFormat,
+			want:     "yaml",
+		},
+		{name: "explicit flag on terminal", terminal: true, flag: defaultOutputFormat, defValue: defaultOutputFormat, want: defaultOutputFormat},
+		{name: "explicit flag on pipe", cfg: config.Config{FormatOutputPipe: "toml"}, flag: formatJSONPretty, defValue: defaultOutputFormat, want: formatJSONPretty},
+		{name: "other default on terminal", terminal: true, defValue: "yaml", want: "yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withConfig(t, tt.cfg)
+			withTerminal(t, tt.terminal)
+			cmd := newFormatTestCmd(tt.defValue)
+			if tt.flag != "" {
+				if err := cmd.Flags().Set("output-format", tt.flag); err != nil {
+					t.Fatal(err)
+				}
+			}
+			applyConfigFormats(cmd)
+			if got, _ := cmd.Flags().GetString("output-format"); got != tt.want {
+				t.Errorf("--output-format = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:       "schema [--list] <resource>",
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: schema.Names(),
+	Short:     "Print JSON Schema for payload files",
+	Long: `Print the JSON Schema describing the payload file format accepted by
+commands for a resource. The schema is generated from the same data
+structures that ochami reads payloads into, so it can be used to
+validate payload files before passing them to ochami, e.g. in CI
+pipelines. Pass --list to list the available resources.`,
+	Example: `  ochami schema --list
+  ochami schema component
+  ochami schema discovery-items > discovery.schema.json
+  ochami schema bootparams -F yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cmd.Flag("list").Changed {
+			for _, name := range schema.Names() {
+				fmt.Printf("%-20s %s\n", name, schema.Resources[name].Description)
+			}
+			return
+		}
+		if len(args) == 0 {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+
+		s, err := schema.Generate(args[0])
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to generate schema (available resources: %s)", strings.Join(schema.Names(), ", "))
+			os.Exit(1)
+		}
+		sBytes, err := json.Marshal(s)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to marshal schema")
+			os.Exit(1)
+		}
+
+		// Print the schema
+		outFmt, err := cmd.Flags().GetString("output-format")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+			os.Exit(1)
+		}
+		outBytes, err := client.FormatBody(sBytes, outFmt)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to format output")
+			os.Exit(1)
+		}
+		fmt.Print(string(outBytes))
+	},
+}
+
+func init() {
+	schemaCmd.Flags().Bool("list", false, "list available resources and exit")
+	schemaCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+
+	rootCmd.AddCommand(schemaCmd)
+}
@@ -0,0 +1,159 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+// serveProxyCmd represents the serve-proxy command
+var serveProxyCmd = &cobra.Command{
+	Use:   "serve-proxy",
+	Args:  cobra.NoArgs,
+	Short: "Run a local daemon that keeps connections to services open",
+	Long: `Run a local daemon that keeps connections to services open across
+invocations of ochami. Invocations passed --via-daemon send their requests
+to the daemon over a Unix socket instead of connecting to the services
+themselves, and the daemon forwards them over connections it keeps open.
+This saves a TCP connection and TLS handshake per invocation, which
+dominates the runtime of scripts that run ochami in a loop.
+
+The daemon holds no credentials of its own: the access token of each request
+is passed through as is, and the TLS settings (e.g. --cacert or --insecure)
+are those of the invocation that sent it. Only the user running the daemon
+can connect to its socket. The directory of the socket must be owned by
+that user and not be accessible to other users, and clients refuse to use a
+socket that is not, sending their requests directly instead.
+
+The socket is --socket, which defaults to $OCHAMI_DAEMON_SOCKET, or to
+ochami/proxy.sock in $XDG_RUNTIME_DIR (or in a directory in /tmp if it is not
+set). The daemon runs in the foreground until it is interrupted or, if
+--idle-timeout is passed, until no requests were received for that long.`,
+	Example: `  ochami serve-proxy &
+  for n in $(seq 1 100); do ochami --via-daemon smd component get --xname x1000c0s${n}b0n0; done
+  ochami serve-proxy --idle-timeout 30m --socket /run/user/1000/ochami.sock`,
+	Run: func(cmd *cobra.Command, args []string) {
+		socket, err := cmd.Flags().GetString("socket")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --socket")
+			os.Exit(1)
+		}
+		idleTimeout, err := cmd.Flags().GetDuration("idle-timeout")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --idle-timeout")
+			os.Exit(1)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(socket), 0o700); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to create directory of socket")
+			os.Exit(1)
+		}
+		// Another user may have created the directory first to receive
+		// the requests of clients
+		if err := client.CheckDaemonSocketDir(filepath.Dir(socket)); err != nil {
+			log.Logger.Error().Err(err).Msg("refusing to listen in directory of socket; remove it or pass --socket with a directory only accessible to you")
+			os.Exit(1)
+		}
+		// Remove the socket of a daemon that did not exit cleanly, but not
+		// that of one that is still running
+		if _, err := os.Stat(socket); err == nil {
+			if conn, err := net.Dial("unix", socket); err == nil {
+				conn.Close()
+				log.Logger.Error().Msgf("a daemon is already listening on %s", socket)
+				os.Exit(1)
+			}
+			if err := os.Remove(socket); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to remove stale socket")
+				os.Exit(1)
+			}
+		}
+		l, err := net.Listen("unix", socket)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to listen on socket")
+			os.Exit(1)
+		}
+		defer os.Remove(socket)
+		if err := os.Chmod(socket, 0o600); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to restrict permissions of socket")
+			os.Exit(1)
+		}
+		if err := client.CheckDaemonSocket(socket); err != nil {
+			log.Logger.Error().Err(err).Msg("socket is not private")
+			os.Exit(1)
+		}
+
+		// Tell the main loop about each request so that it can stop after
+		// --idle-timeout
+		activity := make(chan struct{}, 1)
+		handler := client.DaemonHandler()
+		srv := &http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case activity <- struct{}{}:
+				default:
+				}
+				handler.ServeHTTP(w, r)
+			}),
+			ReadHeaderTimeout: 30 * time.Second,
+		}
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- srv.Serve(l)
+		}()
+		log.Logger.Info().Msgf("listening on %s", socket)
+
+		var idle <-chan time.Time
+		var timer *time.Timer
+		if idleTimeout > 0 {
+			timer = time.NewTimer(idleTimeout)
+			idle = timer.C
+		}
+	loop:
+		for {
+			select {
+			case <-activity:
+				if timer != nil {
+					if !timer.Stop() {
+						<-timer.C
+					}
+					timer.Reset(idleTimeout)
+				}
+			case <-idle:
+				log.Logger.Info().Msgf("no requests for %s, stopping", idleTimeout)
+				break loop
+			case <-runner.Context().Done():
+				log.Logger.Info().Msg("interrupted, stopping")
+				break loop
+			case err := <-serveErr:
+				log.Logger.Error().Err(err).Msg("daemon stopped unexpectedly")
+				os.Remove(socket)
+				os.Exit(1)
+			}
+		}
+
+		// Let requests in flight finish
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			log.Logger.Warn().Err(err).Msg("failed to stop daemon cleanly")
+		}
+		client.CloseIdleConnections()
+	},
+}
+
+func init() {
+	serveProxyCmd.Flags().String("socket", client.DefaultDaemonSocket(), "path of Unix socket to listen on")
+	addDurationFlag(serveProxyCmd, "idle-timeout", 0, "stop after no requests were received for this long (0 to never stop)")
+
+	rootCmd.AddCommand(serveProxyCmd)
+}
@@ -22,9 +22,17 @@ Alternatively, pass the xnames in an array of component endpoint data
 via a file with -f. If - is passed to -f, the data is read from standard
 input.
 
+With --all, all component endpoints in SMD are deleted. They are counted first
+and the number that will be deleted is shown before, unless --force is passed,
+the user is asked to confirm. If the count changes while the user is
+confirming, nothing is deleted. With --expect-count, the command fails without
+deleting anything if the count does not match the number passed, e.g. to guard
+scripts against deleting more than expected.
+
 This command sends a DELETE to SMD. An access token is required.`,
 	Example: `  ochami smd compep delete x3000c1s7b56n0 x3000c1s7b56n1
   ochami smd compep delete --all
+  ochami smd compep delete --all --force --expect-count 4213
   ochami smd compep delete -f payload.json
   ochami smd compep delete -f payload.yaml --payload-format yaml
   echo '<json_data>' | ochami smd compep delete -f -
@@ -46,6 +54,11 @@ This command sends a DELETE to SMD. An access token is required.`,
 			}
 		}
 
+		if cmd.Flag("expect-count").Changed && !cmd.Flag("all").Changed {
+			log.Logger.Error().Msg("--expect-count can only be used with --all")
+			os.Exit(1)
+		}
+
 		// Without a base URI, we cannot do anything
 		smdBaseURI, err := getBaseURI(cmd)
 		if err != nil {
@@ -64,18 +77,16 @@ This command sends a DELETE to SMD. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
-		// Ask before attempting deletion unless --force was passed
-		if !cmd.Flag("force").Changed {
+		// Ask before attempting deletion unless --force was passed,
+		// telling the user how many resources --all deletes
+		if cmd.Flag("all").Changed {
+			confirmDeleteAll(cmd, "component endpoints", func() (int, error) { return smdClient.CountComponentEndpoints(token) })
+		} else if !cmd.Flag("force").Changed {
 			log.Logger.Debug().Msg("--force not passed, prompting user to confirm deletion")
-			var respDelete bool
-			if cmd.Flag("all").Changed {
-				respDelete = loopYesNo("Really delete ALL COMPONENT ENDPOINTS?")
-			} else {
-				respDelete = loopYesNo("Really delete?")
-			}
+			respDelete := loopYesNo("Really delete?")
 			if !respDelete {
 				log.Logger.Info().Msg("User aborted component endpoint deletion")
 				os.Exit(0)
@@ -114,32 +125,31 @@ This command sends a DELETE to SMD. An access token is required.`,
 				log.Logger.Error().Err(err).Msg("failed to delete redfish endpoints in SMD")
 				os.Exit(1)
 			}
+			exitIfInterrupted(errs)
+
 			// Since smdClient.DeleteComponentEndpoints does the deletion iteratively, we need to
 			// deal with each error that might have occurred.
-			var errorsOccurred = false
 			for _, e := range errs {
-				if err != nil {
+				if e != nil {
 					if errors.Is(e, client.UnsuccessfulHTTPError) {
 						log.Logger.Error().Err(e).Msg("SMD component endpoint deletion yielded unsuccessful HTTP response")
 					} else {
 						log.Logger.Error().Err(e).Msg("failed to delete component endpoints")
 					}
-					errorsOccurred = true
 				}
 			}
 			// Warn the user if any errors occurred during deletion iterations
-			if errorsOccurred {
-				log.Logger.Warn().Msg("SMD component endpoint deletion completed with errors")
-				os.Exit(1)
-			}
+			exitIfBulkFailed(errs, "SMD component endpoint deletion")
 		}
 	},
 }
 
 func init() {
 	compepDeleteCmd.Flags().BoolP("all", "a", false, "delete all redfish endpoints in SMD")
-	compepDeleteCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	compepDeleteCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	compepDeleteCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	compepDeleteCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	compepDeleteCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
 	compepDeleteCmd.Flags().Bool("force", false, "do not ask before attempting deletion")
+	compepDeleteCmd.Flags().Int("expect-count", 0, "with --all, only delete if this is the number of component endpoints that would be deleted")
 	compepCmd.AddCommand(compepDeleteCmd)
 }
@@ -37,8 +37,8 @@ var compepGetCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
 		var httpEnv client.HTTPEnvelope
 		if len(args) == 0 {
@@ -46,7 +46,7 @@ var compepGetCmd = &cobra.Command{
 			httpEnv, err = smdClient.GetComponentEndpointsAll(token)
 			if err != nil {
 				if errors.Is(err, client.UnsuccessfulHTTPError) {
-					log.Logger.Error().Err(err).Msg("SMD component endpoimt request yielded unsuccessful HTTP response")
+					logHTTPError(err, httpEnv, "SMD component endpoimt request yielded unsuccessful HTTP response")
 				} else {
 					log.Logger.Error().Err(err).Msg("failed to request component endpoints from SMD")
 				}
@@ -54,34 +54,24 @@ var compepGetCmd = &cobra.Command{
 			}
 
 			// Print output
-			outFmt, err := cmd.Flags().GetString("output-format")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
-				os.Exit(1)
-			}
-			if outBytes, err := client.FormatBody(httpEnv.Body, outFmt); err != nil {
-				log.Logger.Error().Err(err).Msg("failed to format output")
-				os.Exit(1)
-			} else {
-				fmt.Printf(string(outBytes))
-			}
+			printEnvelope(cmd, httpEnv)
 		} else {
 			httpEnvs, errs, err := smdClient.GetComponentEndpoints(token, args...)
 			if err != nil {
 				log.Logger.Error().Err(err).Msg("failed to get component endpoints from SMD")
 				os.Exit(1)
 			}
-			// Since smdClient.GetComponentEndpoints does the deletion iteratively, we need to
+			exitIfInterrupted(errs)
+
+			// Since smdClient.GetComponentEndpoints does the GETs iteratively, we need to
 			// deal with each error that might have occurred.
-			var errorsOccurred = false
-			for _, e := range errs {
-				if err != nil {
+			for i, e := range errs {
+				if e != nil {
 					if errors.Is(e, client.UnsuccessfulHTTPError) {
-						log.Logger.Error().Err(e).Msg("SMD redfish endpoint deletion yielded unsuccessful HTTP response")
+						log.Logger.Error().Err(e).Msgf("SMD component endpoint request for %s yielded unsuccessful HTTP response", args[i])
 					} else {
-						log.Logger.Error().Err(e).Msg("failed to delete redfish endpoint")
+						log.Logger.Error().Err(e).Msgf("failed to get component endpoint %s", args[i])
 					}
-					errorsOccurred = true
 				}
 			}
 
@@ -102,12 +92,6 @@ var compepGetCmd = &cobra.Command{
 				}
 			}
 
-			// Warn the user if any errors occurred during deletion iterations
-			if errorsOccurred {
-				log.Logger.Warn().Msg("SMD redfish endpoint deletion completed with errors")
-				os.Exit(1)
-			}
-
 			ces := compEp{ComponentEndpoints: ceArr}
 			cesBytes, err := json.Marshal(ces)
 			if err != nil {
@@ -125,13 +109,18 @@ var compepGetCmd = &cobra.Command{
 				log.Logger.Error().Err(err).Msg("failed to format output")
 				os.Exit(1)
 			} else {
-				fmt.Printf(string(outBytes))
+				fmt.Print(string(outBytes))
 			}
+
+			// Warn the user if any errors occurred during the GETs,
+			// after printing the component endpoints that were found
+			exitIfBulkFailed(errs, "SMD component endpoint retrieval")
 		}
 	},
 }
 
 func init() {
 	compepGetCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	addListFlags(compepGetCmd)
 	compepCmd.AddCommand(compepGetCmd)
 }
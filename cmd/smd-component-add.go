@@ -5,6 +5,7 @@ package cmd
 import (
 	"errors"
 	"os"
+	"strconv"
 
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
@@ -14,32 +15,52 @@ import (
 
 // componentAddCmd represents the smd-component-add command
 var componentAddCmd = &cobra.Command{
-	Use:   "add -f <payload_file> | (<xname> <node_id>)",
+	Use:   "add -f <payload_file> | --template <template> --var-file <csv_file> | ([--set <field>=<value>]... [<xname> <node_id>])",
 	Short: "Add new component(s)",
 	Long: `Add new component(s). A name (xname) and node ID (int64) are required unless
 -f is passed to read from a payload file. Specifying -f also is
 mutually exclusive with the other flags of this command. If - is
 used as the argument to -f, the data is read from standard input.
 
+Any field of the component can be set with --set <field>=<value>, using the
+field names of the payload (e.g. ID, NID, or SoftwareStatus). The name and
+node ID can be set this way instead of being passed as arguments. Values
+are parsed as YAML, so quote a value (e.g. --set "SubRole='123'") to pass a
+number as a string.
+
+Many similar components can be added at once with --template and --var-file.
+The template describes one component in --payload-format, using Go template
+syntax, and is rendered once per row of the CSV file passed with --var-file,
+whose header names the variables available to the template (e.g. {{.xname}}
+for a column named xname). The template is passed as is or as @<path> to read
+it from a file.
+
 This command sends a POST to SMD. An access token is required.`,
 	Example: `  ochami smd component add x3000c1s7b56n0 56
   ochami smd component add --state Ready --enabled --role Compute --arch X86 x3000c1s7b56n0 56
+  ochami smd component add --role Management --subrole Master --class River x3000c1s7b56n0 56
+  ochami smd component add --set ID=x3000c1s7b56n0 --set NID=56 --set SoftwareStatus=AdminDown
   ochami smd component add -f payload.json
+  ochami smd component add --template @component.tmpl --var-file nodes.csv
+  ochami smd component add --template '{"ID": "{{.xname}}", "NID": {{.nid}}}' --var-file nodes.csv
   ochami smd component add -f payload.yaml --payload-format yaml
   echo '<json_data>' | ochami smd component add -f -
   echo '<yaml_data>' | ochami smd component add -f - --payload-format yaml`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Check that all required args are passed
-		if len(args) == 0 && !cmd.Flag("payload").Changed {
+		if len(args) == 0 && !cmd.Flag("payload").Changed && !cmd.Flag("set").Changed && !cmd.Flag("template").Changed {
 			err := cmd.Usage()
 			if err != nil {
 				log.Logger.Error().Err(err).Msg("failed to print usage")
 				os.Exit(1)
 			}
 			os.Exit(0)
-		} else if len(args) > 2 {
+		} else if !cmd.Flag("payload").Changed && !cmd.Flag("template").Changed && len(args) != 2 && (len(args) != 0 || !cmd.Flag("set").Changed) {
 			log.Logger.Error().Msgf("expected 2 arguments (xname, nid) but got %d: %v", len(args), args)
 			os.Exit(1)
+		} else if cmd.Flag("template").Changed && len(args) > 0 {
+			log.Logger.Error().Msgf("expected no arguments with --template but got %d: %v", len(args), args)
+			os.Exit(1)
 		}
 
 		// Without a base URI, we cannot do anything
@@ -60,24 +81,48 @@ This command sends a POST to SMD. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
 		var compSlice smd.ComponentSlice
 		if cmd.Flag("payload").Changed {
 			handlePayload(cmd, &compSlice)
+		} else if cmd.Flag("template").Changed {
+			handleTemplate(cmd, &compSlice.Components)
 		} else {
 			// ...otherwise use CLI options
 			comp := smd.Component{
-				ID:    args[0],
-				State: cmd.Flag("state").Value.String(),
-				Role:  cmd.Flag("role").Value.String(),
-				Arch:  cmd.Flag("arch").Value.String(),
+				Type:    cmd.Flag("type").Value.String(),
+				Subtype: cmd.Flag("subtype").Value.String(),
+				State:   cmd.Flag("state").Value.String(),
+				Flag:    cmd.Flag("flag").Value.String(),
+				Role:    cmd.Flag("role").Value.String(),
+				SubRole: cmd.Flag("subrole").Value.String(),
+				NetType: cmd.Flag("net-type").Value.String(),
+				Arch:    cmd.Flag("arch").Value.String(),
+				Class:   cmd.Flag("class").Value.String(),
 			}
-			comp.Enabled, err = cmd.Flags().GetBool("enabled")
+			enabled, err := cmd.Flags().GetBool("enabled")
 			if err != nil {
 				log.Logger.Error().Err(err).Msg("failed to retrieve flag 'enabled', defaulting to true")
-				comp.Enabled = true
+				enabled = true
+			}
+			comp.Enabled = &enabled
+			if len(args) == 2 {
+				nid, err := strconv.ParseInt(args[1], 10, 64)
+				if err != nil {
+					log.Logger.Error().Err(err).Msgf("invalid node ID: %s", args[1])
+					os.Exit(1)
+				}
+				comp.ID = args[0]
+				comp.NID = nid
+			}
+
+			// Fields passed with --set override the above
+			handleSet(cmd, &comp)
+			if comp.ID == "" {
+				log.Logger.Error().Msg("no xname passed (pass it as an argument or with --set ID=<xname>)")
+				os.Exit(1)
 			}
 
 			compSlice.Components = append(compSlice.Components, comp)
@@ -97,17 +142,43 @@ This command sends a POST to SMD. An access token is required.`,
 }
 
 func init() {
+	componentAddCmd.Flags().String("type", "", "HMS type of new component (e.g. Node, NodeBMC)")
+	componentAddCmd.Flags().String("subtype", "", "subtype of new component")
 	componentAddCmd.Flags().String("state", "Ready", "set readiness state of new component")
+	componentAddCmd.Flags().String("flag", "", "set state flag of new component (e.g. OK, Warning, Alert)")
 	componentAddCmd.Flags().Bool("enabled", true, "set if new component is enabled")
 	componentAddCmd.Flags().String("role", "Compute", "role of new component")
+	componentAddCmd.Flags().String("subrole", "", "subrole of new component")
+	componentAddCmd.Flags().String("net-type", "", "network type of new component (e.g. Sling, Infiniband, Ethernet)")
 	componentAddCmd.Flags().String("arch", "X86", "CPU architecture of new component")
-	componentAddCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	componentAddCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	componentAddCmd.Flags().String("class", "", "hardware class of new component (e.g. River, Mountain, Hill)")
+	addSetFlag(componentAddCmd, "the component")
+	componentAddCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	componentAddCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	componentAddCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
+	addTemplateFlags(componentAddCmd)
 
+	componentAddCmd.MarkFlagsMutuallyExclusive("type", "payload")
+	componentAddCmd.MarkFlagsMutuallyExclusive("subtype", "payload")
 	componentAddCmd.MarkFlagsMutuallyExclusive("state", "payload")
+	componentAddCmd.MarkFlagsMutuallyExclusive("flag", "payload")
 	componentAddCmd.MarkFlagsMutuallyExclusive("enabled", "payload")
 	componentAddCmd.MarkFlagsMutuallyExclusive("role", "payload")
+	componentAddCmd.MarkFlagsMutuallyExclusive("subrole", "payload")
+	componentAddCmd.MarkFlagsMutuallyExclusive("net-type", "payload")
 	componentAddCmd.MarkFlagsMutuallyExclusive("arch", "payload")
+	componentAddCmd.MarkFlagsMutuallyExclusive("class", "payload")
+	componentAddCmd.MarkFlagsMutuallyExclusive("set", "payload")
+	componentAddCmd.MarkFlagsMutuallyExclusive("type", "template")
+	componentAddCmd.MarkFlagsMutuallyExclusive("subtype", "template")
+	componentAddCmd.MarkFlagsMutuallyExclusive("state", "template")
+	componentAddCmd.MarkFlagsMutuallyExclusive("flag", "template")
+	componentAddCmd.MarkFlagsMutuallyExclusive("enabled", "template")
+	componentAddCmd.MarkFlagsMutuallyExclusive("role", "template")
+	componentAddCmd.MarkFlagsMutuallyExclusive("subrole", "template")
+	componentAddCmd.MarkFlagsMutuallyExclusive("net-type", "template")
+	componentAddCmd.MarkFlagsMutuallyExclusive("arch", "template")
+	componentAddCmd.MarkFlagsMutuallyExclusive("class", "template")
 
 	componentCmd.AddCommand(componentAddCmd)
 }
@@ -0,0 +1,237 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/internal/color"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/OpenCHAMI/ochami/pkg/nid"
+	"github.com/spf13/cobra"
+)
+
+// componentAssignNIDsCmd represents the smd-component-assign-nids command
+var componentAssignNIDsCmd = &cobra.Command{
+	Use:   "assign-nids [--group <group>]... [--start <nid>] [--strategy <strategy>] [--dry-run]",
+	Args:  cobra.NoArgs,
+	Short: "Assign NIDs to components that lack them",
+	Long: `Assign NIDs to components that lack them. The components considered are the
+members of the groups passed with --group or, if none are passed, all
+components of type Node. Components that already have a NID keep it.
+
+NIDs are assigned in the natural order of the xnames (e.g. x1000c1s2b0n0
+before x1000c1s10b0n0), starting at --start, according to --strategy:
+
+  sequential-by-xname  the lowest free NIDs, filling gaps between the NIDs
+                       in use (default)
+  contiguous-by-xname  the lowest block of consecutive free NIDs, so that the
+                       components get a range of NIDs without gaps
+
+The NIDs of all components in SMD are fetched first and are never assigned
+again, so that no two components end up with the same NID. A warning is
+printed for NIDs that several components already share.
+
+The assigned NIDs are printed as a table of xnames and NIDs, or, if
+--output-format is passed, as a list of objects with the keys ID and NID in
+that format. Pass --dry-run to print them without assigning them.
+
+This command sends a PATCH to SMD's BulkNID endpoint. An access token is
+required.`,
+	Example: `  ochami smd component assign-nids --group compute --start 1 --strategy sequential-by-xname
+  ochami smd component assign-nids --group compute --group login --start 1000 --dry-run
+  ochami smd component assign-nids --strategy contiguous-by-xname -F json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		start, err := cmd.Flags().GetInt64("start")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --start")
+			os.Exit(1)
+		}
+		if start < 1 || start > nid.MaxNID {
+			log.Logger.Error().Msgf("--start must be between 1 and %d", nid.MaxNID)
+			os.Exit(1)
+		}
+		strategy, err := cmd.Flags().GetString("strategy")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --strategy")
+			os.Exit(1)
+		}
+		if !slices.Contains(smd.NIDStrategies, strategy) {
+			log.Logger.Error().Msgf("unknown --strategy %q (must be one of %s)", strategy, strings.Join(smd.NIDStrategies, ","))
+			os.Exit(1)
+		}
+		groups, err := cmd.Flags().GetStringSlice("group")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --group")
+			os.Exit(1)
+		}
+
+		// Without a base URI, we cannot do anything
+		smdBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+			os.Exit(1)
+		}
+
+		// These endpoints require authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make requests to SMD
+		smdClient, err := smd.NewClient(smdBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		// Get all components, since NIDs must be unique cluster-wide
+		ctx := context.Background()
+		if runner != nil {
+			ctx = runner.Context()
+		}
+		comps, err := smdClient.ComponentPager("", token).All(ctx)
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msg("SMD component request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to get components from SMD")
+			}
+			os.Exit(1)
+		}
+		dups := smd.DuplicateNIDs(comps)
+		dupNIDs := make([]int64, 0, len(dups))
+		for n := range dups {
+			dupNIDs = append(dupNIDs, n)
+		}
+		sort.Slice(dupNIDs, func(i, j int) bool { return dupNIDs[i] < dupNIDs[j] })
+		for _, n := range dupNIDs {
+			log.Logger.Warn().Msgf("NID %d is already shared by components %s", n, strings.Join(dups[n], ","))
+		}
+
+		// Determine the components to assign NIDs to
+		var targets []smd.Component
+		if len(groups) > 0 {
+			members, err := smdClient.GroupMembers(groups, token)
+			if err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msg("SMD group request yielded unsuccessful HTTP response")
+				} else {
+					log.Logger.Error().Err(err).Msg("failed to get group members from SMD")
+				}
+				os.Exit(1)
+			}
+			byID := make(map[string]smd.Component, len(comps))
+			for _, c := range comps {
+				byID[strings.ToLower(c.ID)] = c
+			}
+			var missing []string
+			for _, m := range members {
+				if c, ok := byID[strings.ToLower(m)]; ok {
+					targets = append(targets, c)
+				} else {
+					missing = append(missing, m)
+				}
+			}
+			if len(missing) > 0 {
+				log.Logger.Warn().Msgf("skipping group members that are not components: %s", strings.Join(missing, ","))
+			}
+		} else {
+			for _, c := range comps {
+				if strings.EqualFold(c.Type, "Node") {
+					targets = append(targets, c)
+				}
+			}
+		}
+
+		planned, err := smd.PlanNIDs(targets, comps, start, strategy)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to assign NIDs")
+			os.Exit(1)
+		}
+		if len(planned) == 0 {
+			log.Logger.Info().Msg("no components lack a NID")
+			return
+		}
+
+		if !cmd.Flag("dry-run").Changed {
+			if henv, err := smdClient.PatchComponentsNID(smd.ComponentSlice{Components: planned}, token); err != nil {
+				logHTTPError(err, henv, "failed to set NIDs of components in SMD")
+				os.Exit(1)
+			}
+			log.Logger.Info().Msgf("assigned NIDs %s to %d component(s)", nidsOf(planned), len(planned))
+		}
+
+		// Print output
+		if cmd.Flag("output-format").Changed {
+			outFmt, err := cmd.Flags().GetString("output-format")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+				os.Exit(1)
+			}
+			type assignment struct {
+				ID  string `json:"ID"`
+				NID int64  `json:"NID"`
+			}
+			assignments := make([]assignment, len(planned))
+			for i, c := range planned {
+				assignments[i] = assignment{ID: c.ID, NID: c.NID}
+			}
+			plannedBytes, err := json.Marshal(assignments)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to marshal assigned NIDs")
+				os.Exit(1)
+			}
+			if outBytes, err := client.FormatBody(plannedBytes, outFmt); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to format output")
+				os.Exit(1)
+			} else {
+				fmt.Print(string(outBytes))
+			}
+		} else {
+			tw := color.NewTable(os.Stdout)
+			fmt.Fprintln(tw, "XNAME\tNID")
+			for _, c := range planned {
+				fmt.Fprintf(tw, "%s\t%d\n", c.ID, c.NID)
+			}
+			if err := tw.Flush(); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print assigned NIDs")
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// nidsOf returns the NIDs of comps as a list of ranges (see nid.Format).
+func nidsOf(comps []smd.Component) string {
+	nids := make([]int64, len(comps))
+	for i, c := range comps {
+		nids[i] = c.NID
+	}
+	return nid.Format(nids)
+}
+
+func init() {
+	componentAssignNIDsCmd.Flags().StringSliceP("group", "g", []string{}, "assign NIDs to the members of these groups instead of to all nodes")
+	componentAssignNIDsCmd.Flags().Int64("start", 1, "lowest NID to assign")
+	componentAssignNIDsCmd.Flags().String("strategy", smd.NIDStrategySequentialByXname, "how to assign NIDs ("+strings.Join(smd.NIDStrategies, ",")+")")
+	componentAssignNIDsCmd.Flags().Bool("dry-run", false, "print the NIDs that would be assigned without assigning them")
+	componentAssignNIDsCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+
+	_ = componentAssignNIDsCmd.RegisterFlagCompletionFunc("strategy", cobra.FixedCompletions(smd.NIDStrategies, cobra.ShellCompDirectiveNoFileComp))
+
+	setCommandMutating(componentAssignNIDsCmd, true)
+
+	componentCmd.AddCommand(componentAssignNIDsCmd)
+}
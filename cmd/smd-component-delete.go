@@ -4,7 +4,9 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
@@ -14,17 +16,37 @@ import (
 
 // componentDeleteCmd represents the smd-component-delete command
 var componentDeleteCmd = &cobra.Command{
-	Use:   "delete -f <payload_file> | --all | <xname>...",
+	Use:   "delete -f <payload_file> | --all | --where <filter> | ([--nids <nid_list>] <xname>...)",
 	Short: "Delete one or more components",
 	Long: `Delete one or more components. These can be specified by one or more xnames, one
 or more NIDs, or a combination of both. Alternatively, specify the xnames in
 an array of component structures within a payload file and pass it to -f. If
 - is passed to -f, the data is read from standard input.
 
+NIDs can be passed to --nids as a comma-separated list of NIDs and NID ranges
+(e.g. 1-64,100). They are resolved to xnames using SMD and deleted along with
+any xnames passed as arguments.
+
+Components can also be selected with --where, which takes a filter in query
+string form using SMD's component query parameters (e.g.
+'state=Empty&type=Node'). The components matching the filter are listed and,
+unless --force is passed, the user is asked to confirm before they are
+deleted.
+
+With --all, all components in SMD are deleted. They are counted first and the
+number that will be deleted is shown before, unless --force is passed, the
+user is asked to confirm. If the count changes while the user is confirming,
+nothing is deleted. With --expect-count, the command fails without deleting
+anything if the count does not match the number passed, e.g. to guard scripts
+against deleting more than expected.
+
 This command sends a DELETE to SMD. An access token is required.`,
 	Example: `  ochami smd component delete x3000c1s7b56n0
   ochami smd component delete x3000c1s7b56n0 x3000c1s7b56n1
+  ochami smd component delete --nids 1-64,100
   ochami smd component delete --all
+  ochami smd component delete --all --force --expect-count 4213
+  ochami smd component delete --where 'state=Empty&type=Node'
   ochami smd component delete -f payload.json
   ochami smd component delete -f payload.yaml --payload-format yaml
   echo '<json_data>' | ochami smd component delete -f -
@@ -33,10 +55,11 @@ This command sends a DELETE to SMD. An access token is required.`,
 		// With options, only one of:
 		// - A payload file with -f
 		// - --all
-		// - A set of one or more xnames
+		// - --where
+		// - A set of one or more xnames and/or --nids
 		// must be passed.
 		if len(args) == 0 {
-			if !cmd.Flag("all").Changed && !cmd.Flag("payload").Changed {
+			if !cmd.Flag("all").Changed && !cmd.Flag("payload").Changed && !cmd.Flag("where").Changed && !cmd.Flag("nids").Changed {
 				err := cmd.Usage()
 				if err != nil {
 					log.Logger.Error().Err(err).Msg("failed to print usage")
@@ -46,6 +69,11 @@ This command sends a DELETE to SMD. An access token is required.`,
 			}
 		}
 
+		if cmd.Flag("expect-count").Changed && !cmd.Flag("all").Changed {
+			log.Logger.Error().Msg("--expect-count can only be used with --all")
+			os.Exit(1)
+		}
+
 		// Without a base URI, we cannot do anything
 		smdBaseURI, err := getBaseURI(cmd)
 		if err != nil {
@@ -64,15 +92,51 @@ This command sends a DELETE to SMD. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
-		// Ask before attempting deletion unless --force was passed
-		if !cmd.Flag("force").Changed {
+		// Create list of xnames to delete
+		var xnameSlice []string
+		if cmd.Flag("payload").Changed {
+			// Use payload file if passed
+			var compSlice smd.ComponentSlice
+			handlePayload(cmd, &compSlice)
+			for _, comp := range compSlice.Components {
+				xnameSlice = append(xnameSlice, comp.ID)
+			}
+		} else if cmd.Flag("where").Changed {
+			// Look up the components matching the filter so the user
+			// can see what will be deleted
+			filter := cmd.Flag("where").Value.String()
+			xnameSlice, err = smdClient.GetComponentIDsMatching(filter, token)
+			if err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msg("SMD component request yielded unsuccessful HTTP response")
+				} else {
+					log.Logger.Error().Err(err).Msg("failed to get matching components from SMD")
+				}
+				os.Exit(1)
+			}
+			if len(xnameSlice) == 0 {
+				log.Logger.Info().Msgf("no components match filter %q, nothing to delete", filter)
+				os.Exit(0)
+			}
+			fmt.Fprintf(os.Stderr, "Components matching %q:\n  %s\n", filter, strings.Join(xnameSlice, "\n  "))
+		} else {
+			// ...otherwise, use passed CLI arguments and any
+			// xnames resolved from --nids
+			xnameSlice = append(args, xnamesFromNIDsFlag(cmd, smdClient)...)
+		}
+
+		// Ask before attempting deletion unless --force was passed,
+		// telling the user how many resources --all deletes
+		if cmd.Flag("all").Changed {
+			confirmDeleteAll(cmd, "components", func() (int, error) { return smdClient.CountComponents(token) })
+		} else if !cmd.Flag("force").Changed {
 			log.Logger.Debug().Msg("--force not passed, prompting user to confirm deletion")
 			var respDelete bool
-			if cmd.Flag("all").Changed {
-				respDelete = loopYesNo("Really delete ALL COMPONENTS?")
+			if cmd.Flag("where").Changed {
+				respDelete = loopYesNo(fmt.Sprintf("Really delete these %d components?", len(xnameSlice)))
 			} else {
 				respDelete = loopYesNo("Really delete?")
 			}
@@ -84,17 +148,6 @@ This command sends a DELETE to SMD. An access token is required.`,
 			}
 		}
 
-		// Create list of xnames to delete
-		var compSlice smd.ComponentSlice
-		var xnameSlice []string
-		if cmd.Flag("payload").Changed {
-			// Use payload file if passed
-			handlePayload(cmd, &compSlice)
-		} else {
-			// ...otherwise, use passed CLI arguments
-			xnameSlice = args
-		}
-
 		// Perform deletion
 		if cmd.Flag("all").Changed {
 			// If --all passed, we don't care about any passed arguments
@@ -114,33 +167,36 @@ This command sends a DELETE to SMD. An access token is required.`,
 				log.Logger.Error().Err(err).Msg("failed to delete components in SMD")
 				os.Exit(1)
 			}
+			exitIfInterrupted(errs)
+
 			// Since smdClient.DeleteComponents does the deletion iteratively, we need to deal with
 			// each error that might have occurred.
-			var errorsOccurred = false
 			for _, e := range errs {
-				if err != nil {
+				if e != nil {
 					if errors.Is(e, client.UnsuccessfulHTTPError) {
 						log.Logger.Error().Err(e).Msg("SMD component deletion yielded unsuccessful HTTP response")
 					} else {
 						log.Logger.Error().Err(e).Msg("failed to delete component")
 					}
-					errorsOccurred = true
 				}
 			}
 			// Warn the user if any errors occurred during dletion iterations
-			if errorsOccurred {
-				log.Logger.Warn().Msg("SMD component deletion completed with errors")
-				os.Exit(1)
-			}
+			exitIfBulkFailed(errs, "SMD component deletion")
 		}
 	},
 }
 
 func init() {
 	componentDeleteCmd.Flags().BoolP("all", "a", false, "delete all components in SMD")
-	componentDeleteCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	componentDeleteCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	componentDeleteCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	componentDeleteCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	componentDeleteCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
+	componentDeleteCmd.Flags().String("nids", "", "comma-separated list of node IDs and ranges whose components to delete (e.g. 1-64,100)")
+	componentDeleteCmd.Flags().String("where", "", "delete components matching filter in query string form (e.g. 'state=Empty&type=Node')")
 	componentDeleteCmd.Flags().Bool("force", false, "do not ask before attempting deletion")
+	componentDeleteCmd.Flags().Int("expect-count", 0, "with --all, only delete if this is the number of components that would be deleted")
+
+	componentDeleteCmd.MarkFlagsMutuallyExclusive("all", "payload", "where", "nids")
 
 	componentCmd.AddCommand(componentDeleteCmd)
 }
@@ -3,13 +3,20 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
-	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
 	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/OpenCHAMI/ochami/pkg/format"
+	"github.com/OpenCHAMI/ochami/pkg/nid"
+	"github.com/OpenCHAMI/ochami/pkg/redfish"
 	"github.com/spf13/cobra"
 )
 
@@ -18,6 +25,28 @@ var componentGetCmd = &cobra.Command{
 	Use:   "get",
 	Args:  cobra.NoArgs,
 	Short: "Get all components or component identified by an xname or node ID",
+	Long: `Get all components or the component identified by an xname (--xname) or
+node ID (--nid). Multiple components can be fetched by NID by passing a
+comma-separated list of NIDs and NID ranges to --nids (e.g. 1-64,100).
+
+Pass --with-groups to add the labels of the groups each component is a
+member of ("Groups") and its partition ("Partition"), if any, to the
+output. The memberships are fetched with a single request regardless of
+the number of components.
+
+Pass --live to also query the BMC of each component that has a
+ComponentEndpoint over Redfish and add its current power state and health
+to the output as "Live", along with when and where they were read. All
+other fields are still those cached in SMD. The BMC credentials are taken
+from --bmc-username and --bmc-password-file or, if not passed, from the
+discover section of the cluster's config. Components whose BMC cannot be
+queried get a "Live" object with an "Error" instead.`,
+	Example: `  ochami smd component get
+  ochami smd component get --xname x3000c1s7b56n0
+  ochami smd component get --nid 1
+  ochami smd component get --nids 1-64,100,200-203
+  ochami smd component get --with-groups
+  ochami smd component get --xname x3000c1s7b56n0 --live --bmc-insecure`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Without a base URI, we cannot do anything
 		smdBaseURI, err := getBaseURI(cmd)
@@ -33,8 +62,8 @@ var componentGetCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
 		var httpEnv client.HTTPEnvelope
 		if cmd.Flag("xname").Changed {
@@ -55,39 +84,222 @@ var componentGetCmd = &cobra.Command{
 				os.Exit(1)
 			}
 			httpEnv, err = smdClient.GetComponentsNid(nid, token)
+		} else if cmd.Flag("nids").Changed {
+			// This endpoint requires authentication, so a token is needed
+			setTokenFromEnvVar(cmd)
+			checkToken(cmd)
+
+			var nids []int64
+			nids, err = nid.Parse(cmd.Flag("nids").Value.String())
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("invalid value for --nids")
+				os.Exit(1)
+			}
+			var compSlice smd.ComponentSlice
+			compSlice.Components, err = smdClient.GetComponentsByNIDs(nids, token)
+			if err == nil {
+				httpEnv.Body, err = json.Marshal(compSlice)
+			}
 		} else {
 			httpEnv, err = smdClient.GetComponentsAll()
 		}
 		if err != nil {
 			if errors.Is(err, client.UnsuccessfulHTTPError) {
-				log.Logger.Error().Err(err).Msg("SMD component request yielded unsuccessful HTTP response")
+				logHTTPError(err, httpEnv, "SMD component request yielded unsuccessful HTTP response")
 			} else {
 				log.Logger.Error().Err(err).Msg("failed to request components from SMD")
 			}
 			os.Exit(1)
 		}
 
-		// Print output
-		outFmt, err := cmd.Flags().GetString("output-format")
-		if err != nil {
-			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
-			os.Exit(1)
+		// Annotate components with their groups, fetching the memberships
+		// of all components at once instead of per component
+		if cmd.Flag("with-groups").Changed {
+			if token == "" {
+				// This endpoint requires authentication, so a token is needed
+				setTokenFromEnvVar(cmd)
+				checkToken(cmd)
+			}
+			values := url.Values{}
+			var single struct{ ID string }
+			if err := json.Unmarshal(httpEnv.Body, &single); err == nil && single.ID != "" {
+				values.Set("id", single.ID)
+			}
+			memberships, err := smdClient.GetMembershipsValues(values, token)
+			if err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msg("SMD memberships request yielded unsuccessful HTTP response")
+				} else {
+					log.Logger.Error().Err(err).Msg("failed to request memberships from SMD")
+				}
+				os.Exit(1)
+			}
+			httpEnv.Body, err = smd.AddMemberships(httpEnv.Body, memberships)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to add group memberships to components")
+				os.Exit(1)
+			}
 		}
-		if outBytes, err := client.FormatBody(httpEnv.Body, outFmt); err != nil {
-			log.Logger.Error().Err(err).Msg("failed to format output")
-			os.Exit(1)
-		} else {
-			fmt.Printf(string(outBytes))
+
+		// Annotate components with the live state read from their BMCs
+		if cmd.Flag("live").Changed {
+			if token == "" {
+				// This endpoint requires authentication, so a token is needed
+				setTokenFromEnvVar(cmd)
+				checkToken(cmd)
+			}
+			live := liveComponentStatus(cmd, smdClient, httpEnv.Body)
+			httpEnv.Body, err = smd.AddLiveStatus(httpEnv.Body, live)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to add live status to components")
+				os.Exit(1)
+			}
 		}
+
+		// Print output
+		printEnvelope(cmd, httpEnv)
 	},
 }
 
 func init() {
 	componentGetCmd.Flags().StringP("xname", "x", "", "xname whose Component to fetch")
 	componentGetCmd.Flags().Int32P("nid", "n", 0, "node ID whose Component to fetch")
+	componentGetCmd.Flags().String("nids", "", "comma-separated list of node IDs and ranges whose Components to fetch (e.g. 1-64,100)")
+	componentGetCmd.Flags().Bool("with-groups", false, "include the groups and partition of each component")
+	componentGetCmd.Flags().Bool("live", false, "include the live power state and health of each component read from its BMC")
+	componentGetCmd.Flags().String("bmc-username", "", "username for BMCs queried with --live")
+	componentGetCmd.Flags().String("bmc-password-file", "", "file containing the password for BMCs queried with --live")
+	componentGetCmd.Flags().Bool("bmc-insecure", false, "do not verify TLS certificates of BMCs queried with --live")
 	componentGetCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	addTableFormat(componentGetCmd, format.KindComponents)
+	addListFlags(componentGetCmd)
 
-	componentGetCmd.MarkFlagsMutuallyExclusive("xname", "nid")
+	componentGetCmd.MarkFlagsMutuallyExclusive("xname", "nid", "nids")
+	componentGetCmd.MarkFlagsRequiredTogether("bmc-username", "bmc-password-file")
 
 	componentCmd.AddCommand(componentGetCmd)
 }
+
+// liveComponentStatus queries the BMCs of the components in body, which holds
+// either a single component or a list of components, over Redfish and returns
+// the live status of each component that has a ComponentEndpoint for a
+// ComputerSystem, keyed by component ID. If the ComponentEndpoints cannot be
+// fetched from SMD, a log is printed and the program exits. BMCs that cannot be
+// queried are logged as warnings and their components get a LiveStatus with
+// only Error set.
+func liveComponentStatus(cmd *cobra.Command, smdClient *smd.SMDClient, body client.HTTPBody) map[string]smd.LiveStatus {
+	var comps struct {
+		ID         string
+		Components []struct{ ID string }
+	}
+	if err := json.Unmarshal(body, &comps); err != nil {
+		log.Logger.Error().Err(err).Msg("failed to unmarshal components")
+		os.Exit(1)
+	}
+	ids := make(map[string]bool)
+	if comps.ID != "" {
+		ids[strings.ToLower(comps.ID)] = true
+	}
+	for _, c := range comps.Components {
+		ids[strings.ToLower(c.ID)] = true
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// Fetch the ComponentEndpoint of a single component directly and all of
+	// them otherwise, since that takes one request regardless of the number
+	// of components
+	var ceps smd.ComponentEndpointSlice
+	if comps.ID != "" {
+		henvs, errs, err := smdClient.GetComponentEndpoints(token, comps.ID)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to request component endpoint from SMD")
+			os.Exit(1)
+		}
+		if errs[0] != nil {
+			if henvs[0].StatusCode == http.StatusNotFound {
+				log.Logger.Warn().Msgf("component %s has no ComponentEndpoint, not querying its BMC", comps.ID)
+				return nil
+			}
+			if errors.Is(errs[0], client.UnsuccessfulHTTPError) {
+				logHTTPError(errs[0], henvs[0], "SMD component endpoint request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(errs[0]).Msg("failed to request component endpoint from SMD")
+			}
+			os.Exit(1)
+		}
+		var cep smd.ComponentEndpoint
+		if err := json.Unmarshal(henvs[0].Body, &cep); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to unmarshal component endpoint")
+			os.Exit(1)
+		}
+		ceps.ComponentEndpoints = append(ceps.ComponentEndpoints, cep)
+	} else {
+		henv, err := smdClient.GetComponentEndpointsAll(token)
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				logHTTPError(err, henv, "SMD component endpoint request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to request component endpoints from SMD")
+			}
+			os.Exit(1)
+		}
+		if err := json.Unmarshal(henv.Body, &ceps); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to unmarshal component endpoints")
+			os.Exit(1)
+		}
+	}
+
+	var (
+		compIDs []string
+		uris    []string
+	)
+	for _, cep := range ceps.ComponentEndpoints {
+		if !ids[strings.ToLower(cep.ID)] || cep.RedfishType != "ComputerSystem" || cep.RedfishURL == "" {
+			continue
+		}
+		compIDs = append(compIDs, cep.ID)
+		uris = append(uris, cep.RedfishURL)
+	}
+	if len(uris) == 0 {
+		log.Logger.Warn().Msg("none of the components have a ComponentEndpoint for a ComputerSystem, not querying any BMCs")
+		return nil
+	}
+
+	bmc := bmcSettings(cmd)
+	bmcInsecure, err := cmd.Flags().GetBool("bmc-insecure")
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to get value for --bmc-insecure")
+		os.Exit(1)
+	}
+	rf := redfish.NewClient(bmc.Username, bmc.Password, bmcInsecure, 0)
+	rf.Scheme = bmc.Scheme
+	rf.Port = bmc.Port
+
+	log.Logger.Info().Msgf("querying %d BMC(s) for live component status", len(uris))
+	systems, errs := rf.Systems(client.BulkContext, uris, smdClient.BulkConcurrency(http.MethodGet))
+	fetched := time.Now().UTC()
+
+	live := make(map[string]smd.LiveStatus, len(compIDs))
+	failed := 0
+	for i, id := range compIDs {
+		source, _ := rf.URL(uris[i])
+		ls := smd.LiveStatus{Source: source, FetchedAt: fetched}
+		if errs[i] != nil {
+			log.Logger.Warn().Err(errs[i]).Msgf("failed to query BMC of %s", id)
+			ls.Error = errs[i].Error()
+			failed++
+		} else {
+			ls.PowerState = systems[i].PowerState
+			ls.Health = systems[i].Status.Health
+			ls.State = systems[i].Status.State
+		}
+		live[id] = ls
+	}
+	if failed > 0 {
+		log.Logger.Warn().Msgf("%d of %d BMC(s) could not be queried", failed, len(compIDs))
+	}
+
+	return live
+}
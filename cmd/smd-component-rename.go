@@ -0,0 +1,436 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/OpenCHAMI/bss/pkg/bssTypes"
+	"github.com/OpenCHAMI/ochami/internal/cli"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/bss"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// componentRenameCmd represents the smd-component-rename command
+var componentRenameCmd = &cobra.Command{
+	Use:   "rename [--dry-run] (--map <file> | <old_xname> <new_xname>)",
+	Short: "Change the xname of one or more components",
+	Long: `Change the xname of one or more components. SMD does not allow changing the
+ID of a component, so for each rename the following steps are performed:
+
+  1. create the new component as a copy of the old one (without its NID)
+  2. add the new component to each group the old one is a member of
+  3. point the old component's ethernet interfaces at the new component
+  4. copy the old component's BSS boot parameters to the new xname
+  5. delete the old component's BSS boot parameters
+  6. remove the old component from its groups
+  7. delete the old component
+  8. set the old component's NID on the new component
+
+Either pass a single rename as arguments or pass --map with a CSV file (or
+- for standard input) containing one old_xname,new_xname pair per line. Lines
+starting with # and a leading old,new header line are ignored. The new
+xnames must not exist yet and a component cannot be both renamed and the
+target of a rename.
+
+Each rename is done on its own. If a step fails, the steps of that rename
+that were completed are undone in reverse order so that the old component
+is left as it was, and the remaining renames are still attempted. Pass
+--dry-run to print the steps of each rename without performing them.
+
+Partition memberships are not migrated; a warning is printed for
+components that are in a partition.
+
+This command sends requests to SMD's /Components, /groups, and
+/Inventory/EthernetInterfaces endpoints and to BSS's /bootparameters
+endpoint. An access token is required.`,
+	Example: `  ochami smd component rename x3000c1s7b56n0 x3000c1s7b57n0
+  ochami smd component rename --map old_to_new.csv --dry-run
+  ochami smd component rename --map old_to_new.csv
+  printf 'x3000c1s7b56n0,x3000c1s7b57n0\n' | ochami smd component rename --map -`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Either a map file or exactly one old/new pair must be passed
+		var renames [][2]string
+		if cmd.Flag("map").Changed {
+			if len(args) > 0 {
+				log.Logger.Error().Msg("xnames cannot be passed as arguments with --map")
+				os.Exit(1)
+			}
+			mapFile, err := cmd.Flags().GetString("map")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get value for --map")
+				os.Exit(1)
+			}
+			if renames, err = readRenameMap(strings.TrimPrefix(mapFile, "@")); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to read rename map")
+				os.Exit(1)
+			}
+		} else if len(args) == 2 {
+			renames = [][2]string{{args[0], args[1]}}
+		} else {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+		if err := checkRenames(renames); err != nil {
+			log.Logger.Error().Err(err).Msg("invalid renames")
+			os.Exit(1)
+		}
+
+		// Without a base URI, we cannot do anything
+		baseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI")
+			os.Exit(1)
+		}
+
+		// These endpoints require authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create clients to make requests to SMD and BSS
+		smdClient, err := smd.NewClient(baseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+		configureClient(smdClient.OchamiClient)
+		bssClient, err := bss.NewClient(baseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new BSS client")
+			os.Exit(1)
+		}
+		configureClient(bssClient.OchamiClient)
+
+		ctx := context.Background()
+		if runner != nil {
+			ctx = runner.Context()
+		}
+
+		// Rename each component, continuing on failure so that one bad
+		// rename does not prevent the others
+		dryRun := cmd.Flag("dry-run").Changed
+		failed := 0
+		for _, r := range renames {
+			oldID, newID := r[0], r[1]
+			steps, err := planRename(ctx, smdClient, bssClient, oldID, newID)
+			if err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msgf("request yielded unsuccessful HTTP response while looking up %s", oldID)
+				} else {
+					log.Logger.Error().Err(err).Msgf("failed to look up what to rename for %s", oldID)
+				}
+				failed++
+				continue
+			}
+			if dryRun {
+				fmt.Printf("%s -> %s:\n", oldID, newID)
+				for i, s := range steps {
+					fmt.Printf("  %d. %s\n", i+1, s.desc)
+				}
+				continue
+			}
+			if err := runRename(steps); err != nil {
+				log.Logger.Error().Err(err).Msgf("failed to rename %s to %s", oldID, newID)
+				failed++
+				continue
+			}
+			log.Logger.Info().Msgf("renamed %s to %s", oldID, newID)
+		}
+		exitIfItemsFailed("SMD component rename", cli.BulkSummary{Total: len(renames), Succeeded: len(renames) - failed, Failed: failed})
+	},
+}
+
+// renameStep is a single change made while renaming a component. undo, if not
+// nil, reverts it.
+type renameStep struct {
+	desc string
+	do   func() error
+	undo func() error
+}
+
+// readRenameMap reads old_xname,new_xname pairs from the CSV file at path, or
+// from standard input if path is "-".
+func readRenameMap(path string) ([][2]string, error) {
+	var r io.Reader
+	if path == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = 2
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(records) > 0 && strings.EqualFold(records[0][0], "old") && strings.EqualFold(records[0][1], "new") {
+		records = records[1:]
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s contains no renames", path)
+	}
+	renames := make([][2]string, 0, len(records))
+	for _, rec := range records {
+		renames = append(renames, [2]string{strings.TrimSpace(rec[0]), strings.TrimSpace(rec[1])})
+	}
+
+	return renames, nil
+}
+
+// checkRenames makes sure that renames are unambiguous: every xname is
+// non-empty, renamed at most once, and not both renamed and renamed to. Xnames
+// are compared case-insensitively, like SMD does.
+func checkRenames(renames [][2]string) error {
+	olds := make(map[string]bool)
+	news := make(map[string]bool)
+	for _, r := range renames {
+		oldID, newID := strings.ToLower(r[0]), strings.ToLower(r[1])
+		switch {
+		case oldID == "" || newID == "":
+			return fmt.Errorf("empty xname in rename %q -> %q", r[0], r[1])
+		case oldID == newID:
+			return fmt.Errorf("%s is renamed to itself", r[0])
+		case olds[oldID]:
+			return fmt.Errorf("%s is renamed more than once", r[0])
+		case news[newID]:
+			return fmt.Errorf("more than one component is renamed to %s", r[1])
+		}
+		olds[oldID] = true
+		news[newID] = true
+	}
+	for _, r := range renames {
+		if olds[strings.ToLower(r[1])] {
+			return fmt.Errorf("%s is both renamed and the target of a rename", r[1])
+		}
+	}
+
+	return nil
+}
+
+// planRename looks up the component oldID, its group memberships, ethernet
+// interfaces, and BSS boot parameters and returns the steps that rename it to
+// newID. Nothing is modified.
+func planRename(ctx context.Context, smdClient *smd.SMDClient, bssClient *bss.BSSClient, oldID, newID string) ([]renameStep, error) {
+	henv, err := smdClient.GetComponentsXname(oldID, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get component %s: %w", oldID, err)
+	}
+	var oldComp smd.Component
+	if err := json.Unmarshal(henv.Body, &oldComp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal component %s: %w", oldID, err)
+	}
+	if henv, err = smdClient.GetComponentsXname(newID, token); err == nil {
+		return nil, fmt.Errorf("component %s already exists", newID)
+	} else if henv.StatusCode != http.StatusNotFound {
+		return nil, fmt.Errorf("failed to check whether component %s exists: %w", newID, err)
+	}
+
+	memberships, err := smdClient.GetMembershipsValues(url.Values{"id": {oldID}}, token)
+	if err != nil {
+		return nil, err
+	}
+	var groups []string
+	for _, m := range memberships {
+		groups = append(groups, m.GroupLabels...)
+		if m.PartitionName != "" {
+			log.Logger.Warn().Msgf("%s is in partition %s, which is not migrated to %s", oldID, m.PartitionName, newID)
+		}
+	}
+	eis, err := smdClient.EthernetInterfacePager("ComponentID="+url.QueryEscape(oldID), token).All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ethernet interfaces of %s: %w", oldID, err)
+	}
+
+	// BSS responds with 404 if there are no boot parameters for the host
+	var bps []bssTypes.BootParams
+	if henv, err = bssClient.GetBootParamsValues(url.Values{"name": {oldID}}, token); err == nil {
+		if err := json.Unmarshal(henv.Body, &bps); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal boot parameters of %s: %w", oldID, err)
+		}
+	} else if henv.StatusCode != http.StatusNotFound {
+		return nil, fmt.Errorf("failed to get boot parameters of %s: %w", oldID, err)
+	}
+	var oldBP *bssTypes.BootParams
+	for i := range bps {
+		if slices.ContainsFunc(bps[i].Hosts, func(h string) bool { return strings.EqualFold(h, oldID) }) {
+			oldBP = &bps[i]
+			break
+		}
+	}
+
+	// NIDs are expected to be unique, so the NID is only set on the new
+	// component once the old one is gone.
+	newComp := oldComp
+	newComp.ID = newID
+	newComp.NID = 0
+
+	postComp := func(c smd.Component) func() error {
+		return func() error {
+			_, err := smdClient.PostComponents(smd.ComponentSlice{Components: []smd.Component{c}}, token)
+			return err
+		}
+	}
+	deleteComp := func(xname string) func() error {
+		return func() error {
+			return firstErr(smdClient.DeleteComponents(token, xname))
+		}
+	}
+	addMember := func(group, xname string) func() error {
+		return func() error {
+			return firstErr(smdClient.PostGroupMembers(token, group, xname))
+		}
+	}
+	delMember := func(group, xname string) func() error {
+		return func() error {
+			return firstErr(smdClient.DeleteGroupMembers(token, group, xname))
+		}
+	}
+	setOwner := func(ei smd.EthernetInterface, xname string) func() error {
+		ei.ComponentID = xname
+		return func() error {
+			return firstErr(smdClient.PatchEthernetInterfaces([]smd.EthernetInterface{ei}, token))
+		}
+	}
+	postBP := func(xname string) func() error {
+		bp := bssTypes.BootParams{Hosts: []string{xname}, Params: oldBP.Params, Kernel: oldBP.Kernel, Initrd: oldBP.Initrd}
+		return func() error {
+			_, err := bssClient.PostBootParams(bp, token)
+			return err
+		}
+	}
+	deleteBP := func(xname string) func() error {
+		return func() error {
+			_, err := bssClient.DeleteBootParams(bssTypes.BootParams{Hosts: []string{xname}}, token)
+			return err
+		}
+	}
+
+	steps := []renameStep{{
+		desc: fmt.Sprintf("create component %s as a copy of %s", newID, oldID),
+		do:   postComp(newComp),
+		undo: deleteComp(newID),
+	}}
+	for _, g := range groups {
+		steps = append(steps, renameStep{
+			desc: fmt.Sprintf("add %s to group %s", newID, g),
+			do:   addMember(g, newID),
+			undo: delMember(g, newID),
+		})
+	}
+	for _, ei := range eis {
+		steps = append(steps, renameStep{
+			desc: fmt.Sprintf("move ethernet interface %s (%s) to %s", ei.ID, ei.MACAddress, newID),
+			do:   setOwner(ei, newID),
+			undo: setOwner(ei, oldID),
+		})
+	}
+	if oldBP != nil {
+		steps = append(steps, renameStep{
+			desc: fmt.Sprintf("copy BSS boot parameters of %s to %s", oldID, newID),
+			do:   postBP(newID),
+			undo: deleteBP(newID),
+		}, renameStep{
+			desc: fmt.Sprintf("delete BSS boot parameters of %s", oldID),
+			do:   deleteBP(oldID),
+			undo: postBP(oldID),
+		})
+	}
+	for _, g := range groups {
+		steps = append(steps, renameStep{
+			desc: fmt.Sprintf("remove %s from group %s", oldID, g),
+			do:   delMember(g, oldID),
+			undo: addMember(g, oldID),
+		})
+	}
+	steps = append(steps, renameStep{
+		desc: fmt.Sprintf("delete component %s", oldID),
+		do:   deleteComp(oldID),
+		undo: postComp(oldComp),
+	})
+	if oldComp.NID != 0 {
+		steps = append(steps, renameStep{
+			desc: fmt.Sprintf("set NID %d on %s", oldComp.NID, newID),
+			do: func() error {
+				_, err := smdClient.PatchComponentsNID(smd.ComponentSlice{Components: []smd.Component{{ID: newID, NID: oldComp.NID}}}, token)
+				return err
+			},
+		})
+	}
+
+	return steps, nil
+}
+
+// runRename performs steps in order. If one fails, the completed steps are
+// undone in reverse order and the returned error says which step failed and
+// whether undoing the others failed too.
+func runRename(steps []renameStep) error {
+	for i, s := range steps {
+		log.Logger.Debug().Msg(s.desc)
+		err := s.do()
+		if err == nil {
+			continue
+		}
+		var rbErrs []error
+		for j := i - 1; j >= 0; j-- {
+			if steps[j].undo == nil {
+				continue
+			}
+			log.Logger.Debug().Msgf("undoing: %s", steps[j].desc)
+			if rbErr := steps[j].undo(); rbErr != nil {
+				rbErrs = append(rbErrs, fmt.Errorf("failed to undo %q: %w", steps[j].desc, rbErr))
+			}
+		}
+		if len(rbErrs) > 0 {
+			return fmt.Errorf("failed to %s (%w) and rollback was incomplete: %w", s.desc, err, errors.Join(rbErrs...))
+		}
+		return fmt.Errorf("failed to %s, rolled back: %w", s.desc, err)
+	}
+
+	return nil
+}
+
+// firstErr returns the error of a bulk request of a single item, i.e. err if
+// the request could not be made or the item's error otherwise.
+func firstErr(_ []client.HTTPEnvelope, errs []error, err error) error {
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+func init() {
+	componentRenameCmd.Flags().String("map", "", "CSV file of old_xname,new_xname pairs (- for standard input)")
+	componentRenameCmd.Flags().Bool("dry-run", false, "print the steps of each rename without performing them")
+
+	setCommandServices(componentRenameCmd, "bss", "smd")
+
+	componentCmd.AddCommand(componentRenameCmd)
+}
@@ -0,0 +1,131 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/cli"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// componentSetStateCmd represents the smd-component-set-state command
+var componentSetStateCmd = &cobra.Command{
+	Use:   "set-state [--flag <flag>] [--force] <state> (<xname>... | --nids <nid_list>)",
+	Short: "Set the state of one or more components",
+	Long: `Set the state of one or more components, and optionally their state flag,
+with a single request.
+
+SMD only allows some state changes (e.g. a Ready node cannot become Empty
+without first being powered off), so the current state of each component
+is fetched first and components that cannot be moved to <state> are
+reported along with the states they can be moved to, and are not changed.
+Pass --force to skip this check and have SMD change the state regardless.
+
+This command sends a PATCH to SMD's BulkStateData endpoint. An access token
+is required.`,
+	Example: `  ochami smd component set-state Off x3000c1s7b56n0
+  ochami smd component set-state Ready --flag OK x3000c1s7b56n0 x3000c1s7b57n0
+  ochami smd component set-state Off --nids 1-64,100
+  ochami smd component set-state Empty --force x3000c1s7b56n0`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 || (len(args) == 1 && !cmd.Flag("nids").Changed) {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		} else if cmd.Flag("nids").Changed && len(args) != 1 {
+			log.Logger.Error().Msgf("expected only a state with --nids but got %d arguments: %v", len(args), args)
+			os.Exit(1)
+		}
+		state := args[0]
+
+		// Without a base URI, we cannot do anything
+		smdBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to SMD
+		smdClient, err := smd.NewClient(smdBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		xnames := args[1:]
+		if cmd.Flag("nids").Changed {
+			xnames = xnamesFromNIDsFlag(cmd, smdClient)
+		}
+
+		// Only change components that can be moved to the new state,
+		// unless forced
+		force := cmd.Flag("force").Changed
+		failed := 0
+		if !force {
+			var valid []string
+			for _, xname := range xnames {
+				henv, err := smdClient.GetComponentsXname(xname, token)
+				if err != nil {
+					if errors.Is(err, client.UnsuccessfulHTTPError) {
+						log.Logger.Error().Err(err).Msg("SMD component request yielded unsuccessful HTTP response")
+					} else {
+						log.Logger.Error().Err(err).Msgf("failed to get component %s from SMD", xname)
+					}
+					failed++
+					continue
+				}
+				var comp smd.Component
+				if err := json.Unmarshal(henv.Body, &comp); err != nil {
+					log.Logger.Error().Err(err).Msgf("failed to unmarshal component %s", xname)
+					failed++
+					continue
+				}
+				if err := smd.CheckStateTransition(comp.State, state); err != nil {
+					log.Logger.Error().Err(err).Msgf("not changing state of %s (pass --force to change it anyway)", xname)
+					failed++
+					continue
+				}
+				valid = append(valid, xname)
+			}
+			xnames = valid
+		}
+
+		if len(xnames) > 0 {
+			flag := cmd.Flag("flag").Value.String()
+			if _, err := smdClient.PatchComponentsState(xnames, state, flag, force, token); err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msg("SMD component state request yielded unsuccessful HTTP response")
+				} else {
+					log.Logger.Error().Err(err).Msg("failed to set state of component(s) in SMD")
+				}
+				os.Exit(1)
+			}
+			log.Logger.Info().Msgf("set state of %d component(s) to %s", len(xnames), state)
+		}
+		exitIfItemsFailed("SMD component set-state", cli.BulkSummary{Total: len(xnames) + failed, Succeeded: len(xnames), Failed: failed})
+	},
+}
+
+func init() {
+	componentSetStateCmd.Flags().String("flag", "", "state flag to set along with the state (e.g. OK, Warning, Alert)")
+	componentSetStateCmd.Flags().Bool("force", false, "skip state transition checks and have SMD force the change")
+	componentSetStateCmd.Flags().String("nids", "", "comma-separated list of node IDs and ranges whose components to change (e.g. 1-64,100)")
+
+	componentCmd.AddCommand(componentSetStateCmd)
+}
@@ -0,0 +1,261 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// componentUpdateCmd represents the smd-component-update command
+var componentUpdateCmd = &cobra.Command{
+	Use:   "update -f <payload_file> | (([--state <state>] [--flag <flag>] [--role <role>] ... [--set <field>=<value>]... | --patch-file <patch_file> [--patch-type <type>]) (<xname> | --nids <nid_list>))",
+	Short: "Update existing component(s)",
+	Long: `Update existing component(s). If an xname is passed, the current component is
+fetched from SMD and only the fields whose flags are passed are
+changed before it is sent back, so that fields not specified are
+preserved. Alternatively, pass -f to pass a file (optionally
+specifying --payload-format, JSON by default) containing the full
+component(s) to replace. If - is used as the argument to -f, the data
+is read from standard input.
+
+Fields without a flag of their own can be changed with --set
+<field>=<value>, using the field names of the payload (e.g.
+ReservationDisabled). Values are parsed as YAML.
+
+Instead of an xname, a comma-separated list of NIDs and NID ranges (e.g.
+1-64,100) can be passed to --nids to apply the same changes to each of
+the components with those NIDs.
+
+Updates are sent with SMD's Force option, so SMD does not check state
+changes itself. Instead, when --state is passed, components that SMD would
+not normally allow to move from their current state to the new one (e.g.
+Ready to Empty) are reported along with the states they can be moved to,
+and nothing is updated. Pass --force to skip this check. See also 'ochami
+smd component set-state'.
+
+Alternatively, pass --patch-file to send a patch document to SMD as is
+instead of a whole component, without fetching the component first. The
+document is a JSON Merge Patch (RFC 7386) by default, or a JSON Patch (RFC
+6902) with --patch-type json-patch, and is sent with the corresponding
+Content-Type. In this case, a PATCH is sent for each component instead of
+a PUT, and states are not checked.
+
+This command sends one or more PUTs to SMD. An access token is required.`,
+	Example: `  ochami smd component update --state Off x3000c1s7b56n0
+  ochami smd component update --role Management --subrole Worker x3000c1s7b56n0
+  ochami smd component update --enabled=false x3000c1s7b56n0
+  ochami smd component update --set SoftwareStatus=AdminDown --set Locked=true x3000c1s7b56n0
+  ochami smd component update --state Off --nids 1-64,100
+  ochami smd component update -f payload.json
+  ochami smd component update -f payload.yaml --payload-format yaml
+  echo '<json_data>' | ochami smd component update -f -
+  echo '<yaml_data>' | ochami smd component update -f - --payload-format yaml
+  ochami smd component update --patch-file patch.json x3000c1s7b56n0
+  ochami smd component update --patch-file ops.json --patch-type json-patch --nids 1-64`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Check that all required args are passed
+		if len(args) == 0 && !cmd.Flag("payload").Changed && !cmd.Flag("nids").Changed {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		} else if cmd.Flag("nids").Changed && len(args) != 0 {
+			log.Logger.Error().Msgf("expected no arguments with --nids but got %d: %v", len(args), args)
+			os.Exit(1)
+		} else if !cmd.Flag("payload").Changed && !cmd.Flag("nids").Changed && len(args) != 1 {
+			log.Logger.Error().Msgf("expected 1 argument (xname) but got %d: %v", len(args), args)
+			os.Exit(1)
+		}
+
+		// Without a base URI, we cannot do anything
+		smdBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to SMD
+		smdClient, err := smd.NewClient(smdBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+		useIfMatch(cmd, smdClient.OchamiClient)
+
+		var (
+			compSlice        smd.ComponentSlice
+			xnames           []string
+			patchType        client.PatchType
+			patch            client.HTTPBody
+			invalidStateSeen = false
+		)
+		if cmd.Flag("payload").Changed {
+			handlePayload(cmd, &compSlice)
+		} else if cmd.Flag("patch-file").Changed {
+			// Send the patch as is to each component
+			xnames = args
+			if cmd.Flag("nids").Changed {
+				xnames = xnamesFromNIDsFlag(cmd, smdClient)
+			}
+			patchType, patch = handlePatch(cmd)
+		} else {
+			// ...otherwise fetch the current component(s) and apply
+			// the CLI options on top of them
+			xnames = args
+			if cmd.Flag("nids").Changed {
+				xnames = xnamesFromNIDsFlag(cmd, smdClient)
+			}
+			for _, xname := range xnames {
+				henv, err := smdClient.GetComponentsXname(xname, token)
+				if err != nil {
+					if errors.Is(err, client.UnsuccessfulHTTPError) {
+						log.Logger.Error().Err(err).Msg("SMD component request yielded unsuccessful HTTP response")
+					} else {
+						log.Logger.Error().Err(err).Msgf("failed to get component %s from SMD", xname)
+					}
+					os.Exit(1)
+				}
+				// Use the ETag of the component we just read so that
+				// changes made after it was read are not overwritten.
+				// With several components, the client fetches each
+				// ETag itself before writing.
+				if len(xnames) == 1 && smdClient.IfMatch == client.IfMatchAuto && henv.ETag != "" {
+					smdClient.IfMatch = henv.ETag
+				}
+				var comp smd.Component
+				if err := json.Unmarshal(henv.Body, &comp); err != nil {
+					log.Logger.Error().Err(err).Msgf("failed to unmarshal component %s", xname)
+					os.Exit(1)
+				}
+
+				if cmd.Flag("state").Changed && !cmd.Flag("force").Changed {
+					if err := smd.CheckStateTransition(comp.State, cmd.Flag("state").Value.String()); err != nil {
+						log.Logger.Error().Err(err).Msgf("cannot update state of %s (pass --force to update it anyway)", xname)
+						invalidStateSeen = true
+					}
+				}
+
+				strFields := map[string]*string{
+					"type":            &comp.Type,
+					"subtype":         &comp.Subtype,
+					"state":           &comp.State,
+					"flag":            &comp.Flag,
+					"role":            &comp.Role,
+					"subrole":         &comp.SubRole,
+					"net-type":        &comp.NetType,
+					"arch":            &comp.Arch,
+					"class":           &comp.Class,
+					"software-status": &comp.SoftwareStatus,
+				}
+				for flag, field := range strFields {
+					if cmd.Flag(flag).Changed {
+						*field = cmd.Flag(flag).Value.String()
+					}
+				}
+				if cmd.Flag("enabled").Changed {
+					enabled, err := cmd.Flags().GetBool("enabled")
+					if err != nil {
+						log.Logger.Error().Err(err).Msg("failed to retrieve flag 'enabled'")
+						os.Exit(1)
+					}
+					comp.Enabled = &enabled
+				}
+
+				// Fields passed with --set override the above
+				prevState := comp.State
+				handleSet(cmd, &comp)
+				if comp.State != prevState && !cmd.Flag("force").Changed {
+					if err := smd.CheckStateTransition(prevState, comp.State); err != nil {
+						log.Logger.Error().Err(err).Msgf("cannot update state of %s (pass --force to update it anyway)", xname)
+						invalidStateSeen = true
+					}
+				}
+
+				compSlice.Components = append(compSlice.Components, comp)
+			}
+		}
+
+		if invalidStateSeen {
+			os.Exit(1)
+		}
+
+		// Send off request
+		var errs []error
+		if patchType != "" {
+			_, errs, err = smdClient.PatchComponentsDocument(xnames, patchType, patch, token)
+		} else {
+			_, errs, err = smdClient.PutComponents(compSlice, token)
+		}
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to update component(s) in SMD")
+			os.Exit(1)
+		}
+		exitIfInterrupted(errs)
+
+		// Since the update is done iteratively, we
+		// need to deal with each error that might have occurred.
+		for _, err := range errs {
+			if err != nil {
+				if errors.Is(err, client.PreconditionFailedError) {
+					log.Logger.Error().Err(err).Msg("component was modified by someone else since it was read; rerun to update the current version")
+				} else if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msg("SMD component request yielded unsuccessful HTTP response")
+				} else {
+					log.Logger.Error().Err(err).Msg("failed to update component in SMD")
+				}
+			}
+		}
+		exitIfBulkFailed(errs, "SMD component update")
+	},
+}
+
+func init() {
+	componentUpdateCmd.Flags().String("type", "", "HMS type of component")
+	componentUpdateCmd.Flags().String("subtype", "", "subtype of component")
+	componentUpdateCmd.Flags().String("state", "", "readiness state of component")
+	componentUpdateCmd.Flags().String("flag", "", "state flag of component (e.g. OK, Warning, Alert)")
+	componentUpdateCmd.Flags().Bool("force", false, "do not check whether the component can be moved to the state passed with --state")
+	componentUpdateCmd.Flags().Bool("enabled", true, "set if component is enabled")
+	componentUpdateCmd.Flags().String("role", "", "role of component")
+	componentUpdateCmd.Flags().String("subrole", "", "subrole of component")
+	componentUpdateCmd.Flags().String("net-type", "", "network type of component (e.g. Sling, Infiniband, Ethernet)")
+	componentUpdateCmd.Flags().String("arch", "", "CPU architecture of component")
+	componentUpdateCmd.Flags().String("class", "", "hardware class of component (e.g. River, Mountain, Hill)")
+	componentUpdateCmd.Flags().String("software-status", "", "software status of component")
+	componentUpdateCmd.Flags().String("nids", "", "comma-separated list of node IDs and ranges whose components to update (e.g. 1-64,100)")
+	componentUpdateCmd.Flags().String("if-match", "", "only write if the resource is unmodified; pass 'auto' to use the ETag from a preceding GET, or an ETag")
+	componentUpdateCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	componentUpdateCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	componentUpdateCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload or --patch-file is an https:// URL")
+	addSetFlag(componentUpdateCmd, "the component")
+	addPatchFlags(componentUpdateCmd)
+
+	componentUpdateCmd.MarkFlagsOneRequired("type", "subtype", "state", "flag", "enabled", "role", "subrole", "net-type", "arch", "class", "software-status", "set", "payload", "patch-file")
+	for _, f := range []string{"type", "subtype", "state", "flag", "enabled", "role", "subrole", "net-type", "arch", "class", "software-status", "set"} {
+		componentUpdateCmd.MarkFlagsMutuallyExclusive(f, "payload")
+		componentUpdateCmd.MarkFlagsMutuallyExclusive(f, "patch-file")
+	}
+
+	componentUpdateCmd.MarkFlagsMutuallyExclusive("nids", "payload")
+	componentUpdateCmd.MarkFlagsMutuallyExclusive("force", "payload")
+	componentUpdateCmd.MarkFlagsMutuallyExclusive("force", "patch-file")
+	componentUpdateCmd.MarkFlagsMutuallyExclusive("payload", "patch-file")
+
+	componentCmd.AddCommand(componentUpdateCmd)
+}
@@ -0,0 +1,99 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"errors"
+	"net/url"
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// fruGetCmd represents the smd-fru-get command
+var fruGetCmd = &cobra.Command{
+	Use:   "get [--fru-id <fru_id>,...] [--type <type>] [--manufacturer <manufacturer>] [--part-number <part_number>] [--serial-number <serial_number>]",
+	Args:  cobra.NoArgs,
+	Short: "Get FRUs",
+	Long: `Get the FRUs known to SMD, whether or not they are installed, optionally
+filtered by FRU ID, type, manufacturer, part number, or serial number. Use
+'ochami smd fru locate' to find out where a FRU is installed.
+
+This command sends a GET to SMD. An access token is required.`,
+	Example: `  ochami smd fru get
+  ochami smd fru get --type Memory --manufacturer Micron
+  ochami smd fru get --serial-number 1A2B3C4D
+  ochami smd fru get --fru-id Memory.Micron.36ASF4G72PZ-2G9E2.1A2B3C4D`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		smdBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to SMD
+		smdClient, err := smd.NewClient(smdBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		values := url.Values{}
+		if cmd.Flag("fru-id").Changed {
+			ids, err := cmd.Flags().GetStringSlice("fru-id")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("unable to fetch FRU ID list")
+				os.Exit(1)
+			}
+			for _, id := range ids {
+				values.Add("fruid", id)
+			}
+		}
+		for flag, param := range map[string]string{
+			"type":          "type",
+			"manufacturer":  "manufacturer",
+			"part-number":   "partnumber",
+			"serial-number": "serialnumber",
+		} {
+			if cmd.Flag(flag).Changed {
+				values.Add(param, cmd.Flag(flag).Value.String())
+			}
+		}
+
+		httpEnv, err := smdClient.GetFRUs(values, token)
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				logHTTPError(err, httpEnv, "SMD FRU request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to request FRUs from SMD")
+			}
+			os.Exit(1)
+		}
+
+		// Print output
+		printEnvelope(cmd, httpEnv)
+	},
+}
+
+func init() {
+	fruGetCmd.Flags().StringSlice("fru-id", []string{}, "filter FRUs by FRU ID")
+	fruGetCmd.Flags().String("type", "", "filter FRUs by hardware type (e.g. Memory, Processor, NodeEnclosure)")
+	fruGetCmd.Flags().String("manufacturer", "", "filter FRUs by manufacturer")
+	fruGetCmd.Flags().String("part-number", "", "filter FRUs by part number")
+	fruGetCmd.Flags().String("serial-number", "", "filter FRUs by serial number")
+	fruGetCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	addListFlags(fruGetCmd)
+
+	fruCmd.AddCommand(fruGetCmd)
+}
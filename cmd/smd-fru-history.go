@@ -0,0 +1,101 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// fruHistoryCmd represents the smd-fru-history command
+var fruHistoryCmd = &cobra.Command{
+	Use:   "history [--fru-id <fru_id>,...] [--event-type <type>] [--since <time>] [--until <time>]",
+	Args:  cobra.NoArgs,
+	Short: "Get the hardware inventory history by FRU",
+	Long: `Get the hardware inventory history by FRU, i.e. the events (e.g. added,
+removed, scanned) recorded for each FRU, grouped by FRU. The ID of each event
+is the location the FRU was at, so the history of a FRU shows where it moved.
+Use 'ochami smd hwinv history' to group events by location instead.
+
+Pass --since and/or --until to only show events within a time window. Times
+can be RFC 3339 timestamps, dates, durations relative to now (e.g. -24h or
+-7d), now, today, yesterday, or Unix timestamps prefixed with @.
+
+This command sends a GET to SMD. An access token is required.`,
+	Example: `  ochami smd fru history
+  ochami smd fru history --fru-id Memory.Micron.36ASF4G72PZ-2G9E2.1A2B3C4D
+  ochami smd fru history --event-type Added --since -30d`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		smdBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to SMD
+		smdClient, err := smd.NewClient(smdBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		values := url.Values{}
+		if cmd.Flag("fru-id").Changed {
+			ids, err := cmd.Flags().GetStringSlice("fru-id")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("unable to fetch FRU ID list")
+				os.Exit(1)
+			}
+			for _, id := range ids {
+				values.Add("id", id)
+			}
+		}
+		if cmd.Flag("event-type").Changed {
+			values.Add("eventtype", cmd.Flag("event-type").Value.String())
+		}
+		w := timeWindow(cmd)
+		if !w.Since.IsZero() {
+			values.Add("starttime", w.Since.UTC().Format(time.RFC3339))
+		}
+		if !w.Until.IsZero() {
+			values.Add("endtime", w.Until.UTC().Format(time.RFC3339))
+		}
+
+		httpEnv, err := smdClient.GetFRUHistory(values, token)
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				logHTTPError(err, httpEnv, "SMD FRU history request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to request FRU history from SMD")
+			}
+			os.Exit(1)
+		}
+
+		// Print output
+		printEnvelope(cmd, httpEnv)
+	},
+}
+
+func init() {
+	fruHistoryCmd.Flags().StringSlice("fru-id", []string{}, "filter history by FRU ID")
+	fruHistoryCmd.Flags().String("event-type", "", "filter history by event type (e.g. Added, Removed, Scanned, DetectedChange)")
+	fruHistoryCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	addTimeWindowFlags(fruHistoryCmd)
+
+	fruCmd.AddCommand(fruHistoryCmd)
+}
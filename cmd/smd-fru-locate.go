@@ -0,0 +1,130 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// fruLocateCmd represents the smd-fru-locate command
+var fruLocateCmd = &cobra.Command{
+	Use:   "locate <fru_id>...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Show where FRUs are installed and where they have been",
+	Long: `Show where FRUs are installed and where they have been. For each FRU ID
+passed, the locations (xnames) in SMD's hardware inventory that the FRU
+populates now are shown, followed by the FRU's history, oldest first, which
+shows each location it was added to or removed from.
+
+The FRUs are printed as text unless --output-format is passed, in which case
+they are printed in that format.
+
+This command sends GETs to SMD. An access token is required.`,
+	Example: `  ochami smd fru locate Memory.Micron.36ASF4G72PZ-2G9E2.1A2B3C4D
+  ochami smd fru locate -F yaml Memory.Micron.36ASF4G72PZ-2G9E2.1A2B3C4D`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		smdBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to SMD
+		smdClient, err := smd.NewClient(smdBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		ctx := context.Background()
+		if runner != nil {
+			ctx = runner.Context()
+		}
+		locs := make([]smd.FRULocation, 0, len(args))
+		for _, id := range args {
+			loc, err := smdClient.LocateFRU(ctx, id, token)
+			if err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msgf("SMD request for FRU %s yielded unsuccessful HTTP response", id)
+				} else {
+					log.Logger.Error().Err(err).Msgf("failed to locate FRU %s", id)
+				}
+				os.Exit(1)
+			}
+			locs = append(locs, loc)
+		}
+
+		// Print output
+		if cmd.Flag("output-format").Changed {
+			outFmt, err := cmd.Flags().GetString("output-format")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+				os.Exit(1)
+			}
+			locsBytes, err := json.Marshal(locs)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to marshal FRU locations")
+				os.Exit(1)
+			}
+			if outBytes, err := client.FormatBody(locsBytes, outFmt); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to format output")
+				os.Exit(1)
+			} else {
+				fmt.Print(string(outBytes))
+			}
+		} else {
+			writeFRULocations(os.Stdout, locs)
+		}
+	},
+}
+
+// writeFRULocations writes locs to w as text, one section per FRU.
+func writeFRULocations(w io.Writer, locs []smd.FRULocation) {
+	for i, loc := range locs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "FRU %s\n", loc.FRUID)
+		if len(loc.Locations) == 0 {
+			fmt.Fprintln(w, "  Location: not installed")
+		} else {
+			fmt.Fprintf(w, "  Location: %s\n", strings.Join(loc.Locations, ", "))
+		}
+		if len(loc.History) == 0 {
+			fmt.Fprintln(w, "  History: none")
+			continue
+		}
+		fmt.Fprintln(w, "  History:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, e := range loc.History {
+			fmt.Fprintf(tw, "    %s\t%s\t%s\n", e.Timestamp, e.EventType, e.ID)
+		}
+		tw.Flush()
+	}
+}
+
+func init() {
+	fruLocateCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+
+	fruCmd.AddCommand(fruLocateCmd)
+}
@@ -0,0 +1,38 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/spf13/cobra"
+)
+
+// fruCmd represents the smd-fru command
+var fruCmd = &cobra.Command{
+	Use:   "fru",
+	Args:  cobra.NoArgs,
+	Short: "Track field-replaceable units (FRUs)",
+	Long: `Track field-replaceable units (FRUs). This is a metacommand. Commands under this
+one interact with the State Management Database (SMD).
+
+SMD keeps hardware inventory both by location (the xname where a part is
+installed, see 'ochami smd hwinv') and by FRU ID (which identifies the part
+itself wherever it is installed). The commands under this one use the latter,
+e.g. to find out where a DIMM or board is now and where it has been.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+	},
+}
+
+func init() {
+	smdCmd.AddCommand(fruCmd)
+}
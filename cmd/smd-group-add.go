@@ -14,13 +14,18 @@ import (
 
 // groupAddCmd represents the smd-group-add command
 var groupAddCmd = &cobra.Command{
-	Use:   "add -f <payload_file> | <group_label>",
+	Use:   "add -f <payload_file> | ([--set <field>=<value>]... [<group_label>])",
 	Short: "Add new group",
 	Long: `Add new group. A group name is required unless -f is passed to read the payload file.
 Specifying -f also is mutually exclusive with the other flags of this commands
 and its arguments. If - is used as the argument to -f, the data is read from
 standard input.
 
+Any field of the group can be set with --set <field>=<value>, using the field
+names of the payload (e.g. label or members.ids). The group name can be set
+this way instead of being passed as an argument. Values are parsed as YAML,
+so lists can be passed in flow style (e.g. [a, b]).
+
 This command sends a POST to SMD. An access token is required.`,
 	Example: `  ochami smd group add computes
   ochami smd group add -d "Compute group" computes
@@ -31,13 +36,14 @@ This command sends a POST to SMD. An access token is required.`,
     --member x3000c1s7b0n1,x3000c1s7b1n1 \
     --exclusive-group amd64 \
     arm64
+  ochami smd group add --set label=arm64 --set "members.ids=[x3000c1s7b0n1, x3000c1s7b1n1]"
   ochami smd group add -f payload.json
   ochami smd group add -f payload.yaml --payload-format yaml
   echo '<json_data>' | ochami smd group add -f -
   echo '<yaml_data>' | ochami smd group add -f - --payload-format yaml`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Check that all required args are passed
-		if len(args) == 0 && !cmd.Flag("payload").Changed {
+		if len(args) == 0 && !cmd.Flag("payload").Changed && !cmd.Flag("set").Changed {
 			err := cmd.Usage()
 			if err != nil {
 				log.Logger.Error().Err(err).Msg("failed to print usage")
@@ -67,8 +73,8 @@ This command sends a POST to SMD. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
 		var groups []smd.Group
 		if cmd.Flag("payload").Changed {
@@ -76,7 +82,10 @@ This command sends a POST to SMD. An access token is required.`,
 			handlePayload(cmd, &groups)
 		} else {
 			// ...otherwise use CLI options/args
-			group := smd.Group{Label: args[0]}
+			var group smd.Group
+			if len(args) == 1 {
+				group.Label = args[0]
+			}
 			if cmd.Flag("description").Changed {
 				if group.Description, err = cmd.Flags().GetString("description"); err != nil {
 					log.Logger.Error().Err(err).Msg("unable to fetch description")
@@ -101,6 +110,13 @@ This command sends a POST to SMD. An access token is required.`,
 					os.Exit(1)
 				}
 			}
+
+			// Fields passed with --set override the above
+			handleSet(cmd, &group)
+			if group.Label == "" {
+				log.Logger.Error().Msg("no group name passed (pass it as an argument or with --set label=<group_label>)")
+				os.Exit(1)
+			}
 			groups = append(groups, group)
 		}
 
@@ -110,9 +126,10 @@ This command sends a POST to SMD. An access token is required.`,
 			log.Logger.Error().Err(err).Msg("failed to add group to SMD")
 			os.Exit(1)
 		}
+		exitIfInterrupted(errs)
+
 		// Since smdClient.PostGroups does the addition iteratively, we need to deal with
 		// each error that might have occurred.
-		var errorsOccurred = false
 		for _, err := range errs {
 			if err != nil {
 				if errors.Is(err, client.UnsuccessfulHTTPError) {
@@ -120,13 +137,9 @@ This command sends a POST to SMD. An access token is required.`,
 				} else {
 					log.Logger.Error().Err(err).Msg("failed to add group(s) to SMD")
 				}
-				errorsOccurred = true
 			}
 		}
-		if errorsOccurred {
-			log.Logger.Warn().Msg("SMD group addition completed with errors")
-			os.Exit(1)
-		}
+		exitIfBulkFailed(errs, "SMD group addition")
 	},
 }
 
@@ -135,13 +148,16 @@ func init() {
 	groupAddCmd.Flags().StringSlice("tag", []string{}, "one or more tags for group")
 	groupAddCmd.Flags().StringP("exclusive-group", "e", "", "name of group that cannot share members with this one")
 	groupAddCmd.Flags().StringSliceP("member", "m", []string{}, "one or more component IDs to add to the new group")
-	groupAddCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	groupAddCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	addSetFlag(groupAddCmd, "the group")
+	groupAddCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	groupAddCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	groupAddCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
 
 	groupAddCmd.MarkFlagsMutuallyExclusive("description", "payload")
 	groupAddCmd.MarkFlagsMutuallyExclusive("tag", "payload")
 	groupAddCmd.MarkFlagsMutuallyExclusive("exclusive-group", "payload")
 	groupAddCmd.MarkFlagsMutuallyExclusive("member", "payload")
+	groupAddCmd.MarkFlagsMutuallyExclusive("set", "payload")
 
 	groupCmd.AddCommand(groupAddCmd)
 }
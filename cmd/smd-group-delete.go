@@ -61,8 +61,8 @@ This command sends a DELETE to SMD. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
 		// Ask before attempting deletion unless --force was passed
 		if !cmd.Flag("force").Changed {
@@ -93,30 +93,28 @@ This command sends a DELETE to SMD. An access token is required.`,
 			log.Logger.Error().Err(err).Msg("failed to delete groups in SMD")
 			os.Exit(1)
 		}
+		exitIfInterrupted(errs)
+
 		// Since smdClient.DeleteGroups does the deletion iteratively, we need to deal with
 		// each error that might have occurred.
-		var errorsOccurred = false
 		for _, e := range errs {
-			if err != nil {
+			if e != nil {
 				if errors.Is(e, client.UnsuccessfulHTTPError) {
 					log.Logger.Error().Err(e).Msg("SMD group deletion yielded unsuccessful HTTP response")
 				} else {
 					log.Logger.Error().Err(e).Msg("failed to delete group")
 				}
-				errorsOccurred = true
 			}
 		}
 		// Warn the user if any errors occurred during deletion iterations
-		if errorsOccurred {
-			log.Logger.Warn().Msg("SMD group deletion completed with errors")
-			os.Exit(1)
-		}
+		exitIfBulkFailed(errs, "SMD group deletion")
 	},
 }
 
 func init() {
-	groupDeleteCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	groupDeleteCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	groupDeleteCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	groupDeleteCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	groupDeleteCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
 	groupDeleteCmd.Flags().Bool("force", false, "do not ask before attempting deletion")
 
 	groupCmd.AddCommand(groupDeleteCmd)
@@ -4,13 +4,13 @@ package cmd
 
 import (
 	"errors"
-	"fmt"
 	"net/url"
 	"os"
 
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
 	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/OpenCHAMI/ochami/pkg/format"
 	"github.com/spf13/cobra"
 )
 
@@ -45,13 +45,12 @@ var groupGetCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
 		// If no ID flags are specified, get all groups
-		qstr := ""
+		values := url.Values{}
 		if cmd.Flag("name").Changed || cmd.Flag("tag").Changed {
-			values := url.Values{}
 			if cmd.Flag("name").Changed {
 				s, err := cmd.Flags().GetStringSlice("name")
 				if err != nil {
@@ -72,12 +71,11 @@ var groupGetCmd = &cobra.Command{
 					values.Add("tag", t)
 				}
 			}
-			qstr = values.Encode()
 		}
-		httpEnv, err := smdClient.GetGroups(qstr, token)
+		httpEnv, err := smdClient.GetGroupsValues(values, token)
 		if err != nil {
 			if errors.Is(err, client.UnsuccessfulHTTPError) {
-				log.Logger.Error().Err(err).Msg("SMD group request yielded unsuccessful HTTP response")
+				logHTTPError(err, httpEnv, "SMD group request yielded unsuccessful HTTP response")
 			} else {
 				log.Logger.Error().Err(err).Msg("failed to request groups from SMD")
 			}
@@ -85,17 +83,7 @@ var groupGetCmd = &cobra.Command{
 		}
 
 		// Print output
-		outFmt, err := cmd.Flags().GetString("output-format")
-		if err != nil {
-			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
-			os.Exit(1)
-		}
-		if outBytes, err := client.FormatBody(httpEnv.Body, outFmt); err != nil {
-			log.Logger.Error().Err(err).Msg("failed to format output")
-			os.Exit(1)
-		} else {
-			fmt.Printf(string(outBytes))
-		}
+		printEnvelope(cmd, httpEnv)
 	},
 }
 
@@ -103,5 +91,7 @@ func init() {
 	groupGetCmd.Flags().StringSlice("name", []string{}, "filter groups by name")
 	groupGetCmd.Flags().StringSlice("tag", []string{}, "filter groups by tag")
 	groupGetCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	addTableFormat(groupGetCmd, format.KindGroups)
+	addListFlags(groupGetCmd)
 	groupCmd.AddCommand(groupGetCmd)
 }
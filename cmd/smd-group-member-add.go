@@ -14,8 +14,8 @@ import (
 
 // groupMemberAddCmd represents the smd-group-member-add command
 var groupMemberAddCmd = &cobra.Command{
-	Use:   "add <group_label> <component>...",
-	Args:  cobra.MinimumNArgs(2),
+	Use:   "add <group_label> [--nids <nid_list>] [<component>...]",
+	Args:  groupMemberArgs,
 	Short: "Add one or more components to a group",
 	Run: func(cmd *cobra.Command, args []string) {
 		// Without a base URI, we cannot do anything
@@ -36,18 +36,43 @@ var groupMemberAddCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		// Members can be passed as arguments and/or as NIDs
+		members := append(args[1:], xnamesFromNIDsFlag(cmd, smdClient)...)
+
+		// SMD rejects adding a component to a group if it is already in
+		// another group with the same exclusive group, so catch this
+		// beforehand and point to 'member move'
+		conflicts, err := smdClient.FindExclusiveConflicts(args[0], members, token)
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msgf("SMD group request for group %s yielded unsuccessful HTTP response", args[0])
+			} else {
+				log.Logger.Error().Err(err).Msgf("failed to check exclusive group membership for group %s", args[0])
+			}
+			os.Exit(1)
+		}
+		if len(conflicts) > 0 {
+			for _, member := range members {
+				if peer, ok := conflicts[member]; ok {
+					log.Logger.Error().Msgf("%s is already a member of group %s, which shares an exclusive group with %s; use 'ochami smd group member move %s %s %s' instead", member, peer, args[0], peer, args[0], member)
+				}
+			}
+			os.Exit(1)
+		}
 
 		// Send off request
-		_, errs, err := smdClient.PostGroupMembers(token, args[0], args[1:]...)
+		_, errs, err := smdClient.PostGroupMembers(token, args[0], members...)
 		if err != nil {
 			log.Logger.Error().Err(err).Msgf("failed to add group member(s) to group %s in SMD", args[0])
 			os.Exit(1)
 		}
+		exitIfInterrupted(errs)
+
 		// Since smdClient.PostGroupMembers does the addition iteratively, we need to deal with
 		// each error that might have occurred.
-		var errorsOccurred = false
 		for _, err := range errs {
 			if err != nil {
 				if errors.Is(err, client.UnsuccessfulHTTPError) {
@@ -55,16 +80,13 @@ var groupMemberAddCmd = &cobra.Command{
 				} else {
 					log.Logger.Error().Err(err).Msgf("failed to add group member(s) to group %s in SMD", args[0])
 				}
-				errorsOccurred = true
 			}
 		}
-		if errorsOccurred {
-			log.Logger.Warn().Msg("SMD group addition completed with errors")
-			os.Exit(1)
-		}
+		exitIfBulkFailed(errs, "SMD group member addition")
 	},
 }
 
 func init() {
+	groupMemberAddCmd.Flags().String("nids", "", "comma-separated list of node IDs and ranges whose components to add (e.g. 1-64,100)")
 	groupMemberCmd.AddCommand(groupMemberAddCmd)
 }
@@ -14,8 +14,8 @@ import (
 
 // groupMemberDeleteCmd represents the smd-group-member-delete command
 var groupMemberDeleteCmd = &cobra.Command{
-	Use:   "delete <group_label> <component>...",
-	Args:  cobra.MinimumNArgs(2),
+	Use:   "delete <group_label> [--nids <nid_list>] [<component>...]",
+	Args:  groupMemberArgs,
 	Short: "Delete one or more members from a group",
 	Run: func(cmd *cobra.Command, args []string) {
 		// Without a base URI, we cannot do anything
@@ -36,8 +36,11 @@ var groupMemberDeleteCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		// Members can be passed as arguments and/or as NIDs
+		members := append(args[1:], xnamesFromNIDsFlag(cmd, smdClient)...)
 
 		// Ask before attempting deletion unless --force was passed
 		if !cmd.Flag("force").Changed {
@@ -52,33 +55,31 @@ var groupMemberDeleteCmd = &cobra.Command{
 		}
 
 		// Perform deletion from arguments
-		_, errs, err := smdClient.DeleteGroupMembers(token, args[0], args[1:]...)
+		_, errs, err := smdClient.DeleteGroupMembers(token, args[0], members...)
 		if err != nil {
 			log.Logger.Error().Err(err).Msgf("failed to delete members from group %s in SMD", args[0])
 			os.Exit(1)
 		}
+		exitIfInterrupted(errs)
+
 		// Since smdClient.DeleteGroupMembers does the deletion iteratively, we need to deal with
 		// each error that might have occurred.
-		var errorsOccurred = false
 		for _, e := range errs {
-			if err != nil {
+			if e != nil {
 				if errors.Is(e, client.UnsuccessfulHTTPError) {
 					log.Logger.Error().Err(e).Msg("SMD group member deletion yielded unsuccessful HTTP response")
 				} else {
 					log.Logger.Error().Err(e).Msg("failed to delete group member(s)")
 				}
-				errorsOccurred = true
 			}
 		}
 		// Warn the user if any errors occurred during deletion iterations
-		if errorsOccurred {
-			log.Logger.Warn().Msg("SMD group member deletion completed with errors")
-			os.Exit(1)
-		}
+		exitIfBulkFailed(errs, "SMD group member deletion")
 	},
 }
 
 func init() {
+	groupMemberDeleteCmd.Flags().String("nids", "", "comma-separated list of node IDs and ranges whose components to delete (e.g. 1-64,100)")
 	groupMemberDeleteCmd.Flags().Bool("force", false, "do not ask before attempting deletion")
 	groupMemberCmd.AddCommand(groupMemberDeleteCmd)
 }
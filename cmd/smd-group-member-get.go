@@ -3,6 +3,8 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -18,6 +20,17 @@ var groupMemberGetCmd = &cobra.Command{
 	Use:   "get <group_label>",
 	Args:  cobra.ExactArgs(1),
 	Short: "Get members of a group",
+	Long: `Get members of a group. By default, the response from SMD is printed
+in the format passed to --output-format.
+
+For large groups, pass --stream to print just the member IDs, one per line,
+as they are read instead of formatting the whole response first. This is
+also convenient for piping into other commands. --output-format and the
+list options do not apply to streamed output.
+
+This command sends a GET to SMD. An access token is required.`,
+	Example: `  ochami smd group member get compute
+  ochami smd group member get compute --stream | wc -l`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Without a base URI, we cannot do anything
 		smdBaseURI, err := getBaseURI(cmd)
@@ -37,14 +50,19 @@ var groupMemberGetCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		if cmd.Flag("stream").Changed {
+			streamGroupMembers(smdClient, args[0])
+			return
+		}
 
 		// Send request
 		httpEnv, err := smdClient.GetGroupMembers(args[0], token)
 		if err != nil {
 			if errors.Is(err, client.UnsuccessfulHTTPError) {
-				log.Logger.Error().Err(err).Msg("SMD group member request yielded unsuccessful HTTP response")
+				logHTTPError(err, httpEnv, "SMD group member request yielded unsuccessful HTTP response")
 			} else {
 				log.Logger.Error().Err(err).Msg("failed to request group members from SMD")
 			}
@@ -52,21 +70,36 @@ var groupMemberGetCmd = &cobra.Command{
 		}
 
 		// Print output
-		outFmt, err := cmd.Flags().GetString("output-format")
+		printEnvelope(cmd, httpEnv)
+	},
+}
+
+// streamGroupMembers prints the IDs of the members of group, one per line,
+// a page at a time. If an error occurs, it is logged and the program exits.
+func streamGroupMembers(smdClient *smd.SMDClient, group string) {
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	pager := smdClient.GroupMemberPager(group, token)
+	for pager.More() {
+		ids, err := pager.Next(context.Background())
 		if err != nil {
-			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+			w.Flush()
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msg("SMD group member request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to request group members from SMD")
+			}
 			os.Exit(1)
 		}
-		if outBytes, err := client.FormatBody(httpEnv.Body, outFmt); err != nil {
-			log.Logger.Error().Err(err).Msg("failed to format output")
-			os.Exit(1)
-		} else {
-			fmt.Printf(string(outBytes))
+		for _, id := range ids {
+			fmt.Fprintln(w, id)
 		}
-	},
+	}
 }
 
 func init() {
+	groupMemberGetCmd.Flags().Bool("stream", false, "print member IDs one per line as they are read instead of formatting the response")
 	groupMemberGetCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	addListFlags(groupMemberGetCmd)
 	groupMemberCmd.AddCommand(groupMemberGetCmd)
 }
@@ -0,0 +1,77 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/cli"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// groupMemberMoveCmd represents the smd-group-member-move command
+var groupMemberMoveCmd = &cobra.Command{
+	Use:   "move <from_group_label> <to_group_label> <component>...",
+	Args:  cobra.MinimumNArgs(3),
+	Short: "Move one or more components from one group to another",
+	Long: `Move one or more components from one group to another. This is required
+for moving components between groups that share an exclusive group, since
+SMD does not allow a component to be in both at the same time.
+
+Each component is moved on its own. If a step of a move fails, the
+completed step is undone so that the component stays in exactly one of
+the groups.
+
+This command sends DELETE and POST requests to the members subendpoint
+under SMD's /groups endpoint. An access token is required.`,
+	Example: `  ochami smd group member move rack1-slots rack2-slots x3000c1s7b56n0
+  ochami smd group member move compute login x3000c1s7b56n0 x3000c1s7b56n1`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		smdBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to SMD
+		smdClient, err := smd.NewClient(smdBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		// Move each member, continuing on failure so that one bad member
+		// does not prevent the others from being moved
+		from, to := args[0], args[1]
+		failed := 0
+		for _, member := range args[2:] {
+			if err := smdClient.MoveGroupMember(from, to, member, token); err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msgf("SMD group member request yielded unsuccessful HTTP response while moving %s", member)
+				} else {
+					log.Logger.Error().Err(err).Msgf("failed to move %s from group %s to group %s", member, from, to)
+				}
+				failed++
+				continue
+			}
+			log.Logger.Info().Msgf("moved %s from group %s to group %s", member, from, to)
+		}
+		exitIfItemsFailed("SMD group member move", cli.BulkSummary{Total: len(args[2:]), Succeeded: len(args[2:]) - failed, Failed: failed})
+	},
+}
+
+func init() {
+	groupMemberCmd.AddCommand(groupMemberMoveCmd)
+}
@@ -22,8 +22,19 @@ in the list are set as the only members of the group. If a component
 specified is already in the group, it remains in the group. If a
 component specified is not already in te group, it is added to the
 group. If a component is in the group but not specified, it is
-removed from the group.`,
-	Example: `  ochami smd group member set compute x1000c1s7b1n0 x1000c1s7b2n0`,
+removed from the group.
+
+SMD can only set the whole member list of a group at once, so setting the
+members of a large group means sending a large request. If more members
+than --batch-size are passed, the current members are fetched instead and
+only the members to remove and to add are sent, one request per member, so
+that members that stay in the group are never removed in between. Pass
+--batch-size 0 to always send a single request.
+
+This command sends a PUT to SMD or, for large groups, a GET followed by
+DELETEs and/or POSTs. An access token is required.`,
+	Example: `  ochami smd group member set compute x1000c1s7b1n0 x1000c1s7b2n0
+  ochami smd group member set compute $(cat compute-nodes.txt) --batch-size 500`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Without a base URI, we cannot do anything
 		smdBaseURI, err := getBaseURI(cmd)
@@ -43,11 +54,17 @@ removed from the group.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
-		// Send off request
-		_, err = smdClient.PutGroupMembers(token, args[0], args[1:]...)
+		batchSize, err := cmd.Flags().GetInt("batch-size")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --batch-size")
+			os.Exit(1)
+		}
+
+		// Send off request(s)
+		_, errs, err := smdClient.SetGroupMembers(token, args[0], batchSize, args[1:]...)
 		if err != nil {
 			if errors.Is(err, client.UnsuccessfulHTTPError) {
 				log.Logger.Error().Err(err).Msgf("SMD group member request for group %s yielded unsuccessful HTTP response", args[0])
@@ -56,9 +73,24 @@ removed from the group.`,
 			}
 			os.Exit(1)
 		}
+		exitIfInterrupted(errs)
+
+		// Large groups are set with a request per changed member, so deal
+		// with each error that might have occurred
+		for _, err := range errs {
+			if err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msgf("SMD group member request for group %s yielded unsuccessful HTTP response", args[0])
+				} else {
+					log.Logger.Error().Err(err).Msgf("failed to set group membership for group %s in SMD", args[0])
+				}
+			}
+		}
+		exitIfBulkFailed(errs, "SMD group member set")
 	},
 }
 
 func init() {
+	groupMemberSetCmd.Flags().Int("batch-size", smd.DefaultGroupMemberBatchSize, "most members to set in a single request; above this, only changes are sent (0 for no limit)")
 	groupMemberCmd.AddCommand(groupMemberSetCmd)
 }
@@ -31,3 +31,13 @@ interact with the State Management Database (SMD).`,
 func init() {
 	groupCmd.AddCommand(groupMemberCmd)
 }
+
+// groupMemberArgs validates the arguments of commands taking a group label
+// followed by one or more members. When --nids is passed, the members can
+// come from it instead, so only the group label is required.
+func groupMemberArgs(cmd *cobra.Command, args []string) error {
+	if cmd.Flag("nids").Changed {
+		return cobra.MinimumNArgs(1)(cmd, args)
+	}
+	return cobra.MinimumNArgs(2)(cmd, args)
+}
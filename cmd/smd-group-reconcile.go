@@ -0,0 +1,227 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/internal/cli"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// groupReconcileCmd represents the smd-group-reconcile command
+var groupReconcileCmd = &cobra.Command{
+	Use:   "reconcile -f <definitions_file> [--payload-format <format>] [--dry-run]",
+	Args:  cobra.NoArgs,
+	Short: "Sync groups with declarative definitions based on component selectors",
+	Long: `Sync groups with declarative definitions based on component selectors.
+The definitions file maps each group label to a selector expression over
+component fields, and the members of each group are set to the components
+in SMD that match its selector. Groups that do not exist are created and
+groups that are not in the file are left alone. A definition can also be
+an object with the selector as "selector" and, optionally, "description",
+"tags", and "exclusiveGroup", which are set on the group as well:
+
+  groups:
+    compute: type=Node,role=Compute
+    gpu:
+      selector: type=Node,role=Compute,subrole in (GPU,Visualization)
+      description: GPU nodes
+      tags: [accel]
+
+A selector is a comma-separated list of requirements that must all be met:
+field=value, field!=value, field in (value,...), field notin (value,...),
+field (the field is set), or !field (the field is not set). Field names and
+values are case-insensitive and values may contain * and ? wildcards.
+
+Members are removed from all groups before any are added so that
+components can move between groups of the same exclusive group. Pass
+--dry-run to print the changes without making them. A line is printed for
+each group describing the change made to it, if any.
+
+This command sends GETs to SMD, then POSTs, PUTs, and/or PATCHes. An access
+token is required.`,
+	Example: `  ochami smd group reconcile -f groups.yaml --payload-format yaml --dry-run
+  ochami smd group reconcile -f groups.yaml --payload-format yaml
+  ochami smd group reconcile -f https://git.example.com/cluster/groups.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var defs smd.GroupDefinitions
+		handlePayload(cmd, &defs)
+		if len(defs.Groups) == 0 {
+			log.Logger.Error().Msg("no group definitions found in payload")
+			os.Exit(1)
+		}
+
+		// Without a base URI, we cannot do anything
+		smdBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to SMD
+		smdClient, err := smd.NewClient(smdBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		// Fetch everything the selectors are evaluated against and the
+		// current groups
+		compEnv, err := smdClient.GetComponentsAll()
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				logHTTPError(err, compEnv, "SMD component request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to request components from SMD")
+			}
+			os.Exit(1)
+		}
+		var comps struct {
+			Components []map[string]any `json:"Components"`
+		}
+		if err := json.Unmarshal(compEnv.Body, &comps); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to unmarshal components")
+			os.Exit(1)
+		}
+		groupEnv, err := smdClient.GetGroups("", token)
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				logHTTPError(err, groupEnv, "SMD group request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to request groups from SMD")
+			}
+			os.Exit(1)
+		}
+		var groups []smd.Group
+		if err := json.Unmarshal(groupEnv.Body, &groups); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to unmarshal groups")
+			os.Exit(1)
+		}
+
+		changes, err := smd.PlanGroupReconcile(defs, comps.Components, groups)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("invalid group definitions")
+			os.Exit(1)
+		}
+		for _, gc := range changes {
+			for _, w := range gc.Warnings {
+				log.Logger.Warn().Msg(w)
+			}
+			if len(gc.Members) == 0 {
+				log.Logger.Warn().Msgf("selector for group %s matches no components", gc.Label)
+			}
+		}
+
+		if cmd.Flag("dry-run").Changed {
+			for _, gc := range changes {
+				fmt.Println(gc)
+			}
+			return
+		}
+
+		// A group's change is only reported as done if all of its requests
+		// succeeded, so track failures by group
+		failedGroups := make(map[string]bool)
+		fail := func(label string, err error) {
+			failedGroups[label] = true
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msgf("SMD request to reconcile group %s yielded unsuccessful HTTP response", label)
+			} else {
+				log.Logger.Error().Err(err).Msgf("failed to reconcile group %s", label)
+			}
+		}
+
+		// Remove members first so that members moving between groups of an
+		// exclusive group are never in two of them at once. For groups that
+		// only lose members, this is all there is to do.
+		for _, gc := range changes {
+			if gc.Create || len(gc.Remove) == 0 {
+				continue
+			}
+			keep := make([]string, 0, len(gc.Members))
+			added := make(map[string]bool, len(gc.Add))
+			for _, id := range gc.Add {
+				added[strings.ToLower(id)] = true
+			}
+			for _, id := range gc.Members {
+				if !added[strings.ToLower(id)] {
+					keep = append(keep, id)
+				}
+			}
+			if len(keep) == 0 {
+				// SMD cannot be sent an empty member list, so remove the
+				// members one by one instead
+				_, errs, err := smdClient.DeleteGroupMembers(token, gc.Label, gc.Remove...)
+				if err = errors.Join(append(errs, err)...); err != nil {
+					fail(gc.Label, err)
+				}
+				continue
+			}
+			if _, err := smdClient.PutGroupMembers(token, gc.Label, keep...); err != nil {
+				fail(gc.Label, err)
+			}
+		}
+
+		for _, gc := range changes {
+			if failedGroups[gc.Label] {
+				continue
+			}
+			if gc.Create {
+				if err := firstErr(smdClient.PostGroups([]smd.Group{gc.Group}, token)); err != nil {
+					fail(gc.Label, err)
+				}
+				continue
+			}
+			if len(gc.Add) > 0 {
+				if _, err := smdClient.PutGroupMembers(token, gc.Label, gc.Members...); err != nil {
+					fail(gc.Label, err)
+					continue
+				}
+			}
+			if gc.UpdateMeta {
+				if err := firstErr(smdClient.PatchGroups([]smd.Group{gc.Group}, token)); err != nil {
+					fail(gc.Label, err)
+				}
+			}
+		}
+
+		for _, gc := range changes {
+			if failedGroups[gc.Label] {
+				fmt.Printf("%s: failed\n", gc.Label)
+				continue
+			}
+			fmt.Println(gc)
+		}
+		exitIfItemsFailed("SMD group reconcile", cli.BulkSummary{
+			Total:     len(changes),
+			Succeeded: len(changes) - len(failedGroups),
+			Failed:    len(failedGroups),
+		})
+	},
+}
+
+func init() {
+	groupReconcileCmd.Flags().StringP("payload", "f", "", "file or URL containing the group definitions; JSON format unless --payload-format specified")
+	groupReconcileCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	groupReconcileCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
+	groupReconcileCmd.Flags().Bool("dry-run", false, "print the changes that would be made without making them")
+
+	groupReconcileCmd.MarkFlagRequired("payload")
+
+	groupCmd.AddCommand(groupReconcileCmd)
+}
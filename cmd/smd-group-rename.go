@@ -0,0 +1,158 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// groupRenameCmd represents the smd-group-rename command
+var groupRenameCmd = &cobra.Command{
+	Use:   "rename [--dry-run] <old_group_label> <new_group_label>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Change the label of a group",
+	Long: `Change the label of a group, keeping its description, tags, exclusive
+group, and members. Since SMD does not allow changing the label of a
+group, the new group is created as a copy of the old one, the old group's
+members are put in it, and the old group is deleted. If the group is part
+of an exclusive group, the old group is deleted before the members are put
+in the new one, since a component cannot be in two groups of the same
+exclusive group.
+
+The new label must not exist yet. If a step fails, the completed steps are
+undone in reverse order.
+
+This command sends requests to SMD's /groups endpoint. An access token is
+required.`,
+	Example: `  ochami smd group rename compute compute-old
+  ochami smd group rename --dry-run compute compute-old`,
+	Run: func(cmd *cobra.Command, args []string) {
+		oldLabel, newLabel := args[0], args[1]
+		if oldLabel == newLabel {
+			log.Logger.Error().Msg("old and new group labels are the same")
+			os.Exit(1)
+		}
+
+		// Without a base URI, we cannot do anything
+		smdBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+			os.Exit(1)
+		}
+
+		// These endpoints require authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make requests to SMD
+		smdClient, err := smd.NewClient(smdBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		steps, err := planGroupRename(smdClient, oldLabel, newLabel)
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msgf("request yielded unsuccessful HTTP response while looking up group %s", oldLabel)
+			} else {
+				log.Logger.Error().Err(err).Msgf("failed to look up what to rename for group %s", oldLabel)
+			}
+			os.Exit(1)
+		}
+		if cmd.Flag("dry-run").Changed {
+			fmt.Printf("%s -> %s:\n", oldLabel, newLabel)
+			for i, s := range steps {
+				fmt.Printf("  %d. %s\n", i+1, s.desc)
+			}
+			return
+		}
+		if err := runRename(steps); err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to rename group %s to %s", oldLabel, newLabel)
+			os.Exit(1)
+		}
+		log.Logger.Info().Msgf("renamed group %s to %s", oldLabel, newLabel)
+	},
+}
+
+// planGroupRename looks up the group labeled oldLabel and returns the steps
+// that rename it to newLabel. Nothing is modified.
+func planGroupRename(smdClient *smd.SMDClient, oldLabel, newLabel string) ([]renameStep, error) {
+	oldGroup, err := smdClient.GetGroup(oldLabel, token)
+	if err != nil {
+		return nil, err
+	}
+	if henv, err := smdClient.GetGroupMembers(newLabel, token); err == nil {
+		return nil, fmt.Errorf("group %s already exists", newLabel)
+	} else if henv.StatusCode != http.StatusNotFound {
+		return nil, fmt.Errorf("failed to check whether group %s exists: %w", newLabel, err)
+	}
+
+	// The new group is created without members so that they are set with a
+	// single PUT once it exists
+	newGroup := oldGroup
+	newGroup.Label = newLabel
+	newGroup.Members.IDs = nil
+	members := oldGroup.Members.IDs
+
+	postGroup := func(g smd.Group) func() error {
+		return func() error {
+			return firstErr(smdClient.PostGroups([]smd.Group{g}, token))
+		}
+	}
+	deleteGroup := func(label string) func() error {
+		return func() error {
+			return firstErr(smdClient.DeleteGroups(token, label))
+		}
+	}
+
+	create := renameStep{
+		desc: fmt.Sprintf("create group %s", newLabel),
+		do:   postGroup(newGroup),
+		undo: deleteGroup(newLabel),
+	}
+	// Deleting the old group is undone by recreating it along with its
+	// members
+	remove := renameStep{
+		desc: fmt.Sprintf("delete group %s", oldLabel),
+		do:   deleteGroup(oldLabel),
+		undo: postGroup(oldGroup),
+	}
+	steps := []renameStep{create}
+	if oldGroup.ExclusiveGroup != "" {
+		steps = append(steps, remove)
+	}
+	if len(members) > 0 {
+		// Members put in the new group are removed along with it when
+		// creating it is undone
+		steps = append(steps, renameStep{
+			desc: fmt.Sprintf("set members of group %s to the %d member(s) of group %s", newLabel, len(members), oldLabel),
+			do: func() error {
+				_, err := smdClient.PutGroupMembers(token, newLabel, members...)
+				return err
+			},
+		})
+	}
+	if oldGroup.ExclusiveGroup == "" {
+		steps = append(steps, remove)
+	}
+
+	return steps, nil
+}
+
+func init() {
+	groupRenameCmd.Flags().Bool("dry-run", false, "print the steps of the rename without performing them")
+
+	groupCmd.AddCommand(groupRenameCmd)
+}
@@ -0,0 +1,33 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// groupTagAddCmd represents the smd-group-tag-add command
+var groupTagAddCmd = &cobra.Command{
+	Use:   "add <group_label> <tag>...",
+	Args:  cobra.MinimumNArgs(2),
+	Short: "Add one or more tags to a group",
+	Long: `Add one or more tags to a group. Tags that the group already has are
+ignored. Only the tags of the group are modified; its description and members
+are left untouched.
+
+The group is fetched and a PATCH containing only its new tags is sent to
+SMD's /groups endpoint. If SMD returns an ETag, the PATCH is conditional on
+the group not having been modified in the meantime. No PATCH is sent if the
+tags do not change. An access token is required.
+
+See ochami-smd(1) for more details.`,
+	Example: `  ochami smd group tag add compute gpu
+  ochami smd group tag add compute gpu highmem`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runGroupTag(cmd, args, true)
+	},
+}
+
+func init() {
+	groupTagCmd.AddCommand(groupTagAddCmd)
+}
@@ -0,0 +1,86 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// groupTagCmd represents the smd-group-tag command
+var groupTagCmd = &cobra.Command{
+	Use:   "tag",
+	Args:  cobra.NoArgs,
+	Short: "Manage group tags",
+	Long: `Manage group tags. This is a metacommand. Commands under this one
+interact with the State Management Database (SMD).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+	},
+}
+
+func init() {
+	groupCmd.AddCommand(groupTagCmd)
+}
+
+// runGroupTag adds (if add is true) or removes the tags in args[1:] to or from
+// the group labeled args[0], leaving the rest of the group untouched.
+func runGroupTag(cmd *cobra.Command, args []string, add bool) {
+	// Without a base URI, we cannot do anything
+	smdBaseURI, err := getBaseURI(cmd)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+		os.Exit(1)
+	}
+
+	// This endpoint requires authentication, so a token is needed
+	setTokenFromEnvVar(cmd)
+	checkToken(cmd)
+
+	// Create client to make request to SMD
+	smdClient, err := smd.NewClient(smdBaseURI, insecure)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("error creating new SMD client")
+		os.Exit(1)
+	}
+
+	// Configure client for the cluster (CA certificates, tenant, etc.)
+	configureClient(smdClient.OchamiClient)
+
+	group := args[0]
+	var toAdd, toRemove []string
+	if add {
+		toAdd = args[1:]
+	} else {
+		toRemove = args[1:]
+	}
+	tags, changed, err := smdClient.PatchGroupTags(group, toAdd, toRemove, token)
+	if err != nil {
+		if errors.Is(err, client.PreconditionFailedError) {
+			log.Logger.Error().Err(err).Msg("group was modified by someone else since it was read; rerun to update the current version")
+		} else if errors.Is(err, client.UnsuccessfulHTTPError) {
+			log.Logger.Error().Err(err).Msg("SMD group request yielded unsuccessful HTTP response")
+		} else {
+			log.Logger.Error().Err(err).Msgf("failed to update tags of group %s", group)
+		}
+		os.Exit(1)
+	}
+	if !changed {
+		log.Logger.Info().Msgf("tags of group %s unchanged: %s", group, strings.Join(tags, ","))
+		return
+	}
+	log.Logger.Info().Msgf("tags of group %s are now: %s", group, strings.Join(tags, ","))
+}
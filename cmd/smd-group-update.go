@@ -14,7 +14,7 @@ import (
 
 // groupUpdateCmd represents the smd-group-update command
 var groupUpdateCmd = &cobra.Command{
-	Use:   "update -f <payload_file> | ([--description <description>] [--tag <tag>]... <group_label>)",
+	Use:   "update -f <payload_file> | ([--description <description>] [--tag <tag>]... <group_label>) | (--patch-file <patch_file> [--patch-type <type>] <group_label>...)",
 	Short: "Update the description and/or tags of a group",
 	Long: `Update the description and/or tags of a group. At least one of --description
 or --tag must be specified. Alternatively, pass -f to pass a file
@@ -22,6 +22,12 @@ or --tag must be specified. Alternatively, pass -f to pass a file
 rules above still apply for the payload. If - is used as the
 argument to -f, the data is read from standard input.
 
+To change other fields, such as the members, pass --patch-file with a
+patch document, which is sent to SMD as is for each group passed. The
+document is a JSON Merge Patch (RFC 7386) by default, or a JSON Patch (RFC
+6902) with --patch-type json-patch, and is sent with the corresponding
+Content-Type.
+
 This command sends a PATCH to SMD. An access token is required.`,
 	Example: `  ochami smd group update --description "New description for compute" compute
   ochami smd group update --tag existing_tag --tag new_tag compute
@@ -30,10 +36,11 @@ This command sends a PATCH to SMD. An access token is required.`,
   ochami smd group update -f payload.json
   ochami smd group update -f payload.yaml --payload-format yaml
   echo '<json_data>' | ochami smd group update -f -
-  echo '<yaml_data>' | ochami smd group update -f - --payload-format yaml`,
+  echo '<yaml_data>' | ochami smd group update -f - --payload-format yaml
+  ochami smd group update --patch-file ops.json --patch-type json-patch compute`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// cmd.LocalFlags().NFlag() doesn't seem to work, so we check every flag
-		if len(args) == 0 && !cmd.Flag("description").Changed && !cmd.Flag("tag").Changed {
+		if len(args) == 0 && !cmd.Flag("description").Changed && !cmd.Flag("tag").Changed && !cmd.Flag("patch-file").Changed {
 			err := cmd.Usage()
 			if err != nil {
 				log.Logger.Error().Err(err).Msg("failed to print usage")
@@ -60,8 +67,26 @@ This command sends a PATCH to SMD. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+		useIfMatch(cmd, smdClient.OchamiClient)
+
+		// With --patch-file, send the patch as is to each group
+		if cmd.Flag("patch-file").Changed {
+			if len(args) == 0 {
+				log.Logger.Error().Msg("expected at least one group label with --patch-file")
+				os.Exit(1)
+			}
+			pt, patch := handlePatch(cmd)
+			_, errs, err := smdClient.PatchGroupsDocument(args, pt, patch, token)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to patch group in SMD")
+				os.Exit(1)
+			}
+			exitIfInterrupted(errs)
+			checkGroupUpdateErrors(errs)
+			return
+		}
 
 		// The group list we will send
 		var groups []smd.Group
@@ -93,33 +118,43 @@ This command sends a PATCH to SMD. An access token is required.`,
 			log.Logger.Error().Err(err).Msg("failed to patch group in SMD")
 			os.Exit(1)
 		}
-		// Since smdClient.PatchGroups does the edition iteratively, we need to deal with
-		// each error that might have occurred.
-		var errorsOccurred = false
-		for _, err := range errs {
-			if err != nil {
-				if errors.Is(err, client.UnsuccessfulHTTPError) {
-					log.Logger.Error().Err(err).Msg("SMD group request yielded unsuccessful HTTP response")
-				} else {
-					log.Logger.Error().Err(err).Msg("failed to update group(s) to SMD")
-				}
-				errorsOccurred = true
+		exitIfInterrupted(errs)
+		checkGroupUpdateErrors(errs)
+	},
+}
+
+// checkGroupUpdateErrors logs each of errs, the errors of iteratively updating
+// groups, and exits if any occurred (see exitIfBulkFailed).
+func checkGroupUpdateErrors(errs []error) {
+	// Since groups are updated iteratively, we need to deal with each error
+	// that might have occurred.
+	for _, err := range errs {
+		if err != nil {
+			if errors.Is(err, client.PreconditionFailedError) {
+				log.Logger.Error().Err(err).Msg("group was modified by someone else since it was read; rerun to update the current version")
+			} else if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msg("SMD group request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to update group(s) to SMD")
 			}
 		}
-		if errorsOccurred {
-			log.Logger.Warn().Msg("SMD group update completed with errors")
-			os.Exit(1)
-		}
-	},
+	}
+	exitIfBulkFailed(errs, "SMD group update")
 }
 
 func init() {
 	groupUpdateCmd.Flags().StringP("description", "d", "", "short description to update group with")
 	groupUpdateCmd.Flags().StringSlice("tag", []string{}, "one or more tags to set for group")
-	groupUpdateCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	groupUpdateCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	groupUpdateCmd.Flags().String("if-match", "", "only write if the resource is unmodified; pass 'auto' to use the ETag from a preceding GET, or an ETag")
+	groupUpdateCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	groupUpdateCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	groupUpdateCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload or --patch-file is an https:// URL")
+	addPatchFlags(groupUpdateCmd)
 
-	groupUpdateCmd.MarkFlagsOneRequired("description", "tag", "payload")
+	groupUpdateCmd.MarkFlagsOneRequired("description", "tag", "payload", "patch-file")
+	for _, f := range []string{"description", "tag", "payload"} {
+		groupUpdateCmd.MarkFlagsMutuallyExclusive(f, "patch-file")
+	}
 
 	groupCmd.AddCommand(groupUpdateCmd)
 }
@@ -0,0 +1,100 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// hwinvHistoryCmd represents the smd-hwinv-history command
+var hwinvHistoryCmd = &cobra.Command{
+	Use:   "history [--xname <xname>,...] [--event-type <type>] [--since <time>] [--until <time>]",
+	Args:  cobra.NoArgs,
+	Short: "Get the hardware inventory history",
+	Long: `Get the hardware inventory history, i.e. the events (e.g. added, removed,
+scanned) recorded for the FRUs at each location, grouped by location.
+
+Pass --since and/or --until to only show events within a time window. Times
+can be RFC 3339 timestamps, dates, durations relative to now (e.g. -24h or
+-7d), now, today, yesterday, or Unix timestamps prefixed with @.
+
+This command sends a GET to SMD. An access token is required.`,
+	Example: `  ochami smd hwinv history
+  ochami smd hwinv history --xname x3000c1s7b56n0
+  ochami smd hwinv history --event-type Removed --since -7d
+  ochami smd hwinv history --since 2024-05-01 --until 2024-05-02T12:00:00Z`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		smdBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to SMD
+		smdClient, err := smd.NewClient(smdBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		values := url.Values{}
+		if cmd.Flag("xname").Changed {
+			s, err := cmd.Flags().GetStringSlice("xname")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("unable to fetch xname list")
+				os.Exit(1)
+			}
+			for _, x := range s {
+				values.Add("id", x)
+			}
+		}
+		if cmd.Flag("event-type").Changed {
+			values.Add("eventtype", cmd.Flag("event-type").Value.String())
+		}
+		w := timeWindow(cmd)
+		if !w.Since.IsZero() {
+			values.Add("starttime", w.Since.UTC().Format(time.RFC3339))
+		}
+		if !w.Until.IsZero() {
+			values.Add("endtime", w.Until.UTC().Format(time.RFC3339))
+		}
+
+		httpEnv, err := smdClient.GetHardwareHistoryValues(values, token)
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				logHTTPError(err, httpEnv, "SMD hardware inventory history request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to request hardware inventory history from SMD")
+			}
+			os.Exit(1)
+		}
+
+		// Print output
+		printEnvelope(cmd, httpEnv)
+	},
+}
+
+func init() {
+	hwinvHistoryCmd.Flags().StringSlice("xname", []string{}, "filter history by location xname")
+	hwinvHistoryCmd.Flags().String("event-type", "", "filter history by event type (e.g. Added, Removed, Scanned, DetectedChange)")
+	hwinvHistoryCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	addTimeWindowFlags(hwinvHistoryCmd)
+
+	hwinvCmd.AddCommand(hwinvHistoryCmd)
+}
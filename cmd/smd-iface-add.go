@@ -16,7 +16,7 @@ import (
 
 // ifaceAddCmd represents the smd-iface-add command
 var ifaceAddCmd = &cobra.Command{
-	Use:   "add -f <payload_file> | (<comp_id> <mac_addr> (<net_name>,<ip_addr>)...)",
+	Use:   "add -f <payload_file> | --template <template> --var-file <csv_file> | ([--set <field>=<value>]... [<comp_id> <mac_addr> (<net_name>,<ip_addr>)...])",
 	Short: "Add new ethernet interface(s)",
 	Long: `Add new ethernet interface(s). A component ID (usually an xname), MAC address, and
 one or more pairs of network name and IP address (delimited by a comma)
@@ -25,26 +25,42 @@ are required unless -f is passed to read from a payload file. Specifying
 its arguments. If - is used as the argument to -f, the data is read
 from standard input.
 
+Any field of the interface can be set with --set <field>=<value>, using the
+field names of the payload (e.g. ComponentID or MACAddress), instead of
+passing arguments. Values are parsed as YAML, so lists can be passed in
+flow style (e.g. "IPAddresses=[{IPAddress: 172.16.0.55, Network: NMN}]").
+
+Many similar ethernet interfaces can be added at once by passing a template
+of one interface in --payload-format with --template (as is, or as @<path>
+to read it from a file) and a CSV file with --var-file. The template is
+rendered once per row, with the columns named by the header of the CSV file
+as its variables (e.g. {{.xname}}).
+
 This command sends a POST to SMD. An access token is required.`,
 	Example: `  ochami smd iface add x3000c1s7b55n0 de:ca:fc:0f:fe:ee NMN,172.16.0.55
   ochami smd iface add -d "Node Management for n55" x3000c1s7b55n0 de:ca:fc:0f:fe:ee NMN,172.16.0.55
   ochami smd iface add x3000c1s7b55n0 de:ca:fc:0f:fe:ee external,10.1.0.55 internal,172.16.0.55
+  ochami smd iface add --set ComponentID=x3000c1s7b55n0 --set MACAddress=de:ca:fc:0f:fe:ee
   ochami smd iface add -f payload.json
+  ochami smd iface add --template @iface.tmpl --var-file macs.csv
   ochami smd iface add -f payload.yaml --payload-format yaml
   echo '<json_data>' | ochami smd iface add -f -
   echo '<yaml_data>' | ochami smd iface add -f - --payload-format yaml`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Check that all required args are passed
-		if len(args) == 0 && !cmd.Flag("payload").Changed {
+		if len(args) == 0 && !cmd.Flag("payload").Changed && !cmd.Flag("set").Changed && !cmd.Flag("template").Changed {
 			err := cmd.Usage()
 			if err != nil {
 				log.Logger.Error().Err(err).Msg("failed to print usage")
 				os.Exit(1)
 			}
 			os.Exit(0)
-		} else if len(args) < 3 {
+		} else if !cmd.Flag("template").Changed && len(args) < 3 && (len(args) != 0 || !cmd.Flag("set").Changed) {
 			log.Logger.Error().Msgf("expected at least 3 arguments (comp_id, mac_addr, net_ip_paor) but got %d: %v", len(args), args)
 			os.Exit(1)
+		} else if cmd.Flag("template").Changed && len(args) > 0 {
+			log.Logger.Error().Msgf("expected no arguments with --template but got %d: %v", len(args), args)
+			os.Exit(1)
 		}
 
 		// Without a base URI, we cannot do anything
@@ -65,15 +81,18 @@ This command sends a POST to SMD. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
 		var eis []smd.EthernetInterface
 		if cmd.Flag("payload").Changed {
 			// Use payload file if passed
 			handlePayload(cmd, &eis)
+		} else if cmd.Flag("template").Changed {
+			handleTemplate(cmd, &eis)
 		} else {
 			// ...otherwise use CLI options/args
+			var ei smd.EthernetInterface
 			var nets []smd.EthernetIP
 			for i := 2; i < len(args); i++ {
 				tokens := strings.SplitN(args[i], ",", 2)
@@ -87,12 +106,15 @@ This command sends a POST to SMD. An access token is required.`,
 				}
 				nets = append(nets, net)
 			}
-			ei := smd.EthernetInterface{
-				ComponentID: args[0],
-				Description: cmd.Flag("description").Value.String(),
-				MACAddress:  args[1],
-				IPAddresses: nets,
+			ei.Description = cmd.Flag("description").Value.String()
+			if len(args) > 0 {
+				ei.ComponentID = args[0]
+				ei.MACAddress = args[1]
+				ei.IPAddresses = nets
 			}
+
+			// Fields passed with --set override the above
+			handleSet(cmd, &ei)
 			eis = append(eis, ei)
 		}
 
@@ -102,9 +124,10 @@ This command sends a POST to SMD. An access token is required.`,
 			log.Logger.Error().Err(err).Msg("failed to add ethernet interface in SMD")
 			os.Exit(1)
 		}
+		exitIfInterrupted(errs)
+
 		// Since smdClient.PostEthernetInterfaces does the addition iteratively, we need to deal with
 		// each error that might have occurred.
-		var errorsOccurred = false
 		for _, err := range errs {
 			if err != nil {
 				if errors.Is(err, client.UnsuccessfulHTTPError) {
@@ -112,22 +135,23 @@ This command sends a POST to SMD. An access token is required.`,
 				} else {
 					log.Logger.Error().Err(err).Msg("failed to add ethernet interfaces to SMD")
 				}
-				errorsOccurred = true
 			}
 		}
-		if errorsOccurred {
-			log.Logger.Warn().Msg("SMD ethernet interface addition completed with errors")
-			os.Exit(1)
-		}
+		exitIfBulkFailed(errs, "SMD ethernet interface addition")
 	},
 }
 
 func init() {
 	ifaceAddCmd.Flags().StringP("description", "d", "Undescribed Ethernet Interface", "description of interface")
-	ifaceAddCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	ifaceAddCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	addSetFlag(ifaceAddCmd, "the interface")
+	ifaceAddCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	ifaceAddCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	ifaceAddCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
+	addTemplateFlags(ifaceAddCmd)
 
 	ifaceAddCmd.MarkFlagsMutuallyExclusive("description", "payload")
+	ifaceAddCmd.MarkFlagsMutuallyExclusive("set", "payload")
+	ifaceAddCmd.MarkFlagsMutuallyExclusive("description", "template")
 
 	ifaceCmd.AddCommand(ifaceAddCmd)
 }
@@ -0,0 +1,151 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// ifaceAuditCmd represents the smd-iface-audit command
+var ifaceAuditCmd = &cobra.Command{
+	Use:   "audit [--comp-type <type>,...] [--exit-code]",
+	Args:  cobra.NoArgs,
+	Short: "Check ethernet interfaces for conflicts",
+	Long: `Check ethernet interfaces for conflicts. All ethernet interfaces and components
+are fetched from SMD and the following are listed:
+
+  - MAC addresses claimed by more than one interface
+  - IP addresses claimed by more than one interface
+  - interfaces whose ComponentID is not a component in SMD
+  - components that no interface belongs to
+
+Pass --comp-type to only list components of the given types (e.g. Node) as
+having no interfaces. Nothing is modified.
+
+The problems are printed as text unless --output-format is passed, in which
+case they are printed in that format. If --exit-code is passed, the exit
+status is 2 if there are problems.`,
+	Example: `  ochami smd iface audit
+  ochami smd iface audit --comp-type Node --exit-code
+  ochami smd iface audit -F yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		smdBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to SMD
+		smdClient, err := smd.NewClient(smdBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		compTypes, err := cmd.Flags().GetStringSlice("comp-type")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --comp-type")
+			os.Exit(1)
+		}
+
+		ctx := context.Background()
+		if runner != nil {
+			ctx = runner.Context()
+		}
+		audit, err := smdClient.AuditEthernetInterfaces(ctx, compTypes, token)
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msg("SMD request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to audit ethernet interfaces")
+			}
+			os.Exit(1)
+		}
+
+		// Print output
+		if cmd.Flag("output-format").Changed {
+			outFmt, err := cmd.Flags().GetString("output-format")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+				os.Exit(1)
+			}
+			auditBytes, err := json.Marshal(audit)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to marshal ethernet interface audit")
+				os.Exit(1)
+			}
+			if outBytes, err := client.FormatBody(auditBytes, outFmt); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to format output")
+				os.Exit(1)
+			} else {
+				fmt.Print(string(outBytes))
+			}
+		} else {
+			writeIfaceAudit(os.Stdout, audit)
+		}
+
+		if cmd.Flag("exit-code").Changed && !audit.Empty() {
+			os.Exit(2)
+		}
+	},
+}
+
+// writeIfaceAudit writes audit to w as text, one section per kind of problem.
+// Sections without problems are omitted.
+func writeIfaceAudit(w io.Writer, audit smd.InterfaceAudit) {
+	if audit.Empty() {
+		fmt.Fprintln(w, "No problems found.")
+		return
+	}
+	if len(audit.DuplicateMACs) > 0 {
+		fmt.Fprintln(w, "MAC addresses claimed by more than one interface:")
+		for _, c := range audit.DuplicateMACs {
+			fmt.Fprintf(w, "  %s: %s\n", c.Value, strings.Join(c.InterfaceIDs, ", "))
+		}
+	}
+	if len(audit.DuplicateIPs) > 0 {
+		fmt.Fprintln(w, "IP addresses claimed by more than one interface:")
+		for _, c := range audit.DuplicateIPs {
+			fmt.Fprintf(w, "  %s: %s\n", c.Value, strings.Join(c.InterfaceIDs, ", "))
+		}
+	}
+	if len(audit.DanglingInterfaces) > 0 {
+		fmt.Fprintln(w, "Interfaces of components not in SMD:")
+		for _, d := range audit.DanglingInterfaces {
+			fmt.Fprintf(w, "  %s: %s\n", d.ID, d.ComponentID)
+		}
+	}
+	if len(audit.ComponentsWithoutInterfaces) > 0 {
+		fmt.Fprintln(w, "Components without interfaces:")
+		for _, id := range audit.ComponentsWithoutInterfaces {
+			fmt.Fprintf(w, "  %s\n", id)
+		}
+	}
+}
+
+func init() {
+	ifaceAuditCmd.Flags().StringSlice("comp-type", []string{}, "only list components of these types as having no interfaces")
+	ifaceAuditCmd.Flags().Bool("exit-code", false, "exit with status 2 if there are problems")
+	ifaceAuditCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+
+	ifaceCmd.AddCommand(ifaceAuditCmd)
+}
@@ -4,7 +4,9 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
@@ -14,7 +16,7 @@ import (
 
 // ifaceDeleteCmd represents the smd-iface-delete command
 var ifaceDeleteCmd = &cobra.Command{
-	Use:   "delete -f <payload_file> | --all | <iface_id>...",
+	Use:   "delete -f <payload_file> | --all | --where <filter> | <iface_id>...",
 	Short: "Delete one or more ethernet interfaces",
 	Long: `Delete one or more ethernet interfaces. These can be specified by one or more ethernet
 interface IDs (note this is not the same as a component xname). Alternatively,
@@ -22,10 +24,25 @@ pass -f to pass a file (optionally specifying --payload-format, JSON by default)
 containing the payload data. If - is used as the argument to -f, the data is
 read from standard input.
 
+Ethernet interfaces can also be selected with --where, which takes a filter in
+query string form using SMD's ethernet interface query parameters (e.g.
+'ComponentID=x3000c1s7b56n0&Type=Node'). The ethernet interfaces matching the
+filter are listed and, unless --force is passed, the user is asked to confirm
+before they are deleted.
+
+With --all, all ethernet interfaces in SMD are deleted. They are counted first
+and the number that will be deleted is shown before, unless --force is passed,
+the user is asked to confirm. If the count changes while the user is
+confirming, nothing is deleted. With --expect-count, the command fails without
+deleting anything if the count does not match the number passed, e.g. to guard
+scripts against deleting more than expected.
+
 This command sends a DELETE to SMD. An access token is required.`,
 	Example: `  ochami smd iface delete decafc0ffeee
   ochami smd iface delete decafc0ffeee de:ad:be:ee:ee:ef
   ochami smd iface delete --all
+  ochami smd iface delete --all --force --expect-count 4213
+  ochami smd iface delete --where 'ComponentID=x3000c1s7b56n0'
   ochami smd iface delete -f payload.json
   ochami smd iface delete -f payload.yaml --payload-format yaml
   echo '<json_data>' | ochami smd iface delete -f -
@@ -34,10 +51,11 @@ This command sends a DELETE to SMD. An access token is required.`,
 		// With options, only one of:
 		// - A payload file with -f
 		// - --all
+		// - --where
 		// - A set of one or more ethernet interface IDs
 		// must be passed.
 		if len(args) == 0 {
-			if !cmd.Flag("all").Changed && !cmd.Flag("payload").Changed {
+			if !cmd.Flag("all").Changed && !cmd.Flag("payload").Changed && !cmd.Flag("where").Changed {
 				err := cmd.Usage()
 				if err != nil {
 					log.Logger.Error().Err(err).Msg("failed to print usage")
@@ -47,6 +65,11 @@ This command sends a DELETE to SMD. An access token is required.`,
 			}
 		}
 
+		if cmd.Flag("expect-count").Changed && !cmd.Flag("all").Changed {
+			log.Logger.Error().Msg("--expect-count can only be used with --all")
+			os.Exit(1)
+		}
+
 		// Without a base URI, we cannot do anything
 		smdBaseURI, err := getBaseURI(cmd)
 		if err != nil {
@@ -65,15 +88,50 @@ This command sends a DELETE to SMD. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		// Create list of ethernet interface IDs to delete
+		var eIdSlice []string
+		if cmd.Flag("payload").Changed {
+			// Use payload file if passed
+			var eiSlice []smd.EthernetInterface
+			handlePayload(cmd, &eiSlice)
+			for _, ei := range eiSlice {
+				eIdSlice = append(eIdSlice, ei.ID)
+			}
+		} else if cmd.Flag("where").Changed {
+			// Look up the ethernet interfaces matching the filter so
+			// the user can see what will be deleted
+			filter := cmd.Flag("where").Value.String()
+			eIdSlice, err = smdClient.GetEthernetInterfaceIDsMatching(filter, token)
+			if err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msg("SMD ethernet interface request yielded unsuccessful HTTP response")
+				} else {
+					log.Logger.Error().Err(err).Msg("failed to get matching ethernet interfaces from SMD")
+				}
+				os.Exit(1)
+			}
+			if len(eIdSlice) == 0 {
+				log.Logger.Info().Msgf("no ethernet interfaces match filter %q, nothing to delete", filter)
+				os.Exit(0)
+			}
+			fmt.Fprintf(os.Stderr, "Ethernet interfaces matching %q:\n  %s\n", filter, strings.Join(eIdSlice, "\n  "))
+		} else {
+			// ...otherwise, use passed CLI arguments
+			eIdSlice = args
+		}
 
-		// Ask before attempting deletion unless --force was passed
-		if !cmd.Flag("force").Changed {
+		// Ask before attempting deletion unless --force was passed,
+		// telling the user how many resources --all deletes
+		if cmd.Flag("all").Changed {
+			confirmDeleteAll(cmd, "ethernet interfaces", func() (int, error) { return smdClient.CountEthernetInterfaces(token) })
+		} else if !cmd.Flag("force").Changed {
 			log.Logger.Debug().Msg("--force not passed, prompting user to confirm deletion")
 			var respDelete bool
-			if cmd.Flag("all").Changed {
-				respDelete = loopYesNo("Really delete ALL ETHERNET INTERFACES?")
+			if cmd.Flag("where").Changed {
+				respDelete = loopYesNo(fmt.Sprintf("Really delete these %d ethernet interfaces?", len(eIdSlice)))
 			} else {
 				respDelete = loopYesNo("Really delete?")
 			}
@@ -85,17 +143,6 @@ This command sends a DELETE to SMD. An access token is required.`,
 			}
 		}
 
-		// Create list of ethernet interface IDs to delete
-		var eiSlice []smd.EthernetInterface
-		var eIdSlice []string
-		if cmd.Flag("payload").Changed {
-			// Use payload file if passed
-			handlePayload(cmd, &eiSlice)
-		} else {
-			// ...otherwise, use passed CLI arguments
-			eIdSlice = args
-		}
-
 		// Perform deletion
 		if cmd.Flag("all").Changed {
 			// If --all passed, we don't care about any passed arguments
@@ -115,32 +162,34 @@ This command sends a DELETE to SMD. An access token is required.`,
 				log.Logger.Error().Err(err).Msg("failed to delete ethernet interfaces in SMD")
 				os.Exit(1)
 			}
+			exitIfInterrupted(errs)
+
 			// Since smdClient.DeleteEthernetInterfaces does the deletion iteratively, we need to deal
 			// with each error that might have occurred.
-			var errorsOccurred = false
 			for _, e := range errs {
-				if err != nil {
+				if e != nil {
 					if errors.Is(e, client.UnsuccessfulHTTPError) {
 						log.Logger.Error().Err(e).Msg("SMD ethernet interface deletion yielded unsuccessful HTTP response")
 					} else {
 						log.Logger.Error().Err(e).Msg("failed to delete ethernet interfaces")
 					}
-					errorsOccurred = true
 				}
 			}
 			// Warn the user if any errors occurred during deletion iterations
-			if errorsOccurred {
-				log.Logger.Warn().Msg("SMD ethernet interface deletion completed with errors")
-				os.Exit(1)
-			}
+			exitIfBulkFailed(errs, "SMD ethernet interface deletion")
 		}
 	},
 }
 
 func init() {
 	ifaceDeleteCmd.Flags().BoolP("all", "a", false, "delete all ethernet interfaces in SMD")
-	ifaceDeleteCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	ifaceDeleteCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	ifaceDeleteCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	ifaceDeleteCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	ifaceDeleteCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
+	ifaceDeleteCmd.Flags().String("where", "", "delete ethernet interfaces matching filter in query string form (e.g. 'ComponentID=x3000c1s7b56n0')")
 	ifaceDeleteCmd.Flags().Bool("force", false, "do not ask before attempting deletion")
+	ifaceDeleteCmd.Flags().Int("expect-count", 0, "with --all, only delete if this is the number of ethernet interfaces that would be deleted")
+
+	ifaceDeleteCmd.MarkFlagsMutuallyExclusive("all", "payload", "where")
 	ifaceCmd.AddCommand(ifaceDeleteCmd)
 }
@@ -0,0 +1,96 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// ifaceExportCmd represents the smd-iface-export command
+var ifaceExportCmd = &cobra.Command{
+	Use:   "export",
+	Args:  cobra.NoArgs,
+	Short: "Export all ethernet interfaces",
+	Long: `Export all ethernet interfaces in SMD as a list that can be edited, kept in
+version control, and imported again with 'ochami smd iface import'. Only the
+fields that can be imported are written and interfaces are sorted by MAC
+address, so that exports of the same interfaces are identical.
+
+Use --output-file to write the list to a file instead of standard output.`,
+	Example: `  ochami smd iface export -o eis.json
+  ochami smd iface export -F yaml -o eis.yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		smdBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to SMD
+		smdClient, err := smd.NewClient(smdBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		ctx := context.Background()
+		if runner != nil {
+			ctx = runner.Context()
+		}
+		eis, err := smdClient.EthernetInterfacePager("", token).All(ctx)
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msg("SMD ethernet interface request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to request ethernet interfaces from SMD")
+			}
+			os.Exit(1)
+		}
+		sort.Slice(eis, func(i, j int) bool { return eis[i].MACAddress < eis[j].MACAddress })
+		if eis == nil {
+			eis = []smd.EthernetInterface{}
+		}
+
+		outFmt, err := cmd.Flags().GetString("output-format")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+			os.Exit(1)
+		}
+		eisBytes, err := json.Marshal(eis)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to marshal ethernet interfaces")
+			os.Exit(1)
+		}
+		if outBytes, err := client.FormatBody(eisBytes, outFmt); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to format output")
+			os.Exit(1)
+		} else {
+			fmt.Print(string(outBytes))
+		}
+		log.Logger.Info().Msgf("exported %d ethernet interface(s)", len(eis))
+	},
+}
+
+func init() {
+	ifaceExportCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+
+	ifaceCmd.AddCommand(ifaceExportCmd)
+}
@@ -4,13 +4,14 @@ package cmd
 
 import (
 	"errors"
-	"fmt"
-	"net/url"
 	"os"
+	"time"
 
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
 	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/OpenCHAMI/ochami/pkg/flagtypes"
+	"github.com/OpenCHAMI/ochami/pkg/format"
 	"github.com/spf13/cobra"
 )
 
@@ -21,7 +22,19 @@ var ifaceGetCmd = &cobra.Command{
 	Short: "Get some or all ethernet interfaces",
 	Long: `Get some or all ethernet interfaces optionally based on filter(s). If no options are
 passed, all ethernet interfaces are returned. Optionally, options can be passed to limit the
-ethernet interfaces returned.`,
+ethernet interfaces returned.
+
+Each filter flag can be passed more than once or with a comma-separated list
+of values, and an interface matches if it matches any of them. Interfaces
+must match all of the filter flags passed. MAC addresses can be written with
+any separators (or none). --component-id also accepts glob patterns (e.g.
+x1000c1s7b*n0) and --ip also accepts CIDR prefixes (e.g. 10.1.0.0/16). SMD
+cannot filter on these, so they are matched against all interfaces that SMD
+returns for the other filters.`,
+	Example: `  ochami smd iface get --component-id x1000c1s7b0n0
+  ochami smd iface get --mac de:ad:be:ef:00:01,DEADBEEF0002
+  ochami smd iface get --ip 172.16.0.0/24 --network NMN
+  ochami smd iface get --component-id 'x1000c1s7b*n0' --type Node`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Without a base URI, we cannot do anything
 		smdBaseURI, err := getBaseURI(cmd)
@@ -37,8 +50,8 @@ ethernet interfaces returned.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
 		// Deal with --id
 		if cmd.Flag("id").Changed {
@@ -58,95 +71,57 @@ ethernet interfaces returned.`,
 			httpEnv, err := smdClient.GetEthernetInterfaceByID(id, token, byIP)
 			if err != nil {
 				if errors.Is(err, client.UnsuccessfulHTTPError) {
-					log.Logger.Error().Err(err).Msg("SMD ethernet interface request by ID yielded unsuccessful HTTP response")
+					logHTTPError(err, httpEnv, "SMD ethernet interface request by ID yielded unsuccessful HTTP response")
 				} else {
 					log.Logger.Error().Err(err).Msg("failed to request ethernet interfaces by ID from SMD")
 				}
 				os.Exit(1)
 			}
-			fmt.Println(string(httpEnv.Body))
-			os.Exit(0)
+			printEnvelope(cmd, httpEnv)
+			return
 		} else if cmd.Flag("by-ip").Changed {
 			log.Logger.Error().Msg("--by-ip can only be used with --id")
 			os.Exit(1)
 		}
 
 		// All other cases
-		qstr := ""
-		if cmd.Flag("mac").Changed || cmd.Flag("ip").Changed || cmd.Flag("net").Changed || cmd.Flag("comp-id").Changed ||
-			cmd.Flag("type").Changed || cmd.Flag("older-than").Changed || cmd.Flag("newer-than").Changed {
-			values := url.Values{}
-			if cmd.Flag("mac").Changed {
-				s, err := cmd.Flags().GetStringSlice("mac")
-				if err != nil {
-					log.Logger.Error().Err(err).Msg("unable to fetch macs")
-					os.Exit(1)
-				}
-				for _, m := range s {
-					values.Add("MACAddress", m)
-				}
-			}
-			if cmd.Flag("ip").Changed {
-				s, err := cmd.Flags().GetStringSlice("ip")
-				if err != nil {
-					log.Logger.Error().Err(err).Msg("unable to fetch IPs")
-					os.Exit(1)
-				}
-				for _, i := range s {
-					values.Add("IPAddress", i)
-				}
-			}
-			if cmd.Flag("net").Changed {
-				s, err := cmd.Flags().GetStringSlice("net")
-				if err != nil {
-					log.Logger.Error().Err(err).Msg("unable to fetch networks")
-					os.Exit(1)
-				}
-				for _, n := range s {
-					values.Add("Network", n)
-				}
-			}
-			if cmd.Flag("comp-id").Changed {
-				s, err := cmd.Flags().GetStringSlice("comp-id")
-				if err != nil {
-					log.Logger.Error().Err(err).Msg("unable to fetch component IDs")
-					os.Exit(1)
-				}
-				for _, c := range s {
-					values.Add("ComponentID", c)
-				}
-			}
-			if cmd.Flag("type").Changed {
-				s, err := cmd.Flags().GetStringSlice("type")
-				if err != nil {
-					log.Logger.Error().Err(err).Msg("unable to fetch type")
-					os.Exit(1)
-				}
-				for _, t := range s {
-					values.Add("Type", t)
-				}
-			}
-			if cmd.Flag("older-than").Changed {
-				s, err := cmd.Flags().GetString("older-than")
+		var filter smd.EthernetInterfaceFilter
+		for _, f := range []struct {
+			flags []string
+			dst   *[]string
+		}{
+			{[]string{"component-id", "comp-id"}, &filter.ComponentIDs},
+			{[]string{"mac"}, &filter.MACs},
+			{[]string{"ip"}, &filter.IPs},
+			{[]string{"network", "net"}, &filter.Networks},
+			{[]string{"type"}, &filter.Types},
+		} {
+			for _, name := range f.flags {
+				s, err := cmd.Flags().GetStringSlice(name)
 				if err != nil {
-					log.Logger.Error().Err(err).Msg("unable to fetch older-than timestamp")
+					log.Logger.Error().Err(err).Msgf("unable to fetch value of --%s", name)
 					os.Exit(1)
 				}
-				values.Add("OlderThan", s)
+				*f.dst = append(*f.dst, s...)
 			}
-			if cmd.Flag("newer-than").Changed {
-				s, err := cmd.Flags().GetString("newer-than")
-				if err != nil {
-					log.Logger.Error().Err(err).Msg("unable to fetch newer-than timestamp")
-					os.Exit(1)
-				}
-				values.Add("NewerThan", s)
+		}
+		for _, f := range []struct {
+			flag string
+			dst  *string
+		}{{"older-than", &filter.OlderThan}, {"newer-than", &filter.NewerThan}} {
+			if t, _ := flagtypes.GetTime(cmd.Flags(), f.flag); !t.IsZero() {
+				*f.dst = t.UTC().Format(time.RFC3339)
 			}
 		}
-		httpEnv, err := smdClient.GetEthernetInterfaces(qstr)
+		if err := filter.Validate(); err != nil {
+			log.Logger.Error().Err(err).Msg("invalid filter")
+			os.Exit(1)
+		}
+
+		httpEnv, err := smdClient.GetEthernetInterfaces(filter)
 		if err != nil {
 			if errors.Is(err, client.UnsuccessfulHTTPError) {
-				log.Logger.Error().Err(err).Msg("SMD ethernet interface request yielded unsuccessful HTTP response")
+				logHTTPError(err, httpEnv, "SMD ethernet interface request yielded unsuccessful HTTP response")
 			} else {
 				log.Logger.Error().Err(err).Msg("failed to request ethernet interfaces from SMD")
 			}
@@ -154,17 +129,7 @@ ethernet interfaces returned.`,
 		}
 
 		// Print output
-		outFmt, err := cmd.Flags().GetString("output-format")
-		if err != nil {
-			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
-			os.Exit(1)
-		}
-		if outBytes, err := client.FormatBody(httpEnv.Body, outFmt); err != nil {
-			log.Logger.Error().Err(err).Msg("failed to format output")
-			os.Exit(1)
-		} else {
-			fmt.Printf(string(outBytes))
-		}
+		printEnvelope(cmd, httpEnv)
 	},
 }
 
@@ -172,25 +137,35 @@ func init() {
 	ifaceGetCmd.Flags().StringP("id", "i", "", "get an ethernet interface by its ID")
 	ifaceGetCmd.Flags().Bool("by-ip", false, "get all IP addresses for an ethernet interface (used with --id)")
 	ifaceGetCmd.Flags().StringSliceP("mac", "m", []string{}, "filter ethernet interfaces by mac address")
-	ifaceGetCmd.Flags().StringSlice("ip", []string{}, "filter ethernet interfaces by IP address")
+	ifaceGetCmd.Flags().StringSlice("ip", []string{}, "filter ethernet interfaces by IP address or CIDR prefix")
+	ifaceGetCmd.Flags().StringSlice("network", []string{}, "filter ethernet interfaces by IP on given network")
+	ifaceGetCmd.Flags().StringSlice("component-id", []string{}, "filter ethernet interfaces by component ID or glob pattern")
 	ifaceGetCmd.Flags().StringSlice("net", []string{}, "filter ethernet interfaces by IP on given network")
 	ifaceGetCmd.Flags().StringSlice("comp-id", []string{}, "filter ethernet interfaces by component ID")
+	setFlagDeprecated(ifaceGetCmd, "net", deprecation{Replacement: "--network"})
+	setFlagDeprecated(ifaceGetCmd, "comp-id", deprecation{Replacement: "--component-id"})
 	ifaceGetCmd.Flags().StringSlice("type", []string{}, "filter ethernet interfaces by type")
-	ifaceGetCmd.Flags().String("older-than", "", "filter ethernet interfaces by update time older than specified time (RFC3339-formatted)")
-	ifaceGetCmd.Flags().String("newer-than", "", "filter ethernet interfaces by update time older than specified time (RFC3339-formatted)")
+	addTimeFlag(ifaceGetCmd, "older-than", "filter ethernet interfaces by update time older than specified time (RFC 3339, date, -24h, ...)")
+	addTimeFlag(ifaceGetCmd, "newer-than", "filter ethernet interfaces by update time newer than specified time (RFC 3339, date, -24h, ...)")
 	ifaceGetCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	addTableFormat(ifaceGetCmd, format.KindEthernetInterfaces)
+	addListFlags(ifaceGetCmd)
 
 	ifaceGetCmd.MarkFlagsMutuallyExclusive("id", "mac")
 	ifaceGetCmd.MarkFlagsMutuallyExclusive("id", "ip")
 	ifaceGetCmd.MarkFlagsMutuallyExclusive("id", "net")
+	ifaceGetCmd.MarkFlagsMutuallyExclusive("id", "network")
 	ifaceGetCmd.MarkFlagsMutuallyExclusive("id", "comp-id")
+	ifaceGetCmd.MarkFlagsMutuallyExclusive("id", "component-id")
 	ifaceGetCmd.MarkFlagsMutuallyExclusive("id", "type")
 	ifaceGetCmd.MarkFlagsMutuallyExclusive("id", "older-than")
 	ifaceGetCmd.MarkFlagsMutuallyExclusive("id", "newer-than")
 	ifaceGetCmd.MarkFlagsMutuallyExclusive("by-ip", "mac")
 	ifaceGetCmd.MarkFlagsMutuallyExclusive("by-ip", "ip")
 	ifaceGetCmd.MarkFlagsMutuallyExclusive("by-ip", "net")
+	ifaceGetCmd.MarkFlagsMutuallyExclusive("by-ip", "network")
 	ifaceGetCmd.MarkFlagsMutuallyExclusive("by-ip", "comp-id")
+	ifaceGetCmd.MarkFlagsMutuallyExclusive("by-ip", "component-id")
 	ifaceGetCmd.MarkFlagsMutuallyExclusive("by-ip", "type")
 	ifaceGetCmd.MarkFlagsMutuallyExclusive("by-ip", "older-than")
 	ifaceGetCmd.MarkFlagsMutuallyExclusive("by-ip", "newer-than")
@@ -0,0 +1,151 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/spf13/cobra"
+)
+
+// ifaceImportCmd represents the smd-iface-import command
+var ifaceImportCmd = &cobra.Command{
+	Use:   "import -f <payload_file> [--merge-strategy replace|merge-ips|skip-existing]",
+	Args:  cobra.NoArgs,
+	Short: "Import ethernet interfaces, merging them with existing ones",
+	Long: `Import ethernet interfaces from a list such as the one written by 'ochami smd
+iface export'. If - is used as the argument to -f, the list is read from
+standard input.
+
+Interfaces are matched with the ones in SMD by MAC address, regardless of
+case and separators. Interfaces whose MAC addresses are not in SMD are
+added. What happens to those whose MAC addresses are depends on
+--merge-strategy:
+
+  replace        The component ID, description, and IP addresses of the
+                 existing interface are replaced with the imported ones.
+  merge-ips      The imported IP addresses that the existing interface does
+                 not have are added to it, keeping the ones it has. Its
+                 component ID and description are replaced with the
+                 imported ones unless those are empty. This is the default.
+  skip-existing  The existing interface is left as it is.
+
+Existing interfaces that would not change are not sent to SMD, so importing
+the same list twice changes nothing the second time. Use --plan-only to see
+what would be sent first.
+
+This command sends a POST to SMD for each interface to add and a PATCH for
+each interface to update. An access token is required.`,
+	Example: `  ochami smd iface import -f eis.json
+  ochami smd iface import -f eis.yaml --payload-format yaml --merge-strategy replace
+  ochami smd iface export | ochami --cluster new smd iface import -f - --merge-strategy skip-existing`,
+	Run: func(cmd *cobra.Command, args []string) {
+		strategy, err := smd.ParseMergeStrategy(cmd.Flag("merge-strategy").Value.String())
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("invalid value for --merge-strategy")
+			os.Exit(1)
+		}
+		var incoming []smd.EthernetInterface
+		handlePayload(cmd, &incoming)
+		if len(incoming) == 0 {
+			log.Logger.Warn().Msg("no ethernet interfaces to import")
+			os.Exit(0)
+		}
+
+		// Without a base URI, we cannot do anything
+		smdBaseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI for SMD")
+			os.Exit(1)
+		}
+
+		// This endpoint requires authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		// Create client to make request to SMD
+		smdClient, err := smd.NewClient(smdBaseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		ctx := context.Background()
+		if runner != nil {
+			ctx = runner.Context()
+		}
+		existing, err := smdClient.EthernetInterfacePager("", token).All(ctx)
+		if err != nil {
+			if errors.Is(err, client.UnsuccessfulHTTPError) {
+				log.Logger.Error().Err(err).Msg("SMD ethernet interface request yielded unsuccessful HTTP response")
+			} else {
+				log.Logger.Error().Err(err).Msg("failed to request ethernet interfaces from SMD")
+			}
+			os.Exit(1)
+		}
+		plan, err := smd.PlanInterfaceImport(existing, incoming, strategy)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to import ethernet interfaces")
+			os.Exit(1)
+		}
+		if len(plan.Skipped) > 0 {
+			log.Logger.Info().Msgf("skipping existing ethernet interface(s): %s", strings.Join(plan.Skipped, ", "))
+		}
+		log.Logger.Info().Msgf("ethernet interfaces: %d to add, %d to update, %d unchanged, %d skipped",
+			len(plan.Add), len(plan.Update), len(plan.Unchanged), len(plan.Skipped))
+
+		// Send off requests
+		var errs []error
+		if len(plan.Add) > 0 {
+			_, postErrs, err := smdClient.PostEthernetInterfaces(plan.Add, token)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to add ethernet interfaces to SMD")
+				os.Exit(1)
+			}
+			errs = append(errs, postErrs...)
+		}
+		if len(plan.Update) > 0 {
+			_, patchErrs, err := smdClient.PatchEthernetInterfaces(plan.Update, token)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to update ethernet interfaces in SMD")
+				os.Exit(1)
+			}
+			errs = append(errs, patchErrs...)
+		}
+		exitIfInterrupted(errs)
+
+		// Since smdClient.PostEthernetInterfaces and
+		// smdClient.PatchEthernetInterfaces do the requests iteratively, we
+		// need to deal with each error that might have occurred.
+		for _, err := range errs {
+			if err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msg("SMD ethernet interface request yielded unsuccessful HTTP response")
+				} else {
+					log.Logger.Error().Err(err).Msg("failed to import ethernet interfaces into SMD")
+				}
+			}
+		}
+		exitIfBulkFailed(errs, "SMD ethernet interface import")
+	},
+}
+
+func init() {
+	ifaceImportCmd.Flags().StringP("payload", "f", "", "file or URL containing the interfaces to import; JSON format unless --payload-format specified")
+	ifaceImportCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	ifaceImportCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
+	ifaceImportCmd.Flags().String("merge-strategy", string(smd.MergeIPs), "what to do with interfaces whose MAC addresses are already in SMD ("+strings.Join(smd.MergeStrategies, ",")+")")
+	ifaceImportCmd.MarkFlagRequired("payload")
+	_ = ifaceImportCmd.RegisterFlagCompletionFunc("merge-strategy", cobra.FixedCompletions(smd.MergeStrategies, cobra.ShellCompDirectiveNoFileComp))
+
+	ifaceCmd.AddCommand(ifaceImportCmd)
+}
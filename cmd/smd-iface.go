@@ -11,9 +11,10 @@ import (
 
 // ifaceCmd represents the smd-iface command
 var ifaceCmd = &cobra.Command{
-	Use:   "iface",
-	Args:  cobra.NoArgs,
-	Short: "Manage ethernet interfaces",
+	Use:     "iface",
+	Aliases: []string{"ethernet-interface"},
+	Args:    cobra.NoArgs,
+	Short:   "Manage ethernet interfaces",
 	Long: `Manage ethernet interfaces. This is a metacommand. Commands under this one
 interact with the State Management Database (SMD).`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -15,31 +15,53 @@ import (
 
 // rfeAddCmd represents the smd-rfe-add command
 var rfeAddCmd = &cobra.Command{
-	Use:   "add -f <payload_file> | (<xname> <name> <ip_addr> <mac_addr>)",
+	Use:   "add -f <payload_file> | --template <template> --var-file <csv_file> | ([--set <field>=<value>]... [<xname> <name> <ip_addr> <mac_addr>])",
 	Short: "Add new redfish endpoint(s)",
 	Long: `Add new redfish endpoint(s). An xname, name, IP address, and MAC address are required
 unless -f is passed to read from a payload file. Specifying -f also is
 mutually exclusive with the other flags of this command and its arguments.
 If - is used as the argument to -f, the data is read from standard input.
 
+Any field of the redfish endpoint can be set with --set <field>=<value>,
+using the field names of the payload (e.g. ID, FQDN, or RediscoverOnUpdate),
+instead of passing arguments. Values are parsed as YAML.
+
+Many similar redfish endpoints can be added at once by passing a template of
+one redfish endpoint in --payload-format with --template (as is, or as
+@<path> to read it from a file) and a CSV file with --var-file. The template
+is rendered once per row, with the columns named by the header of the CSV
+file as its variables (e.g. {{.xname}}).
+
+Redfish endpoints are sent using SMD's V2 schema so that SMD creates
+components and interfaces from their Systems and Managers. Redfish
+endpoints without a SchemaVersion have it set, and those without Managers
+get one derived from their BMC xname, MAC address, and IP address. A
+warning is printed for redfish endpoints without Systems, since SMD creates
+no node components for them.
+
 This command sends a POST to SMD. An access token is required.`,
 	Example: `  ochami smd rfe add x3000c1s7b56 bmc-node56 172.16.0.156 de:ca:fc:0f:fe:ee
+  ochami smd rfe add --set ID=x3000c1s7b56 --set FQDN=172.16.0.156 --set MACAddr=de:ca:fc:0f:fe:ee
   ochami smd rfe add -f payload.json
+  ochami smd rfe add --template @rfe.tmpl --var-file bmcs.csv
   ochami smd rfe add -f payload.yaml --payload-format yaml
   echo '<json_data>' | ochami smd rfe add -f -
   echo '<yaml_data>' | ochami smd rfe add -f - --payload-format yaml`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Check that all required args are passed
-		if len(args) == 0 && !cmd.Flag("payload").Changed {
+		if len(args) == 0 && !cmd.Flag("payload").Changed && !cmd.Flag("set").Changed && !cmd.Flag("template").Changed {
 			err := cmd.Usage()
 			if err != nil {
 				log.Logger.Error().Err(err).Msg("failed to print usage")
 				os.Exit(1)
 			}
 			os.Exit(0)
-		} else if len(args) > 4 {
+		} else if !cmd.Flag("template").Changed && !cmd.Flag("payload").Changed && len(args) != 4 && (len(args) != 0 || !cmd.Flag("set").Changed) {
 			log.Logger.Error().Msgf("expected 4 arguments (xname, name, ip_addr, mac_addr) but got %d: %v", len(args), args)
 			os.Exit(1)
+		} else if cmd.Flag("template").Changed && len(args) > 0 {
+			log.Logger.Error().Msgf("expected no arguments with --template but got %d: %v", len(args), args)
+			os.Exit(1)
 		}
 
 		// Without a base URI, we cannot do anything
@@ -60,20 +82,25 @@ This command sends a POST to SMD. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
-		var rfes smd.RedfishEndpointSlice
+		var rfes smd.RedfishEndpointSliceV2
 		if cmd.Flag("payload").Changed {
 			// Use payload file if passed
 			handlePayload(cmd, &rfes.RedfishEndpoints)
+		} else if cmd.Flag("template").Changed {
+			handleTemplate(cmd, &rfes.RedfishEndpoints)
 		} else {
 			// ...otherwise use CLI options/args
-			rfe := csm.RedfishEndpoint{
-				ID:        args[0],
-				Name:      args[1],
-				IPAddress: args[2],
-				MACAddr:   args[3],
+			var rfe smd.RedfishEndpointV2
+			if len(args) == 4 {
+				rfe.RedfishEndpoint = csm.RedfishEndpoint{
+					ID:        args[0],
+					Name:      args[1],
+					IPAddress: args[2],
+					MACAddr:   args[3],
+				}
 			}
 			if cmd.Flag("domain").Changed {
 				if rfe.Domain, err = cmd.Flags().GetString("domain"); err != nil {
@@ -99,18 +126,34 @@ This command sends a POST to SMD. An access token is required.`,
 					os.Exit(1)
 				}
 			}
+
+			// Fields passed with --set override the above
+			handleSet(cmd, &rfe)
 			rfes.RedfishEndpoints = append(rfes.RedfishEndpoints, rfe)
 		}
 
+		// Fill in what SMD needs to handle the redfish endpoints as V2
+		for i := range rfes.RedfishEndpoints {
+			warnings, err := smd.NormalizeRedfishEndpointV2(&rfes.RedfishEndpoints[i])
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("invalid redfish endpoint")
+				os.Exit(1)
+			}
+			for _, w := range warnings {
+				log.Logger.Warn().Msg(w)
+			}
+		}
+
 		// Send off request
-		_, errs, err := smdClient.PostRedfishEndpoints(rfes, token)
+		_, errs, err := smdClient.PostRedfishEndpointsV2(rfes, token)
 		if err != nil {
 			log.Logger.Error().Err(err).Msg("failed to add redfish endpoint in SMD")
 			os.Exit(1)
 		}
-		// Since smdClient.PostRedfishEndpoints does the addition iteratively, we need to deal with
+		exitIfInterrupted(errs)
+
+		// Since smdClient.PostRedfishEndpointsV2 does the addition iteratively, we need to deal with
 		// each error that might have occurred.
-		var errorsOccurred = false
 		for _, err := range errs {
 			if err != nil {
 				if errors.Is(err, client.UnsuccessfulHTTPError) {
@@ -118,13 +161,9 @@ This command sends a POST to SMD. An access token is required.`,
 				} else {
 					log.Logger.Error().Err(err).Msg("failed to add redfish endpoint(s) to SMD")
 				}
-				errorsOccurred = true
 			}
 		}
-		if errorsOccurred {
-			log.Logger.Warn().Msg("SMD redfish endpoint addition completed with errors")
-			os.Exit(1)
-		}
+		exitIfBulkFailed(errs, "SMD redfish endpoint addition")
 	},
 }
 
@@ -133,13 +172,21 @@ func init() {
 	rfeAddCmd.Flags().String("hostname", "", "hostname of redfish endpoint's FQDN")
 	rfeAddCmd.Flags().String("username", "", "username to use when interrogating endpoint")
 	rfeAddCmd.Flags().String("password", "", "password to use when interrogating endpoint")
-	rfeAddCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	rfeAddCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	addSetFlag(rfeAddCmd, "the redfish endpoint")
+	rfeAddCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	rfeAddCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	rfeAddCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
+	addTemplateFlags(rfeAddCmd)
 
 	rfeAddCmd.MarkFlagsMutuallyExclusive("domain", "payload")
 	rfeAddCmd.MarkFlagsMutuallyExclusive("hostname", "payload")
 	rfeAddCmd.MarkFlagsMutuallyExclusive("username", "payload")
 	rfeAddCmd.MarkFlagsMutuallyExclusive("password", "payload")
+	rfeAddCmd.MarkFlagsMutuallyExclusive("set", "payload")
+	rfeAddCmd.MarkFlagsMutuallyExclusive("domain", "template")
+	rfeAddCmd.MarkFlagsMutuallyExclusive("hostname", "template")
+	rfeAddCmd.MarkFlagsMutuallyExclusive("username", "template")
+	rfeAddCmd.MarkFlagsMutuallyExclusive("password", "template")
 
 	rfeCmd.AddCommand(rfeAddCmd)
 }
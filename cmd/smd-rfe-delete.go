@@ -21,10 +21,18 @@ Alternatively, use -f to read the payload data from a file (optionally
 specifying --payload-format, JSON by default). If - is used as the
 argument to -f, the data is read from standard input.
 
+With --all, all redfish endpoints in SMD are deleted. They are counted first
+and the number that will be deleted is shown before, unless --force is passed,
+the user is asked to confirm. If the count changes while the user is
+confirming, nothing is deleted. With --expect-count, the command fails without
+deleting anything if the count does not match the number passed, e.g. to guard
+scripts against deleting more than expected.
+
 This command sends a DELETE to SMD. An access token is required.`,
 	Example: `  ochami smd rfe delete x3000c1s7b56
   ochami smd rfe delete x3000c1s7b56 x3000c1s7b56
   ochami smd rfe delete --all
+  ochami smd rfe delete --all --force --expect-count 4213
   ochami smd rfe delete -f payload.json
   ochami smd rfe delete -f payload.yaml --payload-format yaml
   echo '<json_data>' | ochami smd rfe delete -f -
@@ -46,6 +54,11 @@ This command sends a DELETE to SMD. An access token is required.`,
 			}
 		}
 
+		if cmd.Flag("expect-count").Changed && !cmd.Flag("all").Changed {
+			log.Logger.Error().Msg("--expect-count can only be used with --all")
+			os.Exit(1)
+		}
+
 		// Without a base URI, we cannot do anything
 		smdBaseURI, err := getBaseURI(cmd)
 		if err != nil {
@@ -64,18 +77,16 @@ This command sends a DELETE to SMD. An access token is required.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
-		// Ask before attempting deletion unless --force was passed
-		if !cmd.Flag("force").Changed {
+		// Ask before attempting deletion unless --force was passed,
+		// telling the user how many resources --all deletes
+		if cmd.Flag("all").Changed {
+			confirmDeleteAll(cmd, "redfish endpoints", func() (int, error) { return smdClient.CountRedfishEndpoints(token) })
+		} else if !cmd.Flag("force").Changed {
 			log.Logger.Debug().Msg("--force not passed, prompting user to confirm deletion")
-			var respDelete bool
-			if cmd.Flag("all").Changed {
-				respDelete = loopYesNo("Really delete ALL REDFISH ENDPOINTS?")
-			} else {
-				respDelete = loopYesNo("Really delete?")
-			}
+			respDelete := loopYesNo("Really delete?")
 			if !respDelete {
 				log.Logger.Info().Msg("User aborted redfish endpoint deletion")
 				os.Exit(0)
@@ -114,33 +125,32 @@ This command sends a DELETE to SMD. An access token is required.`,
 				log.Logger.Error().Err(err).Msg("failed to delete redfish endpoints in SMD")
 				os.Exit(1)
 			}
+			exitIfInterrupted(errs)
+
 			// Since smdClient.DeleteRedfishEndpoints does the deletion iteratively, we need to deal with
 			// each error that might have occurred.
-			var errorsOccurred = false
 			for _, e := range errs {
-				if err != nil {
+				if e != nil {
 					if errors.Is(e, client.UnsuccessfulHTTPError) {
 						log.Logger.Error().Err(e).Msg("SMD redfish endpoint deletion yielded unsuccessful HTTP response")
 					} else {
 						log.Logger.Error().Err(e).Msg("failed to delete redfish endpoint")
 					}
-					errorsOccurred = true
 				}
 			}
 			// Warn the user if any errors occurred during deletion iterations
-			if errorsOccurred {
-				log.Logger.Warn().Msg("SMD redfish endpoint deletion completed with errors")
-				os.Exit(1)
-			}
+			exitIfBulkFailed(errs, "SMD redfish endpoint deletion")
 		}
 	},
 }
 
 func init() {
 	rfeDeleteCmd.Flags().BoolP("all", "a", false, "delete all redfish endpoints in SMD")
-	rfeDeleteCmd.Flags().StringP("payload", "f", "", "file containing the request payload; JSON format unless --payload-format specified")
-	rfeDeleteCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (yaml,json) passed with --payload")
+	rfeDeleteCmd.Flags().StringP("payload", "f", "", "file or URL containing the request payload; JSON format unless --payload-format specified")
+	rfeDeleteCmd.Flags().String("payload-format", defaultPayloadFormat, "format of payload file (json,yaml,toml) passed with --payload")
+	rfeDeleteCmd.Flags().Bool("payload-insecure", false, "do not verify TLS certificate when --payload is an https:// URL")
 	rfeDeleteCmd.Flags().Bool("force", false, "do not ask before attempting deletion")
+	rfeDeleteCmd.Flags().Int("expect-count", 0, "with --all, only delete if this is the number of redfish endpoints that would be deleted")
 
 	rfeCmd.AddCommand(rfeDeleteCmd)
 }
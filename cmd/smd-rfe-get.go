@@ -4,13 +4,13 @@ package cmd
 
 import (
 	"errors"
-	"fmt"
 	"net/url"
 	"os"
 
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
 	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/OpenCHAMI/ochami/pkg/format"
 	"github.com/spf13/cobra"
 )
 
@@ -41,14 +41,13 @@ endpoints returned.`,
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
 
 		// If no ID flags are specified, get all redfish endpoints
-		qstr := ""
+		values := url.Values{}
 		if cmd.Flag("xname").Changed || cmd.Flag("mac").Changed || cmd.Flag("ip").Changed ||
 			cmd.Flag("fqdn").Changed || cmd.Flag("type").Changed || cmd.Flag("uuid").Changed {
-			values := url.Values{}
 			if cmd.Flag("xname").Changed {
 				s, err := cmd.Flags().GetStringSlice("xname")
 				if err != nil {
@@ -109,12 +108,11 @@ endpoints returned.`,
 					values.Add("uuid", u)
 				}
 			}
-			qstr = values.Encode()
 		}
-		httpEnv, err := smdClient.GetRedfishEndpoints(qstr, token)
+		httpEnv, err := smdClient.GetRedfishEndpointsValues(values, token)
 		if err != nil {
 			if errors.Is(err, client.UnsuccessfulHTTPError) {
-				log.Logger.Error().Err(err).Msg("SMD redfish endpoint request yielded unsuccessful HTTP response")
+				logHTTPError(err, httpEnv, "SMD redfish endpoint request yielded unsuccessful HTTP response")
 			} else {
 				log.Logger.Error().Err(err).Msg("failed to request redfish endpoints from SMD")
 			}
@@ -122,17 +120,7 @@ endpoints returned.`,
 		}
 
 		// Print output
-		outFmt, err := cmd.Flags().GetString("output-format")
-		if err != nil {
-			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
-			os.Exit(1)
-		}
-		if outBytes, err := client.FormatBody(httpEnv.Body, outFmt); err != nil {
-			log.Logger.Error().Err(err).Msg("failed to format output")
-			os.Exit(1)
-		} else {
-			fmt.Printf(string(outBytes))
-		}
+		printEnvelope(cmd, httpEnv)
 	},
 }
 
@@ -144,5 +132,7 @@ func init() {
 	rfeGetCmd.Flags().StringSliceP("mac", "m", []string{}, "filter redfish endpoints by MAC address")
 	rfeGetCmd.Flags().StringSliceP("ip", "i", []string{}, "filter redfish endpoints by IP address")
 	rfeGetCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	addTableFormat(rfeGetCmd, format.KindRedfishEndpoints)
+	addListFlags(rfeGetCmd)
 	rfeCmd.AddCommand(rfeGetCmd)
 }
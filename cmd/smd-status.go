@@ -3,9 +3,13 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
@@ -18,6 +22,17 @@ var smdStatusCmd = &cobra.Command{
 	Use:   "status",
 	Args:  cobra.NoArgs,
 	Short: "Get status of SMD service",
+	Long: `Get status of SMD service. By default, SMD's readiness is printed. Pass --all
+to print the raw values SMD accepts for component fields.
+
+Pass --detail to print a summary of SMD's readiness (which includes whether
+its database is reachable), version, and the values it accepts for component
+fields (states, roles, architectures, etc.) as a table. If --output-format is
+also passed, the summary is printed in that format instead.`,
+	Example: `  ochami smd status
+  ochami smd status --all
+  ochami smd status --detail
+  ochami smd status --detail -F yaml`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Without a base URI, we cannot do anything
 		smdBaseURI, err := getBaseURI(cmd)
@@ -33,8 +48,25 @@ var smdStatusCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Check if a CA certificate was passed and load it into client if valid
-		useCACert(smdClient.OchamiClient)
+		// Configure client for the cluster (CA certificates, tenant, etc.)
+		configureClient(smdClient.OchamiClient)
+
+		if cmd.Flag("detail").Changed {
+			status, err := smdClient.GetServiceStatus()
+			if err != nil {
+				if errors.Is(err, client.UnsuccessfulHTTPError) {
+					log.Logger.Error().Err(err).Msg("SMD status request yielded unsuccessful HTTP response")
+				} else {
+					log.Logger.Error().Err(err).Msg("failed to get SMD status")
+				}
+				os.Exit(1)
+			}
+			printSMDStatusDetail(cmd, status)
+			if !status.Ready {
+				os.Exit(1)
+			}
+			return
+		}
 
 		// Determine which component to get status for and send request
 		var httpEnv client.HTTPEnvelope
@@ -45,7 +77,7 @@ var smdStatusCmd = &cobra.Command{
 		}
 		if err != nil {
 			if errors.Is(err, client.UnsuccessfulHTTPError) {
-				log.Logger.Error().Err(err).Msg("SMD status request yielded unsuccessful HTTP response")
+				logHTTPError(err, httpEnv, "SMD status request yielded unsuccessful HTTP response")
 			} else {
 				log.Logger.Error().Err(err).Msg("failed to get SMD status")
 			}
@@ -53,23 +85,86 @@ var smdStatusCmd = &cobra.Command{
 		}
 
 		// Print output
+		printEnvelope(cmd, httpEnv)
+	},
+}
+
+func init() {
+	smdStatusCmd.Flags().Bool("all", false, "print all status data from SMD")
+	smdStatusCmd.Flags().Bool("detail", false, "print summary of readiness, version, and supported values as a table")
+
+	smdStatusCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	smdStatusCmd.MarkFlagsMutuallyExclusive("all", "detail")
+
+	smdCmd.AddCommand(smdStatusCmd)
+}
+
+// printSMDStatusDetail prints status as a table unless --output-format was
+// passed, in which case status is printed in that format.
+func printSMDStatusDetail(cmd *cobra.Command, status smd.ServiceStatus) {
+	if cmd.Flag("output-format").Changed {
 		outFmt, err := cmd.Flags().GetString("output-format")
 		if err != nil {
 			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
 			os.Exit(1)
 		}
-		if outBytes, err := client.FormatBody(httpEnv.Body, outFmt); err != nil {
+		statusBytes, err := json.Marshal(status)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to marshal SMD status")
+			os.Exit(1)
+		}
+		if outBytes, err := client.FormatBody(statusBytes, outFmt); err != nil {
 			log.Logger.Error().Err(err).Msg("failed to format output")
 			os.Exit(1)
 		} else {
-			fmt.Printf(string(outBytes))
+			fmt.Print(string(outBytes))
 		}
-	},
+		return
+	}
+
+	if err := writeSMDStatusTable(os.Stdout, status); err != nil {
+		log.Logger.Error().Err(err).Msg("failed to print SMD status")
+		os.Exit(1)
+	}
 }
 
-func init() {
-	smdStatusCmd.Flags().Bool("all", false, "print all status data from SMD")
+// writeSMDStatusTable writes status to w as a two-column table of fields and
+// their values.
+func writeSMDStatusTable(w io.Writer, status smd.ServiceStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
 
-	smdStatusCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
-	smdCmd.AddCommand(smdStatusCmd)
+	ready := "no"
+	if status.Ready {
+		ready = "yes"
+	}
+	if status.Message != "" {
+		ready += " (" + status.Message + ")"
+	}
+	version := status.Version
+	if version == "" {
+		version = "unknown"
+	}
+	fmt.Fprintf(tw, "READY\t%s\n", ready)
+	fmt.Fprintf(tw, "VERSION\t%s\n", version)
+
+	if status.Values != nil {
+		rows := []struct {
+			name string
+			vals []string
+		}{
+			{"STATES", status.Values.State},
+			{"FLAGS", status.Values.Flag},
+			{"ROLES", status.Values.Role},
+			{"SUBROLES", status.Values.SubRole},
+			{"ARCHES", status.Values.Arch},
+			{"CLASSES", status.Values.Class},
+			{"NET TYPES", status.Values.NetType},
+			{"TYPES", status.Values.Type},
+		}
+		for _, r := range rows {
+			fmt.Fprintf(tw, "%s\t%s\n", r.name, strings.Join(r.vals, ", "))
+		}
+	}
+
+	return tw.Flush()
 }
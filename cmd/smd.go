@@ -3,9 +3,16 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/OpenCHAMI/ochami/pkg/nid"
 	"github.com/spf13/cobra"
 )
 
@@ -29,3 +36,139 @@ var smdCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(smdCmd)
 }
+
+// xnamesFromNIDsFlag parses the NID list passed to --nids (e.g.
+// "1-64,100,200-203") and resolves each NID to the xname of its component
+// using SMD. The xnames are returned in NID order. If --nids was not passed,
+// nil is returned. Errors are logged and cause an exit.
+func xnamesFromNIDsFlag(cmd *cobra.Command, smdClient *smd.SMDClient) []string {
+	if !cmd.Flag("nids").Changed {
+		return nil
+	}
+	nids, err := nid.Parse(cmd.Flag("nids").Value.String())
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("invalid value for --nids")
+		os.Exit(1)
+	}
+
+	// Resolving NIDs requires authentication, so a token is needed
+	setTokenFromEnvVar(cmd)
+	checkToken(cmd)
+
+	xnames, err := smdClient.GetXnamesByNIDs(nids, token)
+	if err != nil {
+		if errors.Is(err, client.UnsuccessfulHTTPError) {
+			log.Logger.Error().Err(err).Msg("SMD NID query yielded unsuccessful HTTP response")
+		} else {
+			log.Logger.Error().Err(err).Msg("failed to resolve NIDs to xnames")
+		}
+		os.Exit(1)
+	}
+	log.Logger.Debug().Msgf("resolved %d NID(s) to xnames", len(xnames))
+
+	return xnames
+}
+
+// confirmDeleteAll counts the resources that deleting all of them with --all
+// would delete, called what (e.g. "components"), using count and tells the
+// user how many they are. If --expect-count was passed and the count does not
+// match it, or the user does not confirm the deletion when asked because
+// --force was not passed, the program exits. Since the user may take a while
+// to answer, the resources are counted again afterwards and the program exits
+// if the count changed, so that the user does not confirm deleting a number of
+// resources that is no longer accurate. If there is nothing to delete, the
+// program exits successfully.
+func confirmDeleteAll(cmd *cobra.Command, what string, count func() (int, error)) {
+	n, err := count()
+	if err != nil {
+		if errors.Is(err, client.UnsuccessfulHTTPError) {
+			log.Logger.Error().Err(err).Msgf("SMD request to count %s yielded unsuccessful HTTP response", what)
+		} else {
+			log.Logger.Error().Err(err).Msgf("failed to count %s in SMD", what)
+		}
+		os.Exit(1)
+	}
+	if cmd.Flag("expect-count").Changed {
+		expected, err := cmd.Flags().GetInt("expect-count")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --expect-count")
+			os.Exit(1)
+		}
+		if n != expected {
+			log.Logger.Error().Msgf("expected to delete %s %s, but there are %s; not deleting", formatCount(expected), what, formatCount(n))
+			os.Exit(1)
+		}
+	}
+	if n == 0 {
+		log.Logger.Info().Msgf("no %s in SMD, nothing to delete", what)
+		os.Exit(0)
+	}
+
+	msg := fmt.Sprintf("This will delete %s %s", formatCount(n), what)
+	cluster, _ := getCluster(cmd)
+	if cluster != nil {
+		msg += " on cluster " + cluster.Name
+	} else {
+		msg += " at " + baseURI
+	}
+	if cmd.Flag("force").Changed {
+		log.Logger.Info().Msg(msg)
+		return
+	}
+	log.Logger.Debug().Msg("--force not passed, prompting user to confirm deletion")
+	fmt.Fprintln(os.Stderr, msg+".")
+	if !loopYesNo("Really delete ALL " + strings.ToUpper(what) + "?") {
+		log.Logger.Info().Msgf("User aborted %s deletion", what)
+		os.Exit(0)
+	}
+	log.Logger.Debug().Msgf("User answered affirmatively to delete %s", what)
+
+	now, err := count()
+	if err != nil {
+		log.Logger.Error().Err(err).Msgf("failed to count %s in SMD again", what)
+		os.Exit(1)
+	}
+	if now != n {
+		log.Logger.Error().Msgf("number of %s changed from %s to %s while confirming; not deleting", what, formatCount(n), formatCount(now))
+		os.Exit(1)
+	}
+}
+
+// formatCount returns n with its digits grouped in threes by commas, e.g.
+// 4,213.
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, s = "-", s[1:]
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return sign + s
+}
+
+// xnamesFromIdentifiers resolves ids, a mix of xnames, MAC addresses, and NIDs
+// (e.g. passed as arguments), to the xnames of the components they refer to
+// using SMD. The xnames are returned in the order of ids. Errors, including
+// identifiers that are unknown or ambiguous, are logged and cause an exit.
+func xnamesFromIdentifiers(smdClient *smd.SMDClient, ids []string) []string {
+	resolved, err := smdClient.ResolveIdentifiers(ids, token)
+	if err != nil {
+		if errors.Is(err, client.UnsuccessfulHTTPError) {
+			log.Logger.Error().Err(err).Msg("SMD request to resolve identifiers yielded unsuccessful HTTP response")
+		} else {
+			log.Logger.Error().Err(err).Msg("failed to resolve identifiers to components")
+		}
+		os.Exit(1)
+	}
+	xnames := make([]string, len(resolved))
+	for i, r := range resolved {
+		if r.Kind != smd.IdentifierXname || r.Xname != r.ID {
+			log.Logger.Debug().Msgf("resolved %s %s to %s", r.Kind, r.ID, r.Xname)
+		}
+		xnames[i] = r.Xname
+	}
+
+	return xnames
+}
@@ -0,0 +1,149 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/OpenCHAMI/ochami/internal/config"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/bss"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/OpenCHAMI/ochami/pkg/snapshot"
+	"github.com/spf13/cobra"
+)
+
+// snapshotCreateCmd represents the snapshot-create command
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create [--name <name>] [--resources <kind>,...]",
+	Args:  cobra.NoArgs,
+	Short: "Store a snapshot of SMD and BSS data",
+	Long: `Store a snapshot of SMD and BSS data. The following kinds of resources
+can be stored, all of them by default:
+
+  - components (SMD)
+  - groups (SMD)
+  - ethernet-interfaces (SMD)
+  - redfish-endpoints (SMD)
+  - boot-params (BSS)
+
+Pass --resources to only store some of them. The snapshot is named after
+the time it was created unless --name is passed, and its path is printed.
+An existing snapshot is never overwritten.
+
+An access token is required.`,
+	Example: `  ochami snapshot create
+  ochami snapshot create --name before-upgrade
+  ochami snapshot create --resources components,groups`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		baseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI")
+			os.Exit(1)
+		}
+
+		// These endpoints require authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		kinds, err := cmd.Flags().GetStringSlice("resources")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --resources")
+			os.Exit(1)
+		}
+		for _, k := range kinds {
+			if !slices.Contains(snapshot.Kinds, k) {
+				log.Logger.Error().Msgf("unknown resource kind %q, must be one of %v", k, snapshot.Kinds)
+				os.Exit(1)
+			}
+		}
+
+		snap := snapshot.New(snapshotCluster(cmd, baseURI), baseURI)
+		snap.Name = cmd.Flag("name").Value.String()
+		for _, kind := range kinds {
+			log.Logger.Debug().Msgf("fetching %s", kind)
+			body := fetchSnapshotResources(baseURI, kind)
+			if err := snap.AddResponse(kind, body); err != nil {
+				log.Logger.Error().Err(err).Msgf("failed to add %s to snapshot", kind)
+				os.Exit(1)
+			}
+			log.Logger.Info().Msgf("stored %d %s", len(snap.Resources[kind]), kind)
+		}
+
+		path, err := snapshot.Save(snapshotDir(cmd, baseURI), snap)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to save snapshot")
+			os.Exit(1)
+		}
+		fmt.Println(path)
+	},
+}
+
+// fetchSnapshotResources returns the response body of the endpoint listing the
+// resources of kind. If the request fails, a log is printed and the program
+// exits.
+func fetchSnapshotResources(baseURI, kind string) []byte {
+	cluster, _ := getCluster(rootCmd)
+	return fetchClusterResources(cluster, baseURI, token, kind)
+}
+
+// fetchClusterResources is like fetchSnapshotResources, but fetches the
+// resources from cluster, which may be nil, at baseURI using the access token
+// tok instead of from the cluster being contacted.
+func fetchClusterResources(cluster *config.ConfigCluster, baseURI, tok, kind string) []byte {
+	henv, err := getClusterResources(cluster, baseURI, tok, kind)
+	if err != nil {
+		if errors.Is(err, client.UnsuccessfulHTTPError) {
+			logHTTPError(err, henv, fmt.Sprintf("%s request yielded unsuccessful HTTP response", kind))
+		} else {
+			log.Logger.Error().Err(err).Msgf("failed to get %s", kind)
+		}
+		os.Exit(1)
+	}
+	return henv.Body
+}
+
+// getClusterResources returns the response to the request for the endpoint
+// listing the resources of kind from cluster, which may be nil, at baseURI
+// using the access token tok.
+func getClusterResources(cluster *config.ConfigCluster, baseURI, tok, kind string) (client.HTTPEnvelope, error) {
+	if kind == snapshot.KindBootParams {
+		bssClient, err := bss.NewClient(baseURI, insecure)
+		if err != nil {
+			return client.HTTPEnvelope{}, fmt.Errorf("error creating new BSS client: %w", err)
+		}
+		configureClusterClient(bssClient.OchamiClient, cluster)
+		return bssClient.GetBootParams("", tok)
+	}
+
+	smdClient, err := smd.NewClient(baseURI, insecure)
+	if err != nil {
+		return client.HTTPEnvelope{}, fmt.Errorf("error creating new SMD client: %w", err)
+	}
+	configureClusterClient(smdClient.OchamiClient, cluster)
+	switch kind {
+	case snapshot.KindComponents:
+		return smdClient.GetComponents("", tok)
+	case snapshot.KindGroups:
+		return smdClient.GetGroups("", tok)
+	case snapshot.KindEthernetInterfaces:
+		return smdClient.GetEthernetInterfaces(smd.EthernetInterfaceFilter{})
+	case snapshot.KindRedfishEndpoints:
+		return smdClient.GetRedfishEndpoints("", tok)
+	}
+	return client.HTTPEnvelope{}, fmt.Errorf("unknown resource kind: %s", kind)
+}
+
+func init() {
+	snapshotCreateCmd.Flags().String("name", "", "name of snapshot (default: time of creation, e.g. 20240102T150405Z)")
+	snapshotCreateCmd.Flags().StringSlice("resources", snapshot.Kinds, "kinds of resources to store")
+
+	setCommandServices(snapshotCreateCmd, "bss", "smd")
+
+	snapshotCmd.AddCommand(snapshotCreateCmd)
+}
@@ -0,0 +1,158 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/OpenCHAMI/ochami/internal/color"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/snapshot"
+	"github.com/spf13/cobra"
+)
+
+// snapshotDiffCmd represents the snapshot-diff command
+var snapshotDiffCmd = &cobra.Command{
+	Use:   "diff [--exit-code] <snapshot> [<snapshot>]",
+	Args:  cobra.RangeArgs(1, 2),
+	Short: "Compare two snapshots, or a snapshot against live data",
+	Long: `Compare two snapshots, or a snapshot against live data. Snapshots are
+identified by name, by "latest" for the most recently created one, or by
+the path of a snapshot file. If only one snapshot is passed, it is compared
+against the current data of the same kinds of resources, which requires
+an access token.
+
+The resources that were added, removed, or modified between the first and
+second snapshot are listed, along with the fields that changed for
+modified ones. Only kinds of resources in both snapshots are compared.
+
+The changes are printed as text unless --output-format is passed, in which
+case they are printed in that format. If --exit-code is passed, the exit
+status is 2 if there are changes.`,
+	Example: `  ochami snapshot diff latest
+  ochami snapshot diff before-upgrade latest
+  ochami snapshot diff 20240102T150405Z --exit-code
+  ochami snapshot diff ./old.json ./new.json -F yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		baseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI")
+			os.Exit(1)
+		}
+		dir := snapshotDir(cmd, baseURI)
+
+		from, err := snapshot.Find(dir, args[0])
+		if err != nil {
+			log.Logger.Error().Err(err).Msgf("failed to load snapshot %s", args[0])
+			os.Exit(1)
+		}
+
+		var to *snapshot.Snapshot
+		if len(args) == 2 {
+			if to, err = snapshot.Find(dir, args[1]); err != nil {
+				log.Logger.Error().Err(err).Msgf("failed to load snapshot %s", args[1])
+				os.Exit(1)
+			}
+		} else {
+			// These endpoints require authentication, so a token is needed
+			setTokenFromEnvVar(cmd)
+			checkToken(cmd)
+
+			to = snapshot.New(snapshotCluster(cmd, baseURI), baseURI)
+			to.Name = "live"
+			for _, kind := range from.Kinds() {
+				if err := to.AddResponse(kind, fetchSnapshotResources(baseURI, kind)); err != nil {
+					log.Logger.Error().Err(err).Msgf("failed to read live %s", kind)
+					os.Exit(1)
+				}
+			}
+		}
+		if from.Cluster != to.Cluster {
+			log.Logger.Warn().Msgf("comparing snapshots of different clusters (%s and %s)", from.Cluster, to.Cluster)
+		}
+
+		report := snapshot.Diff(from, to)
+
+		// Print output
+		if cmd.Flag("output-format").Changed {
+			outFmt, err := cmd.Flags().GetString("output-format")
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+				os.Exit(1)
+			}
+			reportBytes, err := json.Marshal(report)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to marshal snapshot diff")
+				os.Exit(1)
+			}
+			if outBytes, err := client.FormatBody(reportBytes, outFmt); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to format output")
+				os.Exit(1)
+			} else {
+				fmt.Print(string(outBytes))
+			}
+		} else {
+			writeSnapshotDiff(os.Stdout, report)
+		}
+
+		if cmd.Flag("exit-code").Changed && !report.Empty() {
+			os.Exit(2)
+		}
+	},
+}
+
+// writeSnapshotDiff writes report to w as text, one section per kind of
+// resource with changes, followed by a summary line. Added resources are
+// marked with "+", removed ones with "-", and modified ones with "~", and
+// colored if color.Stdout is enabled.
+func writeSnapshotDiff(w io.Writer, report snapshot.Report) {
+	if report.Empty() {
+		fmt.Fprintf(w, "No changes between %s and %s.\n", report.From, report.To)
+		return
+	}
+	c := color.Stdout
+	kind := ""
+	for _, ch := range report.Changes {
+		if ch.Kind != kind {
+			if kind != "" {
+				fmt.Fprintln(w)
+			}
+			kind = ch.Kind
+			fmt.Fprintf(w, "%s:\n", c.Bold(kind))
+		}
+		switch ch.Type {
+		case snapshot.ChangeAdded:
+			fmt.Fprintln(w, c.Green("  + "+ch.ID))
+		case snapshot.ChangeRemoved:
+			fmt.Fprintln(w, c.Red("  - "+ch.ID))
+		case snapshot.ChangeModified:
+			fmt.Fprintln(w, c.Yellow("  ~ "+ch.ID))
+			for _, f := range ch.Fields {
+				switch {
+				case f.Old == "":
+					fmt.Fprintf(w, "      %s: (unset) -> %s\n", f.Field, c.Green(f.New))
+				case f.New == "":
+					fmt.Fprintf(w, "      %s: %s -> (unset)\n", f.Field, c.Red(f.Old))
+				default:
+					fmt.Fprintf(w, "      %s: %s -> %s\n", f.Field, c.Red(f.Old), c.Green(f.New))
+				}
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%s -> %s: %d added, %d removed, %d modified\n", report.From, report.To,
+		report.Count(snapshot.ChangeAdded), report.Count(snapshot.ChangeRemoved), report.Count(snapshot.ChangeModified))
+}
+
+func init() {
+	snapshotDiffCmd.Flags().Bool("exit-code", false, "exit with status 2 if there are changes")
+	snapshotDiffCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+
+	setCommandServices(snapshotDiffCmd, "bss", "smd")
+
+	snapshotCmd.AddCommand(snapshotDiffCmd)
+}
@@ -0,0 +1,60 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/internal/color"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/snapshot"
+	"github.com/spf13/cobra"
+)
+
+// snapshotListCmd represents the snapshot-list command
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Args:  cobra.NoArgs,
+	Short: "List stored snapshots",
+	Long: `List the snapshots stored for the cluster being used, oldest first, along
+with the number of resources of each kind they hold.`,
+	Example: `  ochami snapshot list
+  ochami snapshot list --cluster foobar`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		baseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI")
+			os.Exit(1)
+		}
+		dir := snapshotDir(cmd, baseURI)
+
+		snaps, err := snapshot.List(dir)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to list snapshots")
+			os.Exit(1)
+		}
+		if len(snaps) == 0 {
+			log.Logger.Info().Msgf("no snapshots in %s", dir)
+			return
+		}
+
+		tw := color.NewTable(os.Stdout)
+		fmt.Fprintln(tw, "NAME\tCREATED\tRESOURCES")
+		for _, s := range snaps {
+			var counts []string
+			for _, kind := range s.Kinds() {
+				counts = append(counts, fmt.Sprintf("%s=%d", kind, len(s.Resources[kind])))
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Name, s.Created.Local().Format(time.RFC3339), strings.Join(counts, " "))
+		}
+		tw.Flush()
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotListCmd)
+}
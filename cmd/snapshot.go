@@ -0,0 +1,73 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/snapshot"
+	"github.com/spf13/cobra"
+)
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Args:  cobra.NoArgs,
+	Short: "Store and compare local snapshots of service data",
+	Long: `Store and compare local snapshots of service data. This is a metacommand.
+Commands under this one only read data from services.
+
+Snapshots are stored in a directory per cluster (the name of the cluster
+being used, or the host of the base URI) under
+$XDG_DATA_HOME/ochami/snapshots, or ~/.local/share/ochami/snapshots if
+XDG_DATA_HOME is not set. Pass --dir to use a different directory.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			err := cmd.Usage()
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("failed to print usage")
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+	},
+}
+
+// snapshotCluster returns the name identifying the cluster whose data is
+// snapshotted: the name of the cluster being used, if any, or else the host
+// of baseURI.
+func snapshotCluster(cmd *cobra.Command, baseURI string) string {
+	if cluster, err := getCluster(cmd); err == nil && cluster != nil {
+		return cluster.Name
+	}
+	if u, err := url.Parse(baseURI); err == nil && u.Host != "" {
+		return strings.ReplaceAll(u.Host, ":", "_")
+	}
+	return "default"
+}
+
+// snapshotDir returns the directory the snapshots of the cluster being used
+// are stored in: the directory passed with --dir, or else a directory named
+// after the cluster (see snapshotCluster) in the default snapshot directory.
+// If an error occurs, a log is printed and the program exits.
+func snapshotDir(cmd *cobra.Command, baseURI string) string {
+	if cmd.Flag("dir").Changed {
+		return cmd.Flag("dir").Value.String()
+	}
+	dir, err := snapshot.Dir()
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("failed to get snapshot directory")
+		os.Exit(1)
+	}
+	return filepath.Join(dir, snapshotCluster(cmd, baseURI))
+}
+
+func init() {
+	snapshotCmd.PersistentFlags().String("dir", "", "directory to store snapshots in instead of the default one for the cluster")
+
+	rootCmd.AddCommand(snapshotCmd)
+}
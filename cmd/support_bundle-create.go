@@ -0,0 +1,295 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/OpenCHAMI/ochami/internal/config"
+	oio "github.com/OpenCHAMI/ochami/internal/io"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/internal/version"
+	"github.com/OpenCHAMI/ochami/pkg/anonymize"
+	"github.com/OpenCHAMI/ochami/pkg/audit"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/bss"
+	"github.com/OpenCHAMI/ochami/pkg/client/ci"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/OpenCHAMI/ochami/pkg/snapshot"
+	"github.com/OpenCHAMI/ochami/pkg/supportbundle"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// supportBundleCreateCmd represents the support-bundle-create command
+var supportBundleCreateCmd = &cobra.Command{
+	Use:   "create [--anonymize] [<file>]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Create a support bundle",
+	Long: `Create a support bundle: a gzipped tarball holding the following data about
+the cluster being used and the ochami CLI, which is useful for troubleshooting,
+e.g. when reporting a problem to the OpenCHAMI developers.
+
+  manifest.json       When the bundle was created, the files in it, and any
+                      problems collecting them
+  version.json        Version of ochami and the platform it runs on
+  config.yaml         Merged configuration, with secrets redacted
+  smd/*.json          Components, groups, ethernet interfaces, redfish
+                      endpoints, and status of SMD
+  bss/*.json          Boot parameters and status of BSS
+  cloud-init/*.json   Configs in cloud-init
+  audit/boot.json     Findings of 'ochami audit boot' on the collected data
+
+Data that cannot be collected, e.g. because a service is down, is left out
+and the problem is recorded in the manifest, so that a bundle can be created
+for a cluster that is not working. Secrets in the collected data, e.g. the
+passwords of redfish endpoints, are always replaced with REDACTED.
+
+The bundle is written to <file>, or to a file named after the cluster and
+the time of creation in the current directory if <file> is not passed, whose
+path is printed. If <file> is -, the bundle is written to standard output.
+
+With --anonymize, hostnames, MAC addresses, and IP addresses in the bundle are
+replaced with keyed hashes of them so that the bundle can be shared. The same
+value is replaced with the same hash throughout the bundle, so that e.g. an
+ethernet interface can still be matched with the boot parameters of its MAC
+address. The key is random and not stored, so the hashes cannot be reversed.
+Hostnames that are xnames are kept, as are loopback addresses and netmasks.
+
+An access token is required.`,
+	Example: `  ochami support-bundle create
+  ochami support-bundle create --anonymize bundle.tar.gz
+  ochami support-bundle create - | tar -tzf -`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a base URI, we cannot do anything
+		baseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI")
+			os.Exit(1)
+		}
+
+		// These endpoints require authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		var anon *anonymize.Anonymizer
+		if cmd.Flag("anonymize").Changed {
+			if anon, err = anonymize.NewRandom(); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to set up anonymization")
+				os.Exit(1)
+			}
+		}
+		cluster, _ := getCluster(cmd)
+		clusterName := ""
+		if cluster != nil {
+			clusterName = cluster.Name
+		}
+		b := supportbundle.New(clusterName, anon != nil)
+
+		// Collect service data, keeping the bodies needed for the boot
+		// audit
+		bodies := map[string][]byte{}
+		for _, kind := range snapshot.Kinds {
+			svc := "smd"
+			if kind == snapshot.KindBootParams {
+				svc = "bss"
+			}
+			log.Logger.Debug().Msgf("collecting %s", kind)
+			henv, err := getClusterResources(cluster, baseURI, token, kind)
+			bodies[kind] = addBundleResponse(b, anon, svc+"/"+kind+".json", henv, err)
+		}
+		henv, err := getSMDStatus(baseURI)
+		addBundleResponse(b, anon, "smd/status.json", henv, err)
+		henv, err = getBSSStatus(baseURI)
+		addBundleResponse(b, anon, "bss/status.json", henv, err)
+		henv, err = getCloudInitConfigs(baseURI)
+		ciBody := addBundleResponse(b, anon, "cloud-init/configs.json", henv, err)
+
+		// Audit the collected data
+		if report, err := auditBundleData(bodies, ciBody); err != nil {
+			log.Logger.Warn().Err(err).Msg("failed to audit collected data")
+			b.AddError("audit/boot.json: %v", err)
+		} else if reportBytes, err := json.Marshal(report); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to marshal boot audit")
+			os.Exit(1)
+		} else {
+			addBundleJSON(b, anon, "audit/boot.json", reportBytes)
+		}
+
+		// Add version and config last, so that the hostnames found in
+		// service data are anonymized in them as well
+		versionBytes, err := json.Marshal(map[string]string{
+			"version":   version.Version,
+			"tag":       version.Tag,
+			"commit":    version.Commit,
+			"gitState":  version.GitState,
+			"date":      version.Date,
+			"goVersion": version.GoVersion,
+			"runtime":   runtime.Version(),
+			"os":        runtime.GOOS,
+			"arch":      runtime.GOARCH,
+		})
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to marshal version")
+			os.Exit(1)
+		}
+		addBundleJSON(b, anon, "version.json", versionBytes)
+		cfgBytes, err := yaml.Marshal(config.GlobalConfig.Redacted())
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to marshal config")
+			os.Exit(1)
+		}
+		if anon != nil {
+			cfgBytes = []byte(anon.Text(string(cfgBytes)))
+		}
+		b.Add("config.yaml", cfgBytes)
+
+		// Write bundle
+		var buf bytes.Buffer
+		if err := b.Write(&buf); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to create support bundle")
+			os.Exit(1)
+		}
+		path := b.Name() + ".tar.gz"
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if path == "-" {
+			if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to write support bundle")
+				os.Exit(1)
+			}
+		} else {
+			if err := oio.WriteFileAtomic(path, buf.Bytes(), false, 0o600); err != nil {
+				log.Logger.Error().Err(err).Msg("failed to write support bundle")
+				os.Exit(1)
+			}
+			fmt.Println(path)
+		}
+		if len(b.Errors) > 0 {
+			log.Logger.Warn().Msgf("support bundle is missing data that could not be collected (%d problem(s), see %s)", len(b.Errors), supportbundle.ManifestFile)
+		}
+	},
+}
+
+// addBundleResponse adds the body of henv, the response to a request for the
+// file name, to b, anonymized with anon if it is not nil, and returns the body.
+// If err is not nil, the request failed, so the failure is recorded in b
+// instead and nil is returned.
+func addBundleResponse(b *supportbundle.Bundle, anon *anonymize.Anonymizer, name string, henv client.HTTPEnvelope, err error) []byte {
+	if err != nil {
+		log.Logger.Warn().Err(err).Msgf("failed to collect %s", name)
+		b.AddError("%s: %v", name, err)
+		return nil
+	}
+	addBundleJSON(b, anon, name, henv.Body)
+	return henv.Body
+}
+
+// addBundleJSON adds the JSON data body as the file name to b, indented and
+// anonymized with anon if it is not nil. Secrets in body, e.g. the passwords of
+// redfish endpoints, are always redacted, even with --log-secrets. Data that is
+// not valid JSON is recorded as a problem in b instead.
+func addBundleJSON(b *supportbundle.Bundle, anon *anonymize.Anonymizer, name string, body []byte) {
+	var (
+		out []byte
+		err error
+	)
+	body = client.RedactSecrets(body)
+	if anon != nil {
+		out, err = anon.JSON(body)
+	} else {
+		var buf bytes.Buffer
+		err = json.Indent(&buf, body, "", "  ")
+		out = buf.Bytes()
+	}
+	if err != nil {
+		log.Logger.Warn().Err(err).Msgf("failed to add %s to support bundle", name)
+		b.AddError("%s: %v", name, err)
+		return
+	}
+	b.Add(name, append(out, '\n'))
+}
+
+// getSMDStatus returns the response to the request for all of SMD's status
+// values.
+func getSMDStatus(baseURI string) (client.HTTPEnvelope, error) {
+	smdClient, err := smd.NewClient(baseURI, insecure)
+	if err != nil {
+		return client.HTTPEnvelope{}, fmt.Errorf("error creating new SMD client: %w", err)
+	}
+	configureClient(smdClient.OchamiClient)
+	return smdClient.GetStatus("all")
+}
+
+// getBSSStatus returns the detailed status of BSS and its dependencies in a
+// response envelope.
+func getBSSStatus(baseURI string) (client.HTTPEnvelope, error) {
+	bssClient, err := bss.NewClient(baseURI, insecure)
+	if err != nil {
+		return client.HTTPEnvelope{}, fmt.Errorf("error creating new BSS client: %w", err)
+	}
+	configureClient(bssClient.OchamiClient)
+	detail, err := bssClient.GetStatusDetail()
+	if err != nil {
+		return client.HTTPEnvelope{}, err
+	}
+	body, err := json.Marshal(detail)
+	if err != nil {
+		return client.HTTPEnvelope{}, fmt.Errorf("failed to marshal BSS status: %w", err)
+	}
+	return client.HTTPEnvelope{Body: body}, nil
+}
+
+// getCloudInitConfigs returns the response to the request for all configs in
+// cloud-init.
+func getCloudInitConfigs(baseURI string) (client.HTTPEnvelope, error) {
+	ciClient, err := ci.NewClient(baseURI, insecure)
+	if err != nil {
+		return client.HTTPEnvelope{}, fmt.Errorf("error creating new cloud-init client: %w", err)
+	}
+	configureClient(ciClient.OchamiClient)
+	return ciClient.GetConfigs("")
+}
+
+// auditBundleData returns the boot audit of the collected bodies of the
+// responses listing resources, keyed by their kind, and of the cloud-init
+// configs, which are not checked if ciBody is nil. An error is returned if
+// SMD or BSS data needed for the audit is missing or cannot be unmarshalled.
+func auditBundleData(bodies map[string][]byte, ciBody []byte) (audit.Report, error) {
+	var data audit.BootData
+	for _, kind := range []string{snapshot.KindComponents, snapshot.KindEthernetInterfaces, snapshot.KindBootParams} {
+		if bodies[kind] == nil {
+			return audit.Report{}, fmt.Errorf("%s could not be collected", kind)
+		}
+	}
+	var comps smd.ComponentSlice
+	if err := json.Unmarshal(bodies[snapshot.KindComponents], &comps); err != nil {
+		return audit.Report{}, fmt.Errorf("failed to unmarshal components: %w", err)
+	}
+	data.Components = comps.Components
+	if err := json.Unmarshal(bodies[snapshot.KindEthernetInterfaces], &data.EthernetInterfaces); err != nil {
+		return audit.Report{}, fmt.Errorf("failed to unmarshal ethernet interfaces: %w", err)
+	}
+	if err := json.Unmarshal(bodies[snapshot.KindBootParams], &data.BootParams); err != nil {
+		return audit.Report{}, fmt.Errorf("failed to unmarshal boot parameters: %w", err)
+	}
+	if ciBody != nil {
+		if err := json.Unmarshal(ciBody, &data.CloudInitConfigs); err != nil {
+			return audit.Report{}, fmt.Errorf("failed to unmarshal cloud-init configs: %w", err)
+		}
+	}
+	return audit.Boot(data, false), nil
+}
+
+func init() {
+	supportBundleCreateCmd.Flags().Bool("anonymize", false, "replace hostnames, MAC addresses, and IP addresses with consistent hashes")
+
+	setCommandServices(supportBundleCreateCmd, "bss", "cloud-init", "smd")
+
+	supportBundleCmd.AddCommand(supportBundleCreateCmd)
+}
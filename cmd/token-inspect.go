@@ -0,0 +1,188 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/internal/config"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/spf13/cobra"
+)
+
+// tokenInfo is the summary of an access token printed by 'token inspect'.
+type tokenInfo struct {
+	Valid           bool       `json:"valid" yaml:"valid"`
+	Error           string     `json:"error,omitempty" yaml:"error,omitempty"`
+	Subject         string     `json:"subject,omitempty" yaml:"subject,omitempty"`
+	Issuer          string     `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+	Audience        []string   `json:"audience,omitempty" yaml:"audience,omitempty"`
+	Scopes          []string   `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	Roles           []string   `json:"roles,omitempty" yaml:"roles,omitempty"`
+	Tenants         []string   `json:"tenants,omitempty" yaml:"tenants,omitempty"`
+	IssuedAt        *time.Time `json:"issued_at,omitempty" yaml:"issued_at,omitempty"`
+	NotBefore       *time.Time `json:"not_before,omitempty" yaml:"not_before,omitempty"`
+	Expiration      *time.Time `json:"expiration,omitempty" yaml:"expiration,omitempty"`
+	ExpiresIn       string     `json:"expires_in,omitempty" yaml:"expires_in,omitempty"`
+	MatchedClusters []string   `json:"matched_clusters,omitempty" yaml:"matched_clusters,omitempty"`
+}
+
+// tokenInspectCmd represents the token-inspect command
+var tokenInspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Args:  cobra.NoArgs,
+	Short: "Show the claims of the access token in use",
+	Long: `Show the claims of the access token in use. The token is determined the
+same way as for any other command: the value of --token if passed or the
+<CLUSTER>_ACCESS_TOKEN environment variable for the cluster in use
+otherwise, falling back to the cluster's token or token-source.
+
+The issuer, subject, audience, scopes, roles, tenants, and validity times of
+the token are printed, along with whether the token is currently valid and
+which clusters in the configuration the audience matches. A cluster
+matches if an audience entry is equal to its name, its base URI, or
+the host of its base URI. The signature of the token is not verified.`,
+	Example: `  ochami token inspect
+  ochami --cluster foobar token inspect -F yaml
+  ochami token inspect --token "$(cat token.jwt)"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setTokenFromEnvVar(cmd)
+		if token == "" {
+			log.Logger.Error().Msg("no token set")
+			os.Exit(1)
+		}
+
+		t, err := validateToken(token)
+		if t == nil {
+			log.Logger.Error().Err(err).Msg("failed to inspect token")
+			os.Exit(1)
+		}
+		info := inspectToken(t)
+		if err != nil {
+			info.Error = err.Error()
+		} else {
+			info.Valid = true
+		}
+
+		// Print output
+		outFmt, err := cmd.Flags().GetString("output-format")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --output-format")
+			os.Exit(1)
+		}
+		infoBytes, err := json.Marshal(info)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to marshal token information")
+			os.Exit(1)
+		}
+		if outBytes, err := client.FormatBody(infoBytes, outFmt); err != nil {
+			log.Logger.Error().Err(err).Msg("failed to format output")
+			os.Exit(1)
+		} else {
+			fmt.Print(string(outBytes))
+		}
+
+		if !info.Valid {
+			os.Exit(1)
+		}
+	},
+}
+
+// inspectToken collects the claims of t relevant to a user into a tokenInfo.
+// Scopes are read from the "scope" (space-separated string) or "scp" (list)
+// claims, roles from the "roles" claim, and tenants from the tenant claims (see
+// tenantClaims), whichever are present.
+func inspectToken(t jwt.Token) tokenInfo {
+	info := tokenInfo{
+		Subject:    t.Subject(),
+		Issuer:     t.Issuer(),
+		Audience:   t.Audience(),
+		IssuedAt:   timeOrNil(t.IssuedAt()),
+		NotBefore:  timeOrNil(t.NotBefore()),
+		Expiration: timeOrNil(t.Expiration()),
+	}
+	if info.Expiration != nil {
+		info.ExpiresIn = time.Until(*info.Expiration).Round(time.Second).String()
+	}
+	if scope, ok := t.Get("scope"); ok {
+		if s, ok := scope.(string); ok {
+			info.Scopes = strings.Fields(s)
+		}
+	}
+	if scp, ok := t.Get("scp"); ok {
+		info.Scopes = append(info.Scopes, claimToStrings(scp)...)
+	}
+	if roles, ok := t.Get("roles"); ok {
+		info.Roles = claimToStrings(roles)
+	}
+	info.Tenants = tokenTenants(t)
+	info.MatchedClusters = clustersMatchingAudience(info.Audience)
+
+	return info
+}
+
+// timeOrNil returns a pointer to t, or nil if t is the zero time, so that unset
+// time claims are omitted from output.
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// claimToStrings converts a claim value that is either a single string or a
+// list of strings into a string slice.
+func claimToStrings(claim interface{}) []string {
+	var strs []string
+	switch c := claim.(type) {
+	case string:
+		strs = append(strs, c)
+	case []string:
+		strs = append(strs, c...)
+	case []interface{}:
+		for _, v := range c {
+			if s, ok := v.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+	}
+
+	return strs
+}
+
+// clustersMatchingAudience returns the names of the clusters in the global
+// config that any of the audience entries in aud refers to, either by cluster
+// name, base URI, or base URI host.
+func clustersMatchingAudience(aud []string) []string {
+	var matches []string
+	for _, c := range config.GlobalConfig.Clusters {
+		candidates := []string{c.Name, strings.TrimSuffix(c.Cluster.BaseURI, "/")}
+		if u, err := url.Parse(c.Cluster.BaseURI); err == nil && u.Host != "" {
+			candidates = append(candidates, u.Host, u.Hostname())
+		}
+	audLoop:
+		for _, a := range aud {
+			a = strings.TrimSuffix(a, "/")
+			for _, cand := range candidates {
+				if cand != "" && a == cand {
+					matches = append(matches, c.Name)
+					break audLoop
+				}
+			}
+		}
+	}
+
+	return matches
+}
+
+func init() {
+	tokenInspectCmd.Flags().StringP("output-format", "F", defaultOutputFormat, "format of output printed to standard output")
+	tokenCmd.AddCommand(tokenInspectCmd)
+}
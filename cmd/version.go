@@ -3,9 +3,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"os"
 	"runtime"
+	"time"
 
+	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/internal/version"
 	"github.com/spf13/cobra"
 )
@@ -14,10 +19,20 @@ var output string
 
 // versionCmd represents the version command
 var versionCmd = &cobra.Command{
-	Use:     "version",
-	Args:    cobra.NoArgs,
-	Short:   "Print detailed version to stdout and exit",
-	Example: `  ochami version`,
+	Use:   "version",
+	Args:  cobra.NoArgs,
+	Short: "Print detailed version to stdout and exit",
+	Long: `Print detailed version to stdout and exit.
+
+If --check-update is passed, the latest release of ochami in the release
+channel (stable or prerelease) is also fetched from GitHub and, if it is newer
+than this build, instructions for upgrading are printed. The result of the
+check is cached for 24 hours unless --refresh is passed.
+
+See ochami(1) for more details.`,
+	Example: `  ochami version
+  ochami version --check-update
+  ochami version --check-update --channel prerelease --timeout 5s`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Printf("Version:    %s\n", version.Version)
 		fmt.Printf("Tag:        %s\n", version.Tag)
@@ -29,9 +44,118 @@ var versionCmd = &cobra.Command{
 		fmt.Printf("Compiler:   %s\n", runtime.Compiler)
 		fmt.Printf("Build Host: %s\n", version.BuildHost)
 		fmt.Printf("Build User: %s\n", version.BuildUser)
+
+		if !cmd.Flag("check-update").Changed {
+			return
+		}
+		channel := version.DefaultChannel()
+		if cmd.Flag("channel").Changed {
+			channel, _ = cmd.Flags().GetString("channel")
+		}
+		if channel != version.ChannelStable && channel != version.ChannelPrerelease {
+			log.Logger.Error().Msgf("unknown release channel %q, must be %s or %s", channel, version.ChannelStable, version.ChannelPrerelease)
+			os.Exit(1)
+		}
+
+		release, err := latestRelease(cmd, channel)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to check for updates")
+			os.Exit(1)
+		}
+		printUpdateCheck(channel, release)
 	},
 }
 
+// latestRelease returns the latest release of ochami in channel, from the
+// update check cache if it is fresh and --refresh was not passed, otherwise
+// from the releases API, in which case the cache is updated.
+func latestRelease(cmd *cobra.Command, channel string) (version.Release, error) {
+	now := time.Now()
+	cacheFile, err := version.UpdateCacheFile()
+	if err != nil {
+		log.Logger.Warn().Err(err).Msg("update check will not be cached")
+	}
+	if cacheFile != "" && !cmd.Flag("refresh").Changed {
+		if r, ok := version.ReadUpdateCache(cacheFile, channel, now); ok {
+			log.Logger.Debug().Msgf("using cached update check from %s", cacheFile)
+			return r, nil
+		}
+	}
+
+	// Use a fresh transport so that proxies are honored from the environment
+	// regardless of how the default client was configured for OpenCHAMI
+	// services.
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return version.Release{}, fmt.Errorf("failed to get value for --timeout: %w", err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	hc := &http.Client{Transport: transport, Timeout: timeout}
+
+	ctx := context.Background()
+	if runner != nil {
+		ctx = runner.Context()
+	}
+	releasesURL, _ := cmd.Flags().GetString("releases-url")
+	log.Logger.Debug().Msgf("fetching %s releases from %s", channel, releasesURL)
+	r, _, err := version.LatestRelease(ctx, hc, releasesURL, channel)
+	if err != nil {
+		return r, err
+	}
+	if cacheFile != "" {
+		if err := version.WriteUpdateCache(cacheFile, channel, r, now); err != nil {
+			log.Logger.Warn().Err(err).Msg("failed to cache update check")
+		}
+	}
+
+	return r, nil
+}
+
+// printUpdateCheck prints the latest release in channel and, if it is newer
+// than the running version, how to upgrade to it.
+func printUpdateCheck(channel string, r version.Release) {
+	fmt.Println()
+	fmt.Printf("Channel:    %s\n", channel)
+	fmt.Printf("Latest:     %s\n", r.TagName)
+
+	latest, err := version.ParseSemver(r.TagName)
+	if err != nil {
+		// LatestRelease only returns releases with valid versions, so this
+		// can only happen with a tampered cache.
+		log.Logger.Error().Err(err).Msg("invalid latest release version")
+		os.Exit(1)
+	}
+	current, err := version.ParseSemver(version.Version)
+	if err != nil {
+		fmt.Printf("\nThis build's version (%s) is not a release, so it cannot be compared\n", version.Version)
+		fmt.Printf("with the latest release. To install the latest release, run:\n\n")
+		printUpgradeInstructions(r)
+		return
+	}
+	if current.Compare(latest) >= 0 {
+		fmt.Printf("\nochami is up to date.\n")
+		return
+	}
+	fmt.Printf("\nA newer version of ochami is available: %s -> %s\n", current, latest)
+	fmt.Printf("To upgrade, run:\n\n")
+	printUpgradeInstructions(r)
+}
+
+func printUpgradeInstructions(r version.Release) {
+	fmt.Printf("  go install github.com/OpenCHAMI/ochami@%s\n", r.TagName)
+	if r.HTMLURL != "" {
+		fmt.Printf("\nor download a package for your platform from:\n\n  %s\n", r.HTMLURL)
+	}
+}
+
 func init() {
+	versionCmd.Flags().Bool("check-update", false, "check whether a newer release of ochami is available")
+	versionCmd.Flags().String("channel", "", "release channel to check for updates (stable,prerelease) (default prerelease for prerelease builds, stable otherwise)")
+	addDurationFlag(versionCmd, "timeout", 10*time.Second, "timeout for checking for updates")
+	versionCmd.Flags().Bool("refresh", false, "ignore cached update check result")
+	versionCmd.Flags().String("releases-url", version.ReleasesURL, "URL of releases API to check for updates")
+	versionCmd.Flags().MarkHidden("releases-url")
+
 	rootCmd.AddCommand(versionCmd)
 }
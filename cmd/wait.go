@@ -0,0 +1,154 @@
+// This source code is licensed under the license found in the LICENSE file at
+// the root directory of this source tree.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/internal/cli"
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/OpenCHAMI/ochami/pkg/wait"
+	"github.com/spf13/cobra"
+)
+
+// waitCmd represents the wait command
+var waitCmd = &cobra.Command{
+	Use:   "wait --for <condition>...",
+	Args:  cobra.NoArgs,
+	Short: "Wait until conditions on the state of services hold",
+	Long: `Wait until conditions on the state of services hold, polling the services
+every --interval. A condition is passed with --for, which can be passed
+several times to wait until all conditions hold at once. A condition has
+the form:
+
+  <service>:<kind> <target> <field>=<value>... [<threshold>]
+
+where <kind> is one of:
+
+  smd:component <xname> <field>=<value>...
+      The component with ID <xname> has all of the fields.
+  smd:group <label> <field>=<value>... [<n>%|<n>]
+      At least <n> percent (or <n>) of the members of the group have all
+      of the fields. The default is 100%.
+
+Fields are those of SMD components (e.g. state, flag, enabled, or role) and
+are compared regardless of case. Use <field>!=<value> to match components
+whose field differs from <value>.
+
+Errors fetching the state, e.g. while a service restarts, are logged and
+polling continues, except for authentication errors. If the conditions do not
+hold within --timeout, the exit status is 124. An access token is required.`,
+	Example: `  ochami wait --for 'smd:component x1000c0s0b0n0 state=Ready' --timeout 20m
+  ochami wait --for 'smd:group compute state=Ready 90%'
+  ochami wait --for 'smd:group compute state=Ready flag=OK 60' --interval 30s`,
+	Run: func(cmd *cobra.Command, args []string) {
+		specs, err := cmd.Flags().GetStringArray("for")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --for")
+			os.Exit(1)
+		}
+		var conds []wait.Condition
+		for _, spec := range specs {
+			c, err := wait.Parse(spec)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg("invalid --for")
+				os.Exit(1)
+			}
+			conds = append(conds, c)
+		}
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get value for --timeout")
+			os.Exit(1)
+		}
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil || interval <= 0 {
+			log.Logger.Error().Err(err).Msg("invalid --interval")
+			os.Exit(1)
+		}
+
+		// Without a base URI, we cannot do anything
+		baseURI, err := getBaseURI(cmd)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("failed to get base URI")
+			os.Exit(1)
+		}
+
+		// These endpoints require authentication, so a token is needed
+		setTokenFromEnvVar(cmd)
+		checkToken(cmd)
+
+		smdClient, err := smd.NewClient(baseURI, insecure)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("error creating new SMD client")
+			os.Exit(1)
+		}
+		configureClient(smdClient.OchamiClient)
+		checker := wait.Checker{SMD: smdClient, Token: token}
+
+		ctx := runner.Context()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		for {
+			if allConditionsMet(ctx, checker, conds) {
+				log.Logger.Info().Msg("all conditions hold")
+				return
+			}
+			select {
+			case <-time.After(interval):
+				continue
+			case <-ctx.Done():
+			}
+			if runner.Interrupted() {
+				log.Logger.Error().Msg("interrupted before conditions held")
+				os.Exit(cli.ExitInterrupted)
+			}
+			log.Logger.Error().Msgf("conditions did not hold within %s", timeout)
+			os.Exit(cli.ExitTimeout)
+		}
+	},
+}
+
+// allConditionsMet checks each of conds once with checker, logging their
+// status, and reports whether all of them hold. Errors other than
+// authentication errors, which cause the program to exit, are logged and count
+// as the condition not holding.
+func allConditionsMet(ctx context.Context, checker wait.Checker, conds []wait.Condition) bool {
+	met := true
+	for _, c := range conds {
+		s, err := checker.Check(ctx, c)
+		if err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			if errors.Is(err, wait.ErrUnauthorized) {
+				log.Logger.Error().Err(err).Msgf("failed to check %q", c)
+				os.Exit(1)
+			}
+			log.Logger.Warn().Err(err).Msgf("failed to check %q, will retry", c)
+			met = false
+			continue
+		}
+		log.Logger.Info().Msgf("%q: %s", c, s)
+		met = met && s.Met
+	}
+	return met
+}
+
+func init() {
+	waitCmd.Flags().StringArray("for", []string{}, "condition to wait for (e.g. 'smd:component x1000c0s0b0n0 state=Ready')")
+	addDurationFlag(waitCmd, "timeout", 10*time.Minute, "give up after this long (0 to wait forever)")
+	addDurationFlag(waitCmd, "interval", 10*time.Second, "how often to check the conditions")
+	_ = waitCmd.MarkFlagRequired("for")
+
+	setCommandServices(waitCmd, "smd")
+
+	rootCmd.AddCommand(waitCmd)
+}
@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+)
+
+// ListOptions limits and sorts the items of a list response before it is
+// rendered. The zero value leaves the list as is.
+type ListOptions struct {
+	Limit int    // Maximum number of items to keep, or 0 for all
+	Sort  string // Field to sort items by, or "" to keep the service's order
+	Desc  bool   // Sort in descending order
+}
+
+// ParseSort parses the value of a --sort flag, "field[:asc|:desc]", into o.
+func (o *ListOptions) ParseSort(s string) error {
+	field, order, hasOrder := strings.Cut(s, ":")
+	if field == "" {
+		return fmt.Errorf("invalid sort %q: missing field", s)
+	}
+	o.Sort = field
+	o.Desc = false
+	if hasOrder {
+		switch strings.ToLower(order) {
+		case "asc":
+		case "desc":
+			o.Desc = true
+		default:
+			return fmt.Errorf("invalid sort order %q in %q, must be asc or desc", order, s)
+		}
+	}
+	return nil
+}
+
+// ApplyListOptions sorts and limits the list in body according to o and
+// returns the resulting body. The list is either body itself, if it is a JSON
+// array, or the only array in it, if it is an object with a single array
+// member (e.g. SMD's {"Components": [...]}), in which case the object is kept
+// around the resulting list.
+//
+// Items are sorted by the value of field o.Sort, which can be a dot-separated
+// path to a nested field. Field names are matched case-insensitively if there is
+// no exact match. Numbers are compared numerically and other values as
+// strings. Items without the field are placed last. Lists of plain values
+// (e.g. xnames) are sorted by value regardless of o.Sort. The sort is stable,
+// so items with equal values keep the service's order.
+//
+// No OpenCHAMI service endpoint that ochami lists from supports limiting or
+// sorting yet, so this is always done after the whole list is received.
+func ApplyListOptions(body client.HTTPBody, o ListOptions) (client.HTTPBody, error) {
+	if o.Limit < 0 {
+		return nil, fmt.Errorf("invalid limit %d, must not be negative", o.Limit)
+	}
+	if o.Limit == 0 && o.Sort == "" {
+		return body, nil
+	}
+
+	// Keep numbers as they are, e.g. so that large IDs are not rounded
+	var data any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal list: %w", err)
+	}
+
+	var (
+		list   []any
+		setter func([]any)
+	)
+	switch d := data.(type) {
+	case []any:
+		list = d
+		setter = func(l []any) { data = l }
+	case map[string]any:
+		var key string
+		for k, v := range d {
+			if _, ok := v.([]any); ok {
+				if key != "" {
+					return nil, fmt.Errorf("cannot sort or limit: response has more than one list (%s, %s)", key, k)
+				}
+				key = k
+			}
+		}
+		if key == "" {
+			return nil, fmt.Errorf("cannot sort or limit: response is not a list")
+		}
+		list = d[key].([]any)
+		setter = func(l []any) { d[key] = l }
+	default:
+		return nil, fmt.Errorf("cannot sort or limit: response is not a list")
+	}
+
+	if o.Sort != "" {
+		path := strings.Split(o.Sort, ".")
+		sort.SliceStable(list, func(i, j int) bool {
+			vi, iok := sortValue(list[i], path)
+			vj, jok := sortValue(list[j], path)
+			switch {
+			case !iok || !jok:
+				// Items without the field go last regardless of
+				// order.
+				return iok && !jok
+			case o.Desc:
+				return compareValues(vj, vi) < 0
+			default:
+				return compareValues(vi, vj) < 0
+			}
+		})
+	}
+	if o.Limit > 0 && len(list) > o.Limit {
+		list = list[:o.Limit]
+	}
+	setter(list)
+
+	out, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal list: %w", err)
+	}
+	return out, nil
+}
+
+// sortValue returns the value at path in item. If item is not an object, item
+// itself is returned. The returned bool is false if there is no such value.
+func sortValue(item any, path []string) (any, bool) {
+	if _, ok := item.(map[string]any); !ok {
+		return item, item != nil
+	}
+	v := item
+	for _, name := range path {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = obj[name]; !ok {
+			for k, kv := range obj {
+				if strings.EqualFold(k, name) {
+					v, ok = kv, true
+					break
+				}
+			}
+			if !ok {
+				return nil, false
+			}
+		}
+	}
+	return v, v != nil
+}
+
+// compareValues returns -1, 0, or 1 if a sorts before, the same as, or after
+// b. Numbers are compared numerically if both are numbers and everything else
+// is compared as strings.
+func compareValues(a, b any) int {
+	if na, ok := a.(json.Number); ok {
+		if nb, ok := b.(json.Number); ok {
+			fa, errA := na.Float64()
+			fb, errB := nb.Float64()
+			switch {
+			case errA != nil || errB != nil:
+				break
+			case fa < fb:
+				return -1
+			case fa > fb:
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
\ No newline at end of file
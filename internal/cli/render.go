@@ -0,0 +1,163 @@
+// Package cli contains helpers for presenting the results of requests to
+// OpenCHAMI services to users.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+)
+
+// Problem is an RFC 7807 problem details object, which OpenCHAMI services
+// return as the body (with Content-Type application/problem+json) of
+// unsuccessful responses.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// Summary is rendered in place of the body of a successful response that has
+// no body (e.g. 204 No Content), so that something is printed to confirm
+// success.
+type Summary struct {
+	Success bool   `json:"success"`
+	Status  string `json:"status"`
+}
+
+// ProblemFromEnvelope returns the problem details in the body of henv. If the
+// body is not a problem details object, a Problem is synthesized from the
+// status of henv, with the body, if any, as its detail. The returned bool
+// reports whether the body was a problem details object.
+func ProblemFromEnvelope(henv client.HTTPEnvelope) (Problem, bool) {
+	var p Problem
+	if err := json.Unmarshal(henv.Body, &p); err == nil && (p.Title != "" || p.Detail != "") {
+		if p.Status == 0 {
+			p.Status = henv.StatusCode
+		}
+		return p, true
+	}
+
+	return Problem{
+		Title:  http.StatusText(henv.StatusCode),
+		Status: henv.StatusCode,
+		Detail: strings.TrimSpace(string(henv.Body)),
+	}, false
+}
+
+// OutputHeader identifies where rendered data came from, so that consumers of
+// the output need not rely on the context a command was run in. Cluster is the
+// name of the cluster used, if any, and Service is the OpenCHAMI service (e.g.
+// smd) that was queried.
+type OutputHeader struct {
+	Cluster string
+	Service string
+}
+
+// RequestMetadata describes the request whose response is rendered with an
+// OutputHeader. Time and DurationMS are only set if the request was timed.
+type RequestMetadata struct {
+	Method     string `json:"method,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Status     int    `json:"status"`
+	Time       string `json:"time,omitempty"`
+	DurationMS int64  `json:"duration_ms,omitempty"`
+}
+
+// metadataEnvelope is the object that data is wrapped in when rendered with
+// an OutputHeader.
+type metadataEnvelope struct {
+	Cluster string          `json:"cluster,omitempty"`
+	Service string          `json:"service,omitempty"`
+	Request RequestMetadata `json:"request"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// RenderEnvelope renders henv in format (json or yaml) for display, depending on
+// its status:
+//
+//   - 2xx with a body: the body, as data.
+//   - 2xx without a body (e.g. 204 No Content): a Summary of the response.
+//   - anything else: the Problem describing the failure (see
+//     ProblemFromEnvelope).
+//
+// An envelope without a status, i.e. one built by the caller rather than read
+// from a response, is treated as 200 OK. An error is returned if the body
+// cannot be converted to format.
+func RenderEnvelope(henv client.HTTPEnvelope, format string) ([]byte, error) {
+	data, err := envelopeData(henv)
+	if err != nil {
+		return nil, err
+	}
+	return client.FormatBody(data, format)
+}
+
+// RenderEnvelopeWithHeader is like RenderEnvelope, except that what would be
+// rendered is wrapped in an object along with hdr and the metadata of the
+// request henv is the response to:
+//
+//	{"cluster": ..., "service": ..., "request": {...}, "data": ...}
+func RenderEnvelopeWithHeader(henv client.HTTPEnvelope, format string, hdr OutputHeader) ([]byte, error) {
+	data, err := envelopeData(henv)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("failed to add output header: response body is not JSON")
+	}
+	env := metadataEnvelope{
+		Cluster: hdr.Cluster,
+		Service: hdr.Service,
+		Request: RequestMetadata{
+			Method: henv.Method,
+			URL:    client.RedactURI(henv.URL),
+			Status: henv.StatusCode,
+		},
+		Data: data,
+	}
+	if env.Request.Status == 0 {
+		env.Request.Status = http.StatusOK
+	}
+	if !henv.Start.IsZero() {
+		env.Request.Time = henv.Start.UTC().Format(time.RFC3339Nano)
+		env.Request.DurationMS = henv.Duration.Milliseconds()
+	}
+	envBytes, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output header: %w", err)
+	}
+	return client.FormatBody(envBytes, format)
+}
+
+// envelopeData returns the JSON that RenderEnvelope renders for henv.
+func envelopeData(henv client.HTTPEnvelope) ([]byte, error) {
+	if henv.StatusCode == 0 {
+		henv.StatusCode = http.StatusOK
+	}
+
+	var data any
+	switch {
+	case henv.StatusCode >= 200 && henv.StatusCode < 300 && len(strings.TrimSpace(string(henv.Body))) > 0:
+		return henv.Body, nil
+	case henv.StatusCode >= 200 && henv.StatusCode < 300:
+		status := henv.Status
+		if status == "" {
+			status = fmt.Sprintf("%d %s", henv.StatusCode, http.StatusText(henv.StatusCode))
+		}
+		data = Summary{Success: true, Status: status}
+	default:
+		data, _ = ProblemFromEnvelope(henv)
+	}
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response summary: %w", err)
+	}
+	return dataBytes, nil
+}
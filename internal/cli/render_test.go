@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+)
+
+func TestRenderEnvelope(t *testing.T) {
+	tests := []struct {
+		name string
+		henv client.HTTPEnvelope
+		want string
+	}{
+		{
+			name: "2xx with body",
+			henv: client.HTTPEnvelope{
+				StatusCode: http.StatusOK,
+				Status:     "200 OK",
+				Body:       []byte(`{"Components":[{"ID":"x1000c1s7b0n0","NID":1}]}`),
+			},
+			want: `{"Components":[{"ID":"x1000c1s7b0n0","NID":1}]}`,
+		},
+		{
+			name: "2xx with body containing format verbs",
+			henv: client.HTTPEnvelope{
+				StatusCode: http.StatusOK,
+				Body:       []byte(`{"params":"console=ttyS0,115200n8 url=http://foo/%s%d"}`),
+			},
+			want: `{"params":"console=ttyS0,115200n8 url=http://foo/%s%d"}`,
+		},
+		{
+			name: "envelope without status",
+			henv: client.HTTPEnvelope{Body: []byte(`[1,2]`)},
+			want: `[1,2]`,
+		},
+		{
+			name: "204 without body",
+			henv: client.HTTPEnvelope{
+				StatusCode: http.StatusNoContent,
+				Status:     "204 No Content",
+			},
+			want: `{"status":"204 No Content","success":true}`,
+		},
+		{
+			name: "2xx with blank body and no status text",
+			henv: client.HTTPEnvelope{
+				StatusCode: http.StatusCreated,
+				Body:       []byte("\n"),
+			},
+			want: `{"status":"201 Created","success":true}`,
+		},
+		{
+			name: "problem+json",
+			henv: client.HTTPEnvelope{
+				StatusCode: http.StatusNotFound,
+				Status:     "404 Not Found",
+				Body:       []byte(`{"type":"about:blank","title":"Not Found","detail":"no such xname: x1","status":404}`),
+			},
+			want: `{"detail":"no such xname: x1","status":404,"title":"Not Found","type":"about:blank"}`,
+		},
+		{
+			name: "problem+json without status",
+			henv: client.HTTPEnvelope{
+				StatusCode: http.StatusConflict,
+				Body:       []byte(`{"title":"Conflict","detail":"already exists"}`),
+			},
+			want: `{"detail":"already exists","status":409,"title":"Conflict"}`,
+		},
+		{
+			name: "error without problem details",
+			henv: client.HTTPEnvelope{
+				StatusCode: http.StatusBadGateway,
+				Body:       []byte("upstream unavailable\n"),
+			},
+			want: `{"detail":"upstream unavailable","status":502,"title":"Bad Gateway"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderEnvelope(tt.henv, "json")
+			if err != nil {
+				t.Fatalf("RenderEnvelope() returned error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("RenderEnvelope() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderEnvelopeYAML(t *testing.T) {
+	henv := client.HTTPEnvelope{
+		StatusCode: http.StatusNoContent,
+		Status:     "204 No Content",
+	}
+	got, err := RenderEnvelope(henv, "yaml")
+	if err != nil {
+		t.Fatalf("RenderEnvelope() returned error: %v", err)
+	}
+	want := "status: 204 No Content\nsuccess: true\n"
+	if string(got) != want {
+		t.Errorf("RenderEnvelope() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderEnvelopeInvalidBody(t *testing.T) {
+	henv := client.HTTPEnvelope{
+		StatusCode: http.StatusOK,
+		Body:       []byte("not json"),
+	}
+	if _, err := RenderEnvelope(henv, "json"); err == nil {
+		t.Error("RenderEnvelope() returned no error for a body that is not JSON")
+	}
+}
+
+func TestProblemFromEnvelope(t *testing.T) {
+	p, ok := ProblemFromEnvelope(client.HTTPEnvelope{
+		StatusCode: http.StatusBadRequest,
+		Body:       []byte(`{"title":"Bad Request","detail":"invalid xname"}`),
+	})
+	if !ok {
+		t.Error("ProblemFromEnvelope() did not recognize problem details")
+	}
+	if want := (Problem{Title: "Bad Request", Status: http.StatusBadRequest, Detail: "invalid xname"}); p != want {
+		t.Errorf("ProblemFromEnvelope() = %+v, want %+v", p, want)
+	}
+
+	p, ok = ProblemFromEnvelope(client.HTTPEnvelope{
+		StatusCode: http.StatusInternalServerError,
+		Body:       []byte(`{"error":"boom"}`),
+	})
+	if ok {
+		t.Error("ProblemFromEnvelope() recognized a body without title or detail as problem details")
+	}
+	if want := (Problem{Title: "Internal Server Error", Status: http.StatusInternalServerError, Detail: `{"error":"boom"}`}); p != want {
+		t.Errorf("ProblemFromEnvelope() = %+v, want %+v", p, want)
+	}
+}
+
+func TestRenderEnvelopeWithHeader(t *testing.T) {
+	henv := client.HTTPEnvelope{
+		Method:     http.MethodGet,
+		URL:        "https://foobar.example.com/hsm/v2/State/Components",
+		StatusCode: http.StatusOK,
+		Body:       []byte(`{"Components":[]}`),
+	}
+	got, err := RenderEnvelopeWithHeader(henv, "json", OutputHeader{Cluster: "foobar", Service: "smd"})
+	if err != nil {
+		t.Fatalf("RenderEnvelopeWithHeader() returned error: %v", err)
+	}
+	want := `{"cluster":"foobar","data":{"Components":[]},"request":{"method":"GET","status":200,"url":"https://foobar.example.com/hsm/v2/State/Components"},"service":"smd"}`
+	if string(got) != want {
+		t.Errorf("RenderEnvelopeWithHeader() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+)
+
+// ExitInterrupted is the exit status of a command that was stopped by SIGINT or
+// SIGTERM before it finished. It follows the shell convention for SIGINT
+// (128+2) so that scripts can tell an interrupted run from a failed one.
+const ExitInterrupted = 130
+
+// ExitPartialFailure is the exit status of an iterative command for which some
+// items succeeded and others failed, so that scripts can tell a partial
+// failure, after which only the failed items need to be retried, from a
+// command that failed entirely.
+const ExitPartialFailure = 3
+
+// ExitTimeout is the exit status of a command that gave up waiting for
+// something after its timeout. It follows timeout(1), so that scripts can tell
+// a timeout from a failure.
+const ExitTimeout = 124
+
+// Runner stops long-running operations gracefully when the program receives
+// SIGINT or SIGTERM. Operations check Context (or client.BulkContext, which
+// should be set to it) and stop starting new work once it is done. After the
+// first signal, the default behavior is restored, so a second one terminates
+// the program immediately.
+type Runner struct {
+	ctx  context.Context
+	stop context.CancelFunc
+}
+
+// NewRunner returns a Runner whose context is canceled on the first SIGINT or
+// SIGTERM received.
+func NewRunner() *Runner {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	r := &Runner{ctx: ctx, stop: stop}
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return r
+}
+
+// Context returns the context that is canceled when the program is
+// interrupted.
+func (r *Runner) Context() context.Context {
+	return r.ctx
+}
+
+// Interrupted reports whether the program received SIGINT or SIGTERM since r
+// was created.
+func (r *Runner) Interrupted() bool {
+	return r.ctx.Err() != nil
+}
+
+// Stop stops catching signals and cancels the context of r.
+func (r *Runner) Stop() {
+	r.stop()
+}
+
+// BulkSummary counts the outcomes of the requests of an iterative operation
+// (see client.BulkRequest).
+type BulkSummary struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	NotSent   int
+}
+
+// SummarizeBulk counts the outcomes in errs, the errors returned by an
+// iterative operation for each of its items. Errors wrapping client.ErrNotSent
+// are counted as not sent instead of failed.
+func SummarizeBulk(errs []error) BulkSummary {
+	s := BulkSummary{Total: len(errs)}
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			s.Succeeded++
+		case errors.Is(err, client.ErrNotSent):
+			s.NotSent++
+		default:
+			s.Failed++
+		}
+	}
+	return s
+}
+
+// Partial reports whether some of the requests summarized by s succeeded and
+// others did not.
+func (s BulkSummary) Partial() bool {
+	return s.Succeeded > 0 && s.Succeeded < s.Total
+}
+
+// String returns s in a form fit for a log message, e.g. "3 of 10 succeeded, 1
+// failed, 6 not sent".
+func (s BulkSummary) String() string {
+	return fmt.Sprintf("%d of %d succeeded, %d failed, %d not sent", s.Succeeded, s.Total, s.Failed, s.NotSent)
+}
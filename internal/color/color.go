@@ -0,0 +1,172 @@
+// Package color decides whether output is colored and styles text with ANSI
+// escape codes accordingly. Standard output and standard error are decided
+// separately, since one may be a terminal when the other is not.
+//
+// Color is used if the mode set with Init is Always or, for Auto (the
+// default), if the stream is a terminal, the NO_COLOR environment variable is
+// not set to a non-empty value (see https://no-color.org), and TERM is not
+// "dumb".
+package color
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Mode is the setting of --color.
+type Mode string
+
+const (
+	Auto   Mode = "auto"
+	Always Mode = "always"
+	Never  Mode = "never"
+)
+
+// Modes lists the valid modes.
+var Modes = []Mode{Auto, Always, Never}
+
+// ParseMode parses s as a Mode. The empty string is Auto.
+func ParseMode(s string) (Mode, error) {
+	if s == "" {
+		return Auto, nil
+	}
+	for _, m := range Modes {
+		if strings.EqualFold(s, string(m)) {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("invalid color mode %q (must be auto, always, or never)", s)
+}
+
+// ANSI SGR codes of the styles used by ochami.
+const (
+	codeBold     = 1
+	codeRed      = 31
+	codeGreen    = 32
+	codeYellow   = 33
+	codeBlue     = 34
+	codeMagenta  = 35
+	codeCyan     = 36
+	codeDarkGray = 90
+)
+
+// Styler styles text with ANSI escape codes if it is enabled and returns it
+// unchanged otherwise.
+type Styler struct {
+	enabled bool
+}
+
+var (
+	// Stdout styles text printed to standard output.
+	Stdout = Styler{enabled: decide(Auto, os.Stdout)}
+
+	// Stderr styles text printed to standard error, e.g. logs.
+	Stderr = Styler{enabled: decide(Auto, os.Stderr)}
+
+	mode = Auto
+)
+
+// Init sets the mode and decides again whether Stdout and Stderr color their
+// output. It should be called once the mode is known, before anything is
+// printed.
+func Init(m Mode) {
+	mode = m
+	Stdout.enabled = decide(m, os.Stdout)
+	Stderr.enabled = decide(m, os.Stderr)
+}
+
+// DisableStdout stops Stdout from coloring text unless the mode is Always,
+// e.g. when standard output is redirected to a file.
+func DisableStdout() {
+	if mode != Always {
+		Stdout.enabled = false
+	}
+}
+
+// decide reports whether output to f is colored in mode m.
+func decide(m Mode, f *os.File) bool {
+	switch m {
+	case Always:
+		return true
+	case Never:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(f)
+}
+
+// IsTerminal reports whether f is a terminal (character device).
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Enabled reports whether s colors text.
+func (s Styler) Enabled() bool {
+	return s.enabled
+}
+
+// style returns v formatted with %v and wrapped in ANSI code c if s is
+// enabled.
+func (s Styler) style(c int, v any) string {
+	if !s.enabled {
+		return fmt.Sprint(v)
+	}
+	return fmt.Sprintf("\x1b[%dm%v\x1b[0m", c, v)
+}
+
+// Bold, Red, Green, Yellow, Blue, Magenta, Cyan, and DarkGray return v,
+// formatted with %v, in the respective style if s is enabled.
+func (s Styler) Bold(v any) string     { return s.style(codeBold, v) }
+func (s Styler) Red(v any) string      { return s.style(codeRed, v) }
+func (s Styler) Green(v any) string    { return s.style(codeGreen, v) }
+func (s Styler) Yellow(v any) string   { return s.style(codeYellow, v) }
+func (s Styler) Blue(v any) string     { return s.style(codeBlue, v) }
+func (s Styler) Magenta(v any) string  { return s.style(codeMagenta, v) }
+func (s Styler) Cyan(v any) string     { return s.style(codeCyan, v) }
+func (s Styler) DarkGray(v any) string { return s.style(codeDarkGray, v) }
+
+// Table is a tabwriter.Writer for tables whose first line is a header, which
+// is printed in bold if Stdout is enabled. Since escape codes would throw off
+// the column widths computed by the tabwriter, the table is aligned first and
+// only styled when it is flushed.
+type Table struct {
+	*tabwriter.Writer
+	out     io.Writer
+	buf     strings.Builder
+	flushed bool
+}
+
+// NewTable returns a Table writing to out with the padding used for ochami's
+// tables.
+func NewTable(out io.Writer) *Table {
+	t := &Table{out: out}
+	t.Writer = tabwriter.NewWriter(&t.buf, 0, 0, 2, ' ', 0)
+	return t
+}
+
+// Flush aligns the table and writes it to its output, with the header in
+// bold.
+func (t *Table) Flush() error {
+	if err := t.Writer.Flush(); err != nil {
+		return err
+	}
+	s := t.buf.String()
+	t.buf.Reset()
+	if !t.flushed && s != "" {
+		if header, rest, ok := strings.Cut(s, "\n"); ok {
+			s = Stdout.Bold(strings.TrimRight(header, " ")) + "\n" + rest
+		}
+		t.flushed = true
+	}
+	_, err := io.WriteString(t.out, s)
+	return err
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// AgeKeyEnvVar is the environment variable holding the age identities (secret
+// keys, e.g. AGE-SECRET-KEY-1...) that secrets encrypted with age in the
+// config file are decrypted with. If it is not set, they are read from the
+// identity file (see AgeIdentityFile).
+const AgeKeyEnvVar = "OCHAMI_AGE_KEY"
+
+// AgeIdentityFile returns the path of the file holding the age identities
+// that secrets are decrypted with if AgeKeyEnvVar is not set: the one set with
+// the age-identity key of the config, or else ~/.config/ochami/age-key.txt.
+func AgeIdentityFile() (string, error) {
+	if GlobalConfig.AgeIdentity != "" {
+		return GlobalConfig.AgeIdentity, nil
+	}
+	u, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch current user: %w", err)
+	}
+	return filepath.Join(u.HomeDir, ".config", ProgName, "age-key.txt"), nil
+}
+
+// ageIdentities returns the age identities that secrets are decrypted with,
+// read from AgeKeyEnvVar if it is set or else from the identity file.
+func ageIdentities() ([]age.Identity, error) {
+	if k := os.Getenv(AgeKeyEnvVar); k != "" {
+		ids, err := age.ParseIdentities(strings.NewReader(k))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse age identities in %s: %w", AgeKeyEnvVar, err)
+		}
+		return ids, nil
+	}
+	path, err := AgeIdentityFile()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s not set and failed to open age identity file: %w", AgeKeyEnvVar, err)
+	}
+	defer f.Close()
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse age identities in %s: %w", path, err)
+	}
+	return ids, nil
+}
+
+// DecryptAge decrypts blob, an ASCII-armored age file (as printed by "age
+// --armor"), with the identities of ageIdentities and returns its plaintext.
+// Trailing newlines are removed, as for secrets read from a file.
+func DecryptAge(blob string) (string, error) {
+	ids, err := ageIdentities()
+	if err != nil {
+		return "", err
+	}
+	// Armored blobs are often indented in YAML block scalars, which the
+	// armor reader does not accept
+	lines := strings.Split(strings.TrimSpace(blob), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(strings.Join(lines, "\n")+"\n")), ids...)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt age-encrypted secret: %w", err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt age-encrypted secret: %w", err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
@@ -3,14 +3,15 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
+	"strings"
 
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/go-viper/mapstructure/v2"
 	kyaml "github.com/knadh/koanf/parsers/yaml"
-	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/providers/structs"
 	"github.com/knadh/koanf/v2"
 	"gopkg.in/yaml.v3"
@@ -18,9 +19,19 @@ import (
 
 // Config represents the structure of a configuration file.
 type Config struct {
-	Log            ConfigLog       `yaml:"log,omitempty"`
-	DefaultCluster string          `yaml:"default-cluster,omitempty"`
-	Clusters       []ConfigCluster `yaml:"clusters,omitempty"`
+	ConfigVersion    int                     `yaml:"config-version,omitempty"`
+	Log              ConfigLog               `yaml:"log,omitempty"`
+	Color            string                  `yaml:"color,omitempty"`
+	FormatOutput     string                  `yaml:"format-output,omitempty"`
+	FormatOutputPipe string                  `yaml:"format-output-pipe,omitempty"`
+	FormatInput      string                  `yaml:"format-input,omitempty"`
+	Commands         map[string]ConfigFormat `yaml:"commands,omitempty"`
+	DefaultCluster   string                  `yaml:"default-cluster,omitempty"`
+	Clusters         []ConfigCluster         `yaml:"clusters,omitempty"`
+	ClusterTemplates []ConfigCluster         `yaml:"cluster-templates,omitempty"`
+	Remote           []ConfigRemote          `yaml:"remote,omitempty"`
+	AgeIdentity      string                  `yaml:"age-identity,omitempty"`
+	Features         ConfigFeatures          `yaml:"features,omitempty"`
 }
 
 type ConfigLog struct {
@@ -28,13 +39,174 @@ type ConfigLog struct {
 	Level  string `yaml:"level,omitempty"`
 }
 
+// ConfigFeatures holds the experimental features to enable and whether to warn
+// about the use of deprecated commands and flags, which is the default.
+type ConfigFeatures struct {
+	Enable              []string `yaml:"enable,omitempty"`
+	DeprecationWarnings *bool    `yaml:"deprecation-warnings,omitempty"`
+}
+
 type ConfigCluster struct {
 	Name    string              `yaml:"name,omitempty"`
 	Cluster ConfigClusterConfig `yaml:"cluster,omitempty"`
 }
 
 type ConfigClusterConfig struct {
-	BaseURI string `yaml:"base-uri,omitempty"`
+	BaseURI        string            `yaml:"base-uri,omitempty"`
+	FailoverURIs   []string          `yaml:"failover-uris,omitempty"`
+	PinSHA256      []string          `yaml:"pin-sha256,omitempty"`
+	CACerts        []string          `yaml:"ca-certs,omitempty"`
+	UseSystemStore *bool             `yaml:"use-system-store,omitempty"`
+	Compress       bool              `yaml:"compress,omitempty"`
+	IPVersion      string            `yaml:"ip-version,omitempty"`
+	SSHTunnel      string            `yaml:"ssh-tunnel,omitempty"`
+	Tenant         string            `yaml:"tenant,omitempty"`
+	TenantHeader   string            `yaml:"tenant-header,omitempty"`
+	Token          ConfigSecretRef   `yaml:"token,omitempty"`
+	TokenSource    string            `yaml:"token-source,omitempty"`
+	Attestation    ConfigAttestation `yaml:"attestation,omitempty"`
+	Defaults       ConfigDefaults    `yaml:"defaults,omitempty"`
+	Discover       ConfigDiscover    `yaml:"discover,omitempty"`
+}
+
+// SystemStore reports whether the system's trust store is trusted to verify
+// the certificates of the cluster's services in addition to CACerts, which is
+// the case unless use-system-store is set to false.
+func (c ConfigClusterConfig) SystemStore() bool {
+	return c.UseSystemStore == nil || *c.UseSystemStore
+}
+
+// ConfigAttestation holds the settings of the attestation token source, which
+// exchanges an attestation document read from Document, or printed by
+// DocumentCommand, for a token issued by the service at TokenURI.
+type ConfigAttestation struct {
+	TokenURI        string `yaml:"token-uri,omitempty"`
+	Document        string `yaml:"document,omitempty"`
+	DocumentCommand string `yaml:"document-command,omitempty"`
+	CACert          string `yaml:"cacert,omitempty"`
+}
+
+// ConfigDefaults holds defaults that apply only when a cluster is used,
+// overriding the global ones.
+type ConfigDefaults struct {
+	Log          ConfigLog               `yaml:"log,omitempty"`
+	FormatOutput string                  `yaml:"format-output,omitempty"`
+	FormatInput  string                  `yaml:"format-input,omitempty"`
+	Commands     map[string]ConfigFormat `yaml:"commands,omitempty"`
+}
+
+// ConfigDiscover holds the settings used by "ochami discover" for a cluster
+// when the corresponding flags are not passed.
+type ConfigDiscover struct {
+	BMCUsername   ConfigSecretRef           `yaml:"bmc-username,omitempty"`
+	BMCPassword   ConfigSecretRef           `yaml:"bmc-password,omitempty"`
+	RedfishScheme string                    `yaml:"redfish-scheme,omitempty"`
+	RedfishPort   int                       `yaml:"redfish-port,omitempty"`
+	Groups        []ConfigDiscoverGroupRule `yaml:"groups,omitempty"`
+}
+
+// ConfigDiscoverGroupRule assigns nodes whose xname matches Pattern (a glob,
+// e.g. "x1000c1s*b0n*") to Group.
+type ConfigDiscoverGroupRule struct {
+	Pattern string `yaml:"pattern,omitempty"`
+	Group   string `yaml:"group,omitempty"`
+}
+
+// ConfigSecretRef refers to a secret kept either outside of the config file, in
+// the environment variable named by Env or in File, or in the config file
+// encrypted with age in Age (see DecryptAge), so that the config file can be
+// shared. If several are set, Env is tried first, then Age, then File.
+type ConfigSecretRef struct {
+	Env  string `yaml:"env,omitempty"`
+	Age  string `yaml:"age,omitempty"`
+	File string `yaml:"file,omitempty"`
+}
+
+// IsSet reports whether r refers to a secret.
+func (r ConfigSecretRef) IsSet() bool {
+	return r.Env != "" || r.Age != "" || r.File != ""
+}
+
+// Resolve returns the secret r refers to. Trailing newlines are removed from
+// secrets read from a file or decrypted. An error is returned if the
+// environment variable is unset or empty and nothing else is set, or if the
+// file cannot be read or the secret cannot be decrypted.
+func (r ConfigSecretRef) Resolve() (string, error) {
+	if r.Env != "" {
+		if v := os.Getenv(r.Env); v != "" {
+			return v, nil
+		}
+		if r.Age == "" && r.File == "" {
+			return "", fmt.Errorf("environment variable %s is not set", r.Env)
+		}
+	}
+	if r.Age != "" {
+		return DecryptAge(r.Age)
+	}
+	if r.File != "" {
+		b, err := os.ReadFile(r.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+	return "", nil
+}
+
+// Redacted is the value that secrets are replaced with by Redacted methods.
+const Redacted = "REDACTED"
+
+// Redacted returns r with its age-encrypted secret, if any, replaced with
+// Redacted. The environment variable and file it refers to are kept since
+// they do not reveal the secret.
+func (r ConfigSecretRef) Redacted() ConfigSecretRef {
+	if r.Age != "" {
+		r.Age = Redacted
+	}
+	return r
+}
+
+// Redacted returns a copy of c with the secrets of its clusters and cluster
+// templates (see ConfigSecretRef.Redacted), and the passwords in the URLs of
+// its remote config files, replaced with Redacted, so that it can be shared.
+func (c Config) Redacted() Config {
+	redactClusters := func(clusters []ConfigCluster) []ConfigCluster {
+		if clusters == nil {
+			return nil
+		}
+		out := make([]ConfigCluster, len(clusters))
+		for i, cl := range clusters {
+			cl.Cluster.Token = cl.Cluster.Token.Redacted()
+			cl.Cluster.Discover.BMCUsername = cl.Cluster.Discover.BMCUsername.Redacted()
+			cl.Cluster.Discover.BMCPassword = cl.Cluster.Discover.BMCPassword.Redacted()
+			out[i] = cl
+		}
+		return out
+	}
+	c.Clusters = redactClusters(c.Clusters)
+	c.ClusterTemplates = redactClusters(c.ClusterTemplates)
+	if c.Remote != nil {
+		remote := make([]ConfigRemote, len(c.Remote))
+		for i, r := range c.Remote {
+			if u, err := url.Parse(r.URL); err == nil && u.User != nil {
+				if _, ok := u.User.Password(); ok {
+					u.User = url.UserPassword(u.User.Username(), Redacted)
+					r.URL = u.String()
+				}
+			}
+			remote[i] = r
+		}
+		c.Remote = remote
+	}
+	return c
+}
+
+// ConfigFormat holds the formats used by a single command (keyed by its path
+// without the program name, e.g. "smd component get") when --output-format or
+// --payload-format are not passed.
+type ConfigFormat struct {
+	FormatOutput string `yaml:"format-output,omitempty"`
+	FormatInput  string `yaml:"format-input,omitempty"`
 }
 
 const ProgName = "ochami"
@@ -104,78 +276,49 @@ func RemoveFromSlice[T any](slice []T, index int) []T {
 // is an error in this process or there is a config error (e.g. there is a key
 // specified that doesn't exist in the config struct), an error is returned.
 // Otherwise, nil is returned.
+//
+// path can also be an https:// URL, in which case the config file is fetched
+// from it (see FetchRemote). Remote config files included by a config file
+// with the "remote" key are merged beneath it.
 func LoadConfig(path string) error {
 	earlyLog("early verbose log messages activated")
 
 	// Initialize global koanf structure
 	GlobalKoanf = koanf.NewWithConf(kConfig)
 
-	// If a config file was specified, load it alone. Do not try to merge
-	// its config with any other configuration.
+	var cfgsLoaded []fileConfig
 	if path != "" {
+		// If a config file was specified, load it alone. Do not try to
+		// merge its config with any other configuration besides the
+		// remote config files it includes.
 		earlyLogf("using passed config file %s", path)
-		earlyLogf("parsing %s", path)
-		if err := GlobalKoanf.Load(file.Provider(path), configParser); err != nil {
+		cfgs, err := loadConfigFile(path)
+		if err != nil {
 			return fmt.Errorf("failed to load specified config file %s: %w", path, err)
 		}
-		earlyLog("unmarshalling config into config struct")
-		if err := GlobalKoanf.UnmarshalWithConf("", nil, kUnmarshalConf); err != nil {
-			return fmt.Errorf("failed to unmarshal config from file %s: %w", path, err)
+		cfgsLoaded = cfgs
+	} else {
+		// Otherwise, we merge the config from the system and user config files.
+		earlyLog("no config file specified on command line, attempting to merge configs")
+
+		// Generate user config path: ~/.config/ochami/config.yaml
+		user, err := user.Current()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: unable to fetch current user: %v\n", ProgName, err)
+			os.Exit(1)
 		}
-		return nil
-	}
-	// Otherwise, we merge the config from the system and user config files.
-	earlyLog("no config file specified on command line, attempting to merge configs")
-
-	// Generate user config path: ~/.config/ochami/config.yaml
-	user, err := user.Current()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s: unable to fetch current user: %v\n", ProgName, err)
-		os.Exit(1)
-	}
-	UserConfigFile = filepath.Join(user.HomeDir, ".config", "ochami", "config.yaml")
-
-	// Read config from each file in slice
-	type FileCfgMap struct {
-		File string
-		Cfg  Config
-	}
-	cfgsToCheck := []FileCfgMap{
-		FileCfgMap{File: SystemConfigFile},
-		FileCfgMap{File: UserConfigFile},
-	}
-	var cfgsLoaded []FileCfgMap
-	for _, cfg := range cfgsToCheck {
-		// Create koanf struct to load config from this file into
-		ko := koanf.NewWithConf(kConfig)
-
-		// Create config struct to unmarshal config from this file into
-		var c Config
-
-		// Copy global koanf unmarshal config, but unmarshal into config
-		// struct we made above
-		umc := kUnmarshalConf
-		umc.DecoderConfig.Result = &c
-
-		// Load config file into koanf struct
-		earlyLogf("attempting to load config file: %s", cfg.File)
-		err := ko.Load(file.Provider(cfg.File), configParser)
-		if errors.Is(err, os.ErrNotExist) {
-			earlyLogf("config file %s not found, skipping", cfg.File)
-			continue
-		} else if err != nil {
-			return fmt.Errorf("failed to load config file %s: %w", cfg.File, err)
-		}
-
-		// Unmarshal loaded config into local config struct to lint
-		// (i.e. check for unknown keys, etc).
-		if err := ko.UnmarshalWithConf("", nil, umc); err != nil {
-			return fmt.Errorf("failed to unmarshal config from %s: %w", cfg.File, err)
+		UserConfigFile = filepath.Join(user.HomeDir, ".config", "ochami", "config.yaml")
+
+		for _, f := range []string{SystemConfigFile, UserConfigFile} {
+			cfgs, err := loadConfigFile(f)
+			if errors.Is(err, os.ErrNotExist) {
+				earlyLogf("config file %s not found, skipping", f)
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to load config file %s: %w", f, err)
+			}
+			cfgsLoaded = append(cfgsLoaded, cfgs...)
 		}
-
-		// Add local config struct to slice of loaded configs
-		cfg.Cfg = c
-		cfgsLoaded = append(cfgsLoaded, cfg)
 	}
 
 	// Merge loaded configs into global config. If none loaded, use default
@@ -204,6 +347,80 @@ func LoadConfig(path string) error {
 	return nil
 }
 
+// fileConfig is the config loaded from a config file.
+type fileConfig struct {
+	File string
+	Cfg  Config
+}
+
+// loadConfigFile loads and lints the config file at path, which can be a URL,
+// and returns its config preceded by the configs of the remote config files
+// it includes, in the order in which they should be merged. If path does not
+// exist, the returned error wraps os.ErrNotExist.
+func loadConfigFile(path string) ([]fileConfig, error) {
+	c, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfgs []fileConfig
+	for _, r := range c.Remote {
+		earlyLogf("%s includes remote config %s", path, r.URL)
+		if err := CheckRemoteURL(r.URL); err != nil {
+			return nil, err
+		}
+		ttl, err := r.GetTTL()
+		if err != nil {
+			return nil, err
+		}
+		rc, err := readRemoteConfig(r.URL, ttl)
+		if err != nil {
+			return nil, err
+		}
+		cfgs = append(cfgs, fileConfig{File: r.URL, Cfg: rc})
+	}
+	// The includes have been resolved and must not be merged, since
+	// their entries have no key to be merged by.
+	c.Remote = nil
+
+	return append(cfgs, fileConfig{File: path, Cfg: c}), nil
+}
+
+// readConfigFile loads the config file at path, which can be a URL, into a
+// Config, checking it for errors (e.g. unknown keys).
+func readConfigFile(path string) (Config, error) {
+	if IsRemote(path) {
+		return readRemoteConfig(path, DefaultRemoteTTL)
+	}
+
+	var c Config
+	ko := koanf.NewWithConf(kConfig)
+
+	// Copy global koanf unmarshal config, but unmarshal into config struct
+	// we made above
+	umc := kUnmarshalConf
+	umc.DecoderConfig.Result = &c
+
+	// Load config file into koanf struct, migrating it first if it has an
+	// older layout
+	earlyLogf("attempting to load config file: %s", path)
+	data, err := readConfigData(path, true)
+	if err != nil {
+		return c, err
+	}
+	if err := ko.Load(bytesProvider(data), configParser); err != nil {
+		return c, err
+	}
+
+	// Unmarshal loaded config into local config struct to lint (i.e.
+	// check for unknown keys, etc).
+	if err := ko.UnmarshalWithConf("", nil, umc); err != nil {
+		return c, fmt.Errorf("failed to unmarshal config from %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
 // ModifyConfig modifies a single key in a config file. It does this by opening
 // the config file and loading it into a koanf instance, using koanf to modify
 // the key with the new value, unmarshalling the config into a config struct,
@@ -286,10 +503,17 @@ func ReadConfig(path string) (Config, error) {
 	if path == "" {
 		return cfg, fmt.Errorf("no configuration file passed")
 	}
-	log.Logger.Debug().Msgf("reading config file: %s", path)
+	if IsRemote(path) {
+		return cfg, fmt.Errorf("remote config file %s is read-only", path)
+	}
+	log.ConfigLogger.Debug().Msgf("reading config file: %s", path)
 
 	ko := koanf.NewWithConf(kConfig)
-	if err := ko.Load(file.Provider(path), configParser); err != nil {
+	data, err := readConfigData(path, false)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to load config file %s: %w", path, err)
+	}
+	if err := ko.Load(bytesProvider(data), configParser); err != nil {
 		return cfg, fmt.Errorf("failed to load config file %s: %w", path, err)
 	}
 	kuc := kUnmarshalConf
@@ -311,8 +535,12 @@ func WriteConfig(path string, cfg Config) error {
 	if path == "" {
 		return fmt.Errorf("no configuration file path passed")
 	}
-	log.Logger.Debug().Msgf("writing config file: %s", path)
+	if IsRemote(path) {
+		return fmt.Errorf("remote config file %s is read-only", path)
+	}
+	log.ConfigLogger.Debug().Msgf("writing config file: %s", path)
 
+	cfg.ConfigVersion = ConfigVersion
 	c, err := yaml.Marshal(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config for writing: %w", err)
@@ -328,7 +556,7 @@ func WriteConfig(path string, cfg Config) error {
 	if err := os.WriteFile(path, c, fmode); err != nil {
 		return fmt.Errorf("failed to write config to file %s: %w", path, err)
 	}
-	log.Logger.Info().Msgf("wrote config to %s", path)
+	log.ConfigLogger.Info().Msgf("wrote config to %s", path)
 
 	return nil
 }
@@ -341,3 +569,91 @@ func mergeConfig(src, dst map[string]interface{}) error {
 	// cluster list.
 	return MergeMaps(src, dst, "name")
 }
+
+// GetCluster returns the cluster in clusters (e.g. Config.Clusters or
+// Config.ClusterTemplates) named name and its index, or nil and -1 if there is
+// none.
+func GetCluster(clusters []ConfigCluster, name string) (*ConfigCluster, int) {
+	for i := range clusters {
+		if clusters[i].Name == name {
+			return &clusters[i], i
+		}
+	}
+	return nil, -1
+}
+
+// CopyCluster returns a copy of src named name with the keys in overrides set
+// to their values. Keys are relative to a cluster's entry in the clusters list,
+// e.g. "cluster.base-uri" or "cluster.defaults.format-output". Values are
+// parsed as YAML, so lists can be passed in flow style (e.g. "[a, b]"). An
+// error is returned if a key is not a valid cluster key or a value has the
+// wrong type for its key.
+func CopyCluster(src ConfigCluster, name string, overrides map[string]string) (ConfigCluster, error) {
+	var dst ConfigCluster
+
+	// Overrides replace values of a different Go type (e.g. a
+	// []interface{} parsed from YAML replaces a []string), so merging
+	// cannot be strict here
+	ko := koanf.NewWithConf(koanf.Conf{Delim: kConfig.Delim})
+	if err := ko.Load(structs.Provider(src, "yaml"), nil); err != nil {
+		return dst, fmt.Errorf("failed to load config of cluster %s: %w", src.Name, err)
+	}
+	for key, value := range overrides {
+		if key == "name" {
+			return dst, fmt.Errorf("cannot override name of cluster %s", name)
+		}
+		var v interface{} = value
+		if value != "" {
+			if err := yaml.Unmarshal([]byte(value), &v); err != nil {
+				return dst, fmt.Errorf("failed to parse value of %s: %w", key, err)
+			}
+		}
+		if err := ko.Set(key, v); err != nil {
+			return dst, fmt.Errorf("failed to set key %s to value %v: %w", key, value, err)
+		}
+	}
+	if err := ko.Set("name", name); err != nil {
+		return dst, fmt.Errorf("failed to set cluster name to %s: %w", name, err)
+	}
+
+	dc := *kUnmarshalConf.DecoderConfig
+	dc.Result = &dst
+	kuc := kUnmarshalConf
+	kuc.DecoderConfig = &dc
+	if err := ko.UnmarshalWithConf("", nil, kuc); err != nil {
+		return dst, fmt.Errorf("invalid config for cluster %s: %w", name, err)
+	}
+
+	return dst, nil
+}
+
+// Formats returns the default output and input formats configured for the
+// command at cmdPath (without the program name, e.g. "smd component get") when
+// cluster is used, which may be nil. From lowest to highest precedence, the
+// defaults are taken from the global format-output/format-input keys, the
+// cluster's defaults, the global commands key, and the cluster's commands key.
+// Formats that are not configured are returned empty.
+func (c Config) Formats(cluster *ConfigCluster, cmdPath string) (output, input string) {
+	apply := func(o, i string) {
+		if o != "" {
+			output = o
+		}
+		if i != "" {
+			input = i
+		}
+	}
+	apply(c.FormatOutput, c.FormatInput)
+	if cluster != nil {
+		d := cluster.Cluster.Defaults
+		apply(d.FormatOutput, d.FormatInput)
+	}
+	if f, ok := c.Commands[cmdPath]; ok {
+		apply(f.FormatOutput, f.FormatInput)
+	}
+	if cluster != nil {
+		if f, ok := cluster.Cluster.Defaults.Commands[cmdPath]; ok {
+			apply(f.FormatOutput, f.FormatInput)
+		}
+	}
+	return
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigVersion is the version of the config file layout that this version of
+// ochami reads and writes, stored in the config-version key. Config files
+// with a lower version, or without one, are migrated when loaded (see
+// Migrate).
+const ConfigVersion = 1
+
+// AutoMigrate controls whether config files that need to be migrated are
+// rewritten when loaded, after backing up the original. If false, or if the
+// file cannot be written, they are only migrated in memory.
+var AutoMigrate = true
+
+// migration upgrades a config file layout by one version. migrate modifies the
+// config, as unmarshalled from YAML, in place and returns a description of
+// each change it made.
+type migration struct {
+	description string
+	migrate     func(cfg map[string]interface{}) []string
+}
+
+// migrations[i] migrates config files from version i to version i+1.
+var migrations = []migration{
+	{
+		description: "move cluster settings under the cluster key and rename uri to base-uri",
+		migrate:     migrateV0,
+	},
+}
+
+// Migrate upgrades cfg, a config file as unmarshalled from YAML, in place to
+// ConfigVersion and returns a description of each change made. If the layout
+// of cfg is already current, no changes are made, not even to config-version,
+// so that up-to-date files are not needlessly rewritten. An error is returned
+// if cfg has an invalid config-version or one newer than ConfigVersion.
+func Migrate(cfg map[string]interface{}) ([]string, error) {
+	version := 0
+	if v, ok := cfg["config-version"]; ok {
+		iv, ok := v.(int)
+		if !ok || iv < 0 {
+			return nil, fmt.Errorf("invalid config-version %v", v)
+		}
+		version = iv
+	}
+	if version > ConfigVersion {
+		return nil, fmt.Errorf("config-version %d is newer than the latest version supported by this version of %s (%d), upgrade %s", version, ProgName, ConfigVersion, ProgName)
+	}
+
+	var changes []string
+	for v := version; v < ConfigVersion; v++ {
+		earlyLogf("checking for config migration from version %d to %d: %s", v, v+1, migrations[v].description)
+		changes = append(changes, migrations[v].migrate(cfg)...)
+	}
+	if len(changes) > 0 {
+		cfg["config-version"] = ConfigVersion
+		changes = append(changes, fmt.Sprintf("set config-version to %d", ConfigVersion))
+	}
+
+	return changes, nil
+}
+
+// MigrateFile migrates the config file at path (see Migrate) and returns the
+// changes made. If there are changes and dryRun is false, the original file is
+// copied to a backup, whose path is returned, and the migrated config is
+// written to path.
+func MigrateFile(path string, dryRun bool) (changes []string, backup string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	migrated, changes, err := migrateData(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to migrate config file %s: %w", path, err)
+	}
+	if len(changes) == 0 || dryRun {
+		return changes, "", nil
+	}
+	backup, err = writeMigrated(path, data, migrated)
+	return changes, backup, err
+}
+
+// migrateData migrates data, the contents of a config file, and returns the
+// migrated contents along with the changes made. If there are no changes, data
+// is returned as is.
+func migrateData(data []byte) ([]byte, []string, error) {
+	var cfg map[string]interface{}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	if cfg == nil {
+		return data, nil, nil
+	}
+	changes, err := Migrate(cfg)
+	if err != nil || len(changes) == 0 {
+		return data, nil, err
+	}
+	migrated, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal migrated config: %w", err)
+	}
+	return migrated, changes, nil
+}
+
+// writeMigrated backs up original, the contents of the config file at path,
+// next to it and replaces the file with migrated. The path of the backup is
+// returned.
+func writeMigrated(path string, original, migrated []byte) (string, error) {
+	var fmode os.FileMode = 0o644
+	if finfo, err := os.Stat(path); err == nil {
+		fmode = finfo.Mode()
+	}
+	backup := fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102T150405"))
+	if err := os.WriteFile(backup, original, fmode); err != nil {
+		return "", fmt.Errorf("failed to back up config file %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, migrated, fmode); err != nil {
+		return backup, fmt.Errorf("failed to write migrated config file %s: %w", path, err)
+	}
+	return backup, nil
+}
+
+// readConfigData reads the config file at path and migrates its contents,
+// which are returned. If migrating changed them and write is true and
+// AutoMigrate is set, the file is also rewritten (see MigrateFile). If that
+// fails, a warning is printed and the migrated contents are still returned.
+func readConfigData(path string, write bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	migrated, changes, err := migrateData(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate config file %s: %w", path, err)
+	}
+	if len(changes) == 0 {
+		return data, nil
+	}
+	for _, c := range changes {
+		earlyLogf("migrating %s: %s", path, c)
+	}
+	if !write || !AutoMigrate {
+		return migrated, nil
+	}
+	backup, err := writeMigrated(path, data, migrated)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: warning: %v; config was migrated in memory only, run '%s config migrate' to migrate it\n", ProgName, err, ProgName)
+		return migrated, nil
+	}
+	fmt.Fprintf(os.Stderr, "%s: migrated config file %s to config-version %d (original saved to %s)\n", ProgName, path, ConfigVersion, backup)
+
+	return migrated, nil
+}
+
+// bytesProvider is a koanf.Provider for config file contents that have already
+// been read, e.g. by readConfigData.
+type bytesProvider []byte
+
+func (b bytesProvider) ReadBytes() ([]byte, error) {
+	return b, nil
+}
+
+func (b bytesProvider) Read() (map[string]interface{}, error) {
+	return nil, errors.New("bytesProvider does not support Read()")
+}
+
+// clusterKeys are the keys of the cluster block of a cluster config, which
+// version 0 config files could also have next to the cluster name.
+var clusterKeys = []string{"base-uri", "uri", "pin-sha256", "defaults"}
+
+// migrateV0 migrates config files from version 0, where cluster settings
+// could be set next to the cluster name instead of under the cluster key and
+// the base URI could be set with uri instead of base-uri.
+func migrateV0(cfg map[string]interface{}) []string {
+	var changes []string
+	for _, listKey := range []string{"clusters", "cluster-templates"} {
+		list, ok := cfg[listKey].([]interface{})
+		if !ok {
+			continue
+		}
+		for i, item := range list {
+			cl, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name := fmt.Sprintf("%s[%d]", listKey, i)
+			if n, ok := cl["name"].(string); ok && n != "" {
+				name = fmt.Sprintf("%s[%s]", listKey, n)
+			}
+			block, ok := cl["cluster"].(map[string]interface{})
+			if !ok {
+				block = make(map[string]interface{})
+			}
+			for _, k := range clusterKeys {
+				v, ok := cl[k]
+				if !ok {
+					continue
+				}
+				if _, exists := block[k]; !exists {
+					block[k] = v
+				}
+				delete(cl, k)
+				changes = append(changes, fmt.Sprintf("%s: moved %s under cluster", name, k))
+			}
+			if uri, ok := block["uri"]; ok {
+				if _, exists := block["base-uri"]; exists {
+					changes = append(changes, fmt.Sprintf("%s: removed cluster.uri, superseded by cluster.base-uri", name))
+				} else {
+					block["base-uri"] = uri
+					changes = append(changes, fmt.Sprintf("%s: renamed cluster.uri to cluster.base-uri", name))
+				}
+				delete(block, "uri")
+			}
+			if len(block) > 0 {
+				cl["cluster"] = block
+			}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/pkg/timeparse"
+	"github.com/knadh/koanf/v2"
+)
+
+// DefaultRemoteTTL is how long a fetched remote config file is used before it
+// is fetched again, unless its include directive specifies otherwise.
+const DefaultRemoteTTL = time.Hour
+
+// remoteTimeout is the timeout for fetching a remote config file.
+const remoteTimeout = 30 * time.Second
+
+// ConfigRemote is an include directive for a centrally-managed config file
+// served over HTTP(S). Its config is merged beneath the config of the file
+// including it.
+type ConfigRemote struct {
+	URL string `yaml:"url"`
+	TTL string `yaml:"ttl,omitempty"`
+}
+
+// GetTTL returns the TTL of r, or DefaultRemoteTTL if it is not set.
+func (r ConfigRemote) GetTTL() (time.Duration, error) {
+	if r.TTL == "" {
+		return DefaultRemoteTTL, nil
+	}
+	ttl, err := timeparse.ParseDuration(r.TTL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ttl %q for remote config %s: %w", r.TTL, r.URL, err)
+	}
+	return ttl, nil
+}
+
+// IsRemote reports whether path is the URL of a remote config file rather
+// than a file path. Only https:// URLs can be fetched (see CheckRemoteURL), but
+// http:// URLs are recognized too so that they are not mistaken for paths.
+func IsRemote(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+}
+
+// CheckRemoteURL returns an error if url cannot be fetched as a remote config
+// file. Remote config files must be fetched over https://, since they are
+// trusted to define the clusters that tokens are sent to.
+func CheckRemoteURL(url string) error {
+	if !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("remote config URL %q must start with https://", url)
+	}
+	return nil
+}
+
+// RemoteCacheFile returns the path of the file that caches the remote config
+// file at url, in the user's cache directory.
+func RemoteCacheFile(url string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine user cache directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, ProgName, "remote-config", hex.EncodeToString(sum[:8])+".yaml"), nil
+}
+
+// FetchRemote returns the path of a local copy of the remote config file at
+// url. If the cached copy was fetched less than ttl ago, it is used as is.
+// Otherwise, the file is fetched again and validated (see readRemoteConfig)
+// before replacing the cached copy, so an invalid file never replaces a valid
+// one. If fetching fails but a cached copy exists, a warning is printed and the
+// stale copy is used so that commands keep working while the server is
+// unreachable.
+func FetchRemote(url string, ttl time.Duration) (string, error) {
+	if err := CheckRemoteURL(url); err != nil {
+		return "", err
+	}
+	cacheFile, err := RemoteCacheFile(url)
+	if err != nil {
+		return "", err
+	}
+	fi, statErr := os.Stat(cacheFile)
+	if statErr == nil && time.Since(fi.ModTime()) < ttl {
+		earlyLogf("using cached copy %s of remote config %s", cacheFile, url)
+		return cacheFile, nil
+	}
+
+	earlyLogf("fetching remote config %s", url)
+	if err := fetchRemote(url, cacheFile); err != nil {
+		if statErr != nil {
+			return "", err
+		}
+		fmt.Fprintf(os.Stderr, "%s: warning: %v; using cached copy from %s\n", ProgName, err, fi.ModTime().Format(time.RFC3339))
+	}
+
+	return cacheFile, nil
+}
+
+// fetchRemote fetches the config file at url, validates it, and atomically
+// writes it to cacheFile.
+func fetchRemote(url, cacheFile string) error {
+	// Use a fresh transport so that proxies are honored from the environment.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	hc := &http.Client{Transport: transport, Timeout: remoteTimeout}
+	res, err := hc.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch remote config: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch remote config %s: %s", url, res.Status)
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read remote config %s: %w", url, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory for remote config: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(cacheFile), ".remote-config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for remote config: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file for remote config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write temporary file for remote config: %w", err)
+	}
+	if _, err := validateRemoteConfig(tmp.Name()); err != nil {
+		return fmt.Errorf("invalid remote config %s: %w", url, err)
+	}
+	if err := os.Rename(tmp.Name(), cacheFile); err != nil {
+		return fmt.Errorf("failed to cache remote config: %w", err)
+	}
+
+	return nil
+}
+
+// readRemoteConfig fetches (see FetchRemote) and loads the remote config file
+// at url.
+func readRemoteConfig(url string, ttl time.Duration) (Config, error) {
+	path, err := FetchRemote(url, ttl)
+	if err != nil {
+		return Config{}, err
+	}
+	c, err := validateRemoteConfig(path)
+	if err != nil {
+		return c, fmt.Errorf("invalid remote config %s: %w", url, err)
+	}
+	return c, nil
+}
+
+// validateRemoteConfig loads the local copy at path of a remote config file,
+// checking it like any config file (e.g. for unknown keys) and for keys that
+// remote config files cannot set (see checkRemoteKeys). Remote config files
+// with an older layout are migrated in memory only.
+func validateRemoteConfig(path string) (Config, error) {
+	var c Config
+	ko := koanf.NewWithConf(kConfig)
+	data, err := readConfigData(path, false)
+	if err != nil {
+		return c, err
+	}
+	if err := ko.Load(bytesProvider(data), configParser); err != nil {
+		return c, err
+	}
+	umc := kUnmarshalConf
+	umc.DecoderConfig.Result = &c
+	if err := ko.UnmarshalWithConf("", nil, umc); err != nil {
+		return c, err
+	}
+	if err := checkRemoteKeys(c); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// checkRemoteKeys returns an error naming the keys set in c that remote config
+// files cannot set. Remote config files are meant to share cluster definitions
+// and output settings, so they cannot choose the cluster used by default,
+// include other remote config files, point at local secrets, run commands
+// (e.g. the document-command of attestation), or make ochami connect through
+// other hosts. Those keys are only honored from local config files.
+func checkRemoteKeys(c Config) error {
+	var keys []string
+	if c.DefaultCluster != "" {
+		keys = append(keys, "default-cluster")
+	}
+	if len(c.Remote) > 0 {
+		keys = append(keys, "remote")
+	}
+	if c.AgeIdentity != "" {
+		keys = append(keys, "age-identity")
+	}
+	for _, list := range []struct {
+		key      string
+		clusters []ConfigCluster
+	}{
+		{"clusters", c.Clusters},
+		{"cluster-templates", c.ClusterTemplates},
+	} {
+		for _, cl := range list.clusters {
+			for _, k := range remoteClusterKeys(cl.Cluster) {
+				keys = append(keys, fmt.Sprintf("%s[%s].cluster.%s", list.key, cl.Name, k))
+			}
+		}
+	}
+	if len(keys) > 0 {
+		return fmt.Errorf("remote config files cannot set %s", strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+// remoteClusterKeys returns the keys set in the cluster config c that remote
+// config files cannot set (see checkRemoteKeys).
+func remoteClusterKeys(c ConfigClusterConfig) []string {
+	var keys []string
+	if c.SSHTunnel != "" {
+		keys = append(keys, "ssh-tunnel")
+	}
+	if len(c.CACerts) > 0 {
+		keys = append(keys, "ca-certs")
+	}
+	if c.Token.IsSet() {
+		keys = append(keys, "token")
+	}
+	if c.TokenSource != "" {
+		keys = append(keys, "token-source")
+	}
+	if c.Attestation != (ConfigAttestation{}) {
+		keys = append(keys, "attestation")
+	}
+	if c.Discover.BMCUsername.IsSet() {
+		keys = append(keys, "discover.bmc-username")
+	}
+	if c.Discover.BMCPassword.IsSet() {
+		keys = append(keys, "discover.bmc-password")
+	}
+	return keys
+}
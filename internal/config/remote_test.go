@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateRemoteConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster string
+		wantKey string
+	}{
+		{
+			name:    "allowed",
+			cluster: "base-uri: https://foobar.openchami.cluster\n      discover:\n        redfish-port: 443",
+		},
+		{
+			name:    "ssh-tunnel",
+			cluster: "ssh-tunnel: jump.example.com",
+			wantKey: "clusters[foobar].cluster.ssh-tunnel",
+		},
+		{
+			name:    "token-source",
+			cluster: "token-source: attestation",
+			wantKey: "clusters[foobar].cluster.token-source",
+		},
+		{
+			name:    "attestation",
+			cluster: "attestation:\n        document-command: touch /tmp/pwned",
+			wantKey: "clusters[foobar].cluster.attestation",
+		},
+		{
+			name:    "bmc-username",
+			cluster: "discover:\n        bmc-username:\n          env: HOME",
+			wantKey: "clusters[foobar].cluster.discover.bmc-username",
+		},
+		{
+			name:    "bmc-password",
+			cluster: "discover:\n        bmc-password:\n          file: /home/user/.config/ochami/token",
+			wantKey: "clusters[foobar].cluster.discover.bmc-password",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "remote.yaml")
+			data := "clusters:\n  - name: foobar\n    cluster:\n      " + tt.cluster + "\n"
+			if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+			_, err := validateRemoteConfig(path)
+			switch {
+			case tt.wantKey == "" && err != nil:
+				t.Errorf("remote config rejected: %v", err)
+			case tt.wantKey != "" && err == nil:
+				t.Errorf("remote config setting %s accepted", tt.wantKey)
+			case tt.wantKey != "" && !strings.Contains(err.Error(), tt.wantKey):
+				t.Errorf("error %q does not name %s", err, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestValidateRemoteConfigTopLevel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remote.yaml")
+	data := "default-cluster: foobar\nage-identity: /home/user/.config/ochami/age.key\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	_, err := validateRemoteConfig(path)
+	if err == nil {
+		t.Fatal("remote config setting default-cluster and age-identity accepted")
+	}
+	for _, key := range []string{"default-cluster", "age-identity"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error %q does not name %s", err, key)
+		}
+	}
+}
+
+func TestCheckRemoteURL(t *testing.T) {
+	for url, ok := range map[string]bool{
+		"https://config.example.com/ochami.yaml": true,
+		"http://config.example.com/ochami.yaml":  false,
+		"ftp://config.example.com/ochami.yaml":   false,
+	} {
+		if err := CheckRemoteURL(url); (err == nil) != ok {
+			t.Errorf("CheckRemoteURL(%q) = %v, want ok = %t", url, err, ok)
+		}
+	}
+}
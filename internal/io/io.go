@@ -2,8 +2,11 @@ package io
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 )
 
 // ReadStdin reads all of standard input and returns the bytes. If an error
@@ -20,3 +23,101 @@ func ReadStdin() ([]byte, error) {
 	}
 	return b, nil
 }
+
+// WriteFileAtomic writes data to the file at path such that readers of path
+// see either its previous contents or its new contents, never a partial write.
+// The data is written to a temporary file in the same directory as path, which
+// is synced and then renamed over path. If appendData is true, the existing
+// contents of path, if any, are copied to the temporary file before data. If
+// path already exists, its permissions are kept; otherwise, perm is used. If
+// path is a symlink, the file it points to is written rather than the symlink
+// replaced, e.g. so that shell rc files managed by a dotfiles repository stay
+// linked.
+func WriteFileAtomic(path string, data []byte, appendData bool, perm os.FileMode) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+
+	if fi, err := os.Stat(path); err == nil {
+		if !fi.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", path)
+		}
+		perm = fi.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %s: %w", path, err)
+	}
+	tmpName := tmp.Name()
+	ok := false
+	defer func() {
+		if !ok {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if appendData {
+		if f, err := os.Open(path); err == nil {
+			_, err = io.Copy(tmp, f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("failed to copy existing contents of %s: %w", path, err)
+			}
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write temporary file for %s: %w", path, err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("failed to set permissions of temporary file for %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temporary file for %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file for %s: %w", path, err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("failed to rename temporary file to %s: %w", path, err)
+	}
+	ok = true
+
+	return nil
+}
+
+// CaptureStdout replaces os.Stdout with the write end of a pipe whose contents
+// are collected in memory. The returned function restores os.Stdout and
+// returns everything written in the meantime. It must be called exactly once.
+func CaptureStdout() (func() ([]byte, error), error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe for standard output: %w", err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+
+	var buf bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(&buf, r)
+		r.Close()
+		done <- err
+	}()
+
+	return func() ([]byte, error) {
+		os.Stdout = orig
+		w.Close()
+		if err := <-done; err != nil {
+			return buf.Bytes(), fmt.Errorf("failed to read captured standard output: %w", err)
+		}
+		return buf.Bytes(), nil
+	}, nil
+}
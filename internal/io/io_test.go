@@ -0,0 +1,65 @@
+package io
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file")
+
+	if err := WriteFileAtomic(path, []byte("one\n"), true, 0600); err != nil {
+		t.Fatalf("failed to write new file: %v", err)
+	}
+	if err := WriteFileAtomic(path, []byte("two\n"), true, 0644); err != nil {
+		t.Fatalf("failed to append to file: %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "one\ntwo\n" {
+		t.Errorf("file contains %q after appending, want %q", got, "one\ntwo\n")
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("file has mode %v (%v), want the one it was created with kept", fi.Mode().Perm(), err)
+	}
+
+	if err := WriteFileAtomic(path, []byte("three\n"), false, 0644); err != nil {
+		t.Fatalf("failed to overwrite file: %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "three\n" {
+		t.Errorf("file contains %q after overwriting, want %q", got, "three\n")
+	}
+
+	if err := WriteFileAtomic(dir, []byte("x"), false, 0644); err == nil {
+		t.Error("writing over a directory succeeded")
+	}
+}
+
+func TestWriteFileAtomicSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "bashrc")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("alias ll='ls -l'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, ".bashrc")
+	if err := os.Symlink(filepath.Join("dotfiles", "bashrc"), link); err != nil {
+		t.Skipf("cannot create symlinks: %v", err)
+	}
+
+	if err := WriteFileAtomic(link, []byte("source completions\n"), true, 0644); err != nil {
+		t.Fatalf("failed to append through symlink: %v", err)
+	}
+	if fi, err := os.Lstat(link); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("symlink was replaced (%v)", err)
+	}
+	want := "alias ll='ls -l'\nsource completions\n"
+	if got, _ := os.ReadFile(target); string(got) != want {
+		t.Errorf("target contains %q, want %q", got, want)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+}
@@ -1,53 +1,129 @@
 package log
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/OpenCHAMI/ochami/internal/color"
 	"github.com/rs/zerolog"
 )
 
+// Components whose log messages can be told apart and filtered with
+// ParseFilter. Each has its own logger.
+const (
+	ComponentCLI      = "cli"
+	ComponentClient   = "client"
+	ComponentConfig   = "config"
+	ComponentDiscover = "discover"
+)
+
+// Components lists the valid components.
+var Components = []string{ComponentCLI, ComponentClient, ComponentConfig, ComponentDiscover}
+
 var (
+	// Logger is the logger of the command line interface (package cmd).
 	Logger zerolog.Logger
+
+	// ClientLogger is the logger of the service clients (package client and
+	// its subpackages).
+	ClientLogger zerolog.Logger
+
+	// ConfigLogger is the logger of the configuration (package config).
+	ConfigLogger zerolog.Logger
+
+	// DiscoverLogger is the logger of discovery (package discover).
+	DiscoverLogger zerolog.Logger
 )
 
-// Init() initializes the global logging object so it can be used for logging by
-// any package that imports this internal log package.
-func Init(ll, lf string) error {
+// ParseFilter parses the value of a --log-filter flag, "component=<name>,...",
+// and returns the component names in it.
+func ParseFilter(f string) ([]string, error) {
+	key, val, ok := strings.Cut(f, "=")
+	if !ok || strings.TrimSpace(key) != "component" {
+		return nil, fmt.Errorf("invalid log filter %q: must be component=<name>,...", f)
+	}
+	var comps []string
+	for _, c := range strings.Split(val, ",") {
+		c = strings.TrimSpace(c)
+		if !slices.Contains(Components, c) {
+			return nil, fmt.Errorf("invalid log filter %q: unknown component %q (must be one of: %s)", f, c, strings.Join(Components, ", "))
+		}
+		comps = append(comps, c)
+	}
+	return comps, nil
+}
+
+// Init() initializes the global logging objects so they can be used for
+// logging by any package that imports this internal log package. If components
+// are passed, info, debug, and trace messages are only logged for them;
+// warnings and errors are always logged.
+func Init(ll, lf string, components ...string) error {
 	var loggerLevel zerolog.Level
 	switch ll {
+	case "error":
+		loggerLevel = zerolog.ErrorLevel
 	case "warning":
 		loggerLevel = zerolog.WarnLevel
 	case "info":
 		loggerLevel = zerolog.InfoLevel
 	case "debug":
 		loggerLevel = zerolog.DebugLevel
+	case "trace":
+		loggerLevel = zerolog.TraceLevel
 	default:
 		return fmt.Errorf("unknown log level: %s", ll)
 	}
 
-	cw := zerolog.ConsoleWriter{Out: os.Stderr}
+	var (
+		base   zerolog.Logger
+		tagged bool
+		cw     = zerolog.ConsoleWriter{Out: os.Stderr, NoColor: !color.Stderr.Enabled()}
+	)
 	switch lf {
 	case "rfc3339":
 		cw.TimeFormat = time.RFC3339
 		cw.FormatCaller = getFormatCaller(cw.NoColor)
-		Logger = zerolog.New(cw).Level(loggerLevel).With().Timestamp().Caller().Logger()
+		base = zerolog.New(cw).With().Timestamp().Caller().Logger()
 	case "basic":
 		cw.FormatTimestamp = func(i interface{}) string { return "" }
 		cw.FormatLevel = func(i interface{}) string { return strings.ToUpper(fmt.Sprintf("%-6s|", i)) }
 		cw.FormatCaller = getFormatCaller(cw.NoColor)
-		Logger = zerolog.New(cw).Level(loggerLevel).With().Caller().Logger()
+		base = zerolog.New(cw).With().Caller().Logger()
 	case "json":
-		Logger = zerolog.New(cw).Level(loggerLevel).With().Timestamp().Logger()
+		base = zerolog.New(cw).With().Timestamp().Logger()
+		tagged = true
+	case "logfmt":
+		base = zerolog.New(newLogfmtWriter()).With().Timestamp().Caller().Logger()
+		tagged = true
 	default:
 		return fmt.Errorf("unknown log format: %s", lf)
 	}
 
+	// Machine-readable formats tag each message with its component so
+	// that it can be told apart when the log is processed.
+	newLogger := func(component string) zerolog.Logger {
+		l := base
+		if tagged {
+			l = l.With().Str("component", component).Logger()
+		}
+		if len(components) > 0 && !slices.Contains(components, component) {
+			return l.Level(max(loggerLevel, zerolog.WarnLevel))
+		}
+		return l.Level(loggerLevel)
+	}
+	Logger = newLogger(ComponentCLI)
+	ClientLogger = newLogger(ComponentClient)
+	ConfigLogger = newLogger(ComponentConfig)
+	DiscoverLogger = newLogger(ComponentDiscover)
+
 	return nil
 }
 
@@ -66,9 +142,68 @@ func getFormatCaller(noColor bool) zerolog.Formatter {
 		if ok {
 			out = fmt.Sprintf("%s:%d", filepath.Base(f), l)
 		} else {
-			out = fmt.Sprintf("%s:%d", path, line)
+			out = fmt.Sprintf("%s:%s", path, line)
 		}
 
-		return colorize(out, colorBold, noColor) + colorize(" >", colorCyan, noColor)
+		if noColor {
+			return out + " >"
+		}
+		return color.Stderr.Bold(out) + color.Stderr.Cyan(" >")
+	}
+}
+
+// newLogfmtWriter returns a ConsoleWriter that writes messages in logfmt, one
+// line of key=value pairs per message, e.g.:
+//
+//	time=2025-01-02T15:04:05Z level=debug caller=smd.go:42 msg="getting components" component=client
+func newLogfmtWriter() zerolog.ConsoleWriter {
+	return zerolog.ConsoleWriter{
+		Out:     os.Stderr,
+		NoColor: true,
+		FormatTimestamp: func(i interface{}) string {
+			return "time=" + logfmtValue(i)
+		},
+		FormatLevel: func(i interface{}) string {
+			return "level=" + logfmtValue(i)
+		},
+		FormatCaller: func(i interface{}) string {
+			if s, ok := i.(string); ok {
+				i = filepath.Base(s)
+			}
+			return "caller=" + logfmtValue(i)
+		},
+		FormatMessage: func(i interface{}) string {
+			return "msg=" + logfmtValue(i)
+		},
+		FormatFieldName:     func(i interface{}) string { return fmt.Sprintf("%s=", i) },
+		FormatFieldValue:    logfmtValue,
+		FormatErrFieldName:  func(i interface{}) string { return fmt.Sprintf("%s=", i) },
+		FormatErrFieldValue: logfmtValue,
+	}
+}
+
+// logfmtValue formats i as a logfmt value, quoting it if it is empty or
+// contains spaces, quotes, or equal signs. Values that are not strings are
+// formatted as JSON.
+func logfmtValue(i interface{}) string {
+	var s string
+	switch v := i.(type) {
+	case nil:
+		s = ""
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprint(v)
+		} else {
+			s = string(b)
+		}
+	}
+	if s == "" || strings.ContainsAny(s, " =\"\t\n\\") {
+		return strconv.Quote(s)
 	}
+	return s
 }
@@ -0,0 +1,92 @@
+// Package plugin finds external subcommands ("plugins"), which are executables
+// on PATH named <prog>-<name> that are run as '<prog> <name>'.
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Plugin is an executable found on PATH that provides a subcommand.
+type Plugin struct {
+	// Name is the name of the subcommand, i.e. the executable's name
+	// without the prefix.
+	Name string
+	// Path is the path to the executable that is run for the subcommand.
+	Path string
+	// Shadowed are the paths to executables with the same name that come
+	// later in PATH and are therefore never run.
+	Shadowed []string
+}
+
+// Lookup searches the directories in PATH, in order, for an executable named
+// prefix-name and returns the path to the first one found. An error is
+// returned if name is not a valid plugin name or if no such executable exists.
+func Lookup(prefix, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, "-") {
+		return "", fmt.Errorf("invalid plugin name %q", name)
+	}
+	exe := prefix + "-" + name
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		p := filepath.Join(dir, exe)
+		if isExecutable(p) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("no executable named %s found in PATH", exe)
+}
+
+// List returns all plugins for prefix found in the directories in PATH, sorted
+// by name. If the same name is found in more than one directory, the first one
+// is used and the rest are recorded in Shadowed.
+func List(prefix string) []Plugin {
+	plugins := map[string]*Plugin{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name, ok := strings.CutPrefix(e.Name(), prefix+"-")
+			if !ok || name == "" {
+				continue
+			}
+			p := filepath.Join(dir, e.Name())
+			if !isExecutable(p) {
+				continue
+			}
+			if existing, ok := plugins[name]; ok {
+				existing.Shadowed = append(existing.Shadowed, p)
+				continue
+			}
+			plugins[name] = &Plugin{Name: name, Path: p}
+		}
+	}
+
+	list := make([]Plugin, 0, len(plugins))
+	for _, p := range plugins {
+		list = append(list, *p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+// isExecutable reports whether path is a regular file (following symlinks)
+// that is executable by anyone.
+func isExecutable(path string) bool {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return fi.Mode().IsRegular() && fi.Mode().Perm()&0111 != 0
+}
@@ -0,0 +1,244 @@
+package tokensource
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	oio "github.com/OpenCHAMI/ochami/internal/io"
+	"github.com/lestrrat-go/jwx/jwt"
+)
+
+// attestationTimeout bounds the exchange of an attestation document for a
+// token, including generating the document with DocumentCommand.
+const attestationTimeout = 30 * time.Second
+
+// attestationRefresh is how long before it expires a cached token is replaced
+// by a new one, so that it does not expire while a command is running.
+const attestationRefresh = time.Minute
+
+// Attestation configures the exchange of an attestation document, e.g. a TPM
+// quote of a node or an attestation of a user, for an access token. The
+// document is POSTed as is to TokenURI, which must respond with a JSON object
+// holding the token in access_token (or token) and, optionally, its lifetime
+// in seconds in expires_in. The document is read from the file Document or,
+// if it is not set, from the standard output of the shell command
+// DocumentCommand, so that a fresh document can be generated for each
+// exchange.
+//
+// Since the tokens are short-lived, they are cached in the user's cache
+// directory until shortly before they expire, so that each command does not
+// require an exchange. A token whose lifetime is neither given in expires_in
+// nor in its exp claim is not cached.
+type Attestation struct {
+	TokenURI        string
+	Document        string
+	DocumentCommand string
+	CACert          string // PEM file of CA certificates to verify TokenURI with
+}
+
+// attestationSource gets the token from an attestation service.
+type attestationSource struct {
+	cluster string
+	cfg     Attestation
+}
+
+func newAttestationSource(arg, cluster string, opts Options) (Source, error) {
+	cfg := opts.Attestation
+	if arg != "" {
+		return nil, fmt.Errorf("token source attestation takes no argument (it is configured in the attestation section of the cluster config)")
+	}
+	if cfg.TokenURI == "" {
+		return nil, fmt.Errorf("token source attestation requires attestation.token-uri to be set")
+	}
+	if cfg.Document == "" && cfg.DocumentCommand == "" {
+		return nil, fmt.Errorf("token source attestation requires attestation.document or attestation.document-command to be set")
+	}
+	return attestationSource{cluster: cluster, cfg: cfg}, nil
+}
+
+func (s attestationSource) String() string {
+	return "attestation service " + s.cfg.TokenURI
+}
+
+// attestationCache is the contents of the file caching a token.
+type attestationCache struct {
+	Token  string    `json:"access_token"`
+	Expiry time.Time `json:"expiry"`
+}
+
+// tokenResponse is the response of the token endpoint.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	Token       string `json:"token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func (s attestationSource) Token() (string, error) {
+	cacheFile, cacheErr := s.cacheFile()
+	if cacheErr == nil {
+		if tok, ok := readCachedToken(cacheFile); ok {
+			return tok, nil
+		}
+	}
+
+	doc, err := s.document()
+	if err != nil {
+		return "", err
+	}
+	tok, expiry, err := s.exchange(doc)
+	if err != nil {
+		return "", err
+	}
+
+	// Failing to cache the token only costs an exchange next time, so it
+	// is not an error.
+	if cacheErr == nil && !expiry.IsZero() {
+		if b, err := json.Marshal(attestationCache{Token: tok, Expiry: expiry}); err == nil {
+			if os.MkdirAll(filepath.Dir(cacheFile), 0o700) == nil {
+				_ = oio.WriteFileAtomic(cacheFile, b, false, 0o600)
+			}
+		}
+	}
+	return tok, nil
+}
+
+// cacheFile returns the path of the file caching the token of the cluster,
+// which depends on the token endpoint so that changing it invalidates the
+// cache.
+func (s attestationSource) cacheFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(s.cluster + "\n" + s.cfg.TokenURI))
+	return filepath.Join(dir, "ochami", "tokens", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// readCachedToken returns the token cached in path if there is one that does
+// not expire soon.
+func readCachedToken(path string) (string, bool) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	var c attestationCache
+	if err := json.Unmarshal(b, &c); err != nil || c.Token == "" {
+		return "", false
+	}
+	if time.Until(c.Expiry) < attestationRefresh {
+		return "", false
+	}
+	return c.Token, true
+}
+
+// document reads the attestation document from the file or command it is
+// configured to come from.
+func (s attestationSource) document() ([]byte, error) {
+	if s.cfg.Document != "" {
+		b, err := os.ReadFile(s.cfg.Document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attestation document: %w", err)
+		}
+		return b, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), attestationTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.cfg.DocumentCommand)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("attestation document command %q timed out after %s", s.cfg.DocumentCommand, attestationTimeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("attestation document command %q failed: %w: %s", s.cfg.DocumentCommand, err, msg)
+		}
+		return nil, fmt.Errorf("attestation document command %q failed: %w", s.cfg.DocumentCommand, err)
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, fmt.Errorf("attestation document command %q printed nothing", s.cfg.DocumentCommand)
+	}
+	return out, nil
+}
+
+// exchange POSTs doc to the token endpoint and returns the token it issues
+// and when the token expires, which is zero if unknown.
+func (s attestationSource) exchange(doc []byte) (string, time.Time, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if s.cfg.CACert != "" {
+		pem, err := os.ReadFile(s.cfg.CACert)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("failed to read CA certificate for attestation service: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return "", time.Time{}, fmt.Errorf("no CA certificates found in %s", s.cfg.CACert)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	hc := &http.Client{Transport: transport, Timeout: attestationTimeout}
+
+	contentType := "application/octet-stream"
+	if json.Valid(doc) {
+		contentType = "application/json"
+	}
+	req, err := http.NewRequest(http.MethodPost, s.cfg.TokenURI, bytes.NewReader(doc))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create attestation token request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+	requested := time.Now()
+	res, err := hc.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to exchange attestation document for token: %w", err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read response of %s: %w", s, err)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		if msg := strings.TrimSpace(string(body)); msg != "" && len(msg) < 512 {
+			return "", time.Time{}, fmt.Errorf("%s rejected attestation document: %s: %s", s, res.Status, msg)
+		}
+		return "", time.Time{}, fmt.Errorf("%s rejected attestation document: %s", s, res.Status)
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to unmarshal response of %s: %w", s, err)
+	}
+	tok := tr.AccessToken
+	if tok == "" {
+		tok = tr.Token
+	}
+	tok, err = clean([]byte(tok), s)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("no token in response of %s", s)
+	}
+
+	var expiry time.Time
+	if tr.ExpiresIn > 0 {
+		expiry = requested.Add(time.Duration(tr.ExpiresIn) * time.Second)
+	} else if t, err := jwt.ParseString(tok, jwt.WithValidate(false)); err == nil {
+		expiry = t.Expiration()
+	}
+	return tok, expiry, nil
+}
@@ -0,0 +1,39 @@
+package tokensource
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// readKey returns the payload of the key of type "user" described by
+// description, searching the session keyring and then the user keyring.
+func readKey(description string) ([]byte, error) {
+	var (
+		id  int
+		err error
+	)
+	for _, ring := range []int{unix.KEY_SPEC_SESSION_KEYRING, unix.KEY_SPEC_USER_KEYRING} {
+		if id, err = unix.KeyctlSearch(ring, "user", description, 0); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		if errors.Is(err, unix.ENOKEY) {
+			return nil, errors.New("key not found in session or user keyring")
+		}
+		return nil, err
+	}
+
+	// The size of the payload is returned when reading into an empty buffer
+	size, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, buf, 0)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:min(n, size)], nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package tokensource
+
+import "errors"
+
+// readKey is only supported on Linux, which has the kernel keyring.
+func readKey(description string) ([]byte, error) {
+	return nil, errors.New("the kernel keyring is only supported on Linux")
+}
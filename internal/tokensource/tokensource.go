@@ -0,0 +1,171 @@
+// Package tokensource reads access tokens from places other than the command
+// line and environment, so that they need not be exported into the shell (and
+// its history). A source is configured per cluster with a spec of the form
+// <kind>[:<argument>]:
+//
+//   - keyring[:<description>]: a "user" key in the Linux kernel keyring,
+//     found by searching the session and user keyrings. The description
+//     defaults to ochami:<cluster>.
+//   - systemd-credential:<name>: a systemd credential passed to the unit
+//     running ochami, or stored in one of systemd's credential stores.
+//   - file:<path>: a file holding the token.
+//   - attestation: a short-lived token issued by an attestation service in
+//     exchange for an attestation document (see Attestation).
+package tokensource
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Source is a place an access token is read from.
+type Source interface {
+	// Token reads the token from the source.
+	Token() (string, error)
+
+	// String describes the source for messages, e.g. "file /etc/token".
+	String() string
+}
+
+// Options holds the settings of the kinds of sources that need more than the
+// argument of their spec.
+type Options struct {
+	Attestation Attestation
+}
+
+// kinds maps each kind of source to a function returning a source of that
+// kind given the argument of the spec, which is empty if there is none, the
+// name of the cluster the token is for, and the options of the cluster.
+var kinds = map[string]func(arg, cluster string, opts Options) (Source, error){
+	"keyring":            newKeyringSource,
+	"systemd-credential": newCredentialSource,
+	"file":               newFileSource,
+	"attestation":        newAttestationSource,
+}
+
+// Kinds returns the kinds of sources that Parse accepts, sorted.
+func Kinds() []string {
+	var names []string
+	for k := range kinds {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse returns the Source described by spec (e.g. "file:/etc/ochami/token")
+// that holds the token of the cluster named cluster, configured with opts.
+func Parse(spec, cluster string, opts Options) (Source, error) {
+	kind, arg, _ := strings.Cut(spec, ":")
+	newSource, ok := kinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown token source %q (kind must be one of %s)", spec, strings.Join(Kinds(), ", "))
+	}
+	return newSource(arg, cluster, opts)
+}
+
+// clean removes the surrounding whitespace, e.g. a trailing newline, from a
+// token read from src and returns an error if nothing is left.
+func clean(tok []byte, src Source) (string, error) {
+	t := strings.TrimSpace(string(tok))
+	if t == "" {
+		return "", fmt.Errorf("%s is empty", src)
+	}
+	return t, nil
+}
+
+// fileSource reads the token from a file.
+type fileSource struct {
+	path string
+}
+
+func newFileSource(arg, _ string, _ Options) (Source, error) {
+	if arg == "" {
+		return nil, fmt.Errorf("token source file requires a path (file:<path>)")
+	}
+	return fileSource{path: arg}, nil
+}
+
+func (s fileSource) Token() (string, error) {
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	return clean(b, s)
+}
+
+func (s fileSource) String() string {
+	return "file " + s.path
+}
+
+// credentialStores are the directories systemd looks for credentials in when
+// they are loaded by name, in order.
+var credentialStores = []string{"/etc/credstore", "/run/credstore", "/usr/lib/credstore"}
+
+// credentialSource reads the token from a systemd credential. Inside a unit
+// with the credential set (e.g. with LoadCredential=), it is read from
+// $CREDENTIALS_DIRECTORY. Otherwise, it is read from the first of systemd's
+// credential stores that has it, which only works for credentials stored
+// unencrypted.
+type credentialSource struct {
+	name string
+}
+
+func newCredentialSource(arg, _ string, _ Options) (Source, error) {
+	if arg == "" {
+		return nil, fmt.Errorf("token source systemd-credential requires a credential name (systemd-credential:<name>)")
+	}
+	if strings.ContainsRune(arg, '/') {
+		return nil, fmt.Errorf("invalid systemd credential name %q", arg)
+	}
+	return credentialSource{name: arg}, nil
+}
+
+func (s credentialSource) Token() (string, error) {
+	dirs := credentialStores
+	if d := os.Getenv("CREDENTIALS_DIRECTORY"); d != "" {
+		dirs = []string{d}
+	}
+	for _, d := range dirs {
+		b, err := os.ReadFile(filepath.Join(d, s.name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", s, err)
+		}
+		return clean(b, s)
+	}
+	return "", fmt.Errorf("%s not found in %s", s, strings.Join(dirs, ", "))
+}
+
+func (s credentialSource) String() string {
+	return "systemd credential " + s.name
+}
+
+// keyringSource reads the token from a key of type "user" in the kernel
+// keyring.
+type keyringSource struct {
+	description string
+}
+
+func newKeyringSource(arg, cluster string, _ Options) (Source, error) {
+	if arg == "" {
+		arg = "ochami:" + cluster
+	}
+	return keyringSource{description: arg}, nil
+}
+
+func (s keyringSource) Token() (string, error) {
+	b, err := readKey(s.description)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", s, err)
+	}
+	return clean(b, s)
+}
+
+func (s keyringSource) String() string {
+	return "keyring key " + s.description
+}
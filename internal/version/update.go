@@ -0,0 +1,244 @@
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReleasesURL is the GitHub API endpoint that lists the releases of ochami.
+const ReleasesURL = "https://api.github.com/repos/OpenCHAMI/ochami/releases"
+
+// Release channels. The stable channel only considers full releases, while the
+// prerelease channel also considers release candidates, betas, etc.
+const (
+	ChannelStable     = "stable"
+	ChannelPrerelease = "prerelease"
+)
+
+// UpdateCacheTTL is how long the result of an update check is reused for.
+const UpdateCacheTTL = 24 * time.Hour
+
+// Release is the subset of a GitHub release that is used to check for updates.
+type Release struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	HTMLURL     string    `json:"html_url"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Semver is a parsed semantic version.
+type Semver struct {
+	Major, Minor, Patch int
+	Prerelease          string
+}
+
+// ParseSemver parses a semantic version such as "v1.2.3" or "1.2.3-rc.1". The
+// leading "v" is optional and build metadata (after "+") is ignored.
+func ParseSemver(s string) (Semver, error) {
+	var v Semver
+	core := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	core, _, _ = strings.Cut(core, "+")
+	core, v.Prerelease, _ = strings.Cut(core, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid semantic version %q", s)
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid semantic version %q", s)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+// String returns v in the form "v1.2.3" or "v1.2.3-rc.1".
+func (v Semver) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Compare returns -1, 0, or 1 if v is lower than, equal to, or higher than w,
+// following the precedence rules of semantic versioning: a prerelease is lower
+// than the release it precedes, and prerelease identifiers are compared one by
+// one, numerically if both are numbers.
+func (v Semver) Compare(w Semver) int {
+	for _, d := range []int{v.Major - w.Major, v.Minor - w.Minor, v.Patch - w.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case v.Prerelease == w.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case w.Prerelease == "":
+		return -1
+	}
+	vIDs, wIDs := strings.Split(v.Prerelease, "."), strings.Split(w.Prerelease, ".")
+	for i := 0; i < len(vIDs) && i < len(wIDs); i++ {
+		vn, vErr := strconv.Atoi(vIDs[i])
+		wn, wErr := strconv.Atoi(wIDs[i])
+		switch {
+		case vErr == nil && wErr == nil:
+			if vn != wn {
+				return sign(vn - wn)
+			}
+		case vErr == nil:
+			return -1 // Numeric identifiers are lower than others
+		case wErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(vIDs[i], wIDs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(vIDs) - len(wIDs))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// DefaultChannel returns the channel to check for updates of the running
+// version: the prerelease channel if it is a prerelease, the stable channel
+// otherwise.
+func DefaultChannel() string {
+	if v, err := ParseSemver(Version); err == nil && v.Prerelease != "" {
+		return ChannelPrerelease
+	}
+	return ChannelStable
+}
+
+// LatestRelease returns the highest release in channel, and its parsed version,
+// from the list of releases at url (e.g. ReleasesURL). Drafts and releases
+// whose tags are not semantic versions are skipped.
+func LatestRelease(ctx context.Context, hc *http.Client, url, channel string) (Release, Semver, error) {
+	var (
+		latest    Release
+		latestVer Semver
+		found     bool
+	)
+	if channel != ChannelStable && channel != ChannelPrerelease {
+		return latest, latestVer, fmt.Errorf("unknown release channel %q, must be %s or %s", channel, ChannelStable, ChannelPrerelease)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return latest, latestVer, fmt.Errorf("failed to create request for releases: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "ochami/"+Version)
+	res, err := hc.Do(req)
+	if err != nil {
+		return latest, latestVer, fmt.Errorf("failed to fetch releases: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return latest, latestVer, fmt.Errorf("failed to fetch releases: %s", res.Status)
+	}
+	var releases []Release
+	if err := json.NewDecoder(res.Body).Decode(&releases); err != nil {
+		return latest, latestVer, fmt.Errorf("failed to decode releases: %w", err)
+	}
+
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && channel == ChannelStable) {
+			continue
+		}
+		v, err := ParseSemver(r.TagName)
+		if err != nil || (v.Prerelease != "" && channel == ChannelStable) {
+			continue
+		}
+		if !found || v.Compare(latestVer) > 0 {
+			latest, latestVer, found = r, v, true
+		}
+	}
+	if !found {
+		return latest, latestVer, fmt.Errorf("no releases found in %s channel", channel)
+	}
+
+	return latest, latestVer, nil
+}
+
+// updateCache is the content of the update check cache file. It holds the
+// last check of each release channel.
+type updateCache map[string]updateCheck
+
+type updateCheck struct {
+	Checked time.Time `json:"checked"`
+	Release Release   `json:"release"`
+}
+
+// UpdateCacheFile returns the path of the file that caches the result of the
+// last update check, in the user's cache directory.
+func UpdateCacheFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "ochami", "update-check.json"), nil
+}
+
+// ReadUpdateCache returns the release cached at path for channel if it was
+// checked less than UpdateCacheTTL before now. The returned bool is false if
+// there is no such release.
+func ReadUpdateCache(path, channel string, now time.Time) (Release, bool) {
+	c := readUpdateCache(path)
+	check, ok := c[channel]
+	if !ok || now.Sub(check.Checked) >= UpdateCacheTTL || now.Before(check.Checked) {
+		return Release{}, false
+	}
+	return check.Release, true
+}
+
+// WriteUpdateCache records at path that r was the latest release in channel at
+// time now, keeping the checks of other channels.
+func WriteUpdateCache(path, channel string, r Release, now time.Time) error {
+	c := readUpdateCache(path)
+	c[channel] = updateCheck{Checked: now, Release: r}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal update check cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for update check cache: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write update check cache: %w", err)
+	}
+	return nil
+}
+
+// readUpdateCache reads the update check cache at path. A missing or invalid
+// cache file yields an empty cache.
+func readUpdateCache(path string) updateCache {
+	c := updateCache{}
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &c) != nil || c == nil {
+		return updateCache{}
+	}
+	return c
+}
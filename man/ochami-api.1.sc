@@ -0,0 +1,73 @@
+OCHAMI-API(1) "OpenCHAMI" "Manual Page for ochami-api"
+
+# NAME
+
+ochami-api - Inspect the APIs of OpenCHAMI services
+
+# SYNOPSIS
+
+ochami api diff [OPTIONS] (bss | cloud-init | smd)
+
+# DESCRIPTION
+
+The *api* command inspects the APIs of the OpenCHAMI services that ochami
+communicates with.
+
+# COMMANDS
+
+*diff* [--spec-path _path_ | --file _file_] [--exit-code] (bss | cloud-init | smd)
+	Compare the OpenAPI (or Swagger 2.0) spec served by a service against the
+	endpoints and schemas that this version of ochami was built to use, in
+	order to spot drift after the service is upgraded. The following are
+	listed:
+
+	- endpoints in the spec that ochami does not use
+	- endpoints ochami uses that are missing from the spec
+	- fields of schemas in the spec that ochami does not know about
+	- schemas ochami knows about that are missing from the spec
+
+	Path parameters are compared by position only, so _/groups/{label}_ and
+	_/groups/{group_label}_ are the same endpoint. Field names are compared
+	case-insensitively.
+
+	By default, the spec is fetched from _openapi.json_ under the service's
+	base path: _/hsm/v2/openapi.json_ for SMD, _/boot/v1/openapi.json_ for
+	BSS, and _/cloud-init/openapi.json_ for cloud-init. Not every version of
+	each service serves its spec there (or at all), so *--spec-path* and
+	*--file* can be used to get it elsewhere.
+
+	The differences are printed as text unless *--output-format* is passed,
+	in which case they are printed in that format.
+
+	This command accepts the following options:
+
+	*--exit-code*
+		Exit with status 2 if there are any differences.
+
+	*--file* _file_
+		Read the spec from _file_ instead of fetching it from the service. If
+		_file_ is *-*, the spec is read from standard input. The spec may be
+		JSON or YAML.
+
+	*-F, --output-format* _format_
+		Print the differences in _format_ instead of as text. Supported
+		values are:
+
+		- _json_
+		- _yaml_
+		- _toml_
+
+	*--spec-path* _path_
+		Fetch the spec from _path_, relative to the service's base path,
+		instead of the default.
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1), *ochami-bss*(1), *ochami-cloud-init*(1), *ochami-smd*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
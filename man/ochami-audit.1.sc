@@ -0,0 +1,72 @@
+OCHAMI-AUDIT(1) "OpenCHAMI" "Manual Page for ochami-audit"
+
+# NAME
+
+ochami-audit - Check the consistency of data across OpenCHAMI services
+
+# SYNOPSIS
+
+ochami audit boot [OPTIONS]
+
+# DESCRIPTION
+
+The *audit* command checks that the data that OpenCHAMI services hold about
+the same nodes is consistent across services. Its commands only read data from
+services and never modify it.
+
+# COMMANDS
+
+*boot* [--cloud-init | --no-cloud-init] [--exit-code]
+	Check that every node in SMD can boot from BSS. Components and ethernet
+	interfaces are fetched from SMD, boot parameters are fetched from BSS, and
+	each of the following is reported as a finding with a severity:
+
+	- An enabled Node component that has no boot parameters matching its
+	  xname, the MAC address of one of its ethernet interfaces, or its NID.
+	  This is a _warning_ if BSS would fall back to the boot parameters of
+	  the node's role or to the _Default_ boot parameters, and an _error_
+	  otherwise.
+	- A MAC address in boot parameters that is not the MAC address of an
+	  ethernet interface in SMD (a _warning_).
+	- A node whose kernel parameters use the cloud-init nocloud datasource
+	  (*ds=nocloud*...) but that has no cloud-init config named after its
+	  xname or one of its MAC addresses (an _error_).
+
+	Configs are only fetched from cloud-init if any boot parameters use
+	cloud-init, or if *--cloud-init* is passed.
+
+	The findings are printed as a table, errors first, followed by the number
+	of nodes checked and of findings of each severity, unless
+	*--output-format* is passed, in which case the whole report is printed in
+	that format.
+
+	This command accepts the following options:
+
+	*--cloud-init*
+		Expect every enabled node to have a cloud-init config, regardless of
+		its kernel parameters.
+
+	*--exit-code*
+		Exit with status 2 if there are any findings.
+
+	*--no-cloud-init*
+		Do not check cloud-init, e.g. if it is not deployed.
+
+	*-F, --output-format* _format_
+		Print the report in _format_ instead of as a table. Supported values
+		are:
+
+		- _json_
+		- _yaml_
+		- _toml_
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1), *ochami-bss*(1), *ochami-cloud-init*(1), *ochami-smd*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
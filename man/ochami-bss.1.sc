@@ -21,8 +21,52 @@ The data structure for sending and receiving data with subcommands under the
 }
 ```
 
+Components are identified by one of the *hosts* (xnames), *macs*, or *nids*
+keys, which are mutually exclusive. Boot parameters are validated before being
+sent, whether they come from flags or a payload file: MAC addresses must be
+well-formed and *params* must be a valid kernel command line, i.e. contain no
+control characters such as newlines, have balanced double quotes, and have a
+name for every parameter.
+
 # COMMANDS
 
+## access
+
+View when components last accessed BSS endpoints. BSS records the last time
+each component fetched each of its endpoints (currently _bootscript_ and
+_user-data_), which helps with debugging boot loops.
+
+Subcommands for this command are as follows:
+
+*get* [--output-format _format_] [--xname _xname_,...] [--endpoint _endpoint_]
+	Show the last access of each component to each endpoint. By default, a
+	table is printed, most recent access first, with the time of each access
+	and how long ago it was. Components that have never accessed an endpoint
+	are shown as _never_. If *--output-format* is passed, the accesses are
+	printed as returned by BSS, with times as UNIX timestamps.
+
+	This command sends a GET to BSS's /endpoint-history endpoint, once per
+	xname passed.
+
+	This command accepts the following options:
+
+	*--endpoint* _endpoint_
+		Only show accesses to _endpoint_ (_bootscript_ or _user-data_).
+
+	*-F, --output-format* _format_
+		Output response data in specified _format_ instead of a table.
+		Supported values are:
+
+		- _json_
+		- _yaml_
+		- _toml_
+
+	*--xname* _xname_,...
+		One or more xnames whose accesses to show. For multiple xnames, either
+		this flag can be specified multiple times or this flag can be
+		specified once and multiple xnames, separated by commas. If not
+		passed, the accesses of all components are shown.
+
 ## boot params
 
 Manage boot parameters for components.
@@ -31,11 +75,12 @@ Subcommands for this command are as follows:
 
 *add* ([--mac _mac_,...] [--nid _nid_,...] [--xname _xname_,...]) ([--initrd _initrd_] [--kernel _kernel_])++
 *add* -f _file_ [--payload-format _format_]++
-*add* -f _-_ [--payload-format _format_] < _file_
+*add* -f _-_ [--payload-format _format_] < _file_++
+*add* --set _field_=_value_... [_flags_]
 	Add new boot parameters for one or more components. If boot parameters
 	already exist for the specified components, this command will fail.
 
-	In the first form of the command, one or more of *--mac*, *--nid*, or
+	In the first form of the command, exactly one of *--mac*, *--nid*, or
 	*--xname* is required to identify which component(s) to add boot config for.
 	One or more of *--initrd*, *--kernel*, or *--params* is also required to
 	know which boot parameters to add for the specified components.  For any of
@@ -49,6 +94,13 @@ Subcommands for this command are as follows:
 	In the third form of the command, the payload data is read from standard
 	input.
 
+	In the fourth form of the command, the boot parameters are built from the
+	fields passed with *--set*, e.g. _--set hosts=[x1000c1s7b0n0] --set
+	kernel=https://example.com/kernel_. This also works for fields without a
+	flag of their own, such as _cloud-init.meta-data_. *--set* can also be
+	combined with *-f*, overriding fields of the payload. The flags of the first
+	form override both. See *PAYLOADS* in *ochami*(1).
+
 	This command sends a POST request to BSS's /bootparameters endpoint.
 
 	This command accepts the following options:
@@ -57,12 +109,32 @@ Subcommands for this command are as follows:
 		Specify a file containing the data to send to BSS. The format of this
 		file depends on _--payload-format_ and is _json_ by default. If *-* is
 		used as the argument to _-f_, the command reads the payload data from
-		standard input.
+		standard input. If an _http://_ or _https://_ URL is used, the payload
+		data is fetched from it (see *PAYLOADS* in *ochami*(1)).
 
 	*--payload-format* _format_
 		Format of the file used with _-f_. If unspecified, the payload format is
 		_json_ by default. Supported formats are: _yaml_.
 
+	*--payload-insecure*
+		Do not verify the TLS certificate of the server when the argument to
+		_-f_ is an _https://_ URL.
+
+	*--verify-hosts*
+		Before sending the boot parameters, check that each of the xnames, MAC
+		addresses, and NIDs they are for exists in SMD (as a component or, for
+		MAC addresses, an ethernet interface), and fail if any does not. This
+		catches typos that would otherwise leave a node silently without boot
+		parameters. The host _Default_ is not checked.
+
+	*--allow-unknown-hosts*
+		With *--verify-hosts*, only print a warning for hosts that do not exist
+		in SMD and send the boot parameters anyway.
+
+	*--set* _field_=_value_
+		Set _field_ of the boot parameters to _value_. Can be passed more than
+		once.
+
 	*-m, --mac* _mac_addr_,...
 		One or more MAC addresses to add boot parameters for. For multiple MAC
 		addresses, either this flag can be specified multiple times or this flag
@@ -98,7 +170,8 @@ Subcommands for this command are as follows:
 
 	In the first form of the command, one or more of *--mac*, *--nid*,
 	*--xname*, *--kernel*, or *--initrd* is required to identify which
-	component(s) whose boot parameters to delete. For any of these options,
+	component(s) whose boot parameters to delete. Only one of *--mac*, *--nid*,
+	and *--xname* can be passed. For any of these options,
 	multiple arguments can be passed either by specifying the flag multiple
 	times (e.g. *--mac* _mac1_ *--mac* _mac2_) or by using one flag and
 	separating each argument by commas (e.g. *--mac* _mac1_,_mac2_).
@@ -120,12 +193,17 @@ Subcommands for this command are as follows:
 		Specify a file containing the data to send to BSS. The format of this
 		file depends on _--payload-format_ and is _json_ by default. If *-* is
 		used as the argument to _-f_, the command reads the payload data from
-		standard input.
+		standard input. If an _http://_ or _https://_ URL is used, the payload
+		data is fetched from it (see *PAYLOADS* in *ochami*(1)).
 
 	*--payload-format* _format_
 		Format of the file used with _-f_. If unspecified, the payload format is
 		_json_ by default. Supported formats are: _yaml_.
 
+	*--payload-insecure*
+		Do not verify the TLS certificate of the server when the argument to
+		_-f_ is an _https://_ URL.
+
 	*-m, --mac* _mac_addr_,...
 		One or more MAC addresses to delete boot parameters for. For multiple
 		MAC addresses, either this flag can be specified multiple times or this
@@ -151,7 +229,7 @@ Subcommands for this command are as follows:
 	*--params* _kernel_params_
 		Command line arguments to pass to kernel for components.
 
-*get* [--output-format _format_] [--mac _mac_,...] [--nid _nid_,...] [--xname _xname_,...]
+*get* [--output-format _format_] [--mac _mac_,...] [--nid _nid_,...] [--xname _xname_,...] [--limit _n_] [--sort _field_[:_order_]]
 	Get boot parameters for all components or a subset of components, filtered
 	by MAC address, node ID, and/or xname.
 
@@ -159,11 +237,16 @@ Subcommands for this command are as follows:
 
 	This command accepts the following options:
 
+	*--limit* _n_
+		Print at most _n_ items. See *LISTS* in *ochami*(1).
+
 	*-F, --output-format* _format_
 		Output response data in specified _format_. Supported values are:
 
 		- _json_ (default)
 		- _yaml_
+		- _toml_
+		- _table_ (see *TABLES* in *ochami*(1))
 
 	*-m, --mac* _mac_addr_,...
 		One or more MAC addresses to filter boot parameters by. For multiple MAC
@@ -176,20 +259,26 @@ Subcommands for this command are as follows:
 		either this flag can be specified multiple times or this flag can be
 		specified once and multiple NIDs can be specified, separated by commas.
 
+	*--sort* _field_[:_order_]
+		Sort items by _field_ in _order_ (_asc_, the default, or _desc_). See
+		*LISTS* in *ochami*(1).
+
 	*-x, --xname* _xname_,...
 		One or more xnames to filter boot parameters by. For multiple xnames,
 		either this flag can be specified multiple times or this flag can be
 		specified once and multiple xnames, separated by commas.
 
 *set* ([--mac _mac_,...] [--nid _nid_,...] [--xname _xname_,...]) ([--initrd _initrd_] [--kernel _kernel_])++
+*set* --group _group_,... [--by-mac] [--reconcile] ([--initrd _initrd_] [--kernel _kernel_])++
 *set* -f _file_ [--payload-format _format_]++
-*set* -f _-_ [--payload-format _format_] < _file_
+*set* -f _-_ [--payload-format _format_] < _file_++
+*set* --set _field_=_value_... [_flags_]
 	Set boot parameters for one or more components, even if boot parameters
 	already exist for said components. This is handy if one knows what boot
 	parameters to set for which components, but isn't sure if boot parameters
 	have already been set for one or more of them.
 
-	In the first form of the command, one or more of *--mac*, *--nid*, or
+	In the first form of the command, exactly one of *--mac*, *--nid*, or
 	*--xname* is required to identify which component(s) to set boot config for.
 	One or more of *--initrd*, *--kernel*, or *--params* is also required to
 	know which boot parameters to set for the specified components.  For any of
@@ -197,13 +286,34 @@ Subcommands for this command are as follows:
 	flag multiple times (e.g. *--mac* _mac1_ *--mac* _mac2_) or by using one
 	flag and separating each argument by commas (e.g. *--mac* _mac1_,_mac2_).
 
-	In the second form of the command, a file containing the payload data is
+	In the second form of the command, the boot parameters are set for the
+	members of one or more SMD groups, which are looked up in SMD. By default,
+	they are set for the xnames of the members. With *--by-mac*, they are set
+	for the MAC addresses of the members' ethernet interfaces instead, and a
+	warning is printed for members that have none. With *--reconcile*, hosts
+	that have the same boot parameters (kernel, initrd, and kernel parameters)
+	but are no longer members of the groups have them deleted after they are
+	set, so that the boot parameters follow membership changes. For example,
+	running _ochami bss boot params set --group compute --reconcile --kernel
+	..._ again after a node is removed from _compute_ deletes its boot
+	parameters.
+
+	In the third form of the command, a file containing the payload data is
 	passed. This is convenient in cases of dealing with many components at once.
 
-	In the third form of the command, the payload data is read from standard
+	In the fourth form of the command, the payload data is read from standard
 	input.
 
-	This command sends a PUT request to BSS's /bootparameters endpoint.
+	In the fifth form of the command, the boot parameters are built from the
+	fields passed with *--set*, e.g. _--set hosts=[x1000c1s7b0n0] --set
+	kernel=https://example.com/kernel_. This also works for fields without a
+	flag of their own, such as _cloud-init.meta-data_. *--set* can also be
+	combined with *-f*, overriding fields of the payload. The flags of the first
+	two forms override both. See *PAYLOADS* in *ochami*(1).
+
+	This command sends a PUT request to BSS's /bootparameters endpoint. With
+	*--reconcile*, it also sends a GET request and, if any hosts are no longer
+	members, a DELETE request to it.
 
 	This command accepts the following options:
 
@@ -211,12 +321,32 @@ Subcommands for this command are as follows:
 		Specify a file containing the data to send to BSS. The format of this
 		file depends on _--payload-format_ and is _json_ by default. If *-* is
 		used as the argument to _-f_, the command reads the payload data from
-		standard input.
+		standard input. If an _http://_ or _https://_ URL is used, the payload
+		data is fetched from it (see *PAYLOADS* in *ochami*(1)).
 
 	*--payload-format* _format_
 		Format of the file used with _-f_. If unspecified, the payload format is
 		_json_ by default. Supported formats are: _yaml_.
 
+	*--payload-insecure*
+		Do not verify the TLS certificate of the server when the argument to
+		_-f_ is an _https://_ URL.
+
+	*--verify-hosts*
+		Before sending the boot parameters, check that each of the xnames, MAC
+		addresses, and NIDs they are for exists in SMD (as a component or, for
+		MAC addresses, an ethernet interface), and fail if any does not. This
+		catches typos that would otherwise leave a node silently without boot
+		parameters. The host _Default_ is not checked.
+
+	*--allow-unknown-hosts*
+		With *--verify-hosts*, only print a warning for hosts that do not exist
+		in SMD and send the boot parameters anyway.
+
+	*--set* _field_=_value_
+		Set _field_ of the boot parameters to _value_. Can be passed more than
+		once.
+
 	*-m, --mac* _mac_addr_,...
 		One or more MAC addresses to set boot parameters for. For multiple MAC
 		addresses, either this flag can be specified multiple times or this flag
@@ -233,6 +363,20 @@ Subcommands for this command are as follows:
 		either this flag can be specified multiple times or this flag can be
 		specified once and multiple xnames, separated by commas.
 
+	*-g, --group* _group_,...
+		One or more SMD groups whose members to set boot parameters for. For
+		multiple groups, either this flag can be specified multiple times or
+		this flag can be specified once and multiple groups can be specified,
+		separated by commas.
+
+	*--by-mac*
+		With *--group*, set boot parameters for the MAC addresses of the
+		members' ethernet interfaces instead of their xnames.
+
+	*--reconcile*
+		With *--group*, delete the boot parameters of hosts that have the same
+		boot parameters but are no longer members of the groups.
+
 	*--initrd* _initrd_uri_
 		URI from which to fetch the components' initrd.
 
@@ -242,12 +386,13 @@ Subcommands for this command are as follows:
 	*--params* _kernel_params_
 		Command line arguments to pass to kernel for components.
 
-*update* ([--mac _mac_,...] [--nid _nid_,...] [--xname _xname_,...]) ([--initrd _initrd_] [--kernel _kernel_])++
-*update* -f _file_ [--payload-format _format_]++
-*update* -f _-_ [--payload-format _format_] < _file_
+*update* ([--mac _mac_,...] [--nid _nid_,...] [--xname _xname_,...]) ([--initrd _initrd_] [--kernel _kernel_]) [--if-match _etag_]++
+*update* ([--mac _mac_,...] [--nid _nid_,...] [--xname _xname_,...] | --select _query_) ([--params-add _param_]... [--params-set _key_=_value_]... [--params-remove _param_]...) [--initrd _initrd_] [--kernel _kernel_] [--if-match _etag_]++
+*update* [--if-match _etag_] -f _file_ [--payload-format _format_]++
+*update* [--if-match _etag_] -f _-_ [--payload-format _format_] < _file_
 	Update boot parameters for existing components.
 
-	In the first form of the command, one or more of *--mac*, *--nid*, or
+	In the first form of the command, exactly one of *--mac*, *--nid*, or
 	*--xname* is required to identify which component(s) to update boot config
 	for. One or more of *--initrd*, *--kernel*, or *--params* is also required
 	to know which boot parameters to update for the specified components.  For
@@ -255,13 +400,23 @@ Subcommands for this command are as follows:
 	the flag multiple times (e.g. *--mac* _mac1_ *--mac* _mac2_) or by using one
 	flag and separating each argument by commas (e.g. *--mac* _mac1_,_mac2_).
 
-	In the second form of the command, a file containing the payload data is
+	In the second form of the command, the kernel command line of the selected
+	boot parameters is edited instead of replaced. The current boot parameters
+	are fetched, either those of the components passed with *--mac*, *--nid*,
+	or *--xname* or those matching *--select*, and *--params-add*,
+	*--params-set*, and *--params-remove* are applied to the kernel parameters
+	of each, keeping the order of the parameters that are not changed. Each
+	entry is then sent back with its own PATCH request, and entries that would
+	not change are skipped. *--select* can also be used with *--initrd*,
+	*--kernel*, or *--params* to update all matching entries.
+
+	In the third form of the command, a file containing the payload data is
 	passed. This is convenient in cases of dealing with many components at once.
 
-	In the third form of the command, the payload data is read from standard
+	In the fourth form of the command, the payload data is read from standard
 	input.
 
-	This command sends a PUT request to BSS's /bootparameters endpoint.
+	This command sends a PATCH request to BSS's /bootparameters endpoint.
 
 	This command accepts the following options:
 
@@ -269,12 +424,41 @@ Subcommands for this command are as follows:
 		Specify a file containing the data to send to BSS. The format of this
 		file depends on _--payload-format_ and is _json_ by default. If *-* is
 		used as the argument to _-f_, the command reads the payload data from
-		standard input.
+		standard input. If an _http://_ or _https://_ URL is used, the payload
+		data is fetched from it (see *PAYLOADS* in *ochami*(1)).
 
 	*--payload-format* _format_
 		Format of the file used with _-f_. If unspecified, the payload format is
 		_json_ by default. Supported formats are: _yaml_.
 
+	*--payload-insecure*
+		Do not verify the TLS certificate of the server when the argument to
+		_-f_ is an _https://_ URL.
+
+	*--verify-hosts*
+		Before sending the boot parameters, check that each of the xnames, MAC
+		addresses, and NIDs they are for exists in SMD (as a component or, for
+		MAC addresses, an ethernet interface), and fail if any does not. This
+		catches typos that would otherwise leave a node silently without boot
+		parameters. The host _Default_ is not checked. Cannot be used with
+		*--select*.
+
+	*--allow-unknown-hosts*
+		With *--verify-hosts*, only print a warning for hosts that do not exist
+		in SMD and send the boot parameters anyway.
+
+	*--if-match* _etag_|_auto_
+		Only update the boot parameters if they have not been modified since
+		they were read. The request is sent with an If-Match header containing
+		_etag_ or, if _auto_ is passed, the ETag returned by a GET of the boot
+		parameters right before the update, and fails with a "precondition
+		failed" error if it no longer matches. If BSS does not return ETags, a
+		warning is printed and the update is performed unconditionally.
+
+		When editing kernel parameters, _auto_ uses the ETag returned by the
+		GET that the edit is based on instead, and is refused if more than one
+		entry of boot parameters is selected.
+
 	*-m, --mac* _mac_addr_,...
 		One or more MAC addresses to update boot parameters for. For multiple
 		MAC addresses, either this flag can be specified multiple times or this
@@ -300,6 +484,29 @@ Subcommands for this command are as follows:
 	*--params* _kernel_params_
 		Command line arguments to pass to kernel for components.
 
+	*--params-add* _param_
+		Append the kernel parameter _param_ (e.g. _console=ttyS0_ or _quiet_)
+		unless the identical parameter is already present. Several parameters
+		can be passed at once, separated by spaces, and this flag can be passed
+		multiple times.
+
+	*--params-remove* _param_
+		Remove kernel parameters. If _param_ is a key (e.g. _quiet_ or
+		_console_), every parameter with that key is removed. If it is
+		_key_=_value_, only the identical parameter is removed. This flag can be
+		passed multiple times.
+
+	*--params-set* _key_=_value_
+		Set the kernel parameter _key_ to _value_. The first parameter with
+		_key_ is replaced and any others with _key_ are removed, or the
+		parameter is appended if there is none. This flag can be passed
+		multiple times.
+
+	*--select* _query_
+		Select the boot parameters to update with a query string using the
+		query parameters of BSS's /bootparameters endpoint (_name_, _mac_, and
+		_nid_), e.g. _mac=aa:bb:cc:dd:ee:ff_.
+
 ## boot script
 
 Manage boot scripts for components.
@@ -344,6 +551,7 @@ This command accepts the following options:
 
 	- _json_ (default)
 	- _yaml_
+	- _toml_
 
 ## history
 
@@ -353,17 +561,33 @@ to BSS endpoints with UNIX timestamps. Output can be filtered by component name
 
 The format of the command is:
 
-*history* [--output-format _format_] [--xname _xname_,...] [--endpoint _endpoint_,...]
+*history* [--output-format _format_] [--xname _xname_,...] [--endpoint _endpoint_,...] [--since _time_] [--until _time_] [--limit _n_] [--sort _field_[:_order_]]
 
-This command sends a GET to BSS's /endpoint-history endpoint.
+This command sends a GET to BSS's /endpoint-history endpoint. BSS cannot
+filter by time, so *--since* and *--until* are applied to the _last_epoch_ of
+each entry after receiving the history.
 
 This command accepts the following options:
 
+*--limit* _n_
+	Print at most _n_ items. See *LISTS* in *ochami*(1).
+
 *-F, --output-format* _format_
 	Output response data in specified _format_. Supported values are:
 
 	- _json_ (default)
 	- _yaml_
+	- _toml_
+
+*--since* _time_
+	Only print entries at or after _time_. See *TIMES* in *ochami*(1).
+
+*--sort* _field_[:_order_]
+	Sort items by _field_ in _order_ (_asc_, the default, or _desc_). See
+	*LISTS* in *ochami*(1).
+
+*--until* _time_
+	Only print entries at or before _time_. See *TIMES* in *ochami*(1).
 
 *--xname* _xname_,...
 	One or more xnames to filter endpoint history results by. For multiple
@@ -382,7 +606,7 @@ Work with hosts in BSS.
 
 Subcommands for this command are as follows:
 
-*get* [--output-format _format_ ] [--mac _mac_,...] [--nid _nid_,...] [--xname _xname_,...]
+*get* [--output-format _format_ ] [--mac _mac_,...] [--nid _nid_,...] [--xname _xname_,...] [--limit _n_] [--sort _field_[:_order_]]
 	Get a list of hosts that BSS knows about that are in SMD. These results can
 	be optionally filtered by MAC address, node ID, or xname. If no filters are
 	specified, all results are returned.
@@ -391,11 +615,15 @@ Subcommands for this command are as follows:
 
 	This command accepts the following options:
 
+	*--limit* _n_
+		Print at most _n_ items. See *LISTS* in *ochami*(1).
+
 	*-F, --output-format* _format_
 		Output response data in specified _format_. Supported values are:
 
 		- _json_ (default)
 		- _yaml_
+		- _toml_
 
 	*-m, --mac* _mac_addr_,...
 		One or more MAC addresses to filter results by. For multiple MAC
@@ -408,6 +636,10 @@ Subcommands for this command are as follows:
 		this flag can be specified multiple times or this flag can be specified
 		once and multiple NIDs can be specified, separated by commas.
 
+	*--sort* _field_[:_order_]
+		Sort items by _field_ in _order_ (_asc_, the default, or _desc_). See
+		*LISTS* in *ochami*(1).
+
 	*-x, --xname* _xname_,...
 		One or more xnames to filter results by. For multiple xnames, either
 		this flag can be specified multiple times or this flag can be specified
@@ -420,20 +652,33 @@ connected to SMD, or checking the storage backend type/connection status.
 
 The format of this command is:
 
-*status* [--output-format _format_] [--all | --smd | --storage | --version]
+*status* [--output-format _format_] [--all | --detail | --smd | --storage | --version]
 
 This command sends a GET to endpoints under BSS's /service endpoint.
 
+With *--detail*, the status, version, SMD, and storage endpoints are all
+queried and summarized as a table (or in the format passed with
+*--output-format*), showing whether BSS is running and whether it can reach SMD
+and its storage backend. This helps to find out which layer is down when, for
+example, boot scripts cannot be fetched. A dependency whose endpoint this
+version of BSS does not have is shown as _unknown_. The exit status is 1 if BSS
+is not running or reports an error reaching either dependency.
+
 This command accepts the following options:
 
 *--all*
 	Print out all of the status information BSS knows about.
 
+*--detail*
+	Print a summary of BSS's status and of its connections to SMD and its
+	storage backend.
+
 *-F, --output-format* _format_
 	Output response data in specified _format_. Supported values are:
 
 	- _json_ (default)
 	- _yaml_
+	- _toml_
 
 *--smd*
 	Print out the status of BSS's connection to SMD.
@@ -10,7 +10,11 @@ ochami cloud-init [--secure] config add [OPTIONS] (-f _payload_file_ | -d _json_
 ochami cloud-init [--secure] config delete [OPTIONS] [--force] _id_...++
 ochami cloud-init [--secure] config get [OPTIONS] [-F _format_] [_id_...]++
 ochami cloud-init [--secure] config add [OPTIONS] (-f _payload_file_ | -d _json_data_)++
-ochami cloud-init [--secure] data get [OPTIONS] [--meta | --user | --vendor] _id_...
+ochami cloud-init [--secure] data get [OPTIONS] [--meta | --user | --vendor] _id_...++
+ochami cloud-init lint [--strict] [-F _format_] -d _data_...++
+ochami cloud-init [--secure] node group add [OPTIONS] _id_ _group_...++
+ochami cloud-init node group remove [OPTIONS] _id_ _group_...++
+ochami cloud-init [--secure] render [OPTIONS] [--group _label_ [--sample _n_]] [_id_...]
 
 # DATA STRUCTURE
 
@@ -69,7 +73,8 @@ Subcommands for this command are as follows:
 
 *add* --payload _payload_file_ [--payload-format _format_]++
 *add* --payload _-_ [--payload-format _format_] < _file_++
-*add* --data _raw_data_
+*add* --data _raw_data_++
+*add* --set _field_=_value_...
 	Add cloud-init configuration for one or more IDs. This command only accepts
 	payload data and uses the *name* field to determine which ID to add the data
 	for.
@@ -84,6 +89,10 @@ Subcommands for this command are as follows:
 	In the third form of the command, the payload is passed raw on the command
 	line. This data is passed raw to the server.
 
+	In the fourth form of the command, a single configuration is built from
+	the fields passed with *--set*, e.g. _--set name=compute --set
+	cloud-init.metadata.instance-id=compute_ (see *PAYLOADS* in *ochami*(1)).
+
 	This command sends a POST to the /cloud-init endpoint, or /cloud-init-secure
 	if *--secure* is passed.
 
@@ -97,12 +106,21 @@ Subcommands for this command are as follows:
 		Specify a file containing the data to send to cloud-init. The format of
 		this file depends on _--payload-format_ and is _json_ by default. If *-*
 		is used as the argument to _-f_, the command reads the payload data from
-		standard input.
+		standard input. If an _http://_ or _https://_ URL is used, the payload
+		data is fetched from it (see *PAYLOADS* in *ochami*(1)).
 
 	*--payload-format* _format_
 		Format of the file used with _-f_. If unspecified, the payload format is
 		_json_ by default. Supported formats are: _yaml_.
 
+	*--payload-insecure*
+		Do not verify the TLS certificate of the server when the argument to
+		_-f_ is an _https://_ URL.
+
+	*--set* _field_=_value_
+		Set _field_ of the configuration to _value_. Can be passed more than
+		once.
+
 *delete* [--force] _id_...
 	Delete one or more cloud-init configurations, identified by _id_.
 
@@ -114,6 +132,26 @@ Subcommands for this command are as follows:
 	*--force*
 		Do not ask the user to confirm deletion. Use with caution.
 
+*export* --dir _dir_ [_id_...]
+	Export cloud-init configurations to files so that they can be edited and
+	kept in version control. If no IDs are specified, all cloud-init
+	configurations are exported.
+
+	Each configuration is written to a subdirectory of _dir_ named after its ID.
+	Within it, the user-data, meta-data, and vendor-data are written as YAML to
+	_user-data.yaml_, _meta-data.yaml_, and _vendor-data.yaml_, respectively.
+	Empty data is not written. The user-data file begins with a
+	_#cloud-config_ line.
+
+	This command sends a GET request to the /cloud-init endpoint, or
+	/cloud-init-secure if *--secure* is passed.
+
+	This command accepts the following options:
+
+	*-d, --dir* _dir_
+		Directory to write configurations to. It is created if it does not
+		exist. This flag is required.
+
 *get* [--output-format _format_] [_id_...]
 	Get cloud-init configuration for one or more _id_. If no IDs are specified,
 	all cloud-init configurations are retrieved.
@@ -130,10 +168,30 @@ Subcommands for this command are as follows:
 
 		- _json_ (default)
 		- _yaml_
+		- _toml_
+
+*import* --dir _dir_ [_id_...]
+	Import cloud-init configurations from files written by *export*. Each
+	subdirectory of _dir_ is read as the configuration whose ID is the name of
+	the subdirectory. If IDs are specified, only those subdirectories are read.
+	Missing data files are treated as empty data.
+
+	Configurations that already exist are replaced, and those that do not are
+	created.
+
+	This command sends a GET request to the /cloud-init endpoint, followed by a
+	PUT or POST request for each configuration. If *--secure* is passed, the
+	/cloud-init-secure endpoint is used instead.
+
+	This command accepts the following options:
+
+	*-d, --dir* _dir_
+		Directory to read configurations from. This flag is required.
 
-*update* --payload _payload_file_ [--payload-format _format_]++
+*update* [--if-match _etag_] --payload _payload_file_ [--payload-format _format_]++
 *update* --payload _-_ [--payload-format _format_] < _file_++
-*update* --data _raw_data_
+*update* --data _raw_data_++
+*update* --set _field_=_value_...
 	Update one or more existing cloud-init configurations. This command only
 	accepts payload data and uses the *name* field to determine which ID to
 	update.
@@ -148,11 +206,23 @@ Subcommands for this command are as follows:
 	In the third form of the command, the payload is passed raw on the command
 	line. This data is passed raw to the server.
 
+	In the fourth form of the command, a single configuration is built from
+	the fields passed with *--set*, e.g. _--set name=compute --set
+	cloud-init.metadata.instance-id=compute_ (see *PAYLOADS* in *ochami*(1)).
+
 	This command sends a PUT to the /cloud-init endpoint, or /cloud-init-secure
 	if *--secure* is passed.
 
 	This command accepts the following options:
 
+	*--if-match* _etag_|_auto_
+		Only update a config if it has not been modified since it was read. Each
+		PUT is sent with an If-Match header containing _etag_ or, if _auto_ is
+		passed, the ETag returned by a GET of the config right before the PUT,
+		and fails with a "precondition failed" error if it no longer matches.
+		If cloud-init does not return ETags, a warning is printed and the
+		update is performed unconditionally.
+
 	*-d, --data* _raw_data_
 		Pass the payload as raw data on the command line. Data is provided to
 		the server exactly as passed on the command line.
@@ -161,12 +231,21 @@ Subcommands for this command are as follows:
 		Specify a file containing the data to send to cloud-init. The format of
 		this file depends on _--payload-format_ and is _json_ by default. If *-*
 		is used as the argument to _-f_, the command reads the payload data from
-		standard input.
+		standard input. If an _http://_ or _https://_ URL is used, the payload
+		data is fetched from it (see *PAYLOADS* in *ochami*(1)).
 
 	*--payload-format* _format_
 		Format of the file used with _-f_. If unspecified, the payload format is
 		_json_ by default. Supported formats are: _yaml_.
 
+	*--payload-insecure*
+		Do not verify the TLS certificate of the server when the argument to
+		_-f_ is an _https://_ URL.
+
+	*--set* _field_=_value_
+		Set _field_ of the configuration to _value_. Can be passed more than
+		once.
+
 ## data
 
 View cloud-init data. cloud-init data is the raw data that is received by a
@@ -189,6 +268,141 @@ Subcommands for this command are as follows:
 	*--vendor*
 		Fetch cloud-init vendor-data
 
+## lint
+
+Check cloud-config data locally before uploading it, catching errors that would
+otherwise only appear on the first boot of a node. No requests are sent.
+
+The format of this command is:
+
+*lint* [--strict] [--output-format _format_] -d (_data_ | @_path_ | @-)...
+
+The following is checked:
+
+- The first line is _#cloud-config_, or the second line if the first is _##
+  template: jinja_.
+- The data is valid YAML, e.g. without tabs in indentation, and has no
+  duplicate top-level keys.
+- The top level is a mapping whose keys are known to cloud-init.
+- List-valued keys (_bootcmd_, _packages_, _runcmd_, _ssh_authorized_keys_,
+  and _write_files_) are lists, and _write_files_ entries have a _path_.
+
+Unknown keys are warnings, with a suggestion if they look like a typo of a
+known key, since cloud-init ignores them. Everything else is an error. Data
+starting with _#!_ is a script and is not checked. Problems are printed as
+_source_:_line_: _severity_: _message_, followed by a count of errors and
+warnings. The exit status is 2 if errors are found, or if warnings are found
+and *--strict* is passed.
+
+This command accepts the following options:
+
+*-d, --data* _data_ | @_path_ | @-
+	The cloud-config data to check, either as is, read from the file at
+	_path_, or read from standard input (*@-*). Can be passed several times.
+
+*-F, --output-format* _format_
+	Instead of printing problems as lines, print them in _format_, grouped
+	by source. Supported values are:
+
+	- _json_ (default)
+	- _yaml_
+	- _toml_
+
+*--strict*
+	Exit with status 2 on warnings as well.
+
+## node
+
+Manage how nodes get their cloud-init data.
+
+The cloud-init service does not keep group membership itself. When a node
+requests its data, cloud-init looks up the SMD groups the node is a member of
+and merges the configs named after them, in order, before the node's own
+config. The *node group* subcommands therefore attach nodes to cloud-init
+groups by managing their membership in the SMD groups of the same name, which
+requires a token.
+
+Subcommands for this command are as follows:
+
+*group add* _id_ _group_...
+	Attach the node _id_ to each _group_ by adding it to the SMD group of the
+	same name. The groups must exist in SMD. As with *ochami smd group member
+	add*, the node is not added to any group if it is already a member of
+	another group that shares an exclusive group with one of them. A warning
+	is printed for each _group_ that has no cloud-init config (checked on the
+	secure endpoint if *--secure* is passed), since attaching the node does
+	not change its data until one is added.
+
+	This command sends a GET to SMD's /groups endpoint and a GET to
+	cloud-init per _group_, and then a POST to SMD's
+	/groups/{group}/members endpoint per _group_.
+
+*group remove* _id_ _group_...
+	Detach the node _id_ from each _group_ by removing it from the SMD group
+	of the same name. The cloud-init configs of the groups are left as they
+	are.
+
+	This command sends a DELETE to SMD's /groups/{group}/members/{id}
+	endpoint per _group_.
+
+## render
+
+Render cloud-init data of several nodes side by side to spot unintended
+per-node differences.
+
+The format of this command is:
+
+*render* [--meta | --user | --vendor] [--group _label_ [--sample _n_]] [--diff-only] [--exit-code] [--width _width_] [--output-format _format_] [_id_...]
+
+The nodes are passed as _id_ arguments, taken from the members of the SMD group
+passed with *--group*, or both. The data of all nodes is fetched concurrently
+from cloud-init (from the secure endpoint if *--secure* is passed) and printed
+in columns, one per node, with lines compared by position. Lines that are not
+the same for all nodes are marked with *!*, followed by a count of the lines
+that differ.
+
+This command sends a GET to SMD's /groups endpoint if *--group* is passed
+(which requires a token) and one GET per node to cloud-init.
+
+This command accepts the following options:
+
+*--diff-only*
+	Only print the lines that differ between nodes.
+
+*--exit-code*
+	Exit with status 2 if the data differs between nodes.
+
+*--group* _label_
+	Render members of the SMD group _label_. See *--sample*.
+
+*--meta*
+	Render cloud-init meta-data.
+
+*-F, --output-format* _format_
+	Instead of printing columns, print the data of each node in _format_,
+	keyed by node. Supported values are:
+
+	- _json_ (default)
+	- _yaml_
+	- _toml_
+
+*--sample* _n_
+	Render _n_ members of the group passed with *--group*, spread evenly over
+	the members sorted by xname so that repeated runs compare the same nodes.
+	If _n_ is _0_, all members are rendered.
+
+	Default: *3*
+
+*--user*
+	Render cloud-init user-data. This is the default.
+
+*--vendor*
+	Render cloud-init vendor-data.
+
+*--width* _width_
+	Width of the columns together. Defaults to the value of the *COLUMNS*
+	environment variable or, if unset, 160.
+
 # AUTHOR
 
 Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
@@ -0,0 +1,89 @@
+OCHAMI-COMMANDS(1) "OpenCHAMI" "Manual Page for ochami-commands"
+
+# NAME
+
+ochami-commands - List all commands
+
+# SYNOPSIS
+
+ochami commands [--json]
+
+# DESCRIPTION
+
+List all commands of *ochami* with a short description of each. Hidden commands
+are omitted. Deprecated and experimental commands are marked as such in their
+descriptions (see *EXPERIMENTAL FEATURES AND DEPRECATIONS* in *ochami*(1)).
+
+With *--json*, the full command catalog is printed as JSON instead, so that
+wrappers, documentation, and completion for other tools can be generated from
+it rather than by parsing help output. The catalog is an object with the
+following keys:
+
+[[ *Key*
+:< *Value*
+|  *program*
+:  Name of the program, i.e. _ochami_
+|  *version*
+:  Version of *ochami* (see *ochami version*)
+|  *globalFlags*
+:  Flags accepted by every command, described like the flags of commands
+|  *commands*
+:  Top-level commands, each described as below
+
+Each command is an object with the following keys:
+
+[[ *Key*
+:< *Value*
+|  *path*
+:  Full command, e.g. _ochami smd component get_
+|  *name*, *aliases*
+:  Name of the command and its aliases, if any
+|  *use*, *short*, *long*, *example*
+:  Usage line, descriptions, and examples shown in its help
+|  *runnable*
+:  Whether the command does something when run
+|  *services*
+:  Services the command sends requests to (*bss*, *cloud-init*, *pcs*, *smd*)
+|  *mutating*
+:  Whether the command changes data in those services
+|  *deprecated*
+:  If the command is deprecated, an object with the keys *since*, *removal*,
+   *replacement*, and *reason*, each only if known
+|  *experimental*
+:  If the command is experimental, the name of the feature it belongs to
+|  *flags*
+:  Flags of the command, described below
+|  *flagGroups*
+:  Flags that are *mutuallyExclusive*, *requiredTogether*, or *oneRequired*
+|  *subcommands*
+:  Subcommands of the command, each described in the same way
+
+Each flag is an object with the keys *name*, *shorthand* (if any), *type* (e.g.
+_string_, _bool_, or _stringSlice_), *default*, *usage*, *required*,
+*persistent* (whether the flag is accepted by subcommands as well), and, if
+the flag is deprecated or experimental, *deprecated* and *experimental* as for
+commands. Deprecated flags are listed even though they are hidden from help.
+
+This command accepts the following options:
+
+*--json*
+	Print the full command catalog as JSON.
+
+# EXAMPLES
+
+List the paths of all commands that change data in services:
+
+```
+ochami commands --json | jq -r '.. | objects | select(.mutating? == true) | .path'
+```
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
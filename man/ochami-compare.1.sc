@@ -0,0 +1,77 @@
+OCHAMI-COMPARE(1) "OpenCHAMI" "Manual Page for ochami-compare"
+
+# NAME
+
+ochami-compare - Compare data of OpenCHAMI services between clusters
+
+# SYNOPSIS
+
+ochami compare clusters [OPTIONS] _cluster_a_ _cluster_b_
+
+# DESCRIPTION
+
+The *compare* command fetches data from OpenCHAMI services of several clusters
+and reports how they differ, e.g. to check that a staging cluster matches
+production before a maintenance window. Its commands only read data from
+services and never modify it.
+
+# COMMANDS
+
+*clusters* [--resources _resource_,...] [--exit-code] [-F _format_] _cluster_a_ _cluster_b_
+	Fetch resources from SMD and BSS of _cluster_a_ and _cluster_b_, both of
+	which must be configured in the config file, and compare them. The
+	following resources can be compared:
+
+	- _smd.components_ - SMD components, identified by xname.
+	- _smd.groups_ - SMD groups, identified by label.
+	- _smd.ifaces_ - SMD ethernet interfaces, identified by ID.
+	- _smd.rfes_ - SMD redfish endpoints, identified by xname.
+	- _bss.bootparams_ - BSS boot parameters, identified by the hosts, MAC
+	  addresses, or NIDs they apply to, e.g. _hosts=x1000c1s7b0n0_.
+
+	Resources that only exist in _cluster_a_ are listed as removed, those that
+	only exist in _cluster_b_ as added, and those that differ as modified, in
+	the same format as *ochami snapshot diff* (see *ochami-snapshot*(1)).
+
+	The access token of each cluster is read from its
+	*<CLUSTER>_ACCESS_TOKEN* environment variable or its *token-source* (see
+	*ochami*(1)) unless *--token* is passed, in which case it is used for
+	both clusters.
+
+	This command accepts the following options:
+
+	*--exit-code*
+		Exit with status 2 if there are any differences.
+
+	*-F, --output-format* _format_
+		Print the differences in _format_ instead of as text. Supported
+		values are:
+
+		- _json_
+		- _yaml_
+		- _toml_
+
+	*--resources* _resource_,...
+		Only compare these resources.
+
+		Default: all resources
+
+# EXAMPLES
+
+Check that the groups and boot parameters of the staging cluster match those
+of production:
+
+```
+ochami compare clusters staging prod --resources smd.groups,bss.bootparams
+```
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1), *ochami-bss*(1), *ochami-smd*(1), *ochami-snapshot*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
@@ -0,0 +1,70 @@
+OCHAMI-COMPLETION(1) "OpenCHAMI" "Manual Page for ochami-completion"
+
+# NAME
+
+ochami-completion - Generate and install shell autocompletion scripts
+
+# SYNOPSIS
+
+ochami completion (bash | fish | powershell | zsh) [--no-descriptions]++
+ochami completion install [--shell _shell_] [--dir _dir_] [--no-rc] [--force]
+
+# DESCRIPTION
+
+The *bash*, *fish*, *powershell*, and *zsh* subcommands print the autocompletion
+script for the respective shell to standard output. See each subcommand's
+*--help* for how to load the script.
+
+The *install* subcommand writes the autocompletion script for the current shell
+to where that shell loads completions from for the current user, so that no
+manual steps are required.
+
+# COMMANDS
+
+*install* [--shell _shell_] [--dir _dir_] [--no-rc] [--force]
+	Install the autocompletion script for _shell_, which is detected from the
+	*SHELL* environment variable if *--shell* is not passed. The script is
+	written to:
+
+	- bash: _$XDG_DATA_HOME/bash-completion/completions/ochami_
+	- fish: _$XDG_CONFIG_HOME/fish/completions/ochami.fish_
+	- zsh: _$ZDOTDIR/.zsh/completions/\_ochami_
+
+	If unset, *XDG_DATA_HOME* defaults to _~/.local/share_, *XDG_CONFIG_HOME*
+	to _~/.config_, and *ZDOTDIR* to the home directory. For bash, the
+	bash-completion package must be installed for the script to be loaded.
+
+	zsh only loads completions from directories in its _fpath_, so for zsh a
+	block adding the above directory to _fpath_ and running *compinit* is
+	appended to _$ZDOTDIR/.zshrc_. The block is delimited by marker comments
+	and is not added again if it is already present. The rc files of bash and
+	fish are never changed.
+
+	Unless *--force* is passed, the paths that will be written are shown and
+	the user is asked to confirm.
+
+	This command accepts the following options:
+
+	*--dir* _dir_
+		Write the script to _dir_ instead of the shell's default location.
+
+	*--force*
+		Do not ask before writing files.
+
+	*--no-rc*
+		Do not modify any shell rc file.
+
+	*--shell* _shell_
+		Install the script for _shell_ instead of the detected shell. Supported
+		values are _bash_, _fish_, and _zsh_.
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
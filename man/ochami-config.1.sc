@@ -6,8 +6,11 @@ ochami-config - Manage configuration for ochami CLI
 
 # SYNOPSIS
 
+ochami config cluster copy [-d] [--set _key_=_value_]... _src_cluster_ _dst_cluster_++
 ochami config cluster delete _cluster_name_++
-ochami config cluster set [-u _base_uri_] [-d] _cluster_name_++
+ochami config cluster pin [--add] [--force] _cluster_name_++
+ochami config cluster set [-u _base_uri_] [-d] [--format-output _format_] [--format-input _format_] [--from-template _template_] [--set _key_=_value_]... _cluster_name_++
+ochami config migrate [--user | --system | --config _path_] [--dry-run]++
 ochami config set [--user | --system | --config _path_] _key_ _value_++
 ochami config show [-f _format_]++
 ochami config unset [--user | --system | --config _path_] _key_
@@ -20,10 +23,44 @@ Manage cluster configurations.
 
 Subcommands for this command are as follows:
 
+*copy* [--default] [--set _key_=_value_]... _src_cluster_ _dst_cluster_
+	Create a new cluster named _dst_cluster_ with a copy of the configuration
+	of _src_cluster_. _dst_cluster_ must not exist already.
+
+	This command accepts the following options:
+
+	*-d, --default*
+		Set the new cluster as the default cluster.
+
+	*--set* _key_=_value_
+		Set _key_ of the new cluster to _value_. Keys are relative to the
+		cluster's entry in the *clusters* list, e.g. _cluster.base-uri_ or
+		_cluster.defaults.format-output_ (see *ochami-config*(5)). Values are
+		parsed as YAML, so lists can be passed in flow style, e.g.
+		_cluster.pin-sha256=[a, b]_. This option can be passed multiple times.
+
 *delete* _cluster_name_
 	Delete _cluster_name_ configuration from config file.
 
-*set* [--base-uri _base_uri_] [--default] _cluster_name_
+*pin* [--add] [--force] _cluster_name_
+	Fetch the TLS certificate presented by the base URI of _cluster_name_,
+	print its details and public key fingerprint, and, after confirmation,
+	record the fingerprint in the cluster's *pin-sha256* list (see
+	*ochami-config*(5)). Afterwards, connections to the cluster are only
+	accepted if the presented certificate matches a recorded fingerprint. Since
+	the certificate is fetched without being verified, make sure the
+	fingerprint is the expected one before confirming.
+
+	This command accepts the following options:
+
+	*--add*
+		Add the fingerprint to the existing ones instead of replacing them, e.g.
+		while a certificate is being rotated.
+
+	*--force*
+		Do not ask the user to confirm recording the fingerprint.
+
+*set* [--base-uri _base_uri_] [--default] [--format-output _format_] [--format-input _format_] [--from-template _template_] [--set _key_=_value_]... _cluster_name_
 	Add or set configuration for a cluster.
 
 	This command accepts the following options:
@@ -39,6 +76,62 @@ Subcommands for this command are as follows:
 		is not specified on the command line, this cluster's configuration is
 		used.
 
+	*--format-output* _format_
+		Set the format (e.g. _json_, _json-pretty_, or _yaml_) of data
+		printed by commands run against this cluster when *--output-format*
+		is not passed (see *format-output* in *ochami-config*(5)).
+
+	*--format-input* _format_
+		Set the format (_json_ or _yaml_) of payloads read by commands run
+		against this cluster when *--payload-format* is not passed.
+
+	*--from-template* _template_
+		Create the cluster from the cluster template named _template_ in the
+		*cluster-templates* list (see *ochami-config*(5)). The other options
+		are applied on top of the template. This option can only be used when
+		creating a cluster.
+
+	*--set* _key_=_value_
+		Set _key_ of the cluster to _value_. See *copy* above for the format
+		of keys and values. This option can be passed multiple times.
+
+## migrate
+
+Migrate a configuration file to the latest layout.
+
+The format of this command is:
+
+*migrate* [--user | --system | --config _path_] [--dry-run]
+
+This command upgrades a configuration file written for an older version of
+ochami to the layout of the current version, as indicated by its
+*config-version* key (see *ochami-config*(5)), and prints each change made. The
+original file is saved next to it as _path_._timestamp_.bak before it is
+replaced. Files that are already up to date are not modified. By default, or if
+*--user* is specified, the user configuration file is migrated. If *--system*
+is specified, the system configuration file is migrated. Otherwise, if
+*--config* is specified, _path_ is migrated.
+
+Configuration files are also migrated automatically whenever they are loaded.
+If a file cannot be written (e.g. the system config file when not running as
+root), it is only migrated in memory and a warning is printed. This command can
+then be run with the permissions required to migrate it.
+
+This command accepts the following options:
+
+*--config* _path_
+	Migrate the config file at _path_. The *--config* flag is the same one that
+	is global to all commands and is not unique to this command.
+
+*--dry-run*
+	Print the changes that would be made without making them.
+
+*--system*
+	Migrate the system config file.
+
+*--user*
+	Migrate the user config file (the default).
+
 ## set
 
 Set configuration option for ochami CLI.
@@ -16,11 +16,82 @@ _toml_, but YAML is the default. Configuration options can be set via the
 
 These configuration options are global configuration options.
 
+*age-identity:* _path_
+	The file holding the *age*(1) identities (secret keys) that secrets
+	encrypted with age are decrypted with (see *Encrypted Secrets*) when the
+	*OCHAMI_AGE_KEY* environment variable is not set. Default is
+	_~/.config/ochami/age-key.txt_.
+
+*config-version:* _version_
+	The version of the layout of the config file. It is set by *ochami*
+	whenever it writes a config file and should not be changed by hand. When a
+	config file with an older version (or without a version) is loaded, it is
+	migrated to the current layout, e.g. renamed keys are renamed, and the
+	original file is saved next to it as _path_._timestamp_.bak. If the file
+	cannot be written, it is only migrated in memory. Use *ochami config
+	migrate --dry-run* to preview the changes. A config file with a newer
+	version than *ochami* supports cannot be loaded.
+
+	Changes by version:
+	- _1_: Cluster settings (e.g. *base-uri*) that were set next to the
+	  cluster name are moved under *cluster*, and *cluster.uri* is renamed
+	  to *cluster.base-uri*.
+
 *default-cluster:* _cluster_name_
 	The name of the default cluster to use when *--cluster* is not specified on
 	the command line. A cluster configuration must exist for _cluster_name_ or
 	further commands will fail.
 
+*format-output:* _format_
+	The format of data printed by commands when *--output-format* is not
+	passed. When standard output is not a terminal, _json-pretty_ and
+	_table_ are not used and _json_ is printed instead, unless
+	*format-output-pipe* is set (see *OUTPUT FORMATS* in *ochami*(1)).
+
+	Default: *json-pretty* if standard output is a terminal, otherwise *json*
+	Supported:
+	- _json_, on a single line
+	- _json-pretty_, indented
+	- _yaml_
+	- _toml_
+	- _table_, for commands that can print tables (see *TABLES* in
+	  *ochami*(1)); other commands print _json_
+
+*format-output-pipe:* _format_
+	The format of data printed by commands when *--output-format* is not
+	passed and standard output is not a terminal, e.g. when it is piped into
+	another program, overriding *format-output*. The supported formats are
+	those of *format-output*.
+
+*format-input:* _format_
+	The format of payloads read by commands when *--payload-format* is not
+	passed.
+
+	Default: *json*
+	Supported:
+	- _json_
+	- _yaml_
+	- _toml_
+
+*commands*
+	A map of command paths, without the leading *ochami* (e.g. _smd component
+	get_), to *format-output* and *format-input* keys that apply only to that
+	command, overriding the ones above.
+
+*features*
+	Experimental features and deprecation warnings (see *EXPERIMENTAL
+	FEATURES AND DEPRECATIONS* in *ochami*(1)).
+
+	*enable:* [_feature_, ...]
+		The experimental features to enable, in addition to the ones listed
+		in the *OCHAMI_EXPERIMENTAL* environment variable. _all_ enables all
+		of them.
+
+	*deprecation-warnings:* _bool_
+		Whether to warn when deprecated commands or flags are used.
+
+		Default: *true*
+
 *log*
 	Logging options.
 
@@ -31,6 +102,7 @@ These configuration options are global configuration options.
 		Supported:
 		- _basic_
 		- _json_
+		- _logfmt_
 		- _rfc3339_
 
 	*level:* _level_
@@ -38,9 +110,58 @@ These configuration options are global configuration options.
 
 		Default: *warning*
 		Supported:
-		- _info_
+		- _error_
 		- _warning_
+		- _info_
 		- _debug_
+		- _trace_
+
+*color:* _when_
+	When to color output when *--color* is not passed.
+
+	Default: *auto*
+	Supported:
+	- _auto_
+	- _always_
+	- _never_
+
+*remote*
+	A list of remote config files to include, e.g. centrally-managed cluster
+	definitions. Each item has the following keys:
+
+	*url:* _url_
+		The _https://_ URL of the config file. Remote config files cannot be
+		fetched over plain _http://_.
+
+	*ttl:* _duration_
+		How long a fetched copy of the config file is used before fetching
+		it again, e.g. _30m_.
+
+		Default: *1h*
+
+	Each remote config file is fetched, checked like any other config file
+	(e.g. for unknown keys), and cached in
+	_~/.cache/ochami/remote-config/_ (or under *XDG_CACHE_HOME* if set). An
+	invalid file never replaces a valid cached copy. If the file cannot be
+	fetched, a warning is printed and the cached copy, if any, is used even if
+	it is older than _ttl_. Remote config files are merged beneath the config
+	file including them, the same way the user config file is merged with the
+	system config file.
+
+	Since remote config files are not under the user's control, they can only
+	share cluster definitions and settings such as output formats. A remote
+	config file that sets any of the following keys is rejected; they are only
+	honored from local config files:
+
+	- *default-cluster*, *age-identity*, and *remote*
+	- *ssh-tunnel*, *ca-certs*, *token*, *token-source*, and *attestation* of
+	  clusters and cluster templates
+	- *bmc-username* and *bmc-password* in *discover* of clusters and
+	  cluster templates
+
+	The *--config* option of *ochami*(1) also accepts a URL, which is fetched
+	and cached the same way with a TTL of one hour. Remote config files cannot
+	be modified with *ochami config*.
 
 ## Cluster Configuration
 
@@ -54,27 +175,290 @@ the array containing the below configuration options.
 	*base-uri:* _base_uri_
 		The base URI for the OpenCHAMI services for the cluster.
 
+	*failover-uris:* [_base_uri_,...]
+		Alternative base URIs for the cluster's services, e.g. those of
+		redundant API gateways. If a request to *base-uri* fails because no
+		connection can be made to it, the failover URIs are tried in order.
+		Requests are not tried again after an HTTP response, whatever its
+		status. POST and PATCH requests are only tried again if the
+		connection could not be established, so that they are never sent
+		twice. Once a URI has responded, later requests of the same command
+		are sent to it first.
+
+	*pin-sha256:* [_fingerprint_,...]
+		A list of base64-encoded SHA-256 fingerprints of the Subject Public Key
+		Info of the certificates that the cluster's services may present. If
+		set, connections are only accepted if the server certificate's
+		fingerprint matches one of them. Unless CA certificates are set with
+		*ca-certs* or passed with *--cacert*, the pin is checked instead of
+		verifying the certificate against a certificate authority.
+		Fingerprints can be recorded with *ochami config cluster pin*.
+
+	*ca-certs:* [_path_,...]
+		Paths to CA certificate bundles in PEM format whose certificates are
+		trusted to verify the certificates of the cluster's services, in
+		addition to the system's trust store unless *use-system-store* is
+		_false_. This lets services behind certificates issued by a private
+		CA and services behind publicly issued ones, or behind chains mixing
+		both, be used with the same cluster. A bundle passed with *--cacert*
+		is trusted in addition to these. The CA certificates are not used if
+		*--insecure* is passed.
+
+	*use-system-store:* true|false
+		Whether the certificates of the system's trust store are trusted to
+		verify the certificates of the cluster's services. If _false_, only
+		those in *ca-certs* and passed with *--cacert* are trusted, so at least
+		one bundle must be given. Default is _true_.
+
+	*compress:* true|false
+		Compress request bodies of 1 KiB or more with gzip when the cluster
+		is used, as if *--compress* were passed (see *ochami*(1)). The
+		cluster's services must accept gzip-encoded requests. Default is
+		_false_.
+
+	*ip-version:* "4"|"6"|auto
+		Only connect to the cluster's services over IPv4 (_"4"_) or IPv6
+		(_"6"_), as if *--ip-version* were passed (see *ochami*(1)). The
+		value is a string, so numbers must be quoted. Default is _auto_,
+		which uses both.
+
+	*ssh-tunnel:* [_user_@]_host_[:_port_]
+		Tunnel all connections to the cluster's services through an SSH
+		connection to the jump host _host_, for clusters whose services can
+		only be reached from it (e.g. from a bastion). _user_ defaults to the
+		local user and _port_ to 22. The jump host connects to the services
+		on *ochami*'s behalf, so it also resolves their hostnames, and
+		*--ip-version*, proxy environment variables, and *--via-daemon* do
+		not apply.
+
+		*ochami* authenticates with the keys of the SSH agent that
+		*SSH_AUTH_SOCK* points to and verifies the jump host's key against
+		_~/.ssh/known_hosts_ and _/etc/ssh/ssh_known_hosts_, so the jump
+		host must have been connected to with *ssh* before. Passwords and
+		keys outside of the agent are not supported.
+
+	*tenant:* _tenant_
+		The tenant to make requests to the cluster's services on behalf of,
+		used if *--tenant* is not passed (see *ochami*(1)).
+
+	*tenant-header:* _header_
+		The header that the tenant is sent in. Default is _X-Tenant-ID_.
+
+	*token:* {*env:* _variable_, *age:* _blob_, *file:* _path_}
+		The cluster's access token, used if *--token* is not passed and the
+		*\<CLUSTER_NAME\>_ACCESS_TOKEN* environment variable is not set. It
+		takes precedence over *token-source*. See *Encrypted Secrets* for
+		how to keep it in the config file.
+
+	*token-source:* _source_
+		Where to read the cluster's access token from if *--token* is not
+		passed and the *\<CLUSTER_NAME\>_ACCESS_TOKEN* environment variable
+		is not set (see *ochami*(1)). This avoids exporting the token into
+		the shell. _source_ is one of:
+
+		- _keyring_[:_description_] - The payload of the key of type _user_
+		  with _description_ in the Linux kernel keyring, searched for in
+		  the session keyring and then in the user keyring. The description
+		  defaults to _ochami:<cluster_name>_. Such a key can be added with
+		  e.g. *keyctl add user ochami:foobar* _token_ *@u*.
+		- _systemd-credential_:_name_ - The systemd credential _name_, read
+		  from *$CREDENTIALS_DIRECTORY* when *ochami* is run by a unit with
+		  the credential set (e.g. with *LoadCredential=*). Otherwise, it is
+		  read from the first of _/etc/credstore_, _/run/credstore_, and
+		  _/usr/lib/credstore_ that has it, which only works for credentials
+		  stored unencrypted.
+		- _file_:_path_ - The contents of the file at _path_.
+		- _attestation_ - A short-lived token issued by an attestation
+		  service in exchange for an attestation document, e.g. a TPM quote
+		  of the node. The exchange is configured with *attestation*.
+
+		Surrounding whitespace is removed from the token.
+
+	*attestation*
+		Settings of the _attestation_ token source. The attestation document
+		is POSTed as is to *token-uri*, which must respond with a JSON object
+		containing the token in *access_token* (or *token*) and, optionally,
+		its lifetime in seconds in *expires_in*. The token is cached in the
+		user's cache directory (e.g. _~/.cache/ochami/tokens/_) until a
+		minute before it expires, whether according to *expires_in* or to
+		its *exp* claim, and is then exchanged for a new one. Tokens whose
+		expiration is unknown are not cached.
+
+		*token-uri:* _uri_
+			The URI of the attestation service's token endpoint.
+
+		*document:* _path_
+			The file containing the attestation document.
+
+		*document-command:* _command_
+			A shell command that prints the attestation document to standard
+			output, so that a fresh document is generated for each exchange.
+			Only used if *document* is not set. Like the rest of
+			*attestation*, it is only honored from local config files (see
+			*remote*).
+
+		*cacert:* _path_
+			A CA certificate in PEM format to verify the token endpoint's
+			certificate with. Defaults to the one passed with *--cacert*, if
+			any, or else the system's CA certificates are used.
+
+	*defaults*
+		Defaults that apply when the cluster is used. They override the global
+		options of the same name, and are overridden by the corresponding
+		command line flags.
+
+		*format-output:* _format_
+			See *format-output* under *Global Options*.
+
+		*format-input:* _format_
+			See *format-input* under *Global Options*.
+
+		*commands*
+			See *commands* under *Global Options*. These take precedence over
+			the global per-command formats.
+
+		*log*
+			See *log* under *Global Options*. Only *format* and *level* are
+			supported.
+
+	*discover*
+		Settings used by *ochami discover* when the corresponding flags are
+		not passed (see *ochami-discover*(1)). Secrets are not stored in the
+		config file in plain text. Instead, *bmc-username* and *bmc-password*
+		refer to an environment variable (*env*), a file (*file*), or hold
+		the secret encrypted (*age*, see *Encrypted Secrets*). If several are
+		set, the environment variable is used if it is set, then the
+		encrypted secret, then the file. Trailing newlines are removed from
+		the contents of files and decrypted secrets.
+
+		*bmc-username:* {*env:* _variable_, *age:* _blob_, *file:* _path_}
+			Where to get the username set in the RedfishEndpoint of each
+			BMC. Overridden by *--bmc-username*.
+
+		*bmc-password:* {*env:* _variable_, *age:* _blob_, *file:* _path_}
+			Where to get the password set in the RedfishEndpoint of each
+			BMC. Overridden by *--bmc-password-file*.
+
+		Since *bmc-username* and *bmc-password* can point at local secrets,
+		they are only honored from local config files (see *remote*).
+
+		*redfish-scheme:* _scheme_
+			Scheme (_http_ or _https_) of the URI of each RedfishEndpoint.
+			Overridden by *--redfish-scheme*.
+
+		*redfish-port:* _port_
+			Port of the URI of each RedfishEndpoint. Overridden by
+			*--redfish-port*.
+
+		*groups:* [{*pattern:* _glob_, *group:* _group_},...]
+			Rules adding nodes that do not have a group in the payload to
+			the group of the first rule whose _glob_ matches their xname.
+			Rules passed with *--group-rule* are tried first.
+
 *name:* _cluster_name_
 	The name of the cluster. This is what *--cluster* and the *default-cluster*
 	key use to identify the cluster.
 
+## Cluster Templates
+
+The *cluster-templates* key holds a list of cluster configurations with the
+same structure as *clusters*. Templates are never used to contact a cluster
+directly. Instead, *ochami config cluster set --from-template* _name_ creates
+a cluster from a copy of the template with that name, e.g. to share the
+defaults and certificate pins of many similar clusters.
+
+## Encrypted Secrets
+
+Secrets (*token* and the BMC credentials under *discover*) can be kept in the
+config file encrypted with *age*(1), so that the whole config file can be
+committed to e.g. Git. The *age* key of a secret holds the ASCII-armored
+ciphertext printed by *age --armor*, usually as a YAML block scalar. Secrets are
+decrypted when they are used with the identities in the *OCHAMI_AGE_KEY*
+environment variable (e.g. _AGE-SECRET-KEY-1..._), or, if it is not set, with
+the ones in the file set with *age-identity*.
+
+A secret is encrypted with e.g.:
+
+```
+printf %s "$TOKEN" | age --armor -r age1...
+```
+
 # EXAMPLE
 
 ```
+config-version: 1
 clusters:
     - cluster:
         base-uri: https://foobar.openchami.cluster
+        defaults:
+            format-output: yaml
       name: foobar
+commands:
+    smd component get:
+        format-output: yaml
 default-cluster: foobar
+format-input: yaml
 log:
     format: json
     level: debug
 ```
 
+Here, *ochami smd component get* prints YAML for every cluster and other
+commands print YAML for *foobar* only, unless *-F* is passed.
+
+Discovery settings for a cluster can be set so that *ochami discover* does not
+need them as flags:
+
+```
+clusters:
+    - cluster:
+        base-uri: https://foobar.openchami.cluster
+        discover:
+            bmc-username:
+                env: BMC_USER
+            bmc-password:
+                file: /etc/ochami/bmc-password
+            redfish-scheme: https
+            redfish-port: 443
+            groups:
+                - pattern: x1000c1s*b0n*
+                  group: compute
+                - pattern: x1000c0*
+                  group: service
+      name: foobar
+```
+
+The access token of a cluster can be kept encrypted in the config file:
+
+```
+clusters:
+    - cluster:
+        base-uri: https://foobar.openchami.cluster
+        token:
+            age: |
+                -----BEGIN AGE ENCRYPTED FILE-----
+                YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSBhYm...
+                -----END AGE ENCRYPTED FILE-----
+      name: foobar
+```
+
+A team can distribute its cluster definitions from a central server and let
+each user add their own settings:
+
+```
+remote:
+    - url: https://config.example.com/ochami/prod.yaml
+      ttl: 4h
+log:
+    level: info
+```
+
 # FILES
 
 _~/.config/ochami/config.yaml_
 
+_~/.config/ochami/age-key.txt_
+
 # AUTHOR
 
 Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
@@ -0,0 +1,89 @@
+OCHAMI-DESCRIBE(1) "OpenCHAMI" "Manual Page for ochami-describe"
+
+# NAME
+
+ochami-describe - Show everything OpenCHAMI services know about an entity
+
+# SYNOPSIS
+
+ochami describe node [OPTIONS] _node_...
+
+# DESCRIPTION
+
+The *describe* command joins the records that the OpenCHAMI services hold about
+an entity into one document, so that it can be inspected without querying each
+service separately. The records are fetched concurrently and kept as returned
+by the services, so fields that *ochami* does not know about are shown as well.
+Its commands only read data from services and never modify it.
+
+# COMMANDS
+
+*node* [--no-bss] [--no-cloud-init | --cloud-init-secure] [-F _format_] _node_...
+	Show everything known about each _node_, which is an xname, the MAC
+	address of one of the node's ethernet interfaces, or a NID (see
+	*IDENTIFIERS* in *ochami*(1)):
+
+	- _component_ - Its SMD component.
+	- _ethernet_interfaces_ - Its SMD ethernet interfaces.
+	- _component_endpoint_ - Its SMD component endpoint.
+	- _redfish_endpoint_ - The SMD redfish endpoint of its BMC.
+	- _groups_ and _partition_ - Its SMD group and partition memberships.
+	- _boot_params_ - Its BSS boot parameters.
+	- _cloud_init_ - Its cloud-init config.
+
+	By default, a summary of each node is printed as a table. With
+	*--output-format*, a single node is printed as one document and several
+	nodes as a list of them. Records that a service does not have are left
+	out. If a service cannot be reached, an error is
+	printed for the records that could not be fetched (they are also listed
+	under _errors_ when *--output-format* is passed), the rest of the node is
+	still described, and the exit status is 1. An access token is required.
+
+	This command sends GETs to SMD's /State/Components,
+	/Inventory/EthernetInterfaces, /Inventory/ComponentEndpoints,
+	/Inventory/RedfishEndpoints, and /memberships endpoints, to BSS's
+	/bootparameters endpoint, and to cloud-init.
+
+	This command accepts the following options:
+
+	*--cloud-init-secure*
+		Get the cloud-init config from the secure cloud-init endpoint.
+
+	*--no-bss*
+		Do not get boot parameters from BSS.
+
+	*--no-cloud-init*
+		Do not get the cloud-init config.
+
+	*-F, --output-format* _format_
+		Instead of a summary, print all records in _format_ as one document.
+		Supported values are:
+
+		- _json_ (default)
+		- _yaml_
+		- _toml_
+
+# EXAMPLES
+
+Show the data of a node that does not boot as expected:
+
+```
+ochami describe node x1000c1s7b0n0
+```
+
+Show the nodes with NID 12 and the MAC address _de:ad:be:ef:00:01_:
+
+```
+ochami describe node 12 de:ad:be:ef:00:01
+```
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1), *ochami-bss*(1), *ochami-cloud-init*(1), *ochami-smd*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
@@ -2,12 +2,14 @@ OCHAMI-DISCOVER(1) "OpenCHAMI" "Manual Page for ochami-discover"
 
 # NAME
 
-ochami-discover - Populate SMD using a file
+ochami-discover - Populate SMD, BSS, and cloud-init using a file
 
 # SYNOPSIS
 
 ochami discover [OPTIONS] -f _file_ [--payload-format _format_]
 
+ochami discover [OPTIONS] -f _file_ --reconcile [--prune]
+
 # DESCRIPTION
 
 Sometimes, discovery via Redfish may not be possible or feasible using dynamic
@@ -24,16 +26,39 @@ The file contains a list of "nodes", each with its own configuration (see
 *DATA STRUCTURE*). The *discover* command reads this data and creates the SMD
 RedfishEndpoints, EthernetInterfaces, Components, and groups data in SMD
 corresponding to each node. It also creates Components corresponding to each
-node's BMC which corresponds to each RedfishEndpoint created.
+node's BMC which corresponds to each RedfishEndpoint created. If nodes have boot
+or cloud-init configuration, the corresponding BSS boot parameters and
+cloud-init configs are created as well, so that one file can provision all three
+services.
 
 This command accepts the following options:
 
+*--bmc-password-file* _file_
+	Set the password of each RedfishEndpoint to the contents of _file_,
+	without trailing newlines. Overrides *discover.bmc-password* in the
+	cluster config.
+
+*--bmc-username* _username_
+	Set the username of each RedfishEndpoint to _username_. Overrides
+	*discover.bmc-username* in the cluster config.
+
+*--group-rule* _pattern_=_group_
+	Add nodes that do not have a *group* or *groups* in the payload and whose
+	xname matches _pattern_ to _group_, which is created if it does not
+	exist. _pattern_ is a glob where *\** matches any characters and *?*
+	matches a single character, e.g. _x1000c1s\*b0n\*_. This flag can be
+	passed multiple times. A node is added to the group of the first rule it
+	matches. Rules passed with this flag are tried before the rules in
+	*discover.groups* in the cluster config.
+
 *--overwrite*
 	Instead of failing if data already exists, overwrite it with new data
-	contained in the payload.
+	contained in the payload. This applies to BSS boot parameters and
+	cloud-init configs as well.
 
 *-f, --payload* _file_
-	This option is mandatory.
+	This option is mandatory. If an _http://_ or _https://_ URL is used, the
+	payload data is fetched from it (see *PAYLOADS* in *ochami*(1)).
 
 	Specify a file containing the data to send to SMD. The format of this
 	file depends on _--payload-format_ and is _json_ by default. If *-* is
@@ -44,10 +69,54 @@ This command accepts the following options:
 	Format of the file used with _-f_. If unspecified, the payload format is
 	_json_ by default. Supported formats are: _yaml_.
 
+*--payload-insecure*
+	Do not verify the TLS certificate of the server when the argument to
+	_-f_ is an _https://_ URL.
+
+*--prune*
+	Only valid with *--reconcile*. Delete the EthernetInterfaces in SMD that
+	belong to a node or BMC in the payload but whose MAC address is no
+	longer in the payload, e.g. those of a replaced NIC.
+
+*--reconcile*
+	Compare the Components, RedfishEndpoints, EthernetInterfaces, and groups
+	in SMD with the ones generated from the payload, and only create or
+	update those that are new or differ. This makes it safe to re-run
+	*discover* after changing the payload. Components and RedfishEndpoints
+	are matched by xname, EthernetInterfaces by MAC address, and groups by
+	name. Fields that are not set in the payload, as well as the state of
+	Components, are not compared. Fields that are read from the payload are
+	compared even if empty, so that e.g. removing *bmc_ip* from a node
+	removes the IP address of its RedfishEndpoint. Group members are only
+	added, never removed, and the description of a group is only changed if
+	the payload sets one. BSS boot parameters and cloud-init configs are
+	replaced as with *--overwrite*. Cannot be used with *--overwrite*.
+
+	When done, a table is printed with the number of each kind of resource
+	that was created, updated, unchanged, and deleted. The changes to
+	individual resources are logged at the _info_ log level, or the
+	_debug_ level for unchanged ones.
+
+*--redfish-port* _port_
+	Set the URI of each RedfishEndpoint to the service root of the BMC on
+	_port_. The BMC's IP address (or xname if it has none) is used as the
+	host. The scheme is _https_ unless *--redfish-scheme* is passed.
+	Overrides *discover.redfish-port* in the cluster config.
+
+*--redfish-scheme* _scheme_
+	Set the URI of each RedfishEndpoint to the service root of the BMC using
+	_scheme_, which is _http_ or _https_. Overrides *discover.redfish-scheme*
+	in the cluster config.
+
+Instead of passing the above flags for every discovery run, their values can be
+set in the *discover* section of the config of the cluster being used (see
+*ochami-config*(5)).
+
 # DATA STRUCTURE
 
-The format of the payload is a *nodes* object containing an array of node data.
-An example of such an array containing one node in YAML format is as follows:
+The format of the payload is a *nodes* object containing an array of node data
+and an optional *groups* object containing an array of group configuration. An
+example containing one node and one group in YAML format is as follows:
 
 ```
 nodes:
@@ -60,16 +129,31 @@ nodes:
   interfaces:
   - mac_addr: de:ad:be:ee:ee:f1
     ip_addrs:
-    - name: internal
+    - network: internal
       ip_addr: 172.16.0.1
   - mac_addr: de:ad:be:ee:ee:f2
     ip_addrs:
-    - name: external
+    - network: external
       ip_addr: 10.15.3.100
   - mac_addr: 02:00:00:91:31:b3
     ip_addrs:
-    - name: HSN
+    - network: HSN
       ip_addr: 192.168.0.1
+  boot:
+    kernel: http://172.16.0.254/boot/vmlinuz
+    initrd: http://172.16.0.254/boot/initramfs.img
+    params: console=ttyS0,115200 root=live:http://172.16.0.254/image.squashfs
+  cloud_init:
+    meta_data:
+      hostname: node01
+groups:
+- name: compute
+  description: Compute nodes
+  cloud_init:
+    user_data:
+      write_files:
+      - path: /etc/motd
+        content: Welcome to a compute node
 ```
 
 A description of each key in the above is as follows:
@@ -88,11 +172,34 @@ created for node.
 - *bmc_ip* - Desired IP address of node's BMC.
 - *group* - Optional group to add node to. This will get created during
 discovery if it does not exist.
+- *groups* - Optional list of further groups to add the node to, e.g. to put it
+in both a role group and a rack group. These are created like *group*.
 - *interfaces* - A list of network interfaces for the node.
 	- *mac_addr* - MAC address of network interface.
 	- *ip_addrs* - List of IP addresses assigned to interface.
-		- *name* - Short name identifying the network for the IP address.
+		- *network* - Short name identifying the network for the IP address.
 		- *ip_addr* - IP address for interface.
+- *boot* - Optional boot configuration for the node that is sent to BSS. Nodes
+with identical boot configurations share one set of boot parameters.
+	- *kernel* - URI of the kernel to boot. This is required if *boot* is set.
+	- *initrd* - URI of the initramfs to boot.
+	- *params* - Kernel command line parameters.
+- *cloud_init* - Optional cloud-init data for the node. A cloud-init config
+named after the node's xname is created with it.
+	- *user_data* - User data for the node.
+	- *meta_data* - Meta data for the node.
+	- *vendor_data* - Vendor data for the node.
+
+Each entry in *groups* has the following keys:
+
+- *name* - Name of the group. This matches the *group* or *groups* keys of
+nodes.
+- *description* - Optional description used for the SMD group instead of the
+generated one.
+- *cloud_init* - Optional cloud-init data for the group, with the same keys as
+the node *cloud_init* key. A cloud-init config named after the group is created
+with it. The cloud-init service merges it into the data served to each node in
+the group.
 
 # XNAMES
 
@@ -0,0 +1,68 @@
+OCHAMI-DOCTOR(1) "OpenCHAMI" "Manual Page for ochami-doctor"
+
+# NAME
+
+ochami-doctor - Diagnose problems with the local environment
+
+# SYNOPSIS
+
+ochami doctor
+
+# DESCRIPTION
+
+Check the local environment for problems that keep other commands from
+working and print remediation hints for any that are found. No requests are
+sent to the cluster's services. The following checks are performed:
+
+- *config*: The config files in use (the file passed with *--config*, or the
+  system and user config files) are read and validated one by one. A file
+  that is writable by users other than its owner is reported as a warning,
+  since whoever can write it can change which cluster commands talk to.
+  Remote config files are only checked for whether they loaded. Unlike other
+  commands, *doctor* does not exit if the config cannot be loaded, but
+  reports it, and does not offer to create a config file passed with
+  *--config* that does not exist.
+- *cluster*: A cluster is selected with *--cluster* or *default-cluster*
+  and has a base URI, or *--base-uri* was passed.
+- *token*: A token is available, either passed with *--token*, set in the
+  *\<CLUSTER_NAME\>_ACCESS_TOKEN* environment variable for the cluster in use
+  (see *ochami*(1)), or read from its *token-source* (see *ochami-config*(5)),
+  and can be parsed. An expired token is a failure and one
+  that expires in 15 minutes or less is a warning.
+- *clock*: The token's issued at (_iat_) and not before (_nbf_) times are not
+  more than a minute in the future. If they are, the local clock is most
+  likely behind and services will reject the token.
+- *CA certificate*: Each CA certificate bundle in the cluster's *ca-certs*,
+  and the one passed with *--cacert*, can be read and contains PEM-encoded
+  certificates. Certificates that are expired or not yet valid are reported
+  as a warning. If the cluster sets *use-system-store* to _false_, at least
+  one bundle must be given.
+- *DNS* and *TCP*: The hosts of the cluster's base URI and any
+  *failover-uris* (see *ochami-config*(5)) resolve and accept TCP connections
+  on the URI's port (443 or 80 by default, depending on the scheme). Proxies
+  are not used for this check.
+
+A line is printed for each check with its status (*OK*, *WARN*, *FAIL*, or
+*SKIP*), followed by a hint for warnings and failures. *ochami* exits with a
+status of 1 if any check failed and 0 otherwise.
+
+# EXAMPLES
+
+Check the environment for the default cluster and for cluster _foobar_ using
+a custom CA certificate:
+
+```
+ochami doctor
+ochami --cluster foobar --cacert ca.pem doctor
+```
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1), *ochami-config*(1), *ochami-config*(5), *ochami-token*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
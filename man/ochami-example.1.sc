@@ -0,0 +1,61 @@
+OCHAMI-EXAMPLE(1) "OpenCHAMI" "Manual Page for ochami-example"
+
+# NAME
+
+ochami-example - Print example payload files
+
+# SYNOPSIS
+
+ochami example --list++
+ochami example [-F _format_] _resource_
+
+# DESCRIPTION
+
+Print an example payload file for _resource_, to be filled in and passed to the
+commands that work with it using *-f*. The example is generated from the same
+data structures that *ochami* reads payload files into, just like the schema
+printed by *ochami-schema*(1), so it contains every key that is accepted and
+always matches the version of *ochami* being run.
+
+Keys are set to example values where there are any and to empty values of
+their type otherwise. Lists contain a single item. In YAML, each key is
+preceded by a comment describing it, its type, and whether it is required.
+The same descriptions are included in the schema printed by
+*ochami-schema*(1).
+
+The available resources are the same as for *ochami-schema*(1) and can be
+listed with *--list*.
+
+This command accepts the following options:
+
+*--list*
+	List the available resources and exit.
+
+*-F, --output-format* _format_
+	Output the example in the specified _format_. Supported values are:
+
+	- _yaml_ (default)
+	- _json_
+
+	JSON does not support comments, so JSON examples contain only the values.
+
+# EXAMPLES
+
+Start a discovery payload file from an example and pass it to *discover*
+once filled in:
+
+```
+ochami example discovery-items > nodes.yaml
+ochami discover -f nodes.yaml --payload-format yaml
+```
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1), *ochami-discover*(1), *ochami-schema*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
@@ -0,0 +1,66 @@
+OCHAMI-NODE(1) "OpenCHAMI" "Manual Page for ochami-node"
+
+# NAME
+
+ochami-node - Perform tasks on nodes using data from OpenCHAMI services
+
+# SYNOPSIS
+
+ochami node console [OPTIONS] _node_
+
+# DESCRIPTION
+
+The *node* command performs tasks on nodes that would otherwise require looking
+up information about them in OpenCHAMI services by hand.
+
+# COMMANDS
+
+*console* [--method ipmi|ssh] [--user _user_] [--port _port_] [--print] _node_
+	Open a serial-over-LAN (SOL) console to _node_ via its BMC. _node_ is an
+	xname, the MAC address of one of the node's ethernet interfaces, or a NID
+	(see *IDENTIFIERS* in *ochami*(1)), which is resolved to the node's xname
+	using SMD. The BMC's xname is derived from the node's xname (e.g.
+	*x1000c1s7b0n0* has BMC *x1000c1s7b0*), and the BMC's address and
+	username are read from the redfish endpoint stored for it in SMD. The
+	address used is the endpoint's FQDN, its hostname (with its domain, if
+	set), or its IP address, in that order of preference. An address or
+	username that starts with *-* is refused so that it is not taken for an
+	option of the console command.
+
+	This command sends a GET request to SMD's /Inventory/RedfishEndpoints/{xname}
+	endpoint and therefore requires a token.
+
+	This command accepts the following options:
+
+	*--method* _method_
+		How to connect to the console. Supported values are:
+
+		- _ipmi_ (default): Run *ipmitool*(1) with the lanplus interface and
+		  activate SOL. The password is passed to ipmitool via the
+		  *IPMI_PASSWORD* environment variable. If it is not already set, the
+		  password stored in SMD is used, if SMD returns one (SMD normally
+		  suppresses it).
+		- _ssh_: Run *ssh*(1) against the port that some BMCs (e.g. OpenBMC)
+		  serve the host console on.
+
+	*--port* _port_
+		Port to connect to on the BMC. The default is that of *ipmitool* (623)
+		for _ipmi_ and 2200 for _ssh_.
+
+	*--print*
+		Print the command that would be run instead of running it. The
+		password is never printed.
+
+	*--user* _user_
+		Username to log into the BMC with instead of the one stored in SMD.
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1), *ochami-smd*(1), *ipmitool*(1), *ssh*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
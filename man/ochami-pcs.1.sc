@@ -0,0 +1,89 @@
+OCHAMI-PCS(1) "OpenCHAMI" "Manual Page for ochami-pcs"
+
+# NAME
+
+ochami-pcs - Communicate with the Power Control Service (PCS)
+
+# SYNOPSIS
+
+ochami pcs [OPTIONS] COMMAND
+
+# DESCRIPTION
+
+The *pcs* command performs power operations on components via PCS transitions.
+A transition applies a single power operation to a set of components and is
+carried out by PCS in the background.
+
+# COMMANDS
+
+*power* _operation_ [--group _label_,...] [--batch-size _n_] [--stagger _duration_] [--max-failures _n_] [_component_...]
+	Perform _operation_ on the components passed as arguments and the members
+	of the SMD groups passed with *--group*. Components are passed by xname,
+	MAC address, or NID (see *IDENTIFIERS* in *ochami*(1)) and resolved to
+	xnames using SMD. _operation_ is one of _on_, _off_,
+	_soft-off_, _soft-restart_, _hard-restart_, _init_, or _force-off_.
+
+	By default, a single transition is started for all components. With
+	*--batch-size*, the components are split into batches and a transition
+	is started for each batch in turn, after the previous one has finished
+	and *--stagger* has elapsed, so that a whole group is not power cycled at
+	once. Progress is reported on standard error and a summary of the
+	rollout, including the tasks that failed, is printed at the end.
+
+	The rollout stops without starting further batches once more than
+	*--max-failures* components have failed, or when SIGINT or SIGTERM is
+	received. In the latter case, the current batch is still waited for and
+	*ochami* exits with status 130. If any component failed or the rollout
+	was stopped, the exit status is 1.
+
+	This command sends a POST and GETs to PCS's /transitions endpoint for each
+	batch, as well as a GET to SMD's /groups/{label} endpoint for each group,
+	and therefore requires a token.
+
+	This command accepts the following options:
+
+	*--batch-size* _n_
+		Number of components per transition. The default, _0_, puts all
+		components in a single transition.
+
+	*--deadline* _minutes_
+		Number of minutes PCS may take for each task of a transition. If not
+		passed, PCS's default is used.
+
+	*--force*
+		Do not ask the user to confirm before starting the rollout.
+
+	*--group* _label_,...
+		One or more SMD groups whose members to power. For multiple groups,
+		either this flag can be specified multiple times or this flag can be
+		specified once and multiple groups, separated by commas.
+
+	*--max-failures* _n_
+		Stop starting batches once more than _n_ components have failed.
+		Default: _0_, i.e. stop after the first batch with a failure. Pass
+		_-1_ to never stop.
+
+	*-F, --output-format* _format_
+		Output the summary in specified _format_. Supported values are:
+
+		- _json_ (default)
+		- _yaml_
+		- _toml_
+
+	*--poll-interval* _duration_
+		How often to check the progress of a transition. Default: _5s_.
+
+	*--stagger* _duration_
+		Time to wait after a batch has finished before starting the next one,
+		e.g. _10s_ or _1m_. Default: _0s_.
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1), *ochami-smd*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
@@ -0,0 +1,95 @@
+OCHAMI-PLUGIN(1) "OpenCHAMI" "Manual Page for ochami-plugin"
+
+# NAME
+
+ochami-plugin - Manage external subcommands (plugins)
+
+# SYNOPSIS
+
+ochami plugin list++
+ochami [GLOBAL OPTIONS] _name_ [_argument_...]
+
+# DESCRIPTION
+
+Any executable on *PATH* named *ochami-*_name_ can be run as *ochami* _name_,
+which allows sites to extend ochami without modifying it. If more than one
+directory in *PATH* contains such an executable, the first one is run. Plugins
+with the same name as a built-in command are never run.
+
+Global options passed before _name_ (e.g. *--cluster* or *--base-uri*) are
+processed by ochami as they would be for a built-in command, including reading
+the configuration file. The result is passed to the plugin via the environment
+variables listed below. All arguments after _name_, including ones that look
+like global options, are passed to the plugin unchanged.
+
+The plugin's standard input, output, and error are those of ochami, so
+*--output-file* works for plugins as well. ochami exits with the exit status of
+the plugin.
+
+# COMMANDS
+
+*list*
+	List the plugins found on *PATH* and the executable that is run for each.
+	Executables that are never run, either because they are shadowed by one
+	earlier in *PATH* or because they have the same name as a built-in
+	command, are reported as warnings.
+
+# ENVIRONMENT
+
+The following variables are set for plugins. Variables for settings that are
+not set are not set either.
+
+*OCHAMI_ACCESS_TOKEN*
+	The access token passed with *--token* or, if not passed, read from the
+	environment variable or token source of the cluster being used (see
+	*ochami*(1)).
+	Unlike for built-in commands, a missing token is not an error.
+
+*OCHAMI_BASE_URI*
+	The base URI of the cluster being used, from *--base-uri* or the
+	cluster's configuration.
+
+*OCHAMI_BIN*
+	The path to the ochami executable, so that plugins can run built-in
+	commands.
+
+*OCHAMI_CACERT*
+	The path passed with *--cacert*.
+
+*OCHAMI_CLUSTER*
+	The name of the cluster being used, from *--cluster* or
+	*default-cluster* in the configuration file.
+
+*OCHAMI_CLUSTER_CONFIG*
+	The configuration of the cluster being used, in YAML.
+
+*OCHAMI_CONFIG*
+	The path passed with *--config*.
+
+*OCHAMI_INSECURE*
+	Set to _true_ if *--insecure* was passed.
+
+*OCHAMI_LOG_FORMAT*, *OCHAMI_LOG_LEVEL*
+	The effective log format and level.
+
+*OCHAMI_LOG_SECRETS*
+	Set to _true_ if *--log-secrets* was passed.
+
+*OCHAMI_TENANT*, *OCHAMI_TENANT_HEADER*
+	The tenant passed with *--tenant* or set with *tenant* in the cluster's
+	configuration, and the header it is sent in.
+
+*OCHAMI_VERBOSITY*
+	The preset passed with *--verbosity*, e.g. so that the plugin can also
+	stop reporting progress with _quiet_.
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
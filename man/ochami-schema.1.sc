@@ -0,0 +1,77 @@
+OCHAMI-SCHEMA(1) "OpenCHAMI" "Manual Page for ochami-schema"
+
+# NAME
+
+ochami-schema - Print JSON Schema for payload files
+
+# SYNOPSIS
+
+ochami schema --list++
+ochami schema [-F _format_] _resource_
+
+# DESCRIPTION
+
+Print the JSON Schema describing the format of payload files accepted by the
+commands that work with _resource_. The schema is generated from the same data
+structures that *ochami* reads payload files into, so it always matches the
+version of *ochami* being run. It can be used with any JSON Schema validator to
+check payload files before passing them to *ochami* with *-f*, for instance in
+CI pipelines. YAML payload files can be validated against the schema as well,
+since they are converted to JSON before being read.
+
+Properties that are not part of the schema are not allowed, so that misspelled
+keys (which *ochami* would otherwise silently ignore) are caught.
+
+The available resources are:
+
+[[ *Resource*
+:< *Payload*
+|  *bootparams*
+:  BSS boot parameters (*bss boot params add*/*set*/*update*/*delete*)
+|  *cloud-init-config*
+:  List of cloud-init configs (*cloud-init config add*/*update*)
+|  *component*
+:  SMD components (*smd component add*/*update*/*delete*)
+|  *discovery-items*
+:  Node list for discovery (*discover*)
+|  *group*
+:  List of SMD groups (*smd group add*/*update*)
+|  *iface*
+:  List of SMD ethernet interfaces (*smd iface add*/*delete*)
+|  *rfe*
+:  List of SMD redfish endpoints (*smd rfe add*)
+|  *rfe-v2*
+:  SMD redfish endpoints using the v2 schema, as generated by *discover*
+
+This command accepts the following options:
+
+*--list*
+	List the available resources and exit.
+
+*-F, --output-format* _format_
+	Output the schema in the specified _format_. Supported values are:
+
+	- _json_ (default)
+	- _yaml_
+	- _toml_
+
+# EXAMPLES
+
+Save the schema for discovery payloads and use it to validate a payload file
+with an external validator:
+
+```
+ochami schema discovery-items > discovery.schema.json
+check-jsonschema --schemafile discovery.schema.json nodes.yaml
+```
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1), *ochami-discover*(1), *ochami-example*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
@@ -0,0 +1,80 @@
+OCHAMI-SERVE-PROXY(1) "OpenCHAMI" "Manual Page for ochami-serve-proxy"
+
+# NAME
+
+ochami-serve-proxy - Run a local daemon that keeps connections to services open
+
+# SYNOPSIS
+
+ochami serve-proxy [--socket _path_] [--idle-timeout _duration_]
+
+# DESCRIPTION
+
+The *serve-proxy* command runs a daemon in the foreground that keeps
+connections to OpenCHAMI services open across invocations of *ochami*. When
+*ochami* is passed *--via-daemon*, it sends its requests to the daemon over a
+Unix socket instead of connecting to the services itself, and the daemon
+forwards them over the connections it keeps open. This saves a TCP connection
+and TLS handshake per invocation, which dominate the runtime of scripts that
+run *ochami* in a loop.
+
+The daemon holds no credentials of its own. The access token of each request
+is passed through unchanged, and the TLS settings used to connect to the
+service (*--cacert*, *--insecure*, certificate pins, and *--ip-version*) are
+those of the invocation that sent it. The socket is created with mode 0600 in
+a directory with mode 0700, so that only the user running the daemon can use
+it. Since the default directory is predictable if *XDG_RUNTIME_DIR* is not
+set, the daemon refuses to start if the directory of the socket is not owned by
+the user running it or can be accessed by other users, e.g. because another
+user created it first. Likewise, invocations passed *--via-daemon* refuse to
+use a socket that is not owned by them or whose directory is not, print a
+warning, and connect to the services directly.
+
+If the daemon is not running, invocations passed *--via-daemon* connect to the
+services directly, so scripts work either way. If a request cannot be
+forwarded, the daemon responds with _502 Bad Gateway_ and the error.
+
+The daemon stops when it receives SIGINT or SIGTERM, letting requests in
+flight finish, or after *--idle-timeout*.
+
+# OPTIONS
+
+*--idle-timeout* _duration_
+	Stop after no requests were received for _duration_. _0_ never stops.
+	Default is _0_.
+
+*--socket* _path_
+	Path of the Unix socket to listen on. Default is the value of
+	*OCHAMI_DAEMON_SOCKET* if set, otherwise _ochami/proxy.sock_ in
+	*$XDG_RUNTIME_DIR*, or in _ochami-<uid>_ in the temporary directory if
+	*XDG_RUNTIME_DIR* is not set. Invocations passed *--via-daemon* use the
+	same default, so *OCHAMI_DAEMON_SOCKET* must be set for them if
+	*--socket* is passed.
+
+# ENVIRONMENT
+
+*OCHAMI_DAEMON_SOCKET*
+	Path of the Unix socket of the daemon, used by both the daemon and
+	invocations passed *--via-daemon*.
+
+# EXAMPLES
+
+Run the daemon for the duration of a script:
+
+```
+ochami serve-proxy --idle-timeout 5m &
+for xname in $(cat nodes.txt); do
+	ochami --via-daemon smd component get --xname "$xname"
+done
+```
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
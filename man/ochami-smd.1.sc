@@ -219,7 +219,7 @@ Manage component endpoints.
 
 Subcommands for this command are as follows:
 
-*delete* [--force] --all++
+*delete* [--force] --all [--expect-count _n_]++
 *delete* [--force] _xname_...++
 *delete* [--force] -f _file_ [--payload-format _format_]++
 *delete* [--force] -f _-_ [--payload-format _format_]
@@ -227,7 +227,10 @@ Subcommands for this command are as follows:
 	is asked to confirm deletion.
 
 	In the first form of the command, all component endpoints are deleted. *BE
-	CAREFUL!*
+	CAREFUL!* They are counted first and the number that will be deleted is
+	shown, e.g. _This will delete 4,213 component endpoints on cluster foobar_,
+	before the user is asked to confirm. If the count changes while the user is
+	confirming, nothing is deleted.
 
 	In the second form of the command, one or more xnames identifying the
 	component(s) whose component endpoint(s) to delete is/are specified.
@@ -247,6 +250,11 @@ Subcommands for this command are as follows:
 	*-a, --all*
 		Delete *all* component endpoints in SMD. *BE CAREFUL!*
 
+	*--expect-count* _n_
+		With *--all*, fail without deleting anything unless there are exactly
+		_n_ component endpoints to delete. This guards scripts against deleting
+		more than expected, e.g. because they run against the wrong cluster.
+
 	*--force*
 		Do not ask the user to confirm deletion. Use with caution.
 
@@ -254,13 +262,18 @@ Subcommands for this command are as follows:
 		Specify a file containing the data to send to SMD. The format of this
 		file depends on _--payload-format_ and is _json_ by default. If *-* is
 		used as the argument to _-f_, the command reads the payload data from
-		standard input.
+		standard input. If an _http://_ or _https://_ URL is used, the payload
+		data is fetched from it (see *PAYLOADS* in *ochami*(1)).
 
 	*--payload-format* _format_
 		Format of the file used with _-f_. If unspecified, the payload format is
 		_json_ by default. Supported formats are: _yaml_.
 
-*get* [--output-format _format_] [_xname_]...
+	*--payload-insecure*
+		Do not verify the TLS certificate of the server when the argument to
+		_-f_ is an _https://_ URL.
+
+*get* [--output-format _format_] [_xname_]... [--limit _n_] [--sort _field_[:_order_]]
 	Get all or a subset of component endpoints.
 
 	If no arguments are passed, all component endpoints are returned. Otherwise,
@@ -270,11 +283,19 @@ Subcommands for this command are as follows:
 
 	This command accepts the following options:
 
+	*--limit* _n_
+		Print at most _n_ items. See *LISTS* in *ochami*(1).
+
 	*-F, --output-format* _format_
 		Output response data in specified _format_. Supported values are:
 
 		- _json_ (default)
 		- _yaml_
+		- _toml_
+
+	*--sort* _field_[:_order_]
+		Sort items by _field_ in _order_ (_asc_, the default, or _desc_). See
+		*LISTS* in *ochami*(1).
 
 ## component
 
@@ -282,16 +303,19 @@ Manage components.
 
 Subcommands for this command are as follows:
 
-*add* [--arch _arch_] [--enabled] [--role _role_] [--state _state_] _xname_ _node_id_++
+*add* [--arch _arch_] [--class _class_] [--enabled] [--flag _flag_] [--net-type _type_] [--role _role_] [--set _field_=_value_]... [--state _state_] [--subrole _subrole_] [--subtype _subtype_] [--type _type_] _xname_ _node_id_++
 *add* -f _file_ [--payload-format _format_]++
-*add* -f _-_ [--payload-format _format_]
+*add* -f _-_ [--payload-format _format_]++
+*add* --template _template_ --var-file _file_ [--payload-format _format_]
 	Add one or more new components to SMD. If a component already exists with
 	the same xname, this command will fail.
 
 	In the first form of the command, an _xname_ and _node_id_ is required to
-	identify the component to add. One or more of *--arch*, *--enabled*,
-	*--role*, or *--state* can optionally be specified to specify details of the
-	component.
+	identify the component to add. One or more of *--arch*, *--class*,
+	*--enabled*, *--flag*, *--net-type*, *--role*, *--state*, *--subrole*,
+	*--subtype*, or *--type* can optionally be specified to specify details of
+	the component. Any other field can be set with *--set*, which can also set
+	the _ID_ and _NID_ fields instead of passing _xname_ and _node_id_.
 
 	In the second form of the command, a file containing the payload data is
 	passed. This is convenient in cases of dealing with many components at once.
@@ -299,6 +323,10 @@ Subcommands for this command are as follows:
 	In the third form of the command, the payload data is read from standard
 	input.
 
+	In the fourth form of the command, a template of a single component is
+	rendered once for each row of a CSV file to add many similar components
+	without writing a payload file for them (see *TEMPLATES* in *ochami*(1)).
+
 	This command sends a POST request to SMD's /Components endpoint.
 
 	This command accepts the following options:
@@ -308,48 +336,142 @@ Subcommands for this command are as follows:
 
 		Default: *X86*
 
+	*--class* _class_
+		Specify the hardware class of the component, e.g. _River_, _Mountain_,
+		or _Hill_.
+
 	*--enabled*
 		Specify if component is shows up as enabled in SMD.
 
 		Default: *true*
 
+	*--flag* _flag_
+		Specify the state flag of the component, e.g. _OK_, _Warning_, or
+		_Alert_.
+
+	*--net-type* _type_
+		Specify the network type of the component, e.g. _Sling_, _Infiniband_,
+		or _Ethernet_.
+
 	*-f, --payload* _file_
 		Specify a file containing the data to send to SMD. The format of this
 		file depends on _--payload-format_ and is _json_ by default. If *-* is
 		used as the argument to _-f_, the command reads the payload data from
-		standard input.
+		standard input. If an _http://_ or _https://_ URL is used, the payload
+		data is fetched from it (see *PAYLOADS* in *ochami*(1)).
 
 	*--payload-format* _format_
 		Format of the file used with _-f_. If unspecified, the payload format is
 		_json_ by default. Supported formats are: _yaml_.
 
+	*--payload-insecure*
+		Do not verify the TLS certificate of the server when the argument to
+		_-f_ is an _https://_ URL.
+
 	*--role* _role_
 		Specify the SMD role for the new component.
 
 		Default: *Compute*
 
+	*--set* _field_=_value_
+		Set _field_ of the new component (see the *Component* data structure
+		above) to _value_, overriding the other flags. Can be passed more than
+		once. See *PAYLOADS* in *ochami*(1).
+
 	*--state* _state_
 		Specify the initial state of the new component.
 
 		Default: *Ready*
 
-*delete* --all++
-*delete* _xname_...++
+	*--subrole* _subrole_
+		Specify the SMD subrole for the new component.
+
+	*--subtype* _subtype_
+		Specify the subtype of the new component.
+
+	*--template* _template_
+		Template of a single component, in the format given by
+		*--payload-format*, to render once for each row of the file passed with
+		*--var-file*. If _template_ starts with *@*, the template is read from
+		the file whose path follows it.
+
+	*--type* _type_
+		Specify the HMS type of the new component, e.g. _Node_ or _NodeBMC_.
+
+	*--var-file* _file_
+		CSV file whose header row names the variables of the template passed
+		with *--template* and whose other rows are each rendered with it. If
+		_file_ is *-*, it is read from standard input.
+
+*assign-nids* [--group _group_]... [--start _nid_] [--strategy _strategy_] [--dry-run] [--output-format _format_]
+	Assign NIDs to the components that lack them among the members of the
+	groups passed with *--group* or, if none are passed, among all components
+	of type _Node_. Components that already have a NID keep it. NIDs are
+	assigned in the natural order of the xnames (e.g. _x1000c1s2b0n0_ before
+	_x1000c1s10b0n0_) and set with a single PATCH to SMD's BulkNID endpoint.
+
+	The NIDs of all components in SMD are fetched first and are never assigned
+	again, so that no two components end up with the same NID. A warning is
+	printed for NIDs that several components already share, and group members
+	that are not components are skipped with a warning.
+
+	The assigned NIDs are printed as a table of xnames and NIDs, or, if
+	*--output-format* is passed, as a list of objects with the keys _ID_ and
+	_NID_ in that format.
+
+	This command accepts the following options:
+
+	*--dry-run*
+		Print the NIDs that would be assigned without assigning them.
+
+	*-F, --output-format* _format_
+		Print the assigned NIDs in _format_ instead of as a table.
+
+	*-g, --group* _group_
+		Assign NIDs to the members of _group_. This option can be passed more
+		than once or with a comma-separated list of groups.
+
+	*--start* _nid_
+		The lowest NID to assign.
+
+		Default: *1*
+
+	*--strategy* _strategy_
+		How to choose the NIDs to assign.
+
+		Default: *sequential-by-xname*
+		Supported:
+		- _sequential-by-xname_ - The lowest free NIDs, filling gaps between
+		  the NIDs in use.
+		- _contiguous-by-xname_ - The lowest block of consecutive free NIDs,
+		  so that the components get a range of NIDs without gaps.
+
+*delete* --all [--expect-count _n_]++
+*delete* --where _filter_++
+*delete* [--nids _nid_list_] [_xname_...]++
 *delete* -f _file_ [--payload-format _format_]++
 *delete* -f _-_ [--payload-format _format_]
 	Delete one or more components in SMD. Unless *--force* is passed, the user
 	is asked to confirm deletion.
 
 	In the first form of the command, all components are deleted. *BE CAREFUL!*
+	They are counted first and the number that will be deleted is shown, e.g.
+	_This will delete 4,213 components on cluster foobar_, before the user is
+	asked to confirm. If the count changes while the user is confirming, nothing
+	is deleted.
 
-	In the second form of the command, one or more xnames identifying the
-	component(s) to delete is/are specified.
+	In the second form of the command, the components matching _filter_ are
+	looked up and listed before being deleted. See *--where* below.
 
-	In the third form of the command, a file containing the payload data (see
+	In the third form of the command, one or more xnames identifying the
+	component(s) to delete is/are specified, either as arguments, as NIDs with
+	*--nids*, or both.
+
+	In the fourth form of the command, a file containing the payload data (see
 	the *Component* data structure above) is passed. This is convenient in cases
 	of dealing with many components at once.
 
-	In the fourth form of the command, the payload is read from standard input.
+	In the fifth form of the command, the payload is read from standard input.
 
 	This command sends one or more DELETE requests to SMD's /Components
 	endpoint.
@@ -359,6 +481,11 @@ Subcommands for this command are as follows:
 	*-a, --all*
 		Delete *all* components in SMD. *BE CAREFUL!*
 
+	*--expect-count* _n_
+		With *--all*, fail without deleting anything unless there are exactly
+		_n_ components to delete. This guards scripts against deleting more
+		than expected, e.g. because they run against the wrong cluster.
+
 	*--force*
 		Do not ask the user to confirm deletion. Use with caution.
 
@@ -366,14 +493,32 @@ Subcommands for this command are as follows:
 		Specify a file containing the data to send to SMD. The format of this
 		file depends on _--payload-format_ and is _json_ by default. If *-* is
 		used as the argument to _-f_, the command reads the payload data from
-		standard input.
+		standard input. If an _http://_ or _https://_ URL is used, the payload
+		data is fetched from it (see *PAYLOADS* in *ochami*(1)).
+
+	*--nids* _nid_list_
+		Delete the components with the node IDs in _nid_list_, a
+		comma-separated list of NIDs and inclusive NID ranges, e.g.
+		_1-64,100,200-203_. The NIDs are resolved to xnames using SMD's
+		/State/Components/ByNID/Query endpoint. It is an error for any NID not
+		to belong to a component.
 
 	*--payload-format* _format_
 		Format of the file used with _-f_. If unspecified, the payload format is
 		_json_ by default. Supported formats are: _yaml_.
 
-*get* [--output-format _format_] [--nid _nid_] [--xname _xname_]
-	Get all components or one identified by xname or node ID.
+	*--payload-insecure*
+		Do not verify the TLS certificate of the server when the argument to
+		_-f_ is an _https://_ URL.
+
+	*--where* _filter_
+		Delete the components matching _filter_, which is in query string form
+		and uses the query parameters of SMD's /State/Components endpoint, e.g.
+		_state=Empty&type=Node_. An empty filter is rejected; use *--all* to
+		delete all components.
+
+*get* [--output-format _format_] [--nid _nid_ | --nids _nid_list_ | --xname _xname_] [--with-groups] [--live [--bmc-username _user_ --bmc-password-file _file_] [--bmc-insecure]] [--limit _n_] [--sort _field_[:_order_]]
+	Get all components or those identified by xname or node ID(s).
 
 	If no filter flags are passed, all components are returned. Otherwise, the
 	component specified by the passed filter flag(s) is returned.
@@ -382,29 +527,342 @@ Subcommands for this command are as follows:
 
 	This command accepts the following options:
 
+	*--bmc-insecure*
+		Do not verify the TLS certificates of BMCs queried with *--live*.
+		This is separate from *--insecure* so that BMCs with self-signed
+		certificates can be queried without also trusting any certificate
+		presented for SMD.
+
+	*--bmc-password-file* _file_
+		Read the password for BMCs queried with *--live* from _file_. If not
+		passed, *discover.bmc-password* in the cluster's config is used. See
+		*ochami-config*(5).
+
+	*--bmc-username* _user_
+		Username for BMCs queried with *--live*. If not passed,
+		*discover.bmc-username* in the cluster's config is used.
+
+	*--limit* _n_
+		Print at most _n_ items. See *LISTS* in *ochami*(1).
+
+	*--live*
+		For each returned component that has a *ComponentEndpoint* for a
+		Redfish ComputerSystem, query its BMC and add what was read as a
+		_Live_ object with the fields _PowerState_, _Health_, _State_,
+		_Source_ (the Redfish URL queried), and _FetchedAt_. All other fields
+		are the values cached in SMD. If a BMC cannot be queried, _Live_
+		holds an _Error_ instead and a warning is logged. Components without
+		a *ComponentEndpoint* get no _Live_ object.
+
+		The *ComponentEndpoints* are fetched from SMD with a single request
+		and the BMCs are queried in parallel. The Redfish scheme and port
+		are taken from *discover.redfish-scheme* and *discover.redfish-port*
+		in the cluster's config, defaulting to HTTPS. This requires a token.
+
 	*-f, --output-format* _format_
 		Output response data in specified _format_. Supported values are:
 
 		- _json_ (default)
 		- _yaml_
+		- _toml_
+		- _table_ (see *TABLES* in *ochami*(1))
 
 	*-n, --nid* _nid_,...
 		One or more node IDs to filter results by. For multiple NIDs, either
 		this flag can be specified multiple times or this flag can be specified
 		once and multiple NIDs can be specified, separated by commas.
 
+	*--nids* _nid_list_
+		Get the components with the node IDs in _nid_list_, a comma-separated
+		list of NIDs and inclusive NID ranges, e.g. _1-64,100,200-203_. Large
+		lists are queried in batches using SMD's /State/Components/ByNID/Query
+		endpoint. NIDs not belonging to any component are omitted.
+
+	*--sort* _field_[:_order_]
+		Sort items by _field_ in _order_ (_asc_, the default, or _desc_). See
+		*LISTS* in *ochami*(1).
+
+	*--with-groups*
+		Add the labels of the groups each component is a member of as a
+		_Groups_ list and its partition, if any, as _Partition_. The
+		memberships of all returned components are fetched with a single
+		request to SMD's /memberships endpoint and joined with the
+		components locally. This requires a token.
+
 	*-x, --xname* _xname_,...
 		One or more xnames to filter results by. For multiple xnames, either
 		this flag can be specified multiple times or this flag can be specified
 		once and multiple xnames, separated by commas.
 
+*rename* [--dry-run] _old_xname_ _new_xname_++
+*rename* [--dry-run] --map _file_
+	Change the xname of one or more components. Since SMD does not allow
+	changing the ID of a component, each rename creates the new component as a
+	copy of the old one, adds it to the old component's groups, points the old
+	component's ethernet interfaces at it, moves the old component's BSS boot
+	parameters to it, removes the old component from its groups, deletes the
+	old component, and finally sets the old component's NID on the new one.
+
+	In the first form of the command, a single component is renamed.
+
+	In the second form of the command, the renames are read from _file_ (see
+	*--map*).
+
+	The new xnames must not exist yet, and a component cannot be both renamed
+	and the target of a rename. Each rename is done on its own. If a step
+	fails, the completed steps of that rename are undone in reverse order and
+	the remaining renames are still attempted. Partition memberships are not
+	migrated; a warning is printed for components that are in a partition.
+
+	This command sends requests to SMD's /Components, /groups, and
+	/Inventory/EthernetInterfaces endpoints and to BSS's /bootparameters
+	endpoint.
+
+	This command accepts the following options:
+
+	*--dry-run*
+		Print the steps of each rename without performing them.
+
+	*--map* _file_
+		Read the renames from _file_, a CSV file with one _old_xname_,
+		_new_xname_ pair per line. Lines starting with *#* and a leading
+		_old,new_ header line are ignored. If *-* is passed, the file is read
+		from standard input. A leading *@* in _file_ is ignored.
+
+*set-state* [--flag _flag_] [--force] _state_ _xname_...++
+*set-state* [--flag _flag_] [--force] _state_ --nids _nid_list_
+	Set the state, and optionally the state flag, of one or more components
+	with a single request.
+
+	SMD only allows some state changes without forcing them. Unless *--force*
+	is passed, the current state of each component is fetched first, and
+	components that cannot be moved to _state_ are reported along with the
+	states they can be moved to and are not changed. The other components are
+	still changed. A component can be moved to:
+
+	- _Unknown_ from any state
+	- _Empty_ or _Populated_ from _Unknown_, _Empty_, _Populated_, or _Off_
+	- _Off_ or _On_ from any state but _Empty_
+	- _Standby_ from _On_, _Standby_, or _Ready_
+	- _Halt_ from _On_, _Halt_, or _Ready_
+	- _Ready_ from _On_, _Standby_, _Halt_, or _Ready_
+
+	States other than these are not checked.
+
+	This command sends a PATCH request to SMD's
+	/State/Components/BulkStateData endpoint.
+
+	This command accepts the following options:
+
+	*--flag* _flag_
+		Also set the state flag of the components, e.g. _OK_, _Warning_, or
+		_Alert_.
+
+	*--force*
+		Do not check whether the components can be moved to _state_, and have
+		SMD change their state regardless.
+
+	*--nids* _nid_list_
+		Change the components with the node IDs in _nid_list_, a
+		comma-separated list of NIDs and inclusive NID ranges, e.g.
+		_1-64,100,200-203_.
+
+*update* [--arch _arch_] [--class _class_] [--enabled] [--flag _flag_] [--force] [--net-type _type_] [--role _role_] [--set _field_=_value_]... [--software-status _status_] [--state _state_] [--subrole _subrole_] [--subtype _subtype_] [--type _type_] [--if-match _etag_] _xname_ | --nids _nid_list_++
+*update* [--if-match _etag_] -f _file_ [--payload-format _format_]++
+*update* [--if-match _etag_] -f _-_ [--payload-format _format_]++
+*update* [--if-match _etag_] --patch-file _file_ [--patch-type _type_] [--payload-format _format_] _xname_ | --nids _nid_list_
+	Update one or more existing components in SMD.
+
+	In the first form of the command, the component identified by _xname_ is
+	fetched from SMD, the fields corresponding to the passed flags are changed,
+	and the result is sent back. Fields that are not specified are preserved.
+	Instead of _xname_, *--nids* can be passed to apply the same changes to
+	each component with the NIDs in _nid_list_.
+	At least one of the flags must be passed. The flags have the same meaning as
+	for *add*, with the addition of *--software-status*, which sets the software
+	status of the component. The *--set* flag sets any other field (see
+	*PAYLOADS* in *ochami*(1)). If it changes the _State_ field, the new state
+	is checked like with *--state*.
+
+	In the second form of the command, a file containing the payload data (see
+	the *Component* data structure above) is passed. Each component in the
+	payload replaces the existing one with the same ID.
+
+	In the third form of the command, the payload data is read from standard
+	input.
+
+	In the fourth form of the command, the patch document in _file_ is sent as
+	is to the component identified by _xname_, or to each component with the
+	NIDs in _nid_list_, without fetching the component first. This sends one
+	PATCH request per component instead of a PUT, and states are not checked.
+
+	Updates are sent with SMD's _Force_ option, so SMD does not check state
+	changes itself. Instead, in the first form of the command, if *--state* is
+	passed, each component's current state is checked against the new one (see
+	*set-state*) and nothing is updated if any component cannot be moved to it.
+
+	This command sends one PUT request per component to SMD's /Components
+	endpoint.
+
+	This command accepts the following options:
+
+	*--force*
+		Do not check whether the components can be moved to the state passed
+		with *--state*.
+
+	*--if-match* _etag_|_auto_
+		Only update a component if it has not been modified since it was read.
+		The PUT is sent with an If-Match header and fails with a "precondition
+		failed" error if the component's ETag no longer matches. If _auto_ is
+		passed, the ETag is taken from the response when fetching the component
+		(first form) or from a GET right before each PUT (payload forms).
+		Otherwise, _etag_ is used as is. If SMD does not return ETags, a warning
+		is printed and the update is performed unconditionally.
+
+	*--nids* _nid_list_
+		Update the components with the node IDs in _nid_list_, a
+		comma-separated list of NIDs and inclusive NID ranges, e.g.
+		_1-64,100,200-203_. It is an error for any NID not to belong to a
+		component.
+
+	*--patch-file* _file_
+		Send the patch document in _file_ to each component instead of a whole
+		component. The file is read like the file passed to *-f*, so it can be
+		*-* or a URL and its format depends on *--payload-format*. The patch is
+		checked to be well-formed before anything is sent.
+
+	*--patch-type* _type_
+		Type of the patch document passed with *--patch-file*, which sets the
+		Content-Type of the requests. Supported types are:
+
+		- _merge-patch_ - A JSON Merge Patch (RFC 7386), i.e. an object whose
+		members replace those of the component. Sent as
+		_application/merge-patch+json_.
+		- _json-patch_ - A JSON Patch (RFC 6902), i.e. a list of operations
+		such as _{"op": "replace", "path": "/Role", "value": "Compute"}_. Sent
+		as _application/json-patch+json_.
+
+		Default: _merge-patch_
+
+	*-f, --payload* _file_
+		Specify a file containing the data to send to SMD. The format of this
+		file depends on _--payload-format_ and is _json_ by default. If *-* is
+		used as the argument to _-f_, the command reads the payload data from
+		standard input. If an _http://_ or _https://_ URL is used, the payload
+		data is fetched from it (see *PAYLOADS* in *ochami*(1)).
+
+	*--payload-format* _format_
+		Format of the file used with _-f_. If unspecified, the payload format is
+		_json_ by default. Supported formats are: _yaml_.
+
+	*--payload-insecure*
+		Do not verify the TLS certificate of the server when the argument to
+		_-f_ or *--patch-file* is an _https://_ URL.
+
+## fru
+
+Track field-replaceable units (FRUs). SMD keeps hardware inventory both by
+location (the xname where a part is installed, see *hwinv*) and by FRU ID
+(which identifies the part itself wherever it is installed). These commands
+use the latter, e.g. to find out where a DIMM or board is now and where it has
+been.
+
+Subcommands for this command are as follows:
+
+*get* [--output-format _format_] [--fru-id _fru_id_,...] [--type _type_] [--manufacturer _manufacturer_] [--part-number _part_number_] [--serial-number _serial_number_] [--limit _n_] [--sort _field_[:_order_]]
+	Get the FRUs known to SMD, whether or not they are installed, optionally
+	filtered by the options below.
+
+	This command sends a GET to SMD's /Inventory/HardwareByFRU endpoint.
+
+	This command accepts the following options:
+
+	*--fru-id* _fru_id_,...
+		Only get FRUs with these FRU IDs. For multiple FRU IDs, either this
+		flag can be specified multiple times or this flag can be specified
+		once and multiple FRU IDs can be specified, separated by commas.
+
+	*--manufacturer* _manufacturer_
+		Only get FRUs made by _manufacturer_.
+
+	*-F, --output-format* _format_
+		Output response data in specified _format_. Supported values are:
+
+		- _json_ (default)
+		- _yaml_
+		- _toml_
+
+	*--part-number* _part_number_
+		Only get FRUs with part number _part_number_.
+
+	*--serial-number* _serial_number_
+		Only get FRUs with serial number _serial_number_.
+
+	*--type* _type_
+		Only get FRUs of hardware type _type_ (e.g. _Memory_, _Processor_,
+		_NodeEnclosure_).
+
+*history* [--output-format _format_] [--fru-id _fru_id_,...] [--event-type _type_] [--since _time_] [--until _time_]
+	Get the hardware inventory history by FRU, i.e. the events (e.g.
+	_Added_, _Removed_, _Scanned_) recorded for each FRU, grouped by FRU. The
+	ID of each event is the location the FRU was at, so the history of a FRU
+	shows where it moved. *--since* and *--until* are sent to SMD as the
+	_starttime_ and _endtime_ query parameters.
+
+	This command sends a GET to SMD's /Inventory/HardwareByFRU/History
+	endpoint.
+
+	This command accepts the following options:
+
+	*--event-type* _type_
+		Only get events of _type_ (e.g. _Added_, _Removed_, _Scanned_,
+		_DetectedChange_).
+
+	*--fru-id* _fru_id_,...
+		One or more FRU IDs to get the history of. For multiple FRU IDs,
+		either this flag can be specified multiple times or this flag can be
+		specified once and multiple FRU IDs can be specified, separated by
+		commas.
+
+	*-F, --output-format* _format_
+		Output response data in specified _format_. Supported values are:
+
+		- _json_ (default)
+		- _yaml_
+		- _toml_
+
+	*--since* _time_
+		Only get events at or after _time_. See *TIMES* in *ochami*(1).
+
+	*--until* _time_
+		Only get events at or before _time_. See *TIMES* in *ochami*(1).
+
+*locate* [--output-format _format_] _fru_id_...
+	Show where FRUs are installed and where they have been. For each
+	_fru_id_, the locations (xnames) in SMD's hardware inventory that the FRU
+	populates now are shown, followed by its history, oldest first. The FRUs
+	are printed as text unless *--output-format* is passed.
+
+	This command sends GETs to SMD's /Inventory/Hardware and
+	/Inventory/HardwareByFRU/History endpoints for each FRU.
+
+	This command accepts the following options:
+
+	*-F, --output-format* _format_
+		Output the FRUs in the specified _format_ instead of as text.
+		Supported values are:
+
+		- _json_ (default)
+		- _yaml_
+		- _toml_
+
 ## group
 
 Manage SMD groups. For managing group membership, see *group member* below.
 
 Subcommands for this command are as follows:
 
-*add* [--description _desc_] [--tag _tag_,...] [--member _xname_,...] [--exclusive-group _group_] _group_name_++
+*add* [--description _desc_] [--tag _tag_,...] [--member _xname_,...] [--exclusive-group _group_] [--set _field_=_value_]... _group_name_++
 *add* -f _file_ [--payload-format _format_]++
 *add* -f _-_ [--payload-format _format_]
 	Add a new group to SMD, optionally specifying members to add to the group.
@@ -414,7 +872,9 @@ Subcommands for this command are as follows:
 	*--description*. One or more components can be added to the new group by
 	passing *--member* and one or more tags can be assigned to the group by
 	passing *--tag*. Finally, the group can be set to be mutually exclusive with
-	another group by passing *--exclusive-group*.
+	another group by passing *--exclusive-group*. Any field of the group can
+	also be set with *--set*, including _label_ instead of passing
+	_group_name_.
 
 	In the second form of the command, a file containing the payload data is
 	passed. This is convenient in cases of dealing with many groups at once.
@@ -446,12 +906,23 @@ Subcommands for this command are as follows:
 		Specify a file containing the data to send to SMD. The format of this
 		file depends on _--payload-format_ and is _json_ by default. If *-* is
 		used as the argument to _-f_, the command reads the payload data from
-		standard input.
+		standard input. If an _http://_ or _https://_ URL is used, the payload
+		data is fetched from it (see *PAYLOADS* in *ochami*(1)).
 
 	*--payload-format* _format_
 		Format of the file used with _-f_. If unspecified, the payload format is
 		_json_ by default. Supported formats are: _yaml_.
 
+	*--payload-insecure*
+		Do not verify the TLS certificate of the server when the argument to
+		_-f_ is an _https://_ URL.
+
+	*--set* _field_=_value_
+		Set _field_ of the new group (see the *Group* data structure above) to
+		_value_, e.g. _members.ids=[x3000c1s7b0n1, x3000c1s7b1n1]_, overriding
+		the other flags. Can be passed more than once. See *PAYLOADS* in
+		*ochami*(1).
+
 	*--tag* _tag_,...
 		One or more tags to assign to the group. For multiple tags, either this
 		flag can be specified multiple times or this flag can be specified once
@@ -483,13 +954,18 @@ Subcommands for this command are as follows:
 		Specify a file containing the data to send to SMD. The format of this
 		file depends on _--payload-format_ and is _json_ by default. If *-* is
 		used as the argument to _-f_, the command reads the payload data from
-		standard input.
+		standard input. If an _http://_ or _https://_ URL is used, the payload
+		data is fetched from it (see *PAYLOADS* in *ochami*(1)).
 
 	*--payload-format* _format_
 		Format of the file used with _-f_. If unspecified, the payload format is
 		_json_ by default. Supported formats are: _yaml_.
 
-*get* [--output-format _format_] [--name _name_,...] [--tag _tag_,...]
+	*--payload-insecure*
+		Do not verify the TLS certificate of the server when the argument to
+		_-f_ is an _https://_ URL.
+
+*get* [--output-format _format_] [--name _name_,...] [--tag _tag_,...] [--limit _n_] [--sort _field_[:_order_]]
 	Get group information for all groups in SMD or for a subset, specified by
 	filters.
 
@@ -497,11 +973,16 @@ Subcommands for this command are as follows:
 
 	This command accepts the following options:
 
+	*--limit* _n_
+		Print at most _n_ items. See *LISTS* in *ochami*(1).
+
 	*-f, --output-format* _format_
 		Output response data in specified _format_. Supported values are:
 
 		- _json_ (default)
 		- _yaml_
+		- _toml_
+		- _table_ (see *TABLES* in *ochami*(1))
 
 	*--name* _group_name_,...
 		One or more group names to filter groups by. For multiple groups names,
@@ -509,13 +990,106 @@ Subcommands for this command are as follows:
 		specified once and multiple group names can be specified, separated by
 		commas.
 
+	*--sort* _field_[:_order_]
+		Sort items by _field_ in _order_ (_asc_, the default, or _desc_). See
+		*LISTS* in *ochami*(1).
+
 	*--tag* _tag_,...
 		One or more tags to filter groups by. For multiple tags, either this
 		flag can be specified multiple times or this flag can be specified once
 		and multiple tags can be specified, separated by commas.
 
-*update* [--description _description_] [--tag _tag_,...] _group_name_++
-*update* -f _file_ [--payload-format _format_]
+*reconcile* [--dry-run] -f _file_ [--payload-format _format_]
+	Sync groups with declarative definitions. _file_ maps group names to
+	selector expressions over component fields, and the members of each group
+	are set to the components in SMD matching its selector. Groups that do not
+	exist are created. Groups that are not in _file_ are left alone. This
+	allows groups to be managed from a file kept in version control.
+
+	A definition is either a selector or an object with the selector as
+	_selector_ and, optionally, _description_, _tags_, and _exclusiveGroup_,
+	which are set on the group as well. _exclusiveGroup_ is only set when the
+	group is created. For example:
+
+```
+groups:
+  compute: type=Node,role=Compute
+  gpu:
+    selector: type=Node,role=Compute,subrole in (GPU,Visualization)
+    description: GPU nodes
+    tags: [accel]
+```
+
+	A selector is a comma-separated list of requirements, all of which a
+	component must meet to be selected:
+
+[[ *Requirement*
+:[ *Selects components whose field...*
+|  _field_=_value_
+:  equals _value_ (also _field_==_value_)
+|  _field_!=_value_
+:  does not equal _value_ or is absent
+|  _field_ in (_value_,...)
+:  equals one of the values
+|  _field_ notin (_value_,...)
+:  equals none of the values or is absent
+|  _field_
+:  is set, i.e. not empty, zero, or false
+|  !_field_
+:  is not set
+
+	Field names and values are case-insensitive, and values may contain the
+	wildcards _\*_, _?_, and _[...]_ (e.g. _id=x3000c1s\*_).
+
+	Members are removed from all groups before any are added so that
+	components can move between groups of the same exclusive group. A line is
+	printed for each group describing its change, if any. If the changes to
+	some groups fail, the command exits with status 3 (see *EXIT STATUS* in
+	*ochami*(1)).
+
+	This command sends a GET to SMD's /Components and /groups endpoints, then
+	requests to the /groups endpoint for each group to change.
+
+	This command accepts the following options:
+
+	*--dry-run*
+		Print the changes that would be made without making them.
+
+	*-f, --payload* _file_
+		Specify a file containing the group definitions. The format of this
+		file depends on _--payload-format_ and is _json_ by default. If *-* is
+		used as the argument to _-f_, the command reads the definitions from
+		standard input. If an _http://_ or _https://_ URL is used, the
+		definitions are fetched from it (see *PAYLOADS* in *ochami*(1)).
+
+	*--payload-format* _format_
+		Format of the file used with _-f_. If unspecified, the payload format is
+		_json_ by default. Supported formats are: _yaml_.
+
+	*--payload-insecure*
+		Do not verify the TLS certificate of the server when the argument to
+		_-f_ is an _https://_ URL.
+
+*rename* [--dry-run] _old_group_name_ _new_group_name_
+	Change the name of a group, keeping its description, tags, exclusive group,
+	and members. Since SMD does not allow changing the name of a group, the new
+	group is created as a copy of the old one, the old group's members are put
+	in it, and the old group is deleted. If the group is part of an exclusive
+	group, the old group is deleted before the members are put in the new one.
+
+	The new group must not exist yet. If a step fails, the completed steps are
+	undone in reverse order.
+
+	This command sends requests to SMD's /groups endpoint.
+
+	This command accepts the following options:
+
+	*--dry-run*
+		Print the steps of the rename without performing them.
+
+*update* [--description _description_] [--tag _tag_,...] [--if-match _etag_] _group_name_++
+*update* [--if-match _etag_] -f _file_ [--payload-format _format_]++
+*update* [--if-match _etag_] --patch-file _file_ [--patch-type _type_] [--payload-format _format_] _group_name_...
 	Update one or more existing groups in SMD. If the group does not already
 	exist, this command will fail.
 
@@ -528,6 +1102,10 @@ Subcommands for this command are as follows:
 	In the third form of the command, the payload data is read from standard
 	input.
 
+	In the fourth form of the command, the patch document in _file_ is sent as
+	is to each group passed. This allows changing fields other than the
+	description and tags.
+
 	This command sends a PATCH  request to SMD's /groups endpoint.
 
 	This command accepts the following options:
@@ -535,16 +1113,37 @@ Subcommands for this command are as follows:
 	*-d, --description* _description_
 		Specify a brief description of the group.
 
+	*--if-match* _etag_|_auto_
+		Only update a group if it has not been modified since it was read. The
+		PATCH is sent with an If-Match header containing _etag_ or, if _auto_
+		is passed, the ETag returned by a GET of the group right before the
+		PATCH. See *--if-match* for *component update*.
+
+	*--patch-file* _file_
+		Send the patch document in _file_ to each group. See *--patch-file* for
+		*component update*.
+
+	*--patch-type* _type_
+		Type of the patch document passed with *--patch-file*. See
+		*--patch-type* for *component update*.
+
+		Default: _merge-patch_
+
 	*-f, --payload* _file_
 		Specify a file containing the data to send to SMD. The format of this
 		file depends on _--payload-format_ and is _json_ by default. If *-* is
 		used as the argument to _-f_, the command reads the payload data from
-		standard input.
+		standard input. If an _http://_ or _https://_ URL is used, the payload
+		data is fetched from it (see *PAYLOADS* in *ochami*(1)).
 
 	*--payload-format* _format_
 		Format of the file used with _-f_. If unspecified, the payload format is
 		_json_ by default. Supported formats are: _yaml_.
 
+	*--payload-insecure*
+		Do not verify the TLS certificate of the server when the argument to
+		_-f_ is an _https://_ URL.
+
 	*--tag* _tag_,...
 		One or more tags to assign to the group. For multiple tags, either this
 		flag can be specified multiple times or this flag can be specified once
@@ -558,19 +1157,27 @@ Manage SMD group membership. For general group management, see *group*.
 
 Subcommands for this command are as follows:
 
-*add* _group_name_ _xname_...
-	Add one or more components to an existing SMD group.
+*add* [--nids _nid_list_] _group_name_ [_xname_...]
+	Add one or more components to an existing SMD group. Components can be
+	specified as xnames, as NIDs with *--nids*, or both. _nid_list_ is a
+	comma-separated list of NIDs and inclusive NID ranges, e.g. _1-64,100_.
+
+	If the group has an exclusive group (see *--exclusive-group* under *group
+	add*) and a component is already a member of another group with the same
+	exclusive group, SMD would reject the addition. In this case, nothing is
+	added and the *move* command to use instead is printed.
 
 	This command sends one or more POST requests to the members subendpoint
 	under SMD's /groups endpoint.
 
-*delete* _group_name_ _xname_...
-	Delete one or more components from an existing SMD group.
+*delete* [--force] [--nids _nid_list_] _group_name_ [_xname_...]
+	Delete one or more components from an existing SMD group. Components can
+	be specified as xnames, as NIDs with *--nids*, or both, as for *add*.
 
 	This command sends one or more DELETE requests to the members subendpoint
 	under SMD's /groups endpoint.
 
-*get* [--output-format _format_] _group_name_
+*get* [--output-format _format_ | --stream] _group_name_ [--limit _n_] [--sort _field_[:_order_]]
 	Get members of an SMD group.
 
 	This command sends a GET request to the members subendpoint under SMD's
@@ -578,18 +1185,296 @@ Subcommands for this command are as follows:
 
 	This command accepts the following options:
 
+	*--limit* _n_
+		Print at most _n_ items. See *LISTS* in *ochami*(1).
+
 	*-f, --output-format* _format_
 		Output response data in specified _format_. Supported values are:
 
 		- _json_ (default)
 		- _yaml_
+		- _toml_
+
+	*--sort* _field_[:_order_]
+		Sort items by _field_ in _order_ (_asc_, the default, or _desc_). See
+		*LISTS* in *ochami*(1).
+
+	*--stream*
+		Print only the member IDs, one per line, as they are read instead of
+		formatting the whole response first. This is faster for groups with
+		many members and convenient for piping into other commands.
+		*--output-format*, *--limit*, and *--sort* do not apply.
+
+*move* _from_group_name_ _to_group_name_ _xname_...
+	Move one or more components from _from_group_name_ to _to_group_name_. This
+	is how components are moved between groups that share an exclusive group,
+	since a component cannot be a member of both at once.
+
+	Each component is moved separately. If both groups share an exclusive
+	group, the component is removed from _from_group_name_ and then added to
+	_to_group_name_; if adding it fails, it is added back to _from_group_name_.
+	Otherwise, the component is added to _to_group_name_ first and removed from
+	it again if removing it from _from_group_name_ fails. It is an error if a
+	component is not in _from_group_name_ or is already in _to_group_name_.
+
+	This command sends GET, POST, and DELETE requests to SMD's /groups
+	endpoint.
 
-*set* _group_name_ _xname_...
+*set* [--batch-size _n_] _group_name_ _xname_...
 	Set the membership list of _group_name_ to _xname_.... Xnames specified that
 	are not already in the group are added to it, xnames specified that are
 	already in the group remain in the group, and xnames not specified that are
 	already in the group are removed from the group.
 
+	This command sends a PUT request to the members subendpoint under SMD's
+	/groups endpoint with the whole membership list. Since this request grows
+	with the group, at most _n_ (1000 by default) members are set this way. If
+	more are passed, the current members of the group are fetched with a GET
+	request instead, and only the changes are sent: a DELETE request for each
+	member to remove, followed by a POST request for each member to add.
+	Members that stay in the group are never removed from it in between. Pass
+	*--batch-size 0* to always send a single PUT request.
+
+## group tag
+
+Manage the tags of SMD groups without resubmitting the whole group. For
+general group management, see *group*.
+
+Subcommands for this command are as follows:
+
+*add* _group_name_ _tag_...
+	Add one or more tags to an existing SMD group. Tags that the group already
+	has are ignored.
+
+	The group is fetched and a PATCH request containing only its new tags is
+	sent to SMD's /groups endpoint, so its description and members are left
+	untouched. If SMD returns an ETag for the group, the PATCH is conditional
+	on the group not having been modified in the meantime, and fails
+	otherwise. No PATCH is sent if the tags do not change.
+
+*remove* _group_name_ _tag_...
+	Remove one or more tags from an existing SMD group. Tags that the group
+	does not have are ignored. Requests are sent as for *add*.
+
+## hwinv
+
+Manage hardware inventory.
+
+Subcommands for this command are as follows:
+
+*history* [--output-format _format_] [--xname _xname_,...] [--event-type _type_] [--since _time_] [--until _time_]
+	Get the hardware inventory history, i.e. the events (e.g. _Added_,
+	_Removed_, _Scanned_) recorded for the FRUs at each location, grouped by
+	location. *--since* and *--until* are sent to SMD as the _starttime_ and
+	_endtime_ query parameters.
+
+	This command sends a GET to SMD's /Inventory/Hardware/History endpoint.
+
+	This command accepts the following options:
+
+	*--event-type* _type_
+		Only get events of _type_ (e.g. _Added_, _Removed_, _Scanned_,
+		_DetectedChange_).
+
+	*-F, --output-format* _format_
+		Output response data in specified _format_. Supported values are:
+
+		- _json_ (default)
+		- _yaml_
+		- _toml_
+
+	*--since* _time_
+		Only get events at or after _time_. See *TIMES* in *ochami*(1).
+
+	*--until* _time_
+		Only get events at or before _time_. See *TIMES* in *ochami*(1).
+
+	*--xname* _xname_,...
+		One or more location xnames to get the history of. For multiple
+		xnames, either this flag can be specified multiple times or this flag
+		can be specified once and multiple xnames can be specified, separated
+		by commas.
+
+## iface
+
+Manage ethernet interfaces. *ethernet-interface* can be used as an alias of
+this command.
+
+Subcommands for this command are as follows:
+
+*audit* [--output-format _format_] [--comp-type _type_,...] [--exit-code]
+	Check ethernet interfaces for conflicts. All ethernet interfaces and
+	components are fetched from SMD's /Inventory/EthernetInterfaces and
+	/State/Components endpoints and the following are listed:
+
+	- MAC addresses claimed by more than one interface (compared regardless of
+	  case and separators)
+	- IP addresses claimed by more than one interface
+	- interfaces whose ComponentID is not a component in SMD
+	- components that no interface belongs to
+
+	Nothing is modified. The problems are printed as text unless
+	*--output-format* is passed.
+
+	This command accepts the following options:
+
+	*--comp-type* _type_,...
+		Only list components of the given types (e.g. _Node_,
+		case-insensitive) as having no interfaces. By default, components
+		of all types are listed.
+
+	*--exit-code*
+		Exit with status 2 if any problems are found.
+
+	*-F, --output-format* _format_
+		Output the problems in the specified _format_ instead of as text.
+		Supported values are:
+
+		- _json_ (default)
+		- _yaml_
+		- _toml_
+
+*export* [--output-format _format_]
+	Export all ethernet interfaces in SMD as a list that can be edited, kept
+	in version control, and imported again with *import*. Only the fields
+	that can be imported are written, and interfaces are sorted by MAC
+	address so that exports of the same interfaces are identical. Use
+	*--output-file* (see *ochami*(1)) to write the list to a file.
+
+	This command accepts the following options:
+
+	*-F, --output-format* _format_
+		Output the list in the specified _format_. Supported values are:
+
+		- _json_ (default)
+		- _yaml_
+		- _toml_
+
+*get* [--output-format _format_] [--id _id_ [--by-ip]] [--component-id _xname_,...] [--mac _mac_,...] [--ip _ip_,...] [--network _network_,...] [--type _type_,...] [--older-than _time_] [--newer-than _time_]
+	Get ethernet interfaces from SMD's /Inventory/EthernetInterfaces
+	endpoint. If no options are passed, all ethernet interfaces are
+	returned. Otherwise, only those matching all of the filter options
+	passed, and any of the values of each, are returned.
+
+	SMD filters on the values passed, except for glob patterns passed to
+	*--component-id* and CIDR prefixes passed to *--ip*, which SMD does not
+	support. Those are matched by *ochami* against the interfaces SMD returns
+	for the other options.
+
+	This command accepts the following options:
+
+	*--by-ip*
+		With *--id*, get the IP addresses of the ethernet interface.
+
+	*--component-id* _xname_,...
+		Only get interfaces belonging to these components. An _xname_ can
+		be a glob pattern, e.g. _x1000c1s7b\*n0_. *--comp-id* is a
+		deprecated alias.
+
+	*-F, --output-format* _format_
+		Output response data in specified _format_. Supported values are:
+
+		- _json_ (default)
+		- _yaml_
+		- _toml_
+		- _table_ (see *TABLES* in *ochami*(1))
+
+	*-i, --id* _id_
+		Get the ethernet interface with this ID.
+
+	*--ip* _ip_,...
+		Only get interfaces with one of these IP addresses, or with an IP
+		address in one of these CIDR prefixes, e.g. _10.1.0.0/16_.
+
+	*-m, --mac* _mac_,...
+		Only get interfaces with these MAC addresses. They can be written
+		with colons, dashes, dots, or no separators, in any case.
+
+	*--network* _network_,...
+		Only get interfaces with an IP address on one of these networks.
+		*--net* is a deprecated alias.
+
+	*--newer-than* _time_
+		Only get interfaces last updated after _time_ (see *TIMES* in
+		*ochami*(1)).
+
+	*--older-than* _time_
+		Only get interfaces last updated before _time_ (see *TIMES* in
+		*ochami*(1)).
+
+	*--type* _type_,...
+		Only get interfaces of components of these types, e.g. _Node_.
+
+*import* -f _file_ [--payload-format _format_] [--merge-strategy _strategy_]
+	Import ethernet interfaces from a list such as the one written by
+	*export*. Interfaces are matched with the ones in SMD by MAC address,
+	regardless of case and separators. Interfaces whose MAC addresses are not
+	in SMD are added with a POST, and existing interfaces that the import
+	changes are updated with a PATCH, according to *--merge-strategy*.
+	Existing interfaces that would not change are not sent, so importing the
+	same list twice changes nothing the second time.
+
+	This command accepts the following options:
+
+	*--merge-strategy* _strategy_
+		What to do with interfaces whose MAC addresses are already in SMD.
+		Supported values are:
+
+		- _replace_: replace the component ID, description, and IP addresses
+		  of the existing interface with the imported ones.
+		- _merge-ips_ (default): add the imported IP addresses that the
+		  existing interface does not have to it, keeping the ones it has,
+		  and replace its component ID and description with the imported
+		  ones unless those are empty.
+		- _skip-existing_: leave the existing interface as it is.
+
+	*-f, --payload* _file_
+		File or URL containing the list of interfaces. If - is passed, the
+		list is read from standard input.
+
+	*--payload-format* _format_
+		Format of the file passed to *-f*. Supported values are:
+
+		- _json_ (default)
+		- _yaml_
+		- _toml_
+
+	*--payload-insecure*
+		Do not verify the TLS certificate when *-f* is an https:// URL.
+
+## status
+
+Get SMD's status. This is useful for checking if SMD is running, if it can
+reach its database, and which values it accepts for component fields.
+
+The format of this command is:
+
+*status* [--output-format _format_] [--all | --detail]
+
+This command sends a GET to endpoints under SMD's /service endpoint.
+
+This command accepts the following options:
+
+*--all*
+	Print out the values SMD accepts for component fields as returned by
+	SMD's /service/values endpoint.
+
+*--detail*
+	Print out a table summarizing whether SMD is ready (SMD is only ready when
+	its database is reachable), SMD's version, and the values SMD accepts for
+	component states, flags, roles, subroles, architectures, classes, network
+	types, and types. The version is shown as _unknown_ if SMD does not report
+	it. If *--output-format* is passed, the summary is printed in that format
+	instead of as a table. The command exits with a non-zero status if SMD is
+	not ready.
+
+*-F, --output-format* _format_
+	Output response data in specified _format_. Supported values are:
+
+	- _json_ (default)
+	- _yaml_
+	- _toml_
+
 # AUTHOR
 
 Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
@@ -0,0 +1,112 @@
+OCHAMI-SNAPSHOT(1) "OpenCHAMI" "Manual Page for ochami-snapshot"
+
+# NAME
+
+ochami-snapshot - Store and compare local snapshots of service data
+
+# SYNOPSIS
+
+ochami snapshot create [OPTIONS]++
+ochami snapshot diff [OPTIONS] _snapshot_ [_snapshot_]++
+ochami snapshot list [OPTIONS]
+
+# DESCRIPTION
+
+The *snapshot* command stores timestamped copies of the data held by SMD and
+BSS on the local machine and compares them with each other or with the current
+data. This gives basic change tracking for clusters without audit services.
+Its commands only read data from services and never modify it.
+
+Snapshots are JSON files stored in a directory per cluster, named after the
+cluster being used or, if none is, after the host (and port) of the base URI.
+These directories are under _$XDG_DATA_HOME/ochami/snapshots_, or
+_~/.local/share/ochami/snapshots_ if *XDG_DATA_HOME* is not set.
+
+The following kinds of resources can be stored:
+
+- _components_ - SMD components, identified by xname.
+- _groups_ - SMD groups, identified by label.
+- _ethernet-interfaces_ - SMD ethernet interfaces, identified by ID.
+- _redfish-endpoints_ - SMD redfish endpoints, identified by xname.
+- _boot-params_ - BSS boot parameters, identified by the hosts, MAC addresses,
+or NIDs they apply to, e.g. _hosts=x1000c1s7b0n0_.
+
+Resources are stored as returned by the services, so fields that *ochami* does
+not know about are tracked as well.
+
+All commands accept the following option:
+
+*--dir* _directory_
+	Store and look for snapshots in _directory_ instead of the default
+	directory of the cluster.
+
+# COMMANDS
+
+*create* [--name _name_] [--resources _kind_,...]
+	Fetch resources from SMD and BSS, store them as a new snapshot, and print
+	the path of the snapshot file. An existing snapshot is never overwritten.
+	An access token is required.
+
+	This command accepts the following options:
+
+	*--name* _name_
+		Name of the snapshot, used as its file name.
+
+		Default: the time the snapshot was created, e.g. _20240102T150405Z_
+
+	*--resources* _kind_,...
+		Only store the resources of these kinds.
+
+		Default: all kinds
+
+*diff* [--exit-code] [-F _format_] _snapshot_ [_snapshot_]
+	Compare the first _snapshot_ with the second, or with the current data of
+	the kinds of resources in the first if only one is passed, which requires
+	an access token. A _snapshot_ is the name of a snapshot, _latest_ for the
+	most recently created one, or the path of a snapshot file. Only kinds of
+	resources in both snapshots are compared.
+
+	Resources that were added, removed, or modified are listed by kind,
+	marked with *+*, *-*, and *~* respectively, followed by the number of
+	each. For modified resources, each field that changed is listed with its
+	old and new value as JSON. Nested fields are separated by dots and list
+	items are identified by their index, e.g. _members.ids[1]_.
+
+	This command accepts the following options:
+
+	*--exit-code*
+		Exit with status 2 if there are any changes.
+
+	*-F, --output-format* _format_
+		Print the changes in _format_ instead of as text. Supported values
+		are:
+
+		- _json_
+		- _yaml_
+		- _toml_
+
+*list*
+	List the stored snapshots of the cluster, oldest first, with the time they
+	were created and the number of resources of each kind they hold.
+
+# EXAMPLES
+
+Record the state of the cluster before an upgrade and check what changed
+afterwards:
+
+```
+ochami snapshot create --name before-upgrade
+(upgrade)
+ochami snapshot diff before-upgrade
+```
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1), *ochami-bss*(1), *ochami-compare*(1), *ochami-smd*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
@@ -0,0 +1,117 @@
+OCHAMI-SUPPORT-BUNDLE(1) "OpenCHAMI" "Manual Page for ochami-support-bundle"
+
+# NAME
+
+ochami-support-bundle - Collect data for troubleshooting into a support bundle
+
+# SYNOPSIS
+
+ochami support-bundle create [--anonymize] [_file_]
+
+# DESCRIPTION
+
+The *support-bundle* command collects data about the cluster being used and
+the *ochami* CLI that is useful for troubleshooting into a single file, e.g. to
+attach to a report of a problem to the OpenCHAMI developers. Its commands only
+read data from services and never modify it.
+
+# COMMANDS
+
+*create* [--anonymize] [_file_]
+	Create a support bundle: a gzipped tarball holding a directory named
+	after the cluster and the time of creation (e.g.
+	_ochami-support-foobar-20240102T150405Z_) with the following files:
+
+[[ *File*
+:< *Contents*
+|  _manifest.json_
+:  When the bundle was created, the files in it, and any collection problems
+|  _version.json_
+:  Version of *ochami* and the platform it runs on
+|  _config.yaml_
+:  Merged configuration, with secrets redacted (see below)
+|  _smd/components.json_
+:  SMD components
+|  _smd/groups.json_
+:  SMD groups
+|  _smd/ethernet-interfaces.json_
+:  SMD ethernet interfaces
+|  _smd/redfish-endpoints.json_
+:  SMD redfish endpoints
+|  _smd/status.json_
+:  SMD status values
+|  _bss/boot-params.json_
+:  BSS boot parameters
+|  _bss/status.json_
+:  Status of BSS and its dependencies
+|  _cloud-init/configs.json_
+:  Configs in cloud-init
+|  _audit/boot.json_
+:  Findings of *ochami audit boot* on the collected data
+
+	Data that cannot be collected, e.g. because a service is down, is left
+	out of the bundle and the problem is recorded in the manifest and logged
+	as a warning, so that a bundle can be created for a cluster that is not
+	working. The boot audit is left out if the SMD or BSS data it needs is.
+
+	Secrets in the collected data, i.e. the values of fields whose names
+	contain e.g. _password_, _secret_, _token_, or _credential_, such as the
+	passwords of redfish endpoints, are always replaced with _REDACTED_, even
+	with *--log-secrets*.
+
+	In the configuration, age-encrypted secrets (see *ochami-config*(5)) and
+	passwords in the URLs of remote config files are replaced with
+	_REDACTED_. Environment variables and files that secrets are read from
+	are kept, since they do not reveal the secrets.
+
+	The bundle is written atomically to _file_, which is only readable by its
+	owner, and its path is printed. If _file_ is not passed, it is named after
+	the bundle's directory with the _.tar.gz_ extension and written to the
+	current directory. If _file_ is _-_, the bundle is written to standard
+	output instead.
+
+	An access token is required.
+
+	This command accepts the following options:
+
+	*--anonymize*
+		Replace hostnames, MAC addresses, and IP addresses throughout the
+		bundle with hashes of them, so that the bundle can be shared without
+		revealing them. The same value is always replaced with the same hash,
+		so that the data remains consistent, e.g. an ethernet interface can
+		still be matched with the boot parameters of its MAC address. The
+		hashes are keyed with a random key that is not stored, so they cannot
+		be reversed by hashing guessed values.
+
+		Hashes are formatted like the values they replace:
+
+		- MAC addresses are replaced with locally administered MAC addresses,
+		  keeping their separators and case.
+		- IPv4 addresses are replaced with addresses in _10.0.0.0/8_ and IPv6
+		  addresses with addresses in _fd00::/8_. Loopback and unspecified
+		  addresses, as well as netmasks, are kept.
+		- Hostnames and domains are replaced with _host-_ followed by hex
+		  digits. Hostnames are recognized in hostname fields (e.g.
+		  _Hostname_ and _FQDN_ in redfish endpoints) and in the hosts of
+		  URLs, after which they are replaced wherever else they occur.
+		  Hostnames that are xnames are kept, as are xnames in general.
+
+# EXAMPLES
+
+Create an anonymized bundle to attach to a bug report and list its contents:
+
+```
+ochami support-bundle create --anonymize bundle.tar.gz
+tar -tzf bundle.tar.gz
+```
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1), *ochami-audit*(1), *ochami-config*(5), *ochami-snapshot*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
@@ -0,0 +1,50 @@
+OCHAMI-TOKEN(1) "OpenCHAMI" "Manual Page for ochami-token"
+
+# NAME
+
+ochami-token - Work with access tokens
+
+# SYNOPSIS
+
+ochami token inspect [-F _format_]
+
+# COMMANDS
+
+## inspect
+
+Show the claims of the access token in use. The token is determined the same
+way as for any other command that requires one: the value of *--token* if passed,
+otherwise the *\<CLUSTER_NAME\>_ACCESS_TOKEN* environment variable for the cluster
+in use (see *ochami*(1)) or, if it is not set, the cluster's *token-source* (see
+*ochami-config*(5)).
+
+The issuer, subject, audience, scopes, roles, and validity times of the token
+are printed, along with whether it is currently valid and, if not, why.
+Scopes are read from the _scope_ or _scp_ claims and roles from the _roles_
+claim. The names of clusters in the configuration that the audience matches
+are also printed. A cluster matches if an audience entry is equal to the
+cluster's name, its base URI, or the host of its base URI.
+
+The signature of the token is not verified. If the token is invalid (e.g.
+expired), its details are still printed, but *ochami* exits with a non-zero
+status.
+
+This command accepts the following options:
+
+*-F, --output-format* _format_
+	Output token details in specified _format_. Supported values are:
+
+	- _json_ (default)
+	- _yaml_
+	- _toml_
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1), *ochami-config*(5)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
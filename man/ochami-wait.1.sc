@@ -0,0 +1,94 @@
+OCHAMI-WAIT(1) "OpenCHAMI" "Manual Page for ochami-wait"
+
+# NAME
+
+ochami-wait - Wait until conditions on the state of services hold
+
+# SYNOPSIS
+
+ochami wait --for _condition_ [--for _condition_...] [--timeout _duration_] [--interval _duration_]
+
+# DESCRIPTION
+
+The *wait* command polls OpenCHAMI services every *--interval* until all
+conditions passed with *--for* hold at once, so that scripts (e.g. boot
+orchestration) can wait for the cluster to reach a state before moving on. It
+only reads data from services and never modifies it. An access token is
+required.
+
+A _condition_ has the form:
+
+```
+<service>:<kind> <target> <field>=<value>... [<threshold>]
+```
+
+where _kind_ is one of:
+
+*smd:component* _xname_ _field_=_value_...
+	The SMD component with ID _xname_ has all of the fields. A component that
+	does not exist does not match.
+
+*smd:group* _label_ _field_=_value_... [_n_%|_n_]
+	At least _n_ percent (or _n_) of the members of the SMD group _label_ have
+	all of the fields. The default is _100%_. An empty group does not match.
+
+Fields are those of SMD components (e.g. _state_, _flag_, _enabled_, or
+_role_) and are compared regardless of case. _field_!=_value_ matches components
+whose field differs from _value_ instead.
+
+Errors fetching the state, e.g. while a service restarts, are logged and
+polling continues, except if the token is rejected. The status of each condition
+is logged at the _info_ level after each check.
+
+This command sends GETs to SMD's /State/Components and /groups endpoints.
+
+# OPTIONS
+
+*--for* _condition_
+	A condition to wait for. Can be passed several times. Required.
+
+*--interval* _duration_
+	How often to check the conditions. Default is _10s_.
+
+*--timeout* _duration_
+	Give up after _duration_ and exit with status *124*. _0_ waits forever.
+	Default is _10m_.
+
+# EXIT STATUS
+
+*0*
+	All conditions hold.
+
+*1*
+	A condition is invalid or the token was rejected.
+
+*124*
+	The conditions did not hold within *--timeout*.
+
+*130*
+	*ochami* was interrupted by SIGINT or SIGTERM.
+
+# EXAMPLES
+
+Wait up to 20 minutes for a node to be ready:
+
+```
+ochami wait --for 'smd:component x1000c0s0b0n0 state=Ready' --timeout 20m
+```
+
+Wait for 90% of the compute nodes to be ready and enabled:
+
+```
+ochami wait --for 'smd:group compute state=Ready enabled=true 90%'
+```
+
+# AUTHOR
+
+Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
+
+# SEE ALSO
+
+*ochami*(1), *ochami-smd*(1)
+
+; Vim modeline settings
+; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
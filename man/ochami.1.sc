@@ -18,16 +18,52 @@ List of available commands:
 
 [[ *Command*
 :< *Description*
+|  *api*
+:  Inspect the APIs of OpenCHAMI services
+|  *audit*
+:  Check the consistency of data across OpenCHAMI services
 |  *bss*
 :  Communicate with the Boot Script Service (BSS)
 |  *cloud-init*
 :  Manage cloud-init configurations
+|  *commands*
+:  List all commands or print the full command catalog as JSON
+|  *completion*
+:  Generate and install shell autocompletion scripts
+|  *compare*
+:  Compare data of OpenCHAMI services between clusters
+|  *describe*
+:  Show everything OpenCHAMI services know about an entity
 |  *discover*
 :  Simulate discovery of BMCs and nodes to populate SMD by reading an input file
+|  *doctor*
+:  Diagnose problems with the local environment
+|  *example*
+:  Print example payload files
+|  *node*
+:  Perform tasks on nodes using data from OpenCHAMI services
+|  *pcs*
+:  Communicate with the Power Control Service (PCS)
+|  *plugin*
+:  Manage external subcommands (plugins)
 |  *smd*
 :  Communicate with the State Management Database (SMD)
+|  *schema*
+:  Print JSON Schema for payload files
+|  *serve-proxy*
+:  Run a local daemon that keeps connections to services open
+|  *snapshot*
+:  Store and compare local snapshots of service data
+|  *support-bundle*
+:  Collect data for troubleshooting into a support bundle
+|  *token*
+:  Inspect access tokens
 |  *config*
 :  Manage ochami CLI configuration, including cluster configuration
+|  *version*
+:  Print detailed version and optionally check for updates
+|  *wait*
+:  Wait until conditions on the state of services hold
 
 ## Top-Level Commands
 
@@ -80,27 +116,81 @@ by underscores. In the above example, this variable would be named
 export FOOBAR_ACCESS_TOKEN=...
 ```
 
+Alternatively, so that the token need not be exported into the shell, it can be
+read from the kernel keyring, a systemd credential, or a file, or obtained from
+an attestation service, by setting *token-source* for the cluster (see
+*ochami-config*(5)):
+
+```
+ochami config cluster set foobar --set cluster.token-source=keyring
+keyctl add user ochami:foobar "$(cat token.jwt)" @u
+```
+
+The token can also be kept in the config file encrypted with *age*(1) by
+setting *token* for the cluster, so that the config file can be committed to
+e.g. Git (see *ochami-config*(5)).
+
+The environment variable takes precedence over *token*, which takes precedence
+over the token source.
+
 Once these steps are completed, *ochami* should be ready to use with cluster
 _foobar_.
 
 # GLOBAL OPTIONS
 
+*--append*
+	When used with *--output-file*, append the output to the file instead of
+	replacing it. The file is still updated atomically.
+
 *-u, --base-uri* _uri_
 	Specify the base URI to use when contacting OpenCHAMI services. Overrides
 	the base URI specified in a config file.
 
 *--cacert* _cacert_
 	Specify the path to a certificate authority (CA) certificate file to use to
-	verify TLS certificates. Must be PEM-formatted.
+	verify TLS certificates. Must be PEM-formatted. Its certificates are trusted
+	in addition to those of the system's trust store and of the cluster's
+	*ca-certs*, unless the cluster sets *use-system-store* to _false_ (see
+	*ochami-config*(5)).
 
 *-C, --cluster* _cluster_name_
 	Specify the name of a cluster to use. The cluster corresponding to the
 	passed cluster name must exist in a config file.
 
+*--color* _when_
+	Specify when to color output: log messages, differences printed by
+	commands like *ochami snapshot diff*, and table headers. One of:
+
+	- _auto_ (default): Color output to terminals, unless the *NO_COLOR*
+	  environment variable is set to a non-empty value or *TERM* is _dumb_.
+	  Standard output and standard error are decided separately.
+	- _always_: Always color output, even when it is redirected.
+	- _never_: Never color output.
+
+	Overrides *color* in a config file (see *ochami-config*(5)).
+
+*--compat* _service_=_version_
+	Assume that _service_ (e.g. _smd_) is _version_ (e.g. _1.x_ or _2.3_) when
+	deciding whether to downgrade payloads sent to it, instead of asking it
+	for its version. _latest_ never downgrades payloads for _service_. Can be
+	passed once per service. See *COMPATIBILITY*.
+
+*--compress*
+	Compress request bodies of 1 KiB or more with gzip and send them with a
+	_Content-Encoding: gzip_ header. This is useful when sending large
+	payloads, e.g. for *ochami discover*, to a cluster over a slow link, but
+	the service (or the gateway in front of it) must accept gzip-encoded
+	requests. It can also be enabled per cluster with *compress* (see
+	*ochami-config*(5)). Responses are always requested with gzip compression
+	and decompressed transparently, regardless of this option.
+
 *-c, --config* _config_file_
 	Specify the path to a config file to use. By default, the configuration is
 	merged from the system config with the user config (see *FILES* below). The
-	format of this file should be YAML.
+	format of this file should be YAML. _config_file_ can also be an _https://_
+	URL, in which case the config file is fetched from it and cached for one
+	hour, and is restricted like any remote config file (see *remote* in
+	*ochami-config*(5)).
 
 *--ignore-config*
 	Do not read configuration from any configuration file.
@@ -108,6 +198,26 @@ _foobar_.
 *-k, --insecure*
 	Do not verify TLS certificates.
 
+*--ip-version* 4|6|auto
+	Only connect to the cluster's services over IPv4 (_4_) or IPv6 (_6_), so
+	that hostnames are only resolved to addresses of that family. This works
+	around dual-stack clusters that publish broken AAAA (or A) records, e.g.
+	for their gateway. _auto_, the default, uses both. It can also be set per
+	cluster with *ip-version* (see *ochami-config*(5)).
+
+*--log-filter* component=_component_,...
+	Only print info, debug, and trace log messages of the listed components.
+	Warnings and errors are always printed. This option can be passed more than once.
+	The components are:
+
+	- _cli_: the command line interface, e.g. flag and config handling
+	- _client_: requests to and responses from OpenCHAMI services
+	- _config_: reading and writing config files
+	- _discover_: generating data for *ochami-discover*(1)
+
+	For example, *--log-level debug --log-filter component=client* prints the
+	debug messages of requests and responses only.
+
 *-L, --log-format* _format_
 	Specify the format of log messages, overriding what is set in the config
 	file. Defaults to _json_.
@@ -116,6 +226,8 @@ _foobar_.
 
 	- _basic_
 	- _json_
+	- _logfmt_: one line of _key=value_ pairs per message, including the
+	  component that logged it (see *--log-filter*), for log processors
 	- _rfc3339_
 
 *-l, --log-level* _level_
@@ -124,14 +236,470 @@ _foobar_.
 
 	Supported log levels are:
 
-	- _info_
+	- _error_
 	- _warning_
-	- _debug_
+	- _info_
+	- _debug_: also logs the method and URL of each request and the status of
+	  its response
+	- _trace_: also logs the headers and bodies of requests and responses, with
+	  secrets redacted (see *--log-secrets*)
+
+	This option overrides the log level of *--verbosity*.
+
+*--log-secrets*
+	Do not redact secrets in log messages. By default, trace logs replace the
+	values of Authorization and cookie headers, access tokens, and JSON fields
+	and query parameters whose names look like they hold secrets (e.g.
+	_Password_ or _access_token_) with _REDACTED_. This option should only be
+	used for deep debugging since the logs will then contain credentials.
+
+*--no-headers*
+	Do not print the row of column headers of tables printed with
+	*--output-format table*. See *TABLES*.
+
+*-o, --output-file* _file_
+	Write what the command would print to standard output to _file_ instead.
+	The output is collected and written to a temporary file in the same
+	directory as _file_, which is then renamed to _file_, so _file_ always holds
+	either its previous contents or the complete output. If the command fails,
+	_file_ is left untouched. Log messages and prompts are still printed to
+	standard error.
+
+*--output-header*
+	Wrap the response data printed by commands that print the response of a
+	single request (e.g. *ochami smd component get*) in an object that also
+	identifies where it came from, so that scripts need not rely on the
+	context the command was run in:
+
+	```
+	{"cluster": "foobar", "service": "smd",
+	 "request": {"method": "GET", "url": "...", "status": 200,
+	             "time": "...", "duration_ms": 12},
+	 "data": ...}
+	```
+
+	*cluster* is omitted if *--base-uri* is used instead of a cluster. The
+	object is printed in the format passed to *-F*.
+
+*--partial-ok*
+	Exit with status *0* instead of *3* when some items of an iterative command
+	(e.g. adding or deleting several groups) fail but others succeed. A warning
+	summarizing the failures is still printed. See *EXIT STATUS*.
+
+*--plan* _file_
+	With *--plan-only*, write the plan to _file_ instead of standard output.
+	With *--execute*, read the plan to run from _file_. See *PLANS*.
+
+*--plan-only*
+	Do not send mutating requests. Instead, print a plan of the requests that
+	would be sent. See *PLANS*.
+
+*--execute*
+	Only send the mutating requests that are part of the plan passed with
+	*--plan*. See *PLANS*.
+
+*--tenant* _tenant_
+	Make requests on behalf of _tenant_ in multi-tenant deployments by sending
+	it in the *X-Tenant-ID* header (or the cluster's *tenant-header*) of every
+	request. It can also be set per cluster with *tenant* (see
+	*ochami-config*(5)). If the access token names the tenants it is for in
+	its _tenant_, _tenant_id_, _tenants_, _tenant_ids_, or _tid_ claims,
+	*ochami* refuses to run if _tenant_ is not one of them. Tokens without
+	these claims cannot be checked and are sent as is.
 
 *-t, --token* _token_
 	Access token to include in request headers for authentication to protected
 	service endpoints. Overrides token set in environment variable.
 
+*--transform* _filter_
+	Apply the *jq*(1) filter _filter_ to payload files before they are used,
+	e.g. _'del(.Components[].NID)'_. Can be passed more than once to apply
+	several filters in order. Requires *jq* to be installed. See *PAYLOADS*.
+
+*--transform-command* _command_
+	Pipe payload files through the shell command _command_ before they are
+	used. Can be passed more than once to run several commands in order, after
+	any *--transform* filters. See *PAYLOADS*.
+
+*-v, --verbose*
+	Print messages about loading the configuration before logging is
+	initialized. Also print a summary of each request to standard error once
+	its response has been read: the method, the final URL (after any
+	redirects), the response status, how long the request took, and the
+	number of attempts, e.g.:
+
+	GET https://foobar.openchami.cluster/hsm/v2/State/Components: 200 OK in 35ms (1 attempt)
+
+	This helps to identify slow endpoints. The same information is logged at
+	the _debug_ log level.
+
+*--verbosity* _preset_
+	Set how much *ochami* reports while it runs, configuring the log level,
+	request summaries (see *--verbose*), and the progress messages of
+	long-running commands (e.g. *ochami pcs power*) together. Supported
+	presets are:
+
+	[[ *Preset*
+	:< *Log level*
+	:< *Request summaries*
+	:< *Progress*
+	|  _quiet_
+	:  _error_
+	:  no
+	:  no
+	|  _normal_
+	:  _warning_
+	:  no
+	:  yes
+	|  _debug_
+	:  _debug_
+	:  yes
+	:  yes
+	|  _trace_
+	:  _trace_
+	:  yes
+	:  yes
+
+	_normal_ is what *ochami* does by default. *--log-level* and *--verbose*
+	can be combined with a preset to override its log level and to summarize
+	requests, respectively. At the _trace_ level, complete requests and
+	responses are logged, with secrets redacted unless *--log-secrets* is
+	passed.
+
+*--via-daemon*
+	Send requests through the connection daemon started with *ochami
+	serve-proxy*, which keeps connections to services open across
+	invocations. If the daemon is not running, requests are sent directly.
+	See *ochami-serve-proxy*(1).
+
+# PAYLOADS
+
+Commands that send data to a service can read it from a payload file passed
+with *-f*/*--payload*, in the format given by *--payload-format* (_json_ by
+default, _yaml_, or _toml_). The argument to *-f* can be:
+
+- A file path
+- *-*, to read the payload data from standard input
+- An _http://_ or _https://_ URL, to fetch the payload data from it, e.g. from
+  an internal artifact store:
+
+	ochami smd component add -f https://git.example.com/raw/nodes.yaml --payload-format yaml
+
+The URL is fetched with a plain GET request that is independent of the
+cluster in use: the access token is not sent and the system's CA certificates
+are used instead of *--cacert*. Proxies are honored from the *HTTPS_PROXY*,
+*HTTP_PROXY*, and *NO_PROXY* environment variables. Pass *--payload-insecure*
+to skip verifying the TLS certificate of the server.
+
+Site-specific changes can be made to payload files without editing them by
+passing *--transform* _filter_, a *jq*(1) filter, or *--transform-command*
+_command_, a shell command. The payload, converted to JSON if it is not, is
+passed to each of them on standard input in turn, and each must write the
+transformed payload as a single JSON value to standard output. Their output
+is what is sent. For example, to add components without the NIDs in a
+payload file:
+
+	ochami smd component add -f nodes.yaml --payload-format yaml --transform 'del(.Components[].NID)'
+
+If a filter or command fails or does not output valid JSON, nothing is sent.
+Patch files passed with *--patch-file* are transformed too. Payloads built
+from arguments and flags are not.
+
+Small payloads can instead be built on the command line with *--set*
+_field_=_value_, which can be repeated. It is accepted by *ochami smd component
+add* and *update*, *ochami smd group add*, *ochami smd iface add*, *ochami smd
+rfe add*, *ochami bss boot params add* and *set*, and *ochami cloud-init config
+add* and *update*. _field_ is the name of a field of the payload item (e.g.
+_NID_ for a component) or a dot-separated path to a nested field (e.g.
+_cloud-init.metadata.instance-id_). _value_ is parsed as YAML, so numbers and
+booleans get their type, lists and maps can be passed in flow style (e.g.
+_[x1000c1s7b0n0, x1000c1s7b1n0]_), and a value can be quoted to pass it as a
+string. Unknown fields and values of the wrong type are rejected. For example:
+
+	ochami smd component add --set ID=x1000c0s0b0n0 --set Type=Node --set NID=17
+
+Since a TOML document must be a table, data that is not, such as a list, is
+printed by *-F toml* as an array under the _items_ key. Likewise, a TOML
+payload holding only an _items_ array is read as that array.
+
+# TEMPLATES
+
+Many similar items can be added without writing a payload file for them by
+passing *--template* _template_ and *--var-file* _file_ to *ochami smd
+component add*, *ochami smd iface add*, or *ochami smd rfe add*. _template_ is
+a single payload item (e.g. one component) in the format given by
+*--payload-format*, written with the syntax of Go's _text/template_ package,
+or *@* followed by the path of a file containing it. _file_ is a CSV file (or
+*-* for standard input) whose header row names the variables of the template.
+The template is rendered once for each of the other rows, with the value of a
+column named _xname_ available as _{{.xname}}_ (or _{{index . "col-name"}}_
+for names that are not identifiers), and all of the rendered items are sent.
+For example, with _nodes.csv_:
+
+	xname,nid,role
+	x1000c0s0b0n0,1,Compute
+	x1000c0s1b0n0,2,Application
+
+and _component.tmpl_:
+
+	ID: {{.xname}}
+	NID: {{.nid}}
+	Role: {{.role}}
+
+running:
+
+	ochami smd component add --template @component.tmpl --var-file nodes.csv --payload-format yaml
+
+adds both components. Using a variable that is not a column of the CSV file
+is an error, and nothing is sent if any row fails to render into a valid
+item. Rendered items are not passed through *--transform* or
+*--transform-command*.
+
+# IDENTIFIERS
+
+Commands that act on nodes by identifier (e.g. *ochami describe node*,
+*ochami node console*, and *ochami pcs power*) accept any of the following,
+which can be mixed:
+
+- An xname, e.g. _x1000c1s7b0n0_, matched regardless of case
+- The MAC address of one of the node's ethernet interfaces, e.g.
+  _de:ad:be:ef:00:01_, with colons, dashes, dots (_dead.beef.0001_), or no
+  separators, matched regardless of case and separators
+- A NID, e.g. _12_
+
+Each identifier is classified by its form and resolved to the xname of the
+component it refers to using SMD's /State/Components and
+/Inventory/EthernetInterfaces endpoints. The command fails without doing
+anything if any identifier is not one of the above, does not refer to a
+component, or refers to more than one (e.g. a MAC address claimed by
+interfaces of different components). All such identifiers are listed in the
+error. A MAC address without separators that consists only of digits could
+also be a NID, so it is rejected as ambiguous; write it with separators.
+
+# LISTS
+
+Commands that print a list of items (e.g. *ochami smd component get* or
+*ochami bss boot params get*) accept *--limit* _n_ to print at most _n_ items
+and *--sort* _field_[:_order_] to sort the items by _field_ in _order_, which
+is _asc_ (the default) or _desc_. Sorting happens before limiting, so e.g.
+*--sort NID:desc --limit 10* prints the 10 items with the highest NIDs.
+
+_field_ is the name of a field of the items as printed, matched
+case-insensitively if there is no exact match, or a dot-separated path to a
+nested field (e.g. _cloud-init.meta-data.hostname_). Numbers are sorted
+numerically and other values as strings. Items without _field_ are printed
+last. Lists of plain values, such as the xnames printed by *ochami smd group
+member get*, are sorted by value.
+
+The services ochami lists items from return the whole list, so items are
+limited and sorted by ochami after receiving it.
+
+# OUTPUT FORMATS
+
+Commands that print data accept *-F, --output-format* _format_ to choose its
+format: _json_ (on a single line), _json-pretty_ (indented), _yaml_, or _toml_,
+as well as _table_ for some commands (see *TABLES*). If it is not passed, the
+format depends on where the output goes, so that it is easy to read in a
+terminal and easy to parse in a pipeline:
+
+- If standard output is a terminal, *format-output* from the config (see
+  *ochami-config*(5)) is used, or else _json-pretty_.
+- Otherwise, e.g. when the output is piped into *jq*(1) or written with
+  *--output-file*, *format-output-pipe* from the config is used if set. If it
+  is not, *format-output* is used unless it is _json-pretty_ or _table_, and
+  _json_ is used instead.
+
+Passing *--output-format* always takes precedence. Commands that print a
+summary or a table unless *--output-format* is passed (e.g. *ochami audit
+boot*) print it regardless of where the output goes.
+
+# TABLES
+
+Commands that print resources that are usually read a row at a time accept
+_table_ as well as the other formats for *--output-format* (or
+*format-output* in the config, see *ochami-config*(5)). They print one item per
+row, with columns aligned with spaces under a row of headers, which
+*--no-headers* omits. Lists in a column are joined with commas, and empty
+values are printed as *-* so that each row has a value in every column.
+
+The columns of each command are listed below, in order. Columns are only ever
+added after the existing ones, so scripts that select them by position (e.g.
+with *awk '{print $3}'*) keep working in later releases. Columns whose values
+can contain spaces are last.
+
+[[ *Command*
+:< *Columns*
+|  *ochami bss boot params get*
+:  HOSTS MACS NIDS KERNEL INITRD PARAMS
+|  *ochami smd component get*
+:  ID TYPE STATE FLAG ENABLED ROLE SUBROLE NID ARCH CLASS
+|  *ochami smd group get*
+:  LABEL EXCLUSIVE TAGS MEMBERS DESCRIPTION
+|  *ochami smd iface get*
+:  ID MAC COMPONENT TYPE IPS DESCRIPTION
+|  *ochami smd rfe get*
+:  ID TYPE FQDN IP MAC ENABLED STATUS
+
+Responses that are errors are printed as JSON.
+
+# TIMES
+
+Commands that print history (e.g. *ochami bss history* or *ochami smd hwinv
+history*) accept *--since* _time_ and *--until* _time_ to only print entries
+within a time window. Both ends of the window are inclusive and either can be
+left out. _time_ can be:
+
+- an RFC 3339 timestamp (e.g. _2024-05-01T12:00:00Z_), or one without a time
+  zone, which is taken to be local time (e.g. _2024-05-01T12:00_)
+- a date (e.g. _2024-05-01_), meaning midnight local time
+- a duration relative to now (e.g. _-24h_, _-7d_, _-1w_, or _+1h_), using the
+  units _s_, _m_, _h_, _d_ (days), and _w_ (weeks); durations without a sign
+  are in the past
+- _now_, _today_, or _yesterday_, the latter two meaning midnight local time
+- a Unix timestamp in seconds prefixed with *@* (e.g. _@1714564800_)
+
+Windows are sent to services that can filter by time, and applied by ochami
+after receiving the history otherwise. Other options taking a _time_ (e.g.
+*--older-than* of *ochami smd iface get*) accept the same forms.
+
+Options taking a _duration_ (e.g. *--timeout* of *ochami version*, or
+*--stagger* of *ochami pcs power*), as well as durations in the config file
+(e.g. the _ttl_ of remote config files), accept a sequence of numbers and
+units (e.g. _90s_, _5m_, _2h30m_, or _1d_), using the same units, or an ISO
+8601 duration without years or months (e.g. _PT5M_ or _P1DT12H_).
+
+# COMPATIBILITY
+
+Some payloads are sent in forms that older versions of a service reject. When
+such a payload is sent, the version of the service is fetched once from its
+_/service/version_ endpoint and, if it is too old, the payload is downgraded
+by removing the fields that it rejects. A warning is logged when this happens.
+If the service does not report a version, payloads are not downgraded. Pass
+*--compat* to set the version instead, e.g. when the service is behind a
+gateway that does not expose _/service/version_. The following payloads are
+downgraded:
+
+- Redfish endpoints sent to SMD before 2.0 are sent with the V1 schema, without
+  _SchemaVersion_, _Systems_, and _Managers_. SMD then does not create
+  components or interfaces from the systems and managers of the endpoints.
+
+# EXPERIMENTAL FEATURES AND DEPRECATIONS
+
+Commands and flags that are experimental belong to a named feature and are
+marked _(experimental)_ in their help. Their behavior may change or they may be
+removed in any release, so they refuse to run unless their feature is enabled,
+either with *features.enable* in the config (see *ochami-config*(5)) or in the
+*OCHAMI_EXPERIMENTAL* environment variable, which holds a comma-separated list
+of features, or _all_ to enable all of them. For example:
+
+	OCHAMI_EXPERIMENTAL=foo ochami ...
+
+Commands that are deprecated are marked _(deprecated)_ in their help and flags
+that are deprecated are hidden from it. Both keep working until they are
+removed, but using them logs a warning, once per invocation, saying what to use
+instead and, if known, since which version they are deprecated and in which
+version they will be removed. These details are also fields of the log message
+(_deprecated_, _since_, _removal_, and _replacement_) and are listed in the
+output of *ochami commands --json*. Deprecation warnings are not logged if
+*features.deprecation-warnings* is _false_ in the config, if the
+*OCHAMI_NO_DEPRECATION_WARNINGS* environment variable is set to a non-empty
+value, or if the log level is _error_.
+
+# PLANS
+
+Any command that changes data in OpenCHAMI services can be run with
+*--plan-only* to review what it would do before doing it. Mutating requests
+(POST, PUT, PATCH, and DELETE) are then not sent; instead, the command prints a
+JSON array containing one entry per request, each with the following keys:
+
+- _method_ - The HTTP method of the request
+- _uri_ - The URI of the request
+- _body-digest_ - The SHA-256 digest of the request body, if any
+- _target-id_ - A best-effort identifier of what the request acts upon (e.g.
+  the xname of a component), for the benefit of reviewers
+
+Requests that do not change data (e.g. GET) are sent as usual since commands
+may need their results to determine what to do. The usual output of the
+command is discarded. With *--plan* _file_, the plan is written to _file_
+instead of standard output.
+
+Once reviewed, a plan can be run by passing the same command with *--plan*
+_file_ *--execute*. Each mutating request is only sent if it matches a request
+of the plan that was not sent yet, i.e. has the same method, URI, and body
+digest. Otherwise, it fails with a "request not in plan" error, which can
+happen if the command or the data it is based on changed since the plan was
+made. If some requests of the plan were not sent once the command has
+completed, they are listed and *ochami* exits with status 1. For example:
+
+	ochami smd component delete --all --force --plan-only --plan plan.json++
+ochami smd component delete --all --force --plan plan.json --execute
+
+Commands that do not send requests (e.g. *config*) are not affected.
+
+# SIGNALS
+
+When *ochami* receives SIGINT (e.g. Ctrl-C) or SIGTERM while a command sends
+one request per item (e.g. adding or deleting several components), it stops
+sending new requests and lets the ones in flight finish. It then logs the
+requests that failed and a summary of how many succeeded, failed, and were not
+sent, and exits with status 130. A second signal terminates *ochami*
+immediately.
+
+# EXIT STATUS
+
+*0*
+	The command succeeded.
+
+*1*
+	The command failed.
+
+*3*
+	Some items of an iterative command (e.g. adding or deleting several
+	groups) failed while others succeeded. Only the failed items, which are
+	logged, need to be retried. Pass *--partial-ok* to exit with status *0*
+	instead.
+
+*124*
+	A command that waits (e.g. *ochami wait*) gave up after its timeout.
+
+*130*
+	The command was interrupted by SIGINT or SIGTERM before it finished (see
+	*SIGNALS*).
+
+Some commands use other statuses, which are documented in their man pages.
+
+# CHECKING FOR UPDATES
+
+*ochami version* prints detailed information about the build of *ochami* in use.
+It accepts the following options to check whether a newer release is available:
+
+*--check-update*
+	Fetch the releases of *ochami* from the GitHub releases API and compare
+	the latest one in the release channel with the version of this build. If
+	the latest release is newer, the command to install it and the URL of the
+	release are printed. Drafts are never considered. The result is cached for
+	24 hours per release channel.
+
+	The request honors the *HTTPS_PROXY*, *HTTP_PROXY*, and *NO_PROXY*
+	environment variables.
+
+*--channel* _channel_
+	Release channel to check. Supported values are:
+
+	- _stable_ - Only full releases
+	- _prerelease_ - Full releases and prereleases (release candidates,
+	  betas, etc.)
+
+	The default is _prerelease_ if this build is a prerelease, otherwise
+	_stable_.
+
+*--refresh*
+	Ignore the cached result and query the releases API.
+
+*--timeout* _duration_
+	Give up checking for updates after _duration_ (e.g. _5s_). Default is
+	_10s_.
+
 # FILES
 
 _/usr/share/doc/ochami/config.example.yaml_
@@ -143,13 +711,23 @@ _/etc/ochami/config.yaml_
 _~/.config/ochami/config.yaml_
 	The user-level ochami CLI configuration file.
 
+_~/.cache/ochami/update-check.json_
+	Cached result of *ochami version --check-update*. The location follows
+	*XDG_CACHE_HOME* if set.
+
 # AUTHOR
 
 Written by Devon T. Bautista and maintained by the OpenCHAMI developers.
 
 # SEE ALSO
 
-*ochami-bss*(1), *ochami-config*(1), *ochami-discover*(1), *ochami-smd*(1)
+*ochami-api*(1), *ochami-audit*(1), *ochami-bss*(1), *ochami-commands*(1),
+*ochami-compare*(1), *ochami-completion*(1), *ochami-config*(1),
+*ochami-describe*(1), *ochami-discover*(1), *ochami-doctor*(1),
+*ochami-example*(1), *ochami-node*(1), *ochami-pcs*(1), *ochami-plugin*(1),
+*ochami-schema*(1), *ochami-serve-proxy*(1), *ochami-smd*(1),
+*ochami-snapshot*(1), *ochami-support-bundle*(1), *ochami-token*(1),
+*ochami-wait*(1)
 
 ; Vim modeline settings
 ; vim: set tw=80 noet sts=4 ts=4 sw=4 syntax=scdoc:
@@ -0,0 +1,300 @@
+// Package anonymize replaces hostnames, MAC addresses, and IP addresses in
+// service data with keyed hashes of them, so that the data can be shared
+// without revealing them while keeping it consistent: the same value is
+// always replaced with the same hash, wherever it occurs.
+package anonymize
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// hostnameKeys are the (lowercased) keys of JSON objects whose values are
+// hostnames or domains.
+var hostnameKeys = map[string]bool{
+	"domain":         true,
+	"fqdn":           true,
+	"hostname":       true,
+	"hostnames":      true,
+	"local-hostname": true,
+}
+
+var (
+	// tokenRegexp matches the host of a URL (group 1 is the scheme and
+	// userinfo, group 2 the host), a MAC address, something that may be an
+	// IPv6 address (group 3) not preceded by a character that could be part
+	// of an address, e.g. a colon separating fields, or an IPv4 address, in
+	// that order of preference.
+	tokenRegexp = regexp.MustCompile(`(?i)` +
+		`\b([a-z][a-z0-9+.-]*://(?:[^/@\s"']*@)?)(\[[0-9a-f:.]+\]|[a-z0-9._-]+)` +
+		`|\b[0-9a-f]{2}(?:[:-][0-9a-f]{2}){5}\b` +
+		`|(?:^|[^0-9a-z.:])([0-9a-f]{0,4}(?::[0-9a-f]{0,4}){2,7})` +
+		`|\b(?:[0-9]{1,3}\.){3}[0-9]{1,3}\b`)
+
+	// macRegexp matches MAC addresses with colons.
+	macRegexp = regexp.MustCompile(`^(?i)[0-9a-f]{2}(?::[0-9a-f]{2}){5}$`)
+
+	// bareMACRegexp matches MAC addresses without separators, as SMD uses
+	// for the IDs of ethernet interfaces. Since other IDs may look the same,
+	// it is only applied to the values of ID and MAC address fields.
+	bareMACRegexp = regexp.MustCompile(`^(?i)[0-9a-f]{12}$`)
+
+	// xnameRegexp matches xnames, which are locations rather than names and
+	// so are kept.
+	xnameRegexp = regexp.MustCompile(`^x[0-9]+(?:[a-z]+[0-9]+)*$`)
+)
+
+// Anonymizer replaces hostnames, MAC addresses, and IP addresses with hashes
+// keyed with its key. Hashes are formatted like the values they replace: MAC
+// addresses are replaced with locally administered MAC addresses, IPv4
+// addresses with addresses in 10.0.0.0/8, IPv6 addresses with addresses in
+// fd00::/8, and hostnames with "host-" followed by hex digits. Loopback and
+// unspecified addresses, netmasks, and hostnames that are xnames are kept.
+//
+// Hostnames are only recognized in the values of hostname fields of JSON
+// data, after which they are also replaced wherever they occur in other
+// strings. An Anonymizer is not safe for concurrent use.
+type Anonymizer struct {
+	key         []byte
+	hosts       map[string]bool
+	hostsRegexp *regexp.Regexp
+}
+
+// New returns an Anonymizer that hashes with key. Passing the same key to
+// several Anonymizers makes them replace the same values with the same hashes.
+func New(key []byte) *Anonymizer {
+	return &Anonymizer{key: key, hosts: map[string]bool{}}
+}
+
+// NewRandom returns an Anonymizer that hashes with a random key, so that its
+// hashes cannot be reversed by hashing candidate values.
+func NewRandom() (*Anonymizer, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate anonymization key: %w", err)
+	}
+	return New(key), nil
+}
+
+// hash returns the keyed hash of value of kind.
+func (a *Anonymizer) hash(kind, value string) []byte {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(kind + ":" + value))
+	return mac.Sum(nil)
+}
+
+// MAC returns the anonymized form of the MAC address mac, which keeps its
+// separators (colons, dashes, or none) and case. If mac is not a MAC address,
+// it is returned as is.
+func (a *Anonymizer) MAC(mac string) string {
+	norm := strings.ToLower(strings.NewReplacer(":", "", "-", "").Replace(mac))
+	if !bareMACRegexp.MatchString(norm) {
+		return mac
+	}
+	h := a.hash("mac", norm)[:6]
+	// Locally administered, unicast
+	h[0] = (h[0] | 0x02) &^ 0x01
+
+	sep := ""
+	if len(mac) > 12 {
+		sep = mac[2:3]
+	}
+	parts := make([]string, len(h))
+	for i, b := range h {
+		parts[i] = hex.EncodeToString([]byte{b})
+	}
+	out := strings.Join(parts, sep)
+	if mac != strings.ToLower(mac) {
+		out = strings.ToUpper(out)
+	}
+	return out
+}
+
+// IP returns the anonymized form of the IP address ip. If ip is not an IP
+// address, it is returned as is.
+func (a *Anonymizer) IP(ip string) string {
+	addr := net.ParseIP(ip)
+	if addr == nil || addr.IsLoopback() || addr.IsUnspecified() {
+		return ip
+	}
+	if v4 := addr.To4(); v4 != nil {
+		if _, bits := net.IPMask(v4).Size(); bits != 0 {
+			// Netmask
+			return ip
+		}
+		h := a.hash("ip", v4.String())
+		return net.IPv4(10, h[0], h[1], h[2]).String()
+	}
+	h := a.hash("ip", addr.String())
+	out := make(net.IP, net.IPv6len)
+	out[0] = 0xfd
+	copy(out[1:], h)
+	return out.String()
+}
+
+// Hostname returns the anonymized form of the hostname or domain host, and
+// remembers host so that Text replaces it as well. IP addresses are
+// anonymized as by IP. Empty hostnames, localhost, and xnames are returned as
+// is.
+func (a *Anonymizer) Hostname(host string) string {
+	if host == "" || strings.EqualFold(host, "localhost") || xnameRegexp.MatchString(host) {
+		return host
+	}
+	if net.ParseIP(host) != nil {
+		return a.IP(host)
+	}
+	a.learnHostname(host)
+	return "host-" + hex.EncodeToString(a.hash("host", strings.ToLower(host))[:5])
+}
+
+// learnHostname remembers host so that Text replaces it.
+func (a *Anonymizer) learnHostname(host string) {
+	if host == "" || a.hosts[host] || strings.EqualFold(host, "localhost") || xnameRegexp.MatchString(host) || net.ParseIP(host) != nil {
+		return
+	}
+	a.hosts[host] = true
+	a.hostsRegexp = nil
+}
+
+// Text returns s with the MAC addresses, IP addresses, hosts of URLs, and
+// hostnames seen so far in it anonymized.
+func (a *Anonymizer) Text(s string) string {
+	var sb strings.Builder
+	last := 0
+	for _, m := range tokenRegexp.FindAllStringSubmatchIndex(s, -1) {
+		start, tok := m[0], s[m[0]:m[1]]
+		var out string
+		switch {
+		case m[2] >= 0:
+			// URL
+			scheme, host := s[m[2]:m[3]], s[m[4]:m[5]]
+			if strings.HasPrefix(host, "[") {
+				out = scheme + "[" + a.IP(strings.Trim(host, "[]")) + "]"
+			} else {
+				out = scheme + a.Hostname(host)
+			}
+		case m[6] >= 0:
+			// Possible IPv6 address, after the character preceding it. A
+			// MAC address with colons matches this as well when it is
+			// preceded by such a character, e.g. in "mac=de:ca:...".
+			start, tok = m[6], s[m[6]:m[7]]
+			if macRegexp.MatchString(tok) {
+				out = a.MAC(tok)
+			} else {
+				out = a.IP(tok)
+			}
+		case strings.Count(tok, ":") == 5 || strings.Count(tok, "-") == 5:
+			out = a.MAC(tok)
+		default:
+			out = a.IP(tok)
+		}
+		sb.WriteString(s[last:start])
+		sb.WriteString(out)
+		last = m[1]
+	}
+	sb.WriteString(s[last:])
+	s = sb.String()
+
+	if len(a.hosts) == 0 {
+		return s
+	}
+	if a.hostsRegexp == nil {
+		hosts := make([]string, 0, len(a.hosts))
+		for h := range a.hosts {
+			hosts = append(hosts, h)
+		}
+		// Longest first, so that e.g. an FQDN is replaced rather than the
+		// hostname it starts with
+		sort.Slice(hosts, func(i, j int) bool {
+			if len(hosts[i]) != len(hosts[j]) {
+				return len(hosts[i]) > len(hosts[j])
+			}
+			return hosts[i] < hosts[j]
+		})
+		for i, h := range hosts {
+			hosts[i] = regexp.QuoteMeta(h)
+		}
+		a.hostsRegexp = regexp.MustCompile(`(?i)\b(?:` + strings.Join(hosts, "|") + `)\b`)
+	}
+	return a.hostsRegexp.ReplaceAllStringFunc(s, a.Hostname)
+}
+
+// JSON returns the JSON data body with hostnames, MAC addresses, and IP
+// addresses anonymized, indented with two spaces. The values of hostname
+// fields (e.g. Hostname or FQDN) are anonymized as hostnames, the values of ID
+// and MAC address fields that consist of a MAC address without separators as
+// MAC addresses, and all other strings, as well as the keys of objects, as by
+// Text.
+func (a *Anonymizer) JSON(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON to anonymize: %w", err)
+	}
+
+	// Learn the hostnames first so that they are replaced in strings that
+	// come before their hostname fields
+	a.learnJSON(v, "")
+	out, err := json.MarshalIndent(a.anonymizeJSON(v, ""), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode anonymized JSON: %w", err)
+	}
+	return out, nil
+}
+
+// learnJSON remembers the hostnames in the hostname fields of v, the value of
+// key.
+func (a *Anonymizer) learnJSON(v interface{}, key string) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, e := range val {
+			a.learnJSON(e, k)
+		}
+	case []interface{}:
+		for _, e := range val {
+			a.learnJSON(e, key)
+		}
+	case string:
+		if hostnameKeys[strings.ToLower(key)] {
+			a.learnHostname(val)
+		}
+	}
+}
+
+// anonymizeJSON returns v, the value of key, anonymized.
+func (a *Anonymizer) anonymizeJSON(v interface{}, key string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			out[a.Text(k)] = a.anonymizeJSON(e, k)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, e := range val {
+			out[i] = a.anonymizeJSON(e, key)
+		}
+		return out
+	case string:
+		switch {
+		case hostnameKeys[strings.ToLower(key)]:
+			return a.Hostname(val)
+		case (strings.EqualFold(key, "id") || strings.Contains(strings.ToLower(key), "mac")) && bareMACRegexp.MatchString(val):
+			return a.MAC(val)
+		default:
+			return a.Text(val)
+		}
+	}
+	return v
+}
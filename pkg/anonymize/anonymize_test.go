@@ -0,0 +1,176 @@
+package anonymize
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMAC(t *testing.T) {
+	a := New([]byte("key"))
+	colon := a.MAC("de:ca:fc:0f:ee:ee")
+	if colon == "de:ca:fc:0f:ee:ee" {
+		t.Fatal("MAC address was not anonymized")
+	}
+	if colon != a.MAC("de:ca:fc:0f:ee:ee") {
+		t.Error("same MAC address anonymized differently")
+	}
+	norm := func(mac string) string {
+		return strings.ToLower(strings.NewReplacer(":", "", "-", "").Replace(mac))
+	}
+	for _, mac := range []string{"DE:CA:FC:0F:EE:EE", "de-ca-fc-0f-ee-ee", "decafc0feeee"} {
+		got := a.MAC(mac)
+		if norm(got) != norm(colon) {
+			t.Errorf("MAC(%q) = %q, want the same address as %q", mac, got, colon)
+		}
+		if len(got) != len(mac) || (mac != strings.ToLower(mac)) != (got != strings.ToLower(got)) {
+			t.Errorf("MAC(%q) = %q, which is not formatted like it", mac, got)
+		}
+	}
+	if a.MAC("de:ca:fc:0f:ee:ef") == colon {
+		t.Error("different MAC addresses anonymized the same")
+	}
+	if b := New([]byte("other")); b.MAC("de:ca:fc:0f:ee:ee") == colon {
+		t.Error("MAC address anonymized the same with a different key")
+	}
+	if got := a.MAC("not a mac"); got != "not a mac" {
+		t.Errorf("MAC(%q) = %q, want it unchanged", "not a mac", got)
+	}
+}
+
+func TestIP(t *testing.T) {
+	a := New([]byte("key"))
+	tests := []struct {
+		ip     string
+		kept   bool
+		prefix string
+	}{
+		{ip: "172.16.0.1", prefix: "10."},
+		{ip: "2001:db8::1", prefix: "fd"},
+		{ip: "127.0.0.1", kept: true},
+		{ip: "::1", kept: true},
+		{ip: "0.0.0.0", kept: true},
+		{ip: "::", kept: true},
+		{ip: "255.255.255.0", kept: true},
+		{ip: "not an ip", kept: true},
+	}
+	for _, tt := range tests {
+		got := a.IP(tt.ip)
+		if tt.kept {
+			if got != tt.ip {
+				t.Errorf("IP(%q) = %q, want it unchanged", tt.ip, got)
+			}
+			continue
+		}
+		if got == tt.ip || !strings.HasPrefix(got, tt.prefix) {
+			t.Errorf("IP(%q) = %q, want an address starting with %q", tt.ip, got, tt.prefix)
+		}
+		if again := a.IP(tt.ip); again != got {
+			t.Errorf("IP(%q) = %q, then %q", tt.ip, got, again)
+		}
+	}
+}
+
+func TestHostname(t *testing.T) {
+	a := New([]byte("key"))
+	got := a.Hostname("node01")
+	if !strings.HasPrefix(got, "host-") {
+		t.Errorf("Hostname(%q) = %q, want a hash starting with host-", "node01", got)
+	}
+	if again := a.Hostname("NODE01"); again != got {
+		t.Errorf("Hostname(%q) = %q, want %q as for node01", "NODE01", again, got)
+	}
+	for _, host := range []string{"", "localhost", "x1000c1s7b0n0", "x1000c1s7b0"} {
+		if out := a.Hostname(host); out != host {
+			t.Errorf("Hostname(%q) = %q, want it unchanged", host, out)
+		}
+	}
+	if out := a.Hostname("172.16.0.1"); out != a.IP("172.16.0.1") {
+		t.Errorf("Hostname(%q) = %q, want it anonymized as an IP address", "172.16.0.1", out)
+	}
+}
+
+func TestText(t *testing.T) {
+	a := New([]byte("key"))
+	host := a.Hostname("node01")
+	in := "console=tty0 ip=172.16.0.1 mac=de:ca:fc:0f:ee:ee host=node01 url=https://node01.example.com:8443/path lo=127.0.0.1 xname=x1000c1s7b0n0"
+	got := a.Text(in)
+	for _, want := range []string{
+		"console=tty0",
+		"ip=" + a.IP("172.16.0.1"),
+		"mac=" + a.MAC("de:ca:fc:0f:ee:ee"),
+		"host=" + host,
+		"url=https://" + a.Hostname("node01.example.com") + ":8443/path",
+		"lo=127.0.0.1",
+		"xname=x1000c1s7b0n0",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Text(%q) = %q, which does not contain %q", in, got, want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	a := New([]byte("key"))
+	in := `{
+		"Components": [{"ID": "x1000c1s7b0n0", "Type": "Node"}],
+		"EthernetInterfaces": [{
+			"ID": "decafc0feeee",
+			"ComponentID": "x1000c1s7b0n0",
+			"Description": "Interface 0 for node01",
+			"MACAddress": "de:ca:fc:0f:ee:ee",
+			"IPAddresses": [{"IPAddress": "172.16.0.1"}]
+		}],
+		"Hosts": [{"Hostname": "node01", "Address": "::1", "Count": 3}]
+	}`
+	out, err := a.JSON([]byte(in))
+	if err != nil {
+		t.Fatalf("JSON failed: %v", err)
+	}
+
+	var v struct {
+		Components         []map[string]string
+		EthernetInterfaces []struct {
+			ID          string
+			ComponentID string
+			Description string
+			MACAddress  string
+			IPAddresses []map[string]string
+		}
+		Hosts []struct {
+			Hostname string
+			Address  string
+			Count    int
+		}
+	}
+	if err := json.Unmarshal(out, &v); err != nil {
+		t.Fatalf("anonymized JSON is not valid: %v\n%s", err, out)
+	}
+
+	if id := v.Components[0]["ID"]; id != "x1000c1s7b0n0" {
+		t.Errorf("component ID is %q, want the xname kept", id)
+	}
+	ei := v.EthernetInterfaces[0]
+	if ei.ComponentID != "x1000c1s7b0n0" {
+		t.Errorf("component ID of interface is %q, want the xname kept", ei.ComponentID)
+	}
+	mac := a.MAC("de:ca:fc:0f:ee:ee")
+	if ei.MACAddress != mac || ei.ID != strings.ReplaceAll(mac, ":", "") {
+		t.Errorf("interface has ID %q and MAC address %q, want both anonymized as %q", ei.ID, ei.MACAddress, mac)
+	}
+	if ip := ei.IPAddresses[0]["IPAddress"]; ip != a.IP("172.16.0.1") {
+		t.Errorf("interface has IP address %q, want %q", ip, a.IP("172.16.0.1"))
+	}
+	host := a.Hostname("node01")
+	if want := "Interface 0 for " + host; ei.Description != want {
+		t.Errorf("interface has description %q, want %q with the hostname learned later", ei.Description, want)
+	}
+	h := v.Hosts[0]
+	if h.Hostname != host || h.Address != "::1" || h.Count != 3 {
+		t.Errorf("got host %+v, want hostname %q, loopback address, and count kept", h, host)
+	}
+
+	if _, err := a.JSON([]byte("not json")); err == nil {
+		t.Error("JSON succeeded for invalid data")
+	}
+}
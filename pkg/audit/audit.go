@@ -0,0 +1,227 @@
+// Package audit checks that the data OpenCHAMI services hold about the same
+// nodes is consistent across services, e.g. that every node in SMD can boot
+// from BSS.
+package audit
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/OpenCHAMI/bss/pkg/bssTypes"
+	"github.com/OpenCHAMI/cloud-init/pkg/citypes"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+)
+
+// Severities of findings, from most to least severe.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Checks that findings can come from.
+const (
+	CheckBootParams = "boot-params"
+	CheckBSSMAC     = "bss-mac"
+	CheckCloudInit  = "cloud-init"
+)
+
+// bssDefaultHost is the host that BSS falls back to for nodes that have no
+// boot parameters of their own or for their role.
+const bssDefaultHost = "Default"
+
+// Finding is a single inconsistency. ID identifies what it is about (e.g. an
+// xname or a MAC address).
+type Finding struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	ID       string `json:"id"`
+	Message  string `json:"message"`
+}
+
+// Report is the result of an audit. Counts holds the number of findings of
+// each severity.
+type Report struct {
+	Nodes    int            `json:"nodes"`
+	Findings []Finding      `json:"findings"`
+	Counts   map[string]int `json:"counts"`
+}
+
+// Empty reports whether r contains no findings.
+func (r Report) Empty() bool {
+	return len(r.Findings) == 0
+}
+
+func (r *Report) add(severity, check, id, message string) {
+	r.Findings = append(r.Findings, Finding{Severity: severity, Check: check, ID: id, Message: message})
+	r.Counts[severity]++
+}
+
+// BootData is the data that Boot checks.
+type BootData struct {
+	Components         []smd.Component
+	EthernetInterfaces []smd.EthernetInterface
+	BootParams         []bssTypes.BootParams
+
+	// CloudInitConfigs are the configs in cloud-init, keyed by name, as
+	// cloud-init returns them. If nil, cloud-init is not checked.
+	CloudInitConfigs map[string]citypes.CI
+}
+
+// Boot checks that every enabled Node component in d has BSS boot parameters,
+// matched by xname, MAC address (of its ethernet interfaces), or NID; that
+// every MAC address in BSS boot parameters is that of an ethernet interface;
+// and, if d.CloudInitConfigs is not nil, that every node that is expected to
+// use cloud-init has a cloud-init config named after its xname or one of its
+// MAC addresses. A node is expected to use cloud-init if requireCloudInit is
+// true or if its kernel parameters use the nocloud datasource (see
+// UsesCloudInit).
+//
+// A node without boot parameters of its own that would still boot with
+// the boot parameters of its role or the Default boot parameters yields a
+// warning, and one that would not boot at all yields an error.
+func Boot(d BootData, requireCloudInit bool) Report {
+	r := Report{Counts: make(map[string]int)}
+
+	macsOf := make(map[string][]string)
+	smdMACs := make(map[string]bool)
+	for _, ei := range d.EthernetInterfaces {
+		if ei.MACAddress == "" {
+			continue
+		}
+		mac := normalizeMAC(ei.MACAddress)
+		smdMACs[mac] = true
+		if ei.ComponentID != "" {
+			id := strings.ToLower(ei.ComponentID)
+			macsOf[id] = append(macsOf[id], mac)
+		}
+	}
+
+	// Index boot parameters by each of the ways BSS matches them.
+	byHost := make(map[string]*bssTypes.BootParams)
+	byMAC := make(map[string]*bssTypes.BootParams)
+	bssMACs := make(map[string]string)
+	byNID := make(map[int32]*bssTypes.BootParams)
+	for i := range d.BootParams {
+		bp := &d.BootParams[i]
+		for _, h := range bp.Hosts {
+			byHost[strings.ToLower(h)] = bp
+		}
+		for _, m := range bp.Macs {
+			byMAC[normalizeMAC(m)] = bp
+			bssMACs[normalizeMAC(m)] = m
+		}
+		for _, n := range bp.Nids {
+			byNID[n] = bp
+		}
+	}
+
+	var configs map[string]bool
+	if d.CloudInitConfigs != nil {
+		configs = make(map[string]bool, len(d.CloudInitConfigs))
+		for name := range d.CloudInitConfigs {
+			// Names are normalized like MAC addresses, which leaves
+			// xnames untouched apart from case.
+			configs[normalizeMAC(name)] = true
+		}
+	}
+
+	for _, c := range d.Components {
+		if !strings.EqualFold(c.Type, "Node") || (c.Enabled != nil && !*c.Enabled) {
+			continue
+		}
+		r.Nodes++
+		id := strings.ToLower(c.ID)
+
+		// Find the node's boot parameters the way BSS would.
+		bp := byHost[id]
+		for _, m := range macsOf[id] {
+			if bp != nil {
+				break
+			}
+			bp = byMAC[m]
+		}
+		if bp == nil && c.NID != 0 {
+			bp = byNID[int32(c.NID)]
+		}
+		if bp == nil && c.Role != "" {
+			if bp = byHost[strings.ToLower(c.Role)]; bp != nil {
+				r.add(SeverityWarning, CheckBootParams, c.ID, "no boot parameters by xname, MAC address, or NID; falls back to boot parameters of role "+c.Role)
+			}
+		}
+		if bp == nil {
+			if bp = byHost[strings.ToLower(bssDefaultHost)]; bp != nil {
+				r.add(SeverityWarning, CheckBootParams, c.ID, "no boot parameters by xname, MAC address, or NID; falls back to Default boot parameters")
+			} else {
+				r.add(SeverityError, CheckBootParams, c.ID, "no boot parameters by xname, MAC address, or NID, and no Default boot parameters")
+			}
+		}
+
+		if configs == nil || (!requireCloudInit && (bp == nil || !UsesCloudInit(bp.Params))) {
+			continue
+		}
+		found := configs[id]
+		for _, m := range macsOf[id] {
+			found = found || configs[m]
+		}
+		if !found {
+			r.add(SeverityError, CheckCloudInit, c.ID, "no cloud-init config named after xname or MAC address")
+		}
+	}
+
+	var unknownMACs []string
+	for mac, orig := range bssMACs {
+		if !smdMACs[mac] {
+			unknownMACs = append(unknownMACs, orig)
+		}
+	}
+	slices.Sort(unknownMACs)
+	for _, mac := range unknownMACs {
+		r.add(SeverityWarning, CheckBSSMAC, mac, "MAC address in BSS boot parameters is not an ethernet interface in SMD")
+	}
+
+	slices.SortStableFunc(r.Findings, func(a, b Finding) int {
+		if a.Severity != b.Severity {
+			return severityRank(a.Severity) - severityRank(b.Severity)
+		}
+		if a.Check != b.Check {
+			return strings.Compare(a.Check, b.Check)
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return r
+}
+
+// UsesCloudInit reports whether the kernel parameters params configure
+// cloud-init to fetch its config from a server, i.e. use the nocloud
+// datasource.
+func UsesCloudInit(params string) bool {
+	for _, p := range strings.Fields(params) {
+		if strings.HasPrefix(p, "ds=nocloud") {
+			return true
+		}
+	}
+	return false
+}
+
+func severityRank(s string) int {
+	switch s {
+	case SeverityError:
+		return 0
+	case SeverityWarning:
+		return 1
+	}
+	return 2
+}
+
+// normalizeMAC returns mac in lower case without separators so that MAC
+// addresses written differently can be compared.
+func normalizeMAC(mac string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '-', '.':
+			return -1
+		}
+		return r
+	}, strings.ToLower(mac))
+}
@@ -3,6 +3,7 @@ package bss
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"path"
 
 	"github.com/OpenCHAMI/bss/pkg/bssTypes"
@@ -178,6 +179,12 @@ func (bc *BSSClient) GetBootParams(query, token string) (client.HTTPEnvelope, er
 	return henv, err
 }
 
+// GetBootParamsValues is like GetBootParams, except that the query is passed as values that
+// are encoded according to the client's QueryEncoding, e.g. to repeat a key.
+func (bc *BSSClient) GetBootParamsValues(query url.Values, token string) (client.HTTPEnvelope, error) {
+	return bc.GetBootParams(bc.QueryEncoding.Encode(query), token)
+}
+
 // GetBootScript is a wrapper function around OchamiClient.GetData that takes a
 // query string (without the "?") and passes it to OchamiClient.GetData, using
 // /bootscript as the API endpoint.
@@ -190,6 +197,12 @@ func (bc *BSSClient) GetBootScript(query string) (client.HTTPEnvelope, error) {
 	return henv, err
 }
 
+// GetBootScriptValues is like GetBootScript, except that the query is passed as values that
+// are encoded according to the client's QueryEncoding, e.g. to repeat a key.
+func (bc *BSSClient) GetBootScriptValues(query url.Values) (client.HTTPEnvelope, error) {
+	return bc.GetBootScript(bc.QueryEncoding.Encode(query))
+}
+
 // GetStatus is a wrapper function around OchamiClient.GetData that takes an
 // optional component and uses it to determine which subpath of the BSS /service
 // endpoint to query. If empty, the /service/status endpoint is queried.
@@ -251,6 +264,12 @@ func (bc *BSSClient) GetEndpointHistory(query string) (client.HTTPEnvelope, erro
 	return henv, err
 }
 
+// GetEndpointHistoryValues is like GetEndpointHistory, except that the query is passed as values that
+// are encoded according to the client's QueryEncoding, e.g. to repeat a key.
+func (bc *BSSClient) GetEndpointHistoryValues(query url.Values) (client.HTTPEnvelope, error) {
+	return bc.GetEndpointHistory(bc.QueryEncoding.Encode(query))
+}
+
 // GetHosts is a wrapper function around OchamiClient.GetData that queries /hosts
 // and appends an optional query string (without the "?").
 func (bc *BSSClient) GetHosts(query string) (client.HTTPEnvelope, error) {
@@ -261,3 +280,39 @@ func (bc *BSSClient) GetHosts(query string) (client.HTTPEnvelope, error) {
 
 	return henv, err
 }
+
+// GetHostsValues is like GetHosts, except that the query is passed as values that
+// are encoded according to the client's QueryEncoding, e.g. to repeat a key.
+func (bc *BSSClient) GetHostsValues(query url.Values) (client.HTTPEnvelope, error) {
+	return bc.GetHosts(bc.QueryEncoding.Encode(query))
+}
+
+// GetEndpointAccess is like GetEndpointHistory, except that it takes a token,
+// which is set as the authorization bearer in the headers if not empty, and
+// returns the endpoint accesses in the response instead of the raw response.
+// Each access records the last time a component (by name) fetched a BSS
+// endpoint, e.g. its boot script.
+func (bc *BSSClient) GetEndpointAccess(query, token string) ([]bssTypes.EndpointAccess, error) {
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return nil, fmt.Errorf("GetEndpointAccess(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	henv, err := bc.GetData(BSSRelpathEndpointHistory, query, headers)
+	if err != nil {
+		return nil, fmt.Errorf("GetEndpointAccess(): error getting endpoint accesses: %w", err)
+	}
+	var accesses []bssTypes.EndpointAccess
+	if err := json.Unmarshal(henv.Body, &accesses); err != nil {
+		return nil, fmt.Errorf("GetEndpointAccess(): failed to unmarshal endpoint accesses: %w", err)
+	}
+
+	return accesses, nil
+}
+
+// GetEndpointAccessValues is like GetEndpointAccess, except that the query is passed as values that
+// are encoded according to the client's QueryEncoding, e.g. to repeat a key.
+func (bc *BSSClient) GetEndpointAccessValues(query url.Values, token string) ([]bssTypes.EndpointAccess, error) {
+	return bc.GetEndpointAccess(bc.QueryEncoding.Encode(query), token)
+}
@@ -0,0 +1,177 @@
+package bss
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/OpenCHAMI/bss/pkg/bssTypes"
+	"github.com/OpenCHAMI/ochami/pkg/kparams"
+)
+
+// BootParamsBuilder builds a bssTypes.BootParams while validating it. Its
+// methods can be chained, e.g.:
+//
+//	bp, err := bss.NewBootParams().
+//		WithHosts("x1000c1s7b0n0").
+//		WithKernel("https://example.com/kernel").
+//		WithParamsAppend("console=ttyS0").
+//		Build()
+//
+// Errors from individual methods are collected and returned by Build or
+// BuildSelector, so that all problems are reported at once.
+type BootParamsBuilder struct {
+	bp   bssTypes.BootParams
+	errs []error
+}
+
+// NewBootParams returns a pointer to a new, empty BootParamsBuilder.
+func NewBootParams() *BootParamsBuilder {
+	return &BootParamsBuilder{}
+}
+
+// FromBootParams returns a pointer to a new BootParamsBuilder starting with
+// the contents of bp, e.g. from a payload file, so that they can be amended
+// before being validated.
+func FromBootParams(bp bssTypes.BootParams) *BootParamsBuilder {
+	return &BootParamsBuilder{bp: bp}
+}
+
+// WithHosts sets the xnames that the boot parameters are for, replacing any
+// previously set.
+func (b *BootParamsBuilder) WithHosts(hosts ...string) *BootParamsBuilder {
+	for _, h := range hosts {
+		if h == "" {
+			b.errs = append(b.errs, fmt.Errorf("host cannot be empty"))
+		}
+	}
+	b.bp.Hosts = hosts
+	return b
+}
+
+// WithMacs sets the MAC addresses that the boot parameters are for, replacing
+// any previously set.
+func (b *BootParamsBuilder) WithMacs(macs ...string) *BootParamsBuilder {
+	b.bp.Macs = macs
+	return b
+}
+
+// WithNids sets the node IDs that the boot parameters are for, replacing any
+// previously set.
+func (b *BootParamsBuilder) WithNids(nids ...int32) *BootParamsBuilder {
+	b.bp.Nids = nids
+	return b
+}
+
+// WithKernel sets the URI of the kernel.
+func (b *BootParamsBuilder) WithKernel(uri string) *BootParamsBuilder {
+	if _, err := url.Parse(uri); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("invalid kernel URI: %w", err))
+	}
+	b.bp.Kernel = uri
+	return b
+}
+
+// WithInitrd sets the URI of the initrd/initramfs.
+func (b *BootParamsBuilder) WithInitrd(uri string) *BootParamsBuilder {
+	if _, err := url.Parse(uri); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("invalid initrd URI: %w", err))
+	}
+	b.bp.Initrd = uri
+	return b
+}
+
+// WithParams sets the kernel command line, replacing any previously set.
+func (b *BootParamsBuilder) WithParams(params string) *BootParamsBuilder {
+	b.bp.Params = params
+	return b
+}
+
+// WithParamsAppend appends the kernel parameters in each of params to the
+// kernel command line (see kparams.Cmdline.Add), skipping those that are
+// already present. Each of params can hold several parameters, e.g.
+// "console=ttyS0 quiet".
+func (b *BootParamsBuilder) WithParamsAppend(params ...string) *BootParamsBuilder {
+	c, err := kparams.Parse(b.bp.Params)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("invalid params: %w", err))
+		return b
+	}
+	for _, p := range params {
+		add, err := kparams.Parse(p)
+		if err != nil {
+			b.errs = append(b.errs, fmt.Errorf("invalid params to append: %w", err))
+			continue
+		}
+		for _, ap := range add.Params() {
+			c.Add(ap)
+		}
+	}
+	b.bp.Params = c.String()
+	return b
+}
+
+// Build validates the boot parameters and returns them. Besides the checks
+// done by BuildSelector, exactly one kind of selector (hosts, MACs, or NIDs)
+// and at least one of the kernel, initrd, or params must be set, as required
+// when adding, setting, or updating boot parameters.
+func (b *BootParamsBuilder) Build() (bssTypes.BootParams, error) {
+	bp, err := b.BuildSelector()
+	errs := []error{}
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(bp.Hosts) == 0 && len(bp.Macs) == 0 && len(bp.Nids) == 0 {
+		errs = append(errs, fmt.Errorf("one of hosts (xnames), MACs, or NIDs must be specified"))
+	}
+	if bp.Kernel == "" && bp.Initrd == "" && bp.Params == "" {
+		errs = append(errs, fmt.Errorf("at least one of kernel, initrd, or params must be specified"))
+	}
+
+	return bp, errors.Join(errs...)
+}
+
+// BuildSelector validates the boot parameters for use as a selector, as when
+// deleting boot parameters, and returns them. Hosts, MACs, and NIDs are
+// mutually exclusive, MAC addresses must be well-formed, and params must be a
+// valid kernel command line, but no fields are required beyond at least one
+// being set.
+func (b *BootParamsBuilder) BuildSelector() (bssTypes.BootParams, error) {
+	errs := append([]error{}, b.errs...)
+
+	var selectors []string
+	if len(b.bp.Hosts) > 0 {
+		selectors = append(selectors, "hosts (xnames)")
+	}
+	if len(b.bp.Macs) > 0 {
+		selectors = append(selectors, "MACs")
+		if err := b.bp.CheckMacs(); err != nil {
+			errs = append(errs, errors.New(strings.TrimSpace(err.Error())))
+		}
+	}
+	if len(b.bp.Nids) > 0 {
+		selectors = append(selectors, "NIDs")
+	}
+	if len(selectors) > 1 {
+		errs = append(errs, fmt.Errorf("only one of hosts (xnames), MACs, or NIDs can be specified, got %s", strings.Join(selectors, " and ")))
+	}
+	if err := CheckParams(b.bp.Params); err != nil {
+		errs = append(errs, err)
+	}
+	if len(selectors) == 0 && b.bp.Kernel == "" && b.bp.Initrd == "" && b.bp.Params == "" {
+		errs = append(errs, fmt.Errorf("boot parameters are empty"))
+	}
+
+	return b.bp, errors.Join(errs...)
+}
+
+// CheckParams checks that params is a syntactically valid kernel command line
+// (see kparams.Validate).
+func CheckParams(params string) error {
+	if err := kparams.Validate(params); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package bss
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/OpenCHAMI/bss/pkg/bssTypes"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/openapi"
+)
+
+// BSSRelpathOpenAPI is the path, relative to BSS's base path, that its OpenAPI
+// spec is fetched from by default.
+const BSSRelpathOpenAPI = "/openapi.json"
+
+// KnownAPI describes the BSS endpoints that BSSClient sends requests to and the
+// BSS schemas that it reads and writes, for comparison against BSS's live
+// OpenAPI spec.
+var KnownAPI = openapi.Known{
+	BasePath: basePathBSS,
+	Endpoints: []openapi.Endpoint{
+		{Method: http.MethodGet, Path: BSSRelpathBootParams},
+		{Method: http.MethodPost, Path: BSSRelpathBootParams},
+		{Method: http.MethodPut, Path: BSSRelpathBootParams},
+		{Method: http.MethodPatch, Path: BSSRelpathBootParams},
+		{Method: http.MethodDelete, Path: BSSRelpathBootParams},
+		{Method: http.MethodGet, Path: BSSRelpathBootScript},
+		{Method: http.MethodGet, Path: BSSRelpathService + "/status"},
+		{Method: http.MethodGet, Path: BSSRelpathService + "/status/all"},
+		{Method: http.MethodGet, Path: BSSRelpathService + "/storage/status"},
+		{Method: http.MethodGet, Path: BSSRelpathService + "/hsm"},
+		{Method: http.MethodGet, Path: BSSRelpathService + "/version"},
+		{Method: http.MethodGet, Path: BSSRelpathDumpState},
+		{Method: http.MethodGet, Path: BSSRelpathEndpointHistory},
+		{Method: http.MethodGet, Path: BSSRelpathHosts},
+	},
+	Schemas: map[string]any{
+		"BootParams": bssTypes.BootParams{},
+	},
+}
+
+// GetOpenAPI is a wrapper function around OchamiClient.GetData that fetches
+// the OpenAPI spec that BSS serves at BSSRelpathOpenAPI.
+func (bc *BSSClient) GetOpenAPI() (client.HTTPEnvelope, error) {
+	henv, err := bc.GetData(BSSRelpathOpenAPI, "", nil)
+	if err != nil {
+		err = fmt.Errorf("GetOpenAPI(): error getting BSS OpenAPI spec: %w", err)
+	}
+
+	return henv, err
+}
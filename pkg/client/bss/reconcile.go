@@ -0,0 +1,62 @@
+package bss
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/OpenCHAMI/bss/pkg/bssTypes"
+)
+
+// StaleHosts returns the hosts (xnames) that have boot parameters in existing
+// with the same kernel, initrd, and kernel parameters as bp, but are not among
+// the hosts of bp, sorted. These are e.g. the nodes that left the group whose
+// members bp was set for, which would otherwise keep booting what the group
+// boots. Hosts are compared regardless of case. The Default host is never
+// returned.
+func StaleHosts(existing []bssTypes.BootParams, bp bssTypes.BootParams) []string {
+	return staleOf(existing, bp, bp.Hosts, func(e bssTypes.BootParams) []string { return e.Hosts }, func(h string) string {
+		if strings.EqualFold(h, "Default") {
+			return ""
+		}
+		return strings.ToLower(h)
+	})
+}
+
+// StaleMACs is like StaleHosts, but returns MAC addresses that are not among
+// those of bp, compared regardless of case and separators.
+func StaleMACs(existing []bssTypes.BootParams, bp bssTypes.BootParams) []string {
+	return staleOf(existing, bp, bp.Macs, func(e bssTypes.BootParams) []string { return e.Macs }, normalizeMAC)
+}
+
+// staleOf returns the values returned by values for the boot parameters in
+// existing that are like bp, except those in want, sorted and without
+// duplicates. Values are compared by the keys returned by key, and those
+// whose key is empty are skipped.
+func staleOf(existing []bssTypes.BootParams, bp bssTypes.BootParams, want []string, values func(bssTypes.BootParams) []string, key func(string) string) []string {
+	wanted := make(map[string]bool, len(want))
+	for _, w := range want {
+		wanted[key(w)] = true
+	}
+	seen := make(map[string]bool)
+	var stale []string
+	for _, e := range existing {
+		if e.Kernel != bp.Kernel || e.Initrd != bp.Initrd || e.Params != bp.Params {
+			continue
+		}
+		for _, v := range values(e) {
+			k := key(v)
+			if k != "" && !wanted[k] && !seen[k] {
+				seen[k] = true
+				stale = append(stale, v)
+			}
+		}
+	}
+	sort.Strings(stale)
+
+	return stale
+}
+
+// normalizeMAC returns mac in lowercase without separators, for comparison.
+func normalizeMAC(mac string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "").Replace(mac))
+}
@@ -0,0 +1,144 @@
+package bss
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+)
+
+// Statuses of a dependency of BSS in a DependencyStatus.
+const (
+	DependencyConnected = "connected" // BSS reached the dependency
+	DependencyError     = "error"     // BSS failed to reach the dependency
+	DependencyUnknown   = "unknown"   // BSS did not report on the dependency
+)
+
+// serviceResponse mirrors the responses of BSS's /service endpoints, each of
+// which fills in the fields it reports on.
+type serviceResponse struct {
+	Version        string `json:"bss-version"`
+	Status         string `json:"bss-status"`
+	HSMStatus      string `json:"bss-status-hsm"`
+	StorageBackend *struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+	} `json:"bss-storage-backend"`
+}
+
+// DependencyStatus is BSS's view of its connection to a service it depends on.
+// Status is one of DependencyConnected, DependencyError, or DependencyUnknown.
+// Name is the name of the storage backend (e.g. postgres or etcd), if known,
+// and Message explains an error or unknown status.
+type DependencyStatus struct {
+	Status  string `json:"Status"`
+	Name    string `json:"Name,omitempty"`
+	Message string `json:"Message,omitempty"`
+}
+
+// StatusDetail is a summary of the state of BSS and of its connections to SMD
+// and to its storage backend, as reported by BSS itself. Running reports
+// whether BSS's /service/status endpoint succeeded, and Version is empty if
+// BSS did not report its version.
+type StatusDetail struct {
+	Running bool             `json:"Running"`
+	Message string           `json:"Message,omitempty"`
+	Version string           `json:"Version,omitempty"`
+	SMD     DependencyStatus `json:"SMD"`
+	Storage DependencyStatus `json:"Storage"`
+}
+
+// Healthy reports whether BSS is running and has not reported an error
+// reaching SMD or its storage backend.
+func (sd StatusDetail) Healthy() bool {
+	return sd.Running && sd.SMD.Status != DependencyError && sd.Storage.Status != DependencyError
+}
+
+// GetStatusDetail queries BSS's /service/status, /service/version,
+// /service/hsm, and /service/storage/status endpoints and returns what they
+// report as a StatusDetail. Only failing to reach BSS at all is an error. BSS
+// reporting that it cannot reach a dependency is reflected in the
+// StatusDetail, and endpoints that this version of BSS does not have leave
+// the status of the corresponding dependency unknown. The dependencies are
+// only queried if BSS is running.
+func (bc *BSSClient) GetStatusDetail() (StatusDetail, error) {
+	var sd StatusDetail
+
+	henv, err := bc.GetStatus("")
+	if err != nil {
+		if !errors.Is(err, client.UnsuccessfulHTTPError) {
+			return sd, fmt.Errorf("GetStatusDetail(): %w", err)
+		}
+		sd.Message = henv.Status
+	} else {
+		sd.Running = true
+	}
+
+	if henv, err := bc.GetStatus("version"); err != nil {
+		log.ClientLogger.Debug().Err(err).Msg("BSS did not report its version")
+	} else if resp, ok := parseServiceResponse(henv.Body); ok {
+		sd.Version = resp.Version
+	}
+
+	sd.SMD = DependencyStatus{Status: DependencyUnknown}
+	sd.Storage = DependencyStatus{Status: DependencyUnknown}
+	if !sd.Running {
+		return sd, nil
+	}
+
+	henv, err = bc.GetStatus("smd")
+	resp, ok := parseServiceResponse(henv.Body)
+	switch {
+	case ok && resp.HSMStatus != "":
+		sd.SMD.Status = resp.HSMStatus
+		if err != nil {
+			sd.SMD.Message = henv.Status
+		}
+	default:
+		sd.SMD.Message = dependencyMessage(henv, err)
+	}
+
+	henv, err = bc.GetStatus("storage")
+	resp, ok = parseServiceResponse(henv.Body)
+	switch {
+	case ok && resp.StorageBackend != nil:
+		sd.Storage.Status = resp.StorageBackend.Status
+		sd.Storage.Name = resp.StorageBackend.Name
+		if err != nil {
+			sd.Storage.Message = henv.Status
+		}
+	default:
+		sd.Storage.Message = dependencyMessage(henv, err)
+	}
+
+	return sd, nil
+}
+
+// parseServiceResponse unmarshals body, the body of a response of one of BSS's
+// /service endpoints, and reports whether it could.
+func parseServiceResponse(body client.HTTPBody) (serviceResponse, bool) {
+	var resp serviceResponse
+	if len(body) == 0 {
+		return resp, false
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return resp, false
+	}
+	return resp, true
+}
+
+// dependencyMessage returns why the status of a dependency is unknown, given
+// the response of the endpoint reporting on it and the error returned with
+// it, if any.
+func dependencyMessage(henv client.HTTPEnvelope, err error) string {
+	switch {
+	case henv.StatusCode == http.StatusNotFound:
+		return "not reported by this version of BSS"
+	case err != nil:
+		return err.Error()
+	}
+	return "unexpected response from BSS"
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const (
+	// DefaultReadConcurrency is the number of GET requests iterative client
+	// methods send at once if OchamiClient.Concurrency is not set.
+	DefaultReadConcurrency = 8
+
+	// DefaultWriteConcurrency is the number of requests that modify data
+	// that iterative client methods send at once if
+	// OchamiClient.Concurrency is not set. Writes are sent one at a time by
+	// default so that they reach the service in the order they were passed.
+	DefaultWriteConcurrency = 1
+)
+
+// ErrNotSent is wrapped by the error that BulkRequest returns for items that
+// it did not send a request for because BulkContext was done.
+var ErrNotSent = errors.New("request not sent")
+
+// BulkContext is checked by BulkRequest before each call. Once it is done (e.g.
+// canceled when the user interrupts the program), no further calls are made,
+// while calls already in flight are left to finish. It never expires by
+// default.
+//
+// Unlike the settings of OchamiClient, BulkContext applies to the whole
+// process: it is meant to be set once, by a command-line program, to the
+// context that its interrupt handler cancels. Programs that need to stop the
+// requests of some clients but not others should cancel the contexts of the
+// requests themselves instead.
+var BulkContext = context.Background()
+
+// BulkConcurrency returns the number of requests using the HTTP method method
+// that iterative methods of oc should send at once. If oc.Concurrency is set,
+// it is used. Otherwise, DefaultReadConcurrency is used for GET requests and
+// DefaultWriteConcurrency for all others.
+func (oc *OchamiClient) BulkConcurrency(method string) int {
+	if oc.Concurrency > 0 {
+		return oc.Concurrency
+	}
+	if method == http.MethodGet {
+		return DefaultReadConcurrency
+	}
+	return DefaultWriteConcurrency
+}
+
+// BulkRequest calls do once for each of items and returns the HTTPEnvelope
+// and error of each call. The returned slices always have the same length as
+// items and the HTTPEnvelope and error at an index belong to the item at the
+// same index, whether or not any of the calls failed. If do fails before
+// sending a request, it should return an empty HTTPEnvelope along with the
+// error.
+//
+// Up to concurrency calls are in flight at once. If concurrency is less than 2,
+// items are processed one at a time in order.
+//
+// If BulkContext is done before the call for an item is made, the call is
+// skipped and the item's error wraps ErrNotSent.
+func BulkRequest[T any](items []T, concurrency int, do func(T) (HTTPEnvelope, error)) ([]HTTPEnvelope, []error) {
+	henvs := make([]HTTPEnvelope, len(items))
+	errs := make([]error, len(items))
+	ctx := BulkContext
+	notSent := func(from int) {
+		for i := from; i < len(items); i++ {
+			errs[i] = fmt.Errorf("%w: %w", ErrNotSent, context.Cause(ctx))
+		}
+	}
+
+	if concurrency < 2 {
+		for i, item := range items {
+			if ctx.Err() != nil {
+				notSent(i)
+				break
+			}
+			henvs[i], errs[i] = do(item)
+		}
+		return henvs, errs
+	}
+
+	// Each call writes only to its own index, so no locking is needed
+	// for the results
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, item := range items {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			notSent(i)
+			break
+		}
+		wg.Add(1)
+		go func(i int, item T) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			henvs[i], errs[i] = do(item)
+		}(i, item)
+	}
+	wg.Wait()
+
+	return henvs, errs
+}
@@ -3,6 +3,7 @@ package ci
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 
 	"github.com/OpenCHAMI/cloud-init/pkg/citypes"
@@ -108,7 +109,6 @@ func (cic *CloudInitClient) PostConfigs(data []citypes.CI, token string) ([]clie
 	var (
 		headers = client.NewHTTPHeaders()
 		henvs   []client.HTTPEnvelope
-		body    client.HTTPBody
 		errors  []error
 	)
 	if len(data) == 0 {
@@ -119,26 +119,21 @@ func (cic *CloudInitClient) PostConfigs(data []citypes.CI, token string) ([]clie
 			return henvs, errors, fmt.Errorf("PostConfigs(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, ciData := range data {
-		var err error
-		body, err = json.Marshal(ciData)
+	henvs, errors = client.BulkRequest(data, cic.BulkConcurrency(http.MethodPost), func(ciData citypes.CI) (client.HTTPEnvelope, error) {
+		body, err := json.Marshal(ciData)
 		if err != nil {
 			newErr := fmt.Errorf("PostConfigs(): failed to marshal open cloud-init data for %s: %w", ciData.Name, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := cic.PostData(cloudInitRelpathOpen, "", headers, body)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("PostConfigs(): error posting open cloud-init config %s: %w", ciData.Name, err)
-			log.Logger.Debug().Err(err).Msgf("failed to add open cloud-init config %s", ciData.Name)
-			errors = append(errors, newErr)
-			continue
+			log.ClientLogger.Debug().Err(err).Msgf("failed to add open cloud-init config %s", ciData.Name)
+			return henv, newErr
 		}
-		log.Logger.Debug().Msgf("successfully added open cloud-init config %s", ciData.Name)
-		errors = append(errors, nil)
-	}
+		log.ClientLogger.Debug().Msgf("successfully added open cloud-init config %s", ciData.Name)
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -149,7 +144,6 @@ func (cic *CloudInitClient) PostConfigsSecure(data []citypes.CI, token string) (
 	var (
 		headers = client.NewHTTPHeaders()
 		henvs   []client.HTTPEnvelope
-		body    client.HTTPBody
 		errors  []error
 	)
 	if len(data) == 0 {
@@ -160,26 +154,21 @@ func (cic *CloudInitClient) PostConfigsSecure(data []citypes.CI, token string) (
 			return henvs, errors, fmt.Errorf("PostConfigsSecure(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, ciData := range data {
-		var err error
-		body, err = json.Marshal(ciData)
+	henvs, errors = client.BulkRequest(data, cic.BulkConcurrency(http.MethodPost), func(ciData citypes.CI) (client.HTTPEnvelope, error) {
+		body, err := json.Marshal(ciData)
 		if err != nil {
 			newErr := fmt.Errorf("PostConfigsSecure(): failed to marshal secure cloud-init data for %s: %w", ciData.Name, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := cic.PostData(cloudInitRelpathSecure, "", headers, body)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("PostConfigsSecure(): error posting secure cloud-init config %s: %w", ciData.Name, err)
-			log.Logger.Debug().Err(err).Msgf("failed to add secure cloud-init config %s", ciData.Name)
-			errors = append(errors, newErr)
-			continue
+			log.ClientLogger.Debug().Err(err).Msgf("failed to add secure cloud-init config %s", ciData.Name)
+			return henv, newErr
 		}
-		log.Logger.Debug().Msgf("successfully added secure cloud-init config %s", ciData.Name)
-		errors = append(errors, nil)
-	}
+		log.ClientLogger.Debug().Msgf("successfully added secure cloud-init config %s", ciData.Name)
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -193,7 +182,6 @@ func (cic *CloudInitClient) PutConfigs(data []citypes.CI, token string) ([]clien
 	var (
 		headers = client.NewHTTPHeaders()
 		henvs   []client.HTTPEnvelope
-		body    client.HTTPBody
 		errors  []error
 	)
 	if len(data) == 0 {
@@ -204,38 +192,30 @@ func (cic *CloudInitClient) PutConfigs(data []citypes.CI, token string) ([]clien
 			return henvs, errors, fmt.Errorf("PutConfigs(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, ciData := range data {
+	henvs, errors = client.BulkRequest(data, cic.BulkConcurrency(http.MethodPut), func(ciData citypes.CI) (client.HTTPEnvelope, error) {
 		if ciData.Name == "" {
 			newErr := fmt.Errorf("PutConfigsSecure(): CI.Name field cannot be empty")
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		finalEP, err := url.JoinPath(cloudInitRelpathOpen, ciData.Name)
 		if err != nil {
 			newErr := fmt.Errorf("PutConfigs(): failed to join cloud-init open path (%s) with cloud-init config ID %s: %w", cloudInitRelpathOpen, ciData.Name, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
-		body, err = json.Marshal(ciData)
+		body, err := json.Marshal(ciData)
 		if err != nil {
 			newErr := fmt.Errorf("PutConfigs(): failed to marshal cloud-init data for %s: %w", ciData.Name, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := cic.PutData(finalEP, "", headers, body)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("PutConfigs(): error putting open cloud-init config %s: %w", ciData.Name, err)
-			log.Logger.Debug().Err(err).Msgf("failed to set open cloud-init config %s", ciData.Name)
-			errors = append(errors, newErr)
-			continue
+			log.ClientLogger.Debug().Err(err).Msgf("failed to set open cloud-init config %s", ciData.Name)
+			return henv, newErr
 		}
-		log.Logger.Debug().Msgf("successfully set open cloud-init config %s", ciData.Name)
-		errors = append(errors, nil)
-	}
+		log.ClientLogger.Debug().Msgf("successfully set open cloud-init config %s", ciData.Name)
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -246,7 +226,6 @@ func (cic *CloudInitClient) PutConfigsSecure(data []citypes.CI, token string) ([
 	var (
 		headers = client.NewHTTPHeaders()
 		henvs   []client.HTTPEnvelope
-		body    client.HTTPBody
 		errors  []error
 	)
 	if len(data) == 0 {
@@ -257,38 +236,30 @@ func (cic *CloudInitClient) PutConfigsSecure(data []citypes.CI, token string) ([
 			return henvs, errors, fmt.Errorf("PutConfigsSecure(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, ciData := range data {
+	henvs, errors = client.BulkRequest(data, cic.BulkConcurrency(http.MethodPut), func(ciData citypes.CI) (client.HTTPEnvelope, error) {
 		if ciData.Name == "" {
 			newErr := fmt.Errorf("PutConfigsSecure(): CI.Name field cannot be empty")
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		finalEP, err := url.JoinPath(cloudInitRelpathSecure, ciData.Name)
 		if err != nil {
 			newErr := fmt.Errorf("PutConfigs(): failed to join cloud-init secure path (%s) with cloud-init config ID %s: %w", cloudInitRelpathSecure, ciData.Name, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
-		body, err = json.Marshal(ciData)
+		body, err := json.Marshal(ciData)
 		if err != nil {
 			newErr := fmt.Errorf("PutConfigsSecure(): failed to marshal secure cloud-init data for %s: %w", ciData.Name, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := cic.PutData(finalEP, "", headers, body)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("PutConfigsSecure(): error putting secure cloud-init config %s: %w", ciData.Name, err)
-			log.Logger.Debug().Err(err).Msgf("failed to set secure cloud-init config %s", ciData.Name)
-			errors = append(errors, newErr)
-			continue
+			log.ClientLogger.Debug().Err(err).Msgf("failed to set secure cloud-init config %s", ciData.Name)
+			return henv, newErr
 		}
-		log.Logger.Debug().Msgf("successfully set secure cloud-init config %s", ciData.Name)
-		errors = append(errors, nil)
-	}
+		log.ClientLogger.Debug().Msgf("successfully set secure cloud-init config %s", ciData.Name)
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -310,25 +281,21 @@ func (cic *CloudInitClient) DeleteConfigs(token string, ids ...string) ([]client
 			return henvs, errors, fmt.Errorf("DeleteConfigs(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, id := range ids {
+	henvs, errors = client.BulkRequest(ids, cic.BulkConcurrency(http.MethodDelete), func(id string) (client.HTTPEnvelope, error) {
 		finalEP, err := url.JoinPath(cloudInitRelpathOpen, id)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteConfigs(): failed to join cloud-init open path (%s) with cloud-init config ID %s: %w", cloudInitRelpathOpen, id, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := cic.DeleteData(finalEP, "", headers, nil)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteConfigs(): failed to DELETE cloud-init config %s: %w", id, err)
-			log.Logger.Debug().Err(err).Msgf("failed to delete cloud-init config %s", id)
-			errors = append(errors, newErr)
-			continue
+			log.ClientLogger.Debug().Err(err).Msgf("failed to delete cloud-init config %s", id)
+			return henv, newErr
 		}
-		log.Logger.Debug().Msgf("successfully deleted cloud-init config %s", id)
-		errors = append(errors, nil)
-	}
+		log.ClientLogger.Debug().Msgf("successfully deleted cloud-init config %s", id)
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -349,25 +316,21 @@ func (cic *CloudInitClient) DeleteConfigsSecure(token string, ids ...string) ([]
 			return henvs, errors, fmt.Errorf("DeleteConfigsSecure(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, id := range ids {
+	henvs, errors = client.BulkRequest(ids, cic.BulkConcurrency(http.MethodDelete), func(id string) (client.HTTPEnvelope, error) {
 		finalEP, err := url.JoinPath(cloudInitRelpathSecure, id)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteConfigsSecure(): failed to join cloud-init secure path (%s) with cloud-init config ID %s: %w", cloudInitRelpathSecure, id, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := cic.DeleteData(finalEP, "", headers, nil)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteConfigsSecure(): failed to DELETE cloud-init config %s: %w", id, err)
-			log.Logger.Debug().Err(err).Msgf("failed to delete cloud-init config %s", id)
-			errors = append(errors, newErr)
-			continue
+			log.ClientLogger.Debug().Err(err).Msgf("failed to delete cloud-init config %s", id)
+			return henv, newErr
 		}
-		log.Logger.Debug().Msgf("successfully deleted cloud-init config %s", id)
-		errors = append(errors, nil)
-	}
+		log.ClientLogger.Debug().Msgf("successfully deleted cloud-init config %s", id)
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -388,25 +351,21 @@ func (cic *CloudInitClient) GetCloudInitData(typ CIDataType, ids []string) ([]cl
 	if len(ids) == 0 {
 		return henvs, errors, fmt.Errorf("GetCloudInitData(%s): no ids passed", typ)
 	}
-	for _, id := range ids {
+	henvs, errors = client.BulkRequest(ids, cic.BulkConcurrency(http.MethodGet), func(id string) (client.HTTPEnvelope, error) {
 		finalEP, err := url.JoinPath(cloudInitRelpathOpen, id, string(typ))
 		if err != nil {
 			newErr := fmt.Errorf("GetCloudInitData(%s): failed to join cloud-init open path (%s) with cloud-init config ID: %s: %w", typ, cloudInitRelpathOpen, id, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := cic.GetData(finalEP, "", headers)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("GetCloudInitData(%s): failed to get cloud-init data for %s: %w", typ, id, err)
-			log.Logger.Debug().Err(err).Msgf("failed to get cloud-init %s for %s", typ, id)
-			errors = append(errors, newErr)
-			continue
+			log.ClientLogger.Debug().Err(err).Msgf("failed to get cloud-init %s for %s", typ, id)
+			return henv, newErr
 		}
-		log.Logger.Debug().Msgf("successfully got cloud-init %s for %s", typ, id)
-		errors = append(errors, nil)
-	}
+		log.ClientLogger.Debug().Msgf("successfully got cloud-init %s for %s", typ, id)
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -427,25 +386,21 @@ func (cic *CloudInitClient) GetCloudInitDataSecure(typ CIDataType, ids []string,
 			return henvs, errors, fmt.Errorf("GetCloudInitDataSecure(%s): error setting token in HTTP headers: %w", typ, err)
 		}
 	}
-	for _, id := range ids {
+	henvs, errors = client.BulkRequest(ids, cic.BulkConcurrency(http.MethodGet), func(id string) (client.HTTPEnvelope, error) {
 		finalEP, err := url.JoinPath(cloudInitRelpathSecure, id, string(typ))
 		if err != nil {
 			newErr := fmt.Errorf("GetCloudInitDataSecure(%s): failed to join cloud-init secure path (%s) with cloud-init config ID: %s: %w", typ, cloudInitRelpathSecure, id, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := cic.GetData(finalEP, "", headers)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("GetCloudInitDataSecure(%s): failed to get cloud-init data for %s: %w", typ, id, err)
-			log.Logger.Debug().Err(err).Msgf("failed to get cloud-init %s for %s", typ, id)
-			errors = append(errors, newErr)
-			continue
+			log.ClientLogger.Debug().Err(err).Msgf("failed to get cloud-init %s for %s", typ, id)
+			return henv, newErr
 		}
-		log.Logger.Debug().Msgf("successfully got cloud-init %s for %s", typ, id)
-		errors = append(errors, nil)
-	}
+		log.ClientLogger.Debug().Msgf("successfully got cloud-init %s for %s", typ, id)
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
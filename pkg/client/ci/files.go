@@ -0,0 +1,115 @@
+package ci
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/OpenCHAMI/cloud-init/pkg/citypes"
+	"gopkg.in/yaml.v3"
+)
+
+// cloudConfigHeader is the first line of user-data files written by WriteDir
+// so that they are recognized as cloud-config by editors and linters.
+const cloudConfigHeader = "#cloud-config\n"
+
+// WriteDir writes the user-data, meta-data, and vendor-data of each config in
+// data to its own subdirectory of dir named after the config, creating
+// directories as needed. Each type of data is stored as a YAML file named after
+// the type (e.g. <dir>/compute/user-data.yaml) so that it can be edited by hand
+// and kept in version control. Types of data that are empty are not written and
+// any existing file for them is removed, so that a subsequent ReadDir yields
+// the same config.
+func WriteDir(dir string, data []citypes.CI) error {
+	for _, c := range data {
+		if c.Name == "" {
+			return fmt.Errorf("WriteDir(): CI.Name field cannot be empty")
+		}
+		cDir := filepath.Join(dir, c.Name)
+		if err := os.MkdirAll(cDir, 0755); err != nil {
+			return fmt.Errorf("WriteDir(): failed to create directory for config %s: %w", c.Name, err)
+		}
+		files := map[CIDataType]map[string]interface{}{
+			CloudInitUserData:   c.CIData.UserData,
+			CloudInitMetaData:   c.CIData.MetaData,
+			CloudInitVendorData: c.CIData.VendorData,
+		}
+		for typ, d := range files {
+			fPath := filepath.Join(cDir, string(typ)+".yaml")
+			if len(d) == 0 {
+				if err := os.Remove(fPath); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("WriteDir(): failed to remove stale %s for config %s: %w", typ, c.Name, err)
+				}
+				continue
+			}
+			var buf bytes.Buffer
+			if typ == CloudInitUserData {
+				buf.WriteString(cloudConfigHeader)
+			}
+			enc := yaml.NewEncoder(&buf)
+			enc.SetIndent(2)
+			if err := enc.Encode(d); err != nil {
+				return fmt.Errorf("WriteDir(): failed to marshal %s for config %s: %w", typ, c.Name, err)
+			}
+			if err := enc.Close(); err != nil {
+				return fmt.Errorf("WriteDir(): failed to marshal %s for config %s: %w", typ, c.Name, err)
+			}
+			if err := os.WriteFile(fPath, buf.Bytes(), 0644); err != nil {
+				return fmt.Errorf("WriteDir(): failed to write %s for config %s: %w", typ, c.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReadDir is the inverse of WriteDir. It reads each subdirectory of dir as a
+// config named after the subdirectory and returns the configs. If names are
+// passed, only the subdirectories with those names are read and it is an error
+// for any of them to be missing. Missing data files are treated as empty data.
+func ReadDir(dir string, names ...string) ([]citypes.CI, error) {
+	var data []citypes.CI
+	if len(names) == 0 {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDir(): failed to read directory %s: %w", dir, err)
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				names = append(names, e.Name())
+			}
+		}
+	}
+	for _, name := range names {
+		cDir := filepath.Join(dir, name)
+		if fi, err := os.Stat(cDir); err != nil {
+			return nil, fmt.Errorf("ReadDir(): failed to read config %s: %w", name, err)
+		} else if !fi.IsDir() {
+			return nil, fmt.Errorf("ReadDir(): %s is not a directory", cDir)
+		}
+		c := citypes.CI{Name: name}
+		files := map[CIDataType]*map[string]interface{}{
+			CloudInitUserData:   &c.CIData.UserData,
+			CloudInitMetaData:   &c.CIData.MetaData,
+			CloudInitVendorData: &c.CIData.VendorData,
+		}
+		for typ, d := range files {
+			fBytes, err := os.ReadFile(filepath.Join(cDir, string(typ)+".yaml"))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, fmt.Errorf("ReadDir(): failed to read %s for config %s: %w", typ, name, err)
+			}
+			// The #cloud-config header is a YAML comment, so it is
+			// ignored here.
+			if err := yaml.Unmarshal(fBytes, d); err != nil {
+				return nil, fmt.Errorf("ReadDir(): failed to unmarshal %s for config %s: %w", typ, name, err)
+			}
+		}
+		data = append(data, c)
+	}
+
+	return data, nil
+}
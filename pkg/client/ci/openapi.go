@@ -0,0 +1,55 @@
+package ci
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/OpenCHAMI/cloud-init/pkg/citypes"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/openapi"
+)
+
+// CloudInitRelpathOpenAPI is the path, relative to cloud-init's base path,
+// that its OpenAPI spec is fetched from by default.
+const CloudInitRelpathOpenAPI = cloudInitRelpathOpen + "/openapi.json"
+
+// KnownAPI describes the cloud-init endpoints that CloudInitClient sends
+// requests to and the cloud-init schemas that it reads and writes, for
+// comparison against cloud-init's live OpenAPI spec.
+var KnownAPI = openapi.Known{
+	BasePath:  basePathCloudInit,
+	Endpoints: knownEndpoints(),
+	Schemas: map[string]any{
+		"citypes.CI": citypes.CI{},
+	},
+}
+
+// knownEndpoints returns the endpoints used by CloudInitClient, which are the
+// same for the open and secure endpoints.
+func knownEndpoints() []openapi.Endpoint {
+	var eps []openapi.Endpoint
+	for _, base := range []string{cloudInitRelpathOpen, cloudInitRelpathSecure} {
+		eps = append(eps,
+			openapi.Endpoint{Method: http.MethodGet, Path: base},
+			openapi.Endpoint{Method: http.MethodPost, Path: base},
+			openapi.Endpoint{Method: http.MethodGet, Path: base + "/{id}"},
+			openapi.Endpoint{Method: http.MethodPut, Path: base + "/{id}"},
+			openapi.Endpoint{Method: http.MethodDelete, Path: base + "/{id}"},
+		)
+		for _, typ := range []CIDataType{CloudInitUserData, CloudInitMetaData, CloudInitVendorData} {
+			eps = append(eps, openapi.Endpoint{Method: http.MethodGet, Path: base + "/{id}/" + string(typ)})
+		}
+	}
+	return eps
+}
+
+// GetOpenAPI is a wrapper function around OchamiClient.GetData that fetches
+// the OpenAPI spec that cloud-init serves at CloudInitRelpathOpenAPI.
+func (cic *CloudInitClient) GetOpenAPI() (client.HTTPEnvelope, error) {
+	henv, err := cic.GetData(CloudInitRelpathOpenAPI, "", nil)
+	if err != nil {
+		err = fmt.Errorf("GetOpenAPI(): error getting cloud-init OpenAPI spec: %w", err)
+	}
+
+	return henv, err
+}
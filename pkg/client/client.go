@@ -2,8 +2,8 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"gopkg.in/yaml.v3"
@@ -13,7 +13,9 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	oio "github.com/OpenCHAMI/ochami/internal/io"
@@ -29,6 +31,10 @@ var (
 	responseHeaderTimeout = 120 * time.Second
 )
 
+// IfMatchAuto is the value of OchamiClient.IfMatch that causes the ETag for
+// each PUT or PATCH to be fetched with a GET to the same endpoint beforehand.
+const IfMatchAuto = "auto"
+
 // OchamiClient is an *http.Client that contains metadata for OpenCHAMI services
 // being communicated with.
 type OchamiClient struct {
@@ -36,23 +42,84 @@ type OchamiClient struct {
 	BaseURI     *url.URL // Base URL for OpenCHAMI services (e.g. https://foobar.openchami.cluster)
 	BasePath    string   // Base path for the service (e.g. /boot/v1 for BSS)
 	ServiceName string   // Name of service being contacted (e.g. BSS)
-}
-
-// defaultClient creates an http.DefaultClient for its OchamiClient.
-func (oc *OchamiClient) defaultClient() {
-	oc.Client = http.DefaultClient
-}
 
-// defaultClientInsecure creates an http.DefaultClient for its OchamiClient and
-// configures it to not try to verify TLS certificates.
-func (oc *OchamiClient) defaultClientInsecure() {
-	oc.Client = http.DefaultClient
-	oc.Client.Transport = &http.Transport{
-		TLSClientConfig: &tls.Config{
-			// This default client does not verify server certificate
-			InsecureSkipVerify: true,
-		},
-	}
+	// IfMatch enables conditional writes. If set to IfMatchAuto, PUT and
+	// PATCH requests are preceded by a GET of the same endpoint and its
+	// ETag is sent in an If-Match header. If set to any other non-empty
+	// value, it is used as the ETag. If the resource was modified in the
+	// meantime, the request fails with an error wrapping
+	// PreconditionFailedError.
+	IfMatch string
+
+	// Concurrency is the maximum number of requests that iterative
+	// methods (those taking several items and returning a slice of
+	// HTTPEnvelopes) send at once. If less than 1, the defaults described
+	// by BulkConcurrency are used.
+	Concurrency int
+
+	// QueryEncoding is how query values passed to the *Values methods
+	// (e.g. GetDataValues) are encoded. The default is QueryEncodingForm.
+	QueryEncoding QueryEncoding
+
+	// CoalesceGets enables the coalescing of identical GET requests sent
+	// by GetData while one is already in flight, e.g. when completion
+	// functions, watch mode, and a command query the same endpoint at
+	// nearly the same time. Instead of sending another request, callers
+	// wait for the one in flight and get a copy of its response. Requests
+	// are identical if they have the same URI, headers, and tenant,
+	// whichever OchamiClient with CoalesceGets set sends them. Responses
+	// are not kept once the request completes, so a later GET is always
+	// sent.
+	CoalesceGets bool
+
+	// Plan, if not nil, intercepts the mutating requests (POST, PUT,
+	// PATCH, and DELETE) of the client. A recording plan records them
+	// instead of sending them, and an executing plan only sends those
+	// that are part of it. Other requests are always sent so that
+	// commands can look up what they need to plan. Several clients may
+	// share a plan.
+	Plan *Plan
+
+	// Tenant, if not empty, is the identifier of the tenant that requests
+	// are made on behalf of, for multi-tenant deployments. It is sent in
+	// the TenantHeader header of every request, unless the request sets
+	// that header itself (see ValidateTenant).
+	Tenant string
+
+	// TenantHeader is the header that Tenant is sent in. If empty,
+	// DefaultTenantHeader is used.
+	TenantHeader string
+
+	// CompressRequests enables gzip compression of request bodies of at
+	// least CompressMinSize bytes. The service must accept gzip-encoded
+	// requests (i.e. honor Content-Encoding: gzip), which is why it is not
+	// enabled by default.
+	CompressRequests bool
+
+	// CompatVersion, if not empty, is the version that the service is
+	// assumed to be when deciding which compatibility rules apply to
+	// payloads sent to it (see CompatRule). Otherwise, its version is
+	// fetched from its /service/version endpoint the first time a payload
+	// that a rule could apply to is sent.
+	CompatVersion string
+
+	// Failover, if not nil and BaseURI is one of its base URIs, makes
+	// requests to BaseURI fail over to its other base URIs when they
+	// cannot be sent (see FailoverGroup).
+	Failover *FailoverGroup
+
+	// transportConfig is the configuration of the client's shared
+	// transport (see SharedTransport).
+	transportConfig TransportConfig
+
+	// daemonSocket is the socket of the connection daemon that requests
+	// are sent through, if any (see UseDaemon).
+	daemonSocket string
+
+	// version is the version reported by the service, fetched once when
+	// compatibility rules need it (see CompatRule).
+	versionOnce sync.Once
+	version     string
 }
 
 // NewOchamiClient takes a baseURI and basePath and returns a pointer to a new
@@ -71,18 +138,18 @@ func NewOchamiClient(serviceName, baseURI, basePath string, insecure bool) (*Och
 		BasePath:    basePath,
 		ServiceName: serviceName,
 	}
-	if insecure {
-		oc.defaultClientInsecure()
-	} else {
-		oc.defaultClient()
+	tc := TransportConfig{Insecure: insecure}
+	if err := oc.useTransport(tc); err != nil {
+		return nil, fmt.Errorf("failed to create transport: %w", err)
 	}
-	return oc, err
+	return oc, nil
 }
 
 // GetURI takes an endpoint and joins it with the OchamiClient's BaseURI and
 // BasePath to form the final URI to be used for a request. If query is
 // specified, it is used as a raw query string and appended onto the URL
-// without URL encoding. query should not contain the initial '?'.
+// without URL encoding. query should not contain the initial '?'. To pass the
+// query as values to be encoded instead, use GetURIValues.
 func (oc *OchamiClient) GetURI(endpoint, query string) (string, error) {
 	uri, err := url.Parse(oc.BaseURI.String())
 	if err != nil {
@@ -104,9 +171,30 @@ func (oc *OchamiClient) GetURI(endpoint, query string) (string, error) {
 // is the raw query string (without the '?') to be added to the URI. It should
 // already be URL-encoded, e.g. generated using url.Values' Encode() function.
 func (oc *OchamiClient) GetData(endpoint, query string, headers *HTTPHeaders) (HTTPEnvelope, error) {
+	return oc.GetDataContext(context.Background(), endpoint, query, headers)
+}
+
+// GetDataContext is like GetData, except that the request is canceled when ctx
+// is done. If CoalesceGets is set and an identical request is already in
+// flight, its response is returned instead of sending another.
+func (oc *OchamiClient) GetDataContext(ctx context.Context, endpoint, query string, headers *HTTPHeaders) (HTTPEnvelope, error) {
+	if !oc.CoalesceGets {
+		return oc.getData(ctx, endpoint, query, headers)
+	}
+	uri, err := oc.GetURI(endpoint, query)
+	if err != nil {
+		return HTTPEnvelope{}, fmt.Errorf("error making GET request to %s: %w", oc.ServiceName, err)
+	}
+	return coalesceGet(ctx, uri, oc.getKey(uri, headers), func() (HTTPEnvelope, error) {
+		return oc.getData(ctx, endpoint, query, headers)
+	})
+}
+
+// getData sends the GET request of GetDataContext.
+func (oc *OchamiClient) getData(ctx context.Context, endpoint, query string, headers *HTTPHeaders) (HTTPEnvelope, error) {
 	var he HTTPEnvelope
 
-	res, err := oc.MakeOchamiRequest(http.MethodGet, endpoint, query, headers, nil)
+	res, err := oc.MakeOchamiRequestContext(ctx, http.MethodGet, endpoint, query, headers, nil)
 	if err != nil {
 		return he, fmt.Errorf("error making GET request to %s: %w", oc.ServiceName, err)
 	}
@@ -156,6 +244,10 @@ func (oc *OchamiClient) PostData(endpoint, query string, headers *HTTPHeaders, b
 func (oc *OchamiClient) PutData(endpoint, query string, headers *HTTPHeaders, body HTTPBody) (HTTPEnvelope, error) {
 	var he HTTPEnvelope
 
+	headers, err := oc.conditionalHeaders(endpoint, query, headers)
+	if err != nil {
+		return he, fmt.Errorf("error preparing conditional PUT request to %s: %w", oc.ServiceName, err)
+	}
 	res, err := oc.MakeOchamiRequest(http.MethodPut, endpoint, query, headers, body)
 	if err != nil {
 		return he, fmt.Errorf("error making PUT request to %s, %w", oc.ServiceName, err)
@@ -181,6 +273,10 @@ func (oc *OchamiClient) PutData(endpoint, query string, headers *HTTPHeaders, bo
 func (oc *OchamiClient) PatchData(endpoint, query string, headers *HTTPHeaders, body HTTPBody) (HTTPEnvelope, error) {
 	var he HTTPEnvelope
 
+	headers, err := oc.conditionalHeaders(endpoint, query, headers)
+	if err != nil {
+		return he, fmt.Errorf("error preparing conditional PATCH request to %s: %w", oc.ServiceName, err)
+	}
 	res, err := oc.MakeOchamiRequest(http.MethodPatch, endpoint, query, headers, body)
 	if err != nil {
 		return he, fmt.Errorf("error making PATCH request to %s, %w", oc.ServiceName, err)
@@ -220,9 +316,57 @@ func (oc *OchamiClient) DeleteData(endpoint, query string, headers *HTTPHeaders,
 	return he, fmt.Errorf("%s PATCH response was empty", oc.ServiceName)
 }
 
+// conditionalHeaders returns headers with an If-Match header added according
+// to oc.IfMatch. headers is copied rather than modified since callers reuse it
+// across requests. If oc.IfMatch is empty or headers already contains an
+// If-Match header, headers is returned as is. If the ETag is fetched
+// automatically and the service does not send one, a warning is logged and
+// the request is sent unconditionally.
+func (oc *OchamiClient) conditionalHeaders(endpoint, query string, headers *HTTPHeaders) (*HTTPHeaders, error) {
+	if oc.IfMatch == "" {
+		return headers, nil
+	}
+	newHeaders := NewHTTPHeaders()
+	if headers != nil {
+		if _, ok := (*headers)["If-Match"]; ok {
+			return headers, nil
+		}
+		for k, v := range *headers {
+			(*newHeaders)[k] = append([]string{}, v...)
+		}
+	}
+
+	etag := oc.IfMatch
+	if etag == IfMatchAuto {
+		henv, err := oc.GetData(endpoint, query, headers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current ETag: %w", err)
+		}
+		if henv.ETag == "" {
+			log.ClientLogger.Warn().Msgf("%s did not return an ETag for %s, sending request without If-Match", oc.ServiceName, endpoint)
+			return headers, nil
+		}
+		etag = henv.ETag
+	}
+	log.ClientLogger.Debug().Msgf("sending If-Match: %s", etag)
+	if err := newHeaders.SetIfMatch(etag); err != nil {
+		return nil, err
+	}
+
+	return newHeaders, nil
+}
+
 // MakeOchamiRequest is a wrapper around MakeRequest that calls GetURI to form
 // the final URI to make the request with and pass to MakeRequest.
 func (oc *OchamiClient) MakeOchamiRequest(method, endpoint, query string, headers *HTTPHeaders, body HTTPBody) (*http.Response, error) {
+	return oc.MakeOchamiRequestContext(context.Background(), method, endpoint, query, headers, body)
+}
+
+// MakeOchamiRequestContext is like MakeOchamiRequest, except that the request
+// is canceled when ctx is done. Payloads of POST, PUT, and PATCH requests are
+// downgraded by the compatibility rules that apply to the version of the
+// service, if any (see CompatRule).
+func (oc *OchamiClient) MakeOchamiRequestContext(ctx context.Context, method, endpoint, query string, headers *HTTPHeaders, body HTTPBody) (*http.Response, error) {
 	uri, err := oc.GetURI(endpoint, query)
 	if err != nil {
 		if query == "" {
@@ -232,65 +376,123 @@ func (oc *OchamiClient) MakeOchamiRequest(method, endpoint, query string, header
 		}
 	}
 
-	return oc.MakeRequest(method, uri, headers, body)
+	// Downgrade the payload for older versions of the service, if needed
+	if len(body) > 0 && isPayloadMethod(method) {
+		if body, err = oc.applyCompat(ctx, method, endpoint, body); err != nil {
+			return nil, err
+		}
+	}
+
+	return oc.MakeRequestContext(ctx, method, uri, headers, body)
 }
 
 // MakeRequest is a convenience function that, using an OchamiClient as the HTTP
 // client, sends an HTTP request to the passed uri including optional headers
 // and body, and uses the passed HTTP method.
 func (oc *OchamiClient) MakeRequest(method, uri string, headers *HTTPHeaders, body HTTPBody) (*http.Response, error) {
+	return oc.MakeRequestContext(context.Background(), method, uri, headers, body)
+}
+
+// MakeRequestContext is like MakeRequest, except that the request is canceled
+// when ctx is done. If the client's Plan is set, mutating requests are recorded
+// or checked against it. The timing of the request is recorded so that
+// NewHTTPEnvelopeFromResponse can add it to the HTTPEnvelope of the response.
+func (oc *OchamiClient) MakeRequestContext(ctx context.Context, method, uri string, headers *HTTPHeaders, body HTTPBody) (*http.Response, error) {
 	// Create request using function args
-	log.Logger.Debug().Msgf("%s: %s", method, uri)
-	req, err := http.NewRequest(method, uri, bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create new HTTP request: %w", err)
+	log.ClientLogger.Debug().Msgf("%s: %s", method, RedactURI(uri))
+	if oc.Plan != nil {
+		if res, handled, err := oc.Plan.intercept(method, uri, body); handled {
+			if err == nil {
+				log.ClientLogger.Debug().Msgf("planned %s: %s", method, RedactURI(uri))
+			}
+			return res, err
+		}
 	}
+	rt := &requestTiming{uri: uri}
+	ctx = context.WithValue(ctx, requestTimingKey{}, rt)
 
 	// Create empty headers if headers pointer is nil so range works
 	if headers == nil {
 		headers = NewHTTPHeaders()
 	}
 
-	// Add headers, including user agent
-	req.Header.Add("User-Agent", userAgent)
-	for key, vals := range *headers {
-		for _, val := range vals {
-			req.Header.Add(key, val)
+	// Compress large bodies if enabled (see CompressRequests)
+	sendBody, compressed, err := oc.compressBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Requests are created by a function since a request may be sent to
+	// several base URIs if the client fails over (see Failover)
+	newReq := func(uri string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, uri, bytes.NewBuffer(sendBody))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create new HTTP request: %w", err)
+		}
+
+		// Add headers, including user agent. Accepting gzip explicitly
+		// (instead of letting the transport do it) means that responses
+		// are decompressed below, before the body is logged.
+		req.Header.Add("User-Agent", userAgent)
+		for key, vals := range *headers {
+			for _, val := range vals {
+				req.Header.Add(key, val)
+			}
 		}
+		oc.setTenantHeader(req)
+		if req.Header.Get("Accept-Encoding") == "" {
+			req.Header.Set("Accept-Encoding", "gzip")
+		}
+		if compressed {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+		return req, nil
+	}
+	req, err := newReq(uri)
+	if err != nil {
+		return nil, err
 	}
 
-	// Debug info for request
+	// Trace info for request
 	if len(req.Header) > 0 {
-		log.Logger.Debug().Msg("Request headers:")
+		log.ClientLogger.Trace().Msg("Request headers:")
 		for k, v := range req.Header {
-			log.Logger.Debug().Msgf("  %s: %s", k, v)
+			log.ClientLogger.Trace().Msgf("  %s: %s", k, RedactHeader(k, v))
 		}
 	} else {
-		log.Logger.Debug().Msg("No headers in request")
+		log.ClientLogger.Trace().Msg("No headers in request")
+	}
+	if compressed {
+		log.ClientLogger.Debug().Msgf("Request body compressed from %d to %d bytes", len(body), len(sendBody))
 	}
 	if len(body) > 0 {
-		log.Logger.Debug().Msg("Request body:")
-		log.Logger.Debug().Msgf("%s", string(body))
+		log.ClientLogger.Trace().Msg("Request body:")
+		log.ClientLogger.Trace().Msgf("%s", string(RedactBody(body)))
 	} else {
-		log.Logger.Debug().Msg("No body in request")
+		log.ClientLogger.Trace().Msg("No body in request")
 	}
 
 	// Execute HTTP request
-	res, err := oc.Client.Do(req)
+	rt.start = time.Now()
+	res, err := oc.doWithFailover(ctx, req, newReq, func() { rt.attempts++ })
 	if err != nil {
+		rt.summarizeError(method)
 		return nil, fmt.Errorf("failed to execute HTTP request: %w", err)
 	}
 
-	// Debug info for response
+	// Debug info for response, with headers and body traced
 	if res != nil {
-		log.Logger.Debug().Msg("Response status: " + res.Status)
+		log.ClientLogger.Debug().Msg("Response status: " + res.Status)
+		if err := decompressResponse(res); err != nil {
+			return nil, err
+		}
 		if len(res.Header) > 0 {
-			log.Logger.Debug().Msg("Response headers:")
+			log.ClientLogger.Trace().Msg("Response headers:")
 			for k, v := range res.Header {
-				log.Logger.Debug().Msgf("  %s: %s", k, v)
+				log.ClientLogger.Trace().Msgf("  %s: %s", k, RedactHeader(k, v))
 			}
 		} else {
-			log.Logger.Debug().Msg("No headers in response")
+			log.ClientLogger.Trace().Msg("No headers in response")
 		}
 		resBodyLen := res.ContentLength
 		if resBodyLen > 0 {
@@ -298,54 +500,58 @@ func (oc *OchamiClient) MakeRequest(method, uri string, headers *HTTPHeaders, bo
 			resBodyReader := io.TeeReader(res.Body, &resBodyCopy)
 			resBodyBytes, err := ioutil.ReadAll(resBodyReader)
 			if err != nil {
-				log.Logger.Error().Err(err).Msg("failed to read body for debug message")
+				log.ClientLogger.Error().Err(err).Msg("failed to read body for debug message")
 			}
-			log.Logger.Debug().Msg("Response body:")
-			log.Logger.Debug().Msgf("%s", string(resBodyBytes))
+			log.ClientLogger.Trace().Msg("Response body:")
+			log.ClientLogger.Trace().Msgf("%s", string(RedactBody(resBodyBytes)))
 			res.Body = io.NopCloser(bytes.NewReader(resBodyBytes))
 		} else {
-			log.Logger.Debug().Msg("No body in response")
+			log.ClientLogger.Trace().Msg("No body in response")
 		}
 	} else {
-		log.Logger.Debug().Msg("Response was nil")
+		log.ClientLogger.Debug().Msg("Response was nil")
 	}
 
 	return res, err
 }
 
-// UseCACert takes a path to a CA certificate bundle in PEM format and sets it
-// as the OchamiClient's certificate authority certificate to verify the
-// certificates of connections to TLS-enabled HTTP URIs (HTTPS).
+// UseCACert takes a path to a CA certificate bundle in PEM format and adds it
+// to the bundles whose certificates the OchamiClient trusts to verify the
+// certificates of connections to TLS-enabled HTTP URIs (HTTPS), in addition to
+// the system's trust store unless it was excluded (see UseCACerts). The client
+// switches to the shared transport (see SharedTransport) for its host that
+// uses the certificate.
 func (oc *OchamiClient) UseCACert(caCertPath string) error {
-	cacert, err := os.ReadFile(caCertPath)
-	if err != nil {
-		return fmt.Errorf("failed to read %s: %w", caCertPath, err)
+	if oc == nil {
+		return fmt.Errorf("client is nil")
 	}
-	certPool := x509.NewCertPool()
-	certPool.AppendCertsFromPEM(cacert)
+	return oc.UseCACerts([]string{caCertPath}, !oc.transportConfig.NoSystemStore)
+}
 
+// UseCACerts is like UseCACert, but adds several CA certificate bundles at
+// once and sets whether the certificates of the system's trust store are also
+// trusted. Bundles that are already trusted are not added again.
+func (oc *OchamiClient) UseCACerts(caCertPaths []string, useSystemStore bool) error {
 	if oc == nil {
 		return fmt.Errorf("client is nil")
 	}
-
-	(*oc).Transport = &http.Transport{
-		TLSClientConfig: &tls.Config{
-			RootCAs:            certPool,
-			InsecureSkipVerify: false,
-		},
-		DisableKeepAlives:     true,
-		TLSHandshakeTimeout:   tlsHandshakeTimeout,
-		ResponseHeaderTimeout: responseHeaderTimeout,
+	tc := oc.transportConfig
+	tc.CACertPaths = slices.Clone(tc.CACertPaths)
+	for _, p := range caCertPaths {
+		if !slices.Contains(tc.CACertPaths, p) {
+			tc.CACertPaths = append(tc.CACertPaths, p)
+		}
 	}
+	tc.NoSystemStore = !useSystemStore
 
-	return nil
+	return oc.useTransport(tc)
 }
 
 // BytesToHTTPBody takes byte slice and string representing the format of the
 // data, and tries to marshal it into an HTTPBody (byte array) in JSON form,
 // returning it. If an unmarshalling error occurs or either of the arguments are
-// empty, nil and an error are returned. Current file formats supported are JSON
-// and YAML.
+// empty, nil and an error are returned. Current file formats supported are
+// JSON, YAML, and TOML.
 func BytesToHTTPBody(data []byte, format string) (HTTPBody, error) {
 	if len(data) == 0 {
 		return nil, fmt.Errorf("byte slice is empty")
@@ -378,6 +584,18 @@ func BytesToHTTPBody(data []byte, format string) (HTTPBody, error) {
 		if err != nil {
 			err = fmt.Errorf("failed to marshal JSON (converted from YAML): %w", err)
 		}
+	case "toml":
+		var t interface{}
+		t, err = unmarshalTOML(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal TOML: %w", err)
+		}
+		b, err = json.Marshal(t)
+		if err != nil {
+			err = fmt.Errorf("failed to marshal JSON (converted from TOML): %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown payload format: %s", format)
 	}
 
 	return b, err
@@ -387,7 +605,7 @@ func BytesToHTTPBody(data []byte, format string) (HTTPBody, error) {
 // file, reads the file, and tries to marshal it into an HTTPBody (byte array)
 // in JSON form, returning it. If an unmarshalling error occurs or either of the
 // arguments are empty, nil and an error are returned. Current file formats
-// supported are JSON and YAML.
+// supported are JSON, YAML, and TOML.
 func FileToHTTPBody(path, format string) (HTTPBody, error) {
 	if path == "" {
 		return nil, fmt.Errorf("file path is empty")
@@ -424,29 +642,108 @@ func FileToHTTPBody(path, format string) (HTTPBody, error) {
 		if err != nil {
 			err = fmt.Errorf("failed to marshal JSON (converted from YAML) from file %q: %w", path, err)
 		}
+	case "toml":
+		var t interface{}
+		t, err = unmarshalTOML(contents)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal TOML contents from %q: %w", path, err)
+		}
+		b, err = json.Marshal(t)
+		if err != nil {
+			err = fmt.Errorf("failed to marshal JSON (converted from TOML) from file %q: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown payload format: %s", format)
 	}
 
 	return b, err
 }
 
+// IsPayloadURL reports whether path, as passed to ReadPayload, is a URL to fetch
+// the payload from rather than a file path.
+func IsPayloadURL(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+}
+
+// URLToHTTPBody takes a URL and string representing the format of the data it
+// serves, fetches the data, and tries to marshal it into an HTTPBody (byte
+// array) in JSON form, returning it. The request is made with a plain HTTP
+// client: no token is sent and the system's CA certificates are used, since
+// the URL is usually not an OpenCHAMI service. Proxies are honored from the
+// environment. If insecure is true, the TLS certificate of the server is not
+// verified. If the request fails, the response status is not 200, or an
+// unmarshalling error occurs, nil and an error are returned.
+func URLToHTTPBody(uri, format string, insecure bool) (HTTPBody, error) {
+	if uri == "" {
+		return nil, fmt.Errorf("URL is empty")
+	}
+	if format == "" {
+		return nil, fmt.Errorf("format is empty")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	hc := &http.Client{Transport: transport, Timeout: responseHeaderTimeout}
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %q: %w", uri, err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	res, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %q: %w", uri, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %q: %s", uri, res.Status)
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body from %q: %w", uri, err)
+	}
+	log.ClientLogger.Debug().Msgf("bytes read from %s: %d", uri, len(data))
+
+	b, err := BytesToHTTPBody(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload from %q: %w", uri, err)
+	}
+
+	return b, nil
+}
+
 // ReadPayload reads in the file pointed to by path and unmarshals the data into
 // value v. The data can be in formats other than JSON (whichever formats
-// FileToHTTPBody supports), such as YAML. If a marshalling/unmarshalling error
-// occurs or either path or format are empty, an error is returned.
-func ReadPayload(path, format string, v any) error {
-	log.Logger.Debug().Msgf("payload file: %s", path)
-	log.Logger.Debug().Msgf("payload file format: %s", format)
+// FileToHTTPBody supports), such as YAML. If path is "-", the data is read
+// from standard input. If path is an http:// or https:// URL, the data is
+// fetched from it with URLToHTTPBody, passing insecure. The data is then
+// piped through transforms, if any, in order. If a marshalling/unmarshalling
+// error occurs, a transform fails, or either path or format are empty, an
+// error is returned.
+func ReadPayload(path, format string, insecure bool, v any, transforms ...Transform) error {
+	log.ClientLogger.Debug().Msgf("payload file: %s", path)
+	log.ClientLogger.Debug().Msgf("payload file format: %s", format)
 
 	var body HTTPBody
 	var err error
-	if path == "-" {
-		log.Logger.Debug().Msg("payload file was -, reading from stdin")
+	if IsPayloadURL(path) {
+		body, err = URLToHTTPBody(path, format, insecure)
+		if err != nil {
+			return fmt.Errorf("unable to create HTTP body from URL: %w", err)
+		}
+	} else if path == "-" {
+		log.ClientLogger.Debug().Msg("payload file was -, reading from stdin")
 		var data []byte
 		data, err = oio.ReadStdin()
 		if err != nil {
 			return fmt.Errorf("unable to read payload data: %w", err)
 		}
-		log.Logger.Debug().Msgf("bytes read: %q", data)
+		// The data may be in a format that RedactBody cannot redact, so
+		// only the converted body below is logged
+		log.ClientLogger.Debug().Msgf("bytes read: %d", len(data))
 		body, err = BytesToHTTPBody(data, format)
 		if err != nil {
 			return fmt.Errorf("unable to create HTTP body from payload bytes: %w", err)
@@ -457,7 +754,13 @@ func ReadPayload(path, format string, v any) error {
 			return fmt.Errorf("unable to create HTTP body from file: %w", err)
 		}
 	}
-	log.Logger.Debug().Msgf("body bytes: %q", body)
+	if len(transforms) > 0 {
+		body, err = transformPayload(body, transforms)
+		if err != nil {
+			return err
+		}
+	}
+	log.ClientLogger.Trace().Msgf("body bytes: %q", RedactBody(body))
 
 	err = json.Unmarshal(body, v)
 	if err != nil {
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordServer returns a server that records the method and tenant header of
+// each request it receives and responds with an empty JSON object.
+func recordServer(t *testing.T, header string) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		got = append(got, r.Method+" "+r.Header.Get(header))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, got...)
+	}
+}
+
+func newTestClient(t *testing.T, uri string) *OchamiClient {
+	t.Helper()
+	oc, err := NewOchamiClient("test", uri, "/v1", false)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return oc
+}
+
+func TestClientDefaults(t *testing.T) {
+	oc := newTestClient(t, "http://localhost")
+	if oc.CoalesceGets {
+		t.Error("CoalesceGets is enabled by default")
+	}
+	if oc.Plan != nil {
+		t.Error("Plan is set by default")
+	}
+	if oc.Tenant != "" {
+		t.Errorf("Tenant is %q by default", oc.Tenant)
+	}
+	if tc := oc.transportConfig; tc.IPVersion != "" || tc.SSHTunnel != "" || oc.daemonSocket != "" {
+		t.Errorf("transport is restricted by default: %+v, daemon socket %q", tc, oc.daemonSocket)
+	}
+}
+
+func TestClientTenant(t *testing.T) {
+	srv, got := recordServer(t, "X-Org")
+	a := newTestClient(t, srv.URL)
+	a.Tenant = "alpha"
+	a.TenantHeader = "X-Org"
+	b := newTestClient(t, srv.URL)
+
+	if _, err := a.GetData("/things", "", nil); err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	if _, err := b.GetData("/things", "", nil); err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	headers := NewHTTPHeaders()
+	(*headers)["X-Org"] = []string{"beta"}
+	if _, err := a.GetData("/things", "", headers); err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+
+	want := []string{"GET alpha", "GET ", "GET beta"}
+	if g := got(); !slices.Equal(g, want) {
+		t.Errorf("got requests %q, want %q", g, want)
+	}
+}
+
+func TestClientPlan(t *testing.T) {
+	srv, got := recordServer(t, DefaultTenantHeader)
+	planned := newTestClient(t, srv.URL)
+	planned.Plan = NewPlan()
+	direct := newTestClient(t, srv.URL)
+
+	if _, err := planned.PostData("/things", "", nil, HTTPBody("{}")); err != nil {
+		t.Fatalf("planned POST failed: %v", err)
+	}
+	if _, err := planned.GetData("/things", "", nil); err != nil {
+		t.Fatalf("planned GET failed: %v", err)
+	}
+	if _, err := direct.PostData("/things", "", nil, HTTPBody("{}")); err != nil {
+		t.Fatalf("direct POST failed: %v", err)
+	}
+
+	if steps := planned.Plan.Steps(); len(steps) != 1 || steps[0].Method != http.MethodPost {
+		t.Errorf("plan has steps %+v, want the planned POST only", steps)
+	}
+	want := []string{"GET ", "POST "}
+	if g := got(); !slices.Equal(g, want) {
+		t.Errorf("got requests %q, want %q", g, want)
+	}
+}
+
+func TestUseIPVersion(t *testing.T) {
+	oc := newTestClient(t, "http://localhost")
+	for _, v := range []string{"4", "6", "auto", ""} {
+		if err := oc.UseIPVersion(v); err != nil {
+			t.Fatalf("UseIPVersion(%q) failed: %v", v, err)
+		}
+		want := v
+		if v == "auto" {
+			want = ""
+		}
+		if oc.transportConfig.IPVersion != want {
+			t.Errorf("UseIPVersion(%q) set IP version %q, want %q", v, oc.transportConfig.IPVersion, want)
+		}
+	}
+	if err := oc.UseIPVersion("5"); err == nil {
+		t.Error("UseIPVersion(\"5\") succeeded")
+	}
+	if other := newTestClient(t, "http://localhost"); other.transportConfig.IPVersion != "" {
+		t.Errorf("new client has IP version %q", other.transportConfig.IPVersion)
+	}
+}
+
+func TestClientCompress(t *testing.T) {
+	srv, got := recordServer(t, "Content-Encoding")
+	body := HTTPBody(`{"data":"` + strings.Repeat("x", CompressMinSize) + `"}`)
+	compressed := newTestClient(t, srv.URL)
+	compressed.CompressRequests = true
+	plain := newTestClient(t, srv.URL)
+
+	if _, err := compressed.PostData("/things", "", nil, body); err != nil {
+		t.Fatalf("compressed POST failed: %v", err)
+	}
+	if _, err := compressed.PostData("/things", "", nil, HTTPBody("{}")); err != nil {
+		t.Fatalf("small POST failed: %v", err)
+	}
+	if _, err := plain.PostData("/things", "", nil, body); err != nil {
+		t.Fatalf("plain POST failed: %v", err)
+	}
+
+	want := []string{"POST gzip", "POST ", "POST "}
+	if g := got(); !slices.Equal(g, want) {
+		t.Errorf("got requests %q, want %q", g, want)
+	}
+}
+
+func TestClientCompatVersion(t *testing.T) {
+	oc := newTestClient(t, "http://localhost:1")
+	oc.CompatVersion = "1.x"
+	if v := oc.serviceVersion(context.Background()); v != "1.x" {
+		t.Errorf("got version %q, want the one set on the client", v)
+	}
+	if other := newTestClient(t, "http://localhost:1"); other.CompatVersion != "" {
+		t.Errorf("new client has compat version %q", other.CompatVersion)
+	}
+}
+
+func TestClientFailover(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	srv, got := recordServer(t, DefaultTenantHeader)
+	g, err := NewFailoverGroup(down.URL, srv.URL)
+	if err != nil {
+		t.Fatalf("NewFailoverGroup failed: %v", err)
+	}
+
+	a := newTestClient(t, down.URL)
+	a.Failover = g
+	if _, err := a.GetData("/things", "", nil); err != nil {
+		t.Fatalf("GET did not fail over: %v", err)
+	}
+	// The group is shared, so another client starts with the URI that
+	// worked
+	b := newTestClient(t, down.URL)
+	b.Failover = g
+	if _, err := b.GetData("/things", "", nil); err != nil {
+		t.Fatalf("GET of client sharing group failed: %v", err)
+	}
+	if g := got(); len(g) != 2 {
+		t.Errorf("got requests %q, want one per client", g)
+	}
+
+	if _, err := newTestClient(t, down.URL).GetData("/things", "", nil); err == nil {
+		t.Error("GET of client without failover group succeeded")
+	}
+	other := newTestClient(t, "http://localhost:1")
+	other.Failover = g
+	if other.failoverGroup() != nil {
+		t.Error("client whose base URI is not in the group uses it")
+	}
+
+	if _, err := NewFailoverGroup("/no/host"); err == nil {
+		t.Error("NewFailoverGroup succeeded for URI without host")
+	}
+}
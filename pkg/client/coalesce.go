@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+)
+
+// inflightGet is a GET request sent by GetData that identical requests wait
+// for.
+type inflightGet struct {
+	done    chan struct{}
+	waiters int
+
+	// Result of the request. shared is a copy of henv for the waiters,
+	// so that the sender can modify henv.
+	henv   HTTPEnvelope
+	shared HTTPEnvelope
+	err    error
+}
+
+var (
+	inflightMu   sync.Mutex
+	inflightGets = make(map[string]*inflightGet)
+)
+
+// coalesceGet returns the result of send, which sends the GET request to uri
+// identified by key (see getKey), unless an identical request is already in
+// flight, in which case its result is returned once it completes. A waiting
+// caller stops waiting when ctx is done, but the request in flight is only
+// canceled by the context of its sender.
+func coalesceGet(ctx context.Context, uri, key string, send func() (HTTPEnvelope, error)) (HTTPEnvelope, error) {
+
+	inflightMu.Lock()
+	if g, ok := inflightGets[key]; ok {
+		g.waiters++
+		inflightMu.Unlock()
+		log.ClientLogger.Debug().Msgf("GET: %s: waiting for identical request in flight", RedactURI(uri))
+		select {
+		case <-g.done:
+			return g.shared.clone(), g.err
+		case <-ctx.Done():
+			return HTTPEnvelope{}, ctx.Err()
+		}
+	}
+	g := &inflightGet{done: make(chan struct{})}
+	inflightGets[key] = g
+	inflightMu.Unlock()
+
+	g.henv, g.err = send()
+
+	inflightMu.Lock()
+	delete(inflightGets, key)
+	waiters := g.waiters
+	inflightMu.Unlock()
+	if waiters > 0 {
+		log.ClientLogger.Debug().Msgf("GET: %s: response shared with %d identical request(s)", RedactURI(uri), waiters)
+		g.shared = g.henv.clone()
+	}
+	close(g.done)
+	return g.henv, g.err
+}
+
+// getKey returns the key identifying a GET request sent by oc to uri with
+// headers. The tenant header that oc adds (see OchamiClient.Tenant) is part of
+// the key, so that requests on behalf of different tenants are not coalesced.
+func (oc *OchamiClient) getKey(uri string, headers *HTTPHeaders) string {
+	var b strings.Builder
+	b.WriteString(uri)
+	if oc.Tenant != "" {
+		b.WriteString("\ntenant: " + strings.ToLower(oc.tenantHeader()) + ": " + oc.Tenant)
+	}
+	if headers != nil {
+		names := make([]string, 0, len(*headers))
+		for name := range *headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			b.WriteString("\n" + strings.ToLower(name) + ": " + strings.Join((*headers)[name], ", "))
+		}
+	}
+	return b.String()
+}
+
+// clone returns a copy of henv that does not share its body or headers.
+func (henv HTTPEnvelope) clone() HTTPEnvelope {
+	c := henv
+	if henv.Body != nil {
+		c.Body = append(HTTPBody(nil), henv.Body...)
+	}
+	if henv.Headers != nil {
+		h := make(HTTPHeaders, len(*henv.Headers))
+		for k, v := range *henv.Headers {
+			h[k] = append([]string(nil), v...)
+		}
+		c.Headers = &h
+	}
+	return c
+}
@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+)
+
+// CompatLatest is the value of OchamiClient.CompatVersion that assumes the
+// service is recent enough for all payloads, so that no compatibility rules
+// apply and its version is not fetched.
+const CompatLatest = "latest"
+
+// CompatRule downgrades payloads for versions of a service that do not
+// support them, by removing the fields they reject before the payload is
+// sent.
+type CompatRule struct {
+	// Service is the name of the service (see OchamiClient.ServiceName).
+	Service string
+
+	// Before is the first version of the service that does not need the
+	// rule, e.g. "2.0". The rule applies to all versions before it.
+	Before string
+
+	// Endpoint is the endpoint, relative to the base path of the service,
+	// that the rule applies to payloads sent to, including those sent to
+	// endpoints below it.
+	Endpoint string
+
+	// Methods are the HTTP methods whose payloads the rule applies to.
+	Methods []string
+
+	// Strip are the top-level fields removed from the payload or, if it is
+	// an array, from each of its items.
+	Strip []string
+
+	// Reason describes what the rule is for, e.g. "SMD before 2.0 does not
+	// support ...".
+	Reason string
+}
+
+// compatRules are the rules registered with RegisterCompatRule.
+var compatRules []CompatRule
+
+// RegisterCompatRule adds r to the rules applied to payloads. It is meant to
+// be called by the packages of the services when they are initialized.
+func RegisterCompatRule(r CompatRule) {
+	compatRules = append(compatRules, r)
+}
+
+// CompatRules returns the registered compatibility rules.
+func CompatRules() []CompatRule {
+	return slices.Clone(compatRules)
+}
+
+// ParseCompat parses specs, each of the form <service>=<version> (e.g.
+// "smd=1.x"), into a map of the names of services, in lower case, to the
+// value of OchamiClient.CompatVersion for their clients. The version can be
+// CompatLatest to disable the rules of a service.
+func ParseCompat(specs []string) (map[string]string, error) {
+	versions := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		svc, ver, ok := strings.Cut(spec, "=")
+		svc, ver = strings.ToLower(strings.TrimSpace(svc)), strings.TrimSpace(ver)
+		if !ok || svc == "" || ver == "" {
+			return nil, fmt.Errorf("invalid compatibility %q (expected <service>=<version>, e.g. smd=1.x)", spec)
+		}
+		if !strings.EqualFold(ver, CompatLatest) {
+			if _, ok := parseVersion(ver); !ok {
+				return nil, fmt.Errorf("invalid version %q for %s (expected e.g. 1.x, 2.3, or %s)", ver, svc, CompatLatest)
+			}
+		}
+		versions[svc] = ver
+	}
+	return versions, nil
+}
+
+// versionRE matches the major and, optionally, minor version at the start of a
+// version string such as "v2.3.1-rc1" or "1.x".
+var versionRE = regexp.MustCompile(`^[vV]?(\d+)(?:\.(\d+|[xX*]))?`)
+
+// parseVersion returns the major and minor version of v. A minor version of
+// "x" is 0. ok is false if v does not start with a version number.
+func parseVersion(v string) (ver [2]int, ok bool) {
+	m := versionRE.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return ver, false
+	}
+	ver[0], _ = strconv.Atoi(m[1])
+	ver[1], _ = strconv.Atoi(m[2])
+	return ver, true
+}
+
+// VersionBefore reports whether version v is before version before, comparing
+// their major and minor versions. It returns false if either cannot be parsed.
+func VersionBefore(v, before string) bool {
+	a, ok := parseVersion(v)
+	if !ok {
+		return false
+	}
+	b, ok := parseVersion(before)
+	if !ok {
+		return false
+	}
+	return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+}
+
+// serviceVersion returns the version of the service of oc, as set in
+// oc.CompatVersion or else fetched from its /service/version endpoint once per
+// client. An empty string is returned if the version cannot be determined.
+func (oc *OchamiClient) serviceVersion(ctx context.Context) string {
+	if oc.CompatVersion != "" {
+		return oc.CompatVersion
+	}
+	oc.versionOnce.Do(func() {
+		henv, err := oc.GetDataContext(ctx, "/service/version", "", nil)
+		if err != nil {
+			log.ClientLogger.Debug().Err(err).Msgf("%s did not report its version, assuming it is recent", oc.ServiceName)
+			return
+		}
+		var v struct {
+			Version string `json:"version"`
+		}
+		var s string
+		if err := json.Unmarshal(henv.Body, &v); err == nil && v.Version != "" {
+			oc.version = v.Version
+		} else if err := json.Unmarshal(henv.Body, &s); err == nil {
+			oc.version = s
+		} else {
+			oc.version = strings.TrimSpace(string(henv.Body))
+		}
+		log.ClientLogger.Debug().Msgf("%s reported version %q", oc.ServiceName, oc.version)
+	})
+	return oc.version
+}
+
+// compatLogged holds the rules whose application has been logged, so that it
+// is logged once rather than for each item of an iterative request.
+var compatLogged sync.Map
+
+// applyCompat returns body, the payload of a method request to endpoint, with
+// the compatibility rules that apply to the service's version applied.
+func (oc *OchamiClient) applyCompat(ctx context.Context, method, endpoint string, body HTTPBody) (HTTPBody, error) {
+	var rules []CompatRule
+	for _, r := range compatRules {
+		if strings.EqualFold(r.Service, oc.ServiceName) && slices.Contains(r.Methods, method) &&
+			(endpoint == r.Endpoint || strings.HasPrefix(endpoint, strings.TrimSuffix(r.Endpoint, "/")+"/")) {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) == 0 {
+		return body, nil
+	}
+	version := oc.serviceVersion(ctx)
+	if version == "" || strings.EqualFold(version, CompatLatest) {
+		return body, nil
+	}
+	for _, r := range rules {
+		if !VersionBefore(version, r.Before) {
+			continue
+		}
+		if _, logged := compatLogged.LoadOrStore(r.Service+r.Endpoint+r.Before, true); !logged {
+			log.ClientLogger.Warn().Msgf("%s is version %s: %s; removing %s from payloads", oc.ServiceName, version, r.Reason, strings.Join(r.Strip, ", "))
+		}
+		var err error
+		if body, err = stripFields(body, r.Strip); err != nil {
+			return nil, fmt.Errorf("failed to make payload compatible with %s %s: %w", oc.ServiceName, version, err)
+		}
+	}
+	return body, nil
+}
+
+// stripFields removes fields from body, a JSON object or an array of them. Bodies
+// that are neither are returned as they are.
+func stripFields(body HTTPBody, fields []string) (HTTPBody, error) {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body, nil
+	}
+	strip := func(o any) {
+		if m, ok := o.(map[string]any); ok {
+			for _, f := range fields {
+				delete(m, f)
+			}
+		}
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		strip(t)
+	case []any:
+		for _, item := range t {
+			strip(item)
+		}
+	default:
+		return body, nil
+	}
+	return json.Marshal(v)
+}
+
+// isPayloadMethod reports whether requests of method carry a payload that
+// compatibility rules can apply to.
+func isPayloadMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
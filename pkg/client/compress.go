@@ -0,0 +1,63 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CompressMinSize is the size in bytes from which request bodies are
+// compressed when OchamiClient.CompressRequests is true. Smaller bodies are
+// sent as they are, since compressing them saves little.
+const CompressMinSize = 1024
+
+// compressBody returns body compressed with gzip if oc.CompressRequests is
+// true and body is large enough, and whether it was compressed.
+func (oc *OchamiClient) compressBody(body HTTPBody) (HTTPBody, bool, error) {
+	if !oc.CompressRequests || len(body) < CompressMinSize {
+		return body, false, nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, false, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, false, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	return buf.Bytes(), true, nil
+}
+
+// decompressResponse replaces the body of res, if it is gzip-encoded, with the
+// decompressed body and removes the Content-Encoding header so that callers
+// see the response as if it had not been compressed. The body is read fully so
+// that decompression errors are returned here rather than by later reads.
+func decompressResponse(res *http.Response) error {
+	if !strings.EqualFold(strings.TrimSpace(res.Header.Get("Content-Encoding")), "gzip") {
+		return nil
+	}
+	defer res.Body.Close()
+	zr, err := gzip.NewReader(res.Body)
+	if err == io.EOF {
+		// Empty body despite the header, e.g. for HEAD requests
+		res.Body = io.NopCloser(bytes.NewReader(nil))
+		res.Header.Del("Content-Encoding")
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to decompress gzip-encoded response: %w", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return fmt.Errorf("failed to decompress gzip-encoded response: %w", err)
+	}
+	res.Body = io.NopCloser(bytes.NewReader(data))
+	res.Header.Del("Content-Encoding")
+	res.Header.Set("Content-Length", strconv.Itoa(len(data)))
+	res.ContentLength = int64(len(data))
+	res.Uncompressed = true
+	return nil
+}
@@ -0,0 +1,256 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+)
+
+// DaemonSocketEnvVar is the environment variable that overrides the path of
+// the Unix socket of the connection daemon (see DefaultDaemonSocket).
+const DaemonSocketEnvVar = "OCHAMI_DAEMON_SOCKET"
+
+// Headers of requests sent to the connection daemon, describing where to
+// forward them and how to secure the connection (see TransportConfig). They
+// are removed before forwarding.
+const (
+	daemonHeaderTarget    = "X-Ochami-Daemon-Target"
+	daemonHeaderInsecure  = "X-Ochami-Daemon-Insecure"
+	daemonHeaderCACert    = "X-Ochami-Daemon-Cacert"
+	daemonHeaderNoSystem  = "X-Ochami-Daemon-No-System-Store"
+	daemonHeaderPins      = "X-Ochami-Daemon-Pins"
+	daemonHeaderIPVersion = "X-Ochami-Daemon-Ip-Version"
+)
+
+// DefaultDaemonSocket returns the path of the Unix socket of the connection
+// daemon: the value of DaemonSocketEnvVar if set, otherwise ochami/proxy.sock
+// in $XDG_RUNTIME_DIR, or in a per-user directory in the temporary directory
+// if it is not set.
+func DefaultDaemonSocket() string {
+	if s := os.Getenv(DaemonSocketEnvVar); s != "" {
+		return s
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "ochami", "proxy.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("ochami-%d", os.Getuid()), "proxy.sock")
+}
+
+// UseDaemon sends the OchamiClient's requests through the connection daemon
+// (ochami serve-proxy) listening on the Unix socket at socket (see
+// DefaultDaemonSocket). The daemon keeps connections to services open across
+// invocations, saving a TLS handshake per request. If the daemon is not
+// running, requests are sent directly. Requests tunneled through a jump host
+// (see UseSSHTunnel) are always sent directly. If socket is empty, the daemon
+// is no longer used.
+func (oc *OchamiClient) UseDaemon(socket string) error {
+	if oc == nil {
+		return fmt.Errorf("client is nil")
+	}
+	oc.daemonSocket = socket
+
+	return oc.useTransport(oc.transportConfig)
+}
+
+// CheckDaemonSocketDir returns an error if dir, the directory of the socket of
+// the connection daemon, would let other users intercept requests sent to the
+// daemon: if it is not a directory (e.g. a symbolic link), is not owned by the
+// current user, or can be accessed by other users. The default directory is
+// predictable when $XDG_RUNTIME_DIR is not set, so another user could create
+// it first.
+func CheckDaemonSocketDir(dir string) error {
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	return checkPrivate(dir, fi)
+}
+
+// CheckDaemonSocket returns an error if socket is not a Unix socket owned by
+// and only accessible to the current user, in a directory that passes
+// CheckDaemonSocketDir. The returned error wraps os.ErrNotExist if the socket
+// or its directory does not exist.
+func CheckDaemonSocket(socket string) error {
+	if err := CheckDaemonSocketDir(filepath.Dir(socket)); err != nil {
+		return err
+	}
+	fi, err := os.Lstat(socket)
+	if err != nil {
+		return err
+	}
+	if fi.Mode().Type() != os.ModeSocket {
+		return fmt.Errorf("%s is not a socket", socket)
+	}
+	return checkPrivate(socket, fi)
+}
+
+// daemonTransport is the http.RoundTripper of clients that send requests
+// through the connection daemon listening on socket. Requests are sent over
+// plain HTTP to the daemon, which forwards them to their original URL using a
+// transport configured by tc. If the daemon cannot be reached, requests are
+// sent with direct instead.
+type daemonTransport struct {
+	socket string
+	tc     TransportConfig
+	daemon *http.Transport
+	direct *http.Transport
+}
+
+// daemonTransports holds the transports to connection daemons, keyed by
+// socket, so that all clients share the connections to the daemon.
+var daemonTransports = make(map[string]*http.Transport)
+
+// unsafeDaemonSockets holds the sockets that were refused because they failed
+// CheckDaemonSocket, so that this is only warned about once per socket.
+var unsafeDaemonSockets sync.Map
+
+// newDaemonTransport returns a daemonTransport for the daemon listening on
+// socket, forwarding requests with tc, and falling back to direct.
+func newDaemonTransport(socket string, tc TransportConfig, direct *http.Transport) *daemonTransport {
+	transports.Lock()
+	defer transports.Unlock()
+	t, ok := daemonTransports[socket]
+	if !ok {
+		dialer := &net.Dialer{Timeout: 5 * time.Second}
+		t = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+			MaxIdleConnsPerHost:   DefaultReadConcurrency,
+			IdleConnTimeout:       90 * time.Second,
+			ResponseHeaderTimeout: responseHeaderTimeout,
+		}
+		daemonTransports[socket] = t
+	}
+	return &daemonTransport{socket: socket, tc: tc, daemon: t, direct: direct}
+}
+
+// RoundTrip sends req through the daemon, or directly if the daemon cannot be
+// dialed or its socket fails CheckDaemonSocket, so that requests, including
+// their tokens, are never sent to a socket that another user could have
+// created. The response's Request is req, so that its URL is the one of the
+// service rather than of the daemon.
+func (dt *daemonTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := CheckDaemonSocket(dt.socket); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			log.ClientLogger.Debug().Err(err).Msgf("connection daemon at %s not running, sending request directly", dt.socket)
+		} else if _, warned := unsafeDaemonSockets.LoadOrStore(dt.socket, true); !warned {
+			log.ClientLogger.Warn().Err(err).Msgf("refusing to use connection daemon at %s, sending requests directly", dt.socket)
+		}
+		return dt.direct.RoundTrip(req)
+	}
+
+	out := req.Clone(req.Context())
+	out.URL = &url.URL{Scheme: "http", Host: "ochami-daemon", Path: req.URL.Path, RawPath: req.URL.RawPath, RawQuery: req.URL.RawQuery}
+	out.Host = ""
+	out.Header.Set(daemonHeaderTarget, req.URL.Scheme+"://"+req.URL.Host)
+	out.Header.Set(daemonHeaderInsecure, strconv.FormatBool(dt.tc.Insecure))
+	if len(dt.tc.CACertPaths) > 0 {
+		// The daemon may run in another directory
+		paths := make([]string, 0, len(dt.tc.CACertPaths))
+		for _, p := range dt.tc.CACertPaths {
+			if abs, err := filepath.Abs(p); err == nil {
+				p = abs
+			}
+			paths = append(paths, p)
+		}
+		out.Header.Set(daemonHeaderCACert, strings.Join(paths, string(os.PathListSeparator)))
+	}
+	if dt.tc.NoSystemStore {
+		out.Header.Set(daemonHeaderNoSystem, "true")
+	}
+	if len(dt.tc.Pins) > 0 {
+		out.Header.Set(daemonHeaderPins, strings.Join(dt.tc.Pins, ","))
+	}
+	if dt.tc.IPVersion != "" {
+		out.Header.Set(daemonHeaderIPVersion, dt.tc.IPVersion)
+	}
+
+	res, err := dt.daemon.RoundTrip(out)
+	var opErr *net.OpError
+	if err != nil && errors.As(err, &opErr) && opErr.Op == "dial" {
+		log.ClientLogger.Debug().Err(err).Msgf("connection daemon at %s not reachable, sending request directly", dt.socket)
+		if req.Body != nil && req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+		}
+		return dt.direct.RoundTrip(req)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("request through connection daemon at %s failed: %w", dt.socket, err)
+	}
+	res.Request = req
+	return res, nil
+}
+
+// daemonTransportConfigKey is the context key under which DaemonHandler passes
+// the TransportConfig of a request from its Rewrite to its Transport.
+type daemonTransportConfigKey struct{}
+
+// daemonRoundTripper forwards requests from DaemonHandler using the shared
+// transport (see SharedTransport) for their host and TransportConfig, so that
+// connections are reused across requests from different invocations.
+type daemonRoundTripper struct{}
+
+func (daemonRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	tc, _ := req.Context().Value(daemonTransportConfigKey{}).(TransportConfig)
+	t, err := SharedTransport(req.URL.Host, tc)
+	if err != nil {
+		return nil, err
+	}
+	return t.RoundTrip(req)
+}
+
+// DaemonHandler returns the handler of the connection daemon, which forwards
+// each request received from a client using the daemon (see UseDaemon) to the
+// service it was meant for. Headers, including the authorization token, and
+// bodies are passed through unchanged. If a request cannot be forwarded, the
+// daemon responds with 502 Bad Gateway and the error as the body.
+func DaemonHandler() http.Handler {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			// An invalid target leaves the URL empty, which fails below
+			target, err := url.Parse(pr.In.Header.Get(daemonHeaderTarget))
+			if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+				target = &url.URL{}
+			}
+			tc := TransportConfig{
+				CACertPaths: filepath.SplitList(pr.In.Header.Get(daemonHeaderCACert)),
+				IPVersion:   pr.In.Header.Get(daemonHeaderIPVersion),
+			}
+			tc.Insecure, _ = strconv.ParseBool(pr.In.Header.Get(daemonHeaderInsecure))
+			tc.NoSystemStore, _ = strconv.ParseBool(pr.In.Header.Get(daemonHeaderNoSystem))
+			if pins := pr.In.Header.Get(daemonHeaderPins); pins != "" {
+				tc.Pins = strings.Split(pins, ",")
+			}
+			pr.SetURL(target)
+			pr.Out.Host = target.Host
+			for _, h := range []string{daemonHeaderTarget, daemonHeaderInsecure, daemonHeaderCACert, daemonHeaderNoSystem, daemonHeaderPins, daemonHeaderIPVersion} {
+				pr.Out.Header.Del(h)
+			}
+			pr.Out = pr.Out.WithContext(context.WithValue(pr.Out.Context(), daemonTransportConfigKey{}, tc))
+			log.ClientLogger.Debug().Msgf("forwarding %s %s", pr.Out.Method, RedactURI(pr.Out.URL.String()))
+		},
+		Transport: daemonRoundTripper{},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.ClientLogger.Warn().Err(err).Msgf("failed to forward %s %s", r.Method, r.URL)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		},
+	}
+}
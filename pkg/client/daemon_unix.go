@@ -0,0 +1,21 @@
+//go:build unix
+
+package client
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkPrivate returns an error if the file at path, described by fi, is not
+// owned by the current user or can be accessed by other users.
+func checkPrivate(path string, fi os.FileInfo) error {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is owned by user %d, not by the current user", path, st.Uid)
+	}
+	if perm := fi.Mode().Perm(); perm&0o077 != 0 {
+		return fmt.Errorf("%s can be accessed by other users (mode %04o)", path, perm)
+	}
+	return nil
+}
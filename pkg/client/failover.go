@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+)
+
+// FailoverGroup is a set of interchangeable base URIs, e.g. those of a
+// cluster's redundant API gateways, along with the index of the one that last
+// worked. Clients whose Failover is the group and whose base URI is one of its
+// URIs send each request to the URI that last worked and try the others in
+// order if the connection to it fails. Requests are never retried on another
+// URI after an HTTP response, whatever its status. Since the group is shared,
+// once a request of one of the clients has failed over to another URI, later
+// requests of all of them start with that one. A FailoverGroup is safe for
+// concurrent use.
+type FailoverGroup struct {
+	mu        sync.Mutex
+	uris      []*url.URL
+	preferred int
+}
+
+// NewFailoverGroup returns a FailoverGroup of uris, whose first URI is tried
+// first. An error is returned if one of uris cannot be parsed or has no host.
+func NewFailoverGroup(uris ...string) (*FailoverGroup, error) {
+	g := &FailoverGroup{}
+	for _, s := range uris {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("NewFailoverGroup(): failed to parse URI %s: %w", s, err)
+		}
+		if u.Host == "" {
+			return nil, fmt.Errorf("NewFailoverGroup(): URI %s has no host", s)
+		}
+		g.uris = append(g.uris, u)
+	}
+	return g, nil
+}
+
+// failoverKey returns the form of u that the URIs of failover groups are
+// compared in.
+func failoverKey(u *url.URL) string {
+	return strings.ToLower(u.Scheme+"://"+u.Host) + strings.TrimSuffix(u.Path, "/")
+}
+
+// failoverGroup returns oc.Failover, or nil if oc has no base URI, its base
+// URI is not one of the URIs of the group, or the group has no other URI to
+// fail over to.
+func (oc *OchamiClient) failoverGroup() *FailoverGroup {
+	g := oc.Failover
+	if g == nil || oc.BaseURI == nil || len(g.uris) < 2 {
+		return nil
+	}
+	key := failoverKey(oc.BaseURI)
+	for _, u := range g.uris {
+		if failoverKey(u) == key {
+			return g
+		}
+	}
+	return nil
+}
+
+// candidates returns the base URIs of g in the order they should be tried:
+// the preferred one first, followed by the ones after it, wrapping around.
+func (g *FailoverGroup) candidates() []*url.URL {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c := make([]*url.URL, 0, len(g.uris))
+	for i := range g.uris {
+		c = append(c, g.uris[(g.preferred+i)%len(g.uris)])
+	}
+	return c
+}
+
+// prefer makes u, one of the URIs of g, the one that requests try first.
+func (g *FailoverGroup) prefer(u *url.URL) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, gu := range g.uris {
+		if gu == u {
+			g.preferred = i
+			return
+		}
+	}
+}
+
+// rebase returns uri, which starts with base, with base replaced by to. If uri
+// does not start with base, ok is false.
+func rebase(uri string, base, to *url.URL) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || !strings.EqualFold(u.Scheme, base.Scheme) || !strings.EqualFold(u.Host, base.Host) {
+		return "", false
+	}
+	basePath := strings.TrimSuffix(base.Path, "/")
+	rest, found := strings.CutPrefix(u.Path, basePath)
+	if !found || (rest != "" && !strings.HasPrefix(rest, "/")) {
+		return "", false
+	}
+	u.Scheme = to.Scheme
+	u.Host = to.Host
+	u.Path = strings.TrimSuffix(to.Path, "/") + rest
+	u.RawPath = ""
+	return u.String(), true
+}
+
+// shouldFailOver reports whether a request using method that failed with err,
+// without a response, should be tried on another base URI. Requests canceled
+// through ctx are not. Requests that are not idempotent are only tried again
+// if no connection could be established, since otherwise the service may have
+// received them.
+func shouldFailOver(ctx context.Context, method string, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// doWithFailover sends req using oc.Client. If oc's base URI belongs to its
+// failover group (see Failover) and req's URL starts with it, the
+// request is instead built by newReq for the group's preferred base URI and,
+// if the connection fails, for the others in turn. The base URI that responded
+// becomes the preferred one. attempt is called before each attempt.
+func (oc *OchamiClient) doWithFailover(ctx context.Context, req *http.Request, newReq func(uri string) (*http.Request, error), attempt func()) (*http.Response, error) {
+	g := oc.failoverGroup()
+	uri := req.URL.String()
+	if g == nil {
+		attempt()
+		return oc.Client.Do(req)
+	}
+	if _, ok := rebase(uri, oc.BaseURI, oc.BaseURI); !ok {
+		// Not a request to the service's base URI, so there is nothing to
+		// fail over to
+		attempt()
+		return oc.Client.Do(req)
+	}
+
+	var errs []error
+	for _, base := range g.candidates() {
+		target, _ := rebase(uri, oc.BaseURI, base)
+		r, err := newReq(target)
+		if err != nil {
+			return nil, err
+		}
+		attempt()
+		res, err := oc.Client.Do(r)
+		if err == nil {
+			g.prefer(base)
+			return res, nil
+		}
+		errs = append(errs, err)
+		if !shouldFailOver(ctx, req.Method, err) {
+			break
+		}
+		log.ClientLogger.Warn().Err(err).Msgf("%s: request to %s failed, trying next base URI", oc.ServiceName, RedactURI(base.String()))
+	}
+
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SetNestedField sets the field at path, a dot-separated list of keys (e.g.
+// "cloud-init.metadata.instance-id"), in m to value, creating the maps along
+// the path that do not exist. An error is returned if path contains an empty
+// key or passes through a value that is not a map.
+func SetNestedField(m map[string]interface{}, path string, value interface{}) error {
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		if key == "" {
+			return fmt.Errorf("invalid field %q: empty key", path)
+		}
+		if i == len(keys)-1 {
+			m[key] = value
+			break
+		}
+		next, ok := m[key]
+		if !ok {
+			nm := make(map[string]interface{})
+			m[key] = nm
+			m = nm
+			continue
+		}
+		if m, ok = next.(map[string]interface{}); !ok {
+			return fmt.Errorf("cannot set field %q: %s is already set to a value that is not a map", path, strings.Join(keys[:i+1], "."))
+		}
+	}
+	return nil
+}
+
+// SetFields sets fields of the payload pointed to by v from sets, each of the
+// form <field>=<value>. Fields are named by the JSON keys of the payload, and
+// nested fields by dot-separated paths of them (see SetNestedField). Values
+// are parsed as YAML, so numbers and booleans get their type and lists can be
+// passed in flow style (e.g. "[a, b]"); a value can be quoted to force a
+// string. Fields that are not set are left as they are in v.
+//
+// An error is returned if an item of sets is malformed, a field is set more
+// than once, a field does not exist in the payload, or a value has the wrong
+// type for its field.
+func SetFields(v any, sets []string) error {
+	fields := make(map[string]interface{})
+	seen := make(map[string]bool, len(sets))
+	for _, s := range sets {
+		key, value, found := strings.Cut(s, "=")
+		if !found || key == "" {
+			return fmt.Errorf("expected <field>=<value>, got %q", s)
+		}
+		if seen[key] {
+			return fmt.Errorf("field %s set more than once", key)
+		}
+		seen[key] = true
+
+		var val interface{} = value
+		if value != "" {
+			if err := yaml.Unmarshal([]byte(value), &val); err != nil {
+				return fmt.Errorf("failed to parse value of %s: %w", key, err)
+			}
+			val = CanonicalizeInterface(val)
+		}
+		if err := SetNestedField(fields, key, val); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fields: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("failed to set fields: %w", err)
+	}
+
+	return nil
+}
@@ -1,19 +1,23 @@
 package client
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"gopkg.in/yaml.v3"
 )
 
 var (
-	UnsuccessfulHTTPError = fmt.Errorf("unsuccessful HTTP status")
-	NilMapPointerError    = fmt.Errorf("nil map pointer")
+	UnsuccessfulHTTPError   = fmt.Errorf("unsuccessful HTTP status")
+	NilMapPointerError      = fmt.Errorf("nil map pointer")
+	PreconditionFailedError = fmt.Errorf("resource was modified since it was read")
 )
 
 type HTTPHeaders map[string][]string
@@ -29,6 +33,14 @@ type HTTPEnvelope struct {
 	Proto      string // e.g. "HTTP/1.0"
 	Headers    *HTTPHeaders
 	Body       HTTPBody
+	ETag       string // Value of ETag header, if the service sent one
+
+	// Timing of the request, set if it was sent by MakeRequest
+	Start    time.Time     // When the request was first sent
+	Duration time.Duration // From Start until the response body was read
+	Attempts int           // Number of times the request was sent
+	URL      string        // Final URL of the request, after any redirects
+	Method   string        // Method of the request, e.g. "GET"
 }
 
 // NewHTTPHeaders returns a pointer to a new HTTPHeaders.
@@ -72,6 +84,19 @@ func (h *HTTPHeaders) SetContentType(ct string) error {
 	return nil
 }
 
+// SetIfMatch takes an entity tag (ETag) and sets the "If-Match" header to it in
+// the HTTPHeaders map so that the request only succeeds if the resource still
+// has that ETag.
+func (h *HTTPHeaders) SetIfMatch(etag string) error {
+	if h == nil {
+		return NilMapPointerError
+	}
+	if err := h.Add("If-Match", etag); err != nil {
+		return fmt.Errorf("could not set If-Match in HTTPHeaders: %w", err)
+	}
+	return nil
+}
+
 // NewHTTPEnvelopeFromResponse takes a pointer to an http.Response and returns a
 // populated HTTPEnvelope. If res is nil or there is an error reading the
 // response body, an error is returned. Importantly, this function closes the
@@ -90,6 +115,7 @@ func NewHTTPEnvelopeFromResponse(res *http.Response) (HTTPEnvelope, error) {
 			(*headers)[http.CanonicalHeaderKey(key)] = vals
 		}
 		henv.Headers = headers
+		henv.ETag = res.Header.Get("ETag")
 
 		var body HTTPBody
 		body, err := io.ReadAll(res.Body)
@@ -101,13 +127,25 @@ func NewHTTPEnvelopeFromResponse(res *http.Response) (HTTPEnvelope, error) {
 		}
 		henv.Body = body
 
+		if res.Request != nil {
+			henv.URL = res.Request.URL.String()
+			henv.Method = res.Request.Method
+			if rt, ok := res.Request.Context().Value(requestTimingKey{}).(*requestTiming); ok {
+				henv.Start = rt.start
+				henv.Duration = time.Since(rt.start)
+				henv.Attempts = rt.attempts
+				rt.summarize(res.Request.Method, henv)
+			}
+		}
+
 		return henv, nil
 	} else {
 		return henv, fmt.Errorf("HTTP response was nil")
 	}
 }
 
-// FormatBody takes an HTTPBody and marshals it into the format specified,
+// FormatBody takes an HTTPBody and marshals it into the format specified
+// (json, json-pretty for JSON indented with two spaces, yaml, or toml),
 // returning the resulting bytes. If an error occurs during
 // marshalling/unmarshalling or the format is unsupported, an error occurs.
 func FormatBody(body HTTPBody, format string) ([]byte, error) {
@@ -122,26 +160,119 @@ func FormatBody(body HTTPBody, format string) ([]byte, error) {
 		} else {
 			return jbytes, nil
 		}
+	case "json-pretty":
+		var jmap interface{}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&jmap); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal HTTP body: %w", err)
+		}
+		if jbytes, err := json.MarshalIndent(jmap, "", "  "); err != nil {
+			return nil, fmt.Errorf("failed to marshal HTTP body into JSON: %w", err)
+		} else {
+			return append(jbytes, '\n'), nil
+		}
 	case "yaml":
+		// Decode numbers as json.Number so that integers (e.g. UNIX
+		// timestamps) are not printed in floating point notation
 		var ymap interface{}
-		if err := json.Unmarshal(body, &ymap); err != nil {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&ymap); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal HTTP body: %w", err)
 		}
-		if ybytes, err := yaml.Marshal(ymap); err != nil {
+		if ybytes, err := yaml.Marshal(convertNumbers(ymap)); err != nil {
 			return nil, fmt.Errorf("failed to marshal HTTP body into YAML: %w", err)
 		} else {
 			return ybytes, nil
 		}
+	case "toml":
+		var tmap interface{}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&tmap); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal HTTP body: %w", err)
+		}
+		if tbytes, err := marshalTOML(convertNumbers(tmap)); err != nil {
+			return nil, fmt.Errorf("failed to marshal HTTP body into TOML: %w", err)
+		} else {
+			return tbytes, nil
+		}
 	default:
 		return nil, fmt.Errorf("unknown output format: %s", format)
 	}
 }
 
+// convertNumbers replaces, in the structure v decoded from JSON, each
+// json.Number with an int64 if it is an integer and a float64 otherwise, so that
+// it is marshalled as a number instead of a string.
+func convertNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+		return t.String()
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = convertNumbers(val)
+		}
+	case []interface{}:
+		for i, val := range t {
+			t[i] = convertNumbers(val)
+		}
+	}
+	return v
+}
+
+// TOMLArrayKey is the key under which marshalTOML puts data that is not a
+// table, e.g. the list of boot parameters returned by BSS, since a TOML
+// document must be a table.
+const TOMLArrayKey = "items"
+
+// marshalTOML marshals v, as unmarshalled from JSON, into TOML. If v is not a
+// map, it is put under TOMLArrayKey (see unmarshalTOML). null values are
+// omitted since TOML has no equivalent.
+func marshalTOML(v interface{}) ([]byte, error) {
+	if _, ok := v.(map[string]interface{}); !ok {
+		v = map[string]interface{}{TOMLArrayKey: v}
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalTOML unmarshals the TOML document data. As the inverse of
+// marshalTOML, if the document only holds an array under TOMLArrayKey, the
+// array is returned instead of the document.
+func unmarshalTOML(data []byte) (interface{}, error) {
+	var t map[string]interface{}
+	if err := toml.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	if items, ok := t[TOMLArrayKey].([]interface{}); ok && len(t) == 1 {
+		return items, nil
+	}
+	return t, nil
+}
+
+// CheckResponse returns nil if the HTTPEnvelope has a successful (2XX) status
+// code. Otherwise, an error wrapping UnsuccessfulHTTPError is returned. If the
+// status is 412 Precondition Failed, which is what services return when an
+// If-Match header no longer matches, the error also wraps
+// PreconditionFailedError.
 func (he HTTPEnvelope) CheckResponse() error {
 	statusOK := he.StatusCode >= 200 && he.StatusCode < 300
 	if statusOK {
-		log.Logger.Info().Msgf("Response status: %s %s", he.Proto, he.Status)
+		log.ClientLogger.Info().Msgf("Response status: %s %s", he.Proto, he.Status)
 		return nil
+	} else if he.StatusCode == http.StatusPreconditionFailed {
+		return fmt.Errorf("%w: %w (ETag no longer matches): %s %s", UnsuccessfulHTTPError, PreconditionFailedError, he.Proto, he.Status)
 	} else {
 		if len(he.Body) > 0 {
 			return fmt.Errorf("%w: %s %s: %s", UnsuccessfulHTTPError, he.Proto, he.Status, string(he.Body))
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// ValidateIPVersion returns an error if v is not a valid IP version for
+// OchamiClient.UseIPVersion.
+func ValidateIPVersion(v string) error {
+	switch strings.ToLower(v) {
+	case "", "auto", "4", "6":
+		return nil
+	}
+	return fmt.Errorf("invalid IP version %q (must be 4, 6, or auto)", v)
+}
+
+// UseIPVersion restricts the OchamiClient's connections to one IP address
+// family: "4" for IPv4 or "6" for IPv6. Hosts are then only resolved to
+// addresses of that family, which is useful with dual-stack clusters that
+// publish broken AAAA (or A) records. "auto" or an empty string use both
+// families, as Go does by default. The restriction does not apply to
+// connections tunneled through a jump host (see UseSSHTunnel).
+func (oc *OchamiClient) UseIPVersion(v string) error {
+	if oc == nil {
+		return fmt.Errorf("client is nil")
+	}
+	if err := ValidateIPVersion(v); err != nil {
+		return err
+	}
+	tc := oc.transportConfig
+	tc.IPVersion = strings.ToLower(v)
+	if tc.IPVersion == "auto" {
+		tc.IPVersion = ""
+	}
+
+	return oc.useTransport(tc)
+}
+
+// ipNetwork returns the network to dial for network (e.g. "tcp") restricted to
+// the IP address family ipVersion, e.g. "tcp4" for "4".
+func ipNetwork(network, ipVersion string) string {
+	switch ipVersion {
+	case "4", "6":
+		if network == "tcp" || network == "udp" || network == "ip" {
+			return network + ipVersion
+		}
+	}
+	return network
+}
+
+// ipVersionDialer returns a DialContext function for a transport that only
+// connects to addresses of the IP address family ipVersion. The dialer is
+// configured like the one of http.DefaultTransport.
+func ipVersionDialer(ipVersion string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, ipNetwork(network, ipVersion), addr)
+		if err != nil {
+			return nil, fmt.Errorf("%w (connections restricted to IPv%s)", err, ipVersion)
+		}
+		return conn, err
+	}
+}
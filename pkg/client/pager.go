@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// ErrNoMorePages is returned by Pager.Next once all pages have been returned.
+var ErrNoMorePages = errors.New("no more pages")
+
+// PageFunc fetches the page of items at cursor, an opaque position in a list
+// that is empty for the first page, and returns its items along with the
+// cursor of the next page, which is empty if the page was the last one.
+type PageFunc[T any] func(ctx context.Context, cursor string) (items []T, next string, err error)
+
+// Pager iterates over the pages of a list endpoint, hiding how the service
+// pages it (limit/offset, page tokens, or not at all). Create one with
+// NewPager, OffsetPager, or SinglePage. A Pager is not safe for concurrent use.
+type Pager[T any] struct {
+	fetch  PageFunc[T]
+	cursor string
+	done   bool
+}
+
+// NewPager returns a Pager that fetches pages with fetch. It can be used
+// directly for endpoints that page with tokens, passing the token of the
+// next page as the cursor.
+func NewPager[T any](fetch PageFunc[T]) *Pager[T] {
+	return &Pager[T]{fetch: fetch}
+}
+
+// OffsetPager returns a Pager for endpoints that page with a limit and an
+// offset. fetch is called with limit and the offset of each page in turn, and
+// paging stops at the first page with fewer than limit items. If limit is less
+// than 1, a single page is fetched with a limit of 0, which fetch should treat
+// as "no limit".
+func OffsetPager[T any](limit int, fetch func(ctx context.Context, limit, offset int) ([]T, error)) *Pager[T] {
+	if limit < 1 {
+		return SinglePage(func(ctx context.Context) ([]T, error) {
+			return fetch(ctx, 0, 0)
+		})
+	}
+	return NewPager(func(ctx context.Context, cursor string) ([]T, string, error) {
+		offset := 0
+		if cursor != "" {
+			var err error
+			if offset, err = strconv.Atoi(cursor); err != nil {
+				return nil, "", err
+			}
+		}
+		items, err := fetch(ctx, limit, offset)
+		if err != nil || len(items) < limit {
+			return items, "", err
+		}
+		return items, strconv.Itoa(offset + len(items)), nil
+	})
+}
+
+// SinglePage returns a Pager for endpoints that return the whole list at once,
+// so that they can be used like endpoints that page.
+func SinglePage[T any](fetch func(ctx context.Context) ([]T, error)) *Pager[T] {
+	return NewPager(func(ctx context.Context, _ string) ([]T, string, error) {
+		items, err := fetch(ctx)
+		return items, "", err
+	})
+}
+
+// More reports whether there are pages left to fetch.
+func (p *Pager[T]) More() bool {
+	return !p.done
+}
+
+// Next fetches and returns the next page. ErrNoMorePages is returned once all
+// pages have been returned. If ctx is done or fetching the page fails, the
+// error is returned and the same page is fetched by the next call.
+func (p *Pager[T]) Next(ctx context.Context) ([]T, error) {
+	if p.done {
+		return nil, ErrNoMorePages
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	items, next, err := p.fetch(ctx, p.cursor)
+	if err != nil {
+		return nil, err
+	}
+	p.cursor = next
+	p.done = next == ""
+	return items, nil
+}
+
+// All fetches the remaining pages and returns their items. If an error
+// occurs, the items fetched so far are returned along with it.
+func (p *Pager[T]) All(ctx context.Context) ([]T, error) {
+	var all []T
+	for p.More() {
+		items, err := p.Next(ctx)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, items...)
+	}
+	return all, nil
+}
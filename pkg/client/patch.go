@@ -0,0 +1,128 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PatchType is the format of a patch document sent with PatchDocument.
+type PatchType string
+
+const (
+	// PatchTypeJSONPatch is a JSON Patch (RFC 6902): a list of operations,
+	// such as {"op": "replace", "path": "/Role", "value": "Compute"},
+	// applied in order.
+	PatchTypeJSONPatch PatchType = "json-patch"
+
+	// PatchTypeMergePatch is a JSON Merge Patch (RFC 7386): an object whose
+	// members replace those of the resource, with null members removing
+	// them.
+	PatchTypeMergePatch PatchType = "merge-patch"
+)
+
+// Content types of the patch types, sent in the Content-Type header so that
+// the service knows how to apply the patch.
+const (
+	ContentTypeJSONPatch  = "application/json-patch+json"
+	ContentTypeMergePatch = "application/merge-patch+json"
+)
+
+// PatchTypes lists the supported patch types.
+var PatchTypes = []PatchType{PatchTypeJSONPatch, PatchTypeMergePatch}
+
+// jsonPatchOps maps each JSON Patch operation to whether it needs a "from"
+// member. All other members are checked by the service.
+var jsonPatchOps = map[string]bool{
+	"add":     false,
+	"remove":  false,
+	"replace": false,
+	"move":    true,
+	"copy":    true,
+	"test":    false,
+}
+
+// ParsePatchType returns the PatchType named s, ignoring case. An error is
+// returned if s is not a supported patch type.
+func ParsePatchType(s string) (PatchType, error) {
+	for _, pt := range PatchTypes {
+		if strings.EqualFold(s, string(pt)) {
+			return pt, nil
+		}
+	}
+	return "", fmt.Errorf("unknown patch type %q (supported: %s, %s)", s, PatchTypeJSONPatch, PatchTypeMergePatch)
+}
+
+// ContentType returns the value of the Content-Type header for a patch of type
+// pt, or an empty string if pt is not a supported patch type.
+func (pt PatchType) ContentType() string {
+	switch pt {
+	case PatchTypeJSONPatch:
+		return ContentTypeJSONPatch
+	case PatchTypeMergePatch:
+		return ContentTypeMergePatch
+	}
+	return ""
+}
+
+// ValidatePatch checks that patch is a well-formed patch of type pt, so that
+// mistakes are reported before anything is sent. A JSON Patch must be a list
+// of objects, each with a known "op", a "path", and a "from" for the "move"
+// and "copy" operations. A JSON Merge Patch must be an object. The values in
+// the patch are not checked against the resource.
+func ValidatePatch(pt PatchType, patch HTTPBody) error {
+	switch pt {
+	case PatchTypeJSONPatch:
+		var ops []map[string]json.RawMessage
+		if err := json.Unmarshal(patch, &ops); err != nil {
+			return fmt.Errorf("JSON Patch must be a list of operation objects: %w", err)
+		}
+		for i, op := range ops {
+			var name, path string
+			if err := json.Unmarshal(op["op"], &name); err != nil || name == "" {
+				return fmt.Errorf("operation %d: missing or invalid \"op\"", i)
+			}
+			needsFrom, known := jsonPatchOps[name]
+			if !known {
+				return fmt.Errorf("operation %d: unknown op %q", i, name)
+			}
+			if err := json.Unmarshal(op["path"], &path); err != nil {
+				return fmt.Errorf("operation %d (%s): missing or invalid \"path\"", i, name)
+			}
+			if _, ok := op["from"]; needsFrom && !ok {
+				return fmt.Errorf("operation %d (%s): missing \"from\"", i, name)
+			}
+		}
+	case PatchTypeMergePatch:
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(patch, &obj); err != nil || obj == nil {
+			return fmt.Errorf("JSON Merge Patch must be an object")
+		}
+	default:
+		return fmt.Errorf("unknown patch type %q", pt)
+	}
+	return nil
+}
+
+// PatchDocument is a wrapper around PatchData that sends patch, a patch of type
+// pt, to endpoint with the Content-Type header of pt, replacing any in headers.
+// headers is copied rather than modified. The patch is checked with
+// ValidatePatch first and an error is returned without sending anything if it
+// is invalid.
+func (oc *OchamiClient) PatchDocument(endpoint, query string, headers *HTTPHeaders, pt PatchType, patch HTTPBody) (HTTPEnvelope, error) {
+	if err := ValidatePatch(pt, patch); err != nil {
+		return HTTPEnvelope{}, fmt.Errorf("invalid %s: %w", pt, err)
+	}
+	newHeaders := NewHTTPHeaders()
+	if headers != nil {
+		for k, v := range *headers {
+			if !strings.EqualFold(k, "Content-Type") {
+				(*newHeaders)[k] = append([]string{}, v...)
+			}
+		}
+	}
+	if err := newHeaders.SetContentType(pt.ContentType()); err != nil {
+		return HTTPEnvelope{}, err
+	}
+	return oc.PatchData(endpoint, query, newHeaders, patch)
+}
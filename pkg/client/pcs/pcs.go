@@ -0,0 +1,208 @@
+package pcs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+)
+
+const (
+	serviceNamePCS = "PCS"
+	basePathPCS    = "/power-control/v1"
+
+	PCSRelpathTransitions = "/transitions"
+)
+
+// Power operations that PCS transitions perform.
+const (
+	OperationOn          = "on"
+	OperationOff         = "off"
+	OperationSoftOff     = "soft-off"
+	OperationSoftRestart = "soft-restart"
+	OperationHardRestart = "hard-restart"
+	OperationInit        = "init"
+	OperationForceOff    = "force-off"
+)
+
+// Operations lists the power operations that PCS supports.
+var Operations = []string{
+	OperationOn,
+	OperationOff,
+	OperationSoftOff,
+	OperationSoftRestart,
+	OperationHardRestart,
+	OperationInit,
+	OperationForceOff,
+}
+
+// Statuses of a transition. A transition is finished once it is completed or
+// aborted.
+const (
+	TransitionStatusNew           = "new"
+	TransitionStatusInProgress    = "in-progress"
+	TransitionStatusCompleted     = "completed"
+	TransitionStatusAbortSignaled = "abort-signaled"
+	TransitionStatusAborted       = "aborted"
+)
+
+// Statuses of the task of a transition for a single component.
+const (
+	TaskStatusNew         = "new"
+	TaskStatusInProgress  = "in-progress"
+	TaskStatusFailed      = "failed"
+	TaskStatusSucceeded   = "succeeded"
+	TaskStatusUnsupported = "unsupported"
+)
+
+// PCSClient is an OchamiClient that has its BasePath set configured to the one
+// that PCS uses.
+type PCSClient struct {
+	*client.OchamiClient
+}
+
+// Location identifies a component that a transition applies to.
+type Location struct {
+	Xname     string `json:"xname"`
+	DeputyKey string `json:"deputyKey,omitempty"`
+}
+
+// TransitionParameters is the payload used to start a transition.
+type TransitionParameters struct {
+	Operation           string     `json:"operation"`
+	TaskDeadlineMinutes *int       `json:"taskDeadlineMinutes,omitempty"`
+	Location            []Location `json:"location"`
+}
+
+// TaskCounts counts the tasks of a transition by status.
+type TaskCounts struct {
+	Total       int `json:"total"`
+	New         int `json:"new"`
+	InProgress  int `json:"in-progress"`
+	Failed      int `json:"failed"`
+	Succeeded   int `json:"succeeded"`
+	Unsupported int `json:"un-supported"`
+}
+
+// Task is the part of a transition that applies to a single component.
+type Task struct {
+	Xname                 string `json:"xname"`
+	TaskStatus            string `json:"taskStatus"`
+	TaskStatusDescription string `json:"taskStatusDescription,omitempty"`
+	Error                 string `json:"error,omitempty"`
+}
+
+// Transition represents a PCS transition, i.e. a power operation applied to a
+// set of components. Only TransitionID and Operation are set in the response to
+// starting a transition.
+type Transition struct {
+	TransitionID            string     `json:"transitionID"`
+	Operation               string     `json:"operation"`
+	CreateTime              string     `json:"createTime,omitempty"`
+	AutomaticExpirationTime string     `json:"automaticExpirationTime,omitempty"`
+	TransitionStatus        string     `json:"transitionStatus,omitempty"`
+	TaskCounts              TaskCounts `json:"taskCounts"`
+	Tasks                   []Task     `json:"tasks,omitempty"`
+}
+
+// Finished reports whether t has completed or was aborted.
+func (t Transition) Finished() bool {
+	return t.TransitionStatus == TransitionStatusCompleted || t.TransitionStatus == TransitionStatusAborted
+}
+
+// NewClient takes a baseURI and basePath and returns a pointer to a new
+// PCSClient. If an error occurred creating the embedded OchamiClient, it is
+// returned. If insecure is true, TLS certificates will not be verified.
+func NewClient(baseURI string, insecure bool) (*PCSClient, error) {
+	oc, err := client.NewOchamiClient(serviceNamePCS, baseURI, basePathPCS, insecure)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OchamiClient for %s: %w", serviceNamePCS, err)
+	}
+	pc := &PCSClient{
+		OchamiClient: oc,
+	}
+
+	return pc, err
+}
+
+// StartTransition is a wrapper function around OchamiClient.PostData that
+// starts a transition with params, setting token as the authorization bearer
+// in the headers. The returned Transition only has its TransitionID and
+// Operation set. Use GetTransition to follow its progress.
+func (pc *PCSClient) StartTransition(params TransitionParameters, token string) (Transition, error) {
+	var t Transition
+	body, err := json.Marshal(params)
+	if err != nil {
+		return t, fmt.Errorf("StartTransition(): failed to marshal transition parameters: %w", err)
+	}
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return t, fmt.Errorf("StartTransition(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	henv, err := pc.PostData(PCSRelpathTransitions, "", headers, body)
+	if err != nil {
+		return t, fmt.Errorf("StartTransition(): failed to start %s transition: %w", params.Operation, err)
+	}
+	if err := json.Unmarshal(henv.Body, &t); err != nil {
+		return t, fmt.Errorf("StartTransition(): failed to unmarshal transition: %w", err)
+	}
+
+	return t, nil
+}
+
+// GetTransition is a wrapper function around OchamiClient.GetData that gets the
+// transition with the ID id, including the status of each of its tasks.
+func (pc *PCSClient) GetTransition(id, token string) (Transition, error) {
+	var t Transition
+	if id == "" {
+		return t, fmt.Errorf("GetTransition(): transition ID cannot be empty")
+	}
+	tPath, err := url.JoinPath(PCSRelpathTransitions, id)
+	if err != nil {
+		return t, fmt.Errorf("GetTransition(): failed to join transition path (%s) with ID (%s): %w", PCSRelpathTransitions, id, err)
+	}
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return t, fmt.Errorf("GetTransition(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	henv, err := pc.GetData(tPath, "", headers)
+	if err != nil {
+		return t, fmt.Errorf("GetTransition(): error getting transition %s: %w", id, err)
+	}
+	if err := json.Unmarshal(henv.Body, &t); err != nil {
+		return t, fmt.Errorf("GetTransition(): failed to unmarshal transition %s: %w", id, err)
+	}
+
+	return t, nil
+}
+
+// AbortTransition is a wrapper function around OchamiClient.DeleteData that
+// signals PCS to abort the transition with the ID id. Tasks that have already
+// been carried out are not undone.
+func (pc *PCSClient) AbortTransition(id, token string) (client.HTTPEnvelope, error) {
+	var henv client.HTTPEnvelope
+	if id == "" {
+		return henv, fmt.Errorf("AbortTransition(): transition ID cannot be empty")
+	}
+	tPath, err := url.JoinPath(PCSRelpathTransitions, id)
+	if err != nil {
+		return henv, fmt.Errorf("AbortTransition(): failed to join transition path (%s) with ID (%s): %w", PCSRelpathTransitions, id, err)
+	}
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return henv, fmt.Errorf("AbortTransition(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	henv, err = pc.DeleteData(tPath, "", headers, nil)
+	if err != nil {
+		err = fmt.Errorf("AbortTransition(): error aborting transition %s: %w", id, err)
+	}
+
+	return henv, err
+}
@@ -0,0 +1,224 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotInPlan is wrapped by the error returned for a mutating request that is
+// not part of the plan being executed. Such requests are not sent.
+var ErrNotInPlan = errors.New("request not in plan")
+
+// PlanStep is a mutating request of a Plan. The body is identified by its
+// digest ("sha256:<hex>") so that a plan can be reviewed and verified without
+// containing the payloads. TargetID is a best-effort identifier of what the
+// request acts upon (e.g. a component xname) for the benefit of reviewers; it
+// is not used to match requests.
+type PlanStep struct {
+	Method     string `json:"method"`
+	URI        string `json:"uri"`
+	BodyDigest string `json:"body-digest,omitempty"`
+	TargetID   string `json:"target-id,omitempty"`
+}
+
+// matches reports whether s and o are the same request, ignoring TargetID.
+func (s PlanStep) matches(o PlanStep) bool {
+	return s.Method == o.Method && s.URI == o.URI && s.BodyDigest == o.BodyDigest
+}
+
+// Plan is a list of mutating requests. Create one with NewPlan to record the
+// requests that commands would send, or with ReadPlan to execute a previously
+// recorded (and reviewed) plan. A Plan is safe for concurrent use.
+type Plan struct {
+	mu      sync.Mutex
+	execute bool
+	steps   []PlanStep
+	done    []bool
+}
+
+// NewPlan returns an empty Plan that records mutating requests instead of
+// sending them.
+func NewPlan() *Plan {
+	return &Plan{}
+}
+
+// ReadPlan reads a plan, as written by Plan.Write, from r and returns a Plan
+// that sends mutating requests only if they are part of it.
+func ReadPlan(r io.Reader) (*Plan, error) {
+	p := &Plan{execute: true}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p.steps); err != nil {
+		return nil, fmt.Errorf("failed to decode plan: %w", err)
+	}
+	for i, s := range p.steps {
+		if s.Method == "" || s.URI == "" {
+			return nil, fmt.Errorf("step %d of plan is missing method or uri", i+1)
+		}
+	}
+	p.done = make([]bool, len(p.steps))
+	return p, nil
+}
+
+// Steps returns the steps of p. For a recording plan, these are the requests
+// recorded so far.
+func (p *Plan) Steps() []PlanStep {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PlanStep{}, p.steps...)
+}
+
+// Pending returns the steps of an executing plan that have not been sent.
+func (p *Plan) Pending() []PlanStep {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var pending []PlanStep
+	for i, s := range p.steps {
+		if i < len(p.done) && !p.done[i] {
+			pending = append(pending, s)
+		}
+	}
+	return pending
+}
+
+// Write writes the steps of p to w as an indented JSON array, suitable for
+// ReadPlan.
+func (p *Plan) Write(w io.Writer) error {
+	steps := p.Steps()
+	if steps == nil {
+		steps = []PlanStep{}
+	}
+	data, err := json.MarshalIndent(steps, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// intercept is called for each request before it is sent. If handled is
+// false, the request should be sent as usual. Otherwise, res or err should be
+// returned in place of sending it: a recording plan returns a synthetic 202
+// response and an executing plan returns an error wrapping ErrNotInPlan if the
+// request is not one of its steps that was not sent yet.
+func (p *Plan) intercept(method, uri string, body HTTPBody) (res *http.Response, handled bool, err error) {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return nil, false, nil
+	}
+	step := PlanStep{
+		Method:   method,
+		URI:      RedactURI(uri),
+		TargetID: planTarget(uri, body),
+	}
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		step.BodyDigest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.execute {
+		p.steps = append(p.steps, step)
+		return &http.Response{
+			Status:     "202 Accepted (planned)",
+			StatusCode: http.StatusAccepted,
+			Proto:      "HTTP/1.1",
+			Header:     http.Header{},
+			Body:       io.NopCloser(bytes.NewReader(nil)),
+		}, true, nil
+	}
+	for i, s := range p.steps {
+		if !p.done[i] && s.matches(step) {
+			p.done[i] = true
+			return nil, false, nil
+		}
+	}
+	if step.BodyDigest != "" {
+		return nil, true, fmt.Errorf("%w: %s %s with body %s", ErrNotInPlan, method, step.URI, step.BodyDigest)
+	}
+	return nil, true, fmt.Errorf("%w: %s %s", ErrNotInPlan, method, step.URI)
+}
+
+// planTargetKeys are the keys whose values identify what a request body is
+// about, in order of preference.
+var planTargetKeys = []string{"ID", "id", "xname", "Xname", "name", "Name", "label", "Label", "hosts", "macs", "nids"}
+
+// planTarget returns a best-effort identifier of what the request to uri with
+// body acts upon: the identifiers found in body (see planTargetKeys),
+// including those of items of lists in body, or else the last element of the
+// path of uri.
+func planTarget(uri string, body HTTPBody) string {
+	var v any
+	if len(body) > 0 && json.Unmarshal(body, &v) == nil {
+		if ids := planTargetIDs(v); len(ids) > 0 {
+			return strings.Join(ids, ",")
+		}
+	}
+	u, err := url.Parse(uri)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	return path.Base(u.Path)
+}
+
+func planTargetIDs(v any) []string {
+	switch t := v.(type) {
+	case []any:
+		var ids []string
+		for _, item := range t {
+			ids = append(ids, planTargetIDs(item)...)
+		}
+		return ids
+	case map[string]any:
+		for _, k := range planTargetKeys {
+			switch id := t[k].(type) {
+			case string:
+				if id != "" {
+					return []string{id}
+				}
+			case float64:
+				return []string{fmt.Sprint(id)}
+			case []any:
+				var ids []string
+				for _, e := range id {
+					switch e.(type) {
+					case string, float64:
+						ids = append(ids, fmt.Sprint(e))
+					}
+				}
+				if len(ids) > 0 {
+					return ids
+				}
+			}
+		}
+		// Look for identifiers in lists, e.g. {"Components": [...]}
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var ids []string
+		for _, k := range keys {
+			if list, ok := t[k].([]any); ok {
+				ids = append(ids, planTargetIDs(list)...)
+			}
+		}
+		return ids
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// QueryEncoding is how the query values passed to the *Values methods of
+// OchamiClient (e.g. GetDataValues) are encoded into the query string of the
+// request.
+type QueryEncoding int
+
+const (
+	// QueryEncodingForm encodes query values as url.Values.Encode does
+	// (application/x-www-form-urlencoded), e.g. spaces as "+". This is the
+	// default.
+	QueryEncodingForm QueryEncoding = iota
+
+	// QueryEncodingPercent encodes query values with percent-encoding only
+	// (RFC 3986), e.g. spaces as "%20", for services that do not decode "+"
+	// as a space.
+	QueryEncodingPercent
+)
+
+// Encode returns query as a query string (without the '?') encoded with e.
+// Keys are sorted, and a key with several values is repeated once per value,
+// in order, e.g. "id=x1&id=x2". If query is empty, an empty string is returned.
+func (e QueryEncoding) Encode(query url.Values) string {
+	if e != QueryEncodingPercent {
+		return query.Encode()
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		key := url.PathEscape(k)
+		for _, v := range query[k] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(key + "=" + queryEscape(v))
+		}
+	}
+	return b.String()
+}
+
+// queryEscape percent-encodes s for use as a query value. Unlike
+// url.PathEscape, it also escapes the characters that delimit query
+// parameters.
+func queryEscape(s string) string {
+	return strings.NewReplacer("+", "%2B", "&", "%26", "=", "%3D", ";", "%3B").Replace(url.PathEscape(s))
+}
+
+// GetURIValues is like GetURI, except that the query is passed as values that
+// are encoded according to oc.QueryEncoding, so that callers need not encode
+// it themselves.
+func (oc *OchamiClient) GetURIValues(endpoint string, query url.Values) (string, error) {
+	return oc.GetURI(endpoint, oc.QueryEncoding.Encode(query))
+}
+
+// GetDataValues is like GetData, except that the query is passed as values
+// (see GetURIValues).
+func (oc *OchamiClient) GetDataValues(endpoint string, query url.Values, headers *HTTPHeaders) (HTTPEnvelope, error) {
+	return oc.GetData(endpoint, oc.QueryEncoding.Encode(query), headers)
+}
+
+// GetDataValuesContext is like GetDataContext, except that the query is passed
+// as values (see GetURIValues).
+func (oc *OchamiClient) GetDataValuesContext(ctx context.Context, endpoint string, query url.Values, headers *HTTPHeaders) (HTTPEnvelope, error) {
+	return oc.GetDataContext(ctx, endpoint, oc.QueryEncoding.Encode(query), headers)
+}
+
+// PostDataValues is like PostData, except that the query is passed as values
+// (see GetURIValues).
+func (oc *OchamiClient) PostDataValues(endpoint string, query url.Values, headers *HTTPHeaders, body HTTPBody) (HTTPEnvelope, error) {
+	return oc.PostData(endpoint, oc.QueryEncoding.Encode(query), headers, body)
+}
+
+// PutDataValues is like PutData, except that the query is passed as values
+// (see GetURIValues).
+func (oc *OchamiClient) PutDataValues(endpoint string, query url.Values, headers *HTTPHeaders, body HTTPBody) (HTTPEnvelope, error) {
+	return oc.PutData(endpoint, oc.QueryEncoding.Encode(query), headers, body)
+}
+
+// PatchDataValues is like PatchData, except that the query is passed as values
+// (see GetURIValues).
+func (oc *OchamiClient) PatchDataValues(endpoint string, query url.Values, headers *HTTPHeaders, body HTTPBody) (HTTPEnvelope, error) {
+	return oc.PatchData(endpoint, oc.QueryEncoding.Encode(query), headers, body)
+}
+
+// DeleteDataValues is like DeleteData, except that the query is passed as
+// values (see GetURIValues).
+func (oc *OchamiClient) DeleteDataValues(endpoint string, query url.Values, headers *HTTPHeaders, body HTTPBody) (HTTPEnvelope, error) {
+	return oc.DeleteData(endpoint, oc.QueryEncoding.Encode(query), headers, body)
+}
@@ -0,0 +1,166 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces secrets in debug output.
+const Redacted = "REDACTED"
+
+// LogSecrets disables redaction of secrets (tokens, passwords, etc.) in debug
+// output when true. It should only be enabled for deep debugging, since logs
+// then contain credentials. It governs the package's logger, which all clients
+// write to, so it is a process-wide setting for command-line programs (e.g.
+// their --log-secrets flag) rather than one of each client.
+var LogSecrets bool
+
+var (
+	// sensitiveHeaders are the canonical names of headers whose values are
+	// redacted.
+	sensitiveHeaders = map[string]bool{
+		"Authorization":       true,
+		"Proxy-Authorization": true,
+		"Cookie":              true,
+		"Set-Cookie":          true,
+		"X-Api-Key":           true,
+		"X-Auth-Token":        true,
+	}
+
+	// sensitiveKeyParts are substrings of JSON keys and query parameters
+	// (compared case-insensitively) whose values are redacted.
+	sensitiveKeyParts = []string{
+		"password", "passwd", "secret", "token", "apikey", "api_key",
+		"private_key", "privatekey", "credential",
+	}
+)
+
+// Redact returns s, or Redacted if LogSecrets is false. It is meant for
+// logging values that are known to be secrets, e.g. access tokens.
+func Redact(s string) string {
+	if LogSecrets || s == "" {
+		return s
+	}
+	return Redacted
+}
+
+// isSensitiveKey reports whether key names a value that should be redacted.
+func isSensitiveKey(key string) bool {
+	k := strings.ToLower(key)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(k, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeader returns the values of header key with any secrets replaced by
+// Redacted. For Authorization headers, the scheme (e.g. "Bearer") is kept so
+// that it remains visible which kind of credentials were sent.
+func RedactHeader(key string, vals []string) []string {
+	if LogSecrets || !sensitiveHeaders[http.CanonicalHeaderKey(key)] {
+		return vals
+	}
+	redacted := make([]string, len(vals))
+	for i, v := range vals {
+		if scheme, _, found := strings.Cut(v, " "); found && strings.HasSuffix(http.CanonicalHeaderKey(key), "Authorization") {
+			redacted[i] = scheme + " " + Redacted
+		} else {
+			redacted[i] = Redacted
+		}
+	}
+	return redacted
+}
+
+// RedactURI returns uri with the values of query parameters whose names look
+// like they hold secrets replaced by Redacted, as well as any password in the
+// userinfo. If uri cannot be parsed, it is returned as is.
+func RedactURI(uri string) string {
+	if LogSecrets {
+		return uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	if _, set := u.User.Password(); set {
+		u.User = url.UserPassword(u.User.Username(), Redacted)
+	}
+	if u.RawQuery != "" {
+		q := u.Query()
+		changed := false
+		for k, vals := range q {
+			if isSensitiveKey(k) {
+				for i := range vals {
+					vals[i] = Redacted
+				}
+				changed = true
+			}
+		}
+		if changed {
+			u.RawQuery = q.Encode()
+		}
+	}
+	return u.String()
+}
+
+// RedactBody returns body redacted as by RedactSecrets, unless LogSecrets is
+// set.
+func RedactBody(body []byte) []byte {
+	if LogSecrets {
+		return body
+	}
+	return RedactSecrets(body)
+}
+
+// RedactSecrets returns body with the values of JSON object keys that look like
+// they hold secrets (e.g. "Password" or "access_token") replaced by Redacted,
+// at any depth, regardless of LogSecrets. Bodies that are not JSON, or do not
+// contain such keys, are returned as is.
+func RedactSecrets(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+	if !redactValue(v) {
+		return body
+	}
+	redacted, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// redactValue replaces, in place, the values of sensitive keys in the objects
+// contained in v and reports whether it replaced any.
+func redactValue(v any) bool {
+	changed := false
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if isSensitiveKey(k) {
+				if s, ok := val.(string); ok && s == "" {
+					continue
+				}
+				t[k] = Redacted
+				changed = true
+			} else if redactValue(val) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, val := range t {
+			if redactValue(val) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,60 @@
+package client
+
+import "testing"
+
+func TestRedactSecrets(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "redfish endpoint",
+			body: `{"RedfishEndpoints":[{"ID":"x1000c1s7b0","User":"root","Password":"hunter2"}]}`,
+			want: `{"RedfishEndpoints":[{"ID":"x1000c1s7b0","Password":"REDACTED","User":"root"}]}`,
+		},
+		{
+			name: "nested",
+			body: `{"meta-data":{"access_token":"abc","api_key":{"value":"def"}}}`,
+			want: `{"meta-data":{"access_token":"REDACTED","api_key":"REDACTED"}}`,
+		},
+		{
+			name: "empty secret kept",
+			body: `{"Password":""}`,
+			want: `{"Password":""}`,
+		},
+		{
+			name: "no secrets",
+			body: `{ "ID": "x1000c1s7b0" }`,
+			want: `{ "ID": "x1000c1s7b0" }`,
+		},
+		{
+			name: "not JSON",
+			body: `password=hunter2`,
+			want: `password=hunter2`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(RedactSecrets([]byte(tt.body))); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactBodyLogSecrets(t *testing.T) {
+	body := []byte(`{"Password":"hunter2"}`)
+	if got := string(RedactBody(body)); got != `{"Password":"REDACTED"}` {
+		t.Errorf("got %s, want the password redacted", got)
+	}
+
+	LogSecrets = true
+	t.Cleanup(func() { LogSecrets = false })
+	if got := string(RedactBody(body)); got != string(body) {
+		t.Errorf("got %s with LogSecrets set, want the body as is", got)
+	}
+	if got := string(RedactSecrets(body)); got != `{"Password":"REDACTED"}` {
+		t.Errorf("RedactSecrets returned %s with LogSecrets set, want the password redacted", got)
+	}
+}
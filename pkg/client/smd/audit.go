@@ -0,0 +1,145 @@
+package smd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// InterfaceConflict is a value (a MAC or IP address) claimed by more than one
+// ethernet interface.
+type InterfaceConflict struct {
+	Value        string   `json:"value"`
+	InterfaceIDs []string `json:"interfaceIDs"`
+}
+
+// DanglingInterface is an ethernet interface whose ComponentID does not refer
+// to a component in SMD.
+type DanglingInterface struct {
+	ID          string `json:"id"`
+	ComponentID string `json:"componentID"`
+}
+
+// InterfaceAudit is the result of checking the ethernet interfaces in SMD for
+// conflicts with each other and with the components in SMD.
+type InterfaceAudit struct {
+	DuplicateMACs               []InterfaceConflict `json:"duplicateMACs"`
+	DuplicateIPs                []InterfaceConflict `json:"duplicateIPs"`
+	DanglingInterfaces          []DanglingInterface `json:"danglingInterfaces"`
+	ComponentsWithoutInterfaces []string            `json:"componentsWithoutInterfaces"`
+}
+
+// Empty reports whether a contains no problems.
+func (a InterfaceAudit) Empty() bool {
+	return len(a.DuplicateMACs) == 0 && len(a.DuplicateIPs) == 0 &&
+		len(a.DanglingInterfaces) == 0 && len(a.ComponentsWithoutInterfaces) == 0
+}
+
+// AuditEthernetInterfaces fetches all ethernet interfaces and components from
+// SMD and checks them with AuditInterfaces. Only components whose type is one of
+// compTypes (case-insensitive) are reported as having no interfaces, or all
+// components if compTypes is empty. Nothing is modified.
+func (sc *SMDClient) AuditEthernetInterfaces(ctx context.Context, compTypes []string, token string) (InterfaceAudit, error) {
+	eis, err := sc.EthernetInterfacePager("", token).All(ctx)
+	if err != nil {
+		return InterfaceAudit{}, fmt.Errorf("AuditEthernetInterfaces(): failed to get ethernet interfaces: %w", err)
+	}
+	comps, err := sc.ComponentPager("", token).All(ctx)
+	if err != nil {
+		return InterfaceAudit{}, fmt.Errorf("AuditEthernetInterfaces(): failed to get components: %w", err)
+	}
+	return AuditInterfaces(eis, comps, compTypes), nil
+}
+
+// AuditInterfaces checks eis for MAC addresses and IP addresses claimed by more
+// than one interface and for interfaces whose ComponentID is not the ID of one
+// of comps, and checks comps for components that no interface belongs to. Only
+// components whose type is one of compTypes (case-insensitive) are checked for
+// interfaces, or all components if compTypes is empty. MAC addresses are
+// compared regardless of case and separators. All lists in the result are
+// sorted.
+func AuditInterfaces(eis []EthernetInterface, comps []Component, compTypes []string) InterfaceAudit {
+	var (
+		audit   InterfaceAudit
+		macs    = make(map[string][]string)
+		macVals = make(map[string]string)
+		ips     = make(map[string][]string)
+		hasEI   = make(map[string]bool)
+		compIDs = make(map[string]bool, len(comps))
+	)
+	for _, c := range comps {
+		compIDs[strings.ToLower(c.ID)] = true
+	}
+
+	for _, ei := range eis {
+		if ei.MACAddress != "" {
+			mac := normalizeMAC(ei.MACAddress)
+			macs[mac] = append(macs[mac], ei.ID)
+			if _, ok := macVals[mac]; !ok {
+				macVals[mac] = ei.MACAddress
+			}
+		}
+		// An interface can list the same IP address on more than one
+		// network, which is not a conflict.
+		seen := make(map[string]bool)
+		for _, ip := range ei.IPAddresses {
+			if ip.IPAddress == "" || seen[ip.IPAddress] {
+				continue
+			}
+			seen[ip.IPAddress] = true
+			ips[ip.IPAddress] = append(ips[ip.IPAddress], ei.ID)
+		}
+		if ei.ComponentID != "" {
+			hasEI[strings.ToLower(ei.ComponentID)] = true
+			if !compIDs[strings.ToLower(ei.ComponentID)] {
+				audit.DanglingInterfaces = append(audit.DanglingInterfaces, DanglingInterface{
+					ID:          ei.ID,
+					ComponentID: ei.ComponentID,
+				})
+			}
+		}
+	}
+
+	for mac, ids := range macs {
+		if len(ids) > 1 {
+			slices.Sort(ids)
+			audit.DuplicateMACs = append(audit.DuplicateMACs, InterfaceConflict{Value: macVals[mac], InterfaceIDs: ids})
+		}
+	}
+	for ip, ids := range ips {
+		if len(ids) > 1 {
+			slices.Sort(ids)
+			audit.DuplicateIPs = append(audit.DuplicateIPs, InterfaceConflict{Value: ip, InterfaceIDs: ids})
+		}
+	}
+	for _, c := range comps {
+		if hasEI[strings.ToLower(c.ID)] {
+			continue
+		}
+		if len(compTypes) > 0 && !slices.ContainsFunc(compTypes, func(t string) bool { return strings.EqualFold(t, c.Type) }) {
+			continue
+		}
+		audit.ComponentsWithoutInterfaces = append(audit.ComponentsWithoutInterfaces, c.ID)
+	}
+
+	byValue := func(a, b InterfaceConflict) int { return strings.Compare(a.Value, b.Value) }
+	slices.SortFunc(audit.DuplicateMACs, byValue)
+	slices.SortFunc(audit.DuplicateIPs, byValue)
+	slices.SortFunc(audit.DanglingInterfaces, func(a, b DanglingInterface) int { return strings.Compare(a.ID, b.ID) })
+	slices.Sort(audit.ComponentsWithoutInterfaces)
+
+	return audit
+}
+
+// normalizeMAC returns mac in lower case without separators so that MAC
+// addresses written differently can be compared.
+func normalizeMAC(mac string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '-', '.':
+			return -1
+		}
+		return r
+	}, strings.ToLower(mac))
+}
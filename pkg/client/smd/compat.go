@@ -0,0 +1,20 @@
+package smd
+
+import (
+	"net/http"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+)
+
+func init() {
+	// Redfish endpoints are sent with the V2 schema (see
+	// RedfishEndpointV2), whose fields older versions of SMD reject
+	client.RegisterCompatRule(client.CompatRule{
+		Service:  serviceNameSMD,
+		Before:   "2.0",
+		Endpoint: SMDRelpathRedfishEndpoints,
+		Methods:  []string{http.MethodPost, http.MethodPut},
+		Strip:    []string{"SchemaVersion", "Systems", "Managers"},
+		Reason:   "redfish endpoints are sent with the V1 schema, so SMD does not create components or interfaces from their Systems and Managers",
+	})
+}
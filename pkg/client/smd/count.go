@@ -0,0 +1,84 @@
+package smd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+)
+
+// CountComponents returns the number of components in SMD, e.g. to tell how
+// many DeleteComponentsAll would delete.
+func (sc *SMDClient) CountComponents(token string) (int, error) {
+	henv, err := sc.GetComponents("", token)
+	if err != nil {
+		return 0, fmt.Errorf("CountComponents(): %w", err)
+	}
+	var comps ComponentSlice
+	if err := json.Unmarshal(henv.Body, &comps); err != nil {
+		return 0, fmt.Errorf("CountComponents(): failed to unmarshal components: %w", err)
+	}
+
+	return len(comps.Components), nil
+}
+
+// CountEthernetInterfaces returns the number of ethernet interfaces in SMD,
+// e.g. to tell how many DeleteEthernetInterfacesAll would delete.
+func (sc *SMDClient) CountEthernetInterfaces(token string) (int, error) {
+	henv, err := sc.getWithToken(SMDRelpathEthernetInterfaces, token)
+	if err != nil {
+		return 0, fmt.Errorf("CountEthernetInterfaces(): %w", err)
+	}
+	var eis []json.RawMessage
+	if err := json.Unmarshal(henv.Body, &eis); err != nil {
+		return 0, fmt.Errorf("CountEthernetInterfaces(): failed to unmarshal ethernet interfaces: %w", err)
+	}
+
+	return len(eis), nil
+}
+
+// CountRedfishEndpoints returns the number of redfish endpoints in SMD, e.g.
+// to tell how many DeleteRedfishEndpointsAll would delete.
+func (sc *SMDClient) CountRedfishEndpoints(token string) (int, error) {
+	henv, err := sc.GetRedfishEndpoints("", token)
+	if err != nil {
+		return 0, fmt.Errorf("CountRedfishEndpoints(): %w", err)
+	}
+	var rfes struct {
+		RedfishEndpoints []json.RawMessage `json:"RedfishEndpoints"`
+	}
+	if err := json.Unmarshal(henv.Body, &rfes); err != nil {
+		return 0, fmt.Errorf("CountRedfishEndpoints(): failed to unmarshal redfish endpoints: %w", err)
+	}
+
+	return len(rfes.RedfishEndpoints), nil
+}
+
+// CountComponentEndpoints returns the number of component endpoints in SMD,
+// e.g. to tell how many DeleteComponentEndpointsAll would delete.
+func (sc *SMDClient) CountComponentEndpoints(token string) (int, error) {
+	henv, err := sc.GetComponentEndpointsAll(token)
+	if err != nil {
+		return 0, fmt.Errorf("CountComponentEndpoints(): %w", err)
+	}
+	var ces struct {
+		ComponentEndpoints []json.RawMessage `json:"ComponentEndpoints"`
+	}
+	if err := json.Unmarshal(henv.Body, &ces); err != nil {
+		return 0, fmt.Errorf("CountComponentEndpoints(): failed to unmarshal component endpoints: %w", err)
+	}
+
+	return len(ces.ComponentEndpoints), nil
+}
+
+// getWithToken gets relpath from SMD, presenting token as the authorization
+// bearer if it is not empty.
+func (sc *SMDClient) getWithToken(relpath, token string) (client.HTTPEnvelope, error) {
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return client.HTTPEnvelope{}, fmt.Errorf("error setting token in HTTP headers: %w", err)
+		}
+	}
+	return sc.GetData(relpath, "", headers)
+}
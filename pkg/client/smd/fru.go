@@ -0,0 +1,137 @@
+package smd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+)
+
+// SMD keeps hardware inventory two ways: by location (xname), which is where a
+// part is installed, and by FRU (field-replaceable unit) ID, which identifies
+// the part itself wherever it is installed. The functions below expose the
+// FRU-centric view so that a part can be followed as it moves between
+// locations.
+
+// HardwareEvent is an event in SMD's hardware inventory history, recording
+// that the FRU with FRUID was e.g. added to or removed from location ID.
+type HardwareEvent struct {
+	ID        string `json:"ID"`
+	FRUID     string `json:"FRUID"`
+	Timestamp string `json:"Timestamp"`
+	EventType string `json:"EventType"`
+}
+
+// HardwareHistory is the history of a location or, in the FRU-centric view, of
+// a FRU, in which case ID is the FRU ID.
+type HardwareHistory struct {
+	ID      string          `json:"ID"`
+	History []HardwareEvent `json:"History"`
+}
+
+// HardwareHistoryArray is the format of SMD's hardware inventory history
+// responses.
+type HardwareHistoryArray struct {
+	Components []HardwareHistory `json:"Components"`
+}
+
+// FRULocation is where a FRU is installed now and where it has been, as
+// determined by LocateFRU.
+type FRULocation struct {
+	FRUID string `json:"fruID"`
+
+	// Locations are the xnames of the locations the FRU populates now. It
+	// is empty if the FRU is not installed.
+	Locations []string `json:"locations"`
+
+	// History are the events of the FRU, oldest first.
+	History []HardwareEvent `json:"history"`
+}
+
+// getHardwareEndpoint is a wrapper around OchamiClient.GetDataContext that gets
+// endpoint, one of SMD's hardware inventory API endpoints, with query (without
+// the "?"), setting token, if not empty, as the authorization bearer. fn is
+// the name of the calling function, for errors.
+func (sc *SMDClient) getHardwareEndpoint(ctx context.Context, fn, endpoint, query, token string) (client.HTTPEnvelope, error) {
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return client.HTTPEnvelope{}, fmt.Errorf("%s(): error setting token in HTTP headers: %w", fn, err)
+		}
+	}
+	henv, err := sc.GetDataContext(ctx, endpoint, query, headers)
+	if err != nil {
+		err = fmt.Errorf("%s(): error getting %s: %w", fn, endpoint, err)
+	}
+
+	return henv, err
+}
+
+// GetFRUs gets the hardware inventory by FRU from SMD, i.e. the FRUs it knows
+// of, whether or not they are installed. SMD accepts the fruid, type,
+// manufacturer, partnumber, and serialnumber query parameters, which are
+// encoded according to the client's QueryEncoding. token, if not empty, is
+// sent as the authorization bearer.
+func (sc *SMDClient) GetFRUs(query url.Values, token string) (client.HTTPEnvelope, error) {
+	return sc.getHardwareEndpoint(context.Background(), "GetFRUs", SMDRelpathHardwareByFRU, sc.QueryEncoding.Encode(query), token)
+}
+
+// GetFRUHistory gets the hardware inventory history by FRU from SMD, i.e. the
+// events of each FRU grouped by FRU rather than by location. SMD accepts the
+// id (FRU ID), eventtype, starttime, and endtime query parameters, the latter
+// two as RFC 3339 timestamps. token, if not empty, is sent as the
+// authorization bearer.
+func (sc *SMDClient) GetFRUHistory(query url.Values, token string) (client.HTTPEnvelope, error) {
+	return sc.getHardwareEndpoint(context.Background(), "GetFRUHistory", SMDRelpathFRUHistory, sc.QueryEncoding.Encode(query), token)
+}
+
+// LocateFRU determines where the FRU with ID fruID is installed now, from the
+// locations in SMD's hardware inventory that it populates, and where it has
+// been, from its history. token, if not empty, is sent as the authorization
+// bearer.
+func (sc *SMDClient) LocateFRU(ctx context.Context, fruID, token string) (FRULocation, error) {
+	loc := FRULocation{FRUID: fruID, Locations: []string{}, History: []HardwareEvent{}}
+
+	henv, err := sc.getHardwareEndpoint(ctx, "LocateFRU", SMDRelpathHardware, sc.QueryEncoding.Encode(url.Values{"fruid": {fruID}}), token)
+	if err != nil {
+		return loc, err
+	}
+	var hw []struct {
+		ID string `json:"ID"`
+	}
+	if err := json.Unmarshal(henv.Body, &hw); err != nil {
+		return loc, fmt.Errorf("LocateFRU(): failed to unmarshal hardware inventory: %w", err)
+	}
+	for _, h := range hw {
+		loc.Locations = append(loc.Locations, h.ID)
+	}
+	sort.Strings(loc.Locations)
+
+	histPath, err := url.JoinPath(SMDRelpathFRUHistory, fruID)
+	if err != nil {
+		return loc, fmt.Errorf("LocateFRU(): failed to join FRU history path (%s) with FRU ID (%s): %w", SMDRelpathFRUHistory, fruID, err)
+	}
+	henv, err = sc.getHardwareEndpoint(ctx, "LocateFRU", histPath, "", token)
+	if err != nil {
+		return loc, err
+	}
+	// SMD returns the history of a single FRU either on its own or in an
+	// array like that of all FRUs, depending on its version
+	var hist HardwareHistory
+	var hists HardwareHistoryArray
+	if err := json.Unmarshal(henv.Body, &hists); err == nil && len(hists.Components) > 0 {
+		for _, h := range hists.Components {
+			hist.History = append(hist.History, h.History...)
+		}
+	} else if err := json.Unmarshal(henv.Body, &hist); err != nil {
+		return loc, fmt.Errorf("LocateFRU(): failed to unmarshal FRU history: %w", err)
+	}
+	loc.History = append(loc.History, hist.History...)
+	// RFC 3339 timestamps in the same zone sort chronologically as strings
+	sort.SliceStable(loc.History, func(i, j int) bool { return loc.History[i].Timestamp < loc.History[j].Timestamp })
+
+	return loc, nil
+}
@@ -0,0 +1,191 @@
+package smd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/pkg/nid"
+)
+
+// verifyBatchSize is the number of xnames queried per request by VerifyHosts
+// and InterfaceMACs, which keeps the query string of each request to a
+// reasonable length.
+const verifyBatchSize = 100
+
+// UnknownHosts lists the xnames, MAC addresses, and NIDs referred to (e.g. by
+// boot parameters) that do not exist in SMD.
+type UnknownHosts struct {
+	Xnames []string `json:"xnames,omitempty"`
+	MACs   []string `json:"macs,omitempty"`
+	NIDs   []int32  `json:"nids,omitempty"`
+}
+
+// Empty reports whether u lists no unknown hosts.
+func (u UnknownHosts) Empty() bool {
+	return len(u.Xnames) == 0 && len(u.MACs) == 0 && len(u.NIDs) == 0
+}
+
+// String lists the unknown hosts in u for messages, e.g. "xnames x1000c1s7b0n9;
+// NIDs 7-9".
+func (u UnknownHosts) String() string {
+	var parts []string
+	if len(u.Xnames) > 0 {
+		parts = append(parts, "xnames "+strings.Join(u.Xnames, ","))
+	}
+	if len(u.MACs) > 0 {
+		parts = append(parts, "MAC addresses "+strings.Join(u.MACs, ","))
+	}
+	if len(u.NIDs) > 0 {
+		nids := make([]int64, len(u.NIDs))
+		for i, n := range u.NIDs {
+			nids[i] = int64(n)
+		}
+		parts = append(parts, "NIDs "+nid.Format(nids))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// VerifyHosts checks that each of xnames is the ID of a component in SMD, that
+// each of macs is the MAC address of an ethernet interface in SMD, and that
+// each of nids is the NID of a component in SMD, and returns those that are
+// not. Xnames are compared regardless of case and MAC addresses regardless of
+// case and separators. The special host "Default" of BSS, which applies to all
+// nodes, is not checked. Nothing is modified.
+func (sc *SMDClient) VerifyHosts(xnames, macs []string, nids []int32, token string) (UnknownHosts, error) {
+	var unknown UnknownHosts
+
+	var toCheck []string
+	for _, x := range xnames {
+		if !strings.EqualFold(x, "Default") {
+			toCheck = append(toCheck, x)
+		}
+	}
+	found := make(map[string]bool)
+	for start := 0; start < len(toCheck); start += verifyBatchSize {
+		batch := toCheck[start:min(start+verifyBatchSize, len(toCheck))]
+		vals := url.Values{}
+		for _, x := range batch {
+			vals.Add("id", x)
+		}
+		henv, err := sc.GetComponentsValues(vals, token)
+		if err != nil {
+			return unknown, fmt.Errorf("VerifyHosts(): failed to get components: %w", err)
+		}
+		var compSlice ComponentSlice
+		if err := json.Unmarshal(henv.Body, &compSlice); err != nil {
+			return unknown, fmt.Errorf("VerifyHosts(): failed to unmarshal components: %w", err)
+		}
+		for _, c := range compSlice.Components {
+			found[strings.ToLower(c.ID)] = true
+		}
+	}
+	for _, x := range toCheck {
+		if !found[strings.ToLower(x)] {
+			unknown.Xnames = append(unknown.Xnames, x)
+		}
+	}
+
+	if len(macs) > 0 {
+		henv, err := sc.GetEthernetInterfaces(EthernetInterfaceFilter{MACs: macs})
+		if err != nil {
+			return unknown, fmt.Errorf("VerifyHosts(): failed to get ethernet interfaces: %w", err)
+		}
+		var eis []EthernetInterface
+		if err := json.Unmarshal(henv.Body, &eis); err != nil {
+			return unknown, fmt.Errorf("VerifyHosts(): failed to unmarshal ethernet interfaces: %w", err)
+		}
+		foundMACs := make(map[string]bool, len(eis))
+		for _, ei := range eis {
+			foundMACs[normalizeMAC(ei.MACAddress)] = true
+		}
+		for _, m := range macs {
+			if !foundMACs[normalizeMAC(m)] {
+				unknown.MACs = append(unknown.MACs, m)
+			}
+		}
+	}
+
+	if len(nids) > 0 {
+		nids64 := make([]int64, len(nids))
+		for i, n := range nids {
+			nids64[i] = int64(n)
+		}
+		comps, err := sc.GetComponentsByNIDs(nids64, token)
+		if err != nil {
+			return unknown, fmt.Errorf("VerifyHosts(): %w", err)
+		}
+		foundNIDs := make(map[int64]bool, len(comps))
+		for _, c := range comps {
+			foundNIDs[c.NID] = true
+		}
+		for _, n := range nids {
+			if !foundNIDs[int64(n)] {
+				unknown.NIDs = append(unknown.NIDs, n)
+			}
+		}
+		sort.Slice(unknown.NIDs, func(i, j int) bool { return unknown.NIDs[i] < unknown.NIDs[j] })
+	}
+
+	return unknown, nil
+}
+
+// GroupMembers returns the xnames of the members of the groups labeled labels,
+// sorted and without duplicates, e.g. to resolve groups to the hosts of boot
+// parameters. An error is returned if a group cannot be fetched.
+func (sc *SMDClient) GroupMembers(labels []string, token string) ([]string, error) {
+	seen := make(map[string]bool)
+	var members []string
+	for _, label := range labels {
+		group, err := sc.GetGroup(label, token)
+		if err != nil {
+			return nil, fmt.Errorf("GroupMembers(): %w", err)
+		}
+		for _, id := range group.Members.IDs {
+			if !seen[id] {
+				seen[id] = true
+				members = append(members, id)
+			}
+		}
+	}
+	sort.Strings(members)
+
+	return members, nil
+}
+
+// InterfaceMACs returns the MAC addresses of the ethernet interfaces of the
+// components with IDs xnames, sorted, as well as the xnames without ethernet
+// interfaces. Xnames are compared regardless of case.
+func (sc *SMDClient) InterfaceMACs(xnames []string) (macs, without []string, err error) {
+	if len(xnames) == 0 {
+		return nil, nil, nil
+	}
+	withIfaces := make(map[string]bool, len(xnames))
+	for start := 0; start < len(xnames); start += verifyBatchSize {
+		batch := xnames[start:min(start+verifyBatchSize, len(xnames))]
+		henv, err := sc.GetEthernetInterfaces(EthernetInterfaceFilter{ComponentIDs: batch})
+		if err != nil {
+			return nil, nil, fmt.Errorf("InterfaceMACs(): failed to get ethernet interfaces: %w", err)
+		}
+		var eis []EthernetInterface
+		if err := json.Unmarshal(henv.Body, &eis); err != nil {
+			return nil, nil, fmt.Errorf("InterfaceMACs(): failed to unmarshal ethernet interfaces: %w", err)
+		}
+		for _, ei := range eis {
+			if ei.MACAddress != "" {
+				macs = append(macs, ei.MACAddress)
+				withIfaces[strings.ToLower(ei.ComponentID)] = true
+			}
+		}
+	}
+	for _, x := range xnames {
+		if !withIfaces[strings.ToLower(x)] {
+			without = append(without, x)
+		}
+	}
+	sort.Strings(macs)
+
+	return macs, without, nil
+}
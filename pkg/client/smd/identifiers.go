@@ -0,0 +1,227 @@
+package smd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kinds of identifiers of components, as returned by ClassifyIdentifier.
+const (
+	IdentifierXname = "xname"
+	IdentifierMAC   = "mac"
+	IdentifierNID   = "nid"
+)
+
+var (
+	identifierXnameRegexp = regexp.MustCompile(`^(?i)x[0-9]+(?:[a-z]+[0-9]+)*$`)
+	identifierMACRegexp   = regexp.MustCompile(`^(?i)(?:[0-9a-f]{2}(?:[:-][0-9a-f]{2}){5}|[0-9a-f]{12}|[0-9a-f]{4}\.[0-9a-f]{4}\.[0-9a-f]{4})$`)
+	identifierNIDRegexp   = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// ClassifyIdentifier returns the kind of identifier id is: an xname (e.g.
+// x1000c1s7b0n0), a MAC address (e.g. de:ad:be:ef:00:01, with colons, dashes,
+// dots, or no separators), or a NID (e.g. 12). An error is returned if id is
+// none of these, or if it could be more than one of them, which is the case
+// for MAC addresses without separators that consist only of digits.
+func ClassifyIdentifier(id string) (string, error) {
+	isMAC := identifierMACRegexp.MatchString(id)
+	isNID := identifierNIDRegexp.MatchString(id)
+	switch {
+	case identifierXnameRegexp.MatchString(id):
+		return IdentifierXname, nil
+	case isMAC && isNID:
+		return "", fmt.Errorf("%q is ambiguous: it could be a NID or a MAC address (write MAC addresses with separators, e.g. colons)", id)
+	case isMAC:
+		return IdentifierMAC, nil
+	case isNID:
+		if _, err := strconv.ParseInt(id, 10, 32); err != nil {
+			return "", fmt.Errorf("%q is out of range for a NID", id)
+		}
+		return IdentifierNID, nil
+	}
+	return "", fmt.Errorf("%q is not an xname, MAC address, or NID", id)
+}
+
+// ResolvedIdentifier is an identifier of a component and the component it
+// refers to.
+type ResolvedIdentifier struct {
+	ID    string `json:"id"`    // identifier as passed
+	Kind  string `json:"kind"`  // one of the Identifier* kinds
+	Xname string `json:"xname"` // ID of the component in SMD
+}
+
+// ResolveIdentifiers classifies each of ids with ClassifyIdentifier and
+// resolves it to the component in SMD it refers to: the component with the
+// xname (regardless of case), the component that the ethernet interface with
+// the MAC address (regardless of case and separators) belongs to, or the
+// component with the NID. The resolved identifiers are returned in the order
+// of ids.
+//
+// If any identifier cannot be classified, does not refer to a component, or
+// refers to more than one (e.g. a MAC address claimed by interfaces of
+// different components), an error listing all such identifiers is returned.
+func (sc *SMDClient) ResolveIdentifiers(ids []string, token string) ([]ResolvedIdentifier, error) {
+	var (
+		resolved = make([]ResolvedIdentifier, len(ids))
+		problems []string
+		byKind   = map[string][]string{}
+	)
+	for i, id := range ids {
+		kind, err := ClassifyIdentifier(id)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		resolved[i] = ResolvedIdentifier{ID: id, Kind: kind}
+		byKind[kind] = append(byKind[kind], id)
+	}
+
+	// Look up the components each identifier may refer to, keyed by the
+	// normalized identifier
+	matches := map[string]map[string][]string{}
+	var err error
+	if matches[IdentifierXname], err = sc.xnameMatches(byKind[IdentifierXname], token); err != nil {
+		return nil, fmt.Errorf("ResolveIdentifiers(): %w", err)
+	}
+	if matches[IdentifierMAC], err = sc.macMatches(byKind[IdentifierMAC]); err != nil {
+		return nil, fmt.Errorf("ResolveIdentifiers(): %w", err)
+	}
+	if matches[IdentifierNID], err = sc.nidMatches(byKind[IdentifierNID], token); err != nil {
+		return nil, fmt.Errorf("ResolveIdentifiers(): %w", err)
+	}
+
+	for i := range resolved {
+		r := &resolved[i]
+		if r.Kind == "" {
+			continue
+		}
+		xnames := matches[r.Kind][normalizeIdentifier(r.Kind, r.ID)]
+		switch len(xnames) {
+		case 0:
+			problems = append(problems, fmt.Sprintf("no component found for %s %q", identifierKindName(r.Kind), r.ID))
+		case 1:
+			r.Xname = xnames[0]
+		default:
+			problems = append(problems, fmt.Sprintf("%s %q is ambiguous: it refers to components %s", identifierKindName(r.Kind), r.ID, strings.Join(xnames, ",")))
+		}
+	}
+	if len(problems) > 0 {
+		return resolved, fmt.Errorf("ResolveIdentifiers(): failed to resolve identifiers: %s", strings.Join(problems, "; "))
+	}
+
+	return resolved, nil
+}
+
+// xnameMatches returns the IDs of the components in SMD with the xnames,
+// keyed by the lowercased xname.
+func (sc *SMDClient) xnameMatches(xnames []string, token string) (map[string][]string, error) {
+	found := map[string][]string{}
+	for start := 0; start < len(xnames); start += verifyBatchSize {
+		batch := xnames[start:min(start+verifyBatchSize, len(xnames))]
+		vals := url.Values{}
+		for _, x := range batch {
+			vals.Add("id", x)
+		}
+		henv, err := sc.GetComponentsValues(vals, token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get components: %w", err)
+		}
+		var compSlice ComponentSlice
+		if err := json.Unmarshal(henv.Body, &compSlice); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal components: %w", err)
+		}
+		for _, c := range compSlice.Components {
+			found[strings.ToLower(c.ID)] = appendUnique(found[strings.ToLower(c.ID)], c.ID)
+		}
+	}
+
+	return found, nil
+}
+
+// macMatches returns the IDs of the components that the ethernet interfaces
+// in SMD with the MAC addresses macs belong to, keyed by the normalized MAC
+// address. Interfaces that belong to no component are ignored.
+func (sc *SMDClient) macMatches(macs []string) (map[string][]string, error) {
+	found := map[string][]string{}
+	if len(macs) == 0 {
+		return found, nil
+	}
+	henv, err := sc.GetEthernetInterfaces(EthernetInterfaceFilter{MACs: macs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ethernet interfaces: %w", err)
+	}
+	var eis []EthernetInterface
+	if err := json.Unmarshal(henv.Body, &eis); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ethernet interfaces: %w", err)
+	}
+	for _, ei := range eis {
+		if ei.ComponentID != "" {
+			mac := normalizeMAC(ei.MACAddress)
+			found[mac] = appendUnique(found[mac], ei.ComponentID)
+		}
+	}
+
+	return found, nil
+}
+
+// nidMatches returns the IDs of the components in SMD with the NIDs nids,
+// keyed by the NID in decimal.
+func (sc *SMDClient) nidMatches(nids []string, token string) (map[string][]string, error) {
+	found := map[string][]string{}
+	if len(nids) == 0 {
+		return found, nil
+	}
+	nids64 := make([]int64, len(nids))
+	for i, n := range nids {
+		// ClassifyIdentifier already checked that n is a NID
+		nids64[i], _ = strconv.ParseInt(n, 10, 32)
+	}
+	comps, err := sc.GetComponentsByNIDs(nids64, token)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range comps {
+		key := strconv.FormatInt(c.NID, 10)
+		found[key] = appendUnique(found[key], c.ID)
+	}
+
+	return found, nil
+}
+
+// normalizeIdentifier returns id of kind in the form that the matches of
+// ResolveIdentifiers are keyed by.
+func normalizeIdentifier(kind, id string) string {
+	switch kind {
+	case IdentifierMAC:
+		return normalizeMAC(id)
+	case IdentifierNID:
+		n, _ := strconv.ParseInt(id, 10, 32)
+		return strconv.FormatInt(n, 10)
+	}
+	return strings.ToLower(id)
+}
+
+// identifierKindName returns the name of kind for messages.
+func identifierKindName(kind string) string {
+	switch kind {
+	case IdentifierMAC:
+		return "MAC address"
+	case IdentifierNID:
+		return "NID"
+	}
+	return kind
+}
+
+// appendUnique appends s to list unless it is already in it.
+func appendUnique(list []string, s string) []string {
+	for _, e := range list {
+		if e == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
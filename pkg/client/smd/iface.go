@@ -0,0 +1,194 @@
+package smd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+)
+
+// EthernetInterfaceFilter selects ethernet interfaces. An interface matches if
+// it matches every field that is set and, for fields that are lists, any of
+// the values in the list.
+//
+// Fields that SMD can filter on are passed to it in the query string. Fields
+// that it cannot, namely ComponentIDs containing glob patterns and IPs
+// containing CIDR prefixes, are applied to the interfaces it returns instead.
+type EthernetInterfaceFilter struct {
+	ComponentIDs []string // xnames or glob patterns, e.g. "x1000c1s7b*n0"
+	MACs         []string // MAC addresses, with any separators
+	IPs          []string // IP addresses or CIDR prefixes, e.g. "10.1.0.0/16"
+	Networks     []string // names of networks that an IP address is on
+	Types        []string // component types, e.g. "Node"
+	OlderThan    string   // RFC3339 time that interfaces were last updated before
+	NewerThan    string   // RFC3339 time that interfaces were last updated after
+}
+
+// Validate returns an error if a value of f is malformed.
+func (f EthernetInterfaceFilter) Validate() error {
+	for _, c := range f.ComponentIDs {
+		if _, err := path.Match(c, ""); err != nil {
+			return fmt.Errorf("invalid component ID pattern %q: %w", c, err)
+		}
+	}
+	for _, m := range f.MACs {
+		if _, err := net.ParseMAC(canonicalMAC(m)); err != nil {
+			return fmt.Errorf("invalid MAC address %q", m)
+		}
+	}
+	for _, i := range f.IPs {
+		if strings.Contains(i, "/") {
+			if _, err := netip.ParsePrefix(i); err != nil {
+				return fmt.Errorf("invalid CIDR prefix %q: %w", i, err)
+			}
+		} else if _, err := netip.ParseAddr(i); err != nil {
+			return fmt.Errorf("invalid IP address %q: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Query returns the query string passing the fields of f that SMD can filter
+// on to its ethernet interfaces endpoint. MAC addresses are converted to the
+// lower case, colon-separated form that SMD stores them in.
+func (f EthernetInterfaceFilter) Query() string {
+	values := url.Values{}
+	if !f.matchComponentIDs() {
+		for _, c := range f.ComponentIDs {
+			values.Add("ComponentID", c)
+		}
+	}
+	for _, m := range f.MACs {
+		values.Add("MACAddress", canonicalMAC(m))
+	}
+	if !f.matchIPs() {
+		for _, i := range f.IPs {
+			values.Add("IPAddress", i)
+		}
+	}
+	for _, n := range f.Networks {
+		values.Add("Network", n)
+	}
+	for _, t := range f.Types {
+		values.Add("Type", t)
+	}
+	if f.OlderThan != "" {
+		values.Add("OlderThan", f.OlderThan)
+	}
+	if f.NewerThan != "" {
+		values.Add("NewerThan", f.NewerThan)
+	}
+	return values.Encode()
+}
+
+// matchComponentIDs reports whether ComponentIDs must be matched by the
+// client, which is the case if any of them is a glob pattern.
+func (f EthernetInterfaceFilter) matchComponentIDs() bool {
+	for _, c := range f.ComponentIDs {
+		if strings.ContainsAny(c, "*?[") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchIPs reports whether IPs must be matched by the client, which is the
+// case if any of them is a CIDR prefix.
+func (f EthernetInterfaceFilter) matchIPs() bool {
+	for _, i := range f.IPs {
+		if strings.Contains(i, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether ei matches the fields of f that SMD cannot filter on.
+// The other fields are not checked, since SMD already filtered on them.
+func (f EthernetInterfaceFilter) Match(ei EthernetInterface) bool {
+	if f.matchComponentIDs() {
+		matched := false
+		for _, c := range f.ComponentIDs {
+			if ok, _ := path.Match(strings.ToLower(c), strings.ToLower(ei.ComponentID)); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if f.matchIPs() {
+		matched := false
+		for _, i := range f.IPs {
+			for _, eip := range ei.IPAddresses {
+				addr, err := netip.ParseAddr(eip.IPAddress)
+				if err != nil {
+					continue
+				}
+				if p, err := netip.ParsePrefix(i); err == nil {
+					matched = p.Contains(addr)
+				} else if a, err := netip.ParseAddr(i); err == nil {
+					matched = a == addr
+				}
+				if matched {
+					break
+				}
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// filter removes the ethernet interfaces that do not match f from body, a
+// list of ethernet interfaces returned by SMD. The interfaces that are kept
+// are left as they are, including any fields unknown to EthernetInterface.
+func (f EthernetInterfaceFilter) filter(body client.HTTPBody) (client.HTTPBody, error) {
+	if !f.matchComponentIDs() && !f.matchIPs() {
+		return body, nil
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(body, &raws); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ethernet interfaces: %w", err)
+	}
+	kept := make([]json.RawMessage, 0, len(raws))
+	for _, raw := range raws {
+		var ei EthernetInterface
+		if err := json.Unmarshal(raw, &ei); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal ethernet interface: %w", err)
+		}
+		if f.Match(ei) {
+			kept = append(kept, raw)
+		}
+	}
+	return json.Marshal(kept)
+}
+
+// canonicalMAC returns mac in lower case and separated by colons (e.g.
+// "de:ad:be:ef:00:01") if it consists of 12 hexadecimal digits, with or
+// without separators. Otherwise, mac is returned unchanged.
+func canonicalMAC(mac string) string {
+	digits := normalizeMAC(mac)
+	if len(digits) != 12 {
+		return mac
+	}
+	var b strings.Builder
+	for i := 0; i < len(digits); i += 2 {
+		if i > 0 {
+			b.WriteByte(':')
+		}
+		b.WriteString(digits[i : i+2])
+	}
+	return b.String()
+}
@@ -0,0 +1,169 @@
+package smd
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+)
+
+func TestEthernetInterfaceFilterValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  EthernetInterfaceFilter
+		wantErr bool
+	}{
+		{name: "empty"},
+		{name: "xname", filter: EthernetInterfaceFilter{ComponentIDs: []string{"x1000c1s7b0n0"}}},
+		{name: "glob", filter: EthernetInterfaceFilter{ComponentIDs: []string{"x1000c1s7b*n0", "x1000c1s[0-3]b0n?"}}},
+		{name: "bad glob", filter: EthernetInterfaceFilter{ComponentIDs: []string{"x1000c1s[0-3"}}, wantErr: true},
+		{name: "colon MAC", filter: EthernetInterfaceFilter{MACs: []string{"de:ca:fc:0f:ee:ee"}}},
+		{name: "dash MAC", filter: EthernetInterfaceFilter{MACs: []string{"DE-CA-FC-0F-EE-EE"}}},
+		{name: "bare MAC", filter: EthernetInterfaceFilter{MACs: []string{"decafc0feeee"}}},
+		{name: "short MAC", filter: EthernetInterfaceFilter{MACs: []string{"de:ca:fc:0f:ee"}}, wantErr: true},
+		{name: "non-hex MAC", filter: EthernetInterfaceFilter{MACs: []string{"zz:ca:fc:0f:ee:ee"}}, wantErr: true},
+		{name: "IPv4", filter: EthernetInterfaceFilter{IPs: []string{"10.1.0.5"}}},
+		{name: "IPv6", filter: EthernetInterfaceFilter{IPs: []string{"fd00::5"}}},
+		{name: "IPv4 CIDR", filter: EthernetInterfaceFilter{IPs: []string{"10.1.0.0/16"}}},
+		{name: "IPv6 CIDR", filter: EthernetInterfaceFilter{IPs: []string{"fd00::/64"}}},
+		{name: "bad IP", filter: EthernetInterfaceFilter{IPs: []string{"10.1.0.256"}}, wantErr: true},
+		{name: "bad CIDR length", filter: EthernetInterfaceFilter{IPs: []string{"10.1.0.0/33"}}, wantErr: true},
+		{name: "hostname", filter: EthernetInterfaceFilter{IPs: []string{"node01"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() returned %v, want error: %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEthernetInterfaceFilterQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter EthernetInterfaceFilter
+		want   url.Values
+	}{
+		{
+			name:   "empty",
+			filter: EthernetInterfaceFilter{},
+			want:   url.Values{},
+		},
+		{
+			name:   "xnames",
+			filter: EthernetInterfaceFilter{ComponentIDs: []string{"x1000c1s7b0n0", "x1000c1s7b1n0"}},
+			want:   url.Values{"ComponentID": {"x1000c1s7b0n0", "x1000c1s7b1n0"}},
+		},
+		{
+			name:   "glob is matched by the client",
+			filter: EthernetInterfaceFilter{ComponentIDs: []string{"x1000c1s7b0n0", "x1000c1s7b*n0"}},
+			want:   url.Values{},
+		},
+		{
+			name:   "MACs are canonicalized",
+			filter: EthernetInterfaceFilter{MACs: []string{"DE:CA:FC:0F:EE:EE", "de-ca-fc-0f-ee-ef", "decafc0feef0"}},
+			want:   url.Values{"MACAddress": {"de:ca:fc:0f:ee:ee", "de:ca:fc:0f:ee:ef", "de:ca:fc:0f:ee:f0"}},
+		},
+		{
+			name:   "IPs",
+			filter: EthernetInterfaceFilter{IPs: []string{"10.1.0.5", "fd00::5"}},
+			want:   url.Values{"IPAddress": {"10.1.0.5", "fd00::5"}},
+		},
+		{
+			name:   "CIDR is matched by the client",
+			filter: EthernetInterfaceFilter{IPs: []string{"10.1.0.5", "fd00::/64"}},
+			want:   url.Values{},
+		},
+		{
+			name: "other fields",
+			filter: EthernetInterfaceFilter{
+				Networks:  []string{"mgmt"},
+				Types:     []string{"Node"},
+				OlderThan: "2024-01-02T15:04:05Z",
+				NewerThan: "2024-01-01T15:04:05Z",
+			},
+			want: url.Values{
+				"Network":   {"mgmt"},
+				"Type":      {"Node"},
+				"OlderThan": {"2024-01-02T15:04:05Z"},
+				"NewerThan": {"2024-01-01T15:04:05Z"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := tt.filter.Query(), tt.want.Encode(); got != want {
+				t.Errorf("Query() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestEthernetInterfaceFilterMatch(t *testing.T) {
+	ei := EthernetInterface{
+		ComponentID: "x1000c1s7b0n0",
+		MACAddress:  "de:ca:fc:0f:ee:ee",
+		IPAddresses: []EthernetIP{
+			{IPAddress: "10.1.0.5"},
+			{IPAddress: "fd00::5"},
+		},
+	}
+	tests := []struct {
+		name   string
+		filter EthernetInterfaceFilter
+		want   bool
+	}{
+		{name: "empty", want: true},
+		{name: "xname is left to SMD", filter: EthernetInterfaceFilter{ComponentIDs: []string{"x9000c1s0b0n0"}}, want: true},
+		{name: "MAC is left to SMD", filter: EthernetInterfaceFilter{MACs: []string{"de:ca:fc:0f:ee:ef"}}, want: true},
+		{name: "glob", filter: EthernetInterfaceFilter{ComponentIDs: []string{"x1000c1s7b*n0"}}, want: true},
+		{name: "glob case", filter: EthernetInterfaceFilter{ComponentIDs: []string{"X1000C1S7B*"}}, want: true},
+		{name: "glob class", filter: EthernetInterfaceFilter{ComponentIDs: []string{"x1000c1s[5-7]b0n?"}}, want: true},
+		{name: "glob mismatch", filter: EthernetInterfaceFilter{ComponentIDs: []string{"x1000c2*"}}, want: false},
+		{name: "xname alongside glob", filter: EthernetInterfaceFilter{ComponentIDs: []string{"x1000c2*", "x1000c1s7b0n0"}}, want: true},
+		{name: "IPv4 CIDR", filter: EthernetInterfaceFilter{IPs: []string{"10.1.0.0/16"}}, want: true},
+		{name: "IPv4 CIDR mismatch", filter: EthernetInterfaceFilter{IPs: []string{"10.2.0.0/16"}}, want: false},
+		{name: "IPv6 CIDR", filter: EthernetInterfaceFilter{IPs: []string{"fd00::/64"}}, want: true},
+		{name: "IPv6 CIDR mismatch", filter: EthernetInterfaceFilter{IPs: []string{"fd01::/64"}}, want: false},
+		{name: "any address may match", filter: EthernetInterfaceFilter{IPs: []string{"0.0.0.0/0"}}, want: true},
+		{name: "address alongside CIDR", filter: EthernetInterfaceFilter{IPs: []string{"10.2.0.0/16", "fd00::5"}}, want: true},
+		{name: "address mismatch alongside CIDR", filter: EthernetInterfaceFilter{IPs: []string{"10.2.0.0/16", "fd00::6"}}, want: false},
+		{
+			name:   "glob and CIDR must both match",
+			filter: EthernetInterfaceFilter{ComponentIDs: []string{"x1000c1*"}, IPs: []string{"10.2.0.0/16"}},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(ei); got != tt.want {
+				t.Errorf("Match() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+
+	noIP := ei
+	noIP.IPAddresses = []EthernetIP{{IPAddress: ""}}
+	if (EthernetInterfaceFilter{IPs: []string{"0.0.0.0/0"}}).Match(noIP) {
+		t.Error("interface without IP addresses matched a CIDR prefix")
+	}
+}
+
+func TestEthernetInterfaceFilterFilter(t *testing.T) {
+	body := []byte(`[
+		{"ID": "decafc0feeee", "ComponentID": "x1000c1s7b0n0", "Unknown": 1},
+		{"ID": "decafc0feeef", "ComponentID": "x1000c2s7b0n0"}
+	]`)
+	out, err := EthernetInterfaceFilter{ComponentIDs: []string{"x1000c1*"}}.filter(body)
+	if err != nil {
+		t.Fatalf("filter failed: %v", err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("filtered body is not valid: %v", err)
+	}
+	if len(got) != 1 || got[0]["ID"] != "decafc0feeee" || got[0]["Unknown"] != float64(1) {
+		t.Errorf("got %s, want the first interface as is", out)
+	}
+}
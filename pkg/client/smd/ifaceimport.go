@@ -0,0 +1,148 @@
+package smd
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// MergeStrategy decides what importing an ethernet interface whose MAC
+// address is already in SMD does to the existing interface.
+type MergeStrategy string
+
+const (
+	// MergeReplace replaces the component ID, description, and IP
+	// addresses of the existing interface with those imported.
+	MergeReplace MergeStrategy = "replace"
+
+	// MergeIPs adds the imported IP addresses that the existing interface
+	// does not have to it, keeping the ones it has, and replaces its
+	// component ID and description with those imported unless they are
+	// empty.
+	MergeIPs MergeStrategy = "merge-ips"
+
+	// MergeSkipExisting leaves existing interfaces as they are.
+	MergeSkipExisting MergeStrategy = "skip-existing"
+)
+
+// MergeStrategies are the valid merge strategies, in the order they are
+// documented.
+var MergeStrategies = []string{string(MergeReplace), string(MergeIPs), string(MergeSkipExisting)}
+
+// ParseMergeStrategy returns the MergeStrategy named s.
+func ParseMergeStrategy(s string) (MergeStrategy, error) {
+	if !slices.Contains(MergeStrategies, s) {
+		return "", fmt.Errorf("invalid merge strategy %q (expected one of: %s)", s, strings.Join(MergeStrategies, ","))
+	}
+	return MergeStrategy(s), nil
+}
+
+// InterfaceImport is what importing ethernet interfaces into SMD needs to do,
+// as determined by PlanInterfaceImport. Interfaces are matched with the ones
+// in SMD by MAC address.
+type InterfaceImport struct {
+	// Add are the imported interfaces whose MAC addresses are not in SMD.
+	Add []EthernetInterface `json:"add"`
+
+	// Update are the existing interfaces, with their IDs, merged with the
+	// imported ones according to the merge strategy.
+	Update []EthernetInterface `json:"update"`
+
+	// Unchanged are the IDs of existing interfaces that the import would
+	// not change.
+	Unchanged []string `json:"unchanged"`
+
+	// Skipped are the IDs of existing interfaces left as they are because
+	// of MergeSkipExisting.
+	Skipped []string `json:"skipped"`
+}
+
+// PlanInterfaceImport determines what importing the ethernet interfaces in
+// incoming into SMD, which has the interfaces in existing, needs to do when
+// merging interfaces whose MAC addresses are in both with strategy. MAC
+// addresses are compared regardless of case and separators. An error is
+// returned if an imported interface has no MAC address or if two have the
+// same one.
+func PlanInterfaceImport(existing, incoming []EthernetInterface, strategy MergeStrategy) (InterfaceImport, error) {
+	var plan InterfaceImport
+	byMAC := make(map[string]EthernetInterface, len(existing))
+	for _, ei := range existing {
+		byMAC[normalizeMAC(ei.MACAddress)] = ei
+	}
+	seen := make(map[string]bool, len(incoming))
+	for _, in := range incoming {
+		mac := normalizeMAC(in.MACAddress)
+		if mac == "" {
+			return plan, fmt.Errorf("imported ethernet interface of %q has no MAC address", in.ComponentID)
+		}
+		if seen[mac] {
+			return plan, fmt.Errorf("MAC address %s is imported more than once", in.MACAddress)
+		}
+		seen[mac] = true
+
+		cur, ok := byMAC[mac]
+		if !ok {
+			plan.Add = append(plan.Add, in)
+			continue
+		}
+		var merged EthernetInterface
+		switch strategy {
+		case MergeSkipExisting:
+			plan.Skipped = append(plan.Skipped, cur.ID)
+			continue
+		case MergeReplace:
+			merged = cur
+			merged.ComponentID = in.ComponentID
+			merged.Description = in.Description
+			merged.IPAddresses = in.IPAddresses
+		case MergeIPs:
+			merged = mergeInterfaceIPs(cur, in)
+		default:
+			return plan, fmt.Errorf("invalid merge strategy %q", strategy)
+		}
+		if interfacesEqual(cur, merged) {
+			plan.Unchanged = append(plan.Unchanged, cur.ID)
+		} else {
+			plan.Update = append(plan.Update, merged)
+		}
+	}
+	sort.Strings(plan.Unchanged)
+	sort.Strings(plan.Skipped)
+	return plan, nil
+}
+
+// mergeInterfaceIPs returns cur with the IP addresses of in that it does not
+// have added, and with the component ID and description of in unless they
+// are empty.
+func mergeInterfaceIPs(cur, in EthernetInterface) EthernetInterface {
+	merged := cur
+	if in.ComponentID != "" {
+		merged.ComponentID = in.ComponentID
+	}
+	if in.Description != "" {
+		merged.Description = in.Description
+	}
+	merged.IPAddresses = slices.Clone(cur.IPAddresses)
+	for _, ip := range in.IPAddresses {
+		if !slices.ContainsFunc(merged.IPAddresses, func(e EthernetIP) bool { return e.IPAddress == ip.IPAddress }) {
+			merged.IPAddresses = append(merged.IPAddresses, ip)
+		}
+	}
+	return merged
+}
+
+// interfacesEqual reports whether a and b have the same component ID,
+// description, and IP addresses, which are the fields an import changes. The
+// order of the IP addresses does not matter.
+func interfacesEqual(a, b EthernetInterface) bool {
+	if a.ComponentID != b.ComponentID || a.Description != b.Description || len(a.IPAddresses) != len(b.IPAddresses) {
+		return false
+	}
+	for _, ip := range a.IPAddresses {
+		if !slices.Contains(b.IPAddresses, ip) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package smd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+)
+
+// AddToGroups is the node-centric counterpart of PostGroupMembers: it takes a
+// token, a component ID, and a list of one or more group labels, and
+// iteratively calls OchamiClient.PostData to add the component to each group.
+// A slice of client.HTTPEnvelopes is returned containing one
+// client.HTTPEnvelope per group, as well as an error slice containing errors
+// corresponding to each group. The indexes of these should correspond. If an
+// error in the function itself occurred, a separate error is returned.
+func (sc *SMDClient) AddToGroups(token, member string, groups ...string) ([]client.HTTPEnvelope, []error, error) {
+	if member == "" {
+		return nil, nil, fmt.Errorf("AddToGroups(): no member specified to add to groups")
+	}
+	if len(groups) == 0 {
+		return nil, nil, fmt.Errorf("AddToGroups(): no groups specified to add %s to", member)
+	}
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return nil, nil, fmt.Errorf("AddToGroups(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	body, err := json.Marshal(map[string]string{"id": member})
+	if err != nil {
+		return nil, nil, fmt.Errorf("AddToGroups(): failed to marshal member id %s: %w", member, err)
+	}
+	henvs, errs := client.BulkRequest(groups, sc.BulkConcurrency(http.MethodPost), func(group string) (client.HTTPEnvelope, error) {
+		groupPath, err := url.JoinPath(SMDRelpathGroups, group, "members")
+		if err != nil {
+			return client.HTTPEnvelope{}, fmt.Errorf("AddToGroups(): failed to join group path (%s) with group label (%s): %w", SMDRelpathGroups, group, err)
+		}
+		henv, err := sc.PostData(groupPath, "", headers, body)
+		if err != nil {
+			return henv, fmt.Errorf("AddToGroups(): failed to POST member %s to group %s: %w", member, group, err)
+		}
+		return henv, nil
+	})
+
+	return henvs, errs, nil
+}
+
+// RemoveFromGroups is the node-centric counterpart of DeleteGroupMembers: it
+// takes a token, a component ID, and a list of one or more group labels, and
+// iteratively calls OchamiClient.DeleteData to remove the component from each
+// group. Results are returned as by AddToGroups.
+func (sc *SMDClient) RemoveFromGroups(token, member string, groups ...string) ([]client.HTTPEnvelope, []error, error) {
+	if member == "" {
+		return nil, nil, fmt.Errorf("RemoveFromGroups(): no member specified to remove from groups")
+	}
+	if len(groups) == 0 {
+		return nil, nil, fmt.Errorf("RemoveFromGroups(): no groups specified to remove %s from", member)
+	}
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return nil, nil, fmt.Errorf("RemoveFromGroups(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	henvs, errs := client.BulkRequest(groups, sc.BulkConcurrency(http.MethodDelete), func(group string) (client.HTTPEnvelope, error) {
+		memberPath, err := url.JoinPath(SMDRelpathGroups, group, "members", member)
+		if err != nil {
+			return client.HTTPEnvelope{}, fmt.Errorf("RemoveFromGroups(): failed join group path (%s) with group %s and member %s: %w", SMDRelpathGroups, group, member, err)
+		}
+		henv, err := sc.DeleteData(memberPath, "", headers, nil)
+		if err != nil {
+			return henv, fmt.Errorf("RemoveFromGroups(): failed to DELETE member %s from group %s in SMD: %w", member, group, err)
+		}
+		return henv, nil
+	})
+
+	return henvs, errs, nil
+}
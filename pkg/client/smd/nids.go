@@ -0,0 +1,103 @@
+package smd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/pkg/nid"
+	"github.com/OpenCHAMI/ochami/pkg/xname"
+)
+
+// Strategies of PlanNIDs.
+const (
+	// NIDStrategySequentialByXname assigns the lowest free NIDs in the
+	// order of the xnames, filling gaps between the NIDs in use.
+	NIDStrategySequentialByXname = "sequential-by-xname"
+
+	// NIDStrategyContiguousByXname assigns a block of consecutive free NIDs
+	// in the order of the xnames, so that the components get a range of
+	// NIDs without gaps.
+	NIDStrategyContiguousByXname = "contiguous-by-xname"
+)
+
+// NIDStrategies are the strategies PlanNIDs accepts.
+var NIDStrategies = []string{NIDStrategySequentialByXname, NIDStrategyContiguousByXname}
+
+// PlanNIDs returns the components of targets that lack a NID (i.e. whose NID
+// is not positive) with NIDs of at least start assigned to them according to
+// strategy, sorted by xname in natural order (see xname.Compare). Only the ID
+// and NID of the returned components are set, as PatchComponentsNID needs.
+//
+// The NIDs of existing, which should be all components in SMD, are never
+// assigned, so that no two components end up with the same NID. An error is
+// returned if strategy is unknown, if start is not positive, or if there are
+// not enough free NIDs.
+func PlanNIDs(targets, existing []Component, start int64, strategy string) ([]Component, error) {
+	if start < 1 || start > nid.MaxNID {
+		return nil, fmt.Errorf("PlanNIDs(): start NID %d out of range 1-%d", start, nid.MaxNID)
+	}
+
+	var lacking []string
+	seen := map[string]bool{}
+	for _, c := range targets {
+		if c.NID > 0 || seen[strings.ToLower(c.ID)] {
+			continue
+		}
+		seen[strings.ToLower(c.ID)] = true
+		lacking = append(lacking, c.ID)
+	}
+	if len(lacking) == 0 {
+		return nil, nil
+	}
+	sort.Slice(lacking, func(i, j int) bool { return xname.Compare(lacking[i], lacking[j]) < 0 })
+
+	used := map[int64]bool{}
+	for _, c := range existing {
+		if c.NID > 0 {
+			used[c.NID] = true
+		}
+	}
+
+	var (
+		nids []int64
+		err  error
+	)
+	switch strategy {
+	case NIDStrategySequentialByXname:
+		nids, err = nid.Free(start, len(lacking), used)
+	case NIDStrategyContiguousByXname:
+		nids, err = nid.FreeBlock(start, len(lacking), used)
+	default:
+		return nil, fmt.Errorf("PlanNIDs(): unknown strategy %q (must be one of %s)", strategy, strings.Join(NIDStrategies, ","))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("PlanNIDs(): %w", err)
+	}
+
+	planned := make([]Component, len(lacking))
+	for i, id := range lacking {
+		planned[i] = Component{ID: id, NID: nids[i]}
+	}
+
+	return planned, nil
+}
+
+// DuplicateNIDs returns the NIDs that more than one of comps have, each with
+// the IDs of those components, e.g. to warn about collisions that already
+// exist in SMD.
+func DuplicateNIDs(comps []Component) map[int64][]string {
+	byNID := map[int64][]string{}
+	for _, c := range comps {
+		if c.NID > 0 {
+			byNID[c.NID] = append(byNID[c.NID], c.ID)
+		}
+	}
+	for n, ids := range byNID {
+		if len(ids) < 2 {
+			delete(byNID, n)
+		}
+	}
+
+	return byNID
+}
@@ -0,0 +1,88 @@
+package smd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/openapi"
+	"github.com/openchami/schemas/schemas/csm"
+)
+
+// SMDRelpathOpenAPI is the path, relative to SMD's base path, that its OpenAPI
+// spec is fetched from by default.
+const SMDRelpathOpenAPI = "/openapi.json"
+
+// KnownAPI describes the SMD endpoints that SMDClient sends requests to and the
+// SMD schemas that it reads and writes, for comparison against SMD's live
+// OpenAPI spec.
+var KnownAPI = openapi.Known{
+	BasePath: basePathSMD,
+	Endpoints: []openapi.Endpoint{
+		{Method: http.MethodGet, Path: SMDRelpathService + "/ready"},
+		{Method: http.MethodGet, Path: SMDRelpathService + "/values"},
+		{Method: http.MethodGet, Path: SMDRelpathService + "/version"},
+
+		{Method: http.MethodGet, Path: SMDRelpathComponents},
+		{Method: http.MethodPost, Path: SMDRelpathComponents},
+		{Method: http.MethodDelete, Path: SMDRelpathComponents},
+		{Method: http.MethodGet, Path: SMDRelpathComponents + "/{xname}"},
+		{Method: http.MethodPut, Path: SMDRelpathComponents + "/{xname}"},
+		{Method: http.MethodDelete, Path: SMDRelpathComponents + "/{xname}"},
+		{Method: http.MethodGet, Path: SMDRelpathComponents + "/ByNID/{nid}"},
+		{Method: http.MethodPost, Path: SMDRelpathComponents + "/" + SMDSubpathByNIDQuery},
+		{Method: http.MethodPatch, Path: SMDRelpathComponents + "/" + SMDSubpathBulkNID},
+		{Method: http.MethodPatch, Path: SMDRelpathComponents + "/" + SMDSubpathBulkStateData},
+
+		{Method: http.MethodGet, Path: SMDRelpathRedfishEndpoints},
+		{Method: http.MethodPost, Path: SMDRelpathRedfishEndpoints},
+		{Method: http.MethodDelete, Path: SMDRelpathRedfishEndpoints},
+		{Method: http.MethodGet, Path: SMDRelpathRedfishEndpoints + "/{xname}"},
+		{Method: http.MethodPut, Path: SMDRelpathRedfishEndpoints + "/{xname}"},
+		{Method: http.MethodDelete, Path: SMDRelpathRedfishEndpoints + "/{xname}"},
+
+		{Method: http.MethodGet, Path: SMDRelpathEthernetInterfaces},
+		{Method: http.MethodPost, Path: SMDRelpathEthernetInterfaces},
+		{Method: http.MethodDelete, Path: SMDRelpathEthernetInterfaces},
+		{Method: http.MethodGet, Path: SMDRelpathEthernetInterfaces + "/{ethInterfaceID}"},
+		{Method: http.MethodPatch, Path: SMDRelpathEthernetInterfaces + "/{ethInterfaceID}"},
+		{Method: http.MethodDelete, Path: SMDRelpathEthernetInterfaces + "/{ethInterfaceID}"},
+		{Method: http.MethodGet, Path: SMDRelpathEthernetInterfaces + "/{ethInterfaceID}/IPAddresses"},
+
+		{Method: http.MethodGet, Path: SMDRelpathComponentEndpoints},
+		{Method: http.MethodDelete, Path: SMDRelpathComponentEndpoints},
+		{Method: http.MethodGet, Path: SMDRelpathComponentEndpoints + "/{xname}"},
+		{Method: http.MethodDelete, Path: SMDRelpathComponentEndpoints + "/{xname}"},
+
+		{Method: http.MethodGet, Path: SMDRelpathHardwareHistory},
+
+		{Method: http.MethodGet, Path: SMDRelpathGroups},
+		{Method: http.MethodPost, Path: SMDRelpathGroups},
+		{Method: http.MethodGet, Path: SMDRelpathGroups + "/{group_label}"},
+		{Method: http.MethodPatch, Path: SMDRelpathGroups + "/{group_label}"},
+		{Method: http.MethodDelete, Path: SMDRelpathGroups + "/{group_label}"},
+		{Method: http.MethodGet, Path: SMDRelpathGroups + "/{group_label}/members"},
+		{Method: http.MethodPost, Path: SMDRelpathGroups + "/{group_label}/members"},
+		{Method: http.MethodPut, Path: SMDRelpathGroups + "/{group_label}/members"},
+		{Method: http.MethodDelete, Path: SMDRelpathGroups + "/{group_label}/members/{xname_id}"},
+
+		{Method: http.MethodGet, Path: SMDRelpathMemberships},
+	},
+	Schemas: map[string]any{
+		"Component.1.0.0_Component":             Component{},
+		"CompEthInterface.1.0.0":                EthernetInterface{},
+		"Group.1.0.0":                           Group{},
+		"RedfishEndpoint.1.0.0_RedfishEndpoint": csm.RedfishEndpoint{},
+	},
+}
+
+// GetOpenAPI is a wrapper function around OchamiClient.GetData that fetches
+// the OpenAPI spec that SMD serves at SMDRelpathOpenAPI.
+func (sc *SMDClient) GetOpenAPI() (client.HTTPEnvelope, error) {
+	henv, err := sc.GetData(SMDRelpathOpenAPI, "", nil)
+	if err != nil {
+		err = fmt.Errorf("GetOpenAPI(): error getting SMD OpenAPI spec: %w", err)
+	}
+
+	return henv, err
+}
@@ -0,0 +1,80 @@
+package smd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/openchami/schemas/schemas/csm"
+)
+
+// SMD returns its component, redfish endpoint, ethernet interface, and group
+// member collections whole, so the pagers below yield a single page. Using them
+// nonetheless keeps callers unchanged should SMD start paging these
+// collections.
+
+// ComponentPager returns a Pager over the components in SMD that match query,
+// a query string (without the "?") that may be empty. token, if not empty, is
+// sent as the authorization bearer.
+func (sc *SMDClient) ComponentPager(query, token string) *client.Pager[Component] {
+	return listPager(sc, "ComponentPager", SMDRelpathComponents, query, token, func(cs ComponentSlice) []Component {
+		return cs.Components
+	})
+}
+
+// RedfishEndpointPager returns a Pager over the redfish endpoints in SMD that
+// match query, a query string (without the "?") that may be empty. token, if
+// not empty, is sent as the authorization bearer.
+func (sc *SMDClient) RedfishEndpointPager(query, token string) *client.Pager[csm.RedfishEndpoint] {
+	return listPager(sc, "RedfishEndpointPager", SMDRelpathRedfishEndpoints, query, token, func(rs RedfishEndpointSlice) []csm.RedfishEndpoint {
+		return rs.RedfishEndpoints
+	})
+}
+
+// EthernetInterfacePager returns a Pager over the ethernet interfaces in SMD
+// that match query, a query string (without the "?") that may be empty. token,
+// if not empty, is sent as the authorization bearer.
+func (sc *SMDClient) EthernetInterfacePager(query, token string) *client.Pager[EthernetInterface] {
+	return listPager(sc, "EthernetInterfacePager", SMDRelpathEthernetInterfaces, query, token, func(eis []EthernetInterface) []EthernetInterface {
+		return eis
+	})
+}
+
+// GroupMemberPager returns a Pager over the IDs of the members of group. token,
+// if not empty, is sent as the authorization bearer.
+func (sc *SMDClient) GroupMemberPager(group, token string) *client.Pager[string] {
+	endpoint, err := url.JoinPath(SMDRelpathGroups, group, "members")
+	if err != nil || group == "" {
+		return client.SinglePage(func(ctx context.Context) ([]string, error) {
+			return nil, fmt.Errorf("GroupMemberPager(): invalid group label %q", group)
+		})
+	}
+	return listPager(sc, "GroupMemberPager", endpoint, "", token, func(gm GroupMembers) []string {
+		return gm.IDs
+	})
+}
+
+// listPager returns a single-page Pager that GETs endpoint with query,
+// unmarshals the response body into an L, and yields the items that items
+// extracts from it. Errors are prefixed with fname.
+func listPager[T, L any](sc *SMDClient, fname, endpoint, query, token string, items func(L) []T) *client.Pager[T] {
+	return client.SinglePage(func(ctx context.Context) ([]T, error) {
+		headers := client.NewHTTPHeaders()
+		if token != "" {
+			if err := headers.SetAuthorization(token); err != nil {
+				return nil, fmt.Errorf("%s(): error setting token in HTTP headers: %w", fname, err)
+			}
+		}
+		henv, err := sc.GetDataContext(ctx, endpoint, query, headers)
+		if err != nil {
+			return nil, fmt.Errorf("%s(): error getting %s: %w", fname, endpoint, err)
+		}
+		var list L
+		if err := json.Unmarshal(henv.Body, &list); err != nil {
+			return nil, fmt.Errorf("%s(): failed to unmarshal %s: %w", fname, endpoint, err)
+		}
+		return items(list), nil
+	})
+}
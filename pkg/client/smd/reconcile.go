@@ -0,0 +1,208 @@
+package smd
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/pkg/selector"
+)
+
+// GroupDefinitions is the format of a declarative group definitions file
+// (e.g. groups.yaml), which maps group labels to the definitions of the
+// groups. For example:
+//
+//	groups:
+//	  compute: type=Node,role=Compute
+//	  gpu:
+//	    selector: type=Node,role=Compute,subrole=GPU
+//	    description: GPU nodes
+//	    tags: [accel]
+type GroupDefinitions struct {
+	Groups map[string]GroupDefinition `json:"groups" jsonschema:"required"`
+}
+
+// GroupDefinition defines a group whose members are the components matching
+// Selector (see package selector for its syntax). Description and Tags are
+// only managed if set. ExclusiveGroup is only used when the group is created,
+// since SMD does not allow changing it.
+//
+// A GroupDefinition can be unmarshalled from a string, which is taken to be
+// its Selector.
+type GroupDefinition struct {
+	Selector       string   `json:"selector" jsonschema:"required"`
+	Description    string   `json:"description,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	ExclusiveGroup string   `json:"exclusiveGroup,omitempty"`
+}
+
+// UnmarshalJSON unmarshals a GroupDefinition from either an object or a string
+// holding just its selector.
+func (gd *GroupDefinition) UnmarshalJSON(data []byte) error {
+	var sel string
+	if err := json.Unmarshal(data, &sel); err == nil {
+		*gd = GroupDefinition{Selector: sel}
+		return nil
+	}
+	type plain GroupDefinition
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*gd = GroupDefinition(p)
+	return nil
+}
+
+// GroupChange is what needs to be done to a group for it to match its
+// GroupDefinition.
+type GroupChange struct {
+	Label string
+
+	// Create is true if the group does not exist. Group holds the group
+	// to create.
+	Create bool
+	Group  Group
+
+	// Members is the list of members the group should have, and Add and
+	// Remove are the components to add to and remove from its current
+	// members.
+	Members []string
+	Add     []string
+	Remove  []string
+
+	// UpdateMeta is true if the description or tags of an existing group
+	// differ from its definition. Group holds the new description and
+	// tags.
+	UpdateMeta bool
+
+	// Warnings are problems that do not prevent the group from being
+	// reconciled, e.g. an exclusive group that cannot be changed.
+	Warnings []string
+}
+
+// InSync reports whether the group already matches its definition.
+func (gc GroupChange) InSync() bool {
+	return !gc.Create && !gc.UpdateMeta && len(gc.Add) == 0 && len(gc.Remove) == 0
+}
+
+// String returns a one-line summary of gc.
+func (gc GroupChange) String() string {
+	switch {
+	case gc.Create:
+		return fmt.Sprintf("%s: create with %d member(s)", gc.Label, len(gc.Members))
+	case gc.InSync():
+		return fmt.Sprintf("%s: in sync (%d member(s))", gc.Label, len(gc.Members))
+	}
+	var parts []string
+	if len(gc.Add) > 0 {
+		parts = append(parts, fmt.Sprintf("add %s", strings.Join(gc.Add, ",")))
+	}
+	if len(gc.Remove) > 0 {
+		parts = append(parts, fmt.Sprintf("remove %s", strings.Join(gc.Remove, ",")))
+	}
+	if gc.UpdateMeta {
+		parts = append(parts, "update description/tags")
+	}
+	return fmt.Sprintf("%s: %s", gc.Label, strings.Join(parts, "; "))
+}
+
+// PlanGroupReconcile computes the changes needed for the groups in defs to
+// match their definitions, given all components and groups in SMD. components
+// are SMD components unmarshalled into maps so that selectors can refer to any
+// of their fields. The changes are sorted by group label. Groups that are not
+// in defs are not changed. An error is returned if a definition has no
+// selector or an invalid one.
+func PlanGroupReconcile(defs GroupDefinitions, components []map[string]any, groups []Group) ([]GroupChange, error) {
+	existing := make(map[string]Group, len(groups))
+	for _, g := range groups {
+		existing[strings.ToLower(g.Label)] = g
+	}
+
+	labels := make([]string, 0, len(defs.Groups))
+	for label := range defs.Groups {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	var changes []GroupChange
+	for _, label := range labels {
+		def := defs.Groups[label]
+		if label == "" {
+			return nil, fmt.Errorf("PlanGroupReconcile(): group definition with empty label")
+		}
+		sel, err := selector.Parse(def.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("PlanGroupReconcile(): invalid selector for group %s: %w", label, err)
+		}
+
+		var members []string
+		for _, comp := range components {
+			id, _ := comp["ID"].(string)
+			if id != "" && sel.Matches(comp) {
+				members = append(members, id)
+			}
+		}
+		sort.Strings(members)
+
+		gc := GroupChange{Label: label, Members: members}
+		cur, ok := existing[strings.ToLower(label)]
+		if !ok {
+			gc.Create = true
+			gc.Group = Group{
+				Label:          label,
+				Description:    def.Description,
+				Tags:           def.Tags,
+				ExclusiveGroup: def.ExclusiveGroup,
+			}
+			gc.Group.Members.IDs = members
+			changes = append(changes, gc)
+			continue
+		}
+
+		gc.Label = cur.Label
+		curMembers := make(map[string]bool, len(cur.Members.IDs))
+		for _, id := range cur.Members.IDs {
+			curMembers[strings.ToLower(id)] = true
+		}
+		wanted := make(map[string]bool, len(members))
+		for _, id := range members {
+			wanted[strings.ToLower(id)] = true
+			if !curMembers[strings.ToLower(id)] {
+				gc.Add = append(gc.Add, id)
+			}
+		}
+		for _, id := range cur.Members.IDs {
+			if !wanted[strings.ToLower(id)] {
+				gc.Remove = append(gc.Remove, id)
+			}
+		}
+		sort.Strings(gc.Remove)
+
+		gc.Group = Group{Label: cur.Label, Description: cur.Description, Tags: cur.Tags}
+		if def.Description != "" && def.Description != cur.Description {
+			gc.Group.Description = def.Description
+			gc.UpdateMeta = true
+		}
+		if def.Tags != nil && !sameSet(def.Tags, cur.Tags) {
+			gc.Group.Tags = def.Tags
+			gc.UpdateMeta = true
+		}
+		if def.ExclusiveGroup != "" && def.ExclusiveGroup != cur.ExclusiveGroup {
+			gc.Warnings = append(gc.Warnings, fmt.Sprintf("group %s has exclusive group %q instead of %q, which can only be changed by recreating it", cur.Label, cur.ExclusiveGroup, def.ExclusiveGroup))
+		}
+		changes = append(changes, gc)
+	}
+
+	return changes, nil
+}
+
+// sameSet reports whether a and b hold the same strings, ignoring order and
+// duplicates.
+func sameSet(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
@@ -0,0 +1,99 @@
+package smd
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPlanGroupReconcile(t *testing.T) {
+	components := []map[string]any{
+		{"ID": "x1000c1s7b0n0", "Type": "Node", "Role": "Compute"},
+		{"ID": "x1000c1s7b1n0", "Type": "Node", "Role": "Compute", "SubRole": "GPU"},
+		{"ID": "x1000c1s7b2n0", "Type": "Node", "Role": "Management"},
+		{"ID": "x1000c1s7b0", "Type": "NodeBMC"},
+	}
+	group := func(label, desc string, members ...string) Group {
+		g := Group{Label: label, Description: desc}
+		g.Members.IDs = members
+		return g
+	}
+	groups := []Group{
+		group("Compute", "Compute nodes", "x1000c1s7b1n0", "x1000c1s7b0n0"),
+		group("gpu", "", "x1000c1s7b1n0", "x1000c1s7b2n0"),
+		group("mgmt", "", "x1000c1s7b2n0", "x1000c1s7b0n0"),
+	}
+	defs := GroupDefinitions{Groups: map[string]GroupDefinition{
+		"compute": {Selector: "type=Node,role=Compute", Description: "Compute nodes"},
+		"gpu":     {Selector: "role=Compute,subrole=GPU", Description: "GPU nodes"},
+		"mgmt":    {Selector: "role=Management"},
+		"bmcs":    {Selector: "type=NodeBMC", ExclusiveGroup: "hw"},
+		"storage": {Selector: "role=Storage"},
+	}}
+
+	changes, err := PlanGroupReconcile(defs, components, groups)
+	if err != nil {
+		t.Fatalf("PlanGroupReconcile failed: %v", err)
+	}
+	want := []string{
+		"bmcs: create with 1 member(s)",
+		"Compute: in sync (2 member(s))",
+		"gpu: remove x1000c1s7b2n0; update description/tags",
+		"mgmt: remove x1000c1s7b0n0",
+		"storage: create with 0 member(s)",
+	}
+	var got []string
+	for _, gc := range changes {
+		got = append(got, gc.String())
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got changes %q, want %q", got, want)
+	}
+
+	bmcs := changes[0]
+	if bmcs.Group.ExclusiveGroup != "hw" || !slices.Equal(bmcs.Group.Members.IDs, []string{"x1000c1s7b0"}) {
+		t.Errorf("group to create is %+v, want exclusive group hw with the BMC", bmcs.Group)
+	}
+	gpu := changes[2]
+	if gpu.Group.Description != "GPU nodes" || !slices.Equal(gpu.Members, []string{"x1000c1s7b1n0"}) {
+		t.Errorf("gpu group is %+v with members %v, want the new description and the GPU node", gpu.Group, gpu.Members)
+	}
+	if mgmt := changes[3]; mgmt.UpdateMeta || len(mgmt.Add) != 0 {
+		t.Errorf("mgmt group has %+v, want only a member removed", mgmt)
+	}
+}
+
+func TestPlanGroupReconcileAddAndExclusive(t *testing.T) {
+	components := []map[string]any{
+		{"ID": "x1000c1s7b0n0", "Role": "Compute"},
+		{"ID": "x1000c1s7b1n0", "Role": "Compute"},
+	}
+	g := Group{Label: "compute", ExclusiveGroup: "roles"}
+	g.Members.IDs = []string{"x1000c1s7b0n0", "x1000c1s7b9n0"}
+	defs := GroupDefinitions{Groups: map[string]GroupDefinition{
+		"compute": {Selector: "role=compute", ExclusiveGroup: "partitions"},
+	}}
+
+	changes, err := PlanGroupReconcile(defs, components, []Group{g})
+	if err != nil {
+		t.Fatalf("PlanGroupReconcile failed: %v", err)
+	}
+	gc := changes[0]
+	if !slices.Equal(gc.Add, []string{"x1000c1s7b1n0"}) || !slices.Equal(gc.Remove, []string{"x1000c1s7b9n0"}) {
+		t.Errorf("got add %v and remove %v, want x1000c1s7b1n0 added and x1000c1s7b9n0 removed", gc.Add, gc.Remove)
+	}
+	if len(gc.Warnings) != 1 {
+		t.Errorf("got warnings %q, want one about the exclusive group", gc.Warnings)
+	}
+}
+
+func TestPlanGroupReconcileInvalid(t *testing.T) {
+	for name, defs := range map[string]GroupDefinitions{
+		"empty label":      {Groups: map[string]GroupDefinition{"": {Selector: "type=Node"}}},
+		"empty selector":   {Groups: map[string]GroupDefinition{"compute": {}}},
+		"invalid selector": {Groups: map[string]GroupDefinition{"compute": {Selector: "type="}}},
+	} {
+		if _, err := PlanGroupReconcile(defs, nil, nil); err == nil {
+			t.Errorf("PlanGroupReconcile succeeded with %s", name)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package smd
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/OpenCHAMI/ochami/pkg/xname"
+	"github.com/openchami/schemas/schemas"
+	"github.com/openchami/schemas/schemas/csm"
+)
+
+// RedfishEndpointSchemaVersion is the SchemaVersion of a RedfishEndpointV2
+// that tells SMD to create Components, ComponentEndpoints, and
+// EthernetInterfaces from its Systems and Managers. Without it, SMD treats the
+// redfish endpoint as a V1 record and ignores them.
+const RedfishEndpointSchemaVersion = 1
+
+// defaultRedfishEndpointType is the Type of redfish endpoints that do not have
+// one.
+const defaultRedfishEndpointType = "NodeBMC"
+
+// NormalizeRedfishEndpointV2 checks rfe and fills in what is needed for SMD to
+// handle it as a V2 redfish endpoint:
+//
+//   - SchemaVersion is set to RedfishEndpointSchemaVersion if not set.
+//   - Type is set to NodeBMC if not set.
+//   - If rfe has no Managers, one is derived from the BMC xname in rfe.ID,
+//     with an ethernet interface holding rfe's MAC and IP addresses.
+//
+// An error is returned if rfe has no ID or its MAC or IP address is malformed.
+// Things that SMD accepts but are likely mistakes, such as rfe having no
+// Systems (so that SMD creates no node components for it), are returned as
+// warnings.
+func NormalizeRedfishEndpointV2(rfe *RedfishEndpointV2) ([]string, error) {
+	var warnings []string
+	if rfe.ID == "" {
+		return nil, fmt.Errorf("redfish endpoint has no ID")
+	}
+	if rfe.MACAddr != "" {
+		if _, err := net.ParseMAC(rfe.MACAddr); err != nil {
+			return nil, fmt.Errorf("redfish endpoint %s: invalid MAC address %q", rfe.ID, rfe.MACAddr)
+		}
+	}
+	if rfe.IPAddress != "" && net.ParseIP(rfe.IPAddress) == nil {
+		return nil, fmt.Errorf("redfish endpoint %s: invalid IP address %q", rfe.ID, rfe.IPAddress)
+	}
+
+	if rfe.SchemaVersion == 0 {
+		rfe.SchemaVersion = RedfishEndpointSchemaVersion
+	}
+	if rfe.Type == "" {
+		rfe.Type = defaultRedfishEndpointType
+	}
+
+	if len(rfe.Managers) == 0 {
+		bmcXname := rfe.ID
+		if !csm.IsValidBMCXName(bmcXname) {
+			if x, err := xname.NodeXnameToBMCXname(bmcXname); err == nil {
+				warnings = append(warnings, fmt.Sprintf("redfish endpoint %s: ID is a node xname, using BMC xname %s for its manager", rfe.ID, x))
+				bmcXname = x
+			} else {
+				warnings = append(warnings, fmt.Sprintf("redfish endpoint %s: ID is not a BMC xname", rfe.ID))
+			}
+		}
+		m := Manager{
+			System: System{
+				URI:  managerURI(rfe, bmcXname),
+				Name: bmcXname,
+			},
+			Type: string(rfe.Type),
+		}
+		if rfe.UID != [16]byte{} {
+			m.UUID = rfe.UID.String()
+		}
+		if rfe.MACAddr != "" || rfe.IPAddress != "" {
+			m.EthernetInterfaces = append(m.EthernetInterfaces, schemas.EthernetInterface{
+				Name:        bmcXname,
+				Description: fmt.Sprintf("Interface for BMC %s", bmcXname),
+				MAC:         rfe.MACAddr,
+				IP:          rfe.IPAddress,
+			})
+		}
+		rfe.Managers = append(rfe.Managers, m)
+	}
+
+	if len(rfe.Systems) == 0 {
+		warnings = append(warnings, fmt.Sprintf("redfish endpoint %s has no Systems, so SMD will not create node components or interfaces for it", rfe.ID))
+	}
+
+	return warnings, nil
+}
+
+// managerURI returns the URI of the redfish Manager of rfe for bmcXname. Its
+// scheme and host are taken from rfe.URI, the URI of the redfish service root,
+// if set, or are otherwise https and the first of rfe's FQDN, IP address, or ID
+// that is set.
+func managerURI(rfe *RedfishEndpointV2, bmcXname string) string {
+	u := &url.URL{Scheme: "https"}
+	if root, err := url.Parse(rfe.URI); err == nil && root.Host != "" {
+		u.Scheme, u.Host = root.Scheme, root.Host
+	} else {
+		switch {
+		case rfe.FQDN != "":
+			u.Host = rfe.FQDN
+		case rfe.IPAddress != "":
+			u.Host = rfe.IPAddress
+			if ip := net.ParseIP(rfe.IPAddress); ip != nil && ip.To4() == nil {
+				u.Host = "[" + rfe.IPAddress + "]"
+			}
+		default:
+			u.Host = rfe.ID
+		}
+	}
+	u.Path = "/redfish/v1/Managers/" + bmcXname
+	return u.String()
+}
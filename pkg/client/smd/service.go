@@ -0,0 +1,128 @@
+package smd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"github.com/OpenCHAMI/ochami/pkg/client"
+)
+
+// ServiceValues mirrors the response of SMD's /service/values endpoint, which
+// lists the values SMD accepts for the enumerated fields of a component.
+type ServiceValues struct {
+	Arch    []string `json:"Arch,omitempty"`
+	Class   []string `json:"Class,omitempty"`
+	Flag    []string `json:"Flag,omitempty"`
+	NetType []string `json:"NetType,omitempty"`
+	Role    []string `json:"Role,omitempty"`
+	SubRole []string `json:"SubRole,omitempty"`
+	State   []string `json:"State,omitempty"`
+	Type    []string `json:"Type,omitempty"`
+}
+
+// ServiceStatus is a summary of the state of SMD. Ready reports whether SMD's
+// /service/ready endpoint succeeded, which SMD only does when its database is
+// reachable, and Message is the message that accompanied the result. Version
+// is empty if SMD did not report its version.
+type ServiceStatus struct {
+	Ready   bool           `json:"Ready"`
+	Message string         `json:"Message,omitempty"`
+	Version string         `json:"Version,omitempty"`
+	Values  *ServiceValues `json:"Values,omitempty"`
+}
+
+// GetServiceValues returns the values SMD accepts for the enumerated fields of
+// a component. The values are fetched once per SMDClient and cached, since they
+// only change when SMD is upgraded.
+func (sc *SMDClient) GetServiceValues() (ServiceValues, error) {
+	sc.valuesMu.Lock()
+	defer sc.valuesMu.Unlock()
+	if sc.values != nil {
+		return *sc.values, nil
+	}
+
+	henv, err := sc.GetStatus("all")
+	if err != nil {
+		return ServiceValues{}, fmt.Errorf("GetServiceValues(): %w", err)
+	}
+	var vals ServiceValues
+	if err := json.Unmarshal(henv.Body, &vals); err != nil {
+		return ServiceValues{}, fmt.Errorf("GetServiceValues(): failed to unmarshal service values: %w", err)
+	}
+	sc.values = &vals
+
+	return vals, nil
+}
+
+// GetServiceStatus queries SMD's readiness, version, and service values and
+// returns them as a ServiceStatus. SMD reporting that it is not ready (e.g.
+// because its database is unavailable) is not an error; it is reflected in
+// Ready instead. The version and values are best-effort and are omitted if
+// they cannot be fetched.
+func (sc *SMDClient) GetServiceStatus() (ServiceStatus, error) {
+	var status ServiceStatus
+
+	henv, err := sc.GetStatus("")
+	if err != nil {
+		if !errors.Is(err, client.UnsuccessfulHTTPError) || henv.StatusCode != http.StatusServiceUnavailable {
+			return status, fmt.Errorf("GetServiceStatus(): %w", err)
+		}
+	} else {
+		status.Ready = true
+	}
+	status.Message = readyMessage(henv.Body)
+
+	if henv, err := sc.GetData(path.Join(SMDRelpathService, "version"), "", nil); err != nil {
+		log.ClientLogger.Debug().Err(err).Msg("SMD did not report its version")
+	} else {
+		status.Version = versionString(henv.Body)
+	}
+
+	if status.Ready {
+		if vals, err := sc.GetServiceValues(); err != nil {
+			log.ClientLogger.Warn().Err(err).Msg("failed to get SMD service values")
+		} else {
+			status.Values = &vals
+		}
+	}
+
+	return status, nil
+}
+
+// readyMessage extracts the message from the body of a /service/ready
+// response, which is either a JSON object with a "message" field or, for
+// errors, an RFC 7807 problem with a "detail" field. If neither is present,
+// the body is returned as is.
+func readyMessage(body client.HTTPBody) string {
+	var msg struct {
+		Message string `json:"message"`
+		Detail  string `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return string(body)
+	}
+	if msg.Message != "" {
+		return msg.Message
+	}
+	return msg.Detail
+}
+
+// versionString extracts the version from the body of a /service/version
+// response, which may be a JSON object with a "version" field or plain text.
+func versionString(body client.HTTPBody) string {
+	var v struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(body, &v); err == nil && v.Version != "" {
+		return v.Version
+	}
+	var s string
+	if err := json.Unmarshal(body, &s); err == nil {
+		return s
+	}
+	return string(body)
+}
@@ -1,14 +1,20 @@
 package smd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"path"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/nid"
 	"github.com/openchami/schemas/schemas"
 	"github.com/openchami/schemas/schemas/csm"
 )
@@ -17,6 +23,10 @@ import (
 // that BSS uses.
 type SMDClient struct {
 	*client.OchamiClient
+
+	// values caches the response of GetServiceValues
+	values   *ServiceValues
+	valuesMu sync.Mutex
 }
 
 const (
@@ -28,21 +38,43 @@ const (
 	SMDRelpathEthernetInterfaces = "/Inventory/EthernetInterfaces"
 	SMDRelpathRedfishEndpoints   = "/Inventory/RedfishEndpoints"
 	SMDRelpathComponentEndpoints = "/Inventory/ComponentEndpoints"
+	SMDRelpathHardware           = "/Inventory/Hardware"
+	SMDRelpathHardwareHistory    = "/Inventory/Hardware/History"
+	SMDRelpathHardwareByFRU      = "/Inventory/HardwareByFRU"
+	SMDRelpathFRUHistory         = "/Inventory/HardwareByFRU/History"
 	SMDRelpathGroups             = "/groups"
+	SMDRelpathMemberships        = "/memberships"
+
+	SMDSubpathBulkNID       = "BulkNID"
+	SMDSubpathBulkStateData = "BulkStateData"
+	SMDSubpathByNIDQuery    = "ByNID/Query"
 
-	SMDSubpathBulkNID = "BulkNID"
+	// nidBatchSize is the maximum number of NIDs resolved per request by
+	// GetComponentsByNIDs.
+	nidBatchSize = 500
 )
 
-// Component is a minimal subset of SMD's Component struct that contains only
-// what is necessary for sending a valid Component request to SMD.
+// Component mirrors SMD's Component struct. All fields except ID and Type are
+// optional and omitted when empty so that data read from SMD can be sent back
+// without losing information. Enabled, ReservationDisabled, and Locked are
+// pointers so that an explicit false can be distinguished from an unset value
+// and is sent back as read, since SMD treats a missing Enabled as true.
 type Component struct {
-	ID      string `json:"ID"`
-	Type    string `json:"Type"`
-	State   string `json:"State,omitempty"`
-	Enabled bool   `json:"Enabled,omitempty"`
-	Role    string `json:"Role,omitempty"`
-	Arch    string `json:"Arch,omitempty"`
-	NID     int64  `json:"NID,omitempty"`
+	ID                  string `json:"ID" jsonschema:"required"`
+	Type                string `json:"Type"`
+	Subtype             string `json:"Subtype,omitempty"`
+	Role                string `json:"Role,omitempty"`
+	SubRole             string `json:"SubRole,omitempty"`
+	NetType             string `json:"NetType,omitempty"`
+	Arch                string `json:"Arch,omitempty"`
+	Class               string `json:"Class,omitempty"`
+	State               string `json:"State,omitempty"`
+	Flag                string `json:"Flag,omitempty"`
+	Enabled             *bool  `json:"Enabled,omitempty"`
+	SoftwareStatus      string `json:"SoftwareStatus,omitempty"`
+	NID                 int64  `json:"NID,omitempty"`
+	ReservationDisabled *bool  `json:"ReservationDisabled,omitempty"`
+	Locked              *bool  `json:"Locked,omitempty"`
 }
 
 // ComponentSlice is a convenience data structure to make marshalling Component
@@ -59,7 +91,7 @@ type EthernetInterface struct {
 	ComponentID string       `json:"ComponentID"`
 	Type        string       `json:"Type"`
 	Description string       `json:"Description"`
-	MACAddress  string       `json:"MACAddress"`
+	MACAddress  string       `json:"MACAddress" jsonschema:"required"`
 	IPAddresses []EthernetIP `json:"IPAddresses"`
 }
 
@@ -110,7 +142,7 @@ type Manager struct {
 
 // Group represents the payload structure for SMD groups.
 type Group struct {
-	Label          string   `json:"label"`
+	Label          string   `json:"label" jsonschema:"required"`
 	Description    string   `json:"description"`
 	Tags           []string `json:"tags,omitempty"`
 	ExclusiveGroup string   `json:"exclusiveGroup,omitempty"`
@@ -126,6 +158,42 @@ type GroupMembers struct {
 	IDs   []string `json:"ids"`
 }
 
+// Membership mirrors an entry of SMD's /memberships endpoint, which lists the
+// groups and partition that a component is a member of.
+type Membership struct {
+	ID            string   `json:"id"`
+	GroupLabels   []string `json:"groupLabels"`
+	PartitionName string   `json:"partitionName,omitempty"`
+}
+
+// ComponentEndpoint is the part of an entry of SMD's /ComponentEndpoints
+// endpoint that locates a component's Redfish resource on its BMC.
+type ComponentEndpoint struct {
+	ID                  string `json:"ID"`
+	RedfishType         string `json:"RedfishType"`
+	RedfishURL          string `json:"RedfishURL"`
+	RedfishEndpointID   string `json:"RedfishEndpointID"`
+	RedfishEndpointFQDN string `json:"RedfishEndpointFQDN"`
+}
+
+// ComponentEndpointSlice mirrors the response of SMD's /ComponentEndpoints
+// endpoint.
+type ComponentEndpointSlice struct {
+	ComponentEndpoints []ComponentEndpoint `json:"ComponentEndpoints"`
+}
+
+// LiveStatus is the state of a component as read from its BMC at the time
+// FetchedAt rather than from SMD. Error is set instead of the other fields if
+// the BMC could not be queried.
+type LiveStatus struct {
+	PowerState string    `json:"PowerState,omitempty"`
+	Health     string    `json:"Health,omitempty"`
+	State      string    `json:"State,omitempty"`
+	Source     string    `json:"Source,omitempty"`
+	FetchedAt  time.Time `json:"FetchedAt"`
+	Error      string    `json:"Error,omitempty"`
+}
+
 // NewClient takes a baseURI and basePath and returns a pointer to a new
 // SMDClient. If an error occurred creating the embedded OchamiClient, it is
 // returned. If insecure is true, TLS certificates will not be verified.
@@ -181,6 +249,116 @@ func (sc *SMDClient) GetComponentsAll() (client.HTTPEnvelope, error) {
 	return henv, err
 }
 
+// GetComponents is like GetComponentsAll except that it takes an optional query
+// string (without the "?") and a token. The query string is passed as-is to SMD's
+// /State/Components endpoint, e.g. "type=Node&state=Empty".
+func (sc *SMDClient) GetComponents(query, token string) (client.HTTPEnvelope, error) {
+	var henv client.HTTPEnvelope
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return henv, fmt.Errorf("GetComponents(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	henv, err := sc.GetData(SMDRelpathComponents, query, headers)
+	if err != nil {
+		err = fmt.Errorf("GetComponents(): error getting components: %w", err)
+	}
+
+	return henv, err
+}
+
+// GetComponentsValues is like GetComponents, except that the query is passed as values that
+// are encoded according to the client's QueryEncoding, e.g. to repeat a key.
+func (sc *SMDClient) GetComponentsValues(query url.Values, token string) (client.HTTPEnvelope, error) {
+	return sc.GetComponents(sc.QueryEncoding.Encode(query), token)
+}
+
+// GetComponentIDsMatching takes a filter in query string form (e.g.
+// "state=Empty&type=Node") and a token and returns the IDs (xnames) of the
+// components in SMD that match it. An empty filter is rejected, since it would
+// match every component.
+func (sc *SMDClient) GetComponentIDsMatching(filter, token string) ([]string, error) {
+	if err := checkFilter(filter); err != nil {
+		return nil, fmt.Errorf("GetComponentIDsMatching(): %w", err)
+	}
+	comps, err := sc.ComponentPager(filter, token).All(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("GetComponentIDsMatching(): failed to get components matching %q: %w", filter, err)
+	}
+	ids := make([]string, 0, len(comps))
+	for _, comp := range comps {
+		ids = append(ids, comp.ID)
+	}
+
+	return ids, nil
+}
+
+// GetComponentsByNIDs takes a list of NIDs and a token and returns the
+// components in SMD that have those NIDs, using SMD's
+// /State/Components/ByNID/Query endpoint. The NIDs are sent as ranges in
+// batches of at most nidBatchSize NIDs so that large lists do not result in
+// overly large requests. NIDs that no component has are not an error; compare
+// the NIDs of the returned components to detect them, or use
+// GetXnamesByNIDs.
+func (sc *SMDClient) GetComponentsByNIDs(nids []int64, token string) ([]Component, error) {
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return nil, fmt.Errorf("GetComponentsByNIDs(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	finalEP := path.Join(SMDRelpathComponents, SMDSubpathByNIDQuery)
+	var comps []Component
+	for _, batch := range nid.Batch(nids, nidBatchSize) {
+		body, err := json.Marshal(map[string][]string{"NIDRanges": nid.Ranges(batch)})
+		if err != nil {
+			return nil, fmt.Errorf("GetComponentsByNIDs(): failed to marshal NID query: %w", err)
+		}
+		henv, err := sc.PostData(finalEP, "", headers, body)
+		if err != nil {
+			return nil, fmt.Errorf("GetComponentsByNIDs(): failed to query components for NIDs %s: %w", nid.Format(batch), err)
+		}
+		var compSlice ComponentSlice
+		if err := json.Unmarshal(henv.Body, &compSlice); err != nil {
+			return nil, fmt.Errorf("GetComponentsByNIDs(): failed to unmarshal components: %w", err)
+		}
+		comps = append(comps, compSlice.Components...)
+	}
+
+	return comps, nil
+}
+
+// GetXnamesByNIDs is like GetComponentsByNIDs except that it returns the IDs
+// (xnames) of the components, in the order of nids. An error is returned if no
+// component has one or more of the NIDs.
+func (sc *SMDClient) GetXnamesByNIDs(nids []int64, token string) ([]string, error) {
+	comps, err := sc.GetComponentsByNIDs(nids, token)
+	if err != nil {
+		return nil, fmt.Errorf("GetXnamesByNIDs(): %w", err)
+	}
+	byNID := make(map[int64]string, len(comps))
+	for _, comp := range comps {
+		byNID[comp.NID] = comp.ID
+	}
+	var (
+		xnames  []string
+		missing []int64
+	)
+	for _, n := range nids {
+		if x, ok := byNID[n]; ok {
+			xnames = append(xnames, x)
+		} else {
+			missing = append(missing, n)
+		}
+	}
+	if len(missing) > 0 {
+		return xnames, fmt.Errorf("GetXnamesByNIDs(): no component found for NID(s) %s", nid.Format(missing))
+	}
+
+	return xnames, nil
+}
+
 // GetComponentsXname is like GetComponentsAll except that it takes a token and
 // queries /State/Components/{xname}.
 func (sc *SMDClient) GetComponentsXname(xname, token string) (client.HTTPEnvelope, error) {
@@ -232,7 +410,7 @@ func (sc *SMDClient) GetRedfishEndpoints(query, token string) (client.HTTPEnvelo
 	headers = client.NewHTTPHeaders()
 	if token != "" {
 		if err = headers.SetAuthorization(token); err != nil {
-			return henv, fmt.Errorf("GetRedfishEndpoints(): error setting token in HTTP headers: %w")
+			return henv, fmt.Errorf("GetRedfishEndpoints(): error setting token in HTTP headers: %w", err)
 		}
 	}
 	henv, err = sc.GetData(SMDRelpathRedfishEndpoints, query, headers)
@@ -243,18 +421,107 @@ func (sc *SMDClient) GetRedfishEndpoints(query, token string) (client.HTTPEnvelo
 	return henv, err
 }
 
-// GetEthernetInterfaces is a wrapper around OchamiClient.GetData that takes a
-// query string and passes it to OchamiClient.GetData using SMD's ethernet
-// interfaces endpoint.
-func (sc *SMDClient) GetEthernetInterfaces(query string) (client.HTTPEnvelope, error) {
-	henv, err := sc.GetData(SMDRelpathEthernetInterfaces, query, nil)
+// GetRedfishEndpointsValues is like GetRedfishEndpoints, except that the query is passed as values that
+// are encoded according to the client's QueryEncoding, e.g. to repeat a key.
+func (sc *SMDClient) GetRedfishEndpointsValues(query url.Values, token string) (client.HTTPEnvelope, error) {
+	return sc.GetRedfishEndpoints(sc.QueryEncoding.Encode(query), token)
+}
+
+// GetHardwareHistory is a wrapper around OchamiClient.GetData that takes an
+// optional query string (without the "?") and a token. It sets token as the
+// authorization bearer in the headers and passes the query string and headers
+// to OchamiClient.GetData, using the SMD hardware inventory history API
+// endpoint. SMD accepts the id, eventtype, starttime, and endtime query
+// parameters, the latter two as RFC 3339 timestamps.
+func (sc *SMDClient) GetHardwareHistory(query, token string) (client.HTTPEnvelope, error) {
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return client.HTTPEnvelope{}, fmt.Errorf("GetHardwareHistory(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	henv, err := sc.GetData(SMDRelpathHardwareHistory, query, headers)
 	if err != nil {
-		err = fmt.Errorf("GetEthernetInterfaces(): error getting ethernet interfaces: %w", err)
+		err = fmt.Errorf("GetHardwareHistory(): error getting hardware inventory history: %w", err)
 	}
 
 	return henv, err
 }
 
+// GetHardwareHistoryValues is like GetHardwareHistory, except that the query is passed as values that
+// are encoded according to the client's QueryEncoding, e.g. to repeat a key.
+func (sc *SMDClient) GetHardwareHistoryValues(query url.Values, token string) (client.HTTPEnvelope, error) {
+	return sc.GetHardwareHistory(sc.QueryEncoding.Encode(query), token)
+}
+
+// GetRedfishEndpoint returns the redfish endpoint with ID xname (e.g. a BMC
+// xname) from SMD. token, if not empty, is sent as the authorization bearer.
+func (sc *SMDClient) GetRedfishEndpoint(xname, token string) (csm.RedfishEndpoint, error) {
+	var rfe csm.RedfishEndpoint
+	if xname == "" {
+		return rfe, fmt.Errorf("GetRedfishEndpoint(): xname cannot be empty")
+	}
+	rfePath, err := url.JoinPath(SMDRelpathRedfishEndpoints, xname)
+	if err != nil {
+		return rfe, fmt.Errorf("GetRedfishEndpoint(): failed to join redfish endpoint path (%s) with xname (%s): %w", SMDRelpathRedfishEndpoints, xname, err)
+	}
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return rfe, fmt.Errorf("GetRedfishEndpoint(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	henv, err := sc.GetData(rfePath, "", headers)
+	if err != nil {
+		return rfe, fmt.Errorf("GetRedfishEndpoint(): error getting redfish endpoint %s: %w", xname, err)
+	}
+	if err := json.Unmarshal(henv.Body, &rfe); err != nil {
+		return rfe, fmt.Errorf("GetRedfishEndpoint(): failed to unmarshal redfish endpoint %s: %w", xname, err)
+	}
+
+	return rfe, nil
+}
+
+// GetEthernetInterfaces is a wrapper around OchamiClient.GetData that gets the
+// ethernet interfaces matching filter from SMD's ethernet interfaces endpoint.
+// The fields of filter that SMD cannot filter on are applied to the body of
+// the returned envelope (see EthernetInterfaceFilter). An empty filter gets
+// all ethernet interfaces.
+func (sc *SMDClient) GetEthernetInterfaces(filter EthernetInterfaceFilter) (client.HTTPEnvelope, error) {
+	if err := filter.Validate(); err != nil {
+		return client.HTTPEnvelope{}, fmt.Errorf("GetEthernetInterfaces(): invalid filter: %w", err)
+	}
+	henv, err := sc.GetData(SMDRelpathEthernetInterfaces, filter.Query(), nil)
+	if err != nil {
+		return henv, fmt.Errorf("GetEthernetInterfaces(): error getting ethernet interfaces: %w", err)
+	}
+	if henv.Body, err = filter.filter(henv.Body); err != nil {
+		return henv, fmt.Errorf("GetEthernetInterfaces(): failed to filter ethernet interfaces: %w", err)
+	}
+
+	return henv, nil
+}
+
+// GetEthernetInterfaceIDsMatching takes a filter in query string form (e.g.
+// "ComponentID=x3000c1s7b56n0&Type=Node") and a token and returns the IDs of the
+// ethernet interfaces in SMD that match it. An empty filter is rejected, since
+// it would match every ethernet interface.
+func (sc *SMDClient) GetEthernetInterfaceIDsMatching(filter, token string) ([]string, error) {
+	if err := checkFilter(filter); err != nil {
+		return nil, fmt.Errorf("GetEthernetInterfaceIDsMatching(): %w", err)
+	}
+	eis, err := sc.EthernetInterfacePager(filter, token).All(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("GetEthernetInterfaceIDsMatching(): failed to get ethernet interfaces matching %q: %w", filter, err)
+	}
+	ids := make([]string, 0, len(eis))
+	for _, ei := range eis {
+		ids = append(ids, ei.ID)
+	}
+
+	return ids, nil
+}
+
 // GetEthernetInterfacesByID is a wrapper around OchamiClient.GetData that takes
 // an ethernet interface ID, token, and a flag indicating if the ethernet
 // interface itself should be retrieved or a list of its IPs. It passes these to
@@ -307,17 +574,15 @@ func (sc *SMDClient) GetComponentEndpoints(token string, xnames ...string) ([]cl
 			return henvs, errors, fmt.Errorf("GetComponentEndpoints(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, xname := range xnames {
+	henvs, errors = client.BulkRequest(xnames, sc.BulkConcurrency(http.MethodGet), func(xname string) (client.HTTPEnvelope, error) {
 		henv, err := sc.GetData(SMDRelpathComponentEndpoints+"/"+xname, "", headers)
 		if err != nil {
 			newErr := fmt.Errorf("GetComponentEndpoints(): failed to GET component endpoint from SMD: %w", err)
-			log.Logger.Debug().Err(err).Msg("failed to get component endpoint")
-			errors = append(errors, newErr)
-			continue
+			log.ClientLogger.Debug().Err(err).Msg("failed to get component endpoint")
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-		henvs = append(henvs, henv)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -370,6 +635,12 @@ func (sc *SMDClient) GetGroups(query, token string) (client.HTTPEnvelope, error)
 	return henv, err
 }
 
+// GetGroupsValues is like GetGroups, except that the query is passed as values that
+// are encoded according to the client's QueryEncoding, e.g. to repeat a key.
+func (sc *SMDClient) GetGroupsValues(query url.Values, token string) (client.HTTPEnvelope, error) {
+	return sc.GetGroups(sc.QueryEncoding.Encode(query), token)
+}
+
 // GetGroupMembers is a wrapper function around OchamiClient.GetData that takes
 // a group name, which it passes to the GetData function using the SMD group
 // membership endpoint. It also takes a token, which it puts into the headers as
@@ -380,7 +651,7 @@ func (sc *SMDClient) GetGroupMembers(group, token string) (client.HTTPEnvelope,
 	}
 	finalEP, err := url.JoinPath(SMDRelpathGroups, group, "members")
 	if err != nil {
-		return client.HTTPEnvelope{}, fmt.Errorf("GetGroupMembers(): failed to join group path (%s) with membership path for gorup %s: %w", SMDRelpathGroups, group)
+		return client.HTTPEnvelope{}, fmt.Errorf("GetGroupMembers(): failed to join group path (%s) with membership path for group %s: %w", SMDRelpathGroups, group, err)
 	}
 	headers := client.NewHTTPHeaders()
 	if token != "" {
@@ -396,6 +667,108 @@ func (sc *SMDClient) GetGroupMembers(group, token string) (client.HTTPEnvelope,
 	return henv, err
 }
 
+// GetMemberships returns the group and partition memberships of the components
+// matching query (without the "?", e.g. "id=x1000c1s7b0n0"), or of all
+// components if query is empty, in a single request. token, if not empty, is
+// sent as the authorization bearer.
+func (sc *SMDClient) GetMemberships(query, token string) ([]Membership, error) {
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return nil, fmt.Errorf("GetMemberships(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	henv, err := sc.GetData(SMDRelpathMemberships, query, headers)
+	if err != nil {
+		return nil, fmt.Errorf("GetMemberships(): error getting memberships: %w", err)
+	}
+	var memberships []Membership
+	if err := json.Unmarshal(henv.Body, &memberships); err != nil {
+		return nil, fmt.Errorf("GetMemberships(): failed to unmarshal memberships: %w", err)
+	}
+
+	return memberships, nil
+}
+
+// GetMembershipsValues is like GetMemberships, except that the query is passed as values that
+// are encoded according to the client's QueryEncoding, e.g. to repeat a key.
+func (sc *SMDClient) GetMembershipsValues(query url.Values, token string) ([]Membership, error) {
+	return sc.GetMemberships(sc.QueryEncoding.Encode(query), token)
+}
+
+// AddMemberships adds the groups and partition of each component in body, which
+// is either a single component or an object with a "Components" list as
+// returned by SMD, from memberships. The groups are added as a "Groups" list
+// and the partition as "Partition", if any. All other data in body is kept as
+// is. Components without memberships get an empty "Groups" list.
+func AddMemberships(body client.HTTPBody, memberships []Membership) (client.HTTPBody, error) {
+	byID := make(map[string]Membership, len(memberships))
+	for _, m := range memberships {
+		byID[strings.ToLower(m.ID)] = m
+	}
+	annotate := func(comp map[string]any) {
+		id, _ := comp["ID"].(string)
+		m := byID[strings.ToLower(id)]
+		groups := m.GroupLabels
+		if groups == nil {
+			groups = []string{}
+		}
+		comp["Groups"] = groups
+		if m.PartitionName != "" {
+			comp["Partition"] = m.PartitionName
+		}
+	}
+
+	return annotateComponents(body, annotate, "AddMemberships")
+}
+
+// AddLiveStatus adds the live status in live, keyed by component ID, to each
+// component in body as "Live", leaving the fields read from SMD untouched so
+// that live and cached values can be told apart. Components without an entry
+// in live get none. body may hold either a single component or a list of
+// components, as returned by SMD's /Components endpoints.
+func AddLiveStatus(body client.HTTPBody, live map[string]LiveStatus) (client.HTTPBody, error) {
+	byID := make(map[string]LiveStatus, len(live))
+	for id, l := range live {
+		byID[strings.ToLower(id)] = l
+	}
+	annotate := func(comp map[string]any) {
+		id, _ := comp["ID"].(string)
+		if l, ok := byID[strings.ToLower(id)]; ok {
+			comp["Live"] = l
+		}
+	}
+
+	return annotateComponents(body, annotate, "AddLiveStatus")
+}
+
+// annotateComponents calls annotate on each component in body, which may hold
+// either a single component or a list of components, and returns the modified
+// body. funcName prefixes returned errors.
+func annotateComponents(body client.HTTPBody, annotate func(map[string]any), funcName string) (client.HTTPBody, error) {
+	var data map[string]any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return body, fmt.Errorf("%s(): failed to unmarshal components: %w", funcName, err)
+	}
+	if comps, ok := data["Components"].([]any); ok {
+		for _, c := range comps {
+			if comp, ok := c.(map[string]any); ok {
+				annotate(comp)
+			}
+		}
+	} else if _, ok := data["ID"]; ok {
+		annotate(data)
+	} else {
+		return body, fmt.Errorf("%s(): body contains neither a component nor a list of components", funcName)
+	}
+
+	newBody, err := json.Marshal(data)
+	if err != nil {
+		return body, fmt.Errorf("%s(): failed to marshal components: %w", funcName, err)
+	}
+	return newBody, nil
+}
+
 // PostComponents is a wrapper function around OchamiClient.PostData that takes
 // a ComponentSlice and a token, puts the token in the request headers as an
 // authorization bearer, marshalls compSlice as JSON and sets it as the request
@@ -440,24 +813,20 @@ func (sc *SMDClient) PostRedfishEndpoints(rfes RedfishEndpointSlice, token strin
 			return henvs, errors, fmt.Errorf("PostRedfishEndpoints(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, rfe := range rfes.RedfishEndpoints {
+	henvs, errors = client.BulkRequest(rfes.RedfishEndpoints, sc.BulkConcurrency(http.MethodPost), func(rfe csm.RedfishEndpoint) (client.HTTPEnvelope, error) {
 		var body client.HTTPBody
 		var err error
 		if body, err = json.Marshal(rfe); err != nil {
 			newErr := fmt.Errorf("PostRedfishEndpoints(): failed to marshal RedfishEndpoint: %w", err)
-			errors = append(errors, newErr)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.PostData(SMDRelpathRedfishEndpoints, "", headers, body)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("PostRedfishEndpoints(): failed to POST redfish endpoint to SMD: %w", err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -476,24 +845,20 @@ func (sc *SMDClient) PostRedfishEndpointsV2(rfes RedfishEndpointSliceV2, token s
 			return henvs, errors, fmt.Errorf("PostRedfishEndpointsV2(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, rfe := range rfes.RedfishEndpoints {
+	henvs, errors = client.BulkRequest(rfes.RedfishEndpoints, sc.BulkConcurrency(http.MethodPost), func(rfe RedfishEndpointV2) (client.HTTPEnvelope, error) {
 		var body client.HTTPBody
 		var err error
 		if body, err = json.Marshal(rfe); err != nil {
 			newErr := fmt.Errorf("PostRedfishEndpointsV2(): failed to marshal RedfishEndpoint: %w", err)
-			errors = append(errors, newErr)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.PostData(SMDRelpathRedfishEndpoints, "", headers, body)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("PostRedfishEndpointsV2(): failed to POST redfish endpoint to SMD: %w", err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -514,24 +879,20 @@ func (sc *SMDClient) PostEthernetInterfaces(eis []EthernetInterface, token strin
 			return henvs, errors, fmt.Errorf("PostEthernetInterfaces(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, ei := range eis {
+	henvs, errors = client.BulkRequest(eis, sc.BulkConcurrency(http.MethodPost), func(ei EthernetInterface) (client.HTTPEnvelope, error) {
 		var body client.HTTPBody
 		var err error
 		if body, err = json.Marshal(ei); err != nil {
 			newErr := fmt.Errorf("PostEthernetInterfaces(): failed to marshal EthernetInterface: %w", err)
-			errors = append(errors, newErr)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.PostData(SMDRelpathEthernetInterfaces, "", headers, body)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("PostEthernetInterfaces(): failed to POST ethernet interface(s) to SMD: %w", err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -552,24 +913,20 @@ func (sc *SMDClient) PostGroups(groups []Group, token string) ([]client.HTTPEnve
 			return henvs, errors, fmt.Errorf("PostGroups(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, group := range groups {
+	henvs, errors = client.BulkRequest(groups, sc.BulkConcurrency(http.MethodPost), func(group Group) (client.HTTPEnvelope, error) {
 		var body client.HTTPBody
 		var err error
 		if body, err = json.Marshal(group); err != nil {
 			newErr := fmt.Errorf("PostGroups(): failed to marshal Group: %w", err)
-			errors = append(errors, newErr)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.PostData(SMDRelpathGroups, "", headers, body)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("PostGroups(): failed to POST group to SMD: %w", err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -582,7 +939,6 @@ func (sc *SMDClient) PostGroupMembers(token, group string, members ...string) ([
 	var (
 		henvs   []client.HTTPEnvelope
 		headers *client.HTTPHeaders
-		body    client.HTTPBody
 		errors  []error
 	)
 	if group == "" {
@@ -597,31 +953,26 @@ func (sc *SMDClient) PostGroupMembers(token, group string, members ...string) ([
 			return henvs, errors, fmt.Errorf("PostGroupMembers(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, member := range members {
+	henvs, errors = client.BulkRequest(members, sc.BulkConcurrency(http.MethodPost), func(member string) (client.HTTPEnvelope, error) {
 		groupPath, err := url.JoinPath(SMDRelpathGroups, group, "members")
 		if err != nil {
-			newErr := fmt.Errorf("PostGroupMembers(): failed to join group path (%s) with group label (%s): %w", SMDRelpathGroups, group)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			newErr := fmt.Errorf("PostGroupMembers(): failed to join group path (%s) with group label (%s): %w", SMDRelpathGroups, group, err)
+			return client.HTTPEnvelope{}, newErr
 		}
 		m := make(map[string]string)
 		m["id"] = member
-		if body, err = json.Marshal(m); err != nil {
+		body, err := json.Marshal(m)
+		if err != nil {
 			newErr := fmt.Errorf("PostGroupMembers(): failed to marshal member id %s: %w", member, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.PostData(groupPath, "", headers, body)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("PostGroupMembers(): failed to POST member %s to group %s: %w", member, group, err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -648,19 +999,15 @@ func (sc *SMDClient) PutComponents(compSlice ComponentSlice, token string) ([]cl
 			return henvs, errors, fmt.Errorf("PutComponents(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, comp := range compSlice.Components {
+	henvs, errors = client.BulkRequest(compSlice.Components, sc.BulkConcurrency(http.MethodPut), func(comp Component) (client.HTTPEnvelope, error) {
 		if comp.ID == "" {
 			newErr := fmt.Errorf("PutComponents(): unable to update component with blank ID")
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		xnamePath, err := url.JoinPath(SMDRelpathComponents, comp.ID)
 		if err != nil {
 			newErr := fmt.Errorf("PutComponents(): failed join component path (%s) with xname (%s): %w", SMDRelpathComponents, comp.ID, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		// SMD is weird and requires the PUT body to be a structure that
 		// _contains_ the component, so we do that here.
@@ -668,17 +1015,15 @@ func (sc *SMDClient) PutComponents(compSlice ComponentSlice, token string) ([]cl
 		body, marshalErr := json.Marshal(putComp)
 		if marshalErr != nil {
 			newErr := fmt.Errorf("PutComponents(): failed to marshal component into JSON: %w", marshalErr)
-			errors = append(errors, newErr)
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.PutData(xnamePath, "", headers, body)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("PutComponents(): failed to PUT component %s in SMD: %w", comp.ID, err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -699,37 +1044,29 @@ func (sc *SMDClient) PutRedfishEndpoints(rfes RedfishEndpointSlice, token string
 			return henvs, errors, fmt.Errorf("PutRedfishEndpoints(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, rfe := range rfes.RedfishEndpoints {
+	henvs, errors = client.BulkRequest(rfes.RedfishEndpoints, sc.BulkConcurrency(http.MethodPut), func(rfe csm.RedfishEndpoint) (client.HTTPEnvelope, error) {
 		var body client.HTTPBody
 		var err error
 		if rfe.ID == "" {
 			newErr := fmt.Errorf("PutRedfishEndpoints(): unable to update redfish endpoint with blank ID")
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		xnamePath, err := url.JoinPath(SMDRelpathRedfishEndpoints, rfe.ID)
 		if err != nil {
 			newErr := fmt.Errorf("PutRedfishEndpoints(): failed to join redfish endpoint path (%s) with xname (%s): %w", SMDRelpathRedfishEndpoints, rfe.ID, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		if body, err = json.Marshal(rfe); err != nil {
 			newErr := fmt.Errorf("PutRedfishEndpoints(): failed to marshal RedfishEndpoint: %w", err)
-			errors = append(errors, newErr)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.PutData(xnamePath, "", headers, body)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("PutRedfishEndpoints(): failed to PUT redfish endpoint to SMD: %w", err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -748,37 +1085,29 @@ func (sc *SMDClient) PutRedfishEndpointsV2(rfes RedfishEndpointSliceV2, token st
 			return henvs, errors, fmt.Errorf("PutRedfishEndpointsV2(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, rfe := range rfes.RedfishEndpoints {
+	henvs, errors = client.BulkRequest(rfes.RedfishEndpoints, sc.BulkConcurrency(http.MethodPut), func(rfe RedfishEndpointV2) (client.HTTPEnvelope, error) {
 		var body client.HTTPBody
 		var err error
 		if rfe.ID == "" {
 			newErr := fmt.Errorf("PutRedfishEndpointsV2(): unable to update redfish endpoint with blank ID")
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		xnamePath, err := url.JoinPath(SMDRelpathRedfishEndpoints, rfe.ID)
 		if err != nil {
 			newErr := fmt.Errorf("PutRedfishEndpointsV2(): failed to join redfish endpoint path (%s) with xname (%s): %w", SMDRelpathRedfishEndpoints, rfe.ID, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		if body, err = json.Marshal(rfe); err != nil {
 			newErr := fmt.Errorf("PutRedfishEndpointsV2(): failed to marshal RedfishEndpoint: %w", err)
-			errors = append(errors, newErr)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.PutData(xnamePath, "", headers, body)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("PutRedfishEndpointsV2(): failed to PUT redfish endpoint to SMD: %w", err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -814,7 +1143,7 @@ func (sc *SMDClient) PutGroupMembers(token, group string, members ...string) (cl
 	// Calculate endpoint path for group
 	groupPath, err := url.JoinPath(SMDRelpathGroups, group, "members")
 	if err != nil {
-		return henv, fmt.Errorf("PutGroupMembers(): failed to join group path (%s) with group label (%s): %w", SMDRelpathGroups, group)
+		return henv, fmt.Errorf("PutGroupMembers(): failed to join group path (%s) with group label (%s): %w", SMDRelpathGroups, group, err)
 	}
 
 	// Send request and return response
@@ -833,6 +1162,81 @@ func (sc *SMDClient) PutGroupMembers(token, group string, members ...string) (cl
 	return henv, err
 }
 
+// DefaultGroupMemberBatchSize is the largest number of members that
+// SetGroupMembers sets with a single PUT by default.
+const DefaultGroupMemberBatchSize = 1000
+
+// SetGroupMembers sets the members of group to members, like PutGroupMembers,
+// without sending a PUT of more than batchSize members. SMD can only replace
+// the whole member list of a group with a PUT and add members one at a time,
+// so if there are more than batchSize members (and batchSize is at least 1),
+// the current members of the group are fetched and only the difference is
+// sent: a DELETE for each member to remove, followed by a POST for each member
+// to add. This way, members that are to stay in the group are never removed
+// from it in between. Otherwise, a single PUT is sent. The envelopes and
+// errors of the requests are returned in the order they were sent; the error
+// is for errors that occur before any are.
+func (sc *SMDClient) SetGroupMembers(token, group string, batchSize int, members ...string) ([]client.HTTPEnvelope, []error, error) {
+	if group == "" {
+		return nil, nil, fmt.Errorf("SetGroupMembers(): no group label specified to set members of")
+	}
+	if len(members) == 0 {
+		return nil, nil, fmt.Errorf("SetGroupMembers(): no members specified")
+	}
+	if batchSize < 1 || len(members) <= batchSize {
+		henv, err := sc.PutGroupMembers(token, group, members...)
+		if err != nil {
+			err = fmt.Errorf("SetGroupMembers(): %w", err)
+		}
+		return []client.HTTPEnvelope{henv}, []error{err}, nil
+	}
+
+	current, err := sc.GroupMemberPager(group, token).All(context.Background())
+	if err != nil {
+		return nil, nil, fmt.Errorf("SetGroupMembers(): failed to get current members of group %s: %w", group, err)
+	}
+	wanted := make(map[string]bool, len(members))
+	for _, id := range members {
+		wanted[strings.ToLower(id)] = true
+	}
+	have := make(map[string]bool, len(current))
+	var remove, add []string
+	for _, id := range current {
+		have[strings.ToLower(id)] = true
+		if !wanted[strings.ToLower(id)] {
+			remove = append(remove, id)
+		}
+	}
+	for _, id := range members {
+		if !have[strings.ToLower(id)] {
+			add = append(add, id)
+			have[strings.ToLower(id)] = true
+		}
+	}
+	log.Logger.Debug().Msgf("group %s has %d members, removing %d and adding %d instead of putting %d", group, len(current), len(remove), len(add), len(members))
+
+	var (
+		henvs []client.HTTPEnvelope
+		errs  []error
+	)
+	if len(remove) > 0 {
+		h, e, err := sc.DeleteGroupMembers(token, group, remove...)
+		if err != nil {
+			return henvs, errs, fmt.Errorf("SetGroupMembers(): %w", err)
+		}
+		henvs, errs = append(henvs, h...), append(errs, e...)
+	}
+	if len(add) > 0 {
+		h, e, err := sc.PostGroupMembers(token, group, add...)
+		if err != nil {
+			return henvs, errs, fmt.Errorf("SetGroupMembers(): %w", err)
+		}
+		henvs, errs = append(henvs, h...), append(errs, e...)
+	}
+
+	return henvs, errs, nil
+}
+
 // PatchComponentsNID is a wrapper function around OchamiClient.PatchData that
 // takes a slice of Components and a token. It doesn't read any data fields
 // within each Component except ID (xname) and NID, and for each Component, all
@@ -894,12 +1298,12 @@ func (sc *SMDClient) PatchEthernetInterfaces(eis []EthernetInterface, token stri
 			return henvs, errors, fmt.Errorf("PatchEthernetInterfaces(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, ei := range eis {
+	henvs, errors = client.BulkRequest(eis, sc.BulkConcurrency(http.MethodPatch), func(ei EthernetInterface) (client.HTTPEnvelope, error) {
 		var body client.HTTPBody
 		var err error
 		if ei.ID == "" {
 			if ei.MACAddress != "" {
-				log.Logger.Warn().Msgf("PatchEthernetInterfaces(): ID for ethernet interface is blank, attempting to adapt from MAC address (%s)", ei.MACAddress)
+				log.ClientLogger.Warn().Msgf("PatchEthernetInterfaces(): ID for ethernet interface is blank, attempting to adapt from MAC address (%s)", ei.MACAddress)
 				newID := strings.ToLower(ei.MACAddress)
 				newID = strings.ReplaceAll(newID, ":", "")
 				newID = strings.ReplaceAll(newID, "-", "")
@@ -907,33 +1311,25 @@ func (sc *SMDClient) PatchEthernetInterfaces(eis []EthernetInterface, token stri
 				ei.ID = newID
 			} else {
 				newErr := fmt.Errorf("PatchEthernetInterfaces(): unable to patch ethernet interface with both blank ID and blank MAC address")
-				henvs = append(henvs, client.HTTPEnvelope{})
-				errors = append(errors, newErr)
-				continue
+				return client.HTTPEnvelope{}, newErr
 			}
 		}
 		eiPath, err := url.JoinPath(SMDRelpathEthernetInterfaces, ei.ID)
 		if err != nil {
 			newErr := fmt.Errorf("PatchEthernetInterfaces(): failed to join ethernet interface path (%s) with ethernet interface ID (%s): %w", SMDRelpathEthernetInterfaces, ei.ID, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		if body, err = json.Marshal(ei); err != nil {
 			newErr := fmt.Errorf("PatchEthernetInterfaces(): failed to marshal EthernetInterface: %w", err)
-			errors = append(errors, newErr)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.PatchData(eiPath, "", headers, body)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("PatchEthernetInterfaces(): failed to PATCH ethernet interface(s) to SMD: %w", err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -947,7 +1343,6 @@ func (sc *SMDClient) PatchGroups(groups []Group, token string) ([]client.HTTPEnv
 	var (
 		henvs   []client.HTTPEnvelope
 		headers *client.HTTPHeaders
-		body    client.HTTPBody
 		errors  []error
 	)
 	headers = client.NewHTTPHeaders()
@@ -956,35 +1351,99 @@ func (sc *SMDClient) PatchGroups(groups []Group, token string) ([]client.HTTPEnv
 			return henvs, errors, fmt.Errorf("PatchGroups(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, group := range groups {
+	henvs, errors = client.BulkRequest(groups, sc.BulkConcurrency(http.MethodPatch), func(group Group) (client.HTTPEnvelope, error) {
 		if group.Label == "" {
 			newErr := fmt.Errorf("PatchGroups(): no group label specified to update")
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		groupPath, err := url.JoinPath(SMDRelpathGroups, group.Label)
 		if err != nil {
-			newErr := fmt.Errorf("PatchGroups(): failed to join group path (%s) with group label (%s): %w", SMDRelpathGroups, group.Label)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			newErr := fmt.Errorf("PatchGroups(): failed to join group path (%s) with group label (%s): %w", SMDRelpathGroups, group.Label, err)
+			return client.HTTPEnvelope{}, newErr
 		}
-		if body, err = json.Marshal(group); err != nil {
-			newErr := fmt.Errorf("PatchGroups(): failed to marshal Group: %w")
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+		body, err := json.Marshal(group)
+		if err != nil {
+			newErr := fmt.Errorf("PatchGroups(): failed to marshal Group: %w", err)
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.PatchData(groupPath, "", headers, body)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("PatchGroups(): failed to PATCH group %s in SMD: %w", group.Label, err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
+		}
+		return henv, nil
+	})
+
+	return henvs, errors, nil
+}
+
+// PatchComponentsDocument is a wrapper function around
+// OchamiClient.PatchDocument that sends patch, a patch document of type pt, to
+// the component of each of xnames. token, if not empty, is sent as the
+// authorization bearer. One client.HTTPEnvelope and error are returned per
+// xname, as well as a separate error if an error in the function itself
+// occurred.
+func (sc *SMDClient) PatchComponentsDocument(xnames []string, pt client.PatchType, patch client.HTTPBody, token string) ([]client.HTTPEnvelope, []error, error) {
+	var (
+		henvs   []client.HTTPEnvelope
+		headers *client.HTTPHeaders
+		errors  []error
+	)
+	if err := client.ValidatePatch(pt, patch); err != nil {
+		return henvs, errors, fmt.Errorf("PatchComponentsDocument(): invalid %s: %w", pt, err)
+	}
+	headers = client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return henvs, errors, fmt.Errorf("PatchComponentsDocument(): error setting token in HTTP headers: %w", err)
 		}
-		errors = append(errors, nil)
 	}
+	henvs, errors = client.BulkRequest(xnames, sc.BulkConcurrency(http.MethodPatch), func(xname string) (client.HTTPEnvelope, error) {
+		compPath, err := url.JoinPath(SMDRelpathComponents, xname)
+		if err != nil {
+			return client.HTTPEnvelope{}, fmt.Errorf("PatchComponentsDocument(): failed to join component path (%s) with xname (%s): %w", SMDRelpathComponents, xname, err)
+		}
+		henv, err := sc.PatchDocument(compPath, "", headers, pt, patch)
+		if err != nil {
+			return henv, fmt.Errorf("PatchComponentsDocument(): failed to PATCH component %s in SMD: %w", xname, err)
+		}
+		return henv, nil
+	})
+
+	return henvs, errors, nil
+}
+
+// PatchGroupsDocument is a wrapper function around OchamiClient.PatchDocument
+// that sends patch, a patch document of type pt, to the group of each of
+// labels. token, if not empty, is sent as the authorization bearer. One
+// client.HTTPEnvelope and error are returned per label, as well as a separate
+// error if an error in the function itself occurred.
+func (sc *SMDClient) PatchGroupsDocument(labels []string, pt client.PatchType, patch client.HTTPBody, token string) ([]client.HTTPEnvelope, []error, error) {
+	var (
+		henvs   []client.HTTPEnvelope
+		headers *client.HTTPHeaders
+		errors  []error
+	)
+	if err := client.ValidatePatch(pt, patch); err != nil {
+		return henvs, errors, fmt.Errorf("PatchGroupsDocument(): invalid %s: %w", pt, err)
+	}
+	headers = client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return henvs, errors, fmt.Errorf("PatchGroupsDocument(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	henvs, errors = client.BulkRequest(labels, sc.BulkConcurrency(http.MethodPatch), func(label string) (client.HTTPEnvelope, error) {
+		groupPath, err := url.JoinPath(SMDRelpathGroups, label)
+		if err != nil {
+			return client.HTTPEnvelope{}, fmt.Errorf("PatchGroupsDocument(): failed to join group path (%s) with group label (%s): %w", SMDRelpathGroups, label, err)
+		}
+		henv, err := sc.PatchDocument(groupPath, "", headers, pt, patch)
+		if err != nil {
+			return henv, fmt.Errorf("PatchGroupsDocument(): failed to PATCH group %s in SMD: %w", label, err)
+		}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -1009,23 +1468,19 @@ func (sc *SMDClient) DeleteComponents(token string, xnames ...string) ([]client.
 			return henvs, errors, fmt.Errorf("DeleteComponents(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, xname := range xnames {
+	henvs, errors = client.BulkRequest(xnames, sc.BulkConcurrency(http.MethodDelete), func(xname string) (client.HTTPEnvelope, error) {
 		xnamePath, err := url.JoinPath(SMDRelpathComponents, xname)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteComponents(): failed join component path (%s) with xname (%s): %w", SMDRelpathComponents, xname, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.DeleteData(xnamePath, "", headers, nil)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteComponents(): failed to DELETE component %s in SMD: %w", xname, err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -1055,6 +1510,26 @@ func (sc *SMDClient) DeleteComponentsAll(token string) (client.HTTPEnvelope, err
 	return henv, err
 }
 
+// DeleteComponentsMatching composes GetComponentIDsMatching and
+// DeleteComponents, deleting every component that matches filter (see
+// GetComponentIDsMatching for its format). The IDs that matched are returned
+// along with the client.HTTPEnvelope and error slices from DeleteComponents,
+// whose indexes correspond to those of the IDs. If an error occurs looking up
+// the matching components, it is returned as the last value and nothing is
+// deleted.
+func (sc *SMDClient) DeleteComponentsMatching(filter, token string) ([]string, []client.HTTPEnvelope, []error, error) {
+	ids, err := sc.GetComponentIDsMatching(filter, token)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("DeleteComponentsMatching(): %w", err)
+	}
+	henvs, errs, err := sc.DeleteComponents(token, ids...)
+	if err != nil {
+		err = fmt.Errorf("DeleteComponentsMatching(): %w", err)
+	}
+
+	return ids, henvs, errs, err
+}
+
 // DeleteRedfishEndpoints takes a token and xnames and iteratively calls
 // OchamiClient.DeleteData for each xname. This is necessary because SMD only
 // allows deleting one xname at a time. A slice of client.HTTPEnvelopes is
@@ -1075,23 +1550,19 @@ func (sc *SMDClient) DeleteRedfishEndpoints(token string, xnames ...string) ([]c
 			return henvs, errors, fmt.Errorf("DeleteRedfishEndpoints(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, xname := range xnames {
+	henvs, errors = client.BulkRequest(xnames, sc.BulkConcurrency(http.MethodDelete), func(xname string) (client.HTTPEnvelope, error) {
 		xnamePath, err := url.JoinPath(SMDRelpathRedfishEndpoints, xname)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteRedfishEndpoints(): failed join redfish endpoint path (%s) with xname (%s): %w", SMDRelpathRedfishEndpoints, xname, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.DeleteData(xnamePath, "", headers, nil)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteRedfishEndpoints(): failed to DELETE redfish endpoint %s in SMD: %w", xname, err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -1143,23 +1614,19 @@ func (sc *SMDClient) DeleteEthernetInterfaces(token string, eIds ...string) ([]c
 			return henvs, errors, fmt.Errorf("DeleteEthernetInterfaces(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, eId := range eIds {
+	henvs, errors = client.BulkRequest(eIds, sc.BulkConcurrency(http.MethodDelete), func(eId string) (client.HTTPEnvelope, error) {
 		eIdPath, err := url.JoinPath(SMDRelpathEthernetInterfaces, eId)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteEthernetInterfaces(): failed join ethernet interface path (%s) with ethernet interface %s: %w", SMDRelpathEthernetInterfaces, eId, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.DeleteData(eIdPath, "", headers, nil)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteEthernetInterfaces(): failed to DELETE ethernet interface %s in SMD: %w", eId, err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -1190,6 +1657,22 @@ func (sc *SMDClient) DeleteEthernetInterfacesAll(token string) (client.HTTPEnvel
 	return henv, err
 }
 
+// DeleteEthernetInterfacesMatching is like DeleteComponentsMatching, except
+// that it operates on ethernet interfaces. See
+// GetEthernetInterfaceIDsMatching for the format of filter.
+func (sc *SMDClient) DeleteEthernetInterfacesMatching(filter, token string) ([]string, []client.HTTPEnvelope, []error, error) {
+	ids, err := sc.GetEthernetInterfaceIDsMatching(filter, token)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("DeleteEthernetInterfacesMatching(): %w", err)
+	}
+	henvs, errs, err := sc.DeleteEthernetInterfaces(token, ids...)
+	if err != nil {
+		err = fmt.Errorf("DeleteEthernetInterfacesMatching(): %w", err)
+	}
+
+	return ids, henvs, errs, err
+}
+
 // DeleteComponentEndpoints takes a token and one or more xnames and
 // iteratively calls OchamiClient.DeleteData for each xname. This is necessary
 // because SMD only allows deleting one component endpoint at a time. A slice
@@ -1210,23 +1693,19 @@ func (sc *SMDClient) DeleteComponentEndpoints(token string, xnames ...string) ([
 			return henvs, errors, fmt.Errorf("DeleteComponentEndpoints(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, xname := range xnames {
+	henvs, errors = client.BulkRequest(xnames, sc.BulkConcurrency(http.MethodDelete), func(xname string) (client.HTTPEnvelope, error) {
 		finalEP, err := url.JoinPath(SMDRelpathComponentEndpoints, xname)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteComponentEndpoints(): failed join component endpoint path (%s) with xname %s: %w", SMDRelpathComponentEndpoints, xname, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.DeleteData(finalEP, "", headers, nil)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteComponentEndpoints(): failed to DELETE component endpoint %s in SMD: %w", xname, err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -1277,23 +1756,19 @@ func (sc *SMDClient) DeleteGroups(token string, groupLabels ...string) ([]client
 			return henvs, errors, fmt.Errorf("DeleteGroups(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, label := range groupLabels {
+	henvs, errors = client.BulkRequest(groupLabels, sc.BulkConcurrency(http.MethodDelete), func(label string) (client.HTTPEnvelope, error) {
 		labelPath, err := url.JoinPath(SMDRelpathGroups, label)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteGroups(): failed join group path (%s) with group label (%s): %w", SMDRelpathGroups, label, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.DeleteData(labelPath, "", headers, nil)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteGroups(): failed to DELETE group %s in SMD: %w", label, err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
@@ -1318,23 +1793,237 @@ func (sc *SMDClient) DeleteGroupMembers(token, group string, members ...string)
 			return henvs, errors, fmt.Errorf("DeleteGroupMembers(): error setting token in HTTP headers: %w", err)
 		}
 	}
-	for _, member := range members {
+	henvs, errors = client.BulkRequest(members, sc.BulkConcurrency(http.MethodDelete), func(member string) (client.HTTPEnvelope, error) {
 		memberPath, err := url.JoinPath(SMDRelpathGroups, group, "members", member)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteGroupMembers(): failed join group path (%s) with group %s and member %s: %w", SMDRelpathGroups, group, member, err)
-			henvs = append(henvs, client.HTTPEnvelope{})
-			errors = append(errors, newErr)
-			continue
+			return client.HTTPEnvelope{}, newErr
 		}
 		henv, err := sc.DeleteData(memberPath, "", headers, nil)
-		henvs = append(henvs, henv)
 		if err != nil {
 			newErr := fmt.Errorf("DeleteGroupMembers(): failed to DELETE member %s from group %s in SMD: %w", member, group, err)
-			errors = append(errors, newErr)
-			continue
+			return henv, newErr
 		}
-		errors = append(errors, nil)
-	}
+		return henv, nil
+	})
 
 	return henvs, errors, nil
 }
+
+// GetGroup is a wrapper function around OchamiClient.GetData that fetches the
+// group labeled label from SMD and returns it.
+func (sc *SMDClient) GetGroup(label, token string) (Group, error) {
+	var group Group
+	if label == "" {
+		return group, fmt.Errorf("GetGroup(): group label cannot be empty")
+	}
+	groupPath, err := url.JoinPath(SMDRelpathGroups, label)
+	if err != nil {
+		return group, fmt.Errorf("GetGroup(): failed to join group path (%s) with group label (%s): %w", SMDRelpathGroups, label, err)
+	}
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return group, fmt.Errorf("GetGroup(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	henv, err := sc.GetData(groupPath, "", headers)
+	if err != nil {
+		return group, fmt.Errorf("GetGroup(): error getting group %s: %w", label, err)
+	}
+	if err := json.Unmarshal(henv.Body, &group); err != nil {
+		return group, fmt.Errorf("GetGroup(): failed to unmarshal group %s: %w", label, err)
+	}
+
+	return group, nil
+}
+
+// PatchGroupTags adds the tags in add to, and removes the tags in remove from,
+// the tags of the group labeled label with a read-modify-write: the group is
+// fetched, its tags are modified, and a PATCH containing only the tags is sent
+// so that the rest of the group is left untouched. If SMD returns an ETag for
+// the group, it is sent in an If-Match header so that the PATCH fails with an
+// error wrapping client.PreconditionFailedError if the group was modified in
+// the meantime. Tags in both add and remove are removed. The resulting tags
+// are returned along with whether they changed; if they did not, no PATCH is
+// sent.
+func (sc *SMDClient) PatchGroupTags(label string, add, remove []string, token string) ([]string, bool, error) {
+	if label == "" {
+		return nil, false, fmt.Errorf("PatchGroupTags(): group label cannot be empty")
+	}
+	groupPath, err := url.JoinPath(SMDRelpathGroups, label)
+	if err != nil {
+		return nil, false, fmt.Errorf("PatchGroupTags(): failed to join group path (%s) with group label (%s): %w", SMDRelpathGroups, label, err)
+	}
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return nil, false, fmt.Errorf("PatchGroupTags(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	henv, err := sc.GetData(groupPath, "", headers)
+	if err != nil {
+		return nil, false, fmt.Errorf("PatchGroupTags(): error getting group %s: %w", label, err)
+	}
+	var group Group
+	if err := json.Unmarshal(henv.Body, &group); err != nil {
+		return nil, false, fmt.Errorf("PatchGroupTags(): failed to unmarshal group %s: %w", label, err)
+	}
+
+	tags := []string{}
+	for _, t := range group.Tags {
+		if !slices.Contains(remove, t) && !slices.Contains(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	for _, t := range add {
+		if !slices.Contains(remove, t) && !slices.Contains(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	if slices.Equal(tags, group.Tags) {
+		return tags, false, nil
+	}
+
+	body, err := json.Marshal(struct {
+		Tags []string `json:"tags"`
+	}{Tags: tags})
+	if err != nil {
+		return tags, false, fmt.Errorf("PatchGroupTags(): failed to marshal tags: %w", err)
+	}
+	if henv.ETag != "" {
+		if err := headers.SetIfMatch(henv.ETag); err != nil {
+			return tags, false, fmt.Errorf("PatchGroupTags(): error setting If-Match in HTTP headers: %w", err)
+		}
+	}
+	if _, err := sc.PatchData(groupPath, "", headers, body); err != nil {
+		return tags, false, fmt.Errorf("PatchGroupTags(): failed to PATCH tags of group %s in SMD: %w", label, err)
+	}
+
+	return tags, true, nil
+}
+
+// FindExclusiveConflicts returns, for each of members that cannot be added to
+// the group labeled group because it is already a member of another group
+// sharing the same exclusive group, the label of that other group. SMD rejects
+// such additions, so callers can use this to explain the failure beforehand
+// and point to MoveGroupMember. If group has no exclusive group, the returned
+// map is empty.
+func (sc *SMDClient) FindExclusiveConflicts(group string, members []string, token string) (map[string]string, error) {
+	conflicts := make(map[string]string)
+	target, err := sc.GetGroup(group, token)
+	if err != nil {
+		return conflicts, fmt.Errorf("FindExclusiveConflicts(): %w", err)
+	}
+	if target.ExclusiveGroup == "" {
+		return conflicts, nil
+	}
+	henv, err := sc.GetGroups("", token)
+	if err != nil {
+		return conflicts, fmt.Errorf("FindExclusiveConflicts(): %w", err)
+	}
+	var groups []Group
+	if err := json.Unmarshal(henv.Body, &groups); err != nil {
+		return conflicts, fmt.Errorf("FindExclusiveConflicts(): failed to unmarshal groups: %w", err)
+	}
+	for _, member := range members {
+		for _, g := range groups {
+			if g.Label == target.Label || g.ExclusiveGroup != target.ExclusiveGroup {
+				continue
+			}
+			if slices.Contains(g.Members.IDs, member) {
+				conflicts[member] = g.Label
+				break
+			}
+		}
+	}
+
+	return conflicts, nil
+}
+
+// MoveGroupMember moves member from the group labeled from to the group
+// labeled to. If both groups share an exclusive group, SMD refuses to add the
+// member to the destination while it is still in the source, so the member is
+// removed from the source first and added back to it if adding it to the
+// destination fails. Otherwise, the member is added to the destination first
+// and removed from it again if removing it from the source fails. Either way,
+// the member ends up in exactly one of the groups unless the rollback itself
+// fails, in which case the returned error says so.
+func (sc *SMDClient) MoveGroupMember(from, to, member, token string) error {
+	if from == "" || to == "" || member == "" {
+		return fmt.Errorf("MoveGroupMember(): source group, destination group, and member must all be specified")
+	}
+	if from == to {
+		return fmt.Errorf("MoveGroupMember(): source and destination group are both %s", from)
+	}
+	fromGroup, err := sc.GetGroup(from, token)
+	if err != nil {
+		return fmt.Errorf("MoveGroupMember(): %w", err)
+	}
+	toGroup, err := sc.GetGroup(to, token)
+	if err != nil {
+		return fmt.Errorf("MoveGroupMember(): %w", err)
+	}
+	if !slices.Contains(fromGroup.Members.IDs, member) {
+		return fmt.Errorf("MoveGroupMember(): %s is not a member of group %s", member, from)
+	}
+	if slices.Contains(toGroup.Members.IDs, member) {
+		return fmt.Errorf("MoveGroupMember(): %s is already a member of group %s", member, to)
+	}
+
+	add := func(group string) error {
+		_, errs, err := sc.PostGroupMembers(token, group, member)
+		if err != nil {
+			return err
+		}
+		return errs[0]
+	}
+	del := func(group string) error {
+		_, errs, err := sc.DeleteGroupMembers(token, group, member)
+		if err != nil {
+			return err
+		}
+		return errs[0]
+	}
+
+	if fromGroup.ExclusiveGroup != "" && fromGroup.ExclusiveGroup == toGroup.ExclusiveGroup {
+		if err := del(from); err != nil {
+			return fmt.Errorf("MoveGroupMember(): failed to remove %s from group %s: %w", member, from, err)
+		}
+		if err := add(to); err != nil {
+			if rbErr := add(from); rbErr != nil {
+				return fmt.Errorf("MoveGroupMember(): failed to add %s to group %s (%w) and failed to add it back to group %s: %w", member, to, err, from, rbErr)
+			}
+			return fmt.Errorf("MoveGroupMember(): failed to add %s to group %s, added it back to group %s: %w", member, to, from, err)
+		}
+	} else {
+		if err := add(to); err != nil {
+			return fmt.Errorf("MoveGroupMember(): failed to add %s to group %s: %w", member, to, err)
+		}
+		if err := del(from); err != nil {
+			if rbErr := del(to); rbErr != nil {
+				return fmt.Errorf("MoveGroupMember(): failed to remove %s from group %s (%w) and failed to remove it again from group %s: %w", member, from, err, to, rbErr)
+			}
+			return fmt.Errorf("MoveGroupMember(): failed to remove %s from group %s, removed it again from group %s: %w", member, from, to, err)
+		}
+	}
+
+	return nil
+}
+
+// checkFilter makes sure filter is a non-empty, parseable query string so that
+// it cannot accidentally select every resource of a type.
+func checkFilter(filter string) error {
+	if strings.TrimSpace(filter) == "" {
+		return fmt.Errorf("filter cannot be empty")
+	}
+	vals, err := url.ParseQuery(filter)
+	if err != nil {
+		return fmt.Errorf("invalid filter %q: %w", filter, err)
+	}
+	if len(vals) == 0 {
+		return fmt.Errorf("filter %q contains no keys", filter)
+	}
+
+	return nil
+}
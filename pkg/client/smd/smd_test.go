@@ -0,0 +1,98 @@
+package smd
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// roundTrip unmarshals doc into a value of the type of v, marshals it back, and
+// fails t if the result differs from doc as JSON.
+func roundTrip[T any](t *testing.T, doc string) {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatalf("failed to unmarshal %T: %v", v, err)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal %T: %v", v, err)
+	}
+	var want, got any
+	if err := json.Unmarshal([]byte(doc), &want); err != nil {
+		t.Fatalf("invalid test document: %v", err)
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("failed to unmarshal marshalled %T: %v", v, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%T did not round-trip:\n got: %s\nwant: %s", v, out, doc)
+	}
+}
+
+func TestComponentRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{
+			name: "full",
+			doc: `{
+				"ID": "x1000c1s7b0n0",
+				"Type": "Node",
+				"Subtype": "Compute",
+				"Role": "Compute",
+				"SubRole": "Worker",
+				"NetType": "Sling",
+				"Arch": "X86",
+				"Class": "Mountain",
+				"State": "Ready",
+				"Flag": "OK",
+				"Enabled": false,
+				"SoftwareStatus": "AdminStatus",
+				"NID": 1,
+				"ReservationDisabled": true,
+				"Locked": true
+			}`,
+		},
+		{
+			name: "explicit false",
+			doc: `{
+				"ID": "x1000c1s7b0n1",
+				"Type": "Node",
+				"State": "On",
+				"Flag": "OK",
+				"Enabled": true,
+				"NID": 2,
+				"ReservationDisabled": false,
+				"Locked": false
+			}`,
+		},
+		{
+			name: "minimal",
+			doc:  `{"ID": "x1000c1s7b0", "Type": "NodeBMC"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roundTrip[Component](t, tt.doc)
+		})
+	}
+}
+
+func TestComponentSliceRoundTrip(t *testing.T) {
+	roundTrip[ComponentSlice](t, `{"Components": [
+		{"ID": "x1000c1s7b0n0", "Type": "Node", "Enabled": false, "Locked": true},
+		{"ID": "x1000c1s7b0n1", "Type": "Node", "NID": 2, "ReservationDisabled": false}
+	]}`)
+}
+
+func TestComponentEnabledUnset(t *testing.T) {
+	var c Component
+	if err := json.Unmarshal([]byte(`{"ID": "x1000c1s7b0n0", "Type": "Node"}`), &c); err != nil {
+		t.Fatalf("failed to unmarshal Component: %v", err)
+	}
+	if c.Enabled != nil || c.Locked != nil || c.ReservationDisabled != nil {
+		t.Errorf("unset booleans were set: %+v", c)
+	}
+}
@@ -0,0 +1,133 @@
+package smd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+)
+
+// Component states known to SMD.
+const (
+	StateUnknown   = "Unknown"
+	StateEmpty     = "Empty"
+	StatePopulated = "Populated"
+	StateOff       = "Off"
+	StateOn        = "On"
+	StateStandby   = "Standby"
+	StateHalt      = "Halt"
+	StateReady     = "Ready"
+)
+
+// States lists the component states known to SMD, in the order a node
+// normally goes through them.
+var States = []string{StateUnknown, StateEmpty, StatePopulated, StateOff, StateOn, StateStandby, StateHalt, StateReady}
+
+// stateTransitions maps each state to the states a component can be moved to
+// it from without forcing the change, following the checks SMD makes when
+// updating a component's state. A component can always be moved to Unknown.
+var stateTransitions = map[string][]string{
+	StateEmpty:     {StateUnknown, StateEmpty, StatePopulated, StateOff},
+	StatePopulated: {StateUnknown, StateEmpty, StatePopulated, StateOff},
+	StateOff:       {StateUnknown, StatePopulated, StateOff, StateOn, StateStandby, StateHalt, StateReady},
+	StateOn:        {StateUnknown, StatePopulated, StateOff, StateOn, StateStandby, StateHalt, StateReady},
+	StateStandby:   {StateOn, StateStandby, StateReady},
+	StateHalt:      {StateOn, StateHalt, StateReady},
+	StateReady:     {StateOn, StateStandby, StateHalt, StateReady},
+}
+
+// canonicalState returns the known state equal to s, ignoring case, or s
+// itself if it is not a known state.
+func canonicalState(s string) string {
+	for _, st := range States {
+		if strings.EqualFold(s, st) {
+			return st
+		}
+	}
+	return s
+}
+
+// ValidStateTransition reports whether a component can be moved from state
+// from to state to without forcing the change. States are compared ignoring
+// case. Transitions from or to states that are not known are not checked and
+// are reported as valid, as are transitions from an empty (unset) state.
+func ValidStateTransition(from, to string) bool {
+	from, to = canonicalState(from), canonicalState(to)
+	if from == "" || to == StateUnknown {
+		return true
+	}
+	allowed, known := stateTransitions[to]
+	if !known || !slices.Contains(States, from) {
+		return true
+	}
+	return slices.Contains(allowed, from)
+}
+
+// ValidNextStates returns the known states a component in state from can be
+// moved to without forcing the change.
+func ValidNextStates(from string) []string {
+	var next []string
+	for _, to := range States {
+		if ValidStateTransition(from, to) {
+			next = append(next, to)
+		}
+	}
+	return next
+}
+
+// CheckStateTransition returns an error listing the valid next states if a
+// component cannot be moved from state from to state to without forcing the
+// change (see ValidStateTransition).
+func CheckStateTransition(from, to string) error {
+	if ValidStateTransition(from, to) {
+		return nil
+	}
+	return fmt.Errorf("invalid state transition %s -> %s (valid from %s: %s)",
+		canonicalState(from), canonicalState(to), canonicalState(from), strings.Join(ValidNextStates(from), ", "))
+}
+
+// BulkStateData is the payload of SMD's BulkStateData endpoint, which sets the
+// state and flag of several components at once. If Force is true, SMD does not
+// check whether the components can be moved to State.
+type BulkStateData struct {
+	ComponentIDs []string `json:"ComponentIDs"`
+	State        string   `json:"State"`
+	Flag         string   `json:"Flag,omitempty"`
+	Force        bool     `json:"Force,omitempty"`
+}
+
+// PatchComponentsState is a wrapper function around OchamiClient.PatchData
+// that sets the state (and flag, if not empty) of the components identified
+// by xnames with a single request to SMD's BulkStateData endpoint. If force is
+// true, SMD is told to skip its state transition checks. token, if not empty,
+// is sent as the authorization bearer.
+func (sc *SMDClient) PatchComponentsState(xnames []string, state, flag string, force bool, token string) (client.HTTPEnvelope, error) {
+	headers := client.NewHTTPHeaders()
+	if token != "" {
+		if err := headers.SetAuthorization(token); err != nil {
+			return client.HTTPEnvelope{}, fmt.Errorf("PatchComponentsState(): error setting token in HTTP headers: %w", err)
+		}
+	}
+	statePath, err := url.JoinPath(SMDRelpathComponents, SMDSubpathBulkStateData)
+	if err != nil {
+		return client.HTTPEnvelope{}, fmt.Errorf("PatchComponentsState(): failed to join component path (%s) with BulkStateData path (%s): %w", SMDRelpathComponents, SMDSubpathBulkStateData, err)
+	}
+	body, err := json.Marshal(BulkStateData{
+		ComponentIDs: xnames,
+		State:        state,
+		Flag:         flag,
+		Force:        force,
+	})
+	if err != nil {
+		return client.HTTPEnvelope{}, fmt.Errorf("PatchComponentsState(): failed to marshal state data: %w", err)
+	}
+	henv, err := sc.PatchData(statePath, "", headers, body)
+	if err != nil {
+		err = fmt.Errorf("PatchComponentsState(): failed to PATCH component state in SMD: %w", err)
+	}
+
+	return henv, err
+}
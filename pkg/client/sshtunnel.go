@@ -0,0 +1,296 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshDialTimeout is how long connecting and authenticating to a jump host may
+// take.
+const sshDialTimeout = 30 * time.Second
+
+// sshKeepaliveTimeout is how long a jump host may take to respond to a
+// keepalive request before its connection is considered lost.
+const sshKeepaliveTimeout = 10 * time.Second
+
+// sshTunnel is a connection to a jump host that connections to services are
+// tunneled through. It connects on first use and reconnects if the connection
+// is lost.
+type sshTunnel struct {
+	dest string // as configured, for messages
+	user string
+	addr string // host:port
+
+	mu     sync.Mutex
+	client *ssh.Client
+	agent  io.Closer
+}
+
+// sshTunnels holds the tunnels created by getSSHTunnel, keyed by destination,
+// so that all clients of a cluster share one SSH connection.
+var sshTunnels = struct {
+	sync.Mutex
+	m map[string]*sshTunnel
+}{m: make(map[string]*sshTunnel)}
+
+// UseSSHTunnel tunnels the OchamiClient's connections through the jump host
+// dest, an SSH destination ([user@]host[:port]), for clusters whose services
+// cannot be reached directly. The jump host connects to the services on the
+// client's behalf, so it also resolves their hostnames. The user defaults to
+// the local user and the port to 22. If dest is empty, connections are made
+// directly. Clients tunneled through the same jump host share one SSH
+// connection.
+//
+// The client authenticates with the keys of the local SSH agent (see
+// SSH_AUTH_SOCK) and verifies the jump host's key against the user's and the
+// system's known_hosts files, so the jump host must have been connected to
+// with ssh before.
+func (oc *OchamiClient) UseSSHTunnel(dest string) error {
+	if oc == nil {
+		return fmt.Errorf("client is nil")
+	}
+	if dest != "" {
+		if err := ValidateSSHTunnel(dest); err != nil {
+			return err
+		}
+	}
+	tc := oc.transportConfig
+	tc.SSHTunnel = dest
+
+	return oc.useTransport(tc)
+}
+
+// ValidateSSHTunnel returns an error if dest is not a valid jump host for
+// OchamiClient.UseSSHTunnel.
+func ValidateSSHTunnel(dest string) error {
+	_, _, err := parseSSHDestination(dest)
+	return err
+}
+
+// parseSSHDestination splits the SSH destination dest, [user@]host[:port],
+// into the user, which defaults to the local user, and the address to connect
+// to, whose port defaults to 22.
+func parseSSHDestination(dest string) (string, string, error) {
+	userName, host, found := strings.Cut(dest, "@")
+	if !found {
+		host = userName
+		userName = ""
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("invalid SSH destination %q: no host", dest)
+	}
+	if strings.ContainsAny(host, "/ ") {
+		return "", "", fmt.Errorf("invalid SSH destination %q: must be [user@]host[:port]", dest)
+	}
+	addr := host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		addr = net.JoinHostPort(strings.Trim(host, "[]"), "22")
+	}
+	if userName == "" {
+		if u, err := user.Current(); err == nil {
+			userName = u.Username
+		} else if userName = os.Getenv("USER"); userName == "" {
+			return "", "", fmt.Errorf("invalid SSH destination %q: no user given and the local user is unknown", dest)
+		}
+	}
+	return userName, addr, nil
+}
+
+// getSSHTunnel returns the tunnel through the jump host dest, creating it on
+// first use. It does not connect yet.
+func getSSHTunnel(dest string) (*sshTunnel, error) {
+	sshTunnels.Lock()
+	defer sshTunnels.Unlock()
+	if t, ok := sshTunnels.m[dest]; ok {
+		return t, nil
+	}
+	userName, addr, err := parseSSHDestination(dest)
+	if err != nil {
+		return nil, err
+	}
+	t := &sshTunnel{dest: dest, user: userName, addr: addr}
+	sshTunnels.m[dest] = t
+	return t, nil
+}
+
+// DialContext connects to addr through the jump host of t, connecting to the
+// jump host first if needed. It can be used as the DialContext function of a
+// transport. network is ignored, since the jump host decides how to reach
+// addr. If connecting fails because the connection to the jump host was lost,
+// it reconnects to the jump host and tries once more. Other errors, e.g. the
+// jump host failing to reach addr, are returned as they are, leaving the
+// connection to the jump host and the other connections through it open.
+func (t *sshTunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	c, err := t.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := c.DialContext(ctx, "tcp", addr)
+	if err == nil {
+		return conn, nil
+	}
+	// Other connections share the connection to the jump host, so only
+	// reconnect if it was lost (e.g. it timed out while idle), not if the
+	// jump host could not reach addr
+	var openErr *ssh.OpenChannelError
+	if ctx.Err() != nil || errors.As(err, &openErr) || t.alive(c) {
+		return nil, fmt.Errorf("failed to connect to %s through jump host %s: %w", addr, t.dest, err)
+	}
+	log.ClientLogger.Debug().Err(err).Msgf("lost connection to jump host %s, reconnecting to it", t.dest)
+	t.reset(c)
+	if c, err = t.connect(ctx); err != nil {
+		return nil, err
+	}
+	conn, err = c.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s through jump host %s: %w", addr, t.dest, err)
+	}
+	return conn, nil
+}
+
+// connect returns the SSH client of t, connecting to the jump host if it is
+// not connected.
+func (t *sshTunnel) connect(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, fmt.Errorf("failed to connect to jump host %s: SSH_AUTH_SOCK is not set, an SSH agent is required", t.dest)
+	}
+	agentConn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SSH agent: %w", err)
+	}
+	hostKeyCallback, err := sshHostKeyCallback()
+	if err != nil {
+		agentConn.Close()
+		return nil, fmt.Errorf("failed to verify jump host %s: %w", t.dest, err)
+	}
+	cfg := &ssh.ClientConfig{
+		User:            t.user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(agentConn).Signers)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sshDialTimeout,
+	}
+
+	log.ClientLogger.Debug().Msgf("connecting to jump host %s@%s", t.user, t.addr)
+	dialer := &net.Dialer{Timeout: sshDialTimeout, KeepAlive: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		agentConn.Close()
+		return nil, fmt.Errorf("failed to connect to jump host %s: %w", t.dest, err)
+	}
+	// Bound the handshake, which ssh.NewClientConn does not time out
+	_ = conn.SetDeadline(time.Now().Add(sshDialTimeout))
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, t.addr, cfg)
+	if err != nil {
+		conn.Close()
+		agentConn.Close()
+		return nil, fmt.Errorf("failed to connect to jump host %s: %w", t.dest, err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	t.client = ssh.NewClient(sshConn, chans, reqs)
+	t.agent = agentConn
+	return t.client, nil
+}
+
+// alive reports whether the connection c to the jump host still works, i.e.
+// whether the jump host responds to a keepalive request within
+// sshKeepaliveTimeout.
+func (t *sshTunnel) alive(c *ssh.Client) bool {
+	done := make(chan error, 1)
+	go func() {
+		// The reply does not matter, only that there is one
+		_, _, err := c.SendRequest("keepalive@openssh.com", true, nil)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err == nil
+	case <-time.After(sshKeepaliveTimeout):
+		return false
+	}
+}
+
+// reset closes the connection of t to the jump host, if it is still c, so that
+// the next connection reconnects.
+func (t *sshTunnel) reset(c *ssh.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client != c {
+		return
+	}
+	t.close()
+}
+
+// close closes the connection of t to the jump host and to the SSH agent.
+// t.mu must be held.
+func (t *sshTunnel) close() {
+	if t.client != nil {
+		t.client.Close()
+		t.client = nil
+	}
+	if t.agent != nil {
+		t.agent.Close()
+		t.agent = nil
+	}
+}
+
+// CloseSSHTunnels closes the connections to all jump hosts (see
+// OchamiClient.UseSSHTunnel).
+func CloseSSHTunnels() {
+	sshTunnels.Lock()
+	defer sshTunnels.Unlock()
+	for _, t := range sshTunnels.m {
+		t.mu.Lock()
+		t.close()
+		t.mu.Unlock()
+	}
+}
+
+// sshHostKeyCallback returns a callback that verifies host keys against the
+// user's (~/.ssh/known_hosts) and the system's (/etc/ssh/ssh_known_hosts)
+// known_hosts files, whichever exist. An error is returned if neither does.
+func sshHostKeyCallback() (ssh.HostKeyCallback, error) {
+	var files []string
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, ".ssh", "known_hosts"))
+	}
+	files = append(files, "/etc/ssh/ssh_known_hosts")
+
+	var existing []string
+	for _, f := range files {
+		if _, err := os.Stat(f); err == nil {
+			existing = append(existing, f)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if len(existing) == 0 {
+		return nil, fmt.Errorf("no known_hosts file found (looked for %s), connect to the jump host with ssh first", strings.Join(files, " and "))
+	}
+	cb, err := knownhosts.New(existing...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known_hosts: %w", err)
+	}
+	return cb, nil
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	oio "github.com/OpenCHAMI/ochami/internal/io"
+	"github.com/OpenCHAMI/ochami/internal/log"
+)
+
+// ReadTemplatePayload renders tmpl, a text/template producing a single payload
+// item in format (e.g. one component), once for each row of the CSV file at
+// varFile and unmarshals the items, as a JSON array, into v. If varFile is
+// "-", it is read from standard input.
+//
+// The first row of the CSV file is a header naming its columns. When rendering
+// a row, the template's data is a map of column names to the values in that
+// row, so that a column named xname is used as {{.xname}} (or {{index .
+// "col-name"}} if the name is not a valid identifier). Referring to a column
+// that does not exist is an error. Rendered items are not passed through
+// payload transforms (see ReadPayload).
+func ReadTemplatePayload(tmpl, varFile, format string, v any) error {
+	t, err := template.New("template").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	var data []byte
+	if varFile == "-" {
+		log.ClientLogger.Debug().Msg("variable file was -, reading from stdin")
+		data, err = oio.ReadStdin()
+	} else {
+		data, err = os.ReadFile(varFile)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read variable file: %w", err)
+	}
+	r := csv.NewReader(bytes.NewReader(data))
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err == io.EOF {
+		return fmt.Errorf("variable file %s is empty", varFile)
+	} else if err != nil {
+		return fmt.Errorf("unable to read header of variable file: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	items := []json.RawMessage{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("unable to read variable file: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+		vars := make(map[string]string, len(header))
+		for i, name := range header {
+			vars[name] = record[i]
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, vars); err != nil {
+			return fmt.Errorf("failed to render template for line %d of %s: %w", line, varFile, err)
+		}
+		log.ClientLogger.Debug().Msgf("template rendered for line %d: %q", line, buf.Bytes())
+		item, err := BytesToHTTPBody(buf.Bytes(), format)
+		if err != nil {
+			return fmt.Errorf("invalid payload rendered for line %d of %s: %w", line, varFile, err)
+		}
+		items = append(items, json.RawMessage(item))
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("variable file %s has no rows after its header", varFile)
+	}
+
+	body, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rendered payload items: %w", err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("unable to unmarshal rendered payload into value: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultTenantHeader is the header that carries the tenant of requests (see
+// OchamiClient.Tenant) unless OchamiClient.TenantHeader is set to another one.
+const DefaultTenantHeader = "X-Tenant-ID"
+
+// ValidateTenant returns an error if tenant is not a valid value of
+// OchamiClient.Tenant or header is not a valid value of
+// OchamiClient.TenantHeader.
+func ValidateTenant(tenant, header string) error {
+	if strings.ContainsAny(tenant, "\r\n") || strings.TrimSpace(tenant) != tenant {
+		return fmt.Errorf("invalid tenant %q: must not contain line breaks or surrounding whitespace", tenant)
+	}
+	if strings.ContainsAny(header, " \t\r\n:") {
+		return fmt.Errorf("invalid tenant header %q: must be a header name", header)
+	}
+	return nil
+}
+
+// tenantHeader returns the header that oc sends its tenant in.
+func (oc *OchamiClient) tenantHeader() string {
+	if oc.TenantHeader == "" {
+		return DefaultTenantHeader
+	}
+	return oc.TenantHeader
+}
+
+// setTenantHeader sets the tenant header of req to oc's tenant, if set and if
+// req does not set the header itself.
+func (oc *OchamiClient) setTenantHeader(req *http.Request) {
+	if oc.Tenant != "" && req.Header.Get(oc.tenantHeader()) == "" {
+		req.Header.Set(oc.tenantHeader(), oc.Tenant)
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+)
+
+// SummaryWriter, if not nil, is where a one-line summary of each request sent
+// by MakeRequest is written once its response has been read (see
+// NewHTTPEnvelopeFromResponse): its method, final URL, status, duration, and
+// number of attempts. This makes slow endpoints easy to spot.
+//
+// Like the logs, the summaries are output of the program rather than a
+// setting of a client, so SummaryWriter is shared by all clients. It should be
+// set, e.g. by a command-line program for its --verbose flag, before any
+// requests are sent.
+var SummaryWriter io.Writer
+
+// requestTimingKey is the context key under which MakeRequest stores the
+// requestTiming of a request so that it can be read from the response.
+type requestTimingKey struct{}
+
+// requestTiming is the timing of a request sent by MakeRequest.
+type requestTiming struct {
+	uri      string // URI the request was first sent to
+	start    time.Time
+	attempts int
+}
+
+// summarize logs the timing of the request whose response is henv and, if
+// SummaryWriter is set, writes a summary of it there.
+func (rt *requestTiming) summarize(method string, henv HTTPEnvelope) {
+	url := RedactURI(henv.URL)
+	log.ClientLogger.Debug().Msgf("%s %s took %s (attempts: %d)", method, url, henv.Duration, henv.Attempts)
+	if SummaryWriter == nil {
+		return
+	}
+	redirected := ""
+	if henv.URL != rt.uri {
+		redirected = fmt.Sprintf(", redirected from %s", RedactURI(rt.uri))
+	}
+	fmt.Fprintf(SummaryWriter, "%s %s: %s in %s (%s%s)\n", method, url, henv.Status,
+		henv.Duration.Round(time.Millisecond), attemptsString(henv.Attempts), redirected)
+}
+
+// summarizeError is like summarize, for a request that failed without a
+// response.
+func (rt *requestTiming) summarizeError(method string) {
+	d := time.Since(rt.start)
+	log.ClientLogger.Debug().Msgf("%s %s failed after %s (attempts: %d)", method, RedactURI(rt.uri), d, rt.attempts)
+	if SummaryWriter == nil {
+		return
+	}
+	fmt.Fprintf(SummaryWriter, "%s %s: failed in %s (%s)\n", method, RedactURI(rt.uri), d.Round(time.Millisecond), attemptsString(rt.attempts))
+}
+
+func attemptsString(n int) string {
+	if n == 1 {
+		return "1 attempt"
+	}
+	return fmt.Sprintf("%d attempts", n)
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/internal/log"
+)
+
+// Transform is an external command that a payload is piped through before it
+// is used, e.g. to apply site-specific changes to payload files. The command
+// reads the payload as JSON on standard input and must write the transformed
+// payload, a single JSON value, to standard output.
+type Transform struct {
+	Name string
+	Args []string
+}
+
+// JQTransform returns a Transform that applies the jq filter expr (e.g.
+// "del(.Components[].NID)") to the payload. The jq executable must be in
+// PATH.
+func JQTransform(expr string) Transform {
+	return Transform{Name: "jq", Args: []string{"-c", expr}}
+}
+
+// CommandTransform returns a Transform that runs command with the shell.
+func CommandTransform(command string) Transform {
+	return Transform{Name: "sh", Args: []string{"-c", command}}
+}
+
+// String returns t as it would be typed into a shell, for messages.
+func (t Transform) String() string {
+	return t.Name + " " + strings.Join(t.Args, " ")
+}
+
+// transformPayload pipes body through each of transforms in order and returns
+// the result.
+func transformPayload(body HTTPBody, transforms []Transform) (HTTPBody, error) {
+	for _, t := range transforms {
+		log.ClientLogger.Debug().Msgf("transforming payload with: %s", t)
+		cmd := exec.Command(t.Name, t.Args...)
+		cmd.Stdin = bytes.NewReader(body)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("payload transform %q failed: %w: %s", t.String(), err, msg)
+			}
+			return nil, fmt.Errorf("payload transform %q failed: %w", t.String(), err)
+		}
+		out = bytes.TrimSpace(out)
+		if !json.Valid(out) {
+			return nil, fmt.Errorf("payload transform %q did not output a single JSON value", t.String())
+		}
+		body = out
+	}
+	return body, nil
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// TransportConfig describes how connections to a cluster's services are
+// secured. Service clients with equal TransportConfigs for the same host share
+// a transport (see SharedTransport) and therefore its connection pool and TLS
+// sessions.
+type TransportConfig struct {
+	// Insecure disables verification of server certificates. It is ignored
+	// if CACertPaths or NoSystemStore is set.
+	Insecure bool
+
+	// CACertPaths are the paths to CA certificate bundles in PEM format
+	// whose certificates are trusted to verify server certificates, in
+	// addition to those of the system's trust store unless NoSystemStore
+	// is set. This lets services behind certificates issued by a private
+	// CA and services behind publicly issued certificates be used alike.
+	CACertPaths []string
+
+	// NoSystemStore excludes the certificates of the system's trust store,
+	// so that only those in CACertPaths are trusted.
+	NoSystemStore bool
+
+	// Pins are the SPKI fingerprints (see SPKIFingerprint), without the
+	// "sha256/" prefix, that the server certificate must match one of.
+	Pins []string
+
+	// IPVersion restricts connections to IPv4 ("4") or IPv6 ("6"). Any
+	// other value uses both (see OchamiClient.UseIPVersion). It is ignored
+	// if SSHTunnel is set.
+	IPVersion string
+
+	// SSHTunnel, if not empty, is the SSH destination of the jump host that
+	// connections are tunneled through (see OchamiClient.UseSSHTunnel).
+	SSHTunnel string
+}
+
+// key returns a string identifying tc for connections to host.
+func (tc TransportConfig) key(host string) string {
+	pins := slices.Clone(tc.Pins)
+	slices.Sort(pins)
+	return fmt.Sprintf("%s|%t|%s|%t|%s|%s|%s", host, tc.Insecure, strings.Join(tc.CACertPaths, string(os.PathListSeparator)), tc.NoSystemStore, strings.Join(pins, ","), tc.IPVersion, tc.SSHTunnel)
+}
+
+// transports holds the transports created by SharedTransport, keyed by
+// TransportConfig.key.
+var transports = struct {
+	sync.Mutex
+	m map[string]*http.Transport
+}{m: make(map[string]*http.Transport)}
+
+// SharedTransport returns the transport for connections to host configured by
+// tc, creating it on first use. Subsequent calls with the same host and an
+// equal tc, including concurrent ones, return the same transport so that
+// clients of different services of a cluster (e.g. SMD and BSS) reuse each
+// other's connections. An error is returned if the CA certificate cannot be
+// read.
+func SharedTransport(host string, tc TransportConfig) (*http.Transport, error) {
+	key := tc.key(host)
+	transports.Lock()
+	defer transports.Unlock()
+	if t, ok := transports.m[key]; ok {
+		return t, nil
+	}
+	t, err := newTransport(tc)
+	if err != nil {
+		return nil, err
+	}
+	transports.m[key] = t
+	return t, nil
+}
+
+// CloseIdleConnections closes the idle connections of all transports created
+// by SharedTransport.
+func CloseIdleConnections() {
+	transports.Lock()
+	defer transports.Unlock()
+	for _, t := range transports.m {
+		t.CloseIdleConnections()
+	}
+}
+
+// newTransport returns a new transport configured by tc. It is based on
+// http.DefaultTransport, so proxies are honored from the environment, and keeps
+// enough idle connections for the requests of iterative methods, which are
+// sent in parallel (see BulkConcurrency), to be reused.
+func newTransport(tc TransportConfig) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSHandshakeTimeout = tlsHandshakeTimeout
+	t.ResponseHeaderTimeout = responseHeaderTimeout
+	t.MaxIdleConnsPerHost = DefaultReadConcurrency
+	t.TLSClientConfig = &tls.Config{}
+	if tc.SSHTunnel != "" {
+		// The jump host connects to the services, so proxies from the
+		// environment do not apply
+		tun, err := getSSHTunnel(tc.SSHTunnel)
+		if err != nil {
+			return nil, err
+		}
+		t.DialContext = tun.DialContext
+		t.Proxy = nil
+	} else if tc.IPVersion == "4" || tc.IPVersion == "6" {
+		t.DialContext = ipVersionDialer(tc.IPVersion)
+	}
+
+	if len(tc.CACertPaths) > 0 || tc.NoSystemStore {
+		certPool, err := tc.certPool()
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig.RootCAs = certPool
+	} else if tc.Insecure {
+		t.TLSClientConfig.InsecureSkipVerify = true
+	}
+
+	if len(tc.Pins) > 0 {
+		// Without a CA certificate, the pin replaces CA verification so
+		// that self-signed certificates can be used
+		if t.TLSClientConfig.RootCAs == nil {
+			t.TLSClientConfig.InsecureSkipVerify = true
+		}
+		pinSet := make(map[string]bool, len(tc.Pins))
+		for _, p := range tc.Pins {
+			pinSet[p] = true
+		}
+		t.TLSClientConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("server presented no certificates")
+			}
+			fp := SPKIFingerprint(cs.PeerCertificates[0])
+			if !pinSet[fp] {
+				return fmt.Errorf("server certificate has fingerprint %s%s, which does not match any pin", pinPrefix, fp)
+			}
+			return nil
+		}
+	}
+
+	return t, nil
+}
+
+// certPool returns the pool of CA certificates trusted by tc: those of the
+// system's trust store, unless NoSystemStore is set, followed by those in each
+// of CACertPaths. An error is returned if a bundle cannot be read or holds no
+// certificates, or if no certificates would be trusted at all.
+func (tc TransportConfig) certPool() (*x509.CertPool, error) {
+	certPool := x509.NewCertPool()
+	if !tc.NoSystemStore {
+		sys, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load system trust store: %w", err)
+		}
+		certPool = sys
+	} else if len(tc.CACertPaths) == 0 {
+		return nil, fmt.Errorf("system trust store disabled but no CA certificates given")
+	}
+	for _, path := range tc.CACertPaths {
+		cacert, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if !certPool.AppendCertsFromPEM(cacert) {
+			return nil, fmt.Errorf("%s contains no PEM-encoded certificates", path)
+		}
+	}
+	return certPool, nil
+}
+
+// useTransport sets the OchamiClient's transport to the shared transport for
+// its base URI's host and tc, and records tc so that later changes (e.g. by
+// UseCACerts) build on it. If a daemon socket is set (see UseDaemon), requests
+// are sent through the connection daemon, falling back to the shared
+// transport, unless they are tunneled through a jump host.
+func (oc *OchamiClient) useTransport(tc TransportConfig) error {
+	t, err := SharedTransport(oc.BaseURI.Host, tc)
+	if err != nil {
+		return err
+	}
+	oc.transportConfig = tc
+	if oc.daemonSocket != "" && tc.SSHTunnel == "" {
+		oc.Client = &http.Client{Transport: newDaemonTransport(oc.daemonSocket, tc, t)}
+		return nil
+	}
+	oc.Client = &http.Client{Transport: t}
+	return nil
+}
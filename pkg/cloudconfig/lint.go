@@ -0,0 +1,227 @@
+// Package cloudconfig checks cloud-config user data (the YAML documents that
+// cloud-init configures nodes with) locally, so that mistakes are caught
+// before the data is uploaded rather than on the first boot of a node.
+package cloudconfig
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Header is the first line that cloud-init requires of cloud-config data.
+const Header = "#cloud-config"
+
+// jinjaHeader is the first line of cloud-config data rendered by cloud-init
+// as a Jinja template, which is then followed by Header.
+const jinjaHeader = "## template: jinja"
+
+// Severities of problems, from most to least severe.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Problem is a single problem found in cloud-config data. Line is the 1-based
+// line it is on, or 0 if it is not about a particular line.
+type Problem struct {
+	Severity string `json:"severity"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+// String returns p in the form "<line>: <severity>: <message>".
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Severity + ": " + p.Message
+	}
+	return fmt.Sprintf("%d: %s: %s", p.Line, p.Severity, p.Message)
+}
+
+// knownKeys are the top-level keys that cloud-init modules (and cloud-init
+// itself) read from cloud-config data, including deprecated ones that are
+// still accepted.
+var knownKeys = []string{
+	"allow_public_ssh_keys", "ansible", "apk_repos", "apt", "apt_pipelining",
+	"apt_preserve_sources_list", "apt_proxy", "apt_reboot_if_required",
+	"apt_sources", "apt_update", "apt_upgrade", "autoinstall", "bootcmd",
+	"byobu_by_default", "ca-certs", "ca_certs", "chef", "chpasswd",
+	"cloud_config_modules", "cloud_final_modules", "cloud_init_modules",
+	"create_hostname_file", "datasource", "datasource_list", "device_aliases",
+	"disable_ec2_metadata", "disable_network_activation", "disable_root",
+	"disable_root_opts", "disk_setup", "drivers", "fan", "final_message", "fqdn",
+	"fs_setup", "groups", "growpart", "grub_dpkg", "grub-dpkg", "hostname",
+	"keyboard", "landscape", "locale", "locale_configfile", "lxd",
+	"manage_etc_hosts", "manage_resolv_conf", "mcollective", "merge_how",
+	"merge_type", "mount_default_fields", "mounts", "network",
+	"no_ssh_fingerprints", "ntp", "output", "package_reboot_if_required",
+	"package_update", "package_upgrade", "packages", "password", "phone_home",
+	"power_state", "prefer_fqdn_over_hostname", "preserve_hostname", "puppet",
+	"random_seed", "reporting", "resize_rootfs", "resolv_conf",
+	"rh_subscription", "rsyslog", "runcmd", "salt_minion", "snap", "spacewalk",
+	"ssh", "ssh_authorized_keys", "ssh_deletekeys", "ssh_fp_console_blacklist",
+	"ssh_genkeytypes", "ssh_import_id", "ssh_key_console_blacklist", "ssh_keys",
+	"ssh_publish_hostkeys", "ssh_pwauth", "ssh_quiet_keygen", "swap",
+	"system_info", "timezone", "ubuntu_advantage", "ubuntu_pro", "updates",
+	"user", "users", "vendor_data", "wireguard", "write_files", "yum_repo_dir",
+	"yum_repos", "zypper",
+}
+
+// listKeys are the top-level keys whose value must be a list.
+var listKeys = []string{"bootcmd", "packages", "runcmd", "ssh_authorized_keys", "write_files"}
+
+// yamlLineRE matches the line number in errors of the YAML parser.
+var yamlLineRE = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// Lint checks data, cloud-config user data, and returns the problems found,
+// ordered by line. Data that is not cloud-config (e.g. a shell script starting
+// with "#!") is reported as such, since cloud-init does not read it as YAML.
+func Lint(data []byte) []Problem {
+	text := string(data)
+	if strings.TrimSpace(text) == "" {
+		return []Problem{{Severity: SeverityError, Message: "data is empty"}}
+	}
+	lines := strings.Split(text, "\n")
+	first := strings.TrimRight(lines[0], "\r \t")
+	var problems []Problem
+
+	headerLine := 1
+	switch {
+	case strings.HasPrefix(first, "#!"):
+		return []Problem{{Severity: SeverityWarning, Line: 1, Message: "data is a script (starts with #!), not cloud-config, and is not checked"}}
+	case strings.EqualFold(first, jinjaHeader):
+		headerLine = 2
+		problems = append(problems, Problem{Severity: SeverityWarning, Line: 1, Message: "data is a Jinja template, so only what is valid YAML before rendering is checked"})
+	}
+	if len(lines) < headerLine || strings.TrimRight(lines[headerLine-1], "\r \t") != Header {
+		problems = append(problems, Problem{
+			Severity: SeverityError,
+			Line:     headerLine,
+			Message:  fmt.Sprintf("line %d must be %q for cloud-init to read the data as cloud-config", headerLine, Header),
+		})
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return append(problems, yamlProblems(err)...)
+	}
+	if len(doc.Content) == 0 {
+		return append(problems, Problem{Severity: SeverityWarning, Message: "data contains no configuration"})
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return append(problems, Problem{Severity: SeverityError, Line: root.Line, Message: "top level must be a mapping of module keys to their configuration"})
+	}
+
+	seen := make(map[string]int)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if line, ok := seen[key.Value]; ok {
+			problems = append(problems, Problem{Severity: SeverityError, Line: key.Line, Message: fmt.Sprintf("duplicate key %q (first on line %d) overrides it", key.Value, line)})
+			continue
+		}
+		seen[key.Value] = key.Line
+		switch {
+		case slices.Contains(knownKeys, key.Value):
+			if slices.Contains(listKeys, key.Value) && value.Kind != yaml.SequenceNode && value.Tag != "!!null" {
+				problems = append(problems, Problem{Severity: SeverityError, Line: value.Line, Message: fmt.Sprintf("%s must be a list", key.Value)})
+			}
+			if key.Value == "write_files" && value.Kind == yaml.SequenceNode {
+				problems = append(problems, lintWriteFiles(value)...)
+			}
+		default:
+			msg := fmt.Sprintf("unknown top-level key %q is ignored by cloud-init", key.Value)
+			if s := suggest(key.Value); s != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", s)
+			}
+			problems = append(problems, Problem{Severity: SeverityWarning, Line: key.Line, Message: msg})
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems
+}
+
+// lintWriteFiles checks files, the list of write_files, for entries without a
+// path.
+func lintWriteFiles(files *yaml.Node) []Problem {
+	var problems []Problem
+	for _, f := range files.Content {
+		if f.Kind != yaml.MappingNode {
+			problems = append(problems, Problem{Severity: SeverityError, Line: f.Line, Message: "write_files entries must be mappings"})
+			continue
+		}
+		hasPath := false
+		for i := 0; i+1 < len(f.Content); i += 2 {
+			if f.Content[i].Value == "path" && f.Content[i+1].Value != "" {
+				hasPath = true
+			}
+		}
+		if !hasPath {
+			problems = append(problems, Problem{Severity: SeverityError, Line: f.Line, Message: "write_files entry has no path"})
+		}
+	}
+	return problems
+}
+
+// yamlProblems converts err, returned by the YAML parser, into problems,
+// keeping the line numbers it reports.
+func yamlProblems(err error) []Problem {
+	var msgs []string
+	var terr *yaml.TypeError
+	if errors.As(err, &terr) {
+		msgs = terr.Errors
+	} else {
+		msgs = []string{err.Error()}
+	}
+	problems := make([]Problem, 0, len(msgs))
+	for _, m := range msgs {
+		p := Problem{Severity: SeverityError, Message: "invalid YAML: " + strings.TrimPrefix(m, "yaml: ")}
+		if sm := yamlLineRE.FindStringSubmatch(m); sm != nil {
+			p.Line, _ = strconv.Atoi(sm[1])
+			p.Message = "invalid YAML: " + sm[2]
+		}
+		problems = append(problems, p)
+	}
+	return problems
+}
+
+// suggest returns the known key closest to key if it is likely to be a typo
+// of it, i.e. if at most two edits, and fewer than half of its characters,
+// separate them. Otherwise, an empty string is returned.
+func suggest(key string) string {
+	best, bestDist := "", min(3, (len(key)+1)/2)
+	for _, k := range knownKeys {
+		if d := editDistance(strings.ToLower(key), k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
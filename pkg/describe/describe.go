@@ -0,0 +1,201 @@
+// Package describe aggregates what the OpenCHAMI services know about an entity
+// (e.g. a node) into one document, so that it can be inspected without
+// querying each service separately.
+//
+// Records are kept as the generic JSON values returned by the services so that
+// fields ochami does not know about are described as well.
+package describe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/bss"
+	"github.com/OpenCHAMI/ochami/pkg/client/ci"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/OpenCHAMI/ochami/pkg/xname"
+)
+
+// Sections of a Node, named after their keys in its JSON form. They are used
+// as the keys of Node.Errors.
+const (
+	SectionComponent          = "component"
+	SectionEthernetInterfaces = "ethernet_interfaces"
+	SectionComponentEndpoint  = "component_endpoint"
+	SectionRedfishEndpoint    = "redfish_endpoint"
+	SectionMemberships        = "memberships"
+	SectionBootParams         = "boot_params"
+	SectionCloudInit          = "cloud_init"
+)
+
+// Node is everything known about a node. Sections that a service has no record
+// for are empty. Sections that could not be fetched are empty as well, and the
+// error is kept in Errors, keyed by section, so that the rest of the node can
+// still be described.
+type Node struct {
+	Xname              string            `json:"xname"`
+	Component          map[string]any    `json:"component,omitempty"`
+	EthernetInterfaces []map[string]any  `json:"ethernet_interfaces"`
+	ComponentEndpoint  map[string]any    `json:"component_endpoint,omitempty"`
+	RedfishEndpoint    map[string]any    `json:"redfish_endpoint,omitempty"`
+	Groups             []string          `json:"groups"`
+	Partition          string            `json:"partition,omitempty"`
+	BootParams         []map[string]any  `json:"boot_params"`
+	CloudInit          map[string]any    `json:"cloud_init,omitempty"`
+	Errors             map[string]string `json:"errors,omitempty"`
+}
+
+// Describer fetches the records of entities from the services. BSS and
+// CloudInit may be nil to skip those services. Token, if not empty, is sent to
+// the services that require it. If CloudInitSecure is true, the secure
+// cloud-init endpoint is used.
+type Describer struct {
+	SMD             *smd.SMDClient
+	BSS             *bss.BSSClient
+	CloudInit       *ci.CloudInitClient
+	CloudInitSecure bool
+	Token           string
+}
+
+// Node describes the node id, fetching its records from all services
+// concurrently. An error is only returned if id is not a node xname.
+func (d Describer) Node(id string) (Node, error) {
+	bmc, err := xname.NodeXnameToBMCXname(id)
+	if err != nil {
+		return Node{}, fmt.Errorf("%s is not a node xname: %w", id, err)
+	}
+	n := Node{
+		Xname:              id,
+		EthernetInterfaces: []map[string]any{},
+		Groups:             []string{},
+		BootParams:         []map[string]any{},
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	fetch := func(section string, f func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := f(); err != nil {
+				mu.Lock()
+				if n.Errors == nil {
+					n.Errors = make(map[string]string)
+				}
+				n.Errors[section] = err.Error()
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Each fetch only sets its own fields, so no locking is needed for
+	// them
+	fetch(SectionComponent, func() error {
+		henv, err := d.SMD.GetComponentsXname(id, d.Token)
+		if notFound(henv, err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return json.Unmarshal(henv.Body, &n.Component)
+	})
+	fetch(SectionEthernetInterfaces, func() error {
+		henv, err := d.SMD.GetEthernetInterfaces(smd.EthernetInterfaceFilter{ComponentIDs: []string{id}})
+		if notFound(henv, err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return json.Unmarshal(henv.Body, &n.EthernetInterfaces)
+	})
+	fetch(SectionComponentEndpoint, func() error {
+		henvs, errs, err := d.SMD.GetComponentEndpoints(d.Token, id)
+		if err != nil {
+			return err
+		}
+		if notFound(henvs[0], errs[0]) {
+			return nil
+		} else if errs[0] != nil {
+			return errs[0]
+		}
+		return json.Unmarshal(henvs[0].Body, &n.ComponentEndpoint)
+	})
+	fetch(SectionRedfishEndpoint, func() error {
+		henv, err := d.SMD.GetRedfishEndpointsValues(url.Values{"id": {bmc}}, d.Token)
+		if notFound(henv, err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var rfes struct {
+			RedfishEndpoints []map[string]any `json:"RedfishEndpoints"`
+		}
+		if err := json.Unmarshal(henv.Body, &rfes); err != nil {
+			return err
+		}
+		if len(rfes.RedfishEndpoints) > 0 {
+			n.RedfishEndpoint = rfes.RedfishEndpoints[0]
+		}
+		return nil
+	})
+	fetch(SectionMemberships, func() error {
+		memberships, err := d.SMD.GetMembershipsValues(url.Values{"id": {id}}, d.Token)
+		if err != nil {
+			return err
+		}
+		for _, m := range memberships {
+			if strings.EqualFold(m.ID, id) {
+				n.Groups = append(n.Groups, m.GroupLabels...)
+				n.Partition = m.PartitionName
+			}
+		}
+		return nil
+	})
+	if d.BSS != nil {
+		fetch(SectionBootParams, func() error {
+			henv, err := d.BSS.GetBootParamsValues(url.Values{"name": {id}}, d.Token)
+			if notFound(henv, err) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			return json.Unmarshal(henv.Body, &n.BootParams)
+		})
+	}
+	if d.CloudInit != nil {
+		fetch(SectionCloudInit, func() error {
+			var (
+				henv client.HTTPEnvelope
+				err  error
+			)
+			if d.CloudInitSecure {
+				henv, err = d.CloudInit.GetConfigsSecure(id, d.Token)
+			} else {
+				henv, err = d.CloudInit.GetConfigs(id)
+			}
+			if notFound(henv, err) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			return json.Unmarshal(henv.Body, &n.CloudInit)
+		})
+	}
+	wg.Wait()
+
+	return n, nil
+}
+
+// notFound reports whether err is the unsuccessful response henv with status
+// 404 Not Found, which services return when they have no record.
+func notFound(henv client.HTTPEnvelope, err error) bool {
+	return errors.Is(err, client.UnsuccessfulHTTPError) && henv.StatusCode == http.StatusNotFound
+}
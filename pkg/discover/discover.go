@@ -2,8 +2,15 @@ package discover
 
 import (
 	"fmt"
+	"net"
 	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/OpenCHAMI/bss/pkg/bssTypes"
+	"github.com/OpenCHAMI/cloud-init/pkg/citypes"
 	"github.com/OpenCHAMI/ochami/internal/log"
 	"github.com/OpenCHAMI/ochami/pkg/client/smd"
 	"github.com/OpenCHAMI/ochami/pkg/xname"
@@ -12,9 +19,11 @@ import (
 )
 
 // NodeList is simply a list of Nodes. Data from a payload file is unmarshalled
-// into this.
+// into this. Groups optionally holds extra configuration for the groups that
+// the Nodes reference.
 type NodeList struct {
-	Nodes []Node `json:"nodes"`
+	Nodes  []Node        `json:"nodes"`
+	Groups []GroupConfig `json:"groups,omitempty"`
 }
 
 func (nl NodeList) String() string {
@@ -34,13 +43,16 @@ func (nl NodeList) String() string {
 // Node represents a node entry in a payload file. Multiple of these are send to
 // SMD to "discover" them.
 type Node struct {
-	Name   string  `json:"name"`
-	NID    int64   `json:"nid"`
-	Xname  string  `json:"xname"`
-	Group  string  `json:"group"`
-	BMCMac string  `json:"bmc_mac"`
-	BMCIP  string  `json:"bmc_ip"`
-	Ifaces []Iface `json:"interfaces"`
+	Name      string         `json:"name"`
+	NID       int64          `json:"nid"`
+	Xname     string         `json:"xname" jsonschema:"required"`
+	Group     string         `json:"group"`
+	Groups    []string       `json:"groups,omitempty"`
+	BMCMac    string         `json:"bmc_mac"`
+	BMCIP     string         `json:"bmc_ip"`
+	Ifaces    []Iface        `json:"interfaces"`
+	Boot      *Boot          `json:"boot,omitempty"`
+	CloudInit *CloudInitData `json:"cloud_init,omitempty"`
 }
 
 func (n Node) String() string {
@@ -54,10 +66,43 @@ func (n Node) String() string {
 		}
 	}
 	nStr += "]"
+	if n.Boot != nil {
+		nStr += fmt.Sprintf(" boot={%s}", *n.Boot)
+	}
 
 	return nStr
 }
 
+// Boot represents the boot configuration of a Node that is sent to BSS.
+type Boot struct {
+	Kernel string `json:"kernel" jsonschema:"required"`
+	Initrd string `json:"initrd"`
+	Params string `json:"params"`
+}
+
+func (b Boot) String() string {
+	return fmt.Sprintf("kernel=%s initrd=%s params=%q", b.Kernel, b.Initrd, b.Params)
+}
+
+// CloudInitData represents the cloud-init data of a Node or a GroupConfig that
+// is sent to the cloud-init service.
+type CloudInitData struct {
+	UserData   map[string]interface{} `json:"user_data,omitempty"`
+	MetaData   map[string]interface{} `json:"meta_data,omitempty"`
+	VendorData map[string]interface{} `json:"vendor_data,omitempty"`
+}
+
+// GroupConfig represents extra configuration for a group referenced by the
+// Group or Groups fields of one or more Nodes. The Description, if set, is used for the
+// SMD group. If CloudInit is set, a cloud-init config named after the group is
+// created so that the cloud-init service merges it into the data of each
+// member.
+type GroupConfig struct {
+	Name        string         `json:"name" jsonschema:"required"`
+	Description string         `json:"description,omitempty"`
+	CloudInit   *CloudInitData `json:"cloud_init,omitempty"`
+}
+
 // Iface represents a single interface with multiple IP addresses. Nodes can
 // have multiple of these.
 type Iface struct {
@@ -114,29 +159,30 @@ func DiscoveryInfoV2(baseURI string, nl NodeList) (smd.ComponentSlice, smd.Redfi
 	// Deduplication map for Components
 	compMap := make(map[string]string)
 	for _, node := range nl.Nodes {
-		log.Logger.Debug().Msgf("generating component structure for node with xname %s", node.Xname)
+		log.DiscoverLogger.Debug().Msgf("generating component structure for node with xname %s", node.Xname)
 		if _, ok := compMap[node.Xname]; !ok {
+			enabled := true
 			comp := smd.Component{
 				ID:      node.Xname,
 				NID:     node.NID,
 				Type:    "Node",
 				State:   "On",
-				Enabled: true,
+				Enabled: &enabled,
 			}
-			log.Logger.Debug().Msgf("adding component %v", comp)
+			log.DiscoverLogger.Debug().Msgf("adding component %v", comp)
 			compMap[node.Xname] = "present"
 			comps.Components = append(comps.Components, comp)
 		} else {
-			log.Logger.Warn().Msgf("component with xname %s already exists (duplicate?), not adding", node.Xname)
+			log.DiscoverLogger.Warn().Msgf("component with xname %s already exists (duplicate?), not adding", node.Xname)
 		}
 
-		log.Logger.Debug().Msgf("generating redfish structure for node with xname %s", node.Xname)
+		log.DiscoverLogger.Debug().Msgf("generating redfish structure for node with xname %s", node.Xname)
 		var rfe smd.RedfishEndpointV2
 
 		// Differentiate node Xname from BMC Xname
 		bmcXname, err := xname.NodeXnameToBMCXname(node.Xname)
 		if err != nil {
-			log.Logger.Warn().Err(err).Msgf("node %s: falling back to node xname as BMC xname", node.Xname)
+			log.DiscoverLogger.Warn().Err(err).Msgf("node %s: falling back to node xname as BMC xname", node.Xname)
 			bmcXname = node.Xname
 		}
 
@@ -146,7 +192,7 @@ func DiscoveryInfoV2(baseURI string, nl NodeList) (smd.ComponentSlice, smd.Redfi
 		rfe.ID = bmcXname
 		rfe.MACAddr = node.BMCMac
 		rfe.IPAddress = node.BMCIP
-		rfe.SchemaVersion = 1 // Tells SMD to use new (v2) parsing code
+		rfe.SchemaVersion = smd.RedfishEndpointSchemaVersion
 
 		// Deduplication maps for fake BMC Managers and Systems
 		systemMap := make(map[string]string)
@@ -154,7 +200,7 @@ func DiscoveryInfoV2(baseURI string, nl NodeList) (smd.ComponentSlice, smd.Redfi
 
 		// Create fake BMC "System" for node if it doesn't already exist
 		if _, ok := systemMap[node.Xname]; !ok {
-			log.Logger.Debug().Msgf("node %s: generating fake BMC System", node.Xname)
+			log.DiscoverLogger.Debug().Msgf("node %s: generating fake BMC System", node.Xname)
 			base.Path = "/redfish/v1/Systems/" + node.Xname
 
 			s := smd.System{
@@ -164,7 +210,7 @@ func DiscoveryInfoV2(baseURI string, nl NodeList) (smd.ComponentSlice, smd.Redfi
 
 			// Create unique identifier for system
 			if sysUUID, err := uuid.NewRandom(); err != nil {
-				log.Logger.Warn().Err(err).Msgf("node %s: could not generate UUID for fake BMC System, it will be zero", node.Xname)
+				log.DiscoverLogger.Warn().Err(err).Msgf("node %s: could not generate UUID for fake BMC System, it will be zero", node.Xname)
 			} else {
 				s.UUID = sysUUID.String()
 			}
@@ -194,16 +240,16 @@ func DiscoveryInfoV2(baseURI string, nl NodeList) (smd.ComponentSlice, smd.Redfi
 			}
 
 			systemMap[node.Xname] = "present"
-			log.Logger.Debug().Msgf("node %s: generated system: %v", node.Xname, s)
+			log.DiscoverLogger.Debug().Msgf("node %s: generated system: %v", node.Xname, s)
 			rfe.Systems = append(rfe.Systems, s)
 		} else {
-			log.Logger.Debug().Msgf("node %s: fake BMC System already exists, skipping creation", node.Xname)
+			log.DiscoverLogger.Debug().Msgf("node %s: fake BMC System already exists, skipping creation", node.Xname)
 		}
 
 		// Create fake BMC "Manager" for node if it doesn't already exist
 		// BMC interface
 		if _, ok := managerMap[bmcXname]; !ok {
-			log.Logger.Debug().Msgf("BMC %s: generating fake BMC Manager", bmcXname)
+			log.DiscoverLogger.Debug().Msgf("BMC %s: generating fake BMC Manager", bmcXname)
 			base.Path = "/redfish/v1/Managers/" + bmcXname
 
 			m := smd.Manager{
@@ -216,7 +262,7 @@ func DiscoveryInfoV2(baseURI string, nl NodeList) (smd.ComponentSlice, smd.Redfi
 
 			// Create unique identifier for manager
 			if mngerUUID, err := uuid.NewRandom(); err != nil {
-				log.Logger.Warn().Err(err).Msgf("BMC %s: could not generate UUID for fake BMC Manager, it will be zero", bmcXname)
+				log.DiscoverLogger.Warn().Err(err).Msgf("BMC %s: could not generate UUID for fake BMC Manager, it will be zero", bmcXname)
 			} else {
 				m.UUID = mngerUUID.String()
 				rfe.UID = mngerUUID // Redfish UUID will be fake Manager's UUID
@@ -231,12 +277,232 @@ func DiscoveryInfoV2(baseURI string, nl NodeList) (smd.ComponentSlice, smd.Redfi
 			}
 			m.EthernetInterfaces = append(m.EthernetInterfaces, ifaceBMC)
 			managerMap[bmcXname] = "present"
-			log.Logger.Debug().Msgf("BMC %s: generated manager: %v", bmcXname, m)
+			log.DiscoverLogger.Debug().Msgf("BMC %s: generated manager: %v", bmcXname, m)
 			rfe.Managers = append(rfe.Managers, m)
 		} else {
-			log.Logger.Debug().Msgf("BMC %s: fake BMC Manager already exists, skipping creation", bmcXname)
+			log.DiscoverLogger.Debug().Msgf("BMC %s: fake BMC Manager already exists, skipping creation", bmcXname)
+		}
+		warnings, err := smd.NormalizeRedfishEndpointV2(&rfe)
+		if err != nil {
+			return comps, rfes, ifaces, fmt.Errorf("node %s: %w", node.Xname, err)
+		}
+		for _, w := range warnings {
+			log.DiscoverLogger.Warn().Msgf("node %s: %s", node.Xname, w)
 		}
 		rfes.RedfishEndpoints = append(rfes.RedfishEndpoints, rfe)
 	}
 	return comps, rfes, ifaces, nil
 }
+
+// DiscoveryInfoV3 does everything that DiscoveryInfoV2 does and, additionally,
+// generates the BSS boot parameters and cloud-init configs for the nodes and
+// groups in nl that have them, so that a single payload file can populate SMD,
+// BSS, and cloud-init. Nodes with identical boot configurations share a single
+// BootParams entry identified by their xnames. Cloud-init configs are named
+// after the node's xname or the group's name.
+func DiscoveryInfoV3(baseURI string, nl NodeList) (smd.ComponentSlice, smd.RedfishEndpointSliceV2, []smd.EthernetInterface, []bssTypes.BootParams, []citypes.CI, error) {
+	comps, rfes, ifaces, err := DiscoveryInfoV2(baseURI, nl)
+	if err != nil {
+		return comps, rfes, ifaces, nil, nil, err
+	}
+
+	// Boot parameters, deduplicated by boot configuration
+	var bootParams []bssTypes.BootParams
+	bpIdx := make(map[Boot]int)
+	for _, node := range nl.Nodes {
+		if node.Boot == nil {
+			continue
+		}
+		if node.Boot.Kernel == "" {
+			return comps, rfes, ifaces, nil, nil, fmt.Errorf("node %s: boot configuration is missing kernel", node.Xname)
+		}
+		if idx, ok := bpIdx[*node.Boot]; ok {
+			bootParams[idx].Hosts = append(bootParams[idx].Hosts, node.Xname)
+			continue
+		}
+		log.DiscoverLogger.Debug().Msgf("node %s: generating boot parameters: %s", node.Xname, *node.Boot)
+		bpIdx[*node.Boot] = len(bootParams)
+		bootParams = append(bootParams, bssTypes.BootParams{
+			Hosts:  []string{node.Xname},
+			Kernel: node.Boot.Kernel,
+			Initrd: node.Boot.Initrd,
+			Params: node.Boot.Params,
+		})
+	}
+
+	// Cloud-init configs for groups, then nodes
+	var ciConfigs []citypes.CI
+	ciNames := make(map[string]bool)
+	addCI := func(name string, data *CloudInitData) error {
+		if data == nil {
+			return nil
+		}
+		if ciNames[name] {
+			return fmt.Errorf("duplicate cloud-init config %q", name)
+		}
+		log.DiscoverLogger.Debug().Msgf("generating cloud-init config %s", name)
+		ciNames[name] = true
+		ciConfigs = append(ciConfigs, citypes.CI{
+			Name: name,
+			CIData: citypes.CIData{
+				UserData:   data.UserData,
+				MetaData:   data.MetaData,
+				VendorData: data.VendorData,
+			},
+		})
+		return nil
+	}
+	for _, group := range nl.Groups {
+		if group.Name == "" {
+			return comps, rfes, ifaces, nil, nil, fmt.Errorf("group name cannot be empty")
+		}
+		if err := addCI(group.Name, group.CloudInit); err != nil {
+			return comps, rfes, ifaces, nil, nil, fmt.Errorf("group %s: %w", group.Name, err)
+		}
+	}
+	for _, node := range nl.Nodes {
+		if err := addCI(node.Xname, node.CloudInit); err != nil {
+			return comps, rfes, ifaces, nil, nil, fmt.Errorf("node %s: %w", node.Xname, err)
+		}
+	}
+
+	return comps, rfes, ifaces, bootParams, ciConfigs, nil
+}
+
+// GroupNames returns the names of the groups n is in: its Group followed by its
+// Groups, without duplicates.
+func (n Node) GroupNames() []string {
+	var names []string
+	for _, g := range append([]string{n.Group}, n.Groups...) {
+		if g != "" && !slices.Contains(names, g) {
+			names = append(names, g)
+		}
+	}
+	return names
+}
+
+// DiscoveryGroups returns the SMD groups that the nodes in nl are in (see
+// Node.GroupNames), with the nodes as their members, in the order in which
+// the groups are first referenced. The description of a group is taken from
+// nl.Groups, if there. An error is returned if a node lists an empty group
+// name in Groups.
+func DiscoveryGroups(nl NodeList) ([]smd.Group, error) {
+	descs := make(map[string]string, len(nl.Groups))
+	for _, g := range nl.Groups {
+		descs[g.Name] = g.Description
+	}
+	var groups []smd.Group
+	index := make(map[string]int)
+	for _, node := range nl.Nodes {
+		if slices.Contains(node.Groups, "") {
+			return nil, fmt.Errorf("node %s: group name cannot be empty", node.Xname)
+		}
+		for _, name := range node.GroupNames() {
+			i, ok := index[name]
+			if !ok {
+				i = len(groups)
+				index[name] = i
+				groups = append(groups, smd.Group{Label: name, Description: descs[name]})
+			}
+			if !slices.Contains(groups[i].Members.IDs, node.Xname) {
+				groups[i].Members.IDs = append(groups[i].Members.IDs, node.Xname)
+			}
+		}
+	}
+	for _, g := range groups {
+		log.DiscoverLogger.Debug().Msgf("group %s: %d member(s)", g.Label, len(g.Members.IDs))
+	}
+	return groups, nil
+}
+
+// GroupRule assigns nodes whose xname matches Pattern, a glob as accepted by
+// path.Match (e.g. "x1000c1s*b0n*"), to Group.
+type GroupRule struct {
+	Pattern string `json:"pattern"`
+	Group   string `json:"group"`
+}
+
+// AssignGroups sets the Group of each node in nl that is not in any group (see
+// Node.GroupNames) to the Group of the first rule in rules whose Pattern
+// matches the node's xname, and returns the number of nodes assigned a group.
+// Nodes that are already in a group are left as they are. An error is returned if a rule is invalid.
+func (nl *NodeList) AssignGroups(rules []GroupRule) (int, error) {
+	for _, r := range rules {
+		if r.Group == "" {
+			return 0, fmt.Errorf("group rule for pattern %q has no group", r.Pattern)
+		}
+		if _, err := path.Match(r.Pattern, ""); err != nil {
+			return 0, fmt.Errorf("invalid pattern %q in group rule for group %s: %w", r.Pattern, r.Group, err)
+		}
+	}
+	assigned := 0
+	for i, node := range nl.Nodes {
+		if len(node.GroupNames()) > 0 {
+			continue
+		}
+		for _, r := range rules {
+			if ok, _ := path.Match(r.Pattern, node.Xname); ok {
+				log.DiscoverLogger.Debug().Msgf("node %s: assigning group %s (matches %s)", node.Xname, r.Group, r.Pattern)
+				nl.Nodes[i].Group = r.Group
+				assigned++
+				break
+			}
+		}
+	}
+	return assigned, nil
+}
+
+// BMCOptions holds settings for the redfish endpoints of the BMCs of discovered
+// nodes. Empty fields are not set in the redfish endpoints. If Scheme or Port
+// is set, the URI of each redfish endpoint is set to the BMC's service root,
+// using "https" if Scheme is empty and the scheme's default port if Port is 0.
+type BMCOptions struct {
+	Username string
+	Password string
+	Scheme   string
+	Port     int
+}
+
+// DiscoveryInfoV4 does everything that DiscoveryInfoV3 does and, additionally,
+// sets the credentials and service root URI of the generated redfish endpoints
+// according to bmc. The BMC's IP address is used as the host of the URI, or
+// its xname if the node has no BMC IP address.
+func DiscoveryInfoV4(baseURI string, nl NodeList, bmc BMCOptions) (smd.ComponentSlice, smd.RedfishEndpointSliceV2, []smd.EthernetInterface, []bssTypes.BootParams, []citypes.CI, error) {
+	comps, rfes, ifaces, bootParams, ciConfigs, err := DiscoveryInfoV3(baseURI, nl)
+	if err != nil {
+		return comps, rfes, ifaces, bootParams, ciConfigs, err
+	}
+
+	scheme := bmc.Scheme
+	if scheme == "" && bmc.Port != 0 {
+		scheme = "https"
+	}
+	if scheme != "" && scheme != "http" && scheme != "https" {
+		return comps, rfes, ifaces, bootParams, ciConfigs, fmt.Errorf("invalid redfish scheme %q: must be http or https", scheme)
+	}
+	if bmc.Port < 0 || bmc.Port > 65535 {
+		return comps, rfes, ifaces, bootParams, ciConfigs, fmt.Errorf("invalid redfish port %d", bmc.Port)
+	}
+
+	for i := range rfes.RedfishEndpoints {
+		rfe := &rfes.RedfishEndpoints[i]
+		rfe.User = bmc.Username
+		rfe.Password = bmc.Password
+		if scheme == "" {
+			continue
+		}
+		host := rfe.IPAddress
+		if host == "" {
+			host = rfe.ID
+		}
+		if bmc.Port != 0 {
+			host = net.JoinHostPort(host, strconv.Itoa(bmc.Port))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		rfe.URI = (&url.URL{Scheme: scheme, Host: host}).String()
+		log.DiscoverLogger.Debug().Msgf("BMC %s: using redfish service root %s", rfe.ID, rfe.URI)
+	}
+
+	return comps, rfes, ifaces, bootParams, ciConfigs, nil
+}
@@ -0,0 +1,393 @@
+package discover
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+)
+
+// Kinds of SMD resources reconciled by PlanReconcile, in the order they are
+// reported.
+const (
+	KindComponents         = "components"
+	KindRedfishEndpoints   = "redfish-endpoints"
+	KindEthernetInterfaces = "ethernet-interfaces"
+	KindGroups             = "groups"
+)
+
+// ReconcileKinds lists the kinds of resources reconciled by PlanReconcile.
+var ReconcileKinds = []string{KindComponents, KindRedfishEndpoints, KindEthernetInterfaces, KindGroups}
+
+// Actions of a ReconcileChange.
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionUnchanged = "unchanged"
+	ActionDelete    = "delete"
+)
+
+// ReconcileActions lists the actions of a ReconcileChange, in the order they
+// are reported.
+var ReconcileActions = []string{ActionCreate, ActionUpdate, ActionUnchanged, ActionDelete}
+
+// reconcileIgnored are the fields of each kind of resource that are not
+// compared, since they are generated anew on each run (e.g. UUIDs), are not
+// returned by SMD (e.g. passwords and the Systems and Managers of redfish
+// endpoints), or reflect the state of the hardware rather than its
+// configuration (e.g. component states).
+var reconcileIgnored = map[string][]string{
+	KindComponents:         {"State", "Flag", "SoftwareStatus"},
+	KindRedfishEndpoints:   {"UUID", "Password", "DiscoveryInfo", "SchemaVersion", "Systems", "Managers"},
+	KindEthernetInterfaces: {"ID", "LastUpdate"},
+}
+
+// reconcilePayloadFields are the fields of each kind of resource that
+// discovery always derives from the payload (see DiscoveryInfoV2) but that are
+// left out of the resource when empty. They are compared even if left out, so
+// that e.g. removing the BMC's IP address from the payload removes it from
+// SMD.
+var reconcilePayloadFields = map[string][]string{
+	KindRedfishEndpoints: {"Name", "MACAddr", "IPAddress"},
+}
+
+// ReconcileChange is what needs to be done to a single SMD resource for it to
+// match the discovered one. Fields lists the fields that differ for updates.
+type ReconcileChange struct {
+	Kind   string   `json:"kind"`
+	ID     string   `json:"id"`
+	Action string   `json:"action"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// GroupUpdate is a change to an existing group. AddMembers are the discovered
+// members that the group does not have yet, and Description is set if it
+// differs from that of the group.
+type GroupUpdate struct {
+	Label       string
+	AddMembers  []string
+	Description string
+}
+
+// Existing holds the resources in SMD that discovered resources are
+// reconciled against. Components, redfish endpoints, and ethernet interfaces
+// are kept as the generic JSON values returned by SMD so that they can be
+// compared field by field.
+type Existing struct {
+	Components         []map[string]any
+	RedfishEndpoints   []map[string]any
+	EthernetInterfaces []map[string]any
+	Groups             []smd.Group
+}
+
+// ReconcilePlan is the result of PlanReconcile: the resources to create,
+// update, and delete, and a ReconcileChange for every discovered or deleted
+// resource.
+type ReconcilePlan struct {
+	Changes []ReconcileChange
+
+	CreateComponents         []smd.Component
+	UpdateComponents         []smd.Component
+	CreateRedfishEndpoints   []smd.RedfishEndpointV2
+	UpdateRedfishEndpoints   []smd.RedfishEndpointV2
+	CreateEthernetInterfaces []smd.EthernetInterface
+	UpdateEthernetInterfaces []smd.EthernetInterface
+	DeleteEthernetInterfaces []string
+	CreateGroups             []smd.Group
+	UpdateGroups             []GroupUpdate
+}
+
+// Count returns the number of changes of kind with action.
+func (p ReconcilePlan) Count(kind, action string) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Kind == kind && c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// PlanReconcile compares the discovered components, redfish endpoints,
+// ethernet interfaces, and groups with those in SMD and returns what needs to
+// be created or updated for SMD to match. Components and redfish endpoints
+// are matched by ID, ethernet interfaces by MAC address, and groups by label.
+// A resource is updated if any field set in the discovered one differs from
+// SMD, except for the fields in reconcileIgnored and fields that SMD does not
+// return. A field is set if it is in the JSON of the discovered resource, even
+// if false or empty (e.g. Enabled of a component set to false), or if it is in
+// reconcilePayloadFields. Strings are compared case-insensitively, since SMD
+// normalizes xnames, MAC addresses, and types. Updated components keep their state in
+// SMD, and updated ethernet interfaces their ID.
+//
+// Group members are only added, never removed, since groups can have members
+// that were not discovered. The description of an existing group is only
+// updated if the discovered group sets one.
+//
+// If prune is true, the ethernet interfaces in SMD that belong to a discovered
+// component but were not discovered themselves, e.g. those of a replaced NIC,
+// are deleted.
+func PlanReconcile(comps []smd.Component, rfes []smd.RedfishEndpointV2, ifaces []smd.EthernetInterface, groups []smd.Group, existing Existing, prune bool) (ReconcilePlan, error) {
+	var plan ReconcilePlan
+
+	curComps := indexBy(existing.Components, "ID", strings.ToLower)
+	for _, c := range comps {
+		cur := curComps[strings.ToLower(c.ID)]
+		fields, found, err := compare(KindComponents, c, cur)
+		if err != nil {
+			return plan, fmt.Errorf("PlanReconcile(): component %s: %w", c.ID, err)
+		}
+		switch {
+		case !found:
+			plan.CreateComponents = append(plan.CreateComponents, c)
+			plan.add(KindComponents, c.ID, ActionCreate, nil)
+		case len(fields) > 0:
+			// Keep the state of the component, which is not compared,
+			// rather than resetting it to the discovered one.
+			if state, ok := cur["State"].(string); ok && state != "" {
+				c.State = state
+			}
+			if flag, ok := cur["Flag"].(string); ok && flag != "" {
+				c.Flag = flag
+			}
+			plan.UpdateComponents = append(plan.UpdateComponents, c)
+			plan.add(KindComponents, c.ID, ActionUpdate, fields)
+		default:
+			plan.add(KindComponents, c.ID, ActionUnchanged, nil)
+		}
+	}
+
+	curRFEs := indexBy(existing.RedfishEndpoints, "ID", strings.ToLower)
+	for _, r := range rfes {
+		fields, found, err := compare(KindRedfishEndpoints, r, curRFEs[strings.ToLower(r.ID)])
+		if err != nil {
+			return plan, fmt.Errorf("PlanReconcile(): redfish endpoint %s: %w", r.ID, err)
+		}
+		switch {
+		case !found:
+			plan.CreateRedfishEndpoints = append(plan.CreateRedfishEndpoints, r)
+			plan.add(KindRedfishEndpoints, r.ID, ActionCreate, nil)
+		case len(fields) > 0:
+			plan.UpdateRedfishEndpoints = append(plan.UpdateRedfishEndpoints, r)
+			plan.add(KindRedfishEndpoints, r.ID, ActionUpdate, fields)
+		default:
+			plan.add(KindRedfishEndpoints, r.ID, ActionUnchanged, nil)
+		}
+	}
+
+	curIfaces := indexBy(existing.EthernetInterfaces, "MACAddress", macKey)
+	discoveredMACs := make(map[string]bool, len(ifaces))
+	for _, ei := range ifaces {
+		discoveredMACs[macKey(ei.MACAddress)] = true
+		cur := curIfaces[macKey(ei.MACAddress)]
+		fields, found, err := compare(KindEthernetInterfaces, ei, cur)
+		if err != nil {
+			return plan, fmt.Errorf("PlanReconcile(): ethernet interface %s: %w", ei.MACAddress, err)
+		}
+		switch {
+		case !found:
+			plan.CreateEthernetInterfaces = append(plan.CreateEthernetInterfaces, ei)
+			plan.add(KindEthernetInterfaces, ei.MACAddress, ActionCreate, nil)
+		case len(fields) > 0:
+			if id, ok := cur["ID"].(string); ok {
+				ei.ID = id
+			}
+			plan.UpdateEthernetInterfaces = append(plan.UpdateEthernetInterfaces, ei)
+			plan.add(KindEthernetInterfaces, ei.MACAddress, ActionUpdate, fields)
+		default:
+			plan.add(KindEthernetInterfaces, ei.MACAddress, ActionUnchanged, nil)
+		}
+	}
+	if prune {
+		for _, ei := range existing.EthernetInterfaces {
+			mac, _ := ei["MACAddress"].(string)
+			compID, _ := ei["ComponentID"].(string)
+			id, _ := ei["ID"].(string)
+			if !discovered(comps, compID) || discoveredMACs[macKey(mac)] {
+				continue
+			}
+			plan.DeleteEthernetInterfaces = append(plan.DeleteEthernetInterfaces, id)
+			plan.add(KindEthernetInterfaces, mac, ActionDelete, nil)
+		}
+	}
+
+	curGroups := make(map[string]smd.Group, len(existing.Groups))
+	for _, g := range existing.Groups {
+		curGroups[strings.ToLower(g.Label)] = g
+	}
+	for _, g := range groups {
+		cur, found := curGroups[strings.ToLower(g.Label)]
+		if !found {
+			plan.CreateGroups = append(plan.CreateGroups, g)
+			plan.add(KindGroups, g.Label, ActionCreate, nil)
+			continue
+		}
+		gu := GroupUpdate{Label: cur.Label}
+		members := make(map[string]bool, len(cur.Members.IDs))
+		for _, id := range cur.Members.IDs {
+			members[strings.ToLower(id)] = true
+		}
+		for _, id := range g.Members.IDs {
+			if !members[strings.ToLower(id)] {
+				gu.AddMembers = append(gu.AddMembers, id)
+			}
+		}
+		var fields []string
+		if len(gu.AddMembers) > 0 {
+			fields = append(fields, "members")
+		}
+		if g.Description != "" && g.Description != cur.Description {
+			gu.Description = g.Description
+			fields = append(fields, "description")
+		}
+		if len(fields) == 0 {
+			plan.add(KindGroups, g.Label, ActionUnchanged, nil)
+			continue
+		}
+		plan.UpdateGroups = append(plan.UpdateGroups, gu)
+		plan.add(KindGroups, g.Label, ActionUpdate, fields)
+	}
+
+	return plan, nil
+}
+
+// add records a change of the resource of kind identified by id.
+func (p *ReconcilePlan) add(kind, id, action string, fields []string) {
+	p.Changes = append(p.Changes, ReconcileChange{Kind: kind, ID: id, Action: action, Fields: fields})
+}
+
+// discovered reports whether the component with ID id is in comps.
+func discovered(comps []smd.Component, id string) bool {
+	for _, c := range comps {
+		if strings.EqualFold(c.ID, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexBy returns items keyed by their field key, transformed with norm.
+// Items without the field are left out.
+func indexBy(items []map[string]any, key string, norm func(string) string) map[string]map[string]any {
+	m := make(map[string]map[string]any, len(items))
+	for _, item := range items {
+		if v, ok := item[key].(string); ok && v != "" {
+			m[norm(v)] = item
+		}
+	}
+	return m
+}
+
+// macKey returns mac in lower case without separators so that MAC addresses
+// written differently can be matched.
+func macKey(mac string) string {
+	return strings.NewReplacer(":", "", "-", "", ".", "").Replace(strings.ToLower(mac))
+}
+
+// compare returns the fields of the discovered resource want, of kind, that
+// differ from cur, the resource in SMD, and whether cur exists at all. Fields
+// that want leaves out of its JSON, unless they are payload fields of kind
+// (see reconcilePayloadFields), that SMD did not return in cur, or that are
+// ignored for kind are not compared.
+func compare(kind string, want any, cur map[string]any) ([]string, bool, error) {
+	if cur == nil {
+		return nil, false, nil
+	}
+	b, err := json.Marshal(want)
+	if err != nil {
+		return nil, true, err
+	}
+	var w map[string]any
+	if err := json.Unmarshal(b, &w); err != nil {
+		return nil, true, err
+	}
+
+	for _, k := range reconcilePayloadFields[kind] {
+		if _, ok := w[k]; !ok {
+			w[k] = nil
+		}
+	}
+
+	var fields []string
+	for k, v := range w {
+		if slices.Contains(reconcileIgnored[kind], k) {
+			continue
+		}
+		c, ok := cur[k]
+		if !ok {
+			continue
+		}
+		if !equal(v, c) {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fields, true, nil
+}
+
+// isZero reports whether v, a value unmarshalled from JSON, is null, false, 0,
+// an empty string, or an empty list or object.
+func isZero(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !t
+	case float64:
+		return t == 0
+	case string:
+		return t == ""
+	case []any:
+		return len(t) == 0
+	case map[string]any:
+		return len(t) == 0
+	}
+	return false
+}
+
+// equal reports whether v and c, values unmarshalled from JSON, are equal,
+// ignoring the case of strings. Zero values (see isZero) are equal to each
+// other, so that e.g. an empty string matches null, and a key missing from an
+// object matches a zero value, since SMD leaves out some empty fields.
+func equal(v, c any) bool {
+	if isZero(v) && isZero(c) {
+		return true
+	}
+	switch t := v.(type) {
+	case string:
+		s, ok := c.(string)
+		return ok && strings.EqualFold(t, s)
+	case []any:
+		u, ok := c.([]any)
+		if !ok || len(u) != len(t) {
+			return false
+		}
+		for i := range t {
+			if !equal(t[i], u[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		u, ok := c.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k := range t {
+			if !equal(t[k], u[k]) {
+				return false
+			}
+		}
+		for k := range u {
+			if _, ok := t[k]; !ok && !isZero(u[k]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(v, c)
+}
@@ -0,0 +1,140 @@
+package discover
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestPlanReconcileComponents(t *testing.T) {
+	existing := Existing{Components: []map[string]any{
+		{"ID": "x1000c1s7b0n0", "Type": "Node", "NID": float64(1), "Enabled": true, "State": "Ready", "Role": "Compute"},
+	}}
+	tests := []struct {
+		name   string
+		comp   smd.Component
+		action string
+		fields []string
+	}{
+		{
+			name:   "unchanged",
+			comp:   smd.Component{ID: "x1000c1s7b0n0", Type: "node", NID: 1, Enabled: boolPtr(true), State: "On"},
+			action: ActionUnchanged,
+		},
+		{
+			name:   "unset fields are not compared",
+			comp:   smd.Component{ID: "x1000c1s7b0n0", Type: "Node"},
+			action: ActionUnchanged,
+		},
+		{
+			name:   "disabled",
+			comp:   smd.Component{ID: "x1000c1s7b0n0", Type: "Node", NID: 1, Enabled: boolPtr(false)},
+			action: ActionUpdate,
+			fields: []string{"Enabled"},
+		},
+		{
+			name:   "changed",
+			comp:   smd.Component{ID: "x1000c1s7b0n0", Type: "Node", NID: 2, Role: "Management"},
+			action: ActionUpdate,
+			fields: []string{"NID", "Role"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := PlanReconcile([]smd.Component{tt.comp}, nil, nil, nil, existing, false)
+			if err != nil {
+				t.Fatalf("PlanReconcile failed: %v", err)
+			}
+			if len(plan.Changes) != 1 {
+				t.Fatalf("got %d changes, want 1", len(plan.Changes))
+			}
+			c := plan.Changes[0]
+			if c.Action != tt.action || !slices.Equal(c.Fields, tt.fields) {
+				t.Errorf("got %s of %v, want %s of %v", c.Action, c.Fields, tt.action, tt.fields)
+			}
+			for _, u := range plan.UpdateComponents {
+				if u.State != "Ready" {
+					t.Errorf("updated component has state %q, want the one in SMD", u.State)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanReconcileRedfishEndpoints(t *testing.T) {
+	existing := Existing{RedfishEndpoints: []map[string]any{
+		{"ID": "x1000c1s7b0", "Type": "NodeBMC", "Name": "node01", "MACAddr": "de:ca:fc:0f:ee:ee", "IPAddress": "172.16.0.101", "Enabled": true},
+	}}
+	rfe := func(ip string) smd.RedfishEndpointV2 {
+		var r smd.RedfishEndpointV2
+		r.ID = "x1000c1s7b0"
+		r.Type = "NodeBMC"
+		r.Name = "node01"
+		r.MACAddr = "DE:CA:FC:0F:EE:EE"
+		r.IPAddress = ip
+		return r
+	}
+
+	plan, err := PlanReconcile(nil, []smd.RedfishEndpointV2{rfe("172.16.0.101")}, nil, nil, existing, false)
+	if err != nil {
+		t.Fatalf("PlanReconcile failed: %v", err)
+	}
+	if c := plan.Changes[0]; c.Action != ActionUnchanged {
+		t.Errorf("got %s of %v for same endpoint, want %s", c.Action, c.Fields, ActionUnchanged)
+	}
+
+	plan, err = PlanReconcile(nil, []smd.RedfishEndpointV2{rfe("")}, nil, nil, existing, false)
+	if err != nil {
+		t.Fatalf("PlanReconcile failed: %v", err)
+	}
+	if c := plan.Changes[0]; c.Action != ActionUpdate || !slices.Equal(c.Fields, []string{"IPAddress"}) {
+		t.Errorf("got %s of %v for cleared IP address, want %s of [IPAddress]", c.Action, c.Fields, ActionUpdate)
+	}
+}
+
+func TestPlanReconcileEthernetInterfaces(t *testing.T) {
+	existing := Existing{EthernetInterfaces: []map[string]any{
+		{
+			"ID":          "decafc0feeee",
+			"ComponentID": "x1000c1s7b0n0",
+			"Type":        "Node",
+			"Description": "Interface 0 for node01",
+			"MACAddress":  "de:ca:fc:0f:ee:ee",
+			"IPAddresses": []any{map[string]any{"IPAddress": "172.16.0.1"}},
+		},
+	}}
+	ei := smd.EthernetInterface{
+		ComponentID: "x1000c1s7b0n0",
+		Type:        "Node",
+		Description: "Interface 0 for node01",
+		MACAddress:  "DE:CA:FC:0F:EE:EE",
+		IPAddresses: []smd.EthernetIP{{IPAddress: "172.16.0.1"}},
+	}
+
+	plan, err := PlanReconcile(nil, nil, []smd.EthernetInterface{ei}, nil, existing, false)
+	if err != nil {
+		t.Fatalf("PlanReconcile failed: %v", err)
+	}
+	if c := plan.Changes[0]; c.Action != ActionUnchanged {
+		t.Errorf("got %s of %v for same interface, want %s", c.Action, c.Fields, ActionUnchanged)
+	}
+
+	ei.Description = ""
+	plan, err = PlanReconcile(nil, nil, []smd.EthernetInterface{ei}, nil, existing, false)
+	if err != nil {
+		t.Fatalf("PlanReconcile failed: %v", err)
+	}
+	if c := plan.Changes[0]; c.Action != ActionUpdate || !slices.Equal(c.Fields, []string{"Description"}) {
+		t.Errorf("got %s of %v for cleared description, want %s of [Description]", c.Action, c.Fields, ActionUpdate)
+	}
+	for _, u := range plan.UpdateEthernetInterfaces {
+		if u.ID != "decafc0feeee" {
+			t.Errorf("updated interface has ID %q, want the one in SMD", u.ID)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Package flagtypes provides pflag.Value types for flags taking durations and
+// points in time, so that all commands accept the same forms for them (see
+// package timeparse).
+package flagtypes
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenCHAMI/ochami/pkg/timeparse"
+	"github.com/spf13/pflag"
+)
+
+// DurationHints are example durations offered as shell completions for
+// Duration flags.
+var DurationHints = []string{"30s", "5m", "1h", "2h30m", "1d"}
+
+// TimeHints are example points in time offered as shell completions for Time
+// flags.
+var TimeHints = []string{"now", "today", "yesterday", "-1h", "-24h", "-7d"}
+
+// Duration is a pflag.Value for a duration, parsed with
+// timeparse.ParseDuration (e.g. 5m, 2h30m, 1d, or PT5M). Its type is
+// "duration", like that of pflag's own duration flags, so that its value can
+// be read with pflag.FlagSet.GetDuration.
+type Duration time.Duration
+
+// NewDuration returns a Duration whose value is val.
+func NewDuration(val time.Duration) *Duration {
+	d := Duration(val)
+	return &d
+}
+
+// Set parses s as the value of d.
+func (d *Duration) Set(s string) error {
+	v, err := timeparse.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// String returns the value of d in the form of time.Duration.String.
+func (d *Duration) String() string {
+	return time.Duration(*d).String()
+}
+
+// Type returns the type of d for usage messages.
+func (d *Duration) Type() string {
+	return "duration"
+}
+
+// Time is a pflag.Value for a point in time, parsed with timeparse.Parse (e.g.
+// an RFC 3339 timestamp, a date, or a duration relative to the time the flag
+// is parsed, such as -24h). The zero Time is unset.
+type Time struct {
+	t time.Time
+	s string
+}
+
+// NewTime returns an unset Time.
+func NewTime() *Time {
+	return &Time{}
+}
+
+// Set parses s as the value of t, relative to now.
+func (t *Time) Set(s string) error {
+	v, err := timeparse.Parse(s, time.Now())
+	if err != nil {
+		return err
+	}
+	t.t, t.s = v, s
+	return nil
+}
+
+// String returns the value of t as it was passed.
+func (t *Time) String() string {
+	return t.s
+}
+
+// Type returns the type of t for usage messages.
+func (t *Time) Type() string {
+	return "time"
+}
+
+// Time returns the point in time t holds, which is zero if t is unset.
+func (t *Time) Time() time.Time {
+	return t.t
+}
+
+// GetTime returns the point in time of the Time flag name in fs, which is zero
+// if the flag was not passed.
+func GetTime(fs *pflag.FlagSet, name string) (time.Time, error) {
+	f := fs.Lookup(name)
+	if f == nil {
+		return time.Time{}, fmt.Errorf("flag accessed but not defined: %s", name)
+	}
+	t, ok := f.Value.(*Time)
+	if !ok {
+		return time.Time{}, fmt.Errorf("trying to get time value of flag of type %s", f.Value.Type())
+	}
+	return t.Time(), nil
+}
@@ -0,0 +1,209 @@
+// Package format renders the data returned by OpenCHAMI services in formats
+// meant for people and line-oriented tools rather than for other programs.
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Table is the name of the output format rendered by RenderTable.
+const Table = "table"
+
+// Kinds of resources that can be rendered as tables.
+const (
+	KindBootParams         = "boot-params"
+	KindComponents         = "components"
+	KindEthernetInterfaces = "ethernet-interfaces"
+	KindGroups             = "groups"
+	KindRedfishEndpoints   = "redfish-endpoints"
+)
+
+// Column is a column of a table. Field is the dot-separated path to the field
+// of each item that the column shows, matched case-insensitively if there is
+// no exact match. If a field on the path is a list, the rest of the path is
+// followed for each of its elements (e.g. IPAddresses.IPAddress).
+type Column struct {
+	Header string
+	Field  string
+}
+
+// Columns are the columns of the table of each kind of resource, in order.
+// The order is part of the output's interface, since scripts select columns
+// by position: columns are only ever added at the end, never removed or
+// reordered. Columns whose values may contain spaces (e.g. descriptions) are
+// last so that they do not shift the others when split on whitespace.
+var Columns = map[string][]Column{
+	KindBootParams: {
+		{Header: "HOSTS", Field: "hosts"},
+		{Header: "MACS", Field: "macs"},
+		{Header: "NIDS", Field: "nids"},
+		{Header: "KERNEL", Field: "kernel"},
+		{Header: "INITRD", Field: "initrd"},
+		{Header: "PARAMS", Field: "params"},
+	},
+	KindComponents: {
+		{Header: "ID", Field: "ID"},
+		{Header: "TYPE", Field: "Type"},
+		{Header: "STATE", Field: "State"},
+		{Header: "FLAG", Field: "Flag"},
+		{Header: "ENABLED", Field: "Enabled"},
+		{Header: "ROLE", Field: "Role"},
+		{Header: "SUBROLE", Field: "SubRole"},
+		{Header: "NID", Field: "NID"},
+		{Header: "ARCH", Field: "Arch"},
+		{Header: "CLASS", Field: "Class"},
+	},
+	KindEthernetInterfaces: {
+		{Header: "ID", Field: "ID"},
+		{Header: "MAC", Field: "MACAddress"},
+		{Header: "COMPONENT", Field: "ComponentID"},
+		{Header: "TYPE", Field: "Type"},
+		{Header: "IPS", Field: "IPAddresses.IPAddress"},
+		{Header: "DESCRIPTION", Field: "Description"},
+	},
+	KindGroups: {
+		{Header: "LABEL", Field: "label"},
+		{Header: "EXCLUSIVE", Field: "exclusiveGroup"},
+		{Header: "TAGS", Field: "tags"},
+		{Header: "MEMBERS", Field: "members.ids"},
+		{Header: "DESCRIPTION", Field: "description"},
+	},
+	KindRedfishEndpoints: {
+		{Header: "ID", Field: "ID"},
+		{Header: "TYPE", Field: "Type"},
+		{Header: "FQDN", Field: "FQDN"},
+		{Header: "IP", Field: "IPAddress"},
+		{Header: "MAC", Field: "MACAddr"},
+		{Header: "ENABLED", Field: "Enabled"},
+		{Header: "STATUS", Field: "DiscoveryInfo.LastDiscoveryStatus"},
+	},
+}
+
+// Empty is printed in place of empty values, so that each row has a value in
+// every column.
+const Empty = "-"
+
+// RenderTable writes body, the JSON data of a response listing resources of
+// kind, to w as a table with one row per item, aligned with spaces. The items
+// are either body itself, if it is an array, the only array in it, if it is
+// an object with a single array member (e.g. SMD's {"Components": [...]}), or
+// else body as a single item. If noHeaders is true, the row of column headers
+// is omitted. Values that are lists are joined with commas, and empty values
+// are printed as Empty.
+func RenderTable(w io.Writer, kind string, body []byte, noHeaders bool) error {
+	cols, ok := Columns[kind]
+	if !ok {
+		return fmt.Errorf("no table columns defined for %s", kind)
+	}
+	var data any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", kind, err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if !noHeaders {
+		headers := make([]string, len(cols))
+		for i, c := range cols {
+			headers[i] = c.Header
+		}
+		fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	}
+	for _, item := range items(data) {
+		cells := make([]string, len(cols))
+		for i, c := range cols {
+			cells[i] = cell(lookup(item, strings.Split(c.Field, ".")))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// items returns the items to render as rows from data (see RenderTable).
+func items(data any) []any {
+	switch d := data.(type) {
+	case []any:
+		return d
+	case map[string]any:
+		if len(d) == 1 {
+			for _, v := range d {
+				if l, ok := v.([]any); ok {
+					return l
+				}
+			}
+		}
+		return []any{d}
+	case nil:
+		return nil
+	}
+	return []any{data}
+}
+
+// lookup returns the values at path in v. Lists along the path are followed
+// element by element, so several values may be returned.
+func lookup(v any, path []string) []any {
+	if len(path) == 0 {
+		if l, ok := v.([]any); ok {
+			return l
+		}
+		if v == nil {
+			return nil
+		}
+		return []any{v}
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		val, ok := t[path[0]]
+		if !ok {
+			for k, kv := range t {
+				if strings.EqualFold(k, path[0]) {
+					val, ok = kv, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return nil
+		}
+		return lookup(val, path[1:])
+	case []any:
+		var vals []any
+		for _, e := range t {
+			vals = append(vals, lookup(e, path)...)
+		}
+		return vals
+	}
+	return nil
+}
+
+// cell formats vals, the values of a column for an item, for a table cell.
+// Tabs and newlines are replaced with spaces to keep the row on one line.
+func cell(vals []any) string {
+	strs := make([]string, 0, len(vals))
+	for _, v := range vals {
+		var s string
+		switch t := v.(type) {
+		case string:
+			s = t
+		case map[string]any, []any:
+			b, _ := json.Marshal(t)
+			s = string(b)
+		default:
+			s = fmt.Sprint(t)
+		}
+		if s = strings.Join(strings.Fields(s), " "); s != "" {
+			strs = append(strs, s)
+		}
+	}
+	if len(strs) == 0 {
+		return Empty
+	}
+	return strings.Join(slices.Compact(strs), ",")
+}
@@ -0,0 +1,307 @@
+// Package kparams parses, edits, and formats Linux kernel command lines, such
+// as the params of BSS boot parameters.
+//
+// A command line is a list of parameters separated by whitespace. Each is
+// either a key (e.g. "quiet") or a key and a value separated by the first "="
+// (e.g. "console=ttyS0,115200"). Double quotes group text containing
+// whitespace, either around the value (key="a b") or around the whole
+// parameter ("key=a b"), and are not part of the key or value. Like the
+// kernel, keys are compared treating "-" and "_" as the same character.
+// Parameters after a "--" parameter are passed to init by the kernel; they are
+// kept as they are and parameters are added before the "--".
+//
+// Parameters whose key and value are not changed are formatted exactly as they
+// were parsed, so that parsing and formatting a command line without editing
+// it only normalizes the whitespace between parameters.
+package kparams
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// initSeparator separates kernel parameters from those passed to init.
+const initSeparator = "--"
+
+// Param is a single kernel parameter. Value is only meaningful if HasValue is
+// true, which distinguishes "key=" from "key".
+type Param struct {
+	Key      string
+	Value    string
+	HasValue bool
+
+	// raw is the parameter as it was parsed, used to format it unchanged
+	// as long as Key, Value, and HasValue still match it.
+	raw string
+}
+
+// NewParam returns the parameter key=value, or key alone if value is empty.
+func NewParam(key, value string) Param {
+	return Param{Key: key, Value: value, HasValue: value != ""}
+}
+
+// ParseParam parses a single parameter, e.g. `console=ttyS0` or `foo="a b"`.
+// Quotes are removed from the key and value.
+func ParseParam(s string) Param {
+	unquoted := strings.ReplaceAll(s, `"`, "")
+	key, value, hasValue := strings.Cut(unquoted, "=")
+	return Param{Key: key, Value: value, HasValue: hasValue, raw: s}
+}
+
+// String formats p. A parsed parameter whose key and value were not changed
+// since is formatted as it was parsed. Otherwise, the value is quoted if it
+// contains whitespace.
+func (p Param) String() string {
+	if p.raw != "" {
+		if q := ParseParam(p.raw); q.Key == p.Key && q.Value == p.Value && q.HasValue == p.HasValue {
+			return p.raw
+		}
+	}
+	if !p.HasValue {
+		return p.Key
+	}
+	if strings.ContainsAny(p.Value, " \t\n") {
+		return p.Key + `="` + p.Value + `"`
+	}
+	return p.Key + "=" + p.Value
+}
+
+// Equal reports whether p and o are the same parameter, i.e. their keys are
+// equal (see KeysEqual) and they have the same value or both have none.
+func (p Param) Equal(o Param) bool {
+	return KeysEqual(p.Key, o.Key) && p.HasValue == o.HasValue && p.Value == o.Value
+}
+
+// KeysEqual reports whether the parameter keys a and b are equal, treating "-"
+// and "_" as the same character like the kernel does.
+func KeysEqual(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		ca, cb := a[i], b[i]
+		if ca == '-' {
+			ca = '_'
+		}
+		if cb == '-' {
+			cb = '_'
+		}
+		if ca != cb {
+			return false
+		}
+	}
+	return true
+}
+
+// Cmdline is a parsed kernel command line. The zero value is an empty command
+// line.
+type Cmdline struct {
+	params []Param
+}
+
+// Parse parses the kernel command line s. It returns an error if a double
+// quote is not closed.
+func Parse(s string) (*Cmdline, error) {
+	var (
+		c       Cmdline
+		cur     strings.Builder
+		inQuote bool
+	)
+	flush := func() {
+		if cur.Len() > 0 {
+			c.params = append(c.params, ParseParam(cur.String()))
+			cur.Reset()
+		}
+	}
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			cur.WriteRune(r)
+		case !inQuote && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("unterminated quote in kernel command line %q", s)
+	}
+	flush()
+	return &c, nil
+}
+
+// Validate checks that s is a syntactically valid kernel command line: it must
+// not contain control characters other than tabs, double quotes must be
+// closed, and every parameter must have a key (e.g. "=foo" is invalid).
+func Validate(s string) error {
+	for _, r := range s {
+		if unicode.IsControl(r) && r != '\t' {
+			return fmt.Errorf("kernel command line contains control character %q", r)
+		}
+	}
+	c, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	for _, p := range c.params {
+		if p.Key == "" {
+			return fmt.Errorf("kernel parameter %q has no key", p.raw)
+		}
+	}
+	return nil
+}
+
+// MustParse is like Parse but panics if s cannot be parsed. It is meant for
+// constant command lines.
+func MustParse(s string) *Cmdline {
+	c, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// String formats c as a kernel command line, separating parameters with a
+// single space.
+func (c *Cmdline) String() string {
+	strs := make([]string, len(c.params))
+	for i, p := range c.params {
+		strs[i] = p.String()
+	}
+	return strings.Join(strs, " ")
+}
+
+// Params returns a copy of the parameters of c, in order.
+func (c *Cmdline) Params() []Param {
+	return slices.Clone(c.params)
+}
+
+// Len returns the number of parameters in c.
+func (c *Cmdline) Len() int {
+	return len(c.params)
+}
+
+// Has reports whether c has a kernel parameter with key.
+func (c *Cmdline) Has(key string) bool {
+	return c.index(key) >= 0
+}
+
+// Get returns the value of the last kernel parameter with key, since that is
+// the one that takes effect for most parameters, and whether there is one.
+func (c *Cmdline) Get(key string) (string, bool) {
+	var (
+		value string
+		found bool
+	)
+	for _, p := range c.kernelParams() {
+		if KeysEqual(p.Key, key) {
+			value, found = p.Value, true
+		}
+	}
+	return value, found
+}
+
+// Values returns the values of all kernel parameters with key, in order, e.g.
+// each console of "console=tty0 console=ttyS0".
+func (c *Cmdline) Values(key string) []string {
+	var values []string
+	for _, p := range c.kernelParams() {
+		if KeysEqual(p.Key, key) {
+			values = append(values, p.Value)
+		}
+	}
+	return values
+}
+
+// Add adds p after the last kernel parameter unless an equal kernel parameter
+// is already present. It reports whether p was added.
+func (c *Cmdline) Add(p Param) bool {
+	if slices.ContainsFunc(c.kernelParams(), p.Equal) {
+		return false
+	}
+	c.insert(p)
+	return true
+}
+
+// Set sets the kernel parameter with p's key to p: the first parameter with
+// the key is replaced by p and any others with the key are removed. If there
+// is none, p is added after the last kernel parameter.
+func (c *Cmdline) Set(p Param) {
+	i := c.index(p.Key)
+	if i < 0 {
+		c.insert(p)
+		return
+	}
+	if !c.params[i].Equal(p) {
+		c.params[i] = p
+	}
+	end := c.end()
+	rest := slices.DeleteFunc(slices.Clone(c.params[i+1:end]), func(o Param) bool { return KeysEqual(o.Key, p.Key) })
+	c.params = append(append(c.params[:i+1:i+1], rest...), c.params[end:]...)
+}
+
+// Remove removes all kernel parameters with key and returns how many were
+// removed.
+func (c *Cmdline) Remove(key string) int {
+	return c.removeFunc(func(p Param) bool { return KeysEqual(p.Key, key) })
+}
+
+// RemoveParam removes all kernel parameters equal to p and returns how many
+// were removed.
+func (c *Cmdline) RemoveParam(p Param) int {
+	return c.removeFunc(p.Equal)
+}
+
+// Dedup removes kernel parameters that are equal to an earlier one and returns
+// how many were removed.
+func (c *Cmdline) Dedup() int {
+	end := c.end()
+	var kept []Param
+	for _, p := range c.params[:end] {
+		if !slices.ContainsFunc(kept, p.Equal) {
+			kept = append(kept, p)
+		}
+	}
+	n := end - len(kept)
+	c.params = append(kept, c.params[end:]...)
+	return n
+}
+
+// kernelParams returns the parameters of c before the init separator, if any.
+func (c *Cmdline) kernelParams() []Param {
+	return c.params[:c.end()]
+}
+
+// end returns the index of the init separator, or the number of parameters if
+// there is none.
+func (c *Cmdline) end() int {
+	for i, p := range c.params {
+		if p.Key == initSeparator && !p.HasValue {
+			return i
+		}
+	}
+	return len(c.params)
+}
+
+// index returns the index of the first kernel parameter with key, or -1.
+func (c *Cmdline) index(key string) int {
+	return slices.IndexFunc(c.kernelParams(), func(p Param) bool { return KeysEqual(p.Key, key) })
+}
+
+// insert inserts p after the last kernel parameter.
+func (c *Cmdline) insert(p Param) {
+	c.params = slices.Insert(c.params, c.end(), p)
+}
+
+// removeFunc removes the kernel parameters for which del returns true and
+// returns how many were removed.
+func (c *Cmdline) removeFunc(del func(Param) bool) int {
+	end := c.end()
+	kept := slices.DeleteFunc(slices.Clone(c.params[:end]), del)
+	n := end - len(kept)
+	c.params = append(kept, c.params[end:]...)
+	return n
+}
@@ -0,0 +1,226 @@
+package kparams
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"quiet", "quiet"},
+		{"  console=ttyS0,115200 \t quiet\n", "console=ttyS0,115200 quiet"},
+		{`foo="a b" bar`, `foo="a b" bar`},
+		{`"foo=a b" bar`, `"foo=a b" bar`},
+		{`foo=`, `foo=`},
+		{`a=b=c`, `a=b=c`},
+		{"root=live:http://10.0.0.1/%s -- single", "root=live:http://10.0.0.1/%s -- single"},
+	}
+	for _, tt := range tests {
+		c, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got := c.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseUnterminatedQuote(t *testing.T) {
+	if _, err := Parse(`foo="a b`); err == nil {
+		t.Error(`Parse("foo=\"a b") returned no error`)
+	}
+}
+
+func TestParseParam(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Param
+	}{
+		{"quiet", Param{Key: "quiet"}},
+		{"foo=", Param{Key: "foo", HasValue: true}},
+		{"console=ttyS0,115200", Param{Key: "console", Value: "ttyS0,115200", HasValue: true}},
+		{`foo="a b"`, Param{Key: "foo", Value: "a b", HasValue: true}},
+		{`"foo=a b"`, Param{Key: "foo", Value: "a b", HasValue: true}},
+		{"a=b=c", Param{Key: "a", Value: "b=c", HasValue: true}},
+	}
+	for _, tt := range tests {
+		got := ParseParam(tt.in)
+		if got.Key != tt.want.Key || got.Value != tt.want.Value || got.HasValue != tt.want.HasValue {
+			t.Errorf("ParseParam(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if s := got.String(); s != tt.in {
+			t.Errorf("ParseParam(%q).String() = %q, want it unchanged", tt.in, s)
+		}
+	}
+}
+
+func TestParamString(t *testing.T) {
+	tests := []struct {
+		p    Param
+		want string
+	}{
+		{NewParam("quiet", ""), "quiet"},
+		{NewParam("console", "ttyS0"), "console=ttyS0"},
+		{NewParam("foo", "a b"), `foo="a b"`},
+		{NewParam("foo", "a\tb"), "foo=\"a\tb\""},
+		{Param{Key: "foo", HasValue: true}, "foo="},
+	}
+	for _, tt := range tests {
+		if got := tt.p.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestParamStringEdited(t *testing.T) {
+	p := ParseParam(`foo="a b"`)
+	p.Value = "c"
+	if got, want := p.String(), "foo=c"; got != want {
+		t.Errorf("String() of edited value = %q, want %q", got, want)
+	}
+
+	p = ParseParam(`"foo=a b"`)
+	p.Key = "bar"
+	if got, want := p.String(), `bar="a b"`; got != want {
+		t.Errorf("String() of edited key = %q, want %q", got, want)
+	}
+
+	p = ParseParam("foo=bar")
+	p.HasValue, p.Value = false, ""
+	if got, want := p.String(), "foo"; got != want {
+		t.Errorf("String() of removed value = %q, want %q", got, want)
+	}
+
+	c := MustParse(`a=1 foo="x y" b`)
+	edited := c.Params()[1]
+	edited.Value = "z"
+	c.Set(edited)
+	if got, want := c.String(), "a=1 foo=z b"; got != want {
+		t.Errorf("Set() of edited parsed param: got %q, want %q", got, want)
+	}
+}
+
+func TestKeysEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"foo_bar", "foo-bar", true},
+		{"foo-bar", "foo-bar", true},
+		{"foo", "Foo", false},
+		{"foo", "foobar", false},
+	}
+	for _, tt := range tests {
+		if got := KeysEqual(tt.a, tt.b); got != tt.want {
+			t.Errorf("KeysEqual(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestGetValues(t *testing.T) {
+	c := MustParse("console=tty0 quiet console=ttyS0 -- console=init")
+	if v, ok := c.Get("console"); !ok || v != "ttyS0" {
+		t.Errorf(`Get("console") = %q, %v, want "ttyS0", true`, v, ok)
+	}
+	if v, ok := c.Get("quiet"); !ok || v != "" {
+		t.Errorf(`Get("quiet") = %q, %v, want "", true`, v, ok)
+	}
+	if _, ok := c.Get("root"); ok {
+		t.Error(`Get("root") found a parameter`)
+	}
+	if got, want := c.Values("console"), []string{"tty0", "ttyS0"}; !slices.Equal(got, want) {
+		t.Errorf(`Values("console") = %q, want %q`, got, want)
+	}
+	if !c.Has("quiet") || c.Has("single") {
+		t.Error("Has() considers parameters after the init separator")
+	}
+}
+
+func TestAdd(t *testing.T) {
+	c := MustParse("console=tty0 quiet -- single")
+	if !c.Add(NewParam("console", "ttyS0")) {
+		t.Error("Add() did not add parameter with a new value")
+	}
+	if c.Add(NewParam("quiet", "")) {
+		t.Error("Add() added a duplicate parameter")
+	}
+	if c.Add(ParseParam("console=tty0")) {
+		t.Error("Add() added a duplicate parameter with a value")
+	}
+	if !c.Add(NewParam("single", "")) {
+		t.Error("Add() considered a parameter after the init separator")
+	}
+	if got, want := c.String(), "console=tty0 quiet console=ttyS0 single -- single"; got != want {
+		t.Errorf("after Add(): got %q, want %q", got, want)
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		in   string
+		set  Param
+		want string
+	}{
+		{"a=1 console=tty0 b console=ttyS0 c", NewParam("console", "ttyS1"), "a=1 console=ttyS1 b c"},
+		{"a=1 b", NewParam("console", "ttyS0"), "a=1 b console=ttyS0"},
+		{"a=1 -- b", NewParam("c", "2"), "a=1 c=2 -- b"},
+		{"foo_bar=1 x foo-bar=2", NewParam("foo-bar", "3"), "foo-bar=3 x"},
+		{`foo="a b" x foo=c`, ParseParam(`foo="a b"`), `foo="a b" x`},
+	}
+	for _, tt := range tests {
+		c := MustParse(tt.in)
+		c.Set(tt.set)
+		if got := c.String(); got != tt.want {
+			t.Errorf("Set(%v) on %q: got %q, want %q", tt.set, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	c := MustParse("console=tty0 quiet console=ttyS0 -- console=init")
+	if n := c.Remove("console"); n != 2 {
+		t.Errorf(`Remove("console") = %d, want 2`, n)
+	}
+	if got, want := c.String(), "quiet -- console=init"; got != want {
+		t.Errorf(`after Remove("console"): got %q, want %q`, got, want)
+	}
+
+	c = MustParse("console=tty0 quiet console=ttyS0")
+	if n := c.RemoveParam(NewParam("console", "tty0")); n != 1 {
+		t.Errorf("RemoveParam(console=tty0) = %d, want 1", n)
+	}
+	if got, want := c.String(), "quiet console=ttyS0"; got != want {
+		t.Errorf("after RemoveParam(console=tty0): got %q, want %q", got, want)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	c := MustParse("quiet a=1 quiet a=2 a=1 -- quiet quiet")
+	if n := c.Dedup(); n != 2 {
+		t.Errorf("Dedup() = %d, want 2", n)
+	}
+	if got, want := c.String(), "quiet a=1 a=2 -- quiet quiet"; got != want {
+		t.Errorf("after Dedup(): got %q, want %q", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []string{"", "quiet", `console=ttyS0 foo="a b"`, "a=1\tb=2", "-- init"}
+	for _, s := range valid {
+		if err := Validate(s); err != nil {
+			t.Errorf("Validate(%q) returned error: %v", s, err)
+		}
+	}
+	invalid := []string{"quiet\nfoo", `foo="a b`, "=foo", `"=a b"`, "a \x00"}
+	for _, s := range invalid {
+		if err := Validate(s); err == nil {
+			t.Errorf("Validate(%q) returned no error", s)
+		}
+	}
+}
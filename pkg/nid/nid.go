@@ -0,0 +1,174 @@
+// Package nid parses and formats lists of node IDs (NIDs) such as
+// "1-64,100,200-203".
+package nid
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxNIDs is the maximum number of NIDs that Parse expands a list into. It
+// guards against typos such as "1-10000000" allocating huge lists.
+const MaxNIDs = 1 << 20
+
+// Parse takes a comma-separated list of NIDs and inclusive NID ranges (e.g.
+// "1-64,100,200-203") and returns the NIDs it contains, sorted and without
+// duplicates. Whitespace around items is ignored. An error is returned if an
+// item is not an integer from 0 to MaxNID or a range of them, if a range's
+// start is greater than its end, or if the list contains more than MaxNIDs
+// NIDs.
+func Parse(s string) ([]int64, error) {
+	seen := make(map[int64]bool)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return nil, fmt.Errorf("empty item in NID list %q", s)
+		}
+		startStr, endStr, isRange := strings.Cut(item, "-")
+		start, err := parseOne(startStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NID %q: %w", item, err)
+		}
+		end := start
+		if isRange {
+			if end, err = parseOne(endStr); err != nil {
+				return nil, fmt.Errorf("invalid NID range %q: %w", item, err)
+			}
+			if start > end {
+				return nil, fmt.Errorf("invalid NID range %q: start is greater than end", item)
+			}
+		}
+		if end-start >= MaxNIDs || len(seen)+int(end-start+1) > MaxNIDs {
+			return nil, fmt.Errorf("NID list %q contains more than %d NIDs", s, MaxNIDs)
+		}
+		for n := start; n <= end; n++ {
+			seen[n] = true
+		}
+	}
+
+	nids := make([]int64, 0, len(seen))
+	for n := range seen {
+		nids = append(nids, n)
+	}
+	sort.Slice(nids, func(i, j int) bool { return nids[i] < nids[j] })
+
+	return nids, nil
+}
+
+// parseOne parses a single NID from 0 to MaxNID.
+func parseOne(s string) (int64, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("not an integer")
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("NIDs cannot be negative")
+	}
+	if n > MaxNID {
+		return 0, fmt.Errorf("NIDs cannot be greater than %d", MaxNID)
+	}
+
+	return n, nil
+}
+
+// Ranges is the inverse of Parse. It takes a list of NIDs and returns them as
+// a sorted list of ranges, collapsing consecutive NIDs (e.g. 1, 2, 3, and 5
+// become "1-3" and "5"). nids is not modified.
+func Ranges(nids []int64) []string {
+	if len(nids) == 0 {
+		return nil
+	}
+	sorted := append([]int64{}, nids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var ranges []string
+	start, prev := sorted[0], sorted[0]
+	flush := func() {
+		if start == prev {
+			ranges = append(ranges, strconv.FormatInt(start, 10))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", start, prev))
+		}
+	}
+	for _, n := range sorted[1:] {
+		if n == prev {
+			continue
+		}
+		if n != prev+1 {
+			flush()
+			start = n
+		}
+		prev = n
+	}
+	flush()
+
+	return ranges
+}
+
+// Format returns the NIDs in nids as a comma-separated list of ranges that
+// Parse accepts.
+func Format(nids []int64) string {
+	return strings.Join(Ranges(nids), ",")
+}
+
+// Batch splits nids into consecutive batches of at most size NIDs each, e.g.
+// so that they can be resolved with several requests of bounded size.
+func Batch(nids []int64, size int) [][]int64 {
+	if size <= 0 {
+		size = len(nids)
+	}
+	var batches [][]int64
+	for len(nids) > 0 {
+		n := size
+		if n > len(nids) {
+			n = len(nids)
+		}
+		batches = append(batches, nids[:n])
+		nids = nids[n:]
+	}
+
+	return batches
+}
+
+// MaxNID is the largest NID, as SMD stores NIDs as 32-bit integers.
+const MaxNID = 1<<31 - 1
+
+// Free returns the count lowest NIDs of at least start that are not in used,
+// in ascending order. An error is returned if there are not enough of them
+// below MaxNID.
+func Free(start int64, count int, used map[int64]bool) ([]int64, error) {
+	free := make([]int64, 0, count)
+	for n := start; len(free) < count; n++ {
+		if n > MaxNID {
+			return nil, fmt.Errorf("not enough free NIDs from %d (%d needed, %d free)", start, count, len(free))
+		}
+		if !used[n] {
+			free = append(free, n)
+		}
+	}
+
+	return free, nil
+}
+
+// FreeBlock returns the count consecutive NIDs of at least start, none of which
+// is in used, that start lowest. An error is returned if there is no such
+// block below MaxNID.
+func FreeBlock(start int64, count int, used map[int64]bool) ([]int64, error) {
+	first := start
+	for n := start; n-first < int64(count); n++ {
+		if n > MaxNID {
+			return nil, fmt.Errorf("no %d consecutive free NIDs from %d", count, start)
+		}
+		if used[n] {
+			first = n + 1
+		}
+	}
+	block := make([]int64, count)
+	for i := range block {
+		block[i] = first + int64(i)
+	}
+
+	return block, nil
+}
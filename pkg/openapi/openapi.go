@@ -0,0 +1,281 @@
+// Package openapi compares the OpenAPI (or Swagger 2.0) specification served
+// by an OpenCHAMI service against what ochami knows about that service, in
+// order to spot drift after the service is upgraded.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Endpoint is an HTTP method and a path relative to a service's base path.
+// Path parameters are written as "{}" regardless of their name, so that paths
+// from different sources can be compared.
+type Endpoint struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+func (e Endpoint) String() string {
+	return e.Method + " " + e.Path
+}
+
+// Known describes what ochami knows about a service's API: the base path its
+// client uses, the endpoints it sends requests to, and the Go types it reads
+// and writes for each named schema in the service's spec.
+type Known struct {
+	BasePath  string
+	Endpoints []Endpoint
+	Schemas   map[string]any
+}
+
+// Report is the result of Diff. UnknownEndpoints are in the live spec but are
+// not used by ochami, MissingEndpoints are used by ochami but are not in the
+// live spec, UnknownFields maps schema names to properties in the live spec
+// that the corresponding Go type lacks, and MissingSchemas are schemas known
+// to ochami that are not in the live spec (e.g. because they were renamed).
+type Report struct {
+	UnknownEndpoints []Endpoint          `json:"unknownEndpoints"`
+	MissingEndpoints []Endpoint          `json:"missingEndpoints"`
+	UnknownFields    map[string][]string `json:"unknownFields"`
+	MissingSchemas   []string            `json:"missingSchemas"`
+}
+
+// Empty reports whether r contains no differences.
+func (r Report) Empty() bool {
+	return len(r.UnknownEndpoints) == 0 && len(r.MissingEndpoints) == 0 &&
+		len(r.UnknownFields) == 0 && len(r.MissingSchemas) == 0
+}
+
+// spec is the subset of an OpenAPI 3 or Swagger 2.0 document needed by Diff.
+type spec struct {
+	Swagger  string                                `json:"swagger"`
+	OpenAPI  string                                `json:"openapi"`
+	BasePath string                                `json:"basePath"`
+	Servers  []struct{ URL string }                `json:"servers"`
+	Paths    map[string]map[string]json.RawMessage `json:"paths"`
+
+	Definitions map[string]*schema `json:"definitions"`
+	Components  struct {
+		Schemas map[string]*schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type schema struct {
+	Ref        string             `json:"$ref"`
+	Properties map[string]*schema `json:"properties"`
+	AllOf      []*schema          `json:"allOf"`
+}
+
+// methods are the keys of a path item that are operations, as opposed to
+// shared parameters, summaries, etc.
+var methods = []string{
+	http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+	http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace,
+}
+
+var paramRe = regexp.MustCompile(`\{[^}]*\}`)
+
+// NormalizePath replaces the path parameters in p with "{}" and removes any
+// trailing slash.
+func NormalizePath(p string) string {
+	p = paramRe.ReplaceAllString(p, "{}")
+	if len(p) > 1 {
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p
+}
+
+// parse parses data as an OpenAPI 3 or Swagger 2.0 document in either JSON or
+// YAML.
+func parse(data []byte) (*spec, error) {
+	var s spec
+	if err := json.Unmarshal(data, &s); err != nil {
+		var y any
+		if yerr := yaml.Unmarshal(data, &y); yerr != nil {
+			return nil, fmt.Errorf("spec is neither valid JSON (%v) nor valid YAML (%w)", err, yerr)
+		}
+		jBytes, jerr := json.Marshal(y)
+		if jerr != nil {
+			return nil, fmt.Errorf("failed to convert YAML spec to JSON: %w", jerr)
+		}
+		if err := json.Unmarshal(jBytes, &s); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal spec: %w", err)
+		}
+	}
+	if s.Swagger == "" && s.OpenAPI == "" {
+		return nil, fmt.Errorf("document is not an OpenAPI or Swagger spec (missing 'openapi' or 'swagger' field)")
+	}
+	return &s, nil
+}
+
+// Diff parses the spec in data and compares it against known.
+func Diff(data []byte, known Known) (Report, error) {
+	s, err := parse(data)
+	if err != nil {
+		return Report{}, err
+	}
+	r := Report{
+		UnknownEndpoints: []Endpoint{},
+		MissingEndpoints: []Endpoint{},
+		UnknownFields:    map[string][]string{},
+		MissingSchemas:   []string{},
+	}
+
+	// Compare endpoints
+	live := s.endpoints(known.BasePath)
+	knownSet := map[Endpoint]bool{}
+	for _, e := range known.Endpoints {
+		e.Path = NormalizePath(e.Path)
+		knownSet[e] = true
+	}
+	for e := range live {
+		if !knownSet[e] {
+			r.UnknownEndpoints = append(r.UnknownEndpoints, e)
+		}
+	}
+	for e := range knownSet {
+		if !live[e] {
+			r.MissingEndpoints = append(r.MissingEndpoints, e)
+		}
+	}
+	sortEndpoints(r.UnknownEndpoints)
+	sortEndpoints(r.MissingEndpoints)
+
+	// Compare fields of known schemas
+	schemas := s.Definitions
+	if len(s.Components.Schemas) > 0 {
+		schemas = s.Components.Schemas
+	}
+	for name, v := range known.Schemas {
+		sch, ok := schemas[name]
+		if !ok {
+			r.MissingSchemas = append(r.MissingSchemas, name)
+			continue
+		}
+		fields := jsonFields(reflect.TypeOf(v))
+		var unknown []string
+		for _, p := range properties(sch, schemas, 0) {
+			if !fields[strings.ToLower(p)] {
+				unknown = append(unknown, p)
+			}
+		}
+		if len(unknown) > 0 {
+			slices.Sort(unknown)
+			r.UnknownFields[name] = slices.Compact(unknown)
+		}
+	}
+	slices.Sort(r.MissingSchemas)
+
+	return r, nil
+}
+
+// endpoints returns the set of endpoints in s relative to basePath. Paths in s
+// are first made absolute using the spec's own base path (basePath for Swagger
+// 2.0, the path of the first server for OpenAPI 3) so that specs that include
+// the service prefix in their paths and those that do not compare the same.
+func (s *spec) endpoints(basePath string) map[Endpoint]bool {
+	prefix := s.BasePath
+	if len(s.Servers) > 0 {
+		prefix = s.Servers[0].URL
+		if i := strings.Index(prefix, "://"); i >= 0 {
+			prefix = prefix[i+3:]
+			if j := strings.Index(prefix, "/"); j >= 0 {
+				prefix = prefix[j:]
+			} else {
+				prefix = ""
+			}
+		}
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	basePath = strings.TrimSuffix(basePath, "/")
+
+	eps := map[Endpoint]bool{}
+	for p, item := range s.Paths {
+		full := NormalizePath(prefix + "/" + strings.TrimPrefix(p, "/"))
+		if basePath != "" && (full == basePath || strings.HasPrefix(full, basePath+"/")) {
+			full = strings.TrimPrefix(full, basePath)
+			if full == "" {
+				full = "/"
+			}
+		}
+		for key := range item {
+			m := strings.ToUpper(key)
+			if slices.Contains(methods, m) {
+				eps[Endpoint{Method: m, Path: full}] = true
+			}
+		}
+	}
+	return eps
+}
+
+// properties returns the names of the properties of sch, following $ref and
+// allOf into the schemas in all.
+func properties(sch *schema, all map[string]*schema, depth int) []string {
+	if sch == nil || depth > 10 {
+		return nil
+	}
+	if sch.Ref != "" {
+		name := sch.Ref[strings.LastIndex(sch.Ref, "/")+1:]
+		return properties(all[name], all, depth+1)
+	}
+	var props []string
+	for p := range sch.Properties {
+		props = append(props, p)
+	}
+	for _, sub := range sch.AllOf {
+		props = append(props, properties(sub, all, depth+1)...)
+	}
+	return props
+}
+
+// jsonFields returns the set of JSON field names of struct type t, including
+// those of embedded structs. Names are lower-cased since encoding/json matches
+// them case-insensitively.
+func jsonFields(t reflect.Type) map[string]bool {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	fields := map[string]bool{}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			for n := range jsonFields(f.Type) {
+				fields[n] = true
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = true
+	}
+	return fields
+}
+
+func sortEndpoints(eps []Endpoint) {
+	slices.SortFunc(eps, func(a, b Endpoint) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+}
@@ -0,0 +1,203 @@
+// Package redfish is a minimal client for probing BMCs over Redfish. It only
+// reads what ochami needs from a BMC, such as whether it serves Redfish at all
+// and the live power state and health of its systems, and is not meant to be
+// a general-purpose Redfish client.
+package redfish
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the timeout of each request sent by a Client created with
+// a timeout of 0. BMCs can be slow to respond, but a single unresponsive BMC
+// should not hold up probing the others for long.
+const DefaultTimeout = 10 * time.Second
+
+// ServiceRootPath is the path of the Redfish service root.
+const ServiceRootPath = "/redfish/v1/"
+
+// Client sends Redfish requests to BMCs using HTTP basic authentication.
+type Client struct {
+	Username string
+	Password string
+
+	// Scheme is used for URIs that do not have one, e.g. the RedfishURL of
+	// SMD's ComponentEndpoints. It is https if empty.
+	Scheme string
+
+	// Port is used for URIs that do not have a scheme and whose host does
+	// not include a port. The scheme's default port is used if it is 0.
+	Port int
+
+	HTTPClient *http.Client
+}
+
+// Link is a reference to another Redfish resource.
+type Link struct {
+	ODataID string `json:"@odata.id"`
+}
+
+// Status is the status of a Redfish resource.
+type Status struct {
+	State  string `json:"State,omitempty"`
+	Health string `json:"Health,omitempty"`
+}
+
+// ServiceRoot is the part of a Redfish service root that is used to identify
+// a BMC.
+type ServiceRoot struct {
+	ID             string `json:"Id"`
+	Name           string `json:"Name"`
+	RedfishVersion string `json:"RedfishVersion"`
+	UUID           string `json:"UUID"`
+	Systems        Link   `json:"Systems"`
+	Managers       Link   `json:"Managers"`
+}
+
+// System is the part of a Redfish ComputerSystem that describes its current
+// state.
+type System struct {
+	ID         string `json:"Id"`
+	Name       string `json:"Name"`
+	PowerState string `json:"PowerState"`
+	Status     Status `json:"Status"`
+}
+
+// NewClient returns a Client that authenticates with username and password.
+// If insecure is true, TLS certificates are not verified, which is common for
+// BMCs with self-signed certificates. Each request times out after timeout, or
+// DefaultTimeout if it is 0.
+func NewClient(username, password string, insecure bool, timeout time.Duration) *Client {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = &tls.Config{InsecureSkipVerify: insecure}
+	return &Client{
+		Username: username,
+		Password: password,
+		HTTPClient: &http.Client{
+			Transport: t,
+			Timeout:   timeout,
+		},
+	}
+}
+
+// URL returns the URL for uri. If uri has no scheme, c.Scheme and c.Port are
+// applied to it, so that both full URLs and the scheme-less host and path of
+// SMD's ComponentEndpoints (e.g. x3000c1s7b56/redfish/v1/Systems/Node0) can be
+// passed.
+func (c *Client) URL(uri string) (string, error) {
+	if !strings.Contains(uri, "://") {
+		scheme := c.Scheme
+		if scheme == "" {
+			scheme = "https"
+		}
+		uri = scheme + "://" + uri
+		u, err := url.Parse(uri)
+		if err != nil {
+			return "", fmt.Errorf("invalid Redfish URI %q: %w", uri, err)
+		}
+		if c.Port != 0 && u.Port() == "" {
+			u.Host = fmt.Sprintf("%s:%d", u.Host, c.Port)
+		}
+		return u.String(), nil
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid Redfish URI %q: %w", uri, err)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid Redfish URI %q: no host", uri)
+	}
+	return u.String(), nil
+}
+
+// Get sends a GET to uri (see URL) and unmarshals the JSON response into v.
+// An error is returned if the request fails or the response status is not
+// 2XX.
+func (c *Client) Get(ctx context.Context, uri string, v any) error {
+	u, err := c.URL(uri)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %w", u, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		// The error already includes the URL
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response from %s: %w", u, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("request to %s returned %s", u, resp.Status)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response from %s: %w", u, err)
+	}
+	return nil
+}
+
+// ServiceRoot returns the Redfish service root of the BMC at host, which may
+// include a scheme and port. It is used to check that a BMC serves Redfish
+// before reading anything else from it.
+func (c *Client) ServiceRoot(ctx context.Context, host string) (ServiceRoot, error) {
+	var root ServiceRoot
+	err := c.Get(ctx, strings.TrimSuffix(host, "/")+ServiceRootPath, &root)
+	return root, err
+}
+
+// System returns the ComputerSystem at uri, e.g. the RedfishURL of an SMD
+// ComponentEndpoint.
+func (c *Client) System(ctx context.Context, uri string) (System, error) {
+	var sys System
+	err := c.Get(ctx, uri, &sys)
+	return sys, err
+}
+
+// Systems calls System for each of uris, with up to concurrency requests in
+// flight at once. The returned slices have the same length as uris, and the
+// System and error at an index belong to the URI at the same index.
+func (c *Client) Systems(ctx context.Context, uris []string, concurrency int) ([]System, []error) {
+	systems := make([]System, len(uris))
+	errs := make([]error, len(uris))
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Each call writes only to its own index, so no locking is needed for
+	// the results
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, uri := range uris {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, uri string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			systems[i], errs[i] = c.System(ctx, uri)
+		}(i, uri)
+	}
+	wg.Wait()
+
+	return systems, errs
+}
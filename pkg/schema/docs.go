@@ -0,0 +1,129 @@
+package schema
+
+// fieldDoc documents a property of a payload type. Example is the value used
+// for the property by Example and is encoded like the payload itself.
+type fieldDoc struct {
+	Description string
+	Example     any
+}
+
+// fieldDocs documents the properties of the types in Resources, most of which
+// are defined in other modules and carry no documentation of their own. Keys
+// are of the form "<Go type name>.<JSON property name>", matching the names
+// that the reflected schemas use for their definitions.
+var fieldDocs = map[string]fieldDoc{
+	// SMD components
+	"ComponentSlice.Components":               {Description: "components to add, update, or delete"},
+	"Component.ID":                            {Description: "xname of the component", Example: "x1000c1s7b0n0"},
+	"Component.Type":                          {Description: "HMS type, e.g. Node, NodeBMC, or ChassisBMC", Example: "Node"},
+	"Component.Subtype":                       {Description: "subtype of the component, if any"},
+	"Component.Role":                          {Description: "role of the component, e.g. Compute, Service, or Management", Example: "Compute"},
+	"Component.SubRole":                       {Description: "subrole of the component, e.g. Worker or UAN"},
+	"Component.NetType":                       {Description: "type of high speed network, e.g. Sling or Infiniband"},
+	"Component.Arch":                          {Description: "architecture, e.g. X86 or ARM", Example: "X86"},
+	"Component.Class":                         {Description: "hardware class, e.g. River or Mountain", Example: "River"},
+	"Component.State":                         {Description: "state, e.g. Ready, On, Off, Standby, or Empty", Example: "Ready"},
+	"Component.Flag":                          {Description: "flag qualifying the state, e.g. OK, Warning, Alert, or Locked", Example: "OK"},
+	"Component.Enabled":                       {Description: "whether the component is enabled", Example: true},
+	"Component.SoftwareStatus":                {Description: "free-form status set by software running on the component"},
+	"Component.NID":                           {Description: "node ID (nodes only)", Example: 1},
+	"Component.ReservationDisabled":           {Description: "whether reservations of the component are disabled"},
+	"Component.Locked":                        {Description: "whether the component is locked"},
+	"Group.label":                             {Description: "name of the group", Example: "compute"},
+	"Group.description":                       {Description: "description of the group", Example: "Compute nodes"},
+	"Group.tags":                              {Description: "free-form tags", Example: []string{"tag1"}},
+	"Group.exclusiveGroup":                    {Description: "exclusive group; components can only be in one group of an exclusive group; cannot be changed later"},
+	"Group.members":                           {Description: "members of the group", Example: map[string][]string{"ids": {"x1000c1s7b0n0"}}},
+	"EthernetInterface.ID":                    {Description: "ID of the interface, normally its MAC address without colons", Example: "decafc0feeee"},
+	"EthernetInterface.ComponentID":           {Description: "xname of the component the interface belongs to", Example: "x1000c1s7b0n0"},
+	"EthernetInterface.Type":                  {Description: "HMS type of the component", Example: "Node"},
+	"EthernetInterface.Description":           {Description: "description of the interface"},
+	"EthernetInterface.MACAddress":            {Description: "MAC address of the interface", Example: "de:ca:fc:0f:ee:ee"},
+	"EthernetInterface.IPAddresses":           {Description: "IP addresses assigned to the interface"},
+	"EthernetIP.IPAddress":                    {Description: "IP address", Example: "172.16.0.1"},
+	"EthernetIP.Network":                      {Description: "name of the network the address is on", Example: "internal"},
+	"RedfishEndpointSliceV2.RedfishEndpoints": {Description: "redfish endpoints (BMCs) to add"},
+	"RedfishEndpointV2.ID":                    {Description: "xname of the BMC", Example: "x1000c1s7b0"},
+	"RedfishEndpointV2.Type":                  {Description: "HMS type of the BMC", Example: "NodeBMC"},
+	"RedfishEndpointV2.Name":                  {Description: "name of the endpoint"},
+	"RedfishEndpointV2.Hostname":              {Description: "host name of the BMC"},
+	"RedfishEndpointV2.Domain":                {Description: "domain of the BMC"},
+	"RedfishEndpointV2.FQDN":                  {Description: "fully qualified domain name of the BMC, or its IP address", Example: "172.16.0.101"},
+	"RedfishEndpointV2.Enabled":               {Description: "whether the endpoint is enabled", Example: true},
+	"RedfishEndpointV2.UUID":                  {Description: "UUID of the BMC's service root"},
+	"RedfishEndpointV2.User":                  {Description: "user name to access the BMC with", Example: "root"},
+	"RedfishEndpointV2.Password":              {Description: "password to access the BMC with"},
+	"RedfishEndpointV2.UseSSDP":               {Description: "whether the BMC was found with SSDP"},
+	"RedfishEndpointV2.MacRequired":           {Description: "whether the MAC address is required"},
+	"RedfishEndpointV2.MACAddr":               {Description: "MAC address of the BMC", Example: "de:ca:fc:0f:ee:ee"},
+	"RedfishEndpointV2.IPAddress":             {Description: "IP address of the BMC", Example: "172.16.0.101"},
+	"RedfishEndpointV2.RediscoverOnUpdate":    {Description: "whether to rediscover the BMC when the endpoint is updated"},
+	"RedfishEndpointV2.TemplateID":            {Description: "ID of the template the endpoint was created from"},
+	"RedfishEndpointV2.DiscoveryInfo":         {Description: "result of the last discovery of the BMC"},
+	"RedfishEndpointV2.SchemaVersion":         {Description: "version of the redfish endpoint schema", Example: 1},
+	"RedfishEndpointV2.URI":                   {Description: "URI of the BMC's service root"},
+	"RedfishEndpointV2.Systems":               {Description: "systems (nodes) managed by the BMC"},
+	"RedfishEndpointV2.Managers":              {Description: "managers of the BMC"},
+	"System.uri":                              {Description: "URI of the system resource", Example: "/redfish/v1/Systems/Node0"},
+	"System.name":                             {Description: "name of the system", Example: "Node0"},
+	"System.uuid":                             {Description: "UUID of the system"},
+	"System.ethernet_interfaces":              {Description: "ethernet interfaces of the system"},
+	"Manager.uri":                             {Description: "URI of the manager resource", Example: "/redfish/v1/Managers/BMC"},
+	"Manager.name":                            {Description: "name of the manager", Example: "BMC"},
+	"Manager.uuid":                            {Description: "UUID of the manager"},
+	"Manager.type":                            {Description: "type of the manager, e.g. BMC", Example: "BMC"},
+	"Manager.description":                     {Description: "description of the manager"},
+	"Manager.ethernet_interfaces":             {Description: "ethernet interfaces of the manager"},
+	"EthernetInterface.uri":                   {Description: "URI of the interface resource"},
+	"EthernetInterface.mac":                   {Description: "MAC address of the interface", Example: "de:ad:be:ee:ee:f1"},
+	"EthernetInterface.ip":                    {Description: "IP address of the interface", Example: "172.16.0.1"},
+	"EthernetInterface.name":                  {Description: "name of the interface"},
+	"EthernetInterface.description":           {Description: "description of the interface"},
+	"EthernetInterface.enabled":               {Description: "whether the interface is enabled", Example: true},
+	"DiscoveryInfo.LastAttempt":               {Description: "time of the last discovery attempt"},
+	"DiscoveryInfo.LastStatus":                {Description: "status of the last discovery attempt", Example: "DiscoverOK"},
+	"DiscoveryInfo.RedfishVersion":            {Description: "redfish version of the BMC"},
+
+	// BSS boot parameters
+	"BootParams.hosts":      {Description: "xnames of the nodes to boot with these parameters", Example: []string{"x1000c1s7b0n0"}},
+	"BootParams.macs":       {Description: "MAC addresses of the nodes to boot with these parameters"},
+	"BootParams.nids":       {Description: "node IDs of the nodes to boot with these parameters"},
+	"BootParams.params":     {Description: "kernel command line", Example: "console=ttyS0,115200 root=live:http://172.16.0.254/image.squashfs"},
+	"BootParams.kernel":     {Description: "URL of the kernel", Example: "http://172.16.0.254/boot/vmlinuz"},
+	"BootParams.initrd":     {Description: "URL of the initrd", Example: "http://172.16.0.254/boot/initramfs.img"},
+	"BootParams.cloud-init": {Description: "cloud-init data served by BSS (deprecated in favor of the cloud-init service)"},
+
+	// Discovery
+	"NodeList.nodes":            {Description: "nodes to add to SMD and BSS"},
+	"NodeList.groups":           {Description: "groups to create for the nodes' group keys, with their cloud-init configs"},
+	"Node.name":                 {Description: "short name of the node", Example: "node01"},
+	"Node.nid":                  {Description: "node ID", Example: 1},
+	"Node.xname":                {Description: "xname of the node", Example: "x1000c1s7b0n0"},
+	"Node.group":                {Description: "group to add the node to", Example: "compute"},
+	"Node.groups":               {Description: "more groups to add the node to", Example: []string{"slurm", "rack1"}},
+	"Node.bmc_mac":              {Description: "MAC address of the node's BMC", Example: "de:ca:fc:0f:ee:ee"},
+	"Node.bmc_ip":               {Description: "IP address of the node's BMC", Example: "172.16.0.101"},
+	"Node.interfaces":           {Description: "ethernet interfaces of the node"},
+	"Node.boot":                 {Description: "boot parameters to add to BSS for the node"},
+	"Node.cloud_init":           {Description: "cloud-init data for the node"},
+	"Iface.mac_addr":            {Description: "MAC address of the interface", Example: "de:ad:be:ee:ee:f1"},
+	"Iface.ip_addrs":            {Description: "IP addresses of the interface"},
+	"IfaceIP.network":           {Description: "name of the network the address is on", Example: "internal"},
+	"IfaceIP.ip_addr":           {Description: "IP address", Example: "172.16.0.1"},
+	"Boot.kernel":               {Description: "URL of the kernel", Example: "http://172.16.0.254/boot/vmlinuz"},
+	"Boot.initrd":               {Description: "URL of the initrd", Example: "http://172.16.0.254/boot/initramfs.img"},
+	"Boot.params":               {Description: "kernel command line", Example: "console=ttyS0,115200 root=live:http://172.16.0.254/image.squashfs"},
+	"GroupConfig.name":          {Description: "name of the group", Example: "compute"},
+	"GroupConfig.description":   {Description: "description of the group", Example: "Compute nodes"},
+	"GroupConfig.cloud_init":    {Description: "cloud-init data for the group's nodes"},
+	"CloudInitData.user_data":   {Description: "cloud-init user data", Example: map[string]any{"write_files": []map[string]string{{"path": "/etc/motd", "content": "Welcome to a compute node"}}}},
+	"CloudInitData.meta_data":   {Description: "cloud-init meta data", Example: map[string]string{"hostname": "node01"}},
+	"CloudInitData.vendor_data": {Description: "cloud-init vendor data"},
+
+	// cloud-init configs
+	"CI.name":           {Description: "name of the config: an xname, MAC address, or group name", Example: "compute"},
+	"CI.cloud-init":     {Description: "cloud-init data served for the name"},
+	"CIData.userdata":   {Description: "cloud-init user data", Example: map[string]any{"write_files": []map[string]string{{"path": "/etc/motd", "content": "Welcome to a compute node"}}}},
+	"CIData.metadata":   {Description: "cloud-init meta data", Example: map[string]string{"hostname": "node01"}},
+	"CIData.vendordata": {Description: "cloud-init vendor data"},
+}
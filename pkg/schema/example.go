@@ -0,0 +1,228 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// maxExampleDepth limits how deeply Example descends into nested types so that
+// recursive types terminate.
+const maxExampleDepth = 12
+
+// Example returns an example payload for the resource called name in format,
+// which is either "yaml" or "json". The payload is generated from the
+// resource's schema (see Generate) and contains every property, set to an
+// example value from fieldDocs if there is one and to an empty value of its
+// type otherwise. Lists contain a single item. In YAML, each property is
+// preceded by a comment describing it, its type, and whether it is required.
+// JSON has no comments, so the JSON payload holds only the values.
+func Example(name, format string) ([]byte, error) {
+	s, err := Generate(name)
+	if err != nil {
+		return nil, fmt.Errorf("Example(): %w", err)
+	}
+	eg := exampleGenerator{defs: s.Definitions}
+	node := eg.node(s, "", 0)
+	node.HeadComment = s.Description
+
+	switch strings.ToLower(format) {
+	case "yaml":
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(node); err != nil {
+			return nil, fmt.Errorf("Example(): failed to encode example as YAML: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return nil, fmt.Errorf("Example(): failed to encode example as YAML: %w", err)
+		}
+		return buf.Bytes(), nil
+	case "json":
+		var buf bytes.Buffer
+		if err := writeJSON(&buf, node); err != nil {
+			return nil, fmt.Errorf("Example(): failed to encode example as JSON: %w", err)
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+			return nil, fmt.Errorf("Example(): failed to encode example as JSON: %w", err)
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), nil
+	}
+
+	return nil, fmt.Errorf("Example(): unknown format %q (must be yaml or json)", format)
+}
+
+// exampleGenerator generates example payloads as YAML nodes from a schema
+// with definitions defs.
+type exampleGenerator struct {
+	defs jsonschema.Definitions
+}
+
+// resolve returns the schema that s refers to, if any, along with the name of
+// its definition, or s itself and defName if it is not a reference.
+func (eg exampleGenerator) resolve(s *jsonschema.Schema, defName string) (*jsonschema.Schema, string) {
+	if name, ok := strings.CutPrefix(s.Ref, "#/$defs/"); ok {
+		if def, ok := eg.defs[name]; ok {
+			return def, name
+		}
+	}
+	return s, defName
+}
+
+// node returns an example of s, which is the definition named defName, if
+// any, as a YAML node.
+func (eg exampleGenerator) node(s *jsonschema.Schema, defName string, depth int) *yaml.Node {
+	s, defName = eg.resolve(s, defName)
+	if depth > maxExampleDepth {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+
+	switch {
+	case s.Type == "array" && s.Items != nil:
+		return &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{eg.node(s.Items, "", depth+1)}}
+	case s.Type == "object" && s.Properties != nil && s.Properties.Len() > 0:
+		required := make(map[string]bool, len(s.Required))
+		for _, r := range s.Required {
+			required[r] = true
+		}
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			prop, propSchema := pair.Key, pair.Value
+			doc := fieldDocs[defName+"."+prop]
+			key := &yaml.Node{
+				Kind:        yaml.ScalarNode,
+				Tag:         "!!str",
+				Value:       prop,
+				HeadComment: eg.comment(propSchema, doc, required[prop]),
+			}
+			var value *yaml.Node
+			if doc.Example != nil {
+				value = &yaml.Node{}
+				if err := value.Encode(doc.Example); err != nil {
+					value = eg.node(propSchema, "", depth+1)
+				}
+			} else {
+				value = eg.node(propSchema, "", depth+1)
+			}
+			n.Content = append(n.Content, key, value)
+		}
+		return n
+	case s.Type == "object":
+		return &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle}
+	case len(s.Enum) > 0:
+		n := &yaml.Node{}
+		if err := n.Encode(s.Enum[0]); err == nil {
+			return n
+		}
+	}
+
+	switch s.Type {
+	case "integer", "number":
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "0"}
+	case "boolean":
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"}
+	case "string":
+		// Some formats cannot be left empty
+		value := ""
+		switch s.Format {
+		case "uuid":
+			value = "00000000-0000-0000-0000-000000000000"
+		case "date-time":
+			value = "1970-01-01T00:00:00Z"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: yaml.DoubleQuotedStyle}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
+
+// comment returns the comment describing a property with schema s, e.g.
+// "xname of the component (string, required)".
+func (eg exampleGenerator) comment(s *jsonschema.Schema, doc fieldDoc, required bool) string {
+	typ := eg.typeName(s)
+	if required {
+		typ += ", required"
+	}
+	desc := doc.Description
+	if desc == "" {
+		desc = s.Description
+	}
+	if desc == "" {
+		return typ
+	}
+	return fmt.Sprintf("%s (%s)", desc, typ)
+}
+
+// typeName returns a short name for the type of s, e.g. "list of string".
+func (eg exampleGenerator) typeName(s *jsonschema.Schema) string {
+	s, _ = eg.resolve(s, "")
+	switch {
+	case s.Type == "array" && s.Items != nil:
+		return "list of " + eg.typeName(s.Items)
+	case s.Type == "object" && (s.Properties == nil || s.Properties.Len() == 0):
+		return "map"
+	case s.Format != "":
+		return s.Format
+	case s.Type != "":
+		return s.Type
+	}
+	return "any"
+}
+
+// writeJSON writes n as JSON to buf, keeping the order of mapping keys.
+func writeJSON(buf *bytes.Buffer, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeJSON(buf, n.Content[0])
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, err := json.Marshal(n.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(k)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, c := range n.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, c); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case yaml.ScalarNode:
+		var v any
+		if err := n.Decode(&v); err != nil {
+			return err
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	default:
+		return fmt.Errorf("unsupported YAML node kind %v", n.Kind)
+	}
+	return nil
+}
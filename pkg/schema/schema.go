@@ -0,0 +1,118 @@
+// Package schema generates JSON Schemas for the payloads that ochami accepts
+// so that payload files can be validated before being sent.
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/OpenCHAMI/bss/pkg/bssTypes"
+	"github.com/OpenCHAMI/cloud-init/pkg/citypes"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+	"github.com/OpenCHAMI/ochami/pkg/discover"
+	"github.com/google/uuid"
+	"github.com/invopop/jsonschema"
+)
+
+// Resource represents a payload type that a schema can be generated for.
+// Value is a zero value of the Go type that payloads of the resource are
+// unmarshalled into.
+type Resource struct {
+	Description string
+	Value       any
+}
+
+// Resources maps the name of each resource to its Resource.
+var Resources = map[string]Resource{
+	"bootparams": {
+		Description: "BSS boot parameters (bss boot params add/set/update/delete)",
+		Value:       bssTypes.BootParams{},
+	},
+	"cloud-init-config": {
+		Description: "list of cloud-init configs (cloud-init config add/update)",
+		Value:       []citypes.CI{},
+	},
+	"component": {
+		Description: "SMD components (smd component add/update/delete)",
+		Value:       smd.ComponentSlice{},
+	},
+	"discovery-items": {
+		Description: "node list for discovery (discover)",
+		Value:       discover.NodeList{},
+	},
+	"group": {
+		Description: "list of SMD groups (smd group add/update)",
+		Value:       []smd.Group{},
+	},
+	"iface": {
+		Description: "list of SMD ethernet interfaces (smd iface add/delete)",
+		Value:       []smd.EthernetInterface{},
+	},
+	"rfe": {
+		Description: "list of SMD redfish endpoints (smd rfe add)",
+		Value:       smd.RedfishEndpointSliceV2{}.RedfishEndpoints,
+	},
+	"rfe-v2": {
+		Description: "SMD redfish endpoints using the v2 schema (as generated by discover)",
+		Value:       smd.RedfishEndpointSliceV2{},
+	},
+}
+
+// Names returns the names of all resources in Resources, sorted.
+func Names() []string {
+	names := make([]string, 0, len(Resources))
+	for name := range Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Generate returns the JSON Schema for the resource called name, with
+// properties described by fieldDocs. Fields are only marked as required if
+// they are tagged with `jsonschema:"required"` since most fields of payloads
+// are optional. Properties not in the schema are not allowed so that
+// misspelled keys are caught.
+func Generate(name string) (*jsonschema.Schema, error) {
+	res, ok := Resources[name]
+	if !ok {
+		return nil, fmt.Errorf("Generate(): unknown resource %q", name)
+	}
+	r := jsonschema.Reflector{
+		RequiredFromJSONSchemaTags: true,
+		Mapper:                     mapType,
+	}
+	s := r.Reflect(res.Value)
+	s.Title = name
+	s.Description = res.Description
+	describe(s)
+
+	return s, nil
+}
+
+// describe sets the descriptions of the properties of the definitions in s
+// from fieldDocs, unless they already have one.
+func describe(s *jsonschema.Schema) {
+	for defName, def := range s.Definitions {
+		if def.Properties == nil {
+			continue
+		}
+		for pair := def.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			if doc, ok := fieldDocs[defName+"."+pair.Key]; ok && pair.Value.Description == "" {
+				pair.Value.Description = doc.Description
+			}
+		}
+	}
+}
+
+// mapType overrides the schema of types whose JSON representation differs
+// from their Go representation. It returns nil for all other types.
+func mapType(t reflect.Type) *jsonschema.Schema {
+	if t == reflect.TypeOf(uuid.UUID{}) {
+		return &jsonschema.Schema{Type: "string", Format: "uuid"}
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+// Package selector parses and evaluates selector expressions, which select
+// objects such as SMD components by their fields, in the style of Kubernetes
+// label selectors.
+//
+// A selector is a comma-separated list of requirements, all of which must be
+// met for an object to be selected. The following requirements are accepted:
+//
+//   - field=value (or field==value): the field equals value.
+//   - field!=value: the field does not equal value, or is absent.
+//   - field in (value,...): the field equals one of the values.
+//   - field notin (value,...): the field equals none of the values, or is
+//     absent.
+//   - field: the field is set, i.e. it is present and not empty, zero, or
+//     false.
+//   - !field: the field is not set.
+//
+// Field names and values are compared case-insensitively. Values may contain
+// the wildcards of path.Match (e.g. id=x3000c1s*), and numbers and booleans
+// are compared in their JSON form (e.g. nid=12, enabled=true).
+package selector
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Operator is the operator of a Requirement.
+type Operator string
+
+const (
+	Equals       Operator = "="
+	NotEquals    Operator = "!="
+	In           Operator = "in"
+	NotIn        Operator = "notin"
+	Exists       Operator = "exists"
+	DoesNotExist Operator = "!"
+)
+
+// Requirement is a single requirement of a Selector.
+type Requirement struct {
+	Field    string
+	Operator Operator
+	Values   []string
+}
+
+// Selector is a parsed selector expression. An object is selected if it meets
+// all of its requirements.
+type Selector struct {
+	Requirements []Requirement
+	expr         string
+}
+
+// String returns the expression that s was parsed from.
+func (s Selector) String() string {
+	return s.expr
+}
+
+// Parse parses expr (see the package documentation for the syntax). An error
+// is returned if expr is empty or malformed, or if a value is not a valid
+// pattern.
+func Parse(expr string) (Selector, error) {
+	sel := Selector{expr: strings.TrimSpace(expr)}
+	if sel.expr == "" {
+		return sel, fmt.Errorf("empty selector")
+	}
+	parts, err := splitRequirements(sel.expr)
+	if err != nil {
+		return sel, err
+	}
+	for _, p := range parts {
+		r, err := parseRequirement(p)
+		if err != nil {
+			return sel, fmt.Errorf("invalid requirement %q: %w", p, err)
+		}
+		sel.Requirements = append(sel.Requirements, r)
+	}
+	return sel, nil
+}
+
+// splitRequirements splits expr at commas that are not within parentheses.
+func splitRequirements(expr string) ([]string, error) {
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i, c := range expr {
+		switch c {
+		case '(':
+			depth++
+			if depth > 1 {
+				return nil, fmt.Errorf("nested parentheses in selector %q", expr)
+			}
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in selector %q", expr)
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(expr[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses in selector %q", expr)
+	}
+	parts = append(parts, strings.TrimSpace(expr[start:]))
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("empty requirement in selector %q", expr)
+		}
+	}
+	return parts, nil
+}
+
+// parseRequirement parses a single requirement.
+func parseRequirement(s string) (Requirement, error) {
+	var r Requirement
+	if field, values, ok := cutSetOperator(s, " notin "); ok {
+		r = Requirement{Field: field, Operator: NotIn, Values: values}
+	} else if field, values, ok := cutSetOperator(s, " in "); ok {
+		r = Requirement{Field: field, Operator: In, Values: values}
+	} else if field, value, ok := strings.Cut(s, "!="); ok {
+		r = Requirement{Field: field, Operator: NotEquals, Values: []string{value}}
+	} else if field, value, ok := strings.Cut(s, "=="); ok {
+		r = Requirement{Field: field, Operator: Equals, Values: []string{value}}
+	} else if field, value, ok := strings.Cut(s, "="); ok {
+		r = Requirement{Field: field, Operator: Equals, Values: []string{value}}
+	} else if field, ok := strings.CutPrefix(s, "!"); ok {
+		r = Requirement{Field: field, Operator: DoesNotExist}
+	} else {
+		r = Requirement{Field: s, Operator: Exists}
+	}
+
+	r.Field = strings.TrimSpace(r.Field)
+	if r.Field == "" {
+		return r, fmt.Errorf("missing field")
+	}
+	if strings.ContainsAny(r.Field, " ()=!") {
+		return r, fmt.Errorf("invalid field %q", r.Field)
+	}
+	for i, v := range r.Values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if _, err := path.Match(v, ""); err != nil {
+			return r, fmt.Errorf("invalid value %q: %w", v, err)
+		}
+		r.Values[i] = v
+	}
+	if (r.Operator == Equals || r.Operator == NotEquals) && r.Values[0] == "" {
+		return r, fmt.Errorf("missing value")
+	}
+	return r, nil
+}
+
+// cutSetOperator splits s, a requirement of the form "field op (value,...)",
+// into its field and values if it uses op.
+func cutSetOperator(s, op string) (string, []string, bool) {
+	i := strings.Index(strings.ToLower(s), op)
+	if i < 0 {
+		return "", nil, false
+	}
+	field := s[:i]
+	list := strings.TrimSpace(s[i+len(op):])
+	if !strings.HasPrefix(list, "(") || !strings.HasSuffix(list, ")") {
+		return "", nil, false
+	}
+	var values []string
+	for _, v := range strings.Split(list[1:len(list)-1], ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return "", nil, false
+	}
+	return field, values, true
+}
+
+// Matches reports whether obj, e.g. a component unmarshalled from JSON into a
+// map, meets all of the requirements of s. Fields are looked up in obj
+// case-insensitively.
+func (s Selector) Matches(obj map[string]any) bool {
+	for _, r := range s.Requirements {
+		if !r.matches(obj) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Requirement) matches(obj map[string]any) bool {
+	value, present, set := lookup(obj, r.Field)
+	switch r.Operator {
+	case Exists:
+		return set
+	case DoesNotExist:
+		return !set
+	case Equals, In:
+		return present && matchAny(r.Values, value)
+	case NotEquals, NotIn:
+		return !present || !matchAny(r.Values, value)
+	}
+	return false
+}
+
+// matchAny reports whether value matches any of patterns.
+func matchAny(patterns []string, value string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the value of field in obj, lowercased and in its JSON form,
+// whether it is present (and not null), and whether it is set, i.e. present
+// and not empty, zero, or false.
+func lookup(obj map[string]any, field string) (value string, present, set bool) {
+	for k, v := range obj {
+		if !strings.EqualFold(k, field) {
+			continue
+		}
+		switch t := v.(type) {
+		case nil:
+			return "", false, false
+		case string:
+			return strings.ToLower(t), true, t != ""
+		case bool:
+			return strconv.FormatBool(t), true, t
+		case float64:
+			return strconv.FormatFloat(t, 'f', -1, 64), true, t != 0
+		case int:
+			return strconv.Itoa(t), true, t != 0
+		case int64:
+			return strconv.FormatInt(t, 10), true, t != 0
+		default:
+			return strings.ToLower(fmt.Sprint(t)), true, true
+		}
+	}
+	return "", false, false
+}
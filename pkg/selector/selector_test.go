@@ -0,0 +1,147 @@
+package selector
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []Requirement
+	}{
+		{
+			expr: "type=Node",
+			want: []Requirement{{Field: "type", Operator: Equals, Values: []string{"node"}}},
+		},
+		{
+			expr: "type==Node, role != Compute",
+			want: []Requirement{
+				{Field: "type", Operator: Equals, Values: []string{"node"}},
+				{Field: "role", Operator: NotEquals, Values: []string{"compute"}},
+			},
+		},
+		{
+			expr: "role in (Compute, Application),subrole NOTIN (gpu)",
+			want: []Requirement{
+				{Field: "role", Operator: In, Values: []string{"compute", "application"}},
+				{Field: "subrole", Operator: NotIn, Values: []string{"gpu"}},
+			},
+		},
+		{
+			expr: "nid,!locked",
+			want: []Requirement{
+				{Field: "nid", Operator: Exists},
+				{Field: "locked", Operator: DoesNotExist},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			sel, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if len(sel.Requirements) != len(tt.want) {
+				t.Fatalf("got requirements %+v, want %+v", sel.Requirements, tt.want)
+			}
+			for i, r := range sel.Requirements {
+				w := tt.want[i]
+				if r.Field != w.Field || r.Operator != w.Operator || !slices.Equal(r.Values, w.Values) {
+					t.Errorf("got requirement %+v, want %+v", r, w)
+				}
+			}
+			if sel.String() != tt.expr {
+				t.Errorf("String() = %q, want %q", sel.String(), tt.expr)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"  ",
+		"type=Node,",
+		"=Node",
+		"type=",
+		"type!=",
+		"role in ((compute))",
+		"role in (compute",
+		"role) in (compute",
+		"id=x[1000",
+		"my field=x",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded", expr)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	comp := map[string]any{
+		"ID":      "x1000c1s7b0n0",
+		"Type":    "Node",
+		"Role":    "Compute",
+		"SubRole": "",
+		"NID":     float64(12),
+		"Enabled": true,
+		"Locked":  false,
+		"Flag":    nil,
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"type=node", true},
+		{"TYPE=NODE", true},
+		{"type=NodeBMC", false},
+		{"type!=NodeBMC", true},
+		{"type!=node", false},
+		{"nid=12", true},
+		{"nid=12.0", false},
+		{"enabled=true", true},
+		{"locked=false", true},
+		{"id=x1000c1s7*", true},
+		{"id=x1000c1s?b0n0", true},
+		{"id=x1000c1s[5-7]b0n0", true},
+		{"id=x1000c2*", false},
+		{"role in (compute,application)", true},
+		{"role in (application,storage)", false},
+		{"role in (comp*)", true},
+		{"role notin (application,storage)", true},
+		{"role notin (compute)", false},
+		{"type=node,role=compute", true},
+		{"type=node,role=application", false},
+
+		// Set and unset fields
+		{"nid", true},
+		{"enabled", true},
+		{"locked", false},
+		{"!locked", true},
+		{"subrole", false},
+		{"!subrole", true},
+
+		// Absent and null fields
+		{"arch=x86", false},
+		{"arch!=x86", true},
+		{"arch in (x86)", false},
+		{"arch notin (x86)", true},
+		{"arch", false},
+		{"!arch", true},
+		{"flag=x", false},
+		{"flag!=x", true},
+		{"!flag", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			sel, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if got := sel.Matches(comp); got != tt.want {
+				t.Errorf("Matches() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
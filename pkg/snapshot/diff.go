@@ -0,0 +1,159 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Change types of a Change.
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// FieldChange is a change of a single field of a resource. Field is the path
+// of the field, with nested object members separated by "." and list indexes
+// in brackets (e.g. "members.ids[2]"). Old or New is empty if the field was
+// added or removed. Values are formatted as JSON.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// Change is a change of a single resource. Fields is only set for modified
+// resources.
+type Change struct {
+	Kind   string        `json:"kind"`
+	ID     string        `json:"id"`
+	Type   string        `json:"type"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// Report is the result of comparing two snapshots. From and To name the
+// snapshots. Kinds lists the kinds of resources that were compared.
+type Report struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Kinds   []string `json:"kinds"`
+	Changes []Change `json:"changes"`
+}
+
+// Empty reports whether r contains no changes.
+func (r Report) Empty() bool {
+	return len(r.Changes) == 0
+}
+
+// Count returns the number of changes of type typ in r.
+func (r Report) Count(typ string) int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Type == typ {
+			n++
+		}
+	}
+	return n
+}
+
+// Diff compares snapshot from with snapshot to and returns the resources that
+// were added, removed, or modified between them. Only kinds of resources that
+// are in both snapshots are compared. Changes are ordered by kind (in the order
+// of Kinds), then ID.
+func Diff(from, to *Snapshot) Report {
+	report := Report{From: from.Name, To: to.Name}
+	for _, kind := range Kinds {
+		old, okOld := from.Resources[kind]
+		cur, okCur := to.Resources[kind]
+		if !okOld || !okCur {
+			continue
+		}
+		report.Kinds = append(report.Kinds, kind)
+
+		ids := make([]string, 0, len(old)+len(cur))
+		for id := range old {
+			ids = append(ids, id)
+		}
+		for id := range cur {
+			if _, ok := old[id]; !ok {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			o, inOld := old[id]
+			c, inCur := cur[id]
+			switch {
+			case !inOld:
+				report.Changes = append(report.Changes, Change{Kind: kind, ID: id, Type: ChangeAdded})
+			case !inCur:
+				report.Changes = append(report.Changes, Change{Kind: kind, ID: id, Type: ChangeRemoved})
+			default:
+				if fields := diffFields(o, c); len(fields) > 0 {
+					report.Changes = append(report.Changes, Change{Kind: kind, ID: id, Type: ChangeModified, Fields: fields})
+				}
+			}
+		}
+	}
+	return report
+}
+
+// diffFields returns the fields that differ between old and cur, sorted by
+// field path.
+func diffFields(old, cur interface{}) []FieldChange {
+	oldFlat := make(map[string]string)
+	curFlat := make(map[string]string)
+	flatten("", old, oldFlat)
+	flatten("", cur, curFlat)
+
+	var changes []FieldChange
+	for field, o := range oldFlat {
+		if c, ok := curFlat[field]; !ok {
+			changes = append(changes, FieldChange{Field: field, Old: o})
+		} else if c != o {
+			changes = append(changes, FieldChange{Field: field, Old: o, New: c})
+		}
+	}
+	for field, c := range curFlat {
+		if _, ok := oldFlat[field]; !ok {
+			changes = append(changes, FieldChange{Field: field, New: c})
+		}
+	}
+	slices.SortFunc(changes, func(a, b FieldChange) int { return strings.Compare(a.Field, b.Field) })
+	return changes
+}
+
+// flatten adds the leaf values of v to flat, keyed by their path under prefix
+// and formatted as JSON. Empty objects and lists are leaves, so that they are
+// not lost.
+func flatten(prefix string, v interface{}, flat map[string]string) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) > 0 {
+			for k, val := range t {
+				p := k
+				if prefix != "" {
+					p = prefix + "." + k
+				}
+				flatten(p, val, flat)
+			}
+			return
+		}
+	case []interface{}:
+		if len(t) > 0 {
+			for i, val := range t {
+				flatten(fmt.Sprintf("%s[%d]", prefix, i), val, flat)
+			}
+			return
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		b = []byte(fmt.Sprint(v))
+	}
+	flat[prefix] = string(b)
+}
@@ -0,0 +1,259 @@
+// Package snapshot stores point-in-time copies of resources held by OpenCHAMI
+// services (e.g. SMD components or BSS boot parameters) and compares them,
+// giving basic change tracking for clusters without audit services.
+//
+// A snapshot holds, for each kind of resource, the resources keyed by their
+// ID. Resources are kept as the generic JSON values returned by the services
+// so that fields ochami does not know about are tracked as well.
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of resources that can be snapshotted.
+const (
+	KindComponents         = "components"
+	KindGroups             = "groups"
+	KindEthernetInterfaces = "ethernet-interfaces"
+	KindRedfishEndpoints   = "redfish-endpoints"
+	KindBootParams         = "boot-params"
+)
+
+// Kinds lists the kinds of resources that can be snapshotted, in the order they
+// are reported.
+var Kinds = []string{KindComponents, KindGroups, KindEthernetInterfaces, KindRedfishEndpoints, KindBootParams}
+
+// Version is the version of the snapshot file format written by Save.
+const Version = 1
+
+// fileExt is the extension of snapshot files.
+const fileExt = ".json"
+
+// Snapshot is a copy of resources at a point in time. Resources maps each kind
+// to the resources of that kind, keyed by ID.
+type Snapshot struct {
+	Version   int                               `json:"version"`
+	Name      string                            `json:"name"`
+	Created   time.Time                         `json:"created"`
+	Cluster   string                            `json:"cluster"`
+	BaseURI   string                            `json:"base_uri"`
+	Resources map[string]map[string]interface{} `json:"resources"`
+}
+
+// New returns an empty snapshot of cluster, reachable at baseURI, created now.
+func New(cluster, baseURI string) *Snapshot {
+	return &Snapshot{
+		Version:   Version,
+		Created:   time.Now().UTC(),
+		Cluster:   cluster,
+		BaseURI:   baseURI,
+		Resources: make(map[string]map[string]interface{}),
+	}
+}
+
+// Kinds returns the kinds of resources in s, in the order of Kinds.
+func (s *Snapshot) Kinds() []string {
+	var kinds []string
+	for _, k := range Kinds {
+		if _, ok := s.Resources[k]; ok {
+			kinds = append(kinds, k)
+		}
+	}
+	return kinds
+}
+
+// AddResponse adds the resources of kind in body, the response body of the
+// service's endpoint that lists them (e.g. SMD's /State/Components), to s,
+// replacing any resources of that kind already in s.
+func (s *Snapshot) AddResponse(kind string, body []byte) error {
+	var (
+		items []map[string]interface{}
+		err   error
+	)
+	switch kind {
+	case KindComponents:
+		var wrapper struct {
+			Components []map[string]interface{} `json:"Components"`
+		}
+		err = json.Unmarshal(body, &wrapper)
+		items = wrapper.Components
+	case KindRedfishEndpoints:
+		var wrapper struct {
+			RedfishEndpoints []map[string]interface{} `json:"RedfishEndpoints"`
+		}
+		err = json.Unmarshal(body, &wrapper)
+		items = wrapper.RedfishEndpoints
+	case KindGroups, KindEthernetInterfaces, KindBootParams:
+		err = json.Unmarshal(body, &items)
+	default:
+		return fmt.Errorf("unknown resource kind %q", kind)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", kind, err)
+	}
+
+	resources := make(map[string]interface{}, len(items))
+	for i, item := range items {
+		id := resourceID(kind, item)
+		if id == "" {
+			id = fmt.Sprintf("#%d", i)
+		}
+		resources[id] = item
+	}
+	s.Resources[kind] = resources
+	return nil
+}
+
+// resourceID returns the ID of item, a resource of kind. Boot parameters have
+// no ID of their own, so they are identified by the hosts, MAC addresses, or
+// NIDs they apply to, in that order of preference.
+func resourceID(kind string, item map[string]interface{}) string {
+	switch kind {
+	case KindGroups:
+		s, _ := item["label"].(string)
+		return s
+	case KindBootParams:
+		for _, key := range []string{"hosts", "macs", "nids"} {
+			list, _ := item[key].([]interface{})
+			if len(list) == 0 {
+				continue
+			}
+			strs := make([]string, len(list))
+			for i, v := range list {
+				strs[i] = fmt.Sprint(v)
+			}
+			slices.Sort(strs)
+			return key + "=" + strings.Join(strs, ",")
+		}
+		return ""
+	default:
+		s, _ := item["ID"].(string)
+		return s
+	}
+}
+
+// Dir returns the directory snapshots are stored in by default:
+// $XDG_DATA_HOME/ochami/snapshots, or ~/.local/share/ochami/snapshots if
+// XDG_DATA_HOME is not set.
+func Dir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "ochami", "snapshots"), nil
+}
+
+// Save writes s to a file in dir, creating dir if needed, and returns its path.
+// The file is named after s.Name, which is set to the time s was created if
+// empty. An existing snapshot with the same name is not overwritten.
+func Save(dir string, s *Snapshot) (string, error) {
+	if s.Name == "" {
+		s.Name = s.Created.Format("20060102T150405Z")
+	}
+	if strings.ContainsAny(s.Name, `/\`) || strings.HasPrefix(s.Name, ".") {
+		return "", fmt.Errorf("invalid snapshot name %q", s.Name)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	path := filepath.Join(dir, s.Name+fileExt)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		if os.IsExist(err) {
+			return "", fmt.Errorf("snapshot %s already exists", s.Name)
+		}
+		return "", fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write snapshot file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write snapshot file: %w", err)
+	}
+	return path, nil
+}
+
+// Load reads the snapshot in the file at path.
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot %s: %w", path, err)
+	}
+	if s.Version > Version {
+		return nil, fmt.Errorf("snapshot %s has version %d, newer than the supported version %d", path, s.Version, Version)
+	}
+	if s.Resources == nil {
+		s.Resources = make(map[string]map[string]interface{})
+	}
+	return &s, nil
+}
+
+// List returns the snapshots in dir, oldest first. Files that are not valid
+// snapshots are skipped. If dir does not exist, no snapshots are returned.
+func List(dir string) ([]*Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+	}
+	var snaps []*Snapshot
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), fileExt) {
+			continue
+		}
+		s, err := Load(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		snaps = append(snaps, s)
+	}
+	sort.SliceStable(snaps, func(i, j int) bool { return snaps[i].Created.Before(snaps[j].Created) })
+	return snaps, nil
+}
+
+// Find returns the snapshot named name in dir. If name is "latest", the most
+// recently created snapshot is returned. If name is the path of a file, the
+// snapshot in it is returned instead.
+func Find(dir, name string) (*Snapshot, error) {
+	if strings.ContainsRune(name, filepath.Separator) || strings.HasSuffix(name, fileExt) {
+		return Load(name)
+	}
+	if name == "latest" {
+		snaps, err := List(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(snaps) == 0 {
+			return nil, fmt.Errorf("no snapshots in %s", dir)
+		}
+		return snaps[len(snaps)-1], nil
+	}
+	path := filepath.Join(dir, name+fileExt)
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("snapshot %s not found in %s", name, dir)
+	}
+	return Load(path)
+}
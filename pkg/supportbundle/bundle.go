@@ -0,0 +1,139 @@
+// Package supportbundle writes support bundles: gzipped tarballs of data about
+// a cluster and the ochami CLI that is useful when debugging problems with
+// them, e.g. when reporting them to the OpenCHAMI developers.
+package supportbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+// ManifestFile is the name of the file in a bundle describing its contents.
+const ManifestFile = "manifest.json"
+
+// Manifest describes the contents of a bundle.
+type Manifest struct {
+	Created    time.Time `json:"created"`
+	Cluster    string    `json:"cluster,omitempty"`
+	Anonymized bool      `json:"anonymized"`
+
+	// Files are the names of the files in the bundle, other than the
+	// manifest, in the order they were added.
+	Files []string `json:"files"`
+
+	// Errors are the problems encountered while collecting the data of
+	// the bundle, e.g. services that could not be reached. The data that
+	// could not be collected is missing from the bundle.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// file is a file in a bundle.
+type file struct {
+	name string
+	data []byte
+}
+
+// Bundle is a support bundle being collected.
+type Bundle struct {
+	Manifest
+	files []file
+}
+
+// New returns an empty bundle of data about cluster, which may be empty if
+// no cluster was configured, created now.
+func New(cluster string, anonymized bool) *Bundle {
+	return &Bundle{Manifest: Manifest{
+		Created:    time.Now().UTC().Truncate(time.Second),
+		Cluster:    cluster,
+		Anonymized: anonymized,
+		Files:      []string{},
+	}}
+}
+
+// Name returns the name of b, which is also the name of the directory the
+// files in it are written to, e.g. ochami-support-foobar-20240102T150405Z.
+func (b *Bundle) Name() string {
+	name := "ochami-support"
+	if b.Cluster != "" {
+		name += "-" + b.Cluster
+	}
+	return name + "-" + b.Created.Format("20060102T150405Z")
+}
+
+// Add adds the file name, which may be in a subdirectory (e.g.
+// smd/components.json), with contents data to b.
+func (b *Bundle) Add(name string, data []byte) {
+	b.files = append(b.files, file{name: name, data: data})
+	b.Files = append(b.Files, name)
+}
+
+// AddError records that a problem occurred while collecting the data of b.
+func (b *Bundle) AddError(format string, a ...interface{}) {
+	b.Errors = append(b.Errors, fmt.Sprintf(format, a...))
+}
+
+// Write writes b to w as a gzipped tarball, with its files and manifest in a
+// directory named after b.
+func (b *Bundle) Write(w io.Writer) error {
+	manifest, err := json.MarshalIndent(b.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle manifest: %w", err)
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	dirs := map[string]bool{}
+	for _, f := range append([]file{{name: ManifestFile, data: manifest}}, b.files...) {
+		name := path.Join(b.Name(), f.name)
+		if err := writeDirs(tw, dirs, path.Dir(name), b.Created); err != nil {
+			return err
+		}
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(f.data)),
+			ModTime:  b.Created,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("failed to write header of %s to bundle: %w", f.name, err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return fmt.Errorf("failed to write %s to bundle: %w", f.name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish bundle tarball: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		return fmt.Errorf("failed to finish bundle compression: %w", err)
+	}
+	return nil
+}
+
+// writeDirs writes the headers of dir and its parents to tw, parents first,
+// unless they are in written, to which they are added.
+func writeDirs(tw *tar.Writer, written map[string]bool, dir string, modTime time.Time) error {
+	if dir == "." || written[dir] {
+		return nil
+	}
+	if err := writeDirs(tw, written, path.Dir(dir), modTime); err != nil {
+		return err
+	}
+	written[dir] = true
+	hdr := &tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     dir + "/",
+		Mode:     0o755,
+		ModTime:  modTime,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write header of directory %s to bundle: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+// Package timeparse parses the points in time accepted by time-window flags
+// such as --since and --until, so that commands filtering history-style
+// endpoints accept the same forms.
+//
+// The following forms are accepted:
+//
+//   - An RFC 3339 timestamp (e.g. 2024-05-01T12:00:00Z), or one without a
+//     time zone, which is taken to be local time (e.g. 2024-05-01T12:00:00 or
+//     "2024-05-01 12:00").
+//   - A date (e.g. 2024-05-01), meaning midnight local time.
+//   - A duration relative to now, with an optional sign (e.g. -24h, -90m,
+//     +1h). Besides the units of time.ParseDuration, d (days) and w (weeks)
+//     are accepted (e.g. -7d, -1w12h). Durations without a sign are taken to
+//     be in the past, so 24h is the same as -24h.
+//   - now, today (midnight local time), or yesterday (midnight local time
+//     the day before).
+//   - A Unix timestamp in seconds prefixed with @ (e.g. @1714564800).
+//
+// Durations on their own (e.g. timeouts) are parsed with ParseDuration, which
+// accepts the same units as well as ISO 8601 durations.
+package timeparse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// layouts are the absolute time layouts accepted by Parse, tried in order.
+// Those without a time zone are parsed in local time.
+var layouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// durationRE matches a relative duration, capturing its sign and units.
+var durationRE = regexp.MustCompile(`^([+-]?)((?:\d+(?:\.\d+)?(?:ns|us|µs|ms|s|m|h|d|w))+)$`)
+
+// unitRE matches one number and unit of a relative duration.
+var unitRE = regexp.MustCompile(`(\d+(?:\.\d+)?)(ns|us|µs|ms|s|m|h|d|w)`)
+
+// isoDurationRE matches an ISO 8601 duration without years or months, which
+// have no fixed length, capturing its weeks, days, hours, minutes, and
+// seconds.
+var isoDurationRE = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)W)?(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// Parse returns the point in time described by s (see the package
+// documentation for the accepted forms). Relative forms are relative to now.
+func Parse(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "":
+		return time.Time{}, fmt.Errorf("empty time")
+	case "now":
+		return now, nil
+	case "today":
+		return midnight(now), nil
+	case "yesterday":
+		return midnight(now).AddDate(0, 0, -1), nil
+	}
+
+	if strings.HasPrefix(s, "@") {
+		sec, err := strconv.ParseInt(s[1:], 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid Unix timestamp %q", s)
+		}
+		return time.Unix(sec, 0), nil
+	}
+
+	if m := durationRE.FindStringSubmatch(s); m != nil {
+		d, err := parseDuration(m[2])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		if m[1] == "+" {
+			return now.Add(d), nil
+		}
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: expected RFC 3339 timestamp, date, duration (e.g. -24h), now, today, yesterday, or @<unix_seconds>", s)
+}
+
+// ParseDuration parses s, a non-negative duration, which is either a sequence
+// of numbers and units (e.g. 90s, 5m, or 2h30m), accepting the d (days) and w
+// (weeks) units in addition to those of time.ParseDuration, or an ISO 8601
+// duration without years or months (e.g. PT5M or P1DT12H). 0 is accepted as
+// well.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "0" {
+		return 0, nil
+	}
+	if m := durationRE.FindStringSubmatch(s); m != nil && m[1] == "" {
+		return parseDuration(s)
+	}
+	if m := isoDurationRE.FindStringSubmatch(strings.ToUpper(s)); m != nil && s != "P" && !strings.HasSuffix(strings.ToUpper(s), "T") {
+		var total time.Duration
+		for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+			if m[i+1] == "" {
+				continue
+			}
+			n, err := strconv.ParseFloat(m[i+1], 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+			}
+			total += time.Duration(n * float64(unit))
+		}
+		return total, nil
+	}
+	return 0, fmt.Errorf("invalid duration %q: expected e.g. 30s, 5m, 2h30m, 1d, or PT5M", s)
+}
+
+// parseDuration parses s, a duration without a sign, accepting the d (24h)
+// and w (7d) units in addition to those of time.ParseDuration.
+func parseDuration(s string) (time.Duration, error) {
+	var total time.Duration
+	for _, m := range unitRE.FindAllStringSubmatch(s, -1) {
+		var unit time.Duration
+		switch m[2] {
+		case "d":
+			unit = 24 * time.Hour
+		case "w":
+			unit = 7 * 24 * time.Hour
+		default:
+			d, err := time.ParseDuration(m[0])
+			if err != nil {
+				return 0, err
+			}
+			total += d
+			continue
+		}
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, err
+		}
+		total += time.Duration(n * float64(unit))
+	}
+	return total, nil
+}
+
+// midnight returns the start of the day of t, in t's location.
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// Window is a time window. A zero Since or Until leaves the window open on that
+// side.
+type Window struct {
+	Since time.Time
+	Until time.Time
+}
+
+// ParseWindow parses since and until with Parse, relative to now, and returns
+// the window between them. Either may be empty to leave the window open on
+// that side. An error is returned if since is after until.
+func ParseWindow(since, until string, now time.Time) (Window, error) {
+	var (
+		w   Window
+		err error
+	)
+	if since != "" {
+		if w.Since, err = Parse(since, now); err != nil {
+			return w, fmt.Errorf("invalid start of time window: %w", err)
+		}
+	}
+	if until != "" {
+		if w.Until, err = Parse(until, now); err != nil {
+			return w, fmt.Errorf("invalid end of time window: %w", err)
+		}
+	}
+	return NewWindow(w.Since, w.Until)
+}
+
+// NewWindow returns the window between since and until, either of which may be
+// zero to leave the window open on that side. An error is returned if since is
+// after until.
+func NewWindow(since, until time.Time) (Window, error) {
+	w := Window{Since: since, Until: until}
+	if !w.Since.IsZero() && !w.Until.IsZero() && w.Since.After(w.Until) {
+		return w, fmt.Errorf("start of time window (%s) is after its end (%s)", w.Since.Format(time.RFC3339), w.Until.Format(time.RFC3339))
+	}
+	return w, nil
+}
+
+// IsZero reports whether w is open on both sides, i.e. it contains all times.
+func (w Window) IsZero() bool {
+	return w.Since.IsZero() && w.Until.IsZero()
+}
+
+// Contains reports whether t is within w. Both ends of w are inclusive.
+func (w Window) Contains(t time.Time) bool {
+	if !w.Since.IsZero() && t.Before(w.Since) {
+		return false
+	}
+	if !w.Until.IsZero() && t.After(w.Until) {
+		return false
+	}
+	return true
+}
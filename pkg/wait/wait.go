@@ -0,0 +1,290 @@
+// Package wait evaluates conditions on the state of OpenCHAMI services, such as
+// a component being Ready in SMD, so that scripts can wait for them to hold.
+//
+// A condition is written as:
+//
+//	<service>:<kind> <target> <field>=<value>... [<threshold>]
+//
+// The following kinds are supported:
+//
+//   - smd:component <xname> <field>=<value>...: the component with ID xname
+//     has all of the fields. A component that does not exist does not match.
+//   - smd:group <label> <field>=<value>... [<n>%|<n>]: at least the threshold
+//     of the members of the group have all of the fields, either as a
+//     percentage of the members or as a number of them. The default threshold
+//     is 100%.
+//
+// Fields are the fields of SMD components (e.g. state, flag, enabled, or role),
+// compared regardless of case. field!=value matches components whose field
+// differs from value instead.
+package wait
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/OpenCHAMI/ochami/pkg/client"
+	"github.com/OpenCHAMI/ochami/pkg/client/smd"
+)
+
+// Kinds of conditions, in the form in which they are written.
+const (
+	KindSMDComponent = "smd:component"
+	KindSMDGroup     = "smd:group"
+)
+
+// Match is a <field>=<value> (or <field>!=<value>) test of a condition.
+type Match struct {
+	Field  string
+	Value  string
+	Negate bool
+}
+
+// String returns m in the form in which it is written.
+func (m Match) String() string {
+	if m.Negate {
+		return m.Field + "!=" + m.Value
+	}
+	return m.Field + "=" + m.Value
+}
+
+// Condition is a parsed condition (see the package documentation).
+type Condition struct {
+	Kind    string
+	Target  string
+	Matches []Match
+
+	// Percent is the percentage of group members that must match, used if
+	// Count is 0.
+	Percent float64
+
+	// Count is the number of group members that must match, if not 0.
+	Count int
+
+	spec string
+}
+
+// String returns the condition as it was written.
+func (c Condition) String() string {
+	return c.spec
+}
+
+// Parse parses spec, a condition (see the package documentation).
+func Parse(spec string) (Condition, error) {
+	c := Condition{spec: spec, Percent: 100}
+	fields := strings.Fields(spec)
+	if len(fields) < 3 {
+		return c, fmt.Errorf("invalid condition %q: expected <service>:<kind> <target> <field>=<value>...", spec)
+	}
+	c.Kind, c.Target = strings.ToLower(fields[0]), fields[1]
+	if c.Kind != KindSMDComponent && c.Kind != KindSMDGroup {
+		return c, fmt.Errorf("invalid condition %q: unknown kind %q (must be %s or %s)", spec, fields[0], KindSMDComponent, KindSMDGroup)
+	}
+	for i, f := range fields[2:] {
+		if k, v, ok := strings.Cut(f, "!="); ok && k != "" {
+			c.Matches = append(c.Matches, Match{Field: k, Value: v, Negate: true})
+			continue
+		}
+		if k, v, ok := strings.Cut(f, "="); ok && k != "" {
+			c.Matches = append(c.Matches, Match{Field: k, Value: v})
+			continue
+		}
+		// Only the last word of a group condition can be a threshold
+		if c.Kind != KindSMDGroup || i != len(fields)-3 {
+			return c, fmt.Errorf("invalid condition %q: %q is not <field>=<value>", spec, f)
+		}
+		if p, ok := strings.CutSuffix(f, "%"); ok {
+			n, err := strconv.ParseFloat(p, 64)
+			if err != nil || n <= 0 || n > 100 {
+				return c, fmt.Errorf("invalid condition %q: threshold %q must be a percentage between 0 and 100", spec, f)
+			}
+			c.Percent = n
+			continue
+		}
+		n, err := strconv.Atoi(f)
+		if err != nil || n <= 0 {
+			return c, fmt.Errorf("invalid condition %q: threshold %q must be a percentage (e.g. 90%%) or a positive number of members", spec, f)
+		}
+		c.Count = n
+	}
+	if len(c.Matches) == 0 {
+		return c, fmt.Errorf("invalid condition %q: no <field>=<value> to wait for", spec)
+	}
+	return c, nil
+}
+
+// Status is the result of checking a condition once.
+type Status struct {
+	// Met is true if the condition holds.
+	Met bool
+
+	// Matching and Total are the number of components that match and that
+	// were checked.
+	Matching int
+	Total    int
+
+	// Needed is the number of matching components needed for the condition
+	// to hold.
+	Needed int
+}
+
+// String summarizes s for progress messages, e.g. "12/16 matching (15
+// needed)".
+func (s Status) String() string {
+	return fmt.Sprintf("%d/%d matching (%d needed)", s.Matching, s.Total, s.Needed)
+}
+
+// Checker checks conditions against the services. Token, if not empty, is sent
+// as the authorization bearer.
+type Checker struct {
+	SMD   *smd.SMDClient
+	Token string
+}
+
+// Check checks c once. An error is returned if the state could not be
+// fetched.
+func (ch Checker) Check(ctx context.Context, c Condition) (Status, error) {
+	var (
+		comps []map[string]any
+		err   error
+	)
+	switch c.Kind {
+	case KindSMDComponent:
+		comps, err = ch.component(ctx, c.Target)
+	case KindSMDGroup:
+		comps, err = ch.groupComponents(ctx, c.Target)
+	default:
+		return Status{}, fmt.Errorf("unknown kind %q", c.Kind)
+	}
+	if err != nil {
+		return Status{}, err
+	}
+
+	s := Status{Total: len(comps), Needed: 1}
+	if c.Kind == KindSMDGroup {
+		if c.Count > 0 {
+			s.Needed = c.Count
+		} else {
+			s.Needed = int(math.Ceil(c.Percent / 100 * float64(len(comps))))
+		}
+	}
+	for _, comp := range comps {
+		if matches(comp, c.Matches) {
+			s.Matching++
+		}
+	}
+	// An empty group (or a missing component) never satisfies a condition,
+	// since it may just not have been populated yet
+	s.Met = s.Total > 0 && s.Matching >= s.Needed
+	return s, nil
+}
+
+// ErrUnauthorized is wrapped by errors checking a condition if the service
+// rejected the token, which waiting longer does not fix.
+var ErrUnauthorized = errors.New("token rejected")
+
+// get GETs endpoint of SMD with query and unmarshals the response body into v.
+// found is false, without an error, if the endpoint does not exist.
+func (ch Checker) get(ctx context.Context, endpoint, query string, v any) (found bool, err error) {
+	headers := client.NewHTTPHeaders()
+	if ch.Token != "" {
+		if err := headers.SetAuthorization(ch.Token); err != nil {
+			return false, fmt.Errorf("error setting token in HTTP headers: %w", err)
+		}
+	}
+	henv, err := ch.SMD.GetDataContext(ctx, endpoint, query, headers)
+	if errors.Is(err, client.UnsuccessfulHTTPError) {
+		switch henv.StatusCode {
+		case http.StatusNotFound:
+			return false, nil
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return false, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(henv.Body, v)
+}
+
+// component returns the component xname as a generic JSON object, or no
+// components if it does not exist.
+func (ch Checker) component(ctx context.Context, xname string) ([]map[string]any, error) {
+	ep, err := url.JoinPath(smd.SMDRelpathComponents, xname)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xname %q: %w", xname, err)
+	}
+	var comp map[string]any
+	if found, err := ch.get(ctx, ep, "", &comp); err != nil {
+		return nil, fmt.Errorf("failed to get component %s: %w", xname, err)
+	} else if !found {
+		return nil, nil
+	}
+	return []map[string]any{comp}, nil
+}
+
+// groupComponents returns the components that are members of group as generic
+// JSON objects. Members that are not components are returned as empty
+// objects, so that they never match but count towards the total.
+func (ch Checker) groupComponents(ctx context.Context, group string) ([]map[string]any, error) {
+	ep, err := url.JoinPath(smd.SMDRelpathGroups, group, "members")
+	if err != nil {
+		return nil, fmt.Errorf("invalid group label %q: %w", group, err)
+	}
+	var members smd.GroupMembers
+	if found, err := ch.get(ctx, ep, "", &members); err != nil {
+		return nil, fmt.Errorf("failed to get members of group %s: %w", group, err)
+	} else if !found {
+		return nil, fmt.Errorf("group %s does not exist", group)
+	}
+	if len(members.IDs) == 0 {
+		return nil, nil
+	}
+	var comps struct {
+		Components []map[string]any `json:"Components"`
+	}
+	if _, err := ch.get(ctx, smd.SMDRelpathComponents, url.Values{"group": {group}}.Encode(), &comps); err != nil {
+		return nil, fmt.Errorf("failed to get components of group %s: %w", group, err)
+	}
+	byID := make(map[string]map[string]any, len(comps.Components))
+	for _, comp := range comps.Components {
+		if id, ok := comp["ID"].(string); ok {
+			byID[strings.ToLower(id)] = comp
+		}
+	}
+	objs := make([]map[string]any, 0, len(members.IDs))
+	for _, m := range members.IDs {
+		comp, ok := byID[strings.ToLower(m)]
+		if !ok {
+			comp = map[string]any{}
+		}
+		objs = append(objs, comp)
+	}
+	return objs, nil
+}
+
+// matches reports whether comp, a component as a generic JSON object, passes
+// all of ms. Field names and values are compared regardless of case; a field
+// that comp does not have is empty.
+func matches(comp map[string]any, ms []Match) bool {
+	for _, m := range ms {
+		var value string
+		for k, v := range comp {
+			if strings.EqualFold(k, m.Field) {
+				value = fmt.Sprint(v)
+				break
+			}
+		}
+		if strings.EqualFold(value, m.Value) == m.Negate {
+			return false
+		}
+	}
+	return true
+}
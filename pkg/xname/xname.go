@@ -2,6 +2,7 @@ package xname
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/openchami/schemas/schemas/csm"
 )
@@ -40,3 +41,54 @@ func NodeXnameToBMCXname(xname string) (string, error) {
 	}
 	return bmcXnameStr, nil
 }
+
+// Compare compares the xnames a and b in natural order, i.e. runs of digits
+// are compared by their numeric value, so that e.g. x1000c1s2b0n0 sorts before
+// x1000c1s10b0n0. Letters are compared regardless of case. It returns -1 if a
+// sorts before b, 1 if after, and 0 if they are equal.
+func Compare(a, b string) int {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	for a != "" && b != "" {
+		aNum, bNum := isDigit(a[0]), isDigit(b[0])
+		if aNum && bNum {
+			aRun, bRun := digitRun(a), digitRun(b)
+			aVal, bVal := strings.TrimLeft(aRun, "0"), strings.TrimLeft(bRun, "0")
+			if len(aVal) != len(bVal) {
+				return cmpInt(len(aVal), len(bVal))
+			}
+			if aVal != bVal {
+				return strings.Compare(aVal, bVal)
+			}
+			a, b = a[len(aRun):], b[len(bRun):]
+			continue
+		}
+		if a[0] != b[0] {
+			return cmpInt(int(a[0]), int(b[0]))
+		}
+		a, b = a[1:], b[1:]
+	}
+	return cmpInt(len(a), len(b))
+}
+
+// digitRun returns the leading digits of s.
+func digitRun(s string) string {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}